@@ -20,10 +20,12 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"net/http"
 	"os"
 	"syscall"
 
 	"github.com/oklog/run"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/klog/v2"
@@ -83,6 +85,24 @@ func mainInternal(c string) error {
 	g.Add(run.SignalHandler(ctx, os.Interrupt, syscall.SIGTERM))
 	// Controller
 	g.Add(controller.Start, controller.Stop)
+	// Metrics endpoint
+	if len(cfg.MetricsAddress) != 0 {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", promhttp.Handler())
+		metricsServer := &http.Server{Addr: cfg.MetricsAddress, Handler: mux}
+		g.Add(
+			func() error {
+				klog.Infof("Serving metrics on %s", cfg.MetricsAddress)
+				if err := metricsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+					return err
+				}
+				return nil
+			},
+			func(error) {
+				_ = metricsServer.Shutdown(context.Background())
+			},
+		)
+	}
 
 	return g.Run()
 }
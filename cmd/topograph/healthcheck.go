@@ -0,0 +1,80 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/NVIDIA/topograph/pkg/config"
+)
+
+// runHealthcheck probes the /healthz endpoint of the locally running server
+func runHealthcheck(ctx context.Context, c string, timeout time.Duration) error {
+	cfg, err := config.NewFromFile(c)
+	if err != nil {
+		return err
+	}
+
+	scheme := "http"
+	if cfg.HTTP.SSL {
+		scheme = "https"
+	}
+
+	// with mTLS, the server presents its own certificate as the client certificate
+	var certs []tls.Certificate
+	if cfg.HTTP.MTLS {
+		cert, err := tls.LoadX509KeyPair(cfg.SSL.Cert, cfg.SSL.Key)
+		if err != nil {
+			return fmt.Errorf("failed to load client certificate: %v", err)
+		}
+		certs = append(certs, cert)
+	}
+
+	return healthcheck(ctx, fmt.Sprintf("%s://localhost:%d/healthz", scheme, cfg.HTTP.Port), timeout, certs...)
+}
+
+func healthcheck(ctx context.Context, url string, timeout time.Duration, certs ...tls.Certificate) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	// the server is probed over loopback, so its certificate is not verified
+	client := &http.Client{
+		Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true, Certificates: certs}}, //nolint:gosec
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("healthcheck failed: %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("healthcheck failed: HTTP status %d", resp.StatusCode)
+	}
+
+	return nil
+}
@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"os"
 	"syscall"
+	"time"
 
 	"github.com/oklog/run"
 	"k8s.io/klog/v2"
@@ -34,9 +35,20 @@ var GitTag string
 
 func main() {
 	var cfg string
-	var version bool
+	var version, offline, health, schema bool
+	var healthTimeout time.Duration
+	var params offlineParams
 	flag.StringVar(&cfg, "c", "/etc/topograph/topograph-config.yaml", "config file")
 	flag.BoolVar(&version, "version", false, "show the version")
+	flag.BoolVar(&schema, "print-schema", false, "print the JSON schema of the provider and engine parameters and exit")
+	flag.BoolVar(&health, "healthcheck", false, "probe the /healthz endpoint of the running server and exit")
+	flag.DurationVar(&healthTimeout, "healthcheck-timeout", 5*time.Second, "timeout for the healthcheck probe")
+	flag.BoolVar(&offline, "offline", false, "generate the topology config from ibnetdiscover output without running the server")
+	flag.StringVar(&params.ibFile, "ib-file", "", "ibnetdiscover output file (offline mode)")
+	flag.StringVar(&params.nodes, "nodes", "", "node list, e.g. dgx[001-128] (offline mode)")
+	flag.StringVar(&params.plugin, "plugin", "", "topology plugin: topology/tree (default) or topology/block (offline mode)")
+	flag.StringVar(&params.blockSizes, "block-sizes", "", "block sizes for topology/block plugin (offline mode)")
+	flag.StringVar(&params.output, "o", "", "output file; stdout if omitted (offline mode)")
 
 	klog.InitFlags(nil)
 	flag.Parse()
@@ -47,6 +59,30 @@ func main() {
 		os.Exit(0)
 	}
 
+	if schema {
+		if err := printSchema(os.Stdout); err != nil {
+			klog.Error(err.Error())
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	if health {
+		if err := runHealthcheck(context.Background(), cfg, healthTimeout); err != nil {
+			klog.Error(err.Error())
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	if offline {
+		if err := runOffline(context.Background(), &params); err != nil {
+			klog.Error(err.Error())
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
 	if err := mainInternal(cfg); err != nil {
 		klog.Error(err.Error())
 		os.Exit(1)
@@ -73,6 +109,13 @@ func mainInternal(c string) error {
 	g.Add(run.SignalHandler(ctx, os.Interrupt, syscall.SIGTERM))
 	// HTTP endpoint
 	g.Add(server.GetRunGroup())
+	// gRPC endpoint
+	if cfg.GRPC != nil {
+		if err = server.InitGRPCServer(cfg); err != nil {
+			return err
+		}
+		g.Add(server.GetGRPCRunGroup())
+	}
 
 	return g.Run()
 }
@@ -0,0 +1,142 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/NVIDIA/topograph/internal/cluset"
+	"github.com/NVIDIA/topograph/internal/files"
+	"github.com/NVIDIA/topograph/pkg/engines/slurm"
+	"github.com/NVIDIA/topograph/pkg/ib"
+	"github.com/NVIDIA/topograph/pkg/topology"
+)
+
+// offlineParams are the parameters of the one-shot topology generation from ibnetdiscover output
+type offlineParams struct {
+	ibFile     string
+	nodes      string
+	plugin     string
+	blockSizes string
+	output     string
+}
+
+func runOffline(ctx context.Context, p *offlineParams) error {
+	if len(p.ibFile) == 0 {
+		return fmt.Errorf("missing -ib-file in offline mode")
+	}
+	if len(p.nodes) == 0 {
+		return fmt.Errorf("missing -nodes in offline mode")
+	}
+
+	data, err := files.ReadFile(p.ibFile)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %v", p.ibFile, err)
+	}
+
+	cfg, err := generateOffline(ctx, data, p.nodes, p.plugin, p.blockSizes)
+	if err != nil {
+		return err
+	}
+
+	if len(p.output) == 0 {
+		_, err = os.Stdout.Write(cfg)
+		return err
+	}
+	return files.Create(p.output, cfg)
+}
+
+// generateOffline returns the Slurm topology config of the nodes in the ibnetdiscover output
+func generateOffline(ctx context.Context, data []byte, nodeList, plugin, blockSizes string) ([]byte, error) {
+	nodes, err := cluset.Expand(nodeList)
+	if err != nil {
+		return nil, fmt.Errorf("invalid node list %q: %v", nodeList, err)
+	}
+
+	ibRoot, err := ib.GenerateTopologyConfig(data, false, false)
+	if err != nil {
+		return nil, err
+	}
+
+	present := make(map[string]bool)
+	collectNodes(ibRoot, present)
+	requested := make(map[string]bool, len(nodes))
+	var missing []string
+	for _, node := range nodes {
+		requested[node] = true
+		if !present[node] {
+			missing = append(missing, node)
+		}
+	}
+	if len(missing) != 0 {
+		sort.Strings(missing)
+		return nil, fmt.Errorf("%d nodes are not present in the IB fabric: %s", len(missing), strings.Join(missing, ","))
+	}
+	pruneNodes(ibRoot, requested)
+
+	root := &topology.Vertex{
+		Vertices: map[string]*topology.Vertex{topology.TopologyTree: ibRoot},
+	}
+	if plugin == topology.TopologyBlock {
+		// in the absence of accelerator domains, the leaf switches form the blocks
+		blockRoot := &topology.Vertex{Vertices: make(map[string]*topology.Vertex)}
+		addLeafBlocks(ibRoot, blockRoot)
+		root.Vertices[topology.TopologyBlock] = blockRoot
+	}
+
+	return slurm.GenerateOutputParams(ctx, root, &slurm.Params{Plugin: plugin, BlockSizes: blockSizes})
+}
+
+// collectNodes adds the names of the compute nodes under the switch
+func collectNodes(v *topology.Vertex, nodes map[string]bool) {
+	for _, w := range v.Vertices {
+		if len(w.Vertices) == 0 {
+			nodes[w.Name] = true
+		} else {
+			collectNodes(w, nodes)
+		}
+	}
+}
+
+// pruneNodes removes the nodes not requested, and the switches left without nodes.
+// It returns false if the switch has no nodes left.
+func pruneNodes(v *topology.Vertex, requested map[string]bool) bool {
+	for key, w := range v.Vertices {
+		if len(w.Vertices) == 0 {
+			if !requested[w.Name] {
+				delete(v.Vertices, key)
+			}
+		} else if !pruneNodes(w, requested) {
+			delete(v.Vertices, key)
+		}
+	}
+	return len(v.Vertices) != 0
+}
+
+// addLeafBlocks adds a block for each leaf switch under the switch
+func addLeafBlocks(v *topology.Vertex, blockRoot *topology.Vertex) {
+	for _, w := range v.Vertices {
+		if len(w.Vertices) == 0 {
+			blockRoot.Vertices[v.ID] = &topology.Vertex{ID: v.ID, Vertices: v.Vertices}
+			return
+		}
+		addLeafBlocks(w, blockRoot)
+	}
+}
@@ -0,0 +1,101 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testIbFile = "../../tests/output/ibnetdiscover/example.out"
+
+func TestGenerateOffline(t *testing.T) {
+	data, err := os.ReadFile(testIbFile)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name       string
+		nodes      string
+		plugin     string
+		blockSizes string
+		output     string
+		err        string
+	}{
+		{
+			name:  "Case 1: tree topology",
+			nodes: "node[101-104,201-203]",
+			output: `SwitchName=IB-ComputeSpine-01 Switches=IB-ComputeLeaf-[01-02]
+SwitchName=IB-ComputeLeaf-01 Nodes=node[101-104]
+SwitchName=IB-ComputeLeaf-02 Nodes=node[201-203]
+`,
+		},
+		{
+			name:  "Case 2: tree topology of a node subset",
+			nodes: "node[101-104]",
+			output: `SwitchName=IB-ComputeSpine-01 Switches=IB-ComputeLeaf-01
+SwitchName=IB-ComputeLeaf-01 Nodes=node[101-104]
+`,
+		},
+		{
+			name:       "Case 3: block topology",
+			nodes:      "node[101-104,201-203]",
+			plugin:     "topology/block",
+			blockSizes: "3",
+			output: `BlockName=IB-ComputeLeaf-01 Nodes=node[101-104]
+BlockName=IB-ComputeLeaf-02 Nodes=node[201-203]
+BlockSizes=3
+`,
+		},
+		{
+			name:  "Case 4: nodes missing in the fabric",
+			nodes: "node[103-106]",
+			err:   "2 nodes are not present in the IB fabric: node105,node106",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			output, err := generateOffline(context.TODO(), data, tc.nodes, tc.plugin, tc.blockSizes)
+			if len(tc.err) != 0 {
+				require.EqualError(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.output, string(output))
+		})
+	}
+}
+
+func TestRunOffline(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "topology.conf")
+	params := &offlineParams{
+		ibFile: testIbFile,
+		nodes:  "node[201-203]",
+		output: output,
+	}
+	require.NoError(t, runOffline(context.TODO(), params))
+
+	data, err := os.ReadFile(output)
+	require.NoError(t, err)
+	require.Equal(t, "SwitchName=IB-ComputeSpine-01 Switches=IB-ComputeLeaf-02\nSwitchName=IB-ComputeLeaf-02 Nodes=node[201-203]\n", string(data))
+
+	params.nodes = ""
+	require.EqualError(t, runOffline(context.TODO(), params), "missing -nodes in offline mode")
+}
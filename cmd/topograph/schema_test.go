@@ -0,0 +1,42 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/NVIDIA/topograph/internal/config"
+	"github.com/NVIDIA/topograph/pkg/registry"
+)
+
+func TestPrintSchema(t *testing.T) {
+	buf := &bytes.Buffer{}
+	require.NoError(t, printSchema(buf))
+
+	schema := &config.Schema{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), schema))
+	require.Equal(t, config.SchemaURI, schema.Schema)
+	for name := range registry.Providers {
+		require.Contains(t, schema.Properties["providers"].Properties, name)
+	}
+	for name := range registry.Engines {
+		require.Contains(t, schema.Properties["engines"].Properties, name)
+	}
+}
@@ -21,12 +21,13 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/oklog/run"
 	"k8s.io/klog/v2"
 
-	"github.com/NVIDIA/topograph/pkg/models"
 	"github.com/NVIDIA/topograph/pkg/toposim"
 )
 
@@ -40,8 +41,12 @@ func main() {
 func mainInternal() error {
 	var path string
 	var port int
+	var latency time.Duration
+	var errorRate float64
 	flag.StringVar(&path, "m", "", "topology model file")
 	flag.IntVar(&port, "p", 49025, "gRPC listening port")
+	flag.DurationVar(&latency, "latency", 0, "response latency; overrides the sim_latency directives of the model")
+	flag.Float64Var(&errorRate, "error-rate", 0, "percentage of failed calls (0 to 100); overrides the sim_error_rate directives of the model")
 
 	klog.InitFlags(nil)
 	flag.Parse()
@@ -51,7 +56,18 @@ func mainInternal() error {
 		return fmt.Errorf("must specify topology model path and listening port")
 	}
 
-	model, err := models.NewModelFromFile(path)
+	// only the explicitly set flags override the model directives
+	var opts []toposim.Option
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "latency":
+			opts = append(opts, toposim.WithLatency(latency))
+		case "error-rate":
+			opts = append(opts, toposim.WithErrorRate(errorRate))
+		}
+	})
+
+	server, err := toposim.NewServer(path, port, opts...)
 	if err != nil {
 		return err
 	}
@@ -59,13 +75,33 @@ func mainInternal() error {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	server := toposim.NewServer(model, port)
-
 	var g run.Group
 	// Signal handler
 	g.Add(run.SignalHandler(ctx, os.Interrupt, syscall.SIGTERM))
+	// Model reload on SIGHUP
+	g.Add(reloadHandler(ctx, server))
 	// gRPC endpoint
 	g.Add(server.Start, server.Stop)
 
 	return g.Run()
 }
+
+func reloadHandler(ctx context.Context, server *toposim.Server) (func() error, func(error)) {
+	ctx, cancel := context.WithCancel(ctx)
+	return func() error {
+			sig := make(chan os.Signal, 1)
+			signal.Notify(sig, syscall.SIGHUP)
+			defer signal.Stop(sig)
+			for {
+				select {
+				case <-sig:
+					// the error is logged; the current model keeps being served
+					_ = server.Reload("")
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+		}, func(error) {
+			cancel()
+		}
+}
@@ -0,0 +1,272 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package cluset expands and compacts Slurm hostlist expressions
+package cluset
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Expand returns the node names of a Slurm hostlist expression,
+// e.g. "node-[001-003,007],other" -> ["node-001", "node-002", "node-003", "node-007", "other"].
+// A name may hold several bracket groups followed by a suffix, e.g. "a[01-02]-p[1-2]-dgx",
+// in which case the last group varies fastest. The width of the numbers is preserved.
+func Expand(nodeList string) ([]string, error) {
+	nodeArr := []string{}
+	entries, err := splitEntries(nodeList)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		names, err := expandEntry(entry)
+		if err != nil {
+			return nil, err
+		}
+		nodeArr = append(nodeArr, names...)
+	}
+	return nodeArr, nil
+}
+
+// splitEntries splits the hostlist by the commas outside the brackets
+func splitEntries(nodeList string) ([]string, error) {
+	entries := []string{}
+	depth, start := 0, 0
+	for i, c := range nodeList {
+		switch c {
+		case '[':
+			if depth != 0 {
+				return nil, fmt.Errorf("nested brackets in %q", nodeList)
+			}
+			depth++
+		case ']':
+			if depth == 0 {
+				return nil, fmt.Errorf("unbalanced brackets in %q", nodeList)
+			}
+			depth--
+		case ',':
+			if depth == 0 {
+				if i > start {
+					entries = append(entries, nodeList[start:i])
+				}
+				start = i + 1
+			}
+		}
+	}
+	if depth != 0 {
+		return nil, fmt.Errorf("unbalanced brackets in %q", nodeList)
+	}
+	if len(nodeList) > start {
+		entries = append(entries, nodeList[start:])
+	}
+	return entries, nil
+}
+
+// expandEntry returns the node names of a single hostlist entry,
+// e.g. "a[01-02]-p[1-2]" -> ["a01-p1", "a01-p2", "a02-p1", "a02-p2"]
+func expandEntry(entry string) ([]string, error) {
+	names := []string{""}
+	for len(entry) != 0 {
+		open := strings.Index(entry, "[")
+		if open < 0 {
+			for i := range names {
+				names[i] += entry
+			}
+			break
+		}
+		// the brackets are balanced by splitEntries
+		end := open + strings.Index(entry[open:], "]")
+		fields, err := expandGroup(entry[open+1 : end])
+		if err != nil {
+			return nil, err
+		}
+
+		expanded := make([]string, 0, len(names)*len(fields))
+		for _, name := range names {
+			for _, field := range fields {
+				expanded = append(expanded, name+entry[:open]+field)
+			}
+		}
+		names = expanded
+		entry = entry[end+1:]
+	}
+	return names, nil
+}
+
+// expandGroup returns the numerical fields of a bracket group, e.g. "001-003,7" -> ["001", "002", "003", "7"].
+// The fields of a range are zero-padded to the width of its start.
+func expandGroup(group string) ([]string, error) {
+	fields := []string{}
+	for _, item := range strings.Split(group, ",") {
+		from, to, isRange := strings.Cut(item, "-")
+		if !isNumber(from) || (isRange && !isNumber(to)) {
+			return nil, fmt.Errorf("invalid range %q", item)
+		}
+		if !isRange {
+			fields = append(fields, from)
+			continue
+		}
+
+		start, err := strconv.Atoi(from)
+		if err != nil {
+			return nil, fmt.Errorf("invalid range start %q: %v", from, err)
+		}
+		end, err := strconv.Atoi(to)
+		if err != nil {
+			return nil, fmt.Errorf("invalid range end %q: %v", to, err)
+		}
+		if end < start {
+			return nil, fmt.Errorf("invalid range %q: end is less than start", item)
+		}
+		for i := start; i <= end; i++ {
+			fields = append(fields, fmt.Sprintf("%0*d", len(from), i))
+		}
+	}
+	return fields, nil
+}
+
+// Compact presents the contiguous numbers of the last numerical field of the names as ranges,
+// e.g. ["eos0507", "eos0509", "eos0508", "abc"] -> ["abc", "eos[0507-0509]"].
+// Names are merged only if they share the text around the field and its zero-padding width,
+// so that the ranges expand back to the same names. The padding zeros stay inside the brackets,
+// e.g. n1-1-[01-08] rather than n1-1-0[1-8], as in the Slurm hostlists: a range crossing a power of ten,
+// e.g. n[08-12], has no other form. The names without a numerical field come first,
+// followed by the ranges ordered by the text around the field.
+func Compact(names []string) []string {
+	ret := []string{}
+	groups := make(map[fieldKey]map[int]bool)
+	for _, name := range names {
+		key, num, ok := parseField(name)
+		if !ok {
+			ret = append(ret, name)
+			continue
+		}
+		if _, ok := groups[key]; !ok {
+			groups[key] = make(map[int]bool)
+		}
+		groups[key][num] = true
+	}
+
+	// an unpadded number of the width of a zero-padded field, e.g. "10" next to "08", joins the padded field
+	for key, nums := range groups {
+		if key.width == 0 {
+			continue
+		}
+		unpadded := fieldKey{prefix: key.prefix, suffix: key.suffix}
+		for num := range groups[unpadded] {
+			if len(strconv.Itoa(num)) == key.width {
+				nums[num] = true
+				delete(groups[unpadded], num)
+			}
+		}
+	}
+
+	keys := make([]fieldKey, 0, len(groups))
+	for key, nums := range groups {
+		if len(nums) != 0 {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].prefix != keys[j].prefix {
+			return keys[i].prefix < keys[j].prefix
+		}
+		if keys[i].suffix != keys[j].suffix {
+			return keys[i].suffix < keys[j].suffix
+		}
+		return keys[i].width < keys[j].width
+	})
+
+	for _, key := range keys {
+		nums := make([]int, 0, len(groups[key]))
+		for num := range groups[key] {
+			nums = append(nums, num)
+		}
+		sort.Ints(nums)
+
+		start := 0
+		for i := range nums {
+			if i+1 < len(nums) && nums[i+1] == nums[i]+1 {
+				continue
+			}
+			ret = append(ret, key.format(nums[start], nums[i]))
+			start = i + 1
+		}
+	}
+	return ret
+}
+
+// fieldKey identifies the names that differ only by the value of their last numerical field
+type fieldKey struct {
+	prefix, suffix string
+	// width is the width of a zero-padded field, or 0 for an unpadded one
+	width int
+}
+
+// format returns the name, or the range of names, of the numbers between start and end
+func (k fieldKey) format(start, end int) string {
+	if start == end {
+		return fmt.Sprintf("%s%0*d%s", k.prefix, k.width, start, k.suffix)
+	}
+	return fmt.Sprintf("%s[%0*d-%0*d]%s", k.prefix, k.width, start, k.width, end, k.suffix)
+}
+
+// parseField returns the key and the value of the last numerical field of the name,
+// e.g. "dgx-01-c03-ib" -> ({"dgx-01-c", "-ib", 2}, 3)
+func parseField(name string) (fieldKey, int, bool) {
+	end := len(name)
+	for end > 0 && !isDigit(name[end-1]) {
+		end--
+	}
+	start := end
+	for start > 0 && isDigit(name[start-1]) {
+		start--
+	}
+	if start == end {
+		return fieldKey{}, 0, false
+	}
+
+	field := name[start:end]
+	num, err := strconv.Atoi(field)
+	if err != nil {
+		return fieldKey{}, 0, false
+	}
+	key := fieldKey{prefix: name[:start], suffix: name[end:]}
+	if len(field) > 1 && field[0] == '0' {
+		key.width = len(field)
+	}
+	return key, num, true
+}
+
+func isNumber(s string) bool {
+	if len(s) == 0 {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if !isDigit(s[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
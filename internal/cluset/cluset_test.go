@@ -0,0 +1,323 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluset
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testCase struct {
+	name     string
+	nodeList string
+	nodeArr  []string
+}
+
+func createTestCases() []testCase {
+
+	var testCases []testCase
+
+	// Case 0
+	var nodeArr0 []string
+	for i := 1; i <= 4; i++ {
+		nodeArr0 = append(nodeArr0, fmt.Sprintf("nodename-1-00%v", i))
+	}
+	nodeArr0 = append(nodeArr0, "nodename-1-007")
+	for i := 91; i <= 99; i++ {
+		nodeArr0 = append(nodeArr0, fmt.Sprintf("nodename-1-%v", i))
+	}
+	nodeArr0 = append(nodeArr0, "nodename-1-100")
+	nodeArr0 = append(nodeArr0, "nodename-2-89")
+	case0 := testCase{
+		name:     "Case0",
+		nodeList: "nodename-1-[001-004,007,91-99,100],nodename-2-89",
+		nodeArr:  nodeArr0,
+	}
+
+	// Case 1
+	var nodeArr1 []string
+	prefix1 := "nodename-1-"
+	for i := 1; i <= 4; i++ {
+		nodeArr1 = append(nodeArr1, fmt.Sprintf("%v00%v", prefix1, i))
+	}
+	nodeArr1 = append(nodeArr1, fmt.Sprintf("%v007", prefix1))
+	for i := 91; i <= 99; i++ {
+		nodeArr1 = append(nodeArr1, fmt.Sprintf("%v%v", prefix1, i))
+	}
+	nodeArr1 = append(nodeArr1, fmt.Sprintf("%v100", prefix1))
+	for i := 89; i <= 91; i++ {
+		nodeArr1 = append(nodeArr1, fmt.Sprintf("nodename-2-%v", i))
+	}
+	case1 := testCase{
+		name:     "Case1",
+		nodeList: "nodename-1-[001-004,007,91-99,100],nodename-2-[89-91]",
+		nodeArr:  nodeArr1,
+	}
+
+	// Case 2
+	var nodeArr2 []string
+	nodeArr2 = append(nodeArr2, "nodename-2-89")
+	for i := 1; i <= 4; i++ {
+		nodeArr2 = append(nodeArr2, fmt.Sprintf("nodename-1-00%v", i))
+	}
+	nodeArr2 = append(nodeArr2, "nodename-1-007")
+	for i := 91; i <= 99; i++ {
+		nodeArr2 = append(nodeArr2, fmt.Sprintf("nodename-1-%v", i))
+	}
+	nodeArr2 = append(nodeArr2, "nodename-1-100")
+	case2 := testCase{
+		name:     "Case2",
+		nodeList: "nodename-2-89,nodename-1-[001-004,007,91-99,100]",
+		nodeArr:  nodeArr2,
+	}
+
+	// Case 3
+	var nodeArr3 []string
+
+	for i := 1; i <= 4; i++ {
+		nodeArr3 = append(nodeArr3, fmt.Sprintf("alpha-1-00%v", i))
+	}
+	nodeArr3 = append(nodeArr3, "alpha-1-007")
+	for i := 91; i <= 99; i++ {
+		nodeArr3 = append(nodeArr3, fmt.Sprintf("alpha-1-%v", i))
+	}
+	nodeArr3 = append(nodeArr3, "alpha-1-100")
+	nodeArr3 = append(nodeArr3, "beta-2-89")
+
+	case3 := testCase{
+		name:     "Case3",
+		nodeList: "alpha-1-[001-004,007,91-99,100],beta-2-89",
+		nodeArr:  nodeArr3,
+	}
+	// Case 4
+	case4 := testCase{
+		name:     "Case4",
+		nodeList: "login[01-02],viz[1],node3",
+		nodeArr:  []string{"login01", "login02", "viz1", "node3"},
+	}
+	// Case 5
+	case5 := testCase{
+		name:    "Case5",
+		nodeArr: []string{},
+	}
+	testCases = append(testCases, case0, case1, case2, case3, case4, case5)
+	return testCases
+
+}
+
+func TestExpand(t *testing.T) {
+
+	testCases := createTestCases()
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			res, _ := Expand(tc.nodeList)
+			require.Equal(t, tc.nodeArr, res)
+
+		})
+	}
+
+}
+
+func TestExpandGroups(t *testing.T) {
+	testCases := []struct {
+		name     string
+		nodeList string
+		nodeArr  []string
+		err      string
+	}{
+		{
+			name:     "Case 1: zero padding across powers of ten",
+			nodeList: "fake[098-101]",
+			nodeArr:  []string{"fake098", "fake099", "fake100", "fake101"},
+		},
+		{
+			name:     "Case 2: unpadded range",
+			nodeList: "node[8-10]",
+			nodeArr:  []string{"node8", "node9", "node10"},
+		},
+		{
+			name:     "Case 3: suffix",
+			nodeList: "dgx-01-c[03-04]-ib,dgx-01-c[3-4]-ib",
+			nodeArr:  []string{"dgx-01-c03-ib", "dgx-01-c04-ib", "dgx-01-c3-ib", "dgx-01-c4-ib"},
+		},
+		{
+			name:     "Case 4: multiple bracket groups",
+			nodeList: "a[01-02]-p[1-2]-dgx",
+			nodeArr:  []string{"a01-p1-dgx", "a01-p2-dgx", "a02-p1-dgx", "a02-p2-dgx"},
+		},
+		{
+			name:     "Case 5: GB200 style names",
+			nodeList: "gb200-r[07,12]-c[17-18]-n[1-2]",
+			nodeArr: []string{
+				"gb200-r07-c17-n1", "gb200-r07-c17-n2", "gb200-r07-c18-n1", "gb200-r07-c18-n2",
+				"gb200-r12-c17-n1", "gb200-r12-c17-n2", "gb200-r12-c18-n1", "gb200-r12-c18-n2",
+			},
+		},
+		{
+			name:     "Case 6: empty entries",
+			nodeList: "node1,,node2,",
+			nodeArr:  []string{"node1", "node2"},
+		},
+		{
+			name:     "Case 7: invalid range",
+			nodeList: "eos[a-b]",
+			err:      `invalid range "a-b"`,
+		},
+		{
+			name:     "Case 8: empty bracket group",
+			nodeList: "eos[]",
+			err:      `invalid range ""`,
+		},
+		{
+			name:     "Case 9: descending range",
+			nodeList: "eos[3-1]",
+			err:      `invalid range "3-1": end is less than start`,
+		},
+		{
+			name:     "Case 10: unbalanced brackets",
+			nodeList: "eos[1-3",
+			err:      `unbalanced brackets in "eos[1-3"`,
+		},
+		{
+			name:     "Case 11: unbalanced closing bracket",
+			nodeList: "eos1-3]",
+			err:      `unbalanced brackets in "eos1-3]"`,
+		},
+		{
+			name:     "Case 12: nested brackets",
+			nodeList: "eos[1-[2]]",
+			err:      `nested brackets in "eos[1-[2]]"`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			nodeArr, err := Expand(tc.nodeList)
+			if len(tc.err) != 0 {
+				require.EqualError(t, err, tc.err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tc.nodeArr, nodeArr)
+			}
+		})
+	}
+}
+
+func TestCompact(t *testing.T) {
+	testCases := []struct {
+		name  string
+		names []string
+		ret   []string
+	}{
+		{
+			name: "Case 1: empty list",
+			ret:  []string{},
+		},
+		{
+			name:  "Case 2: names without numbers",
+			names: []string{"login", "viz"},
+			ret:   []string{"login", "viz"},
+		},
+		{
+			name:  "Case 3: ranges and singles",
+			names: []string{"node5", "node1", "node3", "node2", "other"},
+			ret:   []string{"other", "node[1-3]", "node5"},
+		},
+		{
+			name:  "Case 4: zero padding across powers of ten",
+			names: []string{"fake009", "fake010", "fake099", "fake100", "fake101"},
+			ret:   []string{"fake[009-010]", "fake[099-101]"},
+		},
+		{
+			name:  "Case 5: unpadded numbers of the padded width",
+			names: []string{"gpu08", "gpu09", "gpu10", "gpu11"},
+			ret:   []string{"gpu[08-11]"},
+		},
+		{
+			name:  "Case 6: different padding widths",
+			names: []string{"dgx-01-c03", "dgx-01-c3", "dgx-01-c004", "dgx-01-c4", "dgx-01-c04"},
+			ret:   []string{"dgx-01-c[3-4]", "dgx-01-c[03-04]", "dgx-01-c004"},
+		},
+		{
+			name:  "Case 7: suffix",
+			names: []string{"a01-p2-dgx", "a01-p1-dgx", "a02-p1-dgx"},
+			ret:   []string{"a01-p[1-2]-dgx", "a02-p1-dgx"},
+		},
+		{
+			name:  "Case 8: duplicates",
+			names: []string{"node1", "node2", "node1"},
+			ret:   []string{"node[1-2]"},
+		},
+		{
+			name:  "Case 9: zero",
+			names: []string{"node0", "node00", "node1", "node01"},
+			ret:   []string{"node[0-1]", "node[00-01]"},
+		},
+		{
+			name:  "Case 10: IB fixture names",
+			names: []string{"ngcprd10-luna3086", "ngcprd10-luna3085", "node101", "node102", "node201"},
+			ret:   []string{"ngcprd10-luna[3085-3086]", "node[101-102]", "node201"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.ret, Compact(tc.names))
+		})
+	}
+}
+
+func TestCompactExpand(t *testing.T) {
+	testCases := []struct {
+		name     string
+		nodeList string
+	}{
+		{
+			name:     "Case 1: zero-padded range",
+			nodeList: "fake[001-100]",
+		},
+		{
+			name:     "Case 2: unpadded range",
+			nodeList: "fake[1-100]",
+		},
+		{
+			name:     "Case 3: mixed padding with suffix",
+			nodeList: "dgx-01-c[3-9]-ib,dgx-01-c[03-12]-ib",
+		},
+		{
+			name:     "Case 4: GB200 style names",
+			nodeList: "gb200-r07-c17-n[1-4],gb200-r07-c18-n[1-4],gb200-r12-c17-n[1-4]",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			names, err := Expand(tc.nodeList)
+			require.NoError(t, err)
+			require.Equal(t, tc.nodeList, strings.Join(Compact(names), ","))
+
+			// the compacted names expand back to the same names
+			expanded, err := Expand(strings.Join(Compact(names), ","))
+			require.NoError(t, err)
+			require.Equal(t, names, expanded)
+		})
+	}
+}
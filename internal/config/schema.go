@@ -0,0 +1,127 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package config
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Schema is the JSON schema of the parameters decoded by Decode
+type Schema struct {
+	Schema               string             `json:"$schema,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Default              any                `json:"default,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+}
+
+// SchemaURI is the JSON schema dialect of the generated schemas
+const SchemaURI = "https://json-schema.org/draft/2020-12/schema"
+
+// ParamsSchema returns the schema of the parameter struct.
+// The non-zero fields of params are the defaults, and the fields
+// with the "required" validation tag are required.
+func ParamsSchema(params any) *Schema {
+	if params == nil {
+		return &Schema{Type: "object"}
+	}
+	return valueSchema(reflect.ValueOf(params))
+}
+
+func valueSchema(v reflect.Value) *Schema {
+	t := v.Type()
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+		if v.IsNil() {
+			v = reflect.Zero(t)
+		} else {
+			v = v.Elem()
+		}
+	}
+
+	switch t {
+	case typeDuration:
+		return &Schema{Type: "string", Format: "duration", Default: defaultValue(v)}
+	case typeTime:
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+
+	switch t.Kind() { // nolint: exhaustive
+	case reflect.String:
+		return &Schema{Type: "string", Default: defaultValue(v)}
+	case reflect.Bool:
+		return &Schema{Type: "boolean", Default: defaultValue(v)}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer", Default: defaultValue(v)}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number", Default: defaultValue(v)}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: valueSchema(reflect.Zero(t.Elem()))}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: valueSchema(reflect.Zero(t.Elem()))}
+	case reflect.Struct:
+		schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+		addProperties(schema, v)
+		return schema
+	default:
+		return &Schema{}
+	}
+}
+
+// addProperties adds the fields of the struct to the object schema, following the mapstructure tags
+func addProperties(schema *Schema, v reflect.Value) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(field.Tag.Get("mapstructure"), ",")
+		if name == "-" {
+			continue
+		}
+		if opts == "squash" {
+			addProperties(schema, v.Field(i))
+			continue
+		}
+		if len(name) == 0 {
+			name = field.Name
+		}
+		schema.Properties[name] = valueSchema(v.Field(i))
+		for _, rule := range strings.Split(field.Tag.Get("validate"), ",") {
+			if rule == "required" {
+				schema.Required = append(schema.Required, name)
+			}
+		}
+	}
+}
+
+// defaultValue returns the value, or nil for the zero value
+func defaultValue(v reflect.Value) any {
+	if v.IsZero() {
+		return nil
+	}
+	if v.Type() == typeDuration {
+		return v.Interface().(time.Duration).String()
+	}
+	return v.Interface()
+}
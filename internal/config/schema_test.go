@@ -0,0 +1,116 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package config_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/NVIDIA/topograph/internal/config"
+)
+
+type SchemaTestEmbedded struct {
+	Zone string `mapstructure:"zone"`
+}
+
+type schemaTestParams struct {
+	Name    string            `mapstructure:"name" validate:"required"`
+	Count   int               `mapstructure:"count"`
+	Ratio   float64           `mapstructure:"ratio"`
+	Enabled bool              `mapstructure:"enabled"`
+	Timeout time.Duration     `mapstructure:"timeout"`
+	Nodes   []string          `mapstructure:"nodes"`
+	Labels  map[string]string `mapstructure:"labels"`
+	Ignored string            `mapstructure:"-"`
+
+	SchemaTestEmbedded `mapstructure:",squash"`
+}
+
+func TestParamsSchema(t *testing.T) {
+	defaults := schemaTestParams{Count: 3, Timeout: time.Minute}
+
+	expected := &config.Schema{
+		Type: "object",
+		Properties: map[string]*config.Schema{
+			"name":    {Type: "string"},
+			"count":   {Type: "integer", Default: 3},
+			"ratio":   {Type: "number"},
+			"enabled": {Type: "boolean"},
+			"timeout": {Type: "string", Format: "duration", Default: "1m0s"},
+			"nodes":   {Type: "array", Items: &config.Schema{Type: "string"}},
+			"labels":  {Type: "object", AdditionalProperties: &config.Schema{Type: "string"}},
+			"zone":    {Type: "string"},
+		},
+		Required: []string{"name"},
+	}
+	require.Equal(t, expected, config.ParamsSchema(defaults))
+	require.Equal(t, expected, config.ParamsSchema(&defaults))
+	require.Equal(t, &config.Schema{Type: "object"}, config.ParamsSchema(nil))
+}
+
+// TestParamsSchemaRoundTrip checks that values of the schema types decode into the parameters,
+// and that the schema defaults decode into the default parameters
+func TestParamsSchemaRoundTrip(t *testing.T) {
+	defaults := schemaTestParams{Count: 3, Timeout: time.Minute}
+	schema := config.ParamsSchema(defaults)
+
+	// the schema is consumed as JSON
+	data, err := json.Marshal(schema)
+	require.NoError(t, err)
+	schema = &config.Schema{}
+	require.NoError(t, json.Unmarshal(data, schema))
+
+	samples := map[string]any{
+		"string":  "x",
+		"integer": 7,
+		"number":  0.5,
+		"boolean": true,
+		"array":   []any{"a"},
+		"object":  map[string]any{"k": "v"},
+	}
+	input := make(map[string]any)
+	inputDefaults := make(map[string]any)
+	for name, prop := range schema.Properties {
+		if prop.Format == "duration" {
+			input[name] = "2s"
+		} else {
+			input[name] = samples[prop.Type]
+		}
+		if prop.Default != nil {
+			inputDefaults[name] = prop.Default
+		}
+	}
+
+	var p schemaTestParams
+	require.NoError(t, config.Decode(input, &p))
+	require.Equal(t, schemaTestParams{
+		Name:               "x",
+		Count:              7,
+		Ratio:              0.5,
+		Enabled:            true,
+		Timeout:            2 * time.Second,
+		Nodes:              []string{"a"},
+		Labels:             map[string]string{"k": "v"},
+		SchemaTestEmbedded: SchemaTestEmbedded{Zone: "x"},
+	}, p)
+
+	p = schemaTestParams{}
+	require.NoError(t, config.Decode(inputDefaults, &p))
+	require.Equal(t, defaults, p)
+}
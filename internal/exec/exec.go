@@ -17,6 +17,7 @@
 package exec
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"fmt"
@@ -52,3 +53,26 @@ func Exec(ctx context.Context, exe string, args []string, env map[string]string)
 	}
 	return &stdout, nil
 }
+
+// Pdsh executes the command on the nodes and returns the output per node
+func Pdsh(ctx context.Context, nodes []string, command string) (map[string]string, error) {
+	stdout, err := Exec(ctx, "pdsh", []string{"-w", strings.Join(nodes, ","), command}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make(map[string]string)
+	scanner := bufio.NewScanner(stdout)
+	for scanner.Scan() {
+		arr := strings.SplitN(scanner.Text(), ": ", 2)
+		if len(arr) == 2 {
+			res[arr[0]] = arr[1]
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
@@ -0,0 +1,91 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package files_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/NVIDIA/topograph/internal/files"
+)
+
+func TestNormalize(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    []byte
+		expected []byte
+		err      string
+	}{
+		{
+			name:     "Case 1: clean input",
+			input:    []byte("a: 1\nb: 2\n"),
+			expected: []byte("a: 1\nb: 2\n"),
+		},
+		{
+			name:     "Case 2: UTF-8 BOM",
+			input:    []byte("\xEF\xBB\xBFa: 1\n"),
+			expected: []byte("a: 1\n"),
+		},
+		{
+			name:     "Case 3: CRLF",
+			input:    []byte("a: 1\r\nb: 2\r\n"),
+			expected: []byte("a: 1\nb: 2\n"),
+		},
+		{
+			name:     "Case 4: BOM and CRLF",
+			input:    []byte("\xEF\xBB\xBFa: 1\r\nb: 2\r\n"),
+			expected: []byte("a: 1\nb: 2\n"),
+		},
+		{
+			name:  "Case 5: UTF-16 LE",
+			input: []byte("\xFF\xFEa\x00:\x00"),
+			err:   "UTF-16 encoding is not supported; convert the input to UTF-8",
+		},
+		{
+			name:  "Case 6: UTF-16 BE",
+			input: []byte("\xFE\xFF\x00a\x00:"),
+			err:   "UTF-16 encoding is not supported; convert the input to UTF-8",
+		},
+		{
+			name:  "Case 7: invalid UTF-8",
+			input: []byte("name: caf\xE9\n"),
+			err:   "invalid UTF-8 encoding at byte offset 9",
+		},
+		{
+			name:     "Case 8: multi-byte UTF-8",
+			input:    []byte("name: café\r\n"),
+			expected: []byte("name: café\n"),
+		},
+		{
+			name:     "Case 9: empty input",
+			input:    []byte{},
+			expected: []byte{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			data, err := files.Normalize(tc.input)
+			if len(tc.err) != 0 {
+				require.EqualError(t, err, tc.err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tc.expected, data)
+			}
+		})
+	}
+}
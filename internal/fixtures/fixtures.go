@@ -0,0 +1,250 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package fixtures provides topology test sets used by the test provider and by unit tests.
+package fixtures
+
+import (
+	"fmt"
+	"maps"
+	"strconv"
+
+	"github.com/NVIDIA/topograph/pkg/topology"
+)
+
+// LongSwitchName is a switch ID exceeding the 63 characters limit of a label value
+const LongSwitchName = "S3very-very-long-id-to-check-label-value-limits-of-63-characters"
+
+type options struct {
+	longSwitchNames bool
+	instanceID      func(string) string
+	nodesPerSwitch  int
+}
+
+// Option configures a test set
+type Option func(*options)
+
+// WithLongSwitchNames replaces switch IDs with long IDs exceeding the label value limit
+func WithLongSwitchNames() Option {
+	return func(o *options) {
+		o.longSwitchNames = true
+	}
+}
+
+// WithInstanceIDs maps the instance IDs of the test set, e.g. "I21", to the IDs of a provider scheme
+func WithInstanceIDs(f func(id string) string) Option {
+	return func(o *options) {
+		o.instanceID = f
+	}
+}
+
+// WithNodesPerSwitch sets the number of nodes of each leaf switch, and of each block.
+// The nodes of a switch are numbered from 1, e.g. "Node201" to "Node2<n>" for switch "S2".
+func WithNodesPerSwitch(n int) Option {
+	return func(o *options) {
+		o.nodesPerSwitch = n
+	}
+}
+
+// AWSInstanceIDs maps the test set instance IDs to AWS EC2 instance IDs, e.g. "I21" -> "i-00000000000000021"
+func AWSInstanceIDs(id string) string {
+	return fmt.Sprintf("i-%017s", id[1:])
+}
+
+// OCIInstanceIDs maps the test set instance IDs to OCI instance OCIDs, e.g. "I21" -> "ocid1.instance.oc1..i21"
+func OCIInstanceIDs(id string) string {
+	return "ocid1.instance.oc1..i" + id[1:]
+}
+
+func getOptions(opts []Option) *options {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// testSet holds the nodes of a test set
+type testSet struct {
+	opts          *options
+	instance2node map[string]string
+}
+
+func newTestSet(opts []Option) *testSet {
+	return &testSet{opts: getOptions(opts), instance2node: make(map[string]string)}
+}
+
+// nodes returns the nodes of a leaf switch, keyed by instance ID.
+// The nodes are numbered by the given indices, unless the number of nodes per switch is set.
+func (ts *testSet) nodes(group int, indices ...int) map[string]*topology.Vertex {
+	if ts.opts.nodesPerSwitch > 0 {
+		indices = make([]int, ts.opts.nodesPerSwitch)
+		for i := range indices {
+			indices[i] = i + 1
+		}
+	}
+
+	nodes := make(map[string]*topology.Vertex, len(indices))
+	for _, index := range indices {
+		id := fmt.Sprintf("I%d%d", group, index)
+		if ts.opts.instanceID != nil {
+			id = ts.opts.instanceID(id)
+		}
+		name := fmt.Sprintf("Node%d%02d", group, index)
+		nodes[id] = &topology.Vertex{ID: id, Name: name}
+		ts.instance2node[id] = name
+	}
+	return nodes
+}
+
+// TreeTestSet returns a tree topology and its instance/node map
+func TreeTestSet(opts ...Option) (*topology.Vertex, map[string]string) {
+	ts := newTestSet(opts)
+
+	//
+	//        S1
+	//      /    \
+	//    S2      S3
+	//    |       |
+	//   ---     ---
+	//   I21     I34
+	//   I22     I35
+	//   I25     I36
+	//   ---     ---
+	//
+	s3name := "S3"
+	if ts.opts.longSwitchNames {
+		s3name = LongSwitchName
+	}
+
+	sw2 := &topology.Vertex{
+		ID:       "S2",
+		Vertices: ts.nodes(2, 1, 2, 5),
+	}
+	sw3 := &topology.Vertex{
+		ID:       s3name,
+		Vertices: ts.nodes(3, 4, 5, 6),
+	}
+	sw1 := &topology.Vertex{
+		ID:       "S1",
+		Vertices: map[string]*topology.Vertex{"S2": sw2, s3name: sw3},
+	}
+	treeRoot := &topology.Vertex{
+		Vertices: map[string]*topology.Vertex{"S1": sw1},
+	}
+
+	root := &topology.Vertex{
+		Vertices: map[string]*topology.Vertex{topology.TopologyTree: treeRoot},
+	}
+	return root, ts.instance2node
+}
+
+// BlockWithMultiIBTestSet returns a block topology with two IB fabrics and its instance/node map
+func BlockWithMultiIBTestSet(opts ...Option) (*topology.Vertex, map[string]string) {
+	ts := newTestSet(opts)
+
+	//
+	//     ibRoot2        ibRoot1
+	//        |               |
+	//        S1              S4
+	//      /    \          /    \
+	//    S2      S3      S5      S6
+	//    |       |       |       |
+	//   ---     ---     ---     ---
+	//   I14\    I21\    I31\    I41\
+	//   I15-B1  I22-B2  I32-B3  I42-B4
+	//   I16/    I25/     I33/   I43/
+	//   ---     ---      ---    ---
+	//
+	nodes1 := ts.nodes(1, 4, 5, 6)
+	nodes2 := ts.nodes(2, 1, 2, 5)
+	nodes3 := ts.nodes(3, 1, 2, 3)
+	nodes4 := ts.nodes(4, 1, 2, 3)
+
+	sw5 := &topology.Vertex{
+		ID:       "S5",
+		Vertices: nodes3,
+	}
+	sw6 := &topology.Vertex{
+		ID:       "S6",
+		Vertices: nodes4,
+	}
+	sw4 := &topology.Vertex{
+		ID:       "S4",
+		Vertices: map[string]*topology.Vertex{"S5": sw5, "S6": sw6},
+	}
+	ibRoot1 := &topology.Vertex{
+		ID:       "ibRoot1",
+		Vertices: map[string]*topology.Vertex{"S4": sw4},
+	}
+
+	sw2 := &topology.Vertex{
+		ID:       "S2",
+		Vertices: nodes1,
+	}
+	sw3 := &topology.Vertex{
+		ID:       "S3",
+		Vertices: nodes2,
+	}
+	sw1 := &topology.Vertex{
+		ID:       "S1",
+		Vertices: map[string]*topology.Vertex{"S2": sw2, "S3": sw3},
+	}
+	ibRoot2 := &topology.Vertex{
+		ID:       "ibRoot2",
+		Vertices: map[string]*topology.Vertex{"S1": sw1},
+	}
+
+	treeRoot := &topology.Vertex{
+		Vertices: map[string]*topology.Vertex{"IB1": ibRoot1, "IB2": ibRoot2},
+	}
+
+	// the blocks share the node vertices with the leaf switches
+	block1 := &topology.Vertex{
+		ID:       "B1",
+		Vertices: maps.Clone(nodes1),
+	}
+	block2 := &topology.Vertex{
+		ID:       "B2",
+		Vertices: maps.Clone(nodes2),
+	}
+	block3 := &topology.Vertex{
+		ID:       "B3",
+		Vertices: maps.Clone(nodes3),
+	}
+	block4 := &topology.Vertex{
+		ID:       "B4",
+		Vertices: maps.Clone(nodes4),
+	}
+
+	blockRoot := &topology.Vertex{
+		Vertices: map[string]*topology.Vertex{"B1": block1, "B2": block2, "B3": block3, "B4": block4},
+	}
+
+	blockSize := 3
+	if ts.opts.nodesPerSwitch > 0 {
+		blockSize = ts.opts.nodesPerSwitch
+	}
+
+	root := &topology.Vertex{
+		Vertices: map[string]*topology.Vertex{topology.TopologyBlock: blockRoot, topology.TopologyTree: treeRoot},
+		Metadata: map[string]string{
+			topology.KeyPlugin:     topology.TopologyBlock,
+			topology.KeyBlockSizes: strconv.Itoa(blockSize),
+		},
+	}
+	return root, ts.instance2node
+}
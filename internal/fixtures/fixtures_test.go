@@ -0,0 +1,59 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fixtures
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/NVIDIA/topograph/pkg/topology"
+)
+
+func TestTreeTestSet(t *testing.T) {
+	_, i2n := TreeTestSet()
+	require.Equal(t, map[string]string{
+		"I21": "Node201", "I22": "Node202", "I25": "Node205",
+		"I34": "Node304", "I35": "Node305", "I36": "Node306",
+	}, i2n)
+
+	root, i2n := TreeTestSet(WithInstanceIDs(AWSInstanceIDs), WithNodesPerSwitch(2))
+	require.Equal(t, map[string]string{
+		"i-00000000000000021": "Node201", "i-00000000000000022": "Node202",
+		"i-00000000000000031": "Node301", "i-00000000000000032": "Node302",
+	}, i2n)
+
+	sw2 := root.Vertices[topology.TopologyTree].Vertices["S1"].Vertices["S2"]
+	require.Equal(t, &topology.Vertex{ID: "i-00000000000000022", Name: "Node202"}, sw2.Vertices["i-00000000000000022"])
+}
+
+func TestBlockWithMultiIBTestSet(t *testing.T) {
+	root, i2n := BlockWithMultiIBTestSet()
+	require.Len(t, i2n, 12)
+	require.Equal(t, "3", root.Metadata[topology.KeyBlockSizes])
+
+	root, i2n = BlockWithMultiIBTestSet(WithInstanceIDs(OCIInstanceIDs), WithNodesPerSwitch(4))
+	require.Len(t, i2n, 16)
+	require.Equal(t, "Node104", i2n["ocid1.instance.oc1..i14"])
+	require.Equal(t, "4", root.Metadata[topology.KeyBlockSizes])
+
+	// the blocks and the leaf switches share the node vertices
+	block := root.Vertices[topology.TopologyBlock].Vertices["B3"]
+	sw := root.Vertices[topology.TopologyTree].Vertices["IB1"].Vertices["S4"].Vertices["S5"]
+	require.Len(t, block.Vertices, 4)
+	require.Same(t, sw.Vertices["ocid1.instance.oc1..i31"], block.Vertices["ocid1.instance.oc1..i31"])
+}
@@ -0,0 +1,88 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package httpreq
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// TLS is the TLS configuration of the HTTP client
+type TLS struct {
+	// CACert is the path or the inline PEM of the CA bundle trusted in addition to the system CAs
+	CACert string `mapstructure:"ca_cert"`
+	// InsecureSkipVerify disables the verification of the server certificate
+	InsecureSkipVerify bool `mapstructure:"insecure_skip_verify"`
+}
+
+// NewClient returns an HTTP client with the TLS configuration and proxy URL.
+// If the proxy URL is empty, the proxy is taken from the environment.
+func NewClient(tlsCfg *TLS, proxyURL string) (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if len(proxyURL) != 0 {
+		u, err := url.Parse(proxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy URL %q: %v", proxyURL, err)
+		}
+		transport.Proxy = http.ProxyURL(u)
+	}
+
+	if tlsCfg != nil {
+		cfg := &tls.Config{
+			MinVersion:         tls.VersionTLS12,
+			InsecureSkipVerify: tlsCfg.InsecureSkipVerify, // nolint: gosec
+		}
+		if len(tlsCfg.CACert) != 0 {
+			pool, err := getCertPool(tlsCfg.CACert)
+			if err != nil {
+				return nil, err
+			}
+			cfg.RootCAs = pool
+		}
+		transport.TLSClientConfig = cfg
+	}
+
+	return &http.Client{Transport: transport}, nil
+}
+
+// getCertPool returns the system cert pool extended with the CA bundle,
+// given either as a file path or as inline PEM
+func getCertPool(caCert string) (*x509.CertPool, error) {
+	pem := []byte(caCert)
+	if !strings.Contains(caCert, "-----BEGIN") {
+		var err error
+		if pem, err = os.ReadFile(caCert); err != nil {
+			return nil, fmt.Errorf("failed to read CA cert: %v", err)
+		}
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in CA cert")
+	}
+
+	return pool, nil
+}
@@ -0,0 +1,101 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package httpreq
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewClient(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("OK"))
+	}))
+	defer ts.Close()
+
+	caCert := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw}))
+	caPath := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(caPath, []byte(caCert), 0600))
+
+	testCases := []struct {
+		name  string
+		tls   *TLS
+		proxy string
+		err   string
+		body  string
+	}{
+		{
+			name: "Case 1: missing CA",
+			err:  "failed to send HTTP request",
+		},
+		{
+			name: "Case 2: inline CA",
+			tls:  &TLS{CACert: caCert},
+			body: "OK",
+		},
+		{
+			name: "Case 3: CA file",
+			tls:  &TLS{CACert: caPath},
+			body: "OK",
+		},
+		{
+			name: "Case 4: insecure",
+			tls:  &TLS{InsecureSkipVerify: true},
+			body: "OK",
+		},
+		{
+			name:  "Case 5: unreachable proxy",
+			tls:   &TLS{CACert: caCert},
+			proxy: "http://127.0.0.1:1",
+			err:   "failed to send HTTP request",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client, err := NewClient(tc.tls, tc.proxy)
+			require.NoError(t, err)
+
+			_, body, err := DoRequest(client, func() (*http.Request, error) {
+				return http.NewRequest(http.MethodGet, ts.URL, nil)
+			})
+			if len(tc.err) != 0 {
+				require.ErrorContains(t, err, tc.err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tc.body, string(body))
+			}
+		})
+	}
+}
+
+func TestNewClientErrors(t *testing.T) {
+	_, err := NewClient(&TLS{CACert: "-----BEGIN CERTIFICATE-----\ninvalid\n-----END CERTIFICATE-----\n"}, "")
+	require.EqualError(t, err, "no certificates found in CA cert")
+
+	_, err = NewClient(&TLS{CACert: filepath.Join(t.TempDir(), "missing.pem")}, "")
+	require.ErrorContains(t, err, "failed to read CA cert")
+
+	_, err = NewClient(nil, "http://proxy:port")
+	require.ErrorContains(t, err, "invalid proxy URL")
+}
@@ -17,19 +17,20 @@
 package httpreq
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"math"
+	"math/rand"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"k8s.io/klog/v2"
 )
 
 var (
-	// retries specifies number of retries
-	retries = 3
-
 	//retryHttpCodes specifies on which errors to retry the request
 	retryHttpCodes = map[int]bool{
 		http.StatusRequestTimeout:     true,
@@ -38,48 +39,177 @@ var (
 		http.StatusServiceUnavailable: true,
 		http.StatusGatewayTimeout:     true,
 	}
+
+	// sleep and now are replaced in tests
+	sleep = sleepContext
+	now   = time.Now
 )
 
+// RetryPolicy configures the retries of DoRequestWithPolicy.
+// The delay before a retry is drawn uniformly between zero and the backoff delay (full jitter),
+// where the backoff delay starts at BaseDelay and doubles with every retry, up to MaxDelay.
+// The Retry-After header of the 429 and 503 responses takes precedence over the backoff delay.
+// Only the responses are retried: the transport errors are returned at once, since the request
+// may have reached the server.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of requests, including the first one
+	MaxAttempts int
+	// BaseDelay is the backoff delay of the first retry
+	BaseDelay time.Duration
+	// MaxDelay caps the backoff delay; zero disables the cap
+	MaxDelay time.Duration
+	// MaxElapsedTime stops the retries that would start later than this time after the first request;
+	// zero disables the limit
+	MaxElapsedTime time.Duration
+	// Retryable reports whether the response status code is retryable; if nil, the codes 408, 429, 502, 503 and 504 are
+	Retryable func(code int) bool
+}
+
+// DefaultRetryPolicy is the retry policy of DoRequestWithRetries
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    3,
+	BaseDelay:      time.Second,
+	MaxDelay:       30 * time.Second,
+	MaxElapsedTime: 2 * time.Minute,
+}
+
+// RetryOn returns the retry predicate of the default retryable status codes and the given ones
+func RetryOn(codes ...int) func(code int) bool {
+	extra := make(map[int]bool, len(codes))
+	for _, code := range codes {
+		extra[code] = true
+	}
+	return func(code int) bool {
+		return retryHttpCodes[code] || extra[code]
+	}
+}
+
 type RequestFunc func() (*http.Request, error)
 
-// DoRequest sends HTTP requests and returns HTTP response
-func DoRequest(f RequestFunc) (*http.Response, []byte, error) {
+// DoRequest sends HTTP requests and returns HTTP response.
+// If client is nil, the request is sent with the default transport.
+func DoRequest(client *http.Client, f RequestFunc) (*http.Response, []byte, error) {
+	_, resp, body, err := doRequest(client, f)
+	return resp, body, err
+}
+
+// doRequest is DoRequest returning the sent request as well
+func doRequest(client *http.Client, f RequestFunc) (*http.Request, *http.Response, []byte, error) {
 	req, err := f()
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 	klog.V(4).Infof("Sending HTTP request %s", req.URL.String())
-	client := &http.Client{}
+	if client == nil {
+		client = &http.Client{}
+	}
 	resp, err := client.Do(req)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to send HTTP request: %v", err)
+		return req, nil, nil, fmt.Errorf("failed to send HTTP request: %v", err)
 	}
 	defer func() { _ = resp.Body.Close() }()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to read HTTP response: %v", err)
+		return req, nil, nil, fmt.Errorf("failed to read HTTP response: %v", err)
 	}
 
 	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
-		return resp, body, nil
+		return req, resp, body, nil
 	}
 
-	return resp, body, fmt.Errorf("HTTP %d %s: %s", resp.StatusCode, resp.Status, string(body))
+	return req, resp, body, fmt.Errorf("HTTP %d %s: %s", resp.StatusCode, resp.Status, string(body))
 }
 
-// DoRequestWithRetries sends HTTP requests and returns HTTP response; retries if needed
-func DoRequestWithRetries(f RequestFunc) (resp *http.Response, body []byte, err error) {
+// DoRequestWithRetries sends HTTP requests and returns HTTP response; retries with the default retry policy
+func DoRequestWithRetries(client *http.Client, f RequestFunc) (*http.Response, []byte, error) {
+	return DoRequestWithPolicy(client, f, DefaultRetryPolicy)
+}
+
+// DoRequestWithPolicy sends HTTP requests and returns HTTP response; retries the retryable responses
+// according to the policy. The transport errors are not retried. The wait before a retry ends with
+// the context of the request.
+func DoRequestWithPolicy(client *http.Client, f RequestFunc, policy RetryPolicy) (resp *http.Response, body []byte, err error) {
 	klog.V(4).Infof("Sending HTTP request with retries")
-	for r := 1; r <= retries; r++ {
-		resp, body, err = DoRequest(f)
-		if err == nil || !retryHttpCodes[resp.StatusCode] {
-			break
+	retryable := policy.Retryable
+	if retryable == nil {
+		retryable = RetryOn()
+	}
+
+	start := now()
+	for attempt := 1; ; attempt++ {
+		var req *http.Request
+		req, resp, body, err = doRequest(client, f)
+		// no response means that the request could not be built or sent: not retried
+		if err == nil || resp == nil || !retryable(resp.StatusCode) || attempt >= policy.MaxAttempts {
+			return
+		}
+
+		wait := policy.retryDelay(resp, attempt)
+		if policy.MaxElapsedTime > 0 && now().Add(wait).Sub(start) > policy.MaxElapsedTime {
+			klog.Infof("Request error: %v. Retry in %s exceeds the max elapsed time %s", err, wait.String(), policy.MaxElapsedTime.String())
+			return
 		}
-		wait := time.Duration(int(math.Pow(2, float64(r))) * time.Now().Second())
-		klog.Infof("Request error: %v. Retrying in %s\n", err, wait.String())
-		time.Sleep(wait)
+		klog.Infof("Request error: %v. Retrying in %s", err, wait.String())
+		if ctxErr := sleep(req.Context(), wait); ctxErr != nil {
+			err = fmt.Errorf("%v: retry canceled: %w", err, ctxErr)
+			return
+		}
+	}
+}
+
+// sleepContext waits for the given duration or until the context is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// retryDelay returns the delay before the retry following the given attempt
+func (p *RetryPolicy) retryDelay(resp *http.Response, attempt int) time.Duration {
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable {
+		if wait, ok := retryAfter(resp.Header.Get("Retry-After")); ok {
+			return wait
+		}
+	}
+
+	backoff := p.BaseDelay
+	for i := 1; i < attempt && backoff < math.MaxInt64/2 && (p.MaxDelay == 0 || backoff < p.MaxDelay); i++ {
+		backoff *= 2
+	}
+	if p.MaxDelay > 0 {
+		backoff = min(backoff, p.MaxDelay)
+	}
+	if backoff <= 0 {
+		return 0
+	}
+
+	return time.Duration(rand.Int63n(int64(backoff) + 1))
+}
+
+// retryAfter returns the delay of the Retry-After header, given either in seconds or as an HTTP date
+func retryAfter(val string) (time.Duration, bool) {
+	val = strings.TrimSpace(val)
+	if len(val) == 0 {
+		return 0, false
+	}
+
+	if secs, err := strconv.Atoi(val); err == nil {
+		if secs < 0 {
+			return 0, false
+		}
+		return time.Duration(secs) * time.Second, true
+	}
+
+	if t, err := http.ParseTime(val); err == nil {
+		return max(t.Sub(now()), 0), true
 	}
 
-	return
+	return 0, false
 }
@@ -0,0 +1,227 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package httpreq
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClock replaces the sleep and the clock of the retries, advancing the clock by the sleep time
+func fakeClock(t *testing.T) *[]time.Duration {
+	var mutex sync.Mutex
+	var waits []time.Duration
+	clock := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	sleep = func(_ context.Context, d time.Duration) error {
+		mutex.Lock()
+		defer mutex.Unlock()
+		waits = append(waits, d)
+		clock = clock.Add(d)
+		return nil
+	}
+	now = func() time.Time {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return clock
+	}
+	t.Cleanup(func() {
+		sleep = sleepContext
+		now = time.Now
+	})
+
+	return &waits
+}
+
+// responseServer returns the server replying with the given status codes and headers, then with 200 OK
+func responseServer(t *testing.T, codes []int, header http.Header) (*httptest.Server, *int) {
+	var requests int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests <= len(codes) {
+			for key, vals := range header {
+				w.Header()[key] = vals
+			}
+			w.WriteHeader(codes[requests-1])
+			return
+		}
+		_, _ = w.Write([]byte("OK"))
+	}))
+	t.Cleanup(ts.Close)
+	return ts, &requests
+}
+
+func TestDoRequestWithPolicy(t *testing.T) {
+	retryDate := time.Date(2025, time.January, 1, 0, 0, 7, 0, time.UTC).Format(http.TimeFormat)
+
+	testCases := []struct {
+		name     string
+		codes    []int
+		header   http.Header
+		policy   RetryPolicy
+		requests int
+		waits    []time.Duration
+		err      string
+	}{
+		{
+			name:     "Case 1: Retry-After in seconds",
+			codes:    []int{http.StatusTooManyRequests},
+			header:   http.Header{"Retry-After": {"3"}},
+			policy:   DefaultRetryPolicy,
+			requests: 2,
+			waits:    []time.Duration{3 * time.Second},
+		},
+		{
+			name:     "Case 2: Retry-After as HTTP date",
+			codes:    []int{http.StatusServiceUnavailable},
+			header:   http.Header{"Retry-After": {retryDate}},
+			policy:   DefaultRetryPolicy,
+			requests: 2,
+			waits:    []time.Duration{7 * time.Second},
+		},
+		{
+			name:     "Case 3: Retry-After beyond the max elapsed time",
+			codes:    []int{http.StatusTooManyRequests},
+			header:   http.Header{"Retry-After": {"300"}},
+			policy:   DefaultRetryPolicy,
+			requests: 1,
+			err:      "HTTP 429",
+		},
+		{
+			name:     "Case 4: max attempts",
+			codes:    []int{http.StatusTooManyRequests, http.StatusTooManyRequests, http.StatusTooManyRequests},
+			header:   http.Header{"Retry-After": {"1"}},
+			policy:   RetryPolicy{MaxAttempts: 2},
+			requests: 2,
+			waits:    []time.Duration{time.Second},
+			err:      "HTTP 429",
+		},
+		{
+			name:     "Case 5: non-retryable code",
+			codes:    []int{http.StatusInternalServerError},
+			policy:   DefaultRetryPolicy,
+			requests: 1,
+			err:      "HTTP 500",
+		},
+		{
+			name:     "Case 6: retryable code of the predicate",
+			codes:    []int{http.StatusInternalServerError},
+			policy:   RetryPolicy{MaxAttempts: 2, Retryable: RetryOn(http.StatusInternalServerError)},
+			requests: 2,
+			waits:    []time.Duration{0},
+		},
+		{
+			name:     "Case 7: invalid Retry-After",
+			codes:    []int{http.StatusTooManyRequests},
+			header:   http.Header{"Retry-After": {"soon"}},
+			policy:   RetryPolicy{MaxAttempts: 2},
+			requests: 2,
+			waits:    []time.Duration{0},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			waits := fakeClock(t)
+			ts, requests := responseServer(t, tc.codes, tc.header)
+
+			_, body, err := DoRequestWithPolicy(nil, func() (*http.Request, error) {
+				return http.NewRequest(http.MethodGet, ts.URL, nil)
+			}, tc.policy)
+			if len(tc.err) != 0 {
+				require.ErrorContains(t, err, tc.err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, "OK", string(body))
+			}
+			require.Equal(t, tc.requests, *requests)
+			require.Equal(t, tc.waits, *waits)
+		})
+	}
+}
+
+func TestDoRequestWithRetries(t *testing.T) {
+	waits := fakeClock(t)
+	ts, requests := responseServer(t, []int{http.StatusTooManyRequests}, http.Header{"Retry-After": {"2"}})
+
+	_, body, err := DoRequestWithRetries(nil, func() (*http.Request, error) {
+		return http.NewRequest(http.MethodGet, ts.URL, nil)
+	})
+	require.NoError(t, err)
+	require.Equal(t, "OK", string(body))
+	require.Equal(t, 2, *requests)
+	require.Equal(t, []time.Duration{2 * time.Second}, *waits)
+}
+
+func TestDoRequestWithPolicyCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// the context is canceled during the wait of the Retry-After delay
+	var waits []time.Duration
+	sleep = func(ctx context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		cancel()
+		return sleepContext(ctx, d)
+	}
+	t.Cleanup(func() { sleep = sleepContext })
+
+	ts, requests := responseServer(t, []int{http.StatusServiceUnavailable}, http.Header{"Retry-After": {"60"}})
+
+	_, _, err := DoRequestWithPolicy(nil, func() (*http.Request, error) {
+		return http.NewRequestWithContext(ctx, http.MethodGet, ts.URL, nil)
+	}, DefaultRetryPolicy)
+	require.ErrorIs(t, err, context.Canceled)
+	require.ErrorContains(t, err, "HTTP 503")
+	require.Equal(t, 1, *requests)
+	require.Equal(t, []time.Duration{time.Minute}, waits)
+}
+
+func TestDoRequestWithPolicyTransportError(t *testing.T) {
+	waits := fakeClock(t)
+	ts := httptest.NewServer(http.NotFoundHandler())
+	ts.Close()
+
+	_, _, err := DoRequestWithPolicy(nil, func() (*http.Request, error) {
+		return http.NewRequest(http.MethodGet, ts.URL, nil)
+	}, DefaultRetryPolicy)
+	require.ErrorContains(t, err, "failed to send HTTP request")
+	require.Empty(t, *waits)
+}
+
+func TestRetryDelay(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: time.Second, MaxDelay: 10 * time.Second}
+	resp := &http.Response{StatusCode: http.StatusBadGateway}
+
+	for attempt, backoff := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second, 10 * time.Second} {
+		for range 20 {
+			wait := policy.retryDelay(resp, attempt+1)
+			require.GreaterOrEqual(t, wait, time.Duration(0))
+			require.LessOrEqual(t, wait, backoff)
+		}
+	}
+
+	// the backoff delay does not overflow without max delay
+	policy = RetryPolicy{BaseDelay: time.Second}
+	require.NotPanics(t, func() { policy.retryDelay(resp, 100) })
+}
@@ -0,0 +1,229 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package remote
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+	"k8s.io/klog/v2"
+)
+
+const defaultPort = "22"
+
+// Params define the SSH destination of the topology config
+type Params struct {
+	// Host is the SSH server address, with optional port (default 22)
+	Host string `mapstructure:"host" validate:"required"`
+	User string `mapstructure:"user" validate:"required"`
+	// KeyPath is the path of the private key file, e.g., mounted from a Kubernetes Secret
+	KeyPath string `mapstructure:"key_path" validate:"required"`
+	// KnownHosts is the path of the known_hosts file used to verify the host key
+	KnownHosts string `mapstructure:"known_hosts" validate:"required"`
+	// RemotePath is the path of the topology config on the remote host
+	RemotePath string `mapstructure:"remote_path" validate:"required"`
+	// PostCommand, if set, is run on the remote host after the copy, e.g., "scontrol reconfigure"
+	PostCommand string `mapstructure:"post_command"`
+}
+
+// Host defines the SSH server running the remote commands
+type Host struct {
+	// Addr is the SSH server address, with optional port (default 22)
+	Addr string
+	User string
+	// KeyPath is the path of the private key file. If empty, the keys of the SSH agent at SSH_AUTH_SOCK are used
+	KeyPath string
+	// KnownHosts is the path of the known_hosts file used to verify the host key
+	KnownHosts string
+	// InsecureIgnoreHostKey skips the host key verification
+	InsecureIgnoreHostKey bool
+}
+
+// Push writes the data to the remote path over SSH, and runs the post command.
+// The data is uploaded to a temporary file renamed to the remote path,
+// so that the remote path never holds a partial config.
+func Push(ctx context.Context, p *Params, data []byte) error {
+	client, err := dial(ctx, &Host{Addr: p.Host, User: p.User, KeyPath: p.KeyPath, KnownHosts: p.KnownHosts})
+	if err != nil {
+		return err
+	}
+	defer closeOnDone(ctx, client)()
+
+	tmp := p.RemotePath + ".topograph.tmp"
+	klog.Infof("Copying topology config to %s:%s", p.Host, p.RemotePath)
+	if _, err = run(client, fmt.Sprintf("cat > %s && mv -f %s %s", quote(tmp), quote(tmp), quote(p.RemotePath)), data); err != nil {
+		return err
+	}
+
+	if len(p.PostCommand) != 0 {
+		klog.Infof("Running %q on %s", p.PostCommand, p.Host)
+		if _, err = run(client, p.PostCommand, nil); err != nil {
+			return err
+		}
+	}
+
+	return ctx.Err()
+}
+
+// Output runs the command on the host over SSH, and returns its stdout.
+// The command is interrupted when the context is done.
+func Output(ctx context.Context, h *Host, cmd string) ([]byte, error) {
+	client, err := dial(ctx, h)
+	if err != nil {
+		return nil, err
+	}
+	defer closeOnDone(ctx, client)()
+
+	klog.Infof("Running %q on %s", cmd, h.Addr)
+	stdout, err := run(client, cmd, nil)
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return nil, fmt.Errorf("remote command %q interrupted: %w", cmd, ctxErr)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return stdout, nil
+}
+
+// closeOnDone closes the client if the context is done, until the returned function is called.
+// The returned function closes the client.
+func closeOnDone(ctx context.Context, client *ssh.Client) func() {
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = client.Close()
+		case <-done:
+		}
+	}()
+
+	return func() {
+		close(done)
+		_ = client.Close()
+	}
+}
+
+func dial(ctx context.Context, h *Host) (*ssh.Client, error) {
+	auth, closeAuth, err := authMethod(h.KeyPath)
+	if err != nil {
+		return nil, err
+	}
+	defer closeAuth()
+
+	hostKeyCallback, err := hostKeyCallback(h)
+	if err != nil {
+		return nil, err
+	}
+
+	addr := h.Addr
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(addr, defaultPort)
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %v", addr, err)
+	}
+
+	cfg := &ssh.ClientConfig{
+		User:            h.User,
+		Auth:            []ssh.AuthMethod{auth},
+		HostKeyCallback: hostKeyCallback,
+	}
+	c, chans, reqs, err := ssh.NewClientConn(conn, addr, cfg)
+	if err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("SSH handshake with %s failed: %v", addr, err)
+	}
+
+	return ssh.NewClient(c, chans, reqs), nil
+}
+
+// authMethod returns the public key authentication with the key file, or with the SSH agent
+// if the key path is empty. The returned function closes the agent connection after the handshake.
+func authMethod(keyPath string) (ssh.AuthMethod, func(), error) {
+	if len(keyPath) == 0 {
+		sock := os.Getenv("SSH_AUTH_SOCK")
+		if len(sock) == 0 {
+			return nil, nil, fmt.Errorf("no SSH key path and no SSH agent: SSH_AUTH_SOCK is not set")
+		}
+		conn, err := net.Dial("unix", sock)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to connect to SSH agent: %v", err)
+		}
+		return ssh.PublicKeysCallback(agent.NewClient(conn).Signers), func() { _ = conn.Close() }, nil
+	}
+
+	key, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read SSH key: %v", err)
+	}
+	signer, err := ssh.ParsePrivateKey(key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse SSH key %s: %v", keyPath, err)
+	}
+	return ssh.PublicKeys(signer), func() {}, nil
+}
+
+// hostKeyCallback returns the host key verification against the known hosts, unless it is disabled
+func hostKeyCallback(h *Host) (ssh.HostKeyCallback, error) {
+	if h.InsecureIgnoreHostKey {
+		klog.Warningf("Skipping the host key verification of %s", h.Addr)
+		return ssh.InsecureIgnoreHostKey(), nil
+	}
+
+	callback, err := knownhosts.New(h.KnownHosts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read known hosts: %v", err)
+	}
+	return callback, nil
+}
+
+// run executes the command in a new session, returning its stdout and reporting its stderr on failure
+func run(client *ssh.Client, cmd string, stdin []byte) ([]byte, error) {
+	session, err := client.NewSession()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open SSH session: %v", err)
+	}
+	defer func() { _ = session.Close() }()
+
+	var stdout, stderr bytes.Buffer
+	session.Stdout = &stdout
+	session.Stderr = &stderr
+	if stdin != nil {
+		session.Stdin = bytes.NewReader(stdin)
+	}
+
+	if err = session.Run(cmd); err != nil {
+		return nil, fmt.Errorf("remote command %q failed: %v: %s", cmd, err, strings.TrimSpace(stderr.String()))
+	}
+
+	return stdout.Bytes(), nil
+}
+
+// quote returns the string quoted for the POSIX shell
+func quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,369 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package remote
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+
+	"github.com/NVIDIA/topograph/pkg/ib"
+)
+
+// testServer is an SSH server running the exec requests with the local shell
+type testServer struct {
+	listener net.Listener
+	config   *ssh.ServerConfig
+	hostKey  ssh.PublicKey
+}
+
+func newTestServer(t *testing.T, clientKey ssh.PublicKey) *testServer {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	signer, err := ssh.NewSignerFromKey(priv)
+	require.NoError(t, err)
+
+	config := &ssh.ServerConfig{
+		PublicKeyCallback: func(_ ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if string(key.Marshal()) == string(clientKey.Marshal()) {
+				return nil, nil
+			}
+			return nil, errors.New("unknown key")
+		},
+	}
+	config.AddHostKey(signer)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+
+	s := &testServer{listener: listener, config: config, hostKey: signer.PublicKey()}
+	go s.serve()
+	return s
+}
+
+func (s *testServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *testServer) handle(conn net.Conn) {
+	_, chans, reqs, err := ssh.NewServerConn(conn, s.config)
+	if err != nil {
+		_ = conn.Close()
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+
+	for newChannel := range chans {
+		if newChannel.ChannelType() != "session" {
+			_ = newChannel.Reject(ssh.UnknownChannelType, "unsupported channel type")
+			continue
+		}
+		channel, requests, err := newChannel.Accept()
+		if err != nil {
+			continue
+		}
+		go func() {
+			defer func() { _ = channel.Close() }()
+			for req := range requests {
+				if req.Type != "exec" {
+					_ = req.Reply(false, nil)
+					continue
+				}
+				var payload struct{ Command string }
+				if err := ssh.Unmarshal(req.Payload, &payload); err != nil {
+					_ = req.Reply(false, nil)
+					return
+				}
+				_ = req.Reply(true, nil)
+
+				cmd := exec.Command("sh", "-c", payload.Command)
+				cmd.Stdin = channel
+				cmd.Stdout = channel
+				cmd.Stderr = channel.Stderr()
+				var status uint32
+				if err := cmd.Run(); err != nil {
+					status = 1
+					var exitErr *exec.ExitError
+					if errors.As(err, &exitErr) {
+						status = uint32(exitErr.ExitCode())
+					}
+				}
+				_, _ = channel.SendRequest("exit-status", false, binary.BigEndian.AppendUint32(nil, status))
+				return
+			}
+		}()
+	}
+}
+
+// newTestClientKey returns the client key, written to the key file in the directory
+func newTestClientKey(t *testing.T, dir string) (ed25519.PrivateKey, string) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	block, err := ssh.MarshalPrivateKey(priv, "")
+	require.NoError(t, err)
+	keyPath := filepath.Join(dir, "id_ed25519")
+	require.NoError(t, os.WriteFile(keyPath, pem.EncodeToMemory(block), 0600))
+	return priv, keyPath
+}
+
+// writeKnownHosts writes the known_hosts file with the host key of the address, and the file with another key
+func writeKnownHosts(t *testing.T, dir, addr string, hostKey ssh.PublicKey) (string, string) {
+	knownHosts := filepath.Join(dir, "known_hosts")
+	require.NoError(t, os.WriteFile(knownHosts, []byte(knownhosts.Line([]string{addr}, hostKey)+"\n"), 0600))
+
+	_, otherPriv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	otherSigner, err := ssh.NewSignerFromKey(otherPriv)
+	require.NoError(t, err)
+	otherHosts := filepath.Join(dir, "other_hosts")
+	require.NoError(t, os.WriteFile(otherHosts, []byte(knownhosts.Line([]string{addr}, otherSigner.PublicKey())+"\n"), 0600))
+
+	return knownHosts, otherHosts
+}
+
+func TestPush(t *testing.T) {
+	dir := t.TempDir()
+
+	clientPriv, keyPath := newTestClientKey(t, dir)
+	clientSigner, err := ssh.NewSignerFromKey(clientPriv)
+	require.NoError(t, err)
+
+	server := newTestServer(t, clientSigner.PublicKey())
+	addr := server.listener.Addr().String()
+
+	knownHosts, otherHosts := writeKnownHosts(t, dir, addr, server.hostKey)
+
+	remotePath := filepath.Join(dir, "topology.conf")
+	marker := filepath.Join(dir, "reconfigured")
+
+	testCases := []struct {
+		name        string
+		knownHosts  string
+		postCommand string
+		post        string
+		err         string
+	}{
+		{
+			name:       "Case 1: copy",
+			knownHosts: knownHosts,
+		},
+		{
+			name:        "Case 2: copy with post command",
+			knownHosts:  knownHosts,
+			postCommand: "echo reconfigured > " + quote(marker),
+			post:        "reconfigured\n",
+		},
+		{
+			name:        "Case 3: failed post command",
+			knownHosts:  knownHosts,
+			postCommand: "echo 'invalid config' >&2; exit 3",
+			err:         `remote command "echo 'invalid config' >&2; exit 3" failed: Process exited with status 3: invalid config`,
+		},
+		{
+			name:       "Case 4: host key mismatch",
+			knownHosts: otherHosts,
+			err:        "ssh: handshake failed: knownhosts: key mismatch",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_ = os.Remove(remotePath)
+			_ = os.Remove(marker)
+
+			p := &Params{
+				Host:        addr,
+				User:        "slurm",
+				KeyPath:     keyPath,
+				KnownHosts:  tc.knownHosts,
+				RemotePath:  remotePath,
+				PostCommand: tc.postCommand,
+			}
+			data := []byte("SwitchName=S1 Nodes=n[1-2]\n")
+
+			err := Push(context.TODO(), p, data)
+			if len(tc.err) != 0 {
+				require.ErrorContains(t, err, tc.err)
+			} else {
+				require.NoError(t, err)
+			}
+
+			if tc.knownHosts == otherHosts {
+				require.NoFileExists(t, remotePath)
+				return
+			}
+
+			content, err := os.ReadFile(remotePath)
+			require.NoError(t, err)
+			require.Equal(t, data, content)
+			require.NoFileExists(t, remotePath+".topograph.tmp")
+
+			if len(tc.post) != 0 {
+				post, err := os.ReadFile(marker)
+				require.NoError(t, err)
+				require.Equal(t, tc.post, string(post))
+			}
+		})
+	}
+}
+
+func TestOutput(t *testing.T) {
+	dir := t.TempDir()
+
+	clientPriv, keyPath := newTestClientKey(t, dir)
+	clientSigner, err := ssh.NewSignerFromKey(clientPriv)
+	require.NoError(t, err)
+
+	server := newTestServer(t, clientSigner.PublicKey())
+	addr := server.listener.Addr().String()
+
+	knownHosts, otherHosts := writeKnownHosts(t, dir, addr, server.hostKey)
+
+	// SSH agent holding the client key
+	keyring := agent.NewKeyring()
+	require.NoError(t, keyring.Add(agent.AddedKey{PrivateKey: clientPriv}))
+	agentSock := filepath.Join(dir, "agent.sock")
+	agentListener, err := net.Listen("unix", agentSock)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = agentListener.Close() })
+	go func() {
+		for {
+			conn, err := agentListener.Accept()
+			if err != nil {
+				return
+			}
+			go func() { _ = agent.ServeAgent(keyring, conn) }()
+		}
+	}()
+
+	ibnetdiscover, err := filepath.Abs("../../tests/output/ibnetdiscover/example.out")
+	require.NoError(t, err)
+	expected, err := os.ReadFile(ibnetdiscover)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name      string
+		host      Host
+		agentSock string
+		cmd       string
+		timeout   time.Duration
+		output    []byte
+		err       string
+	}{
+		{
+			name:   "Case 1: command output",
+			host:   Host{Addr: addr, User: "ib", KeyPath: keyPath, KnownHosts: knownHosts},
+			cmd:    "cat " + quote(ibnetdiscover),
+			output: expected,
+		},
+		{
+			name: "Case 2: failed command",
+			host: Host{Addr: addr, User: "ib", KeyPath: keyPath, KnownHosts: knownHosts},
+			cmd:  "echo 'ibnetdiscover: command not found' >&2; exit 127",
+			err:  `remote command "echo 'ibnetdiscover: command not found' >&2; exit 127" failed: Process exited with status 127: ibnetdiscover: command not found`,
+		},
+		{
+			name: "Case 3: host key mismatch",
+			host: Host{Addr: addr, User: "ib", KeyPath: keyPath, KnownHosts: otherHosts},
+			cmd:  "echo ok",
+			err:  "ssh: handshake failed: knownhosts: key mismatch",
+		},
+		{
+			name:   "Case 4: insecure host key",
+			host:   Host{Addr: addr, User: "ib", KeyPath: keyPath, InsecureIgnoreHostKey: true},
+			cmd:    "echo ok",
+			output: []byte("ok\n"),
+		},
+		{
+			name:      "Case 5: SSH agent",
+			host:      Host{Addr: addr, User: "ib", KnownHosts: knownHosts},
+			agentSock: agentSock,
+			cmd:       "echo ok",
+			output:    []byte("ok\n"),
+		},
+		{
+			name: "Case 6: no key and no SSH agent",
+			host: Host{Addr: addr, User: "ib", KnownHosts: knownHosts},
+			cmd:  "echo ok",
+			err:  "no SSH key path and no SSH agent: SSH_AUTH_SOCK is not set",
+		},
+		{
+			name:    "Case 7: timeout",
+			host:    Host{Addr: addr, User: "ib", KeyPath: keyPath, KnownHosts: knownHosts},
+			cmd:     "sleep 5",
+			timeout: 100 * time.Millisecond,
+			err:     `remote command "sleep 5" interrupted: context deadline exceeded`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("SSH_AUTH_SOCK", tc.agentSock)
+
+			ctx := context.TODO()
+			if tc.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tc.timeout)
+				defer cancel()
+			}
+
+			output, err := Output(ctx, &tc.host, tc.cmd)
+			if len(tc.err) != 0 {
+				require.ErrorContains(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.output, output)
+		})
+	}
+
+	// the remote output is parsed like the local one
+	output, err := Output(context.TODO(), &Host{Addr: addr, User: "ib", KeyPath: keyPath, KnownHosts: knownHosts}, "cat "+quote(ibnetdiscover))
+	require.NoError(t, err)
+	switches, hca, err := ib.ParseIbnetdiscoverFile(output)
+	require.NoError(t, err)
+	expectedSwitches, expectedHCA, err := ib.ParseIbnetdiscoverFile(expected)
+	require.NoError(t, err)
+	require.NotEmpty(t, switches)
+	require.Equal(t, expectedSwitches, switches)
+	require.Equal(t, expectedHCA, hca)
+}
+
+func TestQuote(t *testing.T) {
+	require.Equal(t, `'/etc/slurm/topology.conf'`, quote("/etc/slurm/topology.conf"))
+	require.Equal(t, `'it'\''s'`, quote("it's"))
+}
@@ -20,33 +20,68 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"sort"
+	"strings"
 	"time"
 
 	"gopkg.in/yaml.v3"
 	"k8s.io/klog/v2"
 
 	"github.com/NVIDIA/topograph/internal/files"
+	"github.com/NVIDIA/topograph/pkg/models"
 	"github.com/NVIDIA/topograph/pkg/registry"
 )
 
+// DefaultProviderTimeout is the default deadline of the provider topology request
+const DefaultProviderTimeout = 10 * time.Minute
+
+// DefaultProviderCacheMaxEntries is the default maximum number of provider topologies kept in the cache
+const DefaultProviderCacheMaxEntries = 32
+
 type Config struct {
 	HTTP                    Endpoint          `yaml:"http"`
+	GRPC                    *Endpoint         `yaml:"grpc,omitempty"`
 	RequestAggregationDelay time.Duration     `yaml:"request_aggregation_delay"`
+	RequestHistoryTTL       time.Duration     `yaml:"request_history_ttl,omitempty"`
 	Provider                string            `yaml:"provider,omitempty"`
 	Engine                  string            `yaml:"engine,omitempty"`
 	PageSize                *int              `yaml:"page_size,omitempty"`
 	SSL                     *SSL              `yaml:"ssl,omitempty"`
 	CredsPath               *string           `yaml:"credentials_path,omitempty"`
+	CredsSecret             *string           `yaml:"credentials_secret,omitempty"`
 	FwdSvcURL               *string           `yaml:"forward_service_url,omitempty"`
+	Notify                  *Notify           `yaml:"notify,omitempty"`
 	Env                     map[string]string `yaml:"env"`
+	AllowArbitraryEnv       bool              `yaml:"allow_arbitrary_env,omitempty"`
+	Models                  *models.Limits    `yaml:"models,omitempty"`
+	ProviderTimeout         time.Duration     `yaml:"provider_timeout,omitempty"`
+	Snapshot                *Snapshot         `yaml:"snapshot,omitempty"`
+	ProviderCacheTTL        time.Duration     `yaml:"provider_cache_ttl,omitempty"`
+	ProviderCacheMaxEntries int               `yaml:"provider_cache_max_entries,omitempty"`
 
 	// derived
 	Credentials map[string]string
 }
 
+// allowedEnv lists the environment variables settable without allow_arbitrary_env
+var allowedEnv = map[string]bool{
+	"SLURM_CONF":  true,
+	"PATH":        true,
+	"HTTP_PROXY":  true,
+	"HTTPS_PROXY": true,
+	"NO_PROXY":    true,
+	"http_proxy":  true,
+	"https_proxy": true,
+	"no_proxy":    true,
+}
+
 type Endpoint struct {
 	Port int  `yaml:"port"`
 	SSL  bool `yaml:"ssl"`
+	// MTLS requires the clients to present a certificate signed by the CA certificate of the ssl section
+	MTLS bool `yaml:"mtls,omitempty"`
+	// AllowedClients lists the common names and SANs of the client certificates allowed to access the endpoint
+	AllowedClients []string `yaml:"allowed_clients,omitempty"`
 }
 
 type SSL struct {
@@ -55,8 +90,23 @@ type SSL struct {
 	CaCert string `yaml:"ca_cert"`
 }
 
+// Notify is the webhook notified when a topology is generated
+type Notify struct {
+	URL        string            `yaml:"url"`
+	Headers    map[string]string `yaml:"headers,omitempty"`
+	Retries    int               `yaml:"retries,omitempty"`
+	RetryDelay time.Duration     `yaml:"retry_delay,omitempty"`
+}
+
+// Snapshot keeps the topology graph reported by the provider for each request, for debugging
+type Snapshot struct {
+	Dir      string        `yaml:"dir"`
+	MaxCount int           `yaml:"max_count,omitempty"`
+	MaxAge   time.Duration `yaml:"max_age,omitempty"`
+}
+
 func NewFromFile(fname string) (*Config, error) {
-	data, err := os.ReadFile(fname)
+	data, err := files.ReadFile(fname)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read %s: %v", fname, err)
 	}
@@ -79,6 +129,15 @@ func (cfg *Config) validate() error {
 		return fmt.Errorf("port is not set")
 	}
 
+	if cfg.GRPC != nil {
+		if cfg.GRPC.Port == 0 {
+			return fmt.Errorf("grpc port is not set")
+		}
+		if cfg.GRPC.Port == cfg.HTTP.Port {
+			return fmt.Errorf("grpc port %d is used by the http server", cfg.GRPC.Port)
+		}
+	}
+
 	if cfg.Provider != "" {
 		_, ok := registry.Providers[cfg.Provider]
 		if !ok {
@@ -97,7 +156,76 @@ func (cfg *Config) validate() error {
 		return fmt.Errorf("request_aggregation_delay is not set")
 	}
 
-	if cfg.HTTP.SSL {
+	if cfg.ProviderTimeout < 0 {
+		return fmt.Errorf("provider_timeout must not be negative")
+	}
+	if cfg.ProviderTimeout == 0 {
+		cfg.ProviderTimeout = DefaultProviderTimeout
+	}
+
+	if cfg.ProviderCacheTTL < 0 {
+		return fmt.Errorf("provider_cache_ttl must not be negative")
+	}
+	if cfg.ProviderCacheMaxEntries < 0 {
+		return fmt.Errorf("provider_cache_max_entries must not be negative")
+	}
+	if cfg.ProviderCacheMaxEntries == 0 {
+		cfg.ProviderCacheMaxEntries = DefaultProviderCacheMaxEntries
+	}
+
+	if err := cfg.validateEnv(); err != nil {
+		return err
+	}
+
+	if cfg.CredsSecret != nil {
+		if cfg.CredsPath != nil {
+			return fmt.Errorf("credentials_path and credentials_secret are mutually exclusive")
+		}
+		if _, _, err := SplitSecretRef(*cfg.CredsSecret); err != nil {
+			return err
+		}
+	}
+
+	if cfg.Notify != nil && len(cfg.Notify.URL) == 0 {
+		return fmt.Errorf("missing notify url")
+	}
+
+	if cfg.Snapshot != nil {
+		if len(cfg.Snapshot.Dir) == 0 {
+			return fmt.Errorf("missing snapshot dir")
+		}
+		if cfg.Snapshot.MaxCount < 0 {
+			return fmt.Errorf("snapshot max_count must not be negative")
+		}
+		if cfg.Snapshot.MaxAge < 0 {
+			return fmt.Errorf("snapshot max_age must not be negative")
+		}
+	}
+
+	if cfg.HTTP.MTLS && !cfg.HTTP.SSL {
+		return fmt.Errorf("http mtls requires ssl")
+	}
+	if len(cfg.HTTP.AllowedClients) != 0 && !cfg.HTTP.MTLS {
+		return fmt.Errorf("http allowed_clients requires mtls")
+	}
+
+	if cfg.GRPC != nil {
+		if cfg.GRPC.MTLS && !cfg.GRPC.SSL {
+			return fmt.Errorf("grpc mtls requires ssl")
+		}
+		if len(cfg.GRPC.AllowedClients) != 0 && !cfg.GRPC.MTLS {
+			return fmt.Errorf("grpc allowed_clients requires mtls")
+		}
+		// the gRPC API must not bypass the client authentication of the HTTP API
+		if cfg.HTTP.MTLS && !cfg.GRPC.MTLS {
+			return fmt.Errorf("grpc mtls is required with http mtls")
+		}
+		if len(cfg.HTTP.AllowedClients) != 0 && len(cfg.GRPC.AllowedClients) == 0 {
+			return fmt.Errorf("grpc allowed_clients is required with http allowed_clients")
+		}
+	}
+
+	if cfg.HTTP.SSL || (cfg.GRPC != nil && cfg.GRPC.SSL) {
 		if cfg.SSL == nil {
 			return fmt.Errorf("missing ssl section")
 		}
@@ -115,20 +243,70 @@ func (cfg *Config) validate() error {
 	return cfg.readCredentials()
 }
 
-func (cfg *Config) UpdateEnv() (err error) {
-	for env, val := range cfg.Env {
+func (cfg *Config) validateEnv() error {
+	if cfg.AllowArbitraryEnv {
+		return nil
+	}
+	for env := range cfg.Env {
+		if !allowedEnv[env] {
+			return fmt.Errorf("environment variable %q is not allowed; set allow_arbitrary_env to permit it", env)
+		}
+	}
+	return nil
+}
+
+// UpdateEnv applies the configured environment variables. It is idempotent:
+// PATH is extended only with the missing segments, and unchanged variables are skipped.
+func (cfg *Config) UpdateEnv() error {
+	if err := cfg.validateEnv(); err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(cfg.Env))
+	for env := range cfg.Env {
+		keys = append(keys, env)
+	}
+	sort.Strings(keys)
+
+	for _, env := range keys {
+		val := cfg.Env[env]
 		if env == "PATH" { // special case for PATH env var
-			err = os.Setenv("PATH", fmt.Sprintf("%s:%s", os.Getenv("PATH"), val))
-		} else {
-			err = os.Setenv(env, val)
+			val = mergePath(os.Getenv("PATH"), val)
+		}
+		if val == os.Getenv(env) {
+			continue
 		}
-		if err != nil {
+		if err := os.Setenv(env, val); err != nil {
 			return fmt.Errorf("failed to set %q environment variable: %v", env, err)
 		}
-		klog.Infof("Updated env %s=%s", env, os.Getenv(env))
+		klog.Infof("Updated env %s=%s", env, val)
 	}
 
-	return
+	return nil
+}
+
+// mergePath appends the segments of ext missing in path
+func mergePath(path, ext string) string {
+	var segments []string
+	seen := make(map[string]bool)
+	for _, list := range []string{path, ext} {
+		for _, segment := range strings.Split(list, ":") {
+			if len(segment) != 0 && !seen[segment] {
+				seen[segment] = true
+				segments = append(segments, segment)
+			}
+		}
+	}
+	return strings.Join(segments, ":")
+}
+
+// SplitSecretRef returns the namespace and the name of the Secret referenced as "namespace/name"
+func SplitSecretRef(ref string) (string, string, error) {
+	namespace, name, ok := strings.Cut(ref, "/")
+	if !ok || len(namespace) == 0 || len(name) == 0 || strings.Contains(name, "/") {
+		return "", "", fmt.Errorf("invalid credentials_secret %q: expected namespace/name", ref)
+	}
+	return namespace, name, nil
 }
 
 func (cfg *Config) readCredentials() error {
@@ -103,6 +103,7 @@ func TestConfig(t *testing.T) {
 			"SLURM_CONF": "/etc/slurm/config.yaml",
 			"PATH":       "/a/b/c",
 		},
+		ProviderTimeout: DefaultProviderTimeout,
 	}
 	require.Equal(t, expected, cfg)
 
@@ -231,6 +232,274 @@ func TestValidate(t *testing.T) {
 				RequestAggregationDelay: time.Second,
 			},
 		},
+		{
+			name: "Case 6.1: disallowed env",
+			cfg: Config{
+				HTTP: Endpoint{
+					Port: 1,
+				},
+				RequestAggregationDelay: time.Second,
+				Env:                     map[string]string{"LD_PRELOAD": "/tmp/lib.so"},
+			},
+			err: `environment variable "LD_PRELOAD" is not allowed; set allow_arbitrary_env to permit it`,
+		},
+		{
+			name: "Case 6.2: arbitrary env allowed",
+			cfg: Config{
+				HTTP: Endpoint{
+					Port: 1,
+				},
+				RequestAggregationDelay: time.Second,
+				Env:                     map[string]string{"LD_PRELOAD": "/tmp/lib.so"},
+				AllowArbitraryEnv:       true,
+			},
+		},
+		{
+			name: "Case 7.1: credentials secret",
+			cfg: Config{
+				HTTP: Endpoint{
+					Port: 1,
+				},
+				RequestAggregationDelay: time.Second,
+				CredsSecret:             ptr.String("topograph/creds"),
+			},
+		},
+		{
+			name: "Case 7.2: invalid credentials secret",
+			cfg: Config{
+				HTTP: Endpoint{
+					Port: 1,
+				},
+				RequestAggregationDelay: time.Second,
+				CredsSecret:             ptr.String("creds"),
+			},
+			err: `invalid credentials_secret "creds": expected namespace/name`,
+		},
+		{
+			name: "Case 7.3: credentials path and secret",
+			cfg: Config{
+				HTTP: Endpoint{
+					Port: 1,
+				},
+				RequestAggregationDelay: time.Second,
+				CredsPath:               ptr.String("/etc/topograph/creds.yaml"),
+				CredsSecret:             ptr.String("topograph/creds"),
+			},
+			err: "credentials_path and credentials_secret are mutually exclusive",
+		},
+		{
+			name: "Case 8: negative provider timeout",
+			cfg: Config{
+				HTTP: Endpoint{
+					Port: 1,
+				},
+				RequestAggregationDelay: time.Second,
+				ProviderTimeout:         -time.Minute,
+			},
+			err: "provider_timeout must not be negative",
+		},
+		{
+			name: "Case 9.1: missing grpc port",
+			cfg: Config{
+				HTTP: Endpoint{
+					Port: 1,
+				},
+				GRPC:                    &Endpoint{},
+				RequestAggregationDelay: time.Second,
+			},
+			err: "grpc port is not set",
+		},
+		{
+			name: "Case 9.2: grpc port used by http server",
+			cfg: Config{
+				HTTP: Endpoint{
+					Port: 1,
+				},
+				GRPC: &Endpoint{
+					Port: 1,
+				},
+				RequestAggregationDelay: time.Second,
+			},
+			err: "grpc port 1 is used by the http server",
+		},
+		{
+			name: "Case 9.3: grpc with missing ssl section",
+			cfg: Config{
+				HTTP: Endpoint{
+					Port: 1,
+				},
+				GRPC: &Endpoint{
+					Port: 2,
+					SSL:  true,
+				},
+				RequestAggregationDelay: time.Second,
+			},
+			err: "missing ssl section",
+		},
+		{
+			name: "Case 9.4: valid grpc endpoint",
+			cfg: Config{
+				HTTP: Endpoint{
+					Port: 1,
+				},
+				GRPC: &Endpoint{
+					Port: 2,
+				},
+				RequestAggregationDelay: time.Second,
+			},
+		},
+		{
+			name: "Case 10.1: missing snapshot dir",
+			cfg: Config{
+				HTTP: Endpoint{
+					Port: 1,
+				},
+				RequestAggregationDelay: time.Second,
+				Snapshot:                &Snapshot{},
+			},
+			err: "missing snapshot dir",
+		},
+		{
+			name: "Case 10.2: negative snapshot max_count",
+			cfg: Config{
+				HTTP: Endpoint{
+					Port: 1,
+				},
+				RequestAggregationDelay: time.Second,
+				Snapshot:                &Snapshot{Dir: "/tmp", MaxCount: -1},
+			},
+			err: "snapshot max_count must not be negative",
+		},
+		{
+			name: "Case 10.3: negative snapshot max_age",
+			cfg: Config{
+				HTTP: Endpoint{
+					Port: 1,
+				},
+				RequestAggregationDelay: time.Second,
+				Snapshot:                &Snapshot{Dir: "/tmp", MaxAge: -time.Second},
+			},
+			err: "snapshot max_age must not be negative",
+		},
+		{
+			name: "Case 10.4: valid snapshot",
+			cfg: Config{
+				HTTP: Endpoint{
+					Port: 1,
+				},
+				RequestAggregationDelay: time.Second,
+				Snapshot:                &Snapshot{Dir: "/tmp", MaxCount: 10, MaxAge: time.Hour},
+			},
+		},
+		{
+			name: "Case 11.1: mtls without ssl",
+			cfg: Config{
+				HTTP: Endpoint{
+					Port: 1,
+					MTLS: true,
+				},
+				RequestAggregationDelay: time.Second,
+			},
+			err: "http mtls requires ssl",
+		},
+		{
+			name: "Case 11.2: allowed clients without mtls",
+			cfg: Config{
+				HTTP: Endpoint{
+					Port:           1,
+					SSL:            true,
+					AllowedClients: []string{"slurmctld"},
+				},
+				RequestAggregationDelay: time.Second,
+			},
+			err: "http allowed_clients requires mtls",
+		},
+		{
+			name: "Case 11.3: grpc mtls without ssl",
+			cfg: Config{
+				HTTP: Endpoint{
+					Port: 1,
+				},
+				GRPC: &Endpoint{
+					Port: 2,
+					MTLS: true,
+				},
+				RequestAggregationDelay: time.Second,
+			},
+			err: "grpc mtls requires ssl",
+		},
+		{
+			name: "Case 11.4: grpc allowed clients without mtls",
+			cfg: Config{
+				HTTP: Endpoint{
+					Port: 1,
+				},
+				GRPC: &Endpoint{
+					Port:           2,
+					SSL:            true,
+					AllowedClients: []string{"slurmctld"},
+				},
+				RequestAggregationDelay: time.Second,
+			},
+			err: "grpc allowed_clients requires mtls",
+		},
+		{
+			name: "Case 11.5: http mtls with grpc without mtls",
+			cfg: Config{
+				HTTP: Endpoint{
+					Port: 1,
+					SSL:  true,
+					MTLS: true,
+				},
+				GRPC: &Endpoint{
+					Port: 2,
+					SSL:  true,
+				},
+				RequestAggregationDelay: time.Second,
+			},
+			err: "grpc mtls is required with http mtls",
+		},
+		{
+			name: "Case 11.6: http allowed clients with grpc without allowed clients",
+			cfg: Config{
+				HTTP: Endpoint{
+					Port:           1,
+					SSL:            true,
+					MTLS:           true,
+					AllowedClients: []string{"slurmctld"},
+				},
+				GRPC: &Endpoint{
+					Port: 2,
+					SSL:  true,
+					MTLS: true,
+				},
+				RequestAggregationDelay: time.Second,
+			},
+			err: "grpc allowed_clients is required with http allowed_clients",
+		},
+		{
+			name: "Case 12: negative provider cache ttl",
+			cfg: Config{
+				HTTP: Endpoint{
+					Port: 1,
+				},
+				RequestAggregationDelay: time.Second,
+				ProviderCacheTTL:        -time.Minute,
+			},
+			err: "provider_cache_ttl must not be negative",
+		},
+		{
+			name: "Case 13: negative provider cache max entries",
+			cfg: Config{
+				HTTP: Endpoint{
+					Port: 1,
+				},
+				RequestAggregationDelay: time.Second,
+				ProviderCacheTTL:        time.Minute,
+				ProviderCacheMaxEntries: -1,
+			},
+			err: "provider_cache_max_entries must not be negative",
+		},
 	}
 
 	for _, tc := range testCases {
@@ -244,3 +513,57 @@ func TestValidate(t *testing.T) {
 		})
 	}
 }
+
+func TestUpdateEnv(t *testing.T) {
+	t.Setenv("PATH", "/usr/bin:/bin")
+	t.Setenv("SLURM_CONF", "")
+
+	cfg := &Config{
+		Env: map[string]string{
+			"SLURM_CONF": "/etc/slurm/slurm.conf",
+			"PATH":       "/opt/bin:/usr/bin:/opt/bin",
+		},
+	}
+
+	// repeated application does not change the environment
+	for i := 0; i < 3; i++ {
+		require.NoError(t, cfg.UpdateEnv())
+		require.Equal(t, "/etc/slurm/slurm.conf", os.Getenv("SLURM_CONF"))
+		require.Equal(t, "/usr/bin:/bin:/opt/bin", os.Getenv("PATH"))
+	}
+
+	cfg.Env["LD_PRELOAD"] = "/tmp/lib.so"
+	require.EqualError(t, cfg.UpdateEnv(), `environment variable "LD_PRELOAD" is not allowed; set allow_arbitrary_env to permit it`)
+}
+
+func TestMergePath(t *testing.T) {
+	testCases := []struct {
+		name      string
+		path, ext string
+		merged    string
+	}{
+		{
+			name:   "Case 1: empty path",
+			ext:    "/a/b/c",
+			merged: "/a/b/c",
+		},
+		{
+			name:   "Case 2: new segments",
+			path:   "/usr/bin:/bin",
+			ext:    "/a/b/c:/d",
+			merged: "/usr/bin:/bin:/a/b/c:/d",
+		},
+		{
+			name:   "Case 3: duplicate segments",
+			path:   "/usr/bin:/bin:/usr/bin",
+			ext:    "/bin:/a/b/c:/a/b/c:",
+			merged: "/usr/bin:/bin:/a/b/c",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.merged, mergePath(tc.path, tc.ext))
+		})
+	}
+}
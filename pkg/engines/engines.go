@@ -32,17 +32,79 @@ type Engine interface {
 
 type Environment interface{}
 
-type Config = struct{}
+// ConfigDiffer is optionally implemented by the engines that keep the applied topology config,
+// so that it can be compared with a newly generated one
+type ConfigDiffer interface {
+	// TopologyConfigs returns the applied topology config, or nil if there is none,
+	// and the config generated from the graph without applying it
+	TopologyConfigs(ctx context.Context, vertex *topology.Vertex, params map[string]any) ([]byte, []byte, error)
+}
+
+// ConfigRollbacker is optionally implemented by the engines that keep the previous revisions of the applied topology config
+type ConfigRollbacker interface {
+	// RollbackTopologyConfig restores the revision of the applied topology config, where revision 1 is the previous config
+	RollbackTopologyConfig(ctx context.Context, params map[string]any, revision int) error
+}
+
+// Config is the engine configuration of the topology request
+type Config struct {
+	// Params are the engine parameters of the request
+	Params map[string]any
+}
+
 type NamedLoader = component.NamedLoader[Engine, Config]
 type Loader = component.Loader[Engine, Config]
 type Registry component.Registry[Engine, Config]
 
-var ErrUnsupportedEngine = errors.New("unsupported engine")
+var (
+	ErrUnsupportedEngine = errors.New("unsupported engine")
+	// ErrTopologyValidation indicates that the generated topology does not match the cluster
+	ErrTopologyValidation = errors.New("topology validation failed")
+	// ErrRevisionNotFound indicates that the requested revision of the topology config is not kept
+	ErrRevisionNotFound = errors.New("topology config revision not found")
+	// ErrInvalidParams indicates that the engine parameters of the request are invalid or incomplete
+	ErrInvalidParams = errors.New("invalid engine parameters")
+)
+
+type ctxKey int
+
+const (
+	requestUIDKey ctxKey = iota
+	requestNodesKey
+)
+
+// WithRequestUID returns a copy of ctx carrying the UID of the topology request
+func WithRequestUID(ctx context.Context, uid string) context.Context {
+	return context.WithValue(ctx, requestUIDKey, uid)
+}
+
+// RequestUID returns the UID of the topology request carried by ctx, if any
+func RequestUID(ctx context.Context) string {
+	uid, _ := ctx.Value(requestUIDKey).(string)
+	return uid
+}
+
+// WithRequestNodes returns a copy of ctx carrying the names of the nodes the topology request is limited to
+func WithRequestNodes(ctx context.Context, nodes []string) context.Context {
+	return context.WithValue(ctx, requestNodesKey, nodes)
+}
+
+// RequestNodes returns the names of the nodes the topology request carried by ctx is limited to,
+// or nil if the request covers the whole cluster
+func RequestNodes(ctx context.Context) []string {
+	nodes, _ := ctx.Value(requestNodesKey).([]string)
+	return nodes
+}
 
 func NewRegistry(namedLoaders ...NamedLoader) Registry {
 	return Registry(component.NewRegistry(namedLoaders...))
 }
 
+// Register adds the named loaders to the registry
+func (r Registry) Register(namedLoaders ...NamedLoader) {
+	component.Registry[Engine, Config](r).Register(namedLoaders...)
+}
+
 func (r Registry) Get(name string) (Loader, error) {
 	loader, ok := r[name]
 	if !ok {
@@ -0,0 +1,178 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+// Package fake provides a configurable implementation of engines.Engine for tests.
+package fake
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/NVIDIA/topograph/internal/component"
+	"github.com/NVIDIA/topograph/pkg/engines"
+	"github.com/NVIDIA/topograph/pkg/topology"
+	"github.com/NVIDIA/topograph/pkg/translate"
+)
+
+// OutputCall is a recorded call of GenerateOutput
+type OutputCall struct {
+	Root   *topology.Vertex
+	Params map[string]any
+}
+
+// Engine returns the configured compute instances and output,
+// and records the calls of GetComputeInstances and GenerateOutput
+type Engine struct {
+	mutex        sync.Mutex
+	instances    []topology.ComputeInstances
+	output       []byte
+	err          error
+	computeCalls int
+	outputCalls  []OutputCall
+	// applied is the topology config returned by TopologyConfigs as applied
+	applied []byte
+	// revisions are the previous topology configs restored by RollbackTopologyConfig, most recent first
+	revisions [][]byte
+}
+
+// New returns an engine with "OK\n" output
+func New() *Engine {
+	return &Engine{output: []byte("OK\n")}
+}
+
+// WithComputeInstances sets the compute instances returned by GetComputeInstances
+func (e *Engine) WithComputeInstances(cis ...topology.ComputeInstances) *Engine {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	e.instances = cis
+	return e
+}
+
+// WithOutput sets the result of GenerateOutput
+func (e *Engine) WithOutput(data []byte, err error) *Engine {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	e.output, e.err = data, err
+	return e
+}
+
+// WithAppliedConfig sets the applied topology config returned by TopologyConfigs
+func (e *Engine) WithAppliedConfig(data []byte) *Engine {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	e.applied = data
+	return e
+}
+
+// WithRevisions sets the previous topology configs, most recent first, restored by RollbackTopologyConfig
+func (e *Engine) WithRevisions(configs ...[]byte) *Engine {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	e.revisions = configs
+	return e
+}
+
+// NamedLoader returns the loader of this engine instance under the given name
+func (e *Engine) NamedLoader(name string) engines.NamedLoader {
+	return component.Named(name, func(context.Context, engines.Config) (engines.Engine, error) {
+		return e, nil
+	})
+}
+
+// GetComputeInstances implements engines.Engine
+func (e *Engine) GetComputeInstances(_ context.Context, _ engines.Environment) ([]topology.ComputeInstances, error) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	e.computeCalls++
+	return e.instances, nil
+}
+
+// GenerateOutput implements engines.Engine
+func (e *Engine) GenerateOutput(_ context.Context, root *topology.Vertex, params map[string]any) ([]byte, error) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	e.outputCalls = append(e.outputCalls, OutputCall{Root: root, Params: params})
+	return e.output, e.err
+}
+
+// TopologyConfigs implements engines.ConfigDiffer. The generated config is the conf format of the graph.
+// It fails with the error set by WithOutput.
+func (e *Engine) TopologyConfigs(ctx context.Context, root *topology.Vertex, _ map[string]any) ([]byte, []byte, error) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if e.err != nil {
+		return nil, nil, e.err
+	}
+
+	buf := &bytes.Buffer{}
+	if err := translate.Write(ctx, buf, root); err != nil {
+		return nil, nil, err
+	}
+	return e.applied, buf.Bytes(), nil
+}
+
+// RollbackTopologyConfig implements engines.ConfigRollbacker. The restored revision becomes the applied config.
+func (e *Engine) RollbackTopologyConfig(_ context.Context, _ map[string]any, revision int) error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if revision < 1 || revision > len(e.revisions) {
+		return fmt.Errorf("%w: no revision %d", engines.ErrRevisionNotFound, revision)
+	}
+	e.applied = e.revisions[revision-1]
+	return nil
+}
+
+// AppliedConfig returns the applied topology config
+func (e *Engine) AppliedConfig() []byte {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	return e.applied
+}
+
+// ComputeInstancesCalls returns the number of GetComputeInstances calls
+func (e *Engine) ComputeInstancesCalls() int {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	return e.computeCalls
+}
+
+// OutputCalls returns the recorded GenerateOutput calls
+func (e *Engine) OutputCalls() []OutputCall {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	return append([]OutputCall{}, e.outputCalls...)
+}
+
+// RequireOutputCalls fails the test unless GenerateOutput was called n times
+func (e *Engine) RequireOutputCalls(t testing.TB, n int) {
+	t.Helper()
+	if calls := len(e.OutputCalls()); calls != n {
+		t.Fatalf("expected %d GenerateOutput calls, got %d", n, calls)
+	}
+}
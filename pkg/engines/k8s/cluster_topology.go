@@ -0,0 +1,181 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+
+	"github.com/NVIDIA/topograph/pkg/topology"
+)
+
+// engine output modes
+const (
+	OutputLabels = "labels"
+	OutputCRD    = "crd"
+
+	DefaultClusterTopologyName = "default"
+)
+
+var ClusterTopologyGVR = schema.GroupVersionResource{
+	Group:    "topology.nvidia.com",
+	Version:  "v1alpha1",
+	Resource: "clustertopologies",
+}
+
+// ClusterTopology is the cluster-scoped custom resource describing the network topology
+type ClusterTopology struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ClusterTopologySpec `json:"spec"`
+}
+
+// ClusterTopologySpec lists the switch tiers, starting from the leaf switches, and the accelerator blocks
+type ClusterTopologySpec struct {
+	Tiers  []TopologyTier  `json:"tiers"`
+	Blocks []TopologyBlock `json:"blocks,omitempty"`
+}
+
+// TopologyTier is a level of the switch hierarchy; level 1 holds the leaf switches
+type TopologyTier struct {
+	Level    int              `json:"level"`
+	Switches []TopologySwitch `json:"switches"`
+}
+
+// TopologySwitch is a network switch with either child switches or nodes
+type TopologySwitch struct {
+	Name     string   `json:"name"`
+	Children []string `json:"children,omitempty"`
+	Nodes    []string `json:"nodes,omitempty"`
+}
+
+// TopologyBlock is an accelerator domain
+type TopologyBlock struct {
+	Name  string   `json:"name"`
+	Nodes []string `json:"nodes"`
+}
+
+// NewClusterTopology converts the topology graph into the ClusterTopology resource
+func NewClusterTopology(name string, root *topology.Vertex) *ClusterTopology {
+	ct := &ClusterTopology{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: ClusterTopologyGVR.GroupVersion().String(),
+			Kind:       "ClusterTopology",
+		},
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec:       ClusterTopologySpec{Tiers: []TopologyTier{}},
+	}
+
+	if treeRoot, ok := root.Vertices[topology.TopologyTree]; ok {
+		tiers := make(map[int][]TopologySwitch)
+		levels := make(map[string]int)
+		for _, sw := range treeRoot.Vertices {
+			// nodes without topology are not part of the switch hierarchy
+			if topology.IsNoTopology(sw.ID) || len(sw.Vertices) == 0 {
+				continue
+			}
+			addTopologySwitch(sw, tiers, levels)
+		}
+		for level := 1; level <= len(tiers); level++ {
+			switches := tiers[level]
+			sort.Slice(switches, func(i, j int) bool { return switches[i].Name < switches[j].Name })
+			ct.Spec.Tiers = append(ct.Spec.Tiers, TopologyTier{Level: level, Switches: switches})
+		}
+	}
+
+	if blockRoot, ok := root.Vertices[topology.TopologyBlock]; ok {
+		for _, block := range blockRoot.Vertices {
+			nodes := make([]string, 0, len(block.Vertices))
+			for _, node := range block.Vertices {
+				nodes = append(nodes, node.Name)
+			}
+			sort.Strings(nodes)
+			ct.Spec.Blocks = append(ct.Spec.Blocks, TopologyBlock{Name: block.ID, Nodes: nodes})
+		}
+		sort.Slice(ct.Spec.Blocks, func(i, j int) bool { return ct.Spec.Blocks[i].Name < ct.Spec.Blocks[j].Name })
+	}
+
+	return ct
+}
+
+// addTopologySwitch adds the switch and its descendants to the tiers, and returns the switch level
+func addTopologySwitch(v *topology.Vertex, tiers map[int][]TopologySwitch, levels map[string]int) int {
+	if level, ok := levels[v.ID]; ok {
+		return level
+	}
+
+	sw := TopologySwitch{Name: v.ID}
+	level := 1
+	for _, w := range v.Vertices {
+		if len(w.Vertices) == 0 {
+			sw.Nodes = append(sw.Nodes, w.Name)
+			continue
+		}
+		sw.Children = append(sw.Children, w.ID)
+		if l := addTopologySwitch(w, tiers, levels) + 1; l > level {
+			level = l
+		}
+	}
+	sort.Strings(sw.Children)
+	sort.Strings(sw.Nodes)
+
+	levels[v.ID] = level
+	tiers[level] = append(tiers[level], sw)
+
+	return level
+}
+
+// ApplyClusterTopology creates or updates the ClusterTopology resource
+func ApplyClusterTopology(ctx context.Context, client dynamic.Interface, ct *ClusterTopology) error {
+	klog.Infof("Updating cluster topology %s", ct.Name)
+
+	obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(ct)
+	if err != nil {
+		return fmt.Errorf("failed to convert cluster topology %s: %w", ct.Name, err)
+	}
+	u := &unstructured.Unstructured{Object: obj}
+	res := client.Resource(ClusterTopologyGVR)
+
+	err = retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		existing, err := res.Get(ctx, ct.Name, metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			_, err = res.Create(ctx, u, metav1.CreateOptions{})
+			return err
+		}
+		if err != nil {
+			return err
+		}
+		u.SetResourceVersion(existing.GetResourceVersion())
+		_, err = res.Update(ctx, u, metav1.UpdateOptions{})
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to apply cluster topology %s: %w", ct.Name, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,91 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package k8s
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+
+	"github.com/NVIDIA/topograph/internal/fixtures"
+	"github.com/NVIDIA/topograph/pkg/topology"
+)
+
+func TestNewClusterTopology(t *testing.T) {
+	root, _ := fixtures.TreeTestSet()
+	root.Vertices[topology.TopologyTree].Vertices[topology.NoTopology] = &topology.Vertex{
+		ID: topology.NoTopology,
+		Vertices: map[string]*topology.Vertex{
+			"I90": {ID: "I90", Name: "Node900"},
+		},
+	}
+
+	expected := ClusterTopologySpec{
+		Tiers: []TopologyTier{
+			{
+				Level: 1,
+				Switches: []TopologySwitch{
+					{Name: "S2", Nodes: []string{"Node201", "Node202", "Node205"}},
+					{Name: "S3", Nodes: []string{"Node304", "Node305", "Node306"}},
+				},
+			},
+			{
+				Level: 2,
+				Switches: []TopologySwitch{
+					{Name: "S1", Children: []string{"S2", "S3"}},
+				},
+			},
+		},
+	}
+
+	ct := NewClusterTopology("default", root)
+	require.Equal(t, "topology.nvidia.com/v1alpha1", ct.APIVersion)
+	require.Equal(t, "ClusterTopology", ct.Kind)
+	require.Equal(t, "default", ct.Name)
+	require.Equal(t, expected, ct.Spec)
+}
+
+func TestApplyClusterTopology(t *testing.T) {
+	ctx := context.TODO()
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{ClusterTopologyGVR: "ClusterTopologyList"})
+
+	get := func() *ClusterTopology {
+		u, err := client.Resource(ClusterTopologyGVR).Get(ctx, "default", metav1.GetOptions{})
+		require.NoError(t, err)
+		ct := &ClusterTopology{}
+		require.NoError(t, runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, ct))
+		return ct
+	}
+
+	// create
+	root, _ := fixtures.TreeTestSet()
+	ct := NewClusterTopology("default", root)
+	require.NoError(t, ApplyClusterTopology(ctx, client, ct))
+	require.Equal(t, ct.Spec, get().Spec)
+
+	// update
+	root, _ = fixtures.TreeTestSet(fixtures.WithLongSwitchNames())
+	ct = NewClusterTopology("default", root)
+	require.NoError(t, ApplyClusterTopology(ctx, client, ct))
+	require.Equal(t, ct.Spec, get().Spec)
+	require.Equal(t, fixtures.LongSwitchName, get().Spec.Tiers[0].Switches[1].Name)
+}
@@ -0,0 +1,102 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"maps"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+)
+
+// ApplyConfigMap creates or updates the ConfigMap, and returns false if the data is unchanged.
+// It tolerates the ConfigMap being deleted or created concurrently,
+// and retries with backoff on update conflicts.
+func ApplyConfigMap(ctx context.Context, client kubernetes.Interface, cm *v1.ConfigMap) (bool, error) {
+	changed := true
+	err := retry.OnError(retry.DefaultBackoff, errors.IsConflict, func() (err error) {
+		changed, err = applyConfigMap(ctx, client, cm)
+		return err
+	})
+	return changed, err
+}
+
+func applyConfigMap(ctx context.Context, client kubernetes.Interface, cm *v1.ConfigMap) (bool, error) {
+	cms := client.CoreV1().ConfigMaps(cm.Namespace)
+
+	existing, err := cms.Get(ctx, cm.Name, metav1.GetOptions{})
+	switch {
+	case err == nil:
+		if maps.Equal(existing.Data, cm.Data) {
+			klog.Infof("Configmap %s/%s unchanged", cm.Namespace, cm.Name)
+			return false, nil
+		}
+		_, err = cms.Update(ctx, cm, metav1.UpdateOptions{})
+		if err == nil {
+			klog.V(4).Infof("Successfully updated configmap %s/%s", cm.Namespace, cm.Name)
+			return true, nil
+		}
+		if !errors.IsNotFound(err) {
+			return false, fmt.Errorf("failed to update configmap %s/%s: %w", cm.Namespace, cm.Name, err)
+		}
+		// the configmap was deleted after the lookup
+		klog.Infof("Configmap %s/%s was deleted during update; creating", cm.Namespace, cm.Name)
+	case errors.IsNotFound(err):
+	default:
+		return false, fmt.Errorf("failed to get configmap %s/%s: %w", cm.Namespace, cm.Name, err)
+	}
+
+	_, err = cms.Create(ctx, cm, metav1.CreateOptions{})
+	if err == nil {
+		klog.V(4).Infof("Successfully created configmap %s/%s", cm.Namespace, cm.Name)
+		return true, nil
+	}
+	if !errors.IsAlreadyExists(err) {
+		return false, fmt.Errorf("failed to create configmap %s/%s: %w", cm.Namespace, cm.Name, err)
+	}
+
+	// the configmap was created after the lookup
+	klog.Infof("Configmap %s/%s was created concurrently; updating", cm.Namespace, cm.Name)
+	if _, err = cms.Update(ctx, cm, metav1.UpdateOptions{}); err != nil {
+		return false, fmt.Errorf("failed to update configmap %s/%s: %w", cm.Namespace, cm.Name, err)
+	}
+	klog.V(4).Infof("Successfully updated configmap %s/%s", cm.Namespace, cm.Name)
+
+	return true, nil
+}
+
+// ReadConfigMapData returns the value of the key in the ConfigMap, or nil if the ConfigMap or the key does not exist
+func ReadConfigMapData(ctx context.Context, client kubernetes.Interface, name, namespace, key string) ([]byte, error) {
+	cm, err := client.CoreV1().ConfigMaps(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get configmap %s/%s: %w", namespace, name, err)
+	}
+
+	data, ok := cm.Data[key]
+	if !ok {
+		return nil, nil
+	}
+	return []byte(data), nil
+}
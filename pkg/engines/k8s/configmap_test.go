@@ -0,0 +1,177 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestApplyConfigMap(t *testing.T) {
+	gr := schema.GroupResource{Resource: "configmaps"}
+	gvr := schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+	newCM := func(val string) *v1.ConfigMap {
+		return &v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "topology", Namespace: "default"},
+			Data:       map[string]string{"key": val},
+		}
+	}
+
+	testCases := []struct {
+		name     string
+		existing []runtime.Object
+		reactor  func(client *fake.Clientset) (string, k8stesting.ReactionFunc)
+		changed  bool
+		err      string
+	}{
+		{
+			name:    "Case 1: create missing configmap",
+			changed: true,
+		},
+		{
+			name:     "Case 2: update existing configmap",
+			existing: []runtime.Object{newCM("old")},
+			changed:  true,
+		},
+		{
+			name:     "Case 2.1: unchanged configmap",
+			existing: []runtime.Object{newCM("new")},
+			reactor: func(client *fake.Clientset) (string, k8stesting.ReactionFunc) {
+				return "update", func(action k8stesting.Action) (bool, runtime.Object, error) {
+					return true, nil, fmt.Errorf("unexpected update of unchanged configmap")
+				}
+			},
+		},
+		{
+			name:     "Case 3: configmap deleted before update",
+			existing: []runtime.Object{newCM("old")},
+			reactor: func(client *fake.Clientset) (string, k8stesting.ReactionFunc) {
+				return "update", func(action k8stesting.Action) (bool, runtime.Object, error) {
+					require.NoError(t, client.Tracker().Delete(gvr, "default", "topology"))
+					return true, nil, errors.NewNotFound(gr, "topology")
+				}
+			},
+			changed: true,
+		},
+		{
+			name: "Case 4: configmap created before create",
+			reactor: func(client *fake.Clientset) (string, k8stesting.ReactionFunc) {
+				return "create", func(action k8stesting.Action) (bool, runtime.Object, error) {
+					require.NoError(t, client.Tracker().Add(newCM("other")))
+					return true, nil, errors.NewAlreadyExists(gr, "topology")
+				}
+			},
+			changed: true,
+		},
+		{
+			name:     "Case 5: update conflict",
+			existing: []runtime.Object{newCM("old")},
+			reactor: func(client *fake.Clientset) (string, k8stesting.ReactionFunc) {
+				conflicts := 2
+				return "update", func(action k8stesting.Action) (bool, runtime.Object, error) {
+					if conflicts == 0 {
+						return false, nil, nil
+					}
+					conflicts--
+					return true, nil, errors.NewConflict(gr, "topology", nil)
+				}
+			},
+			changed: true,
+		},
+		{
+			name: "Case 6: get error",
+			reactor: func(client *fake.Clientset) (string, k8stesting.ReactionFunc) {
+				return "get", func(action k8stesting.Action) (bool, runtime.Object, error) {
+					return true, nil, errors.NewForbidden(gr, "topology", fmt.Errorf("denied"))
+				}
+			},
+			err: `failed to get configmap default/topology: configmaps "topology" is forbidden: denied`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.TODO()
+			client := fake.NewSimpleClientset(tc.existing...)
+			if tc.reactor != nil {
+				verb, reaction := tc.reactor(client)
+				client.PrependReactor(verb, "configmaps", reaction)
+			}
+
+			changed, err := ApplyConfigMap(ctx, client, newCM("new"))
+			if len(tc.err) != 0 {
+				require.EqualError(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.changed, changed)
+
+			cm, err := client.CoreV1().ConfigMaps("default").Get(ctx, "topology", metav1.GetOptions{})
+			require.NoError(t, err)
+			require.Equal(t, map[string]string{"key": "new"}, cm.Data)
+		})
+	}
+}
+
+func TestReadConfigMapData(t *testing.T) {
+	cm := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "topology", Namespace: "default"},
+		Data:       map[string]string{"topology.conf": "SwitchName=S1 Nodes=n1\n"},
+	}
+	client := fake.NewSimpleClientset(cm)
+
+	testCases := []struct {
+		name string
+		cm   string
+		key  string
+		data []byte
+	}{
+		{
+			name: "Case 1: existing key",
+			cm:   "topology",
+			key:  "topology.conf",
+			data: []byte("SwitchName=S1 Nodes=n1\n"),
+		},
+		{
+			name: "Case 2: missing key",
+			cm:   "topology",
+			key:  "topology.yaml",
+		},
+		{
+			name: "Case 3: missing configmap",
+			cm:   "other",
+			key:  "topology.conf",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			data, err := ReadConfigMapData(context.TODO(), client, tc.cm, "default", tc.key)
+			require.NoError(t, err)
+			require.Equal(t, tc.data, data)
+		})
+	}
+}
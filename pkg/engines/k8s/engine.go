@@ -19,10 +19,15 @@ package k8s
 import (
 	"bytes"
 	"context"
+	"encoding/json"
+	"fmt"
+	"time"
 
 	k8s_core_v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
+	"k8s.io/klog/v2"
 
 	"github.com/NVIDIA/topograph/internal/config"
 	"github.com/NVIDIA/topograph/pkg/engines"
@@ -33,13 +38,35 @@ import (
 const NAME = "k8s"
 
 type K8sEngine struct {
-	kubeClient *kubernetes.Clientset
+	kubeClient    *kubernetes.Clientset
+	dynamicClient dynamic.Interface
 }
 
 type Params struct {
 	TopoConfigPath         string `mapstructure:"topology_config_path"`
 	TopoConfigmapName      string `mapstructure:"topology_configmap_name"`
 	TopoConfigmapNamespace string `mapstructure:"topology_configmap_namespace"`
+	UseDisplayName         bool   `mapstructure:"use_display_name"`
+	DryRun                 bool   `mapstructure:"dry_run"`
+	// Output is either "labels" (default) for node labels, or "crd" for the ClusterTopology resource
+	Output              string `mapstructure:"output"`
+	ClusterTopologyName string `mapstructure:"cluster_topology_name"`
+	// RepairNodes limits the node labeling to the listed nodes
+	RepairNodes []string `mapstructure:"repair_nodes"`
+	// Annotate stamps the labeled nodes with the last-applied time and the request UID
+	Annotate bool `mapstructure:"annotate"`
+	// BandwidthAnnotation annotates the labeled nodes with the uplink bandwidth of their leaf switch
+	BandwidthAnnotation bool `mapstructure:"bandwidth_annotation"`
+	// UplinkAnnotations annotates the labeled nodes with the uplink count and oversubscription of their leaf switch
+	UplinkAnnotations bool `mapstructure:"uplink_annotations"`
+	// KueueTopology, if set, names the Kueue Topology resource describing the node label levels
+	KueueTopology string `mapstructure:"kueue_topology"`
+	// ConfigRevisions is the number of previous topology configs kept in the ConfigMap
+	ConfigRevisions int `mapstructure:"config_revisions"`
+	// StaleNodes selects the handling of the labeled nodes left out of the topology: remove (default), mark or keep
+	StaleNodes string `mapstructure:"stale_nodes"`
+	// LabelKeys holds the label keys of the switch tiers, from the leaf tier up
+	LabelKeys []string `mapstructure:"label_keys"`
 }
 
 type k8sNodeInfo interface {
@@ -66,33 +93,140 @@ func New() (*K8sEngine, error) {
 		return nil, err
 	}
 
-	return &K8sEngine{kubeClient: kubeClient}, nil
+	dynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &K8sEngine{kubeClient: kubeClient, dynamicClient: dynamicClient}, nil
+}
+
+// DefaultParams returns the parameters with the defaults resolved by GenerateOutput
+func DefaultParams() Params {
+	return Params{
+		Output:              OutputLabels,
+		ClusterTopologyName: DefaultClusterTopologyName,
+		ConfigRevisions:     DefaultConfigRevisions,
+		StaleNodes:          StaleNodesRemove,
+	}
 }
 
 func (eng *K8sEngine) GenerateOutput(ctx context.Context, tree *topology.Vertex, params map[string]any) ([]byte, error) {
-	if err := NewTopologyLabeler().ApplyNodeLabels(ctx, tree, eng); err != nil {
+	p := DefaultParams()
+	if err := config.Decode(params, &p); err != nil {
 		return nil, err
 	}
-	buf := &bytes.Buffer{}
-	err := translate.Write(buf, tree)
-	if err != nil {
+
+	switch p.Output {
+	case "", OutputLabels, OutputCRD:
+	default:
+		return nil, fmt.Errorf("unsupported output %q", p.Output)
+	}
+	if p.ConfigRevisions < 0 {
+		return nil, fmt.Errorf("config_revisions must not be negative")
+	}
+	switch p.StaleNodes {
+	case "", StaleNodesRemove, StaleNodesMark, StaleNodesKeep:
+	default:
+		return nil, fmt.Errorf("unsupported stale_nodes %q", p.StaleNodes)
+	}
+	if err := ValidateLabelKeys(p.LabelKeys); err != nil {
 		return nil, err
 	}
+	if len(p.KueueTopology) != 0 && p.Output == OutputCRD {
+		return nil, fmt.Errorf("kueue_topology requires %q output", OutputLabels)
+	}
 
-	var p Params
-	if err := config.Decode(params, &p); err != nil {
+	var ct *ClusterTopology
+	if p.Output == OutputCRD {
+		name := p.ClusterTopologyName
+		if len(name) == 0 {
+			name = DefaultClusterTopologyName
+		}
+		ct = NewClusterTopology(name, tree)
+	}
+
+	buf := &bytes.Buffer{}
+	err := translate.Write(ctx, buf, tree)
+	if err != nil {
 		return nil, err
 	}
 
 	cfg := buf.Bytes()
 
-	filename := p.TopoConfigPath
-	cmName := p.TopoConfigmapName
-	cmNamespace := p.TopoConfigmapNamespace
-	err = eng.UpdateTopologyConfigmap(ctx, cmName, cmNamespace, map[string]string{filename: string(cfg)})
+	if p.DryRun {
+		if ct != nil {
+			klog.Info("Returning cluster topology")
+			return json.MarshalIndent(ct, "", "  ")
+		}
+		klog.Info("Returning topology config")
+		return cfg, nil
+	}
+
+	if ct != nil {
+		if err := ApplyClusterTopology(ctx, eng.dynamicClient, ct); err != nil {
+			return nil, err
+		}
+	} else {
+		labeler := NewTopologyLabeler()
+		labeler.useDisplayName = p.UseDisplayName
+		labeler.setNodes(p.RepairNodes)
+		labeler.setScope(engines.RequestNodes(ctx))
+		labeler.bandwidth = p.BandwidthAnnotation
+		labeler.uplinks = p.UplinkAnnotations
+		labeler.staleNodes = p.StaleNodes
+		labeler.setTierKeys(p.LabelKeys)
+		if p.Annotate {
+			labeler.setAnnotations(time.Now(), engines.RequestUID(ctx))
+		}
+		if err := labeler.ApplyNodeLabels(ctx, tree, eng); err != nil {
+			return nil, err
+		}
+		if len(p.KueueTopology) != 0 {
+			if err := ApplyKueueTopology(ctx, eng.dynamicClient, NewKueueTopology(p.KueueTopology, tree, labeler.tierKeys)); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	changed, err := eng.UpdateTopologyConfigmap(ctx, p.TopoConfigmapName, p.TopoConfigmapNamespace, p.TopoConfigPath, string(cfg), p.ConfigRevisions)
 	if err != nil {
 		return nil, err
 	}
+	if !changed {
+		return []byte("UNCHANGED\n"), nil
+	}
 
 	return []byte("OK\n"), nil
 }
+
+// TopologyConfigs implements engines.ConfigDiffer. It reads the applied config from the topology ConfigMap.
+func (eng *K8sEngine) TopologyConfigs(ctx context.Context, tree *topology.Vertex, params map[string]any) ([]byte, []byte, error) {
+	var p Params
+	if err := config.Decode(params, &p); err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", engines.ErrInvalidParams, err)
+	}
+
+	applied, err := ReadConfigMapData(ctx, eng.kubeClient, p.TopoConfigmapName, p.TopoConfigmapNamespace, p.TopoConfigPath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	buf := &bytes.Buffer{}
+	if err := translate.Write(ctx, buf, tree); err != nil {
+		return nil, nil, err
+	}
+
+	return applied, buf.Bytes(), nil
+}
+
+// RollbackTopologyConfig implements engines.ConfigRollbacker. It restores a revision kept in the topology ConfigMap.
+func (eng *K8sEngine) RollbackTopologyConfig(ctx context.Context, params map[string]any, revision int) error {
+	p := DefaultParams()
+	if err := config.Decode(params, &p); err != nil {
+		return err
+	}
+
+	return rollbackTopologyConfig(ctx, eng.kubeClient, p.TopoConfigmapName, p.TopoConfigmapNamespace, p.TopoConfigPath,
+		revision, p.ConfigRevisions, time.Now())
+}
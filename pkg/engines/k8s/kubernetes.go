@@ -20,10 +20,11 @@ import (
 	"context"
 	std_errors "errors"
 	"fmt"
+	"time"
 
 	v1 "k8s.io/api/core/v1"
-	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/klog/v2"
 
 	"github.com/NVIDIA/topograph/pkg/engines"
@@ -69,52 +70,87 @@ func (eng *K8sEngine) GetComputeInstances(ctx context.Context, environment engin
 	return cis, nil
 }
 
-func (eng *K8sEngine) UpdateTopologyConfigmap(ctx context.Context, name, namespace string, data map[string]string) error {
+// UpdateTopologyConfigmap sets the topology config of the key in the ConfigMap, and returns false if the config is unchanged.
+// Up to the given number of previous configs are kept in the ConfigMap as revisions of the key.
+func (eng *K8sEngine) UpdateTopologyConfigmap(ctx context.Context, name, namespace, key, data string, revisions int) (bool, error) {
 	klog.Infof("Updating topology config %s/%s", namespace, name)
 
-	cm := &v1.ConfigMap{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      name,
-			Namespace: namespace,
-		},
-		Data: data,
-	}
+	return applyTopologyConfig(ctx, eng.kubeClient, name, namespace, key, data, revisions, time.Now(), engines.RequestUID(ctx))
+}
 
-	verb := "get"
-	res, err := eng.kubeClient.CoreV1().ConfigMaps(cm.Namespace).Get(ctx, cm.Name, metav1.GetOptions{})
-	if err == nil {
-		verb = "update"
-		res, err = eng.kubeClient.CoreV1().ConfigMaps(cm.Namespace).Update(ctx, cm, metav1.UpdateOptions{})
-	} else if errors.IsNotFound(err) {
-		verb = "create"
-		res, err = eng.kubeClient.CoreV1().ConfigMaps(cm.Namespace).Create(ctx, cm, metav1.CreateOptions{})
-	}
+// UpdateNodeLabels implements the Labeler interface
+func (eng *K8sEngine) UpdateNodeLabels(ctx context.Context, nodeName string, labels, annotations map[string]string, remove []string) ([]string, error) {
+	return (&clientLabeler{client: eng.kubeClient}).UpdateNodeLabels(ctx, nodeName, labels, annotations, remove)
+}
 
+// ListNodeLabels implements the NodeLister interface
+func (eng *K8sEngine) ListNodeLabels(ctx context.Context) (map[string]map[string]string, error) {
+	return (&clientLabeler{client: eng.kubeClient}).ListNodeLabels(ctx)
+}
+
+// clientLabeler implements the Labeler and NodeLister interfaces with the Kubernetes API
+type clientLabeler struct {
+	client kubernetes.Interface
+}
+
+// UpdateNodeLabels implements the Labeler interface
+func (l *clientLabeler) UpdateNodeLabels(ctx context.Context, nodeName string, labels, annotations map[string]string, remove []string) ([]string, error) {
+	klog.Infof("Applying labels on node %s : %v", nodeName, labels)
+	node, err := l.client.CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{})
 	if err != nil {
-		return fmt.Errorf("failed to %s configmap %s/%s: %v",
-			verb, cm.Namespace, cm.Name, err)
+		return nil, err
+	}
+
+	changed := updateLabels(node, labels, remove)
+
+	if len(annotations) != 0 && node.Annotations == nil {
+		node.Annotations = make(map[string]string)
+	}
+	for k, v := range annotations {
+		node.Annotations[k] = v
 	}
 
-	klog.V(4).Infof("Successfully %sd configmap %s/%s", verb, res.Namespace, res.Name)
+	if _, err = l.client.CoreV1().Nodes().Update(ctx, node, metav1.UpdateOptions{}); err != nil {
+		return nil, err
+	}
 
-	return nil
+	return changed, nil
 }
 
-func (eng *K8sEngine) AddNodeLabels(ctx context.Context, nodeName string, labels map[string]string) error {
-	klog.Infof("Applying labels on node %s : %v", nodeName, labels)
-	node, err := eng.kubeClient.CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{})
+// ListNodeLabels implements the NodeLister interface
+func (l *clientLabeler) ListNodeLabels(ctx context.Context) (map[string]map[string]string, error) {
+	nodeList, err := l.client.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("unable to list node in the cluster: %v", err)
+	}
+
+	labels := make(map[string]map[string]string, len(nodeList.Items))
+	for _, n := range nodeList.Items {
+		labels[n.Name] = n.Labels
+	}
+	return labels, nil
+}
+
+// updateLabels sets the labels and removes the listed keys from the node, returning the changed keys
+func updateLabels(node *v1.Node, labels map[string]string, remove []string) []string {
+	var changed []string
+
+	for _, k := range remove {
+		if _, ok := node.Labels[k]; ok {
+			delete(node.Labels, k)
+			changed = append(changed, k)
+		}
 	}
 
 	if node.Labels == nil {
 		node.Labels = make(map[string]string)
 	}
 	for k, v := range labels {
-		node.Labels[k] = v
+		if old, ok := node.Labels[k]; !ok || old != v {
+			node.Labels[k] = v
+			changed = append(changed, k)
+		}
 	}
 
-	_, err = eng.kubeClient.CoreV1().Nodes().Update(ctx, node, metav1.UpdateOptions{})
-
-	return err
+	return changed
 }
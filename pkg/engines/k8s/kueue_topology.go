@@ -0,0 +1,166 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+
+	"github.com/NVIDIA/topograph/pkg/topology"
+)
+
+var KueueTopologyGVR = schema.GroupVersionResource{
+	Group:    "kueue.x-k8s.io",
+	Version:  "v1alpha1",
+	Resource: "topologies",
+}
+
+// KueueTopology is the Topology resource of the Kueue topology-aware scheduling
+type KueueTopology struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec KueueTopologySpec `json:"spec"`
+}
+
+// KueueTopologySpec lists the node label keys of the topology levels, from the top level down
+type KueueTopologySpec struct {
+	Levels []KueueTopologyLevel `json:"levels"`
+}
+
+type KueueTopologyLevel struct {
+	NodeLabel string `json:"nodeLabel"`
+}
+
+// NewKueueTopology returns the Kueue Topology with the levels of the node labels applied for the topology graph
+// tierKeys holds the label keys of the switch tiers, from the leaf tier up; the default keys are used if empty.
+func NewKueueTopology(name string, root *topology.Vertex, tierKeys []string) *KueueTopology {
+	kt := &KueueTopology{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: KueueTopologyGVR.GroupVersion().String(),
+			Kind:       "Topology",
+		},
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec:       KueueTopologySpec{Levels: []KueueTopologyLevel{}},
+	}
+
+	for _, label := range getLabelLevels(root, tierKeys) {
+		kt.Spec.Levels = append(kt.Spec.Levels, KueueTopologyLevel{NodeLabel: label})
+	}
+
+	return kt
+}
+
+// getLabelLevels returns the topology label keys set by the labeler for the topology graph, from the top level down
+func getLabelLevels(root *topology.Vertex, tierKeys []string) []string {
+	var levels []string
+
+	if len(tierKeys) == 0 {
+		tierKeys = switchNetworkHierarchy
+	}
+
+	if treeRoot, ok := root.Vertices[topology.TopologyTree]; ok {
+		depth := 0
+		for _, sw := range treeRoot.Vertices {
+			// nodes without topology are not labeled
+			if topology.IsNoTopology(sw.ID) {
+				continue
+			}
+			depth = max(depth, getSwitchDepth(sw))
+		}
+		if depth != 0 && len(treeRoot.ID) != 0 {
+			depth++
+		}
+		for i := min(depth, len(tierKeys)) - 1; i >= 0; i-- {
+			levels = append(levels, tierKeys[i])
+		}
+	}
+
+	if blockRoot, ok := root.Vertices[topology.TopologyBlock]; ok && len(blockRoot.Vertices) != 0 {
+		levels = append(levels, hierarchyLayerAccelerator)
+	}
+
+	return levels
+}
+
+// getSwitchDepth returns the number of switch levels from the vertex down to the nodes
+func getSwitchDepth(v *topology.Vertex) int {
+	if len(v.Vertices) == 0 {
+		return 0
+	}
+
+	depth := 0
+	for _, w := range v.Vertices {
+		depth = max(depth, getSwitchDepth(w))
+	}
+
+	return depth + 1
+}
+
+// ApplyKueueTopology creates the Kueue Topology resource, or updates its levels if they changed
+func ApplyKueueTopology(ctx context.Context, client dynamic.Interface, kt *KueueTopology) error {
+	if len(kt.Spec.Levels) == 0 {
+		klog.Warningf("Skipping Kueue topology %s: no topology levels", kt.Name)
+		return nil
+	}
+
+	obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(kt)
+	if err != nil {
+		return fmt.Errorf("failed to convert Kueue topology %s: %w", kt.Name, err)
+	}
+	res := client.Resource(KueueTopologyGVR)
+
+	err = retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		existing, err := res.Get(ctx, kt.Name, metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			klog.Infof("Creating Kueue topology %s", kt.Name)
+			_, err = res.Create(ctx, &unstructured.Unstructured{Object: obj}, metav1.CreateOptions{})
+			return err
+		}
+		if err != nil {
+			return err
+		}
+
+		current := &KueueTopology{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(existing.Object, current); err != nil {
+			return err
+		}
+		if reflect.DeepEqual(current.Spec.Levels, kt.Spec.Levels) {
+			klog.V(4).Infof("Kueue topology %s is up to date", kt.Name)
+			return nil
+		}
+
+		klog.Infof("Updating Kueue topology %s", kt.Name)
+		existing.Object["spec"] = obj["spec"]
+		_, err = res.Update(ctx, existing, metav1.UpdateOptions{})
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to apply Kueue topology %s: %w", kt.Name, err)
+	}
+
+	return nil
+}
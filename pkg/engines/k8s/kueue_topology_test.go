@@ -0,0 +1,130 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package k8s
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+
+	"github.com/NVIDIA/topograph/internal/fixtures"
+	"github.com/NVIDIA/topograph/pkg/topology"
+)
+
+func TestNewKueueTopology(t *testing.T) {
+	treeOnly := func() *topology.Vertex {
+		root, _ := fixtures.BlockWithMultiIBTestSet()
+		delete(root.Vertices, topology.TopologyBlock)
+		return root
+	}
+
+	testCases := []struct {
+		name   string
+		root   func() *topology.Vertex
+		keys   []string
+		levels []string
+	}{
+		{
+			name: "Case 1: 2-level tree",
+			root: func() *topology.Vertex {
+				root, _ := fixtures.TreeTestSet()
+				return root
+			},
+			levels: []string{hierarchyLayerSpine, hierarchyLayerBlock},
+		},
+		{
+			name:   "Case 2: 3-level tree",
+			root:   treeOnly,
+			levels: []string{hierarchyLayerDatacenter, hierarchyLayerSpine, hierarchyLayerBlock},
+		},
+		{
+			name: "Case 3: 3-level tree with accelerator",
+			root: func() *topology.Vertex {
+				root, _ := fixtures.BlockWithMultiIBTestSet()
+				return root
+			},
+			levels: []string{hierarchyLayerDatacenter, hierarchyLayerSpine, hierarchyLayerBlock, hierarchyLayerAccelerator},
+		},
+		{
+			name: "Case 4: no topology",
+			root: func() *topology.Vertex {
+				return &topology.Vertex{Vertices: map[string]*topology.Vertex{}}
+			},
+			levels: []string{},
+		},
+		{
+			name:   "Case 5: 3-level tree with 2 label keys",
+			root:   treeOnly,
+			keys:   []string{"example.com/leaf", "example.com/spine"},
+			levels: []string{"example.com/spine", "example.com/leaf"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			kt := NewKueueTopology("default", tc.root(), tc.keys)
+			require.Equal(t, "kueue.x-k8s.io/v1alpha1", kt.APIVersion)
+			require.Equal(t, "Topology", kt.Kind)
+			require.Equal(t, "default", kt.Name)
+
+			levels := []string{}
+			for _, level := range kt.Spec.Levels {
+				levels = append(levels, level.NodeLabel)
+			}
+			require.Equal(t, tc.levels, levels)
+		})
+	}
+}
+
+func TestApplyKueueTopology(t *testing.T) {
+	ctx := context.TODO()
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{KueueTopologyGVR: "TopologyList"})
+
+	get := func() *KueueTopology {
+		u, err := client.Resource(KueueTopologyGVR).Get(ctx, "default", metav1.GetOptions{})
+		require.NoError(t, err)
+		kt := &KueueTopology{}
+		require.NoError(t, runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, kt))
+		return kt
+	}
+
+	// create with 3 levels
+	root, _ := fixtures.BlockWithMultiIBTestSet()
+	delete(root.Vertices, topology.TopologyBlock)
+	kt := NewKueueTopology("default", root, nil)
+	require.NoError(t, ApplyKueueTopology(ctx, client, kt))
+	require.Equal(t, kt.Spec, get().Spec)
+	require.Len(t, get().Spec.Levels, 3)
+
+	// unchanged levels
+	client.ClearActions()
+	require.NoError(t, ApplyKueueTopology(ctx, client, kt))
+	require.Len(t, client.Actions(), 1)
+	require.Equal(t, "get", client.Actions()[0].GetVerb())
+
+	// update to 4 levels
+	root, _ = fixtures.BlockWithMultiIBTestSet()
+	kt = NewKueueTopology("default", root, nil)
+	require.NoError(t, ApplyKueueTopology(ctx, client, kt))
+	require.Equal(t, kt.Spec, get().Spec)
+	require.Equal(t, hierarchyLayerAccelerator, get().Spec.Levels[3].NodeLabel)
+}
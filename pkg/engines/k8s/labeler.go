@@ -19,34 +19,157 @@ package k8s
 import (
 	"context"
 	"fmt"
-	"hash/fnv"
+	"maps"
+	"sort"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/klog/v2"
 
 	"github.com/NVIDIA/topograph/pkg/topology"
+	"github.com/NVIDIA/topograph/pkg/translate"
 )
 
 const (
-	hierarchyLayerAccelerator = "network.topology.kubernetes.io/accelerator"
-	hierarchyLayerBlock       = "network.topology.kubernetes.io/block"
-	hierarchyLayerSpine       = "network.topology.kubernetes.io/spine"
-	hierarchyLayerDatacenter  = "network.topology.kubernetes.io/datacenter"
+	hierarchyLayerAccelerator = translate.LabelAccelerator
+	hierarchyLayerBlock       = translate.LabelBlock
+	hierarchyLayerSpine       = translate.LabelSpine
+	hierarchyLayerDatacenter  = translate.LabelDatacenter
 )
 
-var switchNetworkHierarchy = []string{hierarchyLayerBlock, hierarchyLayerSpine, hierarchyLayerDatacenter}
+const (
+	// AnnotationLastApplied holds the RFC3339 time the topology labels were last applied to the node
+	AnnotationLastApplied = "topograph.nvidia.com/last-applied"
+	// AnnotationRequestUID holds the UID of the topology request that last applied the labels
+	AnnotationRequestUID = "topograph.nvidia.com/request-uid"
+	// AnnotationBandwidth holds the aggregate uplink bandwidth in Gb/s of the leaf switch of the node
+	AnnotationBandwidth = translate.AnnotationBandwidth
+	// AnnotationUplinks holds the number of uplinks of the leaf switch of the node, counting parallel links
+	AnnotationUplinks = "topograph.nvidia.com/leaf-uplinks"
+	// AnnotationOversubscription holds the number of node links per uplink of the leaf switch of the node
+	AnnotationOversubscription = "topograph.nvidia.com/leaf-oversubscription"
+	// LabelStale marks the nodes left out of the topology whose topology labels are kept
+	LabelStale = "topograph.nvidia.com/stale"
+)
+
+// handling of the topology labels of the nodes left out of the topology
+const (
+	// StaleNodesRemove removes the topology labels
+	StaleNodesRemove = "remove"
+	// StaleNodesMark keeps the topology labels and marks the node with the stale label
+	StaleNodesMark = "mark"
+	// StaleNodesKeep leaves the node unchanged
+	StaleNodesKeep = "keep"
+)
+
+// switchNetworkHierarchy holds the default label keys of the switch tiers, from the leaf tier up
+var switchNetworkHierarchy = translate.LabelTierKeys
 
 // map nodename:[label name: label value]
 type nodeLabelMap map[string]map[string]string
 
+// Labeler applies the labels and annotations to the node and removes the listed label keys in a single write.
+// It returns the label keys whose values were added, changed or removed.
 type Labeler interface {
-	AddNodeLabels(context.Context, string, map[string]string) error
+	UpdateNodeLabels(ctx context.Context, nodeName string, labels, annotations map[string]string, remove []string) ([]string, error)
+}
+
+// NodeLister is optionally implemented by the labelers that can list the labels of all the cluster nodes.
+// It enables the reconciliation of the nodes left out of the topology.
+type NodeLister interface {
+	ListNodeLabels(ctx context.Context) (map[string]map[string]string, error)
 }
 
 type topologyLabeler struct {
 	mapper map[string]string
+	// useDisplayName selects the accelerator domain display name, when available, as the label value
+	useDisplayName bool
+	// nodes limits the labeling to the listed nodes, if not empty
+	nodes map[string]bool
+	// scope limits the reconciliation of the nodes left out of the topology to the nodes of the request, if not empty
+	scope map[string]bool
+	// annotations are added to every labeled node
+	annotations map[string]string
+	// bandwidth enables the bandwidth annotation of the nodes
+	bandwidth bool
+	// uplinks enables the uplink count and oversubscription annotations of the nodes
+	uplinks bool
+	// nodeAnnotations are added to the individual nodes
+	nodeAnnotations nodeLabelMap
+	// staleNodes selects the handling of the labeled nodes left out of the topology: remove (default), mark or keep
+	staleNodes string
+	// changes holds the label keys changed on each node by the last apply
+	changes map[string][]string
+	// tierKeys holds the label keys of the switch tiers, from the leaf tier up
+	tierKeys []string
+	// skipped holds the number of top switch tiers without a label key in the last apply
+	skipped int
 }
 
 func NewTopologyLabeler() *topologyLabeler {
 	return &topologyLabeler{
-		mapper: make(map[string]string),
+		mapper:   make(map[string]string),
+		tierKeys: switchNetworkHierarchy,
+	}
+}
+
+// ValidateLabelKeys checks the label keys of the switch tiers
+func ValidateLabelKeys(keys []string) error {
+	seen := map[string]bool{hierarchyLayerAccelerator: true}
+	for _, key := range keys {
+		if errs := validation.IsQualifiedName(key); len(errs) != 0 {
+			return fmt.Errorf("invalid label key %q: %s", key, errs[0])
+		}
+		if seen[key] {
+			return fmt.Errorf("duplicate label key %q", key)
+		}
+		seen[key] = true
+	}
+	return nil
+}
+
+// setTierKeys sets the label keys of the switch tiers, from the leaf tier up.
+// The default keys are kept if the list is empty.
+func (l *topologyLabeler) setTierKeys(keys []string) {
+	if len(keys) != 0 {
+		l.tierKeys = keys
+	}
+}
+
+// managedLabels returns the node labels owned by topograph
+func (l *topologyLabeler) managedLabels() []string {
+	return append([]string{hierarchyLayerAccelerator}, l.tierKeys...)
+}
+
+// setNodes limits the labeling to the given nodes
+func (l *topologyLabeler) setNodes(nodes []string) {
+	if len(nodes) == 0 {
+		l.nodes = nil
+		return
+	}
+	l.nodes = make(map[string]bool, len(nodes))
+	for _, node := range nodes {
+		l.nodes[node] = true
+	}
+}
+
+// setScope limits the reconciliation of the nodes left out of the topology to the nodes of the request
+func (l *topologyLabeler) setScope(nodes []string) {
+	if len(nodes) == 0 {
+		l.scope = nil
+		return
+	}
+	l.scope = make(map[string]bool, len(nodes))
+	for _, node := range nodes {
+		l.scope[node] = true
+	}
+}
+
+// setAnnotations stamps the labeled nodes with the time and the UID of the request
+func (l *topologyLabeler) setAnnotations(now time.Time, uid string) {
+	l.annotations = map[string]string{AnnotationLastApplied: now.UTC().Format(time.RFC3339)}
+	if len(uid) != 0 {
+		l.annotations[AnnotationRequestUID] = uid
 	}
 }
 
@@ -67,20 +190,101 @@ func (l *topologyLabeler) ApplyNodeLabels(ctx context.Context, v *topology.Verte
 		if len(treeRoot.ID) != 0 {
 			layers = append(layers, treeRoot.ID)
 		}
+		l.skipped = 0
 		if err := l.getTreeNodeLabels(treeRoot, nodeMap, layers); err != nil {
 			return err
 		}
+		if l.skipped != 0 {
+			klog.Infof("Skipped %d top switch tiers beyond the %d label keys", l.skipped, len(l.tierKeys))
+		}
 	}
 
+	l.changes = make(map[string][]string)
 	for nodeName, labels := range nodeMap {
-		if err := labeler.AddNodeLabels(ctx, nodeName, labels); err != nil {
+		if l.nodes != nil && !l.nodes[nodeName] {
+			continue
+		}
+		changed, err := labeler.UpdateNodeLabels(ctx, nodeName, labels, l.getAnnotations(nodeName), l.staleLabels(labels))
+		if err != nil {
 			return err
 		}
+		if len(changed) != 0 {
+			sort.Strings(changed)
+			l.changes[nodeName] = changed
+			klog.V(2).Infof("Changed labels on node %s: %v", nodeName, changed)
+		}
 	}
+	klog.Infof("Changed labels on %d of %d nodes", len(l.changes), len(nodeMap))
+
+	// the other nodes are reconciled only with the full topology
+	lister, ok := labeler.(NodeLister)
+	if !ok || l.nodes != nil || l.staleNodes == StaleNodesKeep {
+		return nil
+	}
+	return l.reconcileStaleNodes(ctx, nodeMap, lister, labeler)
+}
+
+// reconcileStaleNodes removes or marks the topology labels of the nodes absent from the topology.
+// With a request limited to some nodes, the other nodes are left unchanged.
+func (l *topologyLabeler) reconcileStaleNodes(ctx context.Context, nodeMap nodeLabelMap, lister NodeLister, labeler Labeler) error {
+	clusterNodes, err := lister.ListNodeLabels(ctx)
+	if err != nil {
+		return err
+	}
+
+	var count int
+	for nodeName, labels := range clusterNodes {
+		if _, ok := nodeMap[nodeName]; ok || !l.hasManagedLabels(labels) {
+			continue
+		}
+		if l.scope != nil && !l.scope[nodeName] {
+			continue
+		}
+
+		var changed []string
+		if l.staleNodes == StaleNodesMark {
+			changed, err = labeler.UpdateNodeLabels(ctx, nodeName, map[string]string{LabelStale: "true"}, nil, nil)
+		} else {
+			changed, err = labeler.UpdateNodeLabels(ctx, nodeName, nil, nil, append([]string{LabelStale}, l.managedLabels()...))
+		}
+		if err != nil {
+			return err
+		}
+		if len(changed) != 0 {
+			sort.Strings(changed)
+			l.changes[nodeName] = changed
+			count++
+			klog.V(2).Infof("Changed labels on stale node %s: %v", nodeName, changed)
+		}
+	}
+	klog.Infof("Changed labels on %d stale nodes", count)
 
 	return nil
 }
 
+// hasManagedLabels returns true if any of the labels is owned by topograph
+func (l *topologyLabeler) hasManagedLabels(labels map[string]string) bool {
+	for _, key := range l.managedLabels() {
+		if _, ok := labels[key]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// staleLabels returns the managed label keys absent from the computed node labels,
+// e.g., the accelerator label of a node that lost its accelerator domain,
+// and the stale label of a node back in the topology
+func (l *topologyLabeler) staleLabels(labels map[string]string) []string {
+	stale := []string{LabelStale}
+	for _, key := range l.managedLabels() {
+		if _, ok := labels[key]; !ok {
+			stale = append(stale, key)
+		}
+	}
+	return stale
+}
+
 func (l *topologyLabeler) getTreeNodeLabels(v *topology.Vertex, nodeMap nodeLabelMap, layers []string) error {
 	if len(v.Vertices) == 0 { // compute node
 		if len(layers) != 0 {
@@ -93,12 +297,15 @@ func (l *topologyLabeler) getTreeNodeLabels(v *topology.Vertex, nodeMap nodeLabe
 				labels = make(map[string]string)
 				nodeMap[nodeName] = labels
 			}
+			// the tiers closest to the node are labeled, the tiers beyond the label keys are skipped
 			for i, sw := range layers[1:] {
 				if len(sw) == 0 {
 					break
 				}
-				if i < len(switchNetworkHierarchy) {
-					labels[(switchNetworkHierarchy[i])] = l.checkLabel(sw)
+				if i < len(l.tierKeys) {
+					labels[l.tierKeys[i]] = l.checkLabel(sw)
+				} else {
+					l.skipped = max(l.skipped, i-len(l.tierKeys)+1)
 				}
 			}
 		}
@@ -106,6 +313,15 @@ func (l *topologyLabeler) getTreeNodeLabels(v *topology.Vertex, nodeMap nodeLabe
 	}
 
 	for _, w := range v.Vertices {
+		if len(w.Vertices) == 0 {
+			if l.bandwidth {
+				l.setNodeAnnotation(w.Name, AnnotationBandwidth, v.Metadata[topology.KeyUplinkBandwidth])
+			}
+			if l.uplinks {
+				l.setNodeAnnotation(w.Name, AnnotationUplinks, v.Metadata[topology.KeyUplinks])
+				l.setNodeAnnotation(w.Name, AnnotationOversubscription, v.Metadata[topology.KeyOversubscription])
+			}
+		}
 		if err := l.getTreeNodeLabels(w, nodeMap, append([]string{w.ID}, layers...)); err != nil {
 			return err
 		}
@@ -114,6 +330,32 @@ func (l *topologyLabeler) getTreeNodeLabels(v *topology.Vertex, nodeMap nodeLabe
 	return nil
 }
 
+// setNodeAnnotation annotates the node with the value taken from its leaf switch, if known
+func (l *topologyLabeler) setNodeAnnotation(nodeName, key, val string) {
+	if len(val) == 0 {
+		return
+	}
+	if l.nodeAnnotations == nil {
+		l.nodeAnnotations = make(nodeLabelMap)
+	}
+	if _, ok := l.nodeAnnotations[nodeName]; !ok {
+		l.nodeAnnotations[nodeName] = make(map[string]string)
+	}
+	l.nodeAnnotations[nodeName][key] = val
+}
+
+// getAnnotations returns the annotations of the node
+func (l *topologyLabeler) getAnnotations(nodeName string) map[string]string {
+	extra := l.nodeAnnotations[nodeName]
+	if len(extra) == 0 {
+		return l.annotations
+	}
+	annotations := make(map[string]string, len(l.annotations)+len(extra))
+	maps.Copy(annotations, l.annotations)
+	maps.Copy(annotations, extra)
+	return annotations
+}
+
 func (l *topologyLabeler) getBlockNodeLabels(v *topology.Vertex, nodeMap nodeLabelMap) error {
 	for _, block := range v.Vertices {
 		for _, node := range block.Vertices {
@@ -126,28 +368,25 @@ func (l *topologyLabeler) getBlockNodeLabels(v *topology.Vertex, nodeMap nodeLab
 			if val, ok := labels[hierarchyLayerAccelerator]; ok {
 				return fmt.Errorf("multiple accelerator labels %s, %s for node %s", val, block.ID, nodeName)
 			}
-			labels[hierarchyLayerAccelerator] = l.checkLabel(block.ID)
+			domain := block.ID
+			if displayName := block.Metadata[topology.KeyDisplayName]; l.useDisplayName && len(displayName) != 0 {
+				domain = displayName
+			}
+			labels[hierarchyLayerAccelerator] = l.checkLabel(domain)
 		}
 	}
 	return nil
 }
 
-// checkLabel checks the length of the label value.
-// If more than 63 characters (Kubernetes limit), it will replace it with hash
+// checkLabel checks the label value against the Kubernetes label value rules.
+// If the value is longer than 63 characters or has invalid characters, it will replace it with hash
 func (l *topologyLabeler) checkLabel(val string) string {
 	v, ok := l.mapper[val]
 	if ok {
 		return v
 	}
 
-	if len(val) <= 63 {
-		v = val
-	} else {
-		h := fnv.New64a()
-		h.Write([]byte(val))
-		v = fmt.Sprintf("x%x", h.Sum64())
-	}
-
+	v = translate.LabelValue(val)
 	l.mapper[val] = v
 	return v
 }
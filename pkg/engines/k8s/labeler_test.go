@@ -19,27 +19,65 @@ package k8s
 import (
 	"context"
 	"fmt"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
 
+	"github.com/NVIDIA/topograph/internal/fixtures"
+	"github.com/NVIDIA/topograph/pkg/topology"
 	"github.com/NVIDIA/topograph/pkg/translate"
 )
 
 type testLabeler struct {
-	data map[string]map[string]string
+	data        map[string]map[string]string
+	annotations map[string]map[string]string
+	writes      int
 }
 
-func (l *testLabeler) AddNodeLabels(_ context.Context, nodeName string, labels map[string]string) error {
+func (l *testLabeler) UpdateNodeLabels(_ context.Context, nodeName string, labels, annotations map[string]string, _ []string) ([]string, error) {
 	if _, ok := l.data[nodeName]; ok {
-		return fmt.Errorf("duplicate entry for %s", nodeName)
+		return nil, fmt.Errorf("duplicate entry for %s", nodeName)
 	}
 	l.data[nodeName] = labels
-	return nil
+	if annotations != nil {
+		if l.annotations == nil {
+			l.annotations = make(map[string]map[string]string)
+		}
+		l.annotations[nodeName] = annotations
+	}
+	l.writes++
+	return nil, nil
+}
+
+// clusterLabeler keeps the node labels across applies
+type clusterLabeler struct {
+	nodes map[string]*v1.Node
+}
+
+func (l *clusterLabeler) UpdateNodeLabels(_ context.Context, nodeName string, labels, _ map[string]string, remove []string) ([]string, error) {
+	node, ok := l.nodes[nodeName]
+	if !ok {
+		node = &v1.Node{}
+		l.nodes[nodeName] = node
+	}
+	return updateLabels(node, labels, remove), nil
+}
+
+func (l *clusterLabeler) labels() map[string]map[string]string {
+	labels := make(map[string]map[string]string, len(l.nodes))
+	for name, node := range l.nodes {
+		labels[name] = node.Labels
+	}
+	return labels
 }
 
 func TestApplyNodeLabelsWithTree(t *testing.T) {
-	root, _ := translate.GetTreeTestSet(true)
+	root, _ := fixtures.TreeTestSet(fixtures.WithLongSwitchNames())
 	labeler := &testLabeler{data: make(map[string]map[string]string)}
 	data := map[string]map[string]string{
 		"Node201": {"network.topology.kubernetes.io/block": "S2", "network.topology.kubernetes.io/spine": "S1"},
@@ -55,8 +93,135 @@ func TestApplyNodeLabelsWithTree(t *testing.T) {
 	require.Equal(t, data, labeler.data)
 }
 
+func TestApplyNodeLabelsWithRepairNodes(t *testing.T) {
+	root, _ := fixtures.TreeTestSet()
+	labeler := &testLabeler{data: make(map[string]map[string]string)}
+	data := map[string]map[string]string{
+		"Node202": {"network.topology.kubernetes.io/block": "S2", "network.topology.kubernetes.io/spine": "S1"},
+		"Node305": {"network.topology.kubernetes.io/block": "S3", "network.topology.kubernetes.io/spine": "S1"},
+	}
+
+	l := NewTopologyLabeler()
+	l.setNodes([]string{"Node202", "Node305", "Node999"})
+	err := l.ApplyNodeLabels(context.TODO(), root, labeler)
+	require.NoError(t, err)
+	require.Equal(t, data, labeler.data)
+}
+
+func TestApplyNodeLabelsWithAnnotations(t *testing.T) {
+	root, _ := fixtures.TreeTestSet()
+	now := time.Date(2024, 10, 1, 12, 30, 0, 0, time.FixedZone("PDT", -7*3600))
+
+	testCases := []struct {
+		name        string
+		uid         string
+		annotations map[string]string
+	}{
+		{
+			name:        "Case 1: with request UID",
+			uid:         "ab12",
+			annotations: map[string]string{AnnotationLastApplied: "2024-10-01T19:30:00Z", AnnotationRequestUID: "ab12"},
+		},
+		{
+			name:        "Case 2: without request UID",
+			annotations: map[string]string{AnnotationLastApplied: "2024-10-01T19:30:00Z"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			labeler := &testLabeler{data: make(map[string]map[string]string)}
+			l := NewTopologyLabeler()
+			l.setNodes([]string{"Node202", "Node305"})
+			l.setAnnotations(now, tc.uid)
+			err := l.ApplyNodeLabels(context.TODO(), root, labeler)
+			require.NoError(t, err)
+			// labels and annotations are written together, once per node
+			require.Equal(t, 2, labeler.writes)
+			require.Equal(t, map[string]map[string]string{"Node202": tc.annotations, "Node305": tc.annotations}, labeler.annotations)
+		})
+	}
+}
+
+func TestApplyNodeLabelsWithBandwidth(t *testing.T) {
+	root, _ := fixtures.TreeTestSet()
+	spine := root.Vertices[topology.TopologyTree].Vertices["S1"]
+	spine.Vertices["S2"].Metadata = map[string]string{topology.KeyUplinkBandwidth: "400"}
+	now := time.Date(2024, 10, 1, 12, 30, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name        string
+		bandwidth   bool
+		annotations map[string]map[string]string
+	}{
+		{
+			name:      "Case 1: bandwidth annotation enabled",
+			bandwidth: true,
+			annotations: map[string]map[string]string{
+				"Node202": {AnnotationLastApplied: "2024-10-01T12:30:00Z", AnnotationBandwidth: "400"},
+				"Node305": {AnnotationLastApplied: "2024-10-01T12:30:00Z"},
+			},
+		},
+		{
+			name: "Case 2: bandwidth annotation disabled",
+			annotations: map[string]map[string]string{
+				"Node202": {AnnotationLastApplied: "2024-10-01T12:30:00Z"},
+				"Node305": {AnnotationLastApplied: "2024-10-01T12:30:00Z"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			labeler := &testLabeler{data: make(map[string]map[string]string)}
+			l := NewTopologyLabeler()
+			l.setNodes([]string{"Node202", "Node305"})
+			l.setAnnotations(now, "")
+			l.bandwidth = tc.bandwidth
+			require.NoError(t, l.ApplyNodeLabels(context.TODO(), root, labeler))
+			require.Equal(t, tc.annotations, labeler.annotations)
+		})
+	}
+}
+
+func TestApplyNodeLabelsWithUplinks(t *testing.T) {
+	root, _ := fixtures.TreeTestSet()
+	spine := root.Vertices[topology.TopologyTree].Vertices["S1"]
+	spine.Vertices["S2"].Metadata = map[string]string{
+		topology.KeyUplinks:          "2",
+		topology.KeyDownlinks:        "3",
+		topology.KeyOversubscription: "1.50",
+	}
+	leaf := map[string]string{AnnotationUplinks: "2", AnnotationOversubscription: "1.50"}
+
+	testCases := []struct {
+		name        string
+		uplinks     bool
+		annotations map[string]map[string]string
+	}{
+		{
+			name:        "Case 1: uplink annotations enabled",
+			uplinks:     true,
+			annotations: map[string]map[string]string{"Node201": leaf, "Node202": leaf, "Node205": leaf},
+		},
+		{
+			name: "Case 2: uplink annotations disabled",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			labeler := &testLabeler{data: make(map[string]map[string]string)}
+			l := NewTopologyLabeler()
+			l.uplinks = tc.uplinks
+			require.NoError(t, l.ApplyNodeLabels(context.TODO(), root, labeler))
+			require.Equal(t, tc.annotations, labeler.annotations)
+		})
+	}
+}
+
 func TestApplyNodeLabelsWithBlock(t *testing.T) {
-	root, _ := translate.GetBlockWithMultiIBTestSet()
+	root, _ := fixtures.BlockWithMultiIBTestSet()
 	labeler := &testLabeler{data: make(map[string]map[string]string)}
 	data := map[string]map[string]string{
 		"Node104": {
@@ -137,3 +302,467 @@ func TestApplyNodeLabelsWithBlock(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, data, labeler.data)
 }
+
+func TestApplyNodeLabelsWithDisplayName(t *testing.T) {
+	domainMap := translate.NewDomainMap()
+	domainMap.AddHost("cb1", "node1")
+	domainMap.AddHost("cb2", "node2")
+	domainMap.SetDisplayName("cb1", "rack-a")
+	root := &topology.Vertex{
+		Vertices: map[string]*topology.Vertex{topology.TopologyBlock: domainMap.ToBlocks()},
+	}
+
+	testCases := []struct {
+		name           string
+		useDisplayName bool
+		data           map[string]map[string]string
+	}{
+		{
+			name: "Case 1: block ID",
+			data: map[string]map[string]string{
+				"node1": {"network.topology.kubernetes.io/accelerator": "block001"},
+				"node2": {"network.topology.kubernetes.io/accelerator": "block002"},
+			},
+		},
+		{
+			name:           "Case 2: display name with fallback",
+			useDisplayName: true,
+			data: map[string]map[string]string{
+				"node1": {"network.topology.kubernetes.io/accelerator": "rack-a"},
+				"node2": {"network.topology.kubernetes.io/accelerator": "block002"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			labeler := &testLabeler{data: make(map[string]map[string]string)}
+			topoLabeler := NewTopologyLabeler()
+			topoLabeler.useDisplayName = tc.useDisplayName
+			err := topoLabeler.ApplyNodeLabels(context.TODO(), root, labeler)
+			require.NoError(t, err)
+			require.Equal(t, tc.data, labeler.data)
+		})
+	}
+}
+
+func TestApplyNodeLabelsRemovesStaleLabels(t *testing.T) {
+	blockRoot, _ := fixtures.BlockWithMultiIBTestSet()
+	treeRoot, _ := fixtures.TreeTestSet()
+
+	treeLabels := func(block string) map[string]string {
+		return map[string]string{hierarchyLayerBlock: block, hierarchyLayerSpine: "S1"}
+	}
+
+	testCases := []struct {
+		name    string
+		first   *topology.Vertex
+		second  *topology.Vertex
+		labels  map[string]map[string]string
+		changes map[string][]string
+	}{
+		{
+			name:   "Case 1: nodes move from block to tree-only topology",
+			first:  blockRoot,
+			second: treeRoot,
+			labels: map[string]map[string]string{
+				"Node201": treeLabels("S2"),
+				"Node202": treeLabels("S2"),
+				"Node205": treeLabels("S2"),
+				"Node304": treeLabels("S3"),
+				"Node305": treeLabels("S3"),
+				"Node306": treeLabels("S3"),
+			},
+			changes: map[string][]string{
+				"Node201": {hierarchyLayerAccelerator, hierarchyLayerBlock, hierarchyLayerDatacenter},
+				"Node202": {hierarchyLayerAccelerator, hierarchyLayerBlock, hierarchyLayerDatacenter},
+				"Node205": {hierarchyLayerAccelerator, hierarchyLayerBlock, hierarchyLayerDatacenter},
+				"Node304": {hierarchyLayerBlock, hierarchyLayerSpine},
+				"Node305": {hierarchyLayerBlock, hierarchyLayerSpine},
+				"Node306": {hierarchyLayerBlock, hierarchyLayerSpine},
+			},
+		},
+		{
+			name:   "Case 2: nodes move from tree-only to block topology",
+			first:  treeRoot,
+			second: blockRoot,
+			labels: map[string]map[string]string{
+				"Node201": {
+					hierarchyLayerAccelerator: "B2",
+					hierarchyLayerBlock:       "S3",
+					hierarchyLayerSpine:       "S1",
+					hierarchyLayerDatacenter:  "ibRoot2",
+				},
+			},
+			changes: map[string][]string{
+				"Node201": {hierarchyLayerAccelerator, hierarchyLayerBlock, hierarchyLayerDatacenter},
+				"Node301": {hierarchyLayerAccelerator, hierarchyLayerBlock, hierarchyLayerDatacenter, hierarchyLayerSpine},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			labeler := &clusterLabeler{nodes: make(map[string]*v1.Node)}
+
+			require.NoError(t, NewTopologyLabeler().ApplyNodeLabels(context.TODO(), tc.first, labeler))
+
+			l := NewTopologyLabeler()
+			require.NoError(t, l.ApplyNodeLabels(context.TODO(), tc.second, labeler))
+
+			labels := labeler.labels()
+			for node, expected := range tc.labels {
+				require.Equal(t, expected, labels[node], node)
+			}
+			for node, expected := range tc.changes {
+				require.Equal(t, expected, l.changes[node], node)
+			}
+		})
+	}
+}
+
+// leafTree returns the tree topology of the nodes, attached to their leaf switches under a single spine switch
+func leafTree(leaves map[string][]string) *topology.Vertex {
+	spine := &topology.Vertex{ID: "spine", Vertices: make(map[string]*topology.Vertex)}
+	for leaf, nodes := range leaves {
+		sw := &topology.Vertex{ID: leaf, Vertices: make(map[string]*topology.Vertex)}
+		for _, node := range nodes {
+			sw.Vertices[node] = &topology.Vertex{ID: node, Name: node}
+		}
+		spine.Vertices[leaf] = sw
+	}
+	treeRoot := &topology.Vertex{Vertices: map[string]*topology.Vertex{"spine": spine}}
+	return &topology.Vertex{Vertices: map[string]*topology.Vertex{topology.TopologyTree: treeRoot}}
+}
+
+func TestApplyNodeLabelsWithStaleNodes(t *testing.T) {
+	initial := leafTree(map[string][]string{"leaf1": {"node1", "node2"}, "leaf2": {"node3"}})
+	// node1 is re-cabled to leaf2, and node3 leaves the topology
+	current := leafTree(map[string][]string{"leaf1": {"node2"}, "leaf2": {"node1"}})
+
+	unlabeled := map[string]string{"kubernetes.io/hostname": "node4"}
+	leafLabels := func(leaf string) map[string]string {
+		return map[string]string{hierarchyLayerBlock: leaf, hierarchyLayerSpine: "spine"}
+	}
+
+	testCases := []struct {
+		name       string
+		staleNodes string
+		nodes      []string
+		scope      []string
+		labels     map[string]map[string]string
+		changes    map[string][]string
+	}{
+		{
+			name:       "Case 1: remove stale labels",
+			staleNodes: StaleNodesRemove,
+			labels: map[string]map[string]string{
+				"node1": leafLabels("leaf2"),
+				"node2": leafLabels("leaf1"),
+				"node3": {},
+				"node4": unlabeled,
+			},
+			changes: map[string][]string{
+				"node1": {hierarchyLayerBlock},
+				"node3": {hierarchyLayerBlock, hierarchyLayerSpine},
+			},
+		},
+		{
+			name: "Case 2: remove stale labels by default",
+			labels: map[string]map[string]string{
+				"node1": leafLabels("leaf2"),
+				"node2": leafLabels("leaf1"),
+				"node3": {},
+				"node4": unlabeled,
+			},
+			changes: map[string][]string{
+				"node1": {hierarchyLayerBlock},
+				"node3": {hierarchyLayerBlock, hierarchyLayerSpine},
+			},
+		},
+		{
+			name:       "Case 3: mark stale nodes",
+			staleNodes: StaleNodesMark,
+			labels: map[string]map[string]string{
+				"node1": leafLabels("leaf2"),
+				"node2": leafLabels("leaf1"),
+				"node3": {hierarchyLayerBlock: "leaf2", hierarchyLayerSpine: "spine", LabelStale: "true"},
+				"node4": unlabeled,
+			},
+			changes: map[string][]string{
+				"node1": {hierarchyLayerBlock},
+				"node3": {LabelStale},
+			},
+		},
+		{
+			name:       "Case 4: keep stale labels",
+			staleNodes: StaleNodesKeep,
+			labels: map[string]map[string]string{
+				"node1": leafLabels("leaf2"),
+				"node2": leafLabels("leaf1"),
+				"node3": leafLabels("leaf2"),
+				"node4": unlabeled,
+			},
+			changes: map[string][]string{
+				"node1": {hierarchyLayerBlock},
+			},
+		},
+		{
+			name:  "Case 5: no reconciliation of repaired nodes",
+			nodes: []string{"node1"},
+			labels: map[string]map[string]string{
+				"node1": leafLabels("leaf2"),
+				"node2": leafLabels("leaf1"),
+				"node3": leafLabels("leaf2"),
+				"node4": unlabeled,
+			},
+			changes: map[string][]string{
+				"node1": {hierarchyLayerBlock},
+			},
+		},
+		{
+			name:  "Case 6: no reconciliation of nodes outside the request",
+			scope: []string{"node1", "node2"},
+			labels: map[string]map[string]string{
+				"node1": leafLabels("leaf2"),
+				"node2": leafLabels("leaf1"),
+				"node3": leafLabels("leaf2"),
+				"node4": unlabeled,
+			},
+			changes: map[string][]string{
+				"node1": {hierarchyLayerBlock},
+			},
+		},
+		{
+			name:  "Case 7: reconciliation of requested nodes left out of the topology",
+			scope: []string{"node1", "node2", "node3"},
+			labels: map[string]map[string]string{
+				"node1": leafLabels("leaf2"),
+				"node2": leafLabels("leaf1"),
+				"node3": {},
+				"node4": unlabeled,
+			},
+			changes: map[string][]string{
+				"node1": {hierarchyLayerBlock},
+				"node3": {hierarchyLayerBlock, hierarchyLayerSpine},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset()
+			for _, name := range []string{"node1", "node2", "node3", "node4"} {
+				node := &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: name}}
+				if name == "node4" {
+					node.Labels = unlabeled
+				}
+				_, err := client.CoreV1().Nodes().Create(context.TODO(), node, metav1.CreateOptions{})
+				require.NoError(t, err)
+			}
+			labeler := &clientLabeler{client: client}
+
+			require.NoError(t, NewTopologyLabeler().ApplyNodeLabels(context.TODO(), initial, labeler))
+
+			l := NewTopologyLabeler()
+			l.staleNodes = tc.staleNodes
+			l.setNodes(tc.nodes)
+			l.setScope(tc.scope)
+			require.NoError(t, l.ApplyNodeLabels(context.TODO(), current, labeler))
+			require.Equal(t, tc.changes, l.changes)
+
+			labels, err := labeler.ListNodeLabels(context.TODO())
+			require.NoError(t, err)
+			for name, expected := range tc.labels {
+				require.Equal(t, len(expected), len(labels[name]), name)
+				for key, val := range expected {
+					require.Equal(t, val, labels[name][key], name)
+				}
+			}
+		})
+	}
+}
+
+// chainTree returns the tree topology of a single node attached to a chain of switches, from sw1 (leaf) up to sw<depth>
+func chainTree(depth int) *topology.Vertex {
+	v := &topology.Vertex{ID: "node1", Name: "node1"}
+	for i := 1; i <= depth; i++ {
+		id := fmt.Sprintf("sw%d", i)
+		v = &topology.Vertex{ID: id, Vertices: map[string]*topology.Vertex{v.ID: v}}
+	}
+	treeRoot := &topology.Vertex{Vertices: map[string]*topology.Vertex{v.ID: v}}
+	return &topology.Vertex{Vertices: map[string]*topology.Vertex{topology.TopologyTree: treeRoot}}
+}
+
+func TestApplyNodeLabelsWithLabelKeys(t *testing.T) {
+	keys := []string{"example.com/leaf", "example.com/spine", "example.com/core"}
+
+	testCases := []struct {
+		name    string
+		depth   int
+		keys    []string
+		labels  map[string]string
+		skipped int
+	}{
+		{
+			name:  "Case 1: 2-level tree",
+			depth: 2,
+			keys:  keys,
+			labels: map[string]string{
+				"example.com/leaf":  "sw1",
+				"example.com/spine": "sw2",
+			},
+		},
+		{
+			name:  "Case 2: 3-level tree",
+			depth: 3,
+			keys:  keys,
+			labels: map[string]string{
+				"example.com/leaf":  "sw1",
+				"example.com/spine": "sw2",
+				"example.com/core":  "sw3",
+			},
+		},
+		{
+			name:  "Case 3: 4-level tree",
+			depth: 4,
+			keys:  keys,
+			labels: map[string]string{
+				"example.com/leaf":  "sw1",
+				"example.com/spine": "sw2",
+				"example.com/core":  "sw3",
+			},
+			skipped: 1,
+		},
+		{
+			name:  "Case 4: 5-level tree",
+			depth: 5,
+			keys:  keys,
+			labels: map[string]string{
+				"example.com/leaf":  "sw1",
+				"example.com/spine": "sw2",
+				"example.com/core":  "sw3",
+			},
+			skipped: 2,
+		},
+		{
+			name:  "Case 5: 5-level tree with 5 label keys",
+			depth: 5,
+			keys:  append(keys, "example.com/zone", "example.com/region"),
+			labels: map[string]string{
+				"example.com/leaf":   "sw1",
+				"example.com/spine":  "sw2",
+				"example.com/core":   "sw3",
+				"example.com/zone":   "sw4",
+				"example.com/region": "sw5",
+			},
+		},
+		{
+			name:  "Case 6: 4-level tree with default label keys",
+			depth: 4,
+			labels: map[string]string{
+				hierarchyLayerBlock:      "sw1",
+				hierarchyLayerSpine:      "sw2",
+				hierarchyLayerDatacenter: "sw3",
+			},
+			skipped: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			labeler := &testLabeler{data: make(map[string]map[string]string)}
+			l := NewTopologyLabeler()
+			l.setTierKeys(tc.keys)
+			require.NoError(t, l.ApplyNodeLabels(context.TODO(), chainTree(tc.depth), labeler))
+			require.Equal(t, map[string]map[string]string{"node1": tc.labels}, labeler.data)
+			require.Equal(t, tc.skipped, l.skipped)
+		})
+	}
+}
+
+func TestValidateLabelKeys(t *testing.T) {
+	testCases := []struct {
+		name string
+		keys []string
+		err  string
+	}{
+		{
+			name: "Case 1: default keys",
+		},
+		{
+			name: "Case 2: valid keys",
+			keys: []string{"example.com/leaf", "spine"},
+		},
+		{
+			name: "Case 3: invalid key",
+			keys: []string{"example.com/leaf switch"},
+			err:  `invalid label key "example.com/leaf switch"`,
+		},
+		{
+			name: "Case 4: duplicate key",
+			keys: []string{"example.com/leaf", "example.com/leaf"},
+			err:  `duplicate label key "example.com/leaf"`,
+		},
+		{
+			name: "Case 5: accelerator key",
+			keys: []string{hierarchyLayerAccelerator},
+			err:  `duplicate label key "network.topology.kubernetes.io/accelerator"`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidateLabelKeys(tc.keys)
+			if len(tc.err) != 0 {
+				require.ErrorContains(t, err, tc.err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestCheckLabel(t *testing.T) {
+	testCases := []struct {
+		name   string
+		val    string
+		hashed bool
+	}{
+		{
+			name: "Case 1: valid value",
+			val:  "ib-leaf_01.rack2",
+		},
+		{
+			name: "Case 2: 63 characters",
+			val:  strings.Repeat("s", 63),
+		},
+		{
+			name:   "Case 3: 64 characters",
+			val:    strings.Repeat("s", 64),
+			hashed: true,
+		},
+		{
+			name:   "Case 4: invalid characters",
+			val:    "ocid1.switch/rack:1",
+			hashed: true,
+		},
+		{
+			name:   "Case 5: invalid first character",
+			val:    "-leaf",
+			hashed: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			val := NewTopologyLabeler().checkLabel(tc.val)
+			if tc.hashed {
+				require.Regexp(t, "^x[0-9a-f]{1,16}$", val)
+				require.Equal(t, val, NewTopologyLabeler().checkLabel(tc.val))
+			} else {
+				require.Equal(t, tc.val, val)
+			}
+		})
+	}
+}
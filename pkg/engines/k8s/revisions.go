@@ -0,0 +1,197 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+
+	"github.com/NVIDIA/topograph/pkg/engines"
+)
+
+// DefaultConfigRevisions is the default number of previous topology configs kept in the ConfigMap
+const DefaultConfigRevisions = 3
+
+// AnnotationRolledBack holds the revision restored by the last rollback of the topology ConfigMap
+const AnnotationRolledBack = "topograph.nvidia.com/rolled-back-revision"
+
+// revisionKey returns the key of the revision, e.g., "topology.conf.1" for the previous config of "topology.conf"
+func revisionKey(key string, revision int) string {
+	return key + "." + strconv.Itoa(revision)
+}
+
+// applyTopologyConfig sets the topology config of the key in the ConfigMap, and returns false if the config is unchanged.
+// The replaced config is kept as revision 1, shifting the older revisions, and up to the given number of revisions is kept.
+// The ConfigMap is written at the version it was read at, and the rotation is redone on top of a concurrent change.
+func applyTopologyConfig(ctx context.Context, client kubernetes.Interface, name, namespace, key, data string, revisions int, now time.Time, uid string) (bool, error) {
+	changed := true
+	err := retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		cm, exists, err := getTopologyConfigMap(ctx, client, name, namespace)
+		if err != nil {
+			return err
+		}
+		if current, ok := cm.Data[key]; ok && current == data {
+			klog.Infof("Topology config %s/%s unchanged", namespace, name)
+			changed = false
+			return nil
+		}
+
+		setTopologyConfig(cm, key, data, revisions, now, uid)
+
+		changed = true
+		return writeTopologyConfigMap(ctx, client, cm, exists)
+	})
+	return changed, err
+}
+
+// rollbackTopologyConfig restores the revision of the topology config of the key in the ConfigMap.
+// The replaced config is kept as revision 1, so that the rollback can be reverted.
+func rollbackTopologyConfig(ctx context.Context, client kubernetes.Interface, name, namespace, key string, revision, revisions int, now time.Time) error {
+	return retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		cm, exists, err := getTopologyConfigMap(ctx, client, name, namespace)
+		if err != nil {
+			return err
+		}
+
+		data, ok := cm.Data[revisionKey(key, revision)]
+		if !ok {
+			return fmt.Errorf("%w: no revision %d of %s in configmap %s/%s", engines.ErrRevisionNotFound, revision, key, namespace, name)
+		}
+		klog.Infof("Rolling back topology config %s/%s to revision %d", namespace, name, revision)
+
+		setTopologyConfig(cm, key, data, revisions, now, cm.Annotations[revisionKey(AnnotationRequestUID, revision)])
+		cm.Annotations[AnnotationRolledBack] = strconv.Itoa(revision)
+
+		return writeTopologyConfigMap(ctx, client, cm, exists)
+	})
+}
+
+// getTopologyConfigMap returns a copy of the ConfigMap to be updated and true,
+// or a new ConfigMap and false if it does not exist.
+// The copy keeps the resource version it was read at, so that its update fails on a concurrent change.
+func getTopologyConfigMap(ctx context.Context, client kubernetes.Interface, name, namespace string) (*v1.ConfigMap, bool, error) {
+	existing, err := client.CoreV1().ConfigMaps(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return &v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}}, false, nil
+		}
+		return nil, false, fmt.Errorf("failed to get configmap %s/%s: %w", namespace, name, err)
+	}
+
+	return existing.DeepCopy(), true, nil
+}
+
+// writeTopologyConfigMap updates the existing ConfigMap at the version it was read at, or creates the new one.
+// It returns a conflict error if the ConfigMap was changed, created or deleted since it was read.
+func writeTopologyConfigMap(ctx context.Context, client kubernetes.Interface, cm *v1.ConfigMap, exists bool) error {
+	cms := client.CoreV1().ConfigMaps(cm.Namespace)
+	resource := v1.Resource("configmaps")
+
+	if !exists {
+		_, err := cms.Create(ctx, cm, metav1.CreateOptions{})
+		switch {
+		case err == nil:
+			klog.V(4).Infof("Successfully created configmap %s/%s", cm.Namespace, cm.Name)
+			return nil
+		case errors.IsAlreadyExists(err):
+			return errors.NewConflict(resource, cm.Name, fmt.Errorf("configmap was created concurrently"))
+		default:
+			return fmt.Errorf("failed to create configmap %s/%s: %w", cm.Namespace, cm.Name, err)
+		}
+	}
+
+	_, err := cms.Update(ctx, cm, metav1.UpdateOptions{})
+	switch {
+	case err == nil:
+		klog.V(4).Infof("Successfully updated configmap %s/%s", cm.Namespace, cm.Name)
+		return nil
+	case errors.IsConflict(err):
+		klog.Infof("Configmap %s/%s was changed concurrently; retrying", cm.Namespace, cm.Name)
+		return err
+	case errors.IsNotFound(err):
+		return errors.NewConflict(resource, cm.Name, fmt.Errorf("configmap was deleted concurrently"))
+	default:
+		return fmt.Errorf("failed to update configmap %s/%s: %w", cm.Namespace, cm.Name, err)
+	}
+}
+
+// setTopologyConfig sets the config of the key, with the time and the request UID of the config as annotations.
+// The replaced config and its annotations become revision 1.
+func setTopologyConfig(cm *v1.ConfigMap, key, data string, revisions int, now time.Time, uid string) {
+	if cm.Data == nil {
+		cm.Data = make(map[string]string)
+	}
+	if cm.Annotations == nil {
+		cm.Annotations = make(map[string]string)
+	}
+
+	if current, ok := cm.Data[key]; ok && revisions > 0 {
+		for revision := revisions; revision > 1; revision-- {
+			moveRevision(cm, key, revision-1, revision)
+		}
+		cm.Data[revisionKey(key, 1)] = current
+		copyAnnotation(cm.Annotations, AnnotationLastApplied, revisionKey(AnnotationLastApplied, 1))
+		copyAnnotation(cm.Annotations, AnnotationRequestUID, revisionKey(AnnotationRequestUID, 1))
+	}
+
+	// drop the revisions beyond the limit
+	for revision := revisions + 1; ; revision++ {
+		if _, ok := cm.Data[revisionKey(key, revision)]; !ok {
+			break
+		}
+		delete(cm.Data, revisionKey(key, revision))
+		delete(cm.Annotations, revisionKey(AnnotationLastApplied, revision))
+		delete(cm.Annotations, revisionKey(AnnotationRequestUID, revision))
+	}
+
+	cm.Data[key] = data
+	cm.Annotations[AnnotationLastApplied] = now.UTC().Format(time.RFC3339)
+	if len(uid) != 0 {
+		cm.Annotations[AnnotationRequestUID] = uid
+	} else {
+		delete(cm.Annotations, AnnotationRequestUID)
+	}
+	delete(cm.Annotations, AnnotationRolledBack)
+}
+
+// moveRevision moves the config and the annotations of a revision to another revision
+func moveRevision(cm *v1.ConfigMap, key string, from, to int) {
+	if data, ok := cm.Data[revisionKey(key, from)]; ok {
+		cm.Data[revisionKey(key, to)] = data
+	} else {
+		delete(cm.Data, revisionKey(key, to))
+	}
+	copyAnnotation(cm.Annotations, revisionKey(AnnotationLastApplied, from), revisionKey(AnnotationLastApplied, to))
+	copyAnnotation(cm.Annotations, revisionKey(AnnotationRequestUID, from), revisionKey(AnnotationRequestUID, to))
+}
+
+// copyAnnotation copies the annotation value to another key, or removes the key if the annotation is not set
+func copyAnnotation(annotations map[string]string, from, to string) {
+	if val, ok := annotations[from]; ok {
+		annotations[to] = val
+	} else {
+		delete(annotations, to)
+	}
+}
@@ -0,0 +1,217 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package k8s
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/NVIDIA/topograph/pkg/engines"
+)
+
+func TestApplyTopologyConfig(t *testing.T) {
+	ctx := context.TODO()
+	client := fake.NewSimpleClientset()
+	now := time.Date(2024, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	apply := func(data, uid string, changed bool) {
+		ok, err := applyTopologyConfig(ctx, client, "topology", "default", "topology.conf", data, 2, now, uid)
+		require.NoError(t, err)
+		require.Equal(t, changed, ok)
+		now = now.Add(time.Hour)
+	}
+
+	// the first config has no revision
+	apply("conf-1", "uid-1", true)
+	cm, err := client.CoreV1().ConfigMaps("default").Get(ctx, "topology", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"topology.conf": "conf-1"}, cm.Data)
+	require.Equal(t, map[string]string{
+		AnnotationLastApplied: "2024-10-01T12:00:00Z",
+		AnnotationRequestUID:  "uid-1",
+	}, cm.Annotations)
+
+	// an unchanged config is not rotated
+	apply("conf-1", "uid-2", false)
+
+	// rotation
+	apply("conf-2", "uid-3", true)
+	apply("conf-3", "", true)
+	cm, err = client.CoreV1().ConfigMaps("default").Get(ctx, "topology", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"topology.conf":   "conf-3",
+		"topology.conf.1": "conf-2",
+		"topology.conf.2": "conf-1",
+	}, cm.Data)
+	require.Equal(t, map[string]string{
+		AnnotationLastApplied:        "2024-10-01T15:00:00Z",
+		AnnotationLastApplied + ".1": "2024-10-01T14:00:00Z",
+		AnnotationRequestUID + ".1":  "uid-3",
+		AnnotationLastApplied + ".2": "2024-10-01T12:00:00Z",
+		AnnotationRequestUID + ".2":  "uid-1",
+	}, cm.Annotations)
+
+	// truncation at the number of revisions
+	apply("conf-4", "uid-4", true)
+	cm, err = client.CoreV1().ConfigMaps("default").Get(ctx, "topology", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"topology.conf":   "conf-4",
+		"topology.conf.1": "conf-3",
+		"topology.conf.2": "conf-2",
+	}, cm.Data)
+	require.Equal(t, map[string]string{
+		AnnotationLastApplied:        "2024-10-01T16:00:00Z",
+		AnnotationRequestUID:         "uid-4",
+		AnnotationLastApplied + ".1": "2024-10-01T15:00:00Z",
+		AnnotationLastApplied + ".2": "2024-10-01T14:00:00Z",
+		AnnotationRequestUID + ".2":  "uid-3",
+	}, cm.Annotations)
+
+	// a lower number of revisions drops the older ones
+	ok, err := applyTopologyConfig(ctx, client, "topology", "default", "topology.conf", "conf-5", 0, now, "uid-5")
+	require.NoError(t, err)
+	require.True(t, ok)
+	cm, err = client.CoreV1().ConfigMaps("default").Get(ctx, "topology", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"topology.conf": "conf-5"}, cm.Data)
+}
+
+func TestApplyTopologyConfigConflict(t *testing.T) {
+	ctx := context.TODO()
+	now := time.Date(2024, 10, 1, 12, 0, 0, 0, time.UTC)
+	gvr := v1.SchemeGroupVersion.WithResource("configmaps")
+	client := fake.NewSimpleClientset(&v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "topology", Namespace: "default", ResourceVersion: "1"},
+		Data:       map[string]string{"topology.conf": "conf-1"},
+	})
+
+	// the updates are accepted at the current resource version only, as by the API server,
+	// and the first update is preceded by a concurrent write of another config
+	var updates int
+	var concurrentData string
+	client.PrependReactor("update", "configmaps", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		cm := action.(k8stesting.UpdateAction).GetObject().(*v1.ConfigMap).DeepCopy()
+		updates++
+		if updates == 1 {
+			obj, err := client.Tracker().Get(gvr, "default", "topology")
+			require.NoError(t, err)
+			concurrent := obj.(*v1.ConfigMap).DeepCopy()
+			concurrent.Data["topology.conf"] = concurrentData
+			version, err := strconv.Atoi(concurrent.ResourceVersion)
+			require.NoError(t, err)
+			concurrent.ResourceVersion = strconv.Itoa(version + 1)
+			require.NoError(t, client.Tracker().Update(gvr, concurrent, "default"))
+		}
+
+		obj, err := client.Tracker().Get(gvr, "default", "topology")
+		if err != nil {
+			return true, nil, err
+		}
+		if current := obj.(*v1.ConfigMap).ResourceVersion; cm.ResourceVersion != current {
+			return true, nil, apierrors.NewConflict(v1.Resource("configmaps"), cm.Name,
+				fmt.Errorf("resource version %s, current %s", cm.ResourceVersion, current))
+		}
+		version, err := strconv.Atoi(cm.ResourceVersion)
+		require.NoError(t, err)
+		cm.ResourceVersion = strconv.Itoa(version + 1)
+		return true, cm, client.Tracker().Update(gvr, cm, "default")
+	})
+
+	// the rotation is redone on top of the concurrent config, instead of overwriting it
+	concurrentData = "conf-2"
+	ok, err := applyTopologyConfig(ctx, client, "topology", "default", "topology.conf", "conf-3", 2, now, "uid-3")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, 2, updates)
+
+	cm, err := client.CoreV1().ConfigMaps("default").Get(ctx, "topology", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, "3", cm.ResourceVersion)
+	require.Equal(t, map[string]string{
+		"topology.conf":   "conf-3",
+		"topology.conf.1": "conf-2",
+		"topology.conf.2": "conf-1",
+	}, cm.Data)
+
+	// the rollback is redone as well
+	updates = 0
+	concurrentData = "conf-4"
+	require.NoError(t, rollbackTopologyConfig(ctx, client, "topology", "default", "topology.conf", 2, 2, now))
+	require.Equal(t, 2, updates)
+
+	cm, err = client.CoreV1().ConfigMaps("default").Get(ctx, "topology", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, "5", cm.ResourceVersion)
+	require.Equal(t, map[string]string{
+		"topology.conf":   "conf-1",
+		"topology.conf.1": "conf-4",
+		"topology.conf.2": "conf-2",
+	}, cm.Data)
+}
+
+func TestRollbackTopologyConfig(t *testing.T) {
+	ctx := context.TODO()
+	client := fake.NewSimpleClientset()
+	now := time.Date(2024, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	for i, data := range []string{"conf-1", "conf-2", "conf-3"} {
+		_, err := applyTopologyConfig(ctx, client, "topology", "default", "topology.conf", data, 3, now.Add(time.Duration(i)*time.Hour), "uid-"+data)
+		require.NoError(t, err)
+	}
+
+	// missing revision
+	err := rollbackTopologyConfig(ctx, client, "topology", "default", "topology.conf", 3, 3, now)
+	require.True(t, errors.Is(err, engines.ErrRevisionNotFound))
+	require.EqualError(t, err, "topology config revision not found: no revision 3 of topology.conf in configmap default/topology")
+
+	// the restored revision keeps its request UID, and the replaced config becomes revision 1
+	require.NoError(t, rollbackTopologyConfig(ctx, client, "topology", "default", "topology.conf", 2, 3, now.Add(5*time.Hour)))
+	cm, err := client.CoreV1().ConfigMaps("default").Get(ctx, "topology", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"topology.conf":   "conf-1",
+		"topology.conf.1": "conf-3",
+		"topology.conf.2": "conf-2",
+		"topology.conf.3": "conf-1",
+	}, cm.Data)
+	require.Equal(t, "2", cm.Annotations[AnnotationRolledBack])
+	require.Equal(t, "2024-10-01T17:00:00Z", cm.Annotations[AnnotationLastApplied])
+	require.Equal(t, "uid-conf-1", cm.Annotations[AnnotationRequestUID])
+	require.Equal(t, "uid-conf-3", cm.Annotations[AnnotationRequestUID+".1"])
+
+	// a new config clears the rollback annotation
+	_, err = applyTopologyConfig(ctx, client, "topology", "default", "topology.conf", "conf-4", 3, now.Add(6*time.Hour), "uid-conf-4")
+	require.NoError(t, err)
+	cm, err = client.CoreV1().ConfigMaps("default").Get(ctx, "topology", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.NotContains(t, cm.Annotations, AnnotationRolledBack)
+	require.Equal(t, "conf-1", cm.Data["topology.conf.1"])
+}
@@ -20,15 +20,21 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"os"
+	"sort"
+	"strconv"
 	"strings"
 
 	"k8s.io/klog/v2"
 
+	"github.com/NVIDIA/topograph/internal/cluset"
 	"github.com/NVIDIA/topograph/internal/config"
 	"github.com/NVIDIA/topograph/internal/exec"
 	"github.com/NVIDIA/topograph/internal/files"
+	"github.com/NVIDIA/topograph/internal/remote"
 	"github.com/NVIDIA/topograph/pkg/engines"
 	"github.com/NVIDIA/topograph/pkg/metrics"
 	"github.com/NVIDIA/topograph/pkg/topology"
@@ -44,28 +50,113 @@ const TopologyHeader = `
 
 const NAME = "slurm"
 
-type SlurmEngine struct{}
+type SlurmEngine struct {
+	// params are the engine parameters of the request, if known at load time
+	params *Params
+}
 
 type Params struct {
 	Plugin         string `mapstructure:"plugin"`
 	TopoConfigPath string `mapstructure:"topology_config_path"`
 	BlockSizes     string `mapstructure:"block_sizes"`
+	BlockSizeHint  string `mapstructure:"block_size_hint"`
+	FlatMode       string `mapstructure:"flat_mode"`
 	Reconfigure    bool   `mapstructure:"reconfigure"`
+	DryRun         bool   `mapstructure:"dry_run"`
+	Format         string `mapstructure:"format"`
+	EchoParams     bool   `mapstructure:"echo_params"`
+	// YAMLSchemaVersion selects the topology.yaml dialect of the yaml format: 25.05 (default) or 24.11
+	YAMLSchemaVersion string `mapstructure:"yaml_schema_version"`
+	// BlockSizeStrategy selects the domain size the block sizes are planned for: min (default), median or histogram
+	BlockSizeStrategy string `mapstructure:"block_size_strategy"`
+	// ExpectedBlockSizes plans the block sizes for the expected block sizes set by the providers, e.g. 18 for GB200 NVL72 racks
+	ExpectedBlockSizes bool `mapstructure:"expected_block_sizes"`
+	// EmitReverseIndex appends the node lookup table to the topology config
+	EmitReverseIndex bool `mapstructure:"emit_reverse_index"`
+	// EmitAccelerators adds the accelerator domains to the topology/tree config
+	EmitAccelerators bool `mapstructure:"emit_accelerators"`
+	// MaxMatrixNodes caps the node count of the matrix format, 1024 by default
+	MaxMatrixNodes int `mapstructure:"max_matrix_nodes"`
+	// Comments selects the comment lines mapping the switch and block names to the original IDs: full (default), none or hash
+	Comments string `mapstructure:"comments"`
+	// DuplicateNodes selects the handling of nodes in more than one block or under more than one leaf switch: first-wins (default) or error
+	DuplicateNodes string `mapstructure:"duplicate_nodes"`
+	// Validate enables comparing the nodes in the topology config with the Slurm node list
+	Validate        bool `mapstructure:"validate"`
+	MaxMissingNodes int  `mapstructure:"max_missing_nodes"`
+	// ExcludeNodes is the hostlist of the nodes left out of the topology, e.g. login[01-04],viz[1-2]
+	ExcludeNodes string `mapstructure:"exclude_nodes"`
+	// ExcludePartitions is the comma-separated list of the partitions whose nodes are left out of the topology
+	ExcludePartitions string `mapstructure:"exclude_partitions"`
+	// Reservation scopes the topology to the nodes of the Slurm reservation; the config is returned, not written
+	Reservation string `mapstructure:"reservation"`
+	// JobID scopes the topology to the nodes of the Slurm job; the config is returned, not written
+	JobID string `mapstructure:"job_id"`
+	// SSH, if set, copies the topology config to an external controller host
+	SSH *remote.Params `mapstructure:"ssh"`
+}
+
+// ResolvedParams is the effective parameter set after defaulting and fallbacks.
+// It must not contain credentials or other secrets.
+type ResolvedParams struct {
+	Plugin           string `json:"plugin"`
+	PluginSource     string `json:"plugin_source"`
+	BlockSizes       []int  `json:"block_sizes,omitempty"`
+	BlockSizesSource string `json:"block_sizes_source,omitempty"`
+	Format           string `json:"format"`
+	TopoConfigPath   string `json:"topology_config_path,omitempty"`
+	Reconfigure      bool   `json:"reconfigure"`
+	DryRun           bool   `json:"dry_run"`
+	// YAMLSchemaVersion is set for the yaml format
+	YAMLSchemaVersion string `json:"yaml_schema_version,omitempty"`
+	// Reservation and JobID are set for the topology scoped to a reservation or a job
+	Reservation string `json:"reservation,omitempty"`
+	JobID       string `json:"job_id,omitempty"`
+}
+
+// EchoResponse is the engine response when the resolved parameters are requested
+type EchoResponse struct {
+	Params *ResolvedParams `json:"params"`
+	Output string          `json:"output"`
 }
 
+// sources of the resolved parameters
+const (
+	SourceRequest  = "request"
+	SourceDefault  = "default"
+	SourceFallback = "fallback"
+	SourceDerived  = "derived"
+)
+
 type instanceMapper interface {
 	Instances2NodeMap(ctx context.Context, nodes []string) (map[string]string, error)
 	GetComputeInstancesRegion() (string, error)
 }
 
+// regionMapper is optionally implemented by providers whose nodes may span several regions
+type regionMapper interface {
+	GetInstancesRegions(ctx context.Context, nodes []string) (map[string]string, error)
+}
+
 var ErrEnvironmentUnsupported = errors.New("environment must implement instanceMapper")
 
 func NamedLoader() (string, engines.Loader) {
 	return NAME, Loader
 }
 
-func Loader(ctx context.Context, config engines.Config) (engines.Engine, error) {
-	return New()
+func Loader(ctx context.Context, cfg engines.Config) (engines.Engine, error) {
+	eng, err := New()
+	if err != nil {
+		return nil, err
+	}
+
+	var p Params
+	if err := config.Decode(cfg.Params, &p); err != nil {
+		return nil, err
+	}
+	eng.params = &p
+
+	return eng, nil
 }
 
 func New() (*SlurmEngine, error) {
@@ -78,10 +169,187 @@ func (eng *SlurmEngine) GetComputeInstances(ctx context.Context, environment eng
 		return nil, ErrEnvironmentUnsupported
 	}
 
-	nodes, err := GetNodeList(ctx)
+	params := eng.params
+	if params == nil {
+		params = &Params{}
+	}
+
+	nodes, err := getNodes(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	excluded, err := getExcludedNodes(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	nodes = filterNodes(nodes, excluded)
+
+	return getComputeInstances(ctx, instanceMapper, nodes)
+}
+
+// getNodes returns the nodes of the reservation or the job, if either is given, or all cluster nodes otherwise
+func getNodes(ctx context.Context, params *Params) ([]string, error) {
+	switch {
+	case len(params.Reservation) != 0 && len(params.JobID) != 0:
+		return nil, fmt.Errorf("reservation and job_id are mutually exclusive")
+	case len(params.Reservation) != 0:
+		return getReservationNodes(ctx, params.Reservation)
+	case len(params.JobID) != 0:
+		return getJobNodes(ctx, params.JobID)
+	default:
+		return GetNodeList(ctx)
+	}
+}
+
+// getReservationNodes returns the nodes of the Slurm reservation
+func getReservationNodes(ctx context.Context, reservation string) ([]string, error) {
+	stdout, err := exec.Exec(ctx, "scontrol", []string{"show", "reservation", reservation, "-o"}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	klog.V(4).Infof("stdout: %s", stdout.String())
+
+	return parseReservationNodes(stdout.String(), reservation)
+}
+
+// parseReservationNodes returns the nodes of the reservation in the one-line scontrol output
+func parseReservationNodes(output, reservation string) ([]string, error) {
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		var name, nodeList string
+		for _, field := range strings.Fields(scanner.Text()) {
+			if key, val, ok := strings.Cut(field, "="); ok {
+				switch key {
+				case "ReservationName":
+					name = val
+				case "Nodes":
+					nodeList = val
+				}
+			}
+		}
+		if name != reservation {
+			continue
+		}
+		if len(nodeList) == 0 || nodeList == "(null)" {
+			return nil, fmt.Errorf("no nodes in reservation %q", reservation)
+		}
+		nodes, err := cluset.Expand(nodeList)
+		if err != nil {
+			return nil, fmt.Errorf("invalid node list %q of reservation %q: %v", nodeList, reservation, err)
+		}
+		return nodes, nil
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed scan output: %v", err)
+	}
+
+	return nil, fmt.Errorf("reservation %q not found", reservation)
+}
+
+// getJobNodes returns the nodes allocated to the Slurm job
+func getJobNodes(ctx context.Context, jobID string) ([]string, error) {
+	stdout, err := exec.Exec(ctx, "squeue", []string{"-h", "-j", jobID, "-o", "%N"}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	klog.V(4).Infof("stdout: %s", stdout.String())
+
+	nodes, err := parseNodeLists(stdout.String())
 	if err != nil {
 		return nil, err
 	}
+	if len(nodes) == 0 {
+		return nil, fmt.Errorf("no nodes allocated to job %q", jobID)
+	}
+
+	return nodes, nil
+}
+
+// getExcludedNodes returns the nodes left out of the topology: the nodes of the exclude_nodes hostlist
+// and the nodes of the exclude_partitions partitions
+func getExcludedNodes(ctx context.Context, params *Params) (map[string]bool, error) {
+	excluded := make(map[string]bool)
+	if len(params.ExcludeNodes) != 0 {
+		nodes, err := cluset.Expand(params.ExcludeNodes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exclude_nodes %q: %v", params.ExcludeNodes, err)
+		}
+		for _, node := range nodes {
+			excluded[node] = true
+		}
+	}
+
+	if len(params.ExcludePartitions) != 0 {
+		nodes, err := getPartitionNodes(ctx, params.ExcludePartitions)
+		if err != nil {
+			return nil, err
+		}
+		for _, node := range nodes {
+			excluded[node] = true
+		}
+	}
+
+	return excluded, nil
+}
+
+// getPartitionNodes returns the nodes of the comma-separated Slurm partitions
+func getPartitionNodes(ctx context.Context, partitions string) ([]string, error) {
+	stdout, err := exec.Exec(ctx, "sinfo", []string{"-h", "-p", partitions, "-o", "%N"}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	klog.V(4).Infof("stdout: %s", stdout.String())
+
+	return parseNodeLists(stdout.String())
+}
+
+// parseNodeLists returns the nodes of the sinfo or squeue output, a hostlist per line
+func parseNodeLists(output string) ([]string, error) {
+	nodes := []string{}
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || line == "n/a" {
+			continue
+		}
+		expanded, err := cluset.Expand(line)
+		if err != nil {
+			return nil, fmt.Errorf("invalid node list %q: %v", line, err)
+		}
+		nodes = append(nodes, expanded...)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed scan output: %v", err)
+	}
+
+	return nodes, nil
+}
+
+// filterNodes returns the nodes that are not excluded
+func filterNodes(nodes []string, excluded map[string]bool) []string {
+	if len(excluded) == 0 {
+		return nodes
+	}
+
+	filtered := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		if !excluded[node] {
+			filtered = append(filtered, node)
+		}
+	}
+	return filtered
+}
+
+func getComputeInstances(ctx context.Context, instanceMapper instanceMapper, nodes []string) ([]topology.ComputeInstances, error) {
+	if regionMapper, ok := instanceMapper.(regionMapper); ok {
+		return getRegionalComputeInstances(ctx, instanceMapper, regionMapper, nodes)
+	}
 
 	i2n, err := instanceMapper.Instances2NodeMap(ctx, nodes)
 	if err != nil {
@@ -99,6 +367,54 @@ func (eng *SlurmEngine) GetComputeInstances(ctx context.Context, environment eng
 	}}, nil
 }
 
+// getRegionalComputeInstances groups the nodes by region and returns a ComputeInstances entry per region.
+// Nodes with unknown region are assigned to the default compute instances region.
+func getRegionalComputeInstances(ctx context.Context, instanceMapper instanceMapper, regionMapper regionMapper, nodes []string) ([]topology.ComputeInstances, error) {
+	n2r, err := regionMapper.GetInstancesRegions(ctx, nodes)
+	if err != nil {
+		return nil, err
+	}
+
+	regionNodes := make(map[string][]string)
+	var unknown []string
+	for _, node := range nodes {
+		if region, ok := n2r[node]; ok && len(region) != 0 {
+			regionNodes[region] = append(regionNodes[region], node)
+		} else {
+			unknown = append(unknown, node)
+		}
+	}
+
+	if len(unknown) != 0 {
+		region, err := instanceMapper.GetComputeInstancesRegion()
+		if err != nil {
+			return nil, err
+		}
+		klog.Warningf("Missing region for nodes %v; using %q", unknown, region)
+		regionNodes[region] = append(regionNodes[region], unknown...)
+	}
+
+	regions := make([]string, 0, len(regionNodes))
+	for region := range regionNodes {
+		regions = append(regions, region)
+	}
+	sort.Strings(regions)
+
+	cis := make([]topology.ComputeInstances, 0, len(regions))
+	for _, region := range regions {
+		i2n, err := instanceMapper.Instances2NodeMap(ctx, regionNodes[region])
+		if err != nil {
+			return nil, err
+		}
+		cis = append(cis, topology.ComputeInstances{
+			Region:    region,
+			Instances: i2n,
+		})
+	}
+
+	return cis, nil
+}
+
 func GetNodeList(ctx context.Context) ([]string, error) {
 	stdout, err := exec.Exec(ctx, "scontrol", []string{"show", "nodes", "-o"}, nil)
 	if err != nil {
@@ -130,6 +446,20 @@ func (eng *SlurmEngine) GenerateOutput(ctx context.Context, tree *topology.Verte
 	return GenerateOutput(ctx, tree, params)
 }
 
+// DefaultParams returns the parameters with the defaults resolved by GenerateOutput
+func DefaultParams() Params {
+	return Params{
+		Plugin:            topology.TopologyTree,
+		Format:            translate.FormatConf,
+		YAMLSchemaVersion: translate.DefaultYAMLSchema,
+		BlockSizeStrategy: translate.BlockSizeStrategyMin,
+		FlatMode:          translate.FlatModeEmpty,
+		Comments:          translate.CommentsFull,
+		DuplicateNodes:    translate.DuplicateNodesFirstWins,
+		MaxMatrixNodes:    translate.DefaultMaxMatrixNodes,
+	}
+}
+
 func GenerateOutput(ctx context.Context, tree *topology.Vertex, params map[string]any) ([]byte, error) {
 	var p Params
 	if err := config.Decode(params, &p); err != nil {
@@ -142,15 +472,36 @@ func GenerateOutput(ctx context.Context, tree *topology.Vertex, params map[strin
 func GenerateOutputParams(ctx context.Context, tree *topology.Vertex, params *Params) ([]byte, error) {
 	buf := &bytes.Buffer{}
 	path, plugin := params.TopoConfigPath, params.Plugin
+	resolved := &ResolvedParams{
+		PluginSource:   SourceRequest,
+		TopoConfigPath: path,
+		Reconfigure:    params.Reconfigure,
+		DryRun:         params.DryRun,
+		Reservation:    params.Reservation,
+		JobID:          params.JobID,
+	}
+	if len(params.Reservation) != 0 && len(params.JobID) != 0 {
+		return nil, fmt.Errorf("reservation and job_id are mutually exclusive")
+	}
+	// the topology of a reservation or a job is returned to the caller, not applied to the cluster
+	scoped := len(params.Reservation) != 0 || len(params.JobID) != 0
+	resolved.DryRun = params.DryRun || scoped
 
 	// set and validate plugin
 	switch plugin {
 	case "":
 		plugin = topology.TopologyTree
+		resolved.PluginSource = SourceDefault
 	case topology.TopologyTree:
 		if _, ok := tree.Vertices[topology.TopologyTree]; !ok {
 			return nil, fmt.Errorf("missing tree topology")
 		}
+	case topology.TopologyFlat:
+		switch params.FlatMode {
+		case "", translate.FlatModeEmpty, translate.FlatModeSwitch:
+		default:
+			return nil, fmt.Errorf("unsupported flat mode %q", params.FlatMode)
+		}
 	case topology.TopologyBlock:
 		if _, ok := tree.Vertices[topology.TopologyTree]; !ok {
 			return nil, fmt.Errorf("missing tree topology")
@@ -158,13 +509,57 @@ func GenerateOutputParams(ctx context.Context, tree *topology.Vertex, params *Pa
 		if _, ok := tree.Vertices[topology.TopologyBlock]; !ok {
 			return nil, fmt.Errorf("missing block topology")
 		}
+		switch params.BlockSizeStrategy {
+		case "", translate.BlockSizeStrategyMin, translate.BlockSizeStrategyMedian, translate.BlockSizeStrategyHistogram:
+		default:
+			return nil, fmt.Errorf("unsupported block size strategy %q", params.BlockSizeStrategy)
+		}
 	default:
 		klog.Infof("Unsupported topology plugin %s. Using %s", plugin, topology.TopologyTree)
 		plugin = topology.TopologyTree
+		resolved.PluginSource = SourceFallback
 		metrics.AddValidationError("unsupported plugin")
 	}
+	resolved.Plugin = plugin
 
-	if len(path) != 0 {
+	if err := translate.ValidateComments(params.Comments); err != nil {
+		return nil, err
+	}
+	if err := translate.ValidateDuplicateNodes(params.DuplicateNodes); err != nil {
+		return nil, err
+	}
+
+	// set and validate format
+	switch params.Format {
+	case "":
+		resolved.Format = translate.FormatConf
+	case translate.FormatConf, translate.FormatJSON, translate.FormatLabels:
+		resolved.Format = params.Format
+	case translate.FormatHostlist:
+		if plugin != topology.TopologyBlock {
+			return nil, fmt.Errorf("%s format requires the %s plugin", translate.FormatHostlist, topology.TopologyBlock)
+		}
+		resolved.Format = params.Format
+	case translate.FormatMatrix:
+		if params.MaxMatrixNodes < 0 {
+			return nil, fmt.Errorf("max_matrix_nodes must not be negative")
+		}
+		resolved.Format = params.Format
+	case translate.FormatYAML:
+		if err := translate.ValidateYAMLSchema(params.YAMLSchemaVersion); err != nil {
+			return nil, err
+		}
+		resolved.Format = params.Format
+		resolved.YAMLSchemaVersion = params.YAMLSchemaVersion
+		if len(resolved.YAMLSchemaVersion) == 0 {
+			resolved.YAMLSchemaVersion = translate.DefaultYAMLSchema
+		}
+	default:
+		return nil, fmt.Errorf("unsupported topology format %q", params.Format)
+	}
+
+	if len(path) != 0 && params.Format != translate.FormatJSON && params.Format != translate.FormatHostlist &&
+		params.Format != translate.FormatMatrix && params.Format != translate.FormatLabels {
 		if _, err := buf.WriteString(fmt.Sprintf(TopologyHeader, plugin)); err != nil {
 			return nil, err
 		}
@@ -174,34 +569,220 @@ func GenerateOutputParams(ctx context.Context, tree *topology.Vertex, params *Pa
 		tree.Metadata = make(map[string]string)
 	}
 
+	// the excluded nodes are removed before the block sizes are computed
+	excluded, err := getExcludedNodes(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	tree.ExcludeNodes(excluded)
+
 	tree.Metadata[topology.KeyPlugin] = plugin
 	if len(params.BlockSizes) != 0 {
 		tree.Metadata[topology.KeyBlockSizes] = params.BlockSizes
 	}
+	if len(params.BlockSizeHint) != 0 {
+		tree.Metadata[topology.KeyBlockSizeHint] = params.BlockSizeHint
+	}
+	if len(params.BlockSizeStrategy) != 0 {
+		tree.Metadata[topology.KeyBlockSizeStrategy] = params.BlockSizeStrategy
+	}
+	if params.ExpectedBlockSizes {
+		tree.Metadata[topology.KeyExpectedBlockSizes] = "true"
+	}
+	if len(params.FlatMode) != 0 {
+		tree.Metadata[topology.KeyFlatMode] = params.FlatMode
+	}
+	if len(params.Comments) != 0 {
+		tree.Metadata[topology.KeyComments] = params.Comments
+	}
+	if len(params.DuplicateNodes) != 0 {
+		tree.Metadata[topology.KeyDuplicateNodes] = params.DuplicateNodes
+	}
 
-	err := translate.Write(buf, tree)
+	unit, err := translate.ToTopologyUnit(ctx, tree)
+	if err != nil {
+		return nil, err
+	}
+	if params.EmitReverseIndex {
+		unit.NodeIndex = unit.ReverseIndex()
+	}
+	if params.EmitAccelerators {
+		unit.AddAccelerators(tree)
+	}
+	if unit.Block != nil {
+		resolved.BlockSizes = unit.Block.BlockSizes
+		resolved.BlockSizesSource = getBlockSizesSource(params, unit.Block)
+	}
+
+	if params.Validate {
+		nodes, err := getNodes(ctx, params)
+		if err != nil {
+			return nil, err
+		}
+		if err = validateNodes(tree, unit, filterNodes(nodes, excluded), params.MaxMissingNodes); err != nil {
+			return nil, err
+		}
+	}
+
+	if data, err := json.Marshal(resolved); err == nil {
+		klog.Infof("Resolved engine parameters: %s", data)
+	}
+
+	switch params.Format {
+	case translate.FormatYAML:
+		err = unit.WriteYAML(ctx, buf, resolved.YAMLSchemaVersion)
+	case translate.FormatHostlist:
+		err = unit.WriteHostlist(ctx, buf, tree)
+	case translate.FormatMatrix:
+		err = translate.WriteMatrix(ctx, buf, tree, params.MaxMatrixNodes)
+	case translate.FormatLabels:
+		err = translate.WriteNodeLabels(ctx, buf, tree)
+	default:
+		err = unit.Write(ctx, buf, params.Format)
+	}
 	if err != nil {
 		return nil, err
 	}
 
 	cfg := buf.Bytes()
 
-	if len(path) == 0 {
+	if (len(path) == 0 && params.SSH == nil) || params.DryRun || scoped {
 		klog.Info("Returning topology config")
-		return cfg, nil
+		return echo(params, resolved, cfg)
 	}
 
-	klog.Infof("Writing topology config in %q", path)
-	if err = files.Create(path, cfg); err != nil {
-		return nil, err
+	if len(path) != 0 {
+		if existing, err := os.ReadFile(path); err == nil && bytes.Equal(existing, cfg) {
+			klog.Infof("Topology unchanged in %q", path)
+			return echo(params, resolved, []byte("UNCHANGED\n"))
+		}
+	}
+
+	// copy to the remote host first, so that a failed copy is retried with the next request
+	if params.SSH != nil {
+		if err = remote.Push(ctx, params.SSH, cfg); err != nil {
+			return nil, err
+		}
 	}
-	if params.Reconfigure {
-		if err = reconfigure(ctx); err != nil {
+
+	if len(path) != 0 {
+		klog.Infof("Writing topology config in %q", path)
+		if err = files.Create(path, cfg); err != nil {
 			return nil, err
 		}
+		if params.Reconfigure {
+			if err = reconfigure(ctx); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return echo(params, resolved, []byte("OK\n"))
+}
+
+// TopologyConfigs implements engines.ConfigDiffer. It reads the applied config from topology_config_path.
+func (eng *SlurmEngine) TopologyConfigs(ctx context.Context, tree *topology.Vertex, params map[string]any) ([]byte, []byte, error) {
+	var p Params
+	if err := config.Decode(params, &p); err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", engines.ErrInvalidParams, err)
+	}
+	if len(p.TopoConfigPath) == 0 {
+		return nil, nil, fmt.Errorf("%w: missing topology_config_path parameter", engines.ErrInvalidParams)
+	}
+
+	applied, err := os.ReadFile(p.TopoConfigPath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, nil, err
+	}
+
+	p.DryRun, p.EchoParams = true, false
+	generated, err := GenerateOutputParams(ctx, tree, &p)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return applied, generated, nil
+}
+
+// echo wraps the output together with the resolved parameters, if requested
+func echo(params *Params, resolved *ResolvedParams, output []byte) ([]byte, error) {
+	if !params.EchoParams {
+		return output, nil
+	}
+	return json.Marshal(&EchoResponse{Params: resolved, Output: string(output)})
+}
+
+// validateNodes compares the nodes in the topology config with the cluster nodes.
+// Nodes without topology count as present. It fails if the number of missing and
+// extra nodes exceeds maxMissing.
+func validateNodes(root *topology.Vertex, unit *translate.TopologyUnit, nodes []string, maxMissing int) error {
+	if unit.Flat != nil && len(unit.Flat.Nodes) == 0 {
+		klog.Info("Skipping node validation for empty flat topology")
+		return nil
+	}
+
+	present := make(map[string]bool)
+	for _, node := range unit.Nodes() {
+		present[node] = true
+	}
+	if treeRoot, ok := root.Vertices[topology.TopologyTree]; ok {
+		for id, sw := range treeRoot.Vertices {
+			if !topology.IsNoTopology(id) {
+				continue
+			}
+			for _, node := range sw.Vertices {
+				present[node.Name] = true
+			}
+		}
+	}
+
+	var missing, extra []string
+	expected := make(map[string]bool, len(nodes))
+	for _, node := range nodes {
+		expected[node] = true
+		if !present[node] {
+			missing = append(missing, node)
+		}
+	}
+	for node := range present {
+		if !expected[node] {
+			extra = append(extra, node)
+		}
+	}
+	sort.Strings(missing)
+	sort.Strings(extra)
+
+	if len(missing)+len(extra) == 0 {
+		return nil
+	}
+
+	msg := fmt.Sprintf("%d missing nodes %v, %d extra nodes %v", len(missing), missing, len(extra), extra)
+	if len(missing)+len(extra) > maxMissing {
+		return fmt.Errorf("%w: %s", engines.ErrTopologyValidation, msg)
+	}
+	klog.Warningf("Tolerated topology drift: %s", msg)
+
+	return nil
+}
+
+func getBlockSizesSource(params *Params, block *translate.BlockTopo) string {
+	switch block.BlockSizesSource {
+	case translate.BlockSizesSourceHint, translate.BlockSizesSourceExpected:
+		return block.BlockSizesSource
+	case translate.BlockSizesSourceFallback:
+		return SourceFallback
 	}
 
-	return []byte("OK\n"), nil
+	if len(params.BlockSizes) != 0 {
+		sizes := make([]string, 0, len(block.BlockSizes))
+		for _, size := range block.BlockSizes {
+			sizes = append(sizes, strconv.Itoa(size))
+		}
+		if strings.ReplaceAll(params.BlockSizes, " ", "") == strings.Join(sizes, ",") {
+			return SourceRequest
+		}
+	}
+	return SourceDerived
 }
 
 func reconfigure(ctx context.Context) error {
@@ -0,0 +1,673 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package slurm
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/NVIDIA/topograph/internal/fixtures"
+	"github.com/NVIDIA/topograph/pkg/engines"
+	"github.com/NVIDIA/topograph/pkg/topology"
+	"github.com/NVIDIA/topograph/pkg/translate"
+)
+
+func TestResolvedParams(t *testing.T) {
+	treeSet := func() *topology.Vertex {
+		root, _ := fixtures.TreeTestSet()
+		return root
+	}
+	blockSet := func() *topology.Vertex {
+		root, _ := fixtures.BlockWithMultiIBTestSet()
+		root.Metadata = nil
+		return root
+	}
+
+	testCases := []struct {
+		name     string
+		root     *topology.Vertex
+		params   map[string]any
+		expected *ResolvedParams
+	}{
+		{
+			name:   "Case 1: default plugin",
+			root:   treeSet(),
+			params: map[string]any{},
+			expected: &ResolvedParams{
+				Plugin:       topology.TopologyTree,
+				PluginSource: SourceDefault,
+				Format:       "conf",
+			},
+		},
+		{
+			name:   "Case 2: unsupported plugin",
+			root:   treeSet(),
+			params: map[string]any{"plugin": "topology/unknown"},
+			expected: &ResolvedParams{
+				Plugin:       topology.TopologyTree,
+				PluginSource: SourceFallback,
+				Format:       "conf",
+			},
+		},
+		{
+			name:   "Case 3: requested block sizes",
+			root:   blockSet(),
+			params: map[string]any{"plugin": topology.TopologyBlock, "block_sizes": "3"},
+			expected: &ResolvedParams{
+				Plugin:           topology.TopologyBlock,
+				PluginSource:     SourceRequest,
+				BlockSizes:       []int{3},
+				BlockSizesSource: SourceRequest,
+				Format:           "conf",
+			},
+		},
+		{
+			name:   "Case 4: derived block sizes",
+			root:   blockSet(),
+			params: map[string]any{"plugin": topology.TopologyBlock},
+			expected: &ResolvedParams{
+				Plugin:           topology.TopologyBlock,
+				PluginSource:     SourceRequest,
+				BlockSizes:       []int{2},
+				BlockSizesSource: SourceDerived,
+				Format:           "conf",
+			},
+		},
+		{
+			name:   "Case 5: block sizes not fitting the domain",
+			root:   blockSet(),
+			params: map[string]any{"plugin": topology.TopologyBlock, "block_sizes": "4"},
+			expected: &ResolvedParams{
+				Plugin:           topology.TopologyBlock,
+				PluginSource:     SourceRequest,
+				BlockSizes:       []int{2},
+				BlockSizesSource: SourceDerived,
+				Format:           "conf",
+			},
+		},
+		{
+			name:   "Case 6: block size hint",
+			root:   blockSet(),
+			params: map[string]any{"plugin": topology.TopologyBlock, "block_size_hint": "1"},
+			expected: &ResolvedParams{
+				Plugin:           topology.TopologyBlock,
+				PluginSource:     SourceRequest,
+				BlockSizes:       []int{1, 2},
+				BlockSizesSource: "hint",
+				Format:           "conf",
+			},
+		},
+		{
+			name:   "Case 7: block size hint not fitting the domain",
+			root:   blockSet(),
+			params: map[string]any{"plugin": topology.TopologyBlock, "block_size_hint": "8"},
+			expected: &ResolvedParams{
+				Plugin:           topology.TopologyBlock,
+				PluginSource:     SourceRequest,
+				BlockSizes:       []int{2},
+				BlockSizesSource: SourceFallback,
+				Format:           "conf",
+			},
+		},
+		{
+			name: "Case 8: dry run",
+			root: treeSet(),
+			params: map[string]any{
+				"topology_config_path": "/tmp/topology.conf",
+				"reconfigure":          true,
+				"dry_run":              true,
+				"format":               "json",
+			},
+			expected: &ResolvedParams{
+				Plugin:         topology.TopologyTree,
+				PluginSource:   SourceDefault,
+				Format:         "json",
+				TopoConfigPath: "/tmp/topology.conf",
+				Reconfigure:    true,
+				DryRun:         true,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// without echo, the output is returned as is
+			output, err := GenerateOutput(context.TODO(), tc.root, tc.params)
+			require.NoError(t, err)
+
+			tc.params["echo_params"] = true
+			data, err := GenerateOutput(context.TODO(), tc.root, tc.params)
+			require.NoError(t, err)
+
+			var resp EchoResponse
+			require.NoError(t, json.Unmarshal(data, &resp))
+			require.Equal(t, tc.expected, resp.Params)
+			require.Equal(t, string(output), resp.Output)
+		})
+	}
+}
+
+func TestFlatPlugin(t *testing.T) {
+	testCases := []struct {
+		name   string
+		root   *topology.Vertex
+		params map[string]any
+		output string
+		err    string
+	}{
+		{
+			name:   "Case 1: flat plugin without topology",
+			root:   &topology.Vertex{Vertices: map[string]*topology.Vertex{}},
+			params: map[string]any{"plugin": topology.TopologyFlat},
+			output: "# topology/flat: network topology is not used\n",
+		},
+		{
+			name: "Case 2: flat plugin with single switch",
+			root: func() *topology.Vertex {
+				root, _ := fixtures.TreeTestSet()
+				return root
+			}(),
+			params: map[string]any{"plugin": topology.TopologyFlat, "flat_mode": "switch"},
+			output: "SwitchName=flat Nodes=Node[201-202],Node205,Node[304-306]\n",
+		},
+		{
+			name: "Case 3: flat plugin with single switch from blocks",
+			root: func() *topology.Vertex {
+				root, _ := fixtures.BlockWithMultiIBTestSet()
+				delete(root.Vertices, topology.TopologyTree)
+				return root
+			}(),
+			params: map[string]any{"plugin": topology.TopologyFlat, "flat_mode": "switch"},
+			output: "SwitchName=flat Nodes=Node[104-106],Node[201-202],Node205,Node[301-303],Node[401-403]\n",
+		},
+		{
+			name:   "Case 4: flat plugin with single switch without nodes",
+			root:   &topology.Vertex{Vertices: map[string]*topology.Vertex{}},
+			params: map[string]any{"plugin": topology.TopologyFlat, "flat_mode": "switch"},
+			err:    "no nodes for the flat switch of the switch mode",
+		},
+		{
+			name:   "Case 5: unsupported flat mode",
+			root:   &topology.Vertex{Vertices: map[string]*topology.Vertex{}},
+			params: map[string]any{"plugin": topology.TopologyFlat, "flat_mode": "bad"},
+			err:    `unsupported flat mode "bad"`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			output, err := GenerateOutput(context.TODO(), tc.root, tc.params)
+			if len(tc.err) != 0 {
+				require.EqualError(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.output, string(output))
+		})
+	}
+}
+
+func TestUnsupportedBlockSizeStrategy(t *testing.T) {
+	root, _ := fixtures.BlockWithMultiIBTestSet()
+	params := map[string]any{"plugin": topology.TopologyBlock, "block_size_strategy": "mean"}
+	_, err := GenerateOutput(context.TODO(), root, params)
+	require.EqualError(t, err, `unsupported block size strategy "mean"`)
+}
+
+func TestUnsupportedComments(t *testing.T) {
+	root, _ := fixtures.TreeTestSet()
+	params := map[string]any{"plugin": topology.TopologyTree, "comments": "short"}
+	_, err := GenerateOutput(context.TODO(), root, params)
+	require.EqualError(t, err, `unsupported comments mode "short"`)
+}
+
+func TestDuplicateNodes(t *testing.T) {
+	newRoot := func() *topology.Vertex {
+		root, _ := fixtures.TreeTestSet()
+		// Node201 is under both S2 and S3
+		root.Vertices[topology.TopologyTree].Vertices["S1"].Vertices["S3"].Vertices["I21"] = &topology.Vertex{ID: "I21", Name: "Node201"}
+		return root
+	}
+
+	params := map[string]any{"plugin": topology.TopologyTree, "duplicate_nodes": "last-wins"}
+	_, err := GenerateOutput(context.TODO(), newRoot(), params)
+	require.EqualError(t, err, `unsupported duplicate nodes policy "last-wins"`)
+
+	params["duplicate_nodes"] = translate.DuplicateNodesError
+	_, err = GenerateOutput(context.TODO(), newRoot(), params)
+	require.ErrorIs(t, err, translate.ErrDuplicateNodes)
+	require.EqualError(t, err, "duplicate nodes: Node201 under switches S2,S3")
+
+	delete(params, "duplicate_nodes")
+	output, err := GenerateOutput(context.TODO(), newRoot(), params)
+	require.NoError(t, err)
+	require.Contains(t, string(output), "SwitchName=S2 Nodes=Node[201-202],Node205\nSwitchName=S3 Nodes=Node[304-306]\n")
+}
+
+func TestHostlistFormat(t *testing.T) {
+	root, _ := fixtures.BlockWithMultiIBTestSet()
+	delete(root.Vertices[topology.TopologyBlock].Vertices, "B4")
+
+	params := map[string]any{"plugin": topology.TopologyBlock, "format": translate.FormatHostlist}
+	output, err := GenerateOutput(context.TODO(), root, params)
+	require.NoError(t, err)
+	require.Equal(t, "B3: Node[301-303]\nB1: Node[104-106]\nB2: Node[201-202],Node205\nunassigned: Node[401-403]\n", string(output))
+
+	_, err = GenerateOutput(context.TODO(), root, map[string]any{"format": translate.FormatHostlist})
+	require.EqualError(t, err, "hostlist format requires the topology/block plugin")
+}
+
+func TestMatrixFormat(t *testing.T) {
+	root, _ := fixtures.TreeTestSet()
+
+	output, err := GenerateOutput(context.TODO(), root, map[string]any{"format": translate.FormatMatrix})
+	require.NoError(t, err)
+	var matrix translate.DistanceMatrix
+	require.NoError(t, json.Unmarshal(output, &matrix))
+	require.NotEmpty(t, matrix.Nodes)
+	require.Len(t, matrix.Distances, len(matrix.Nodes))
+
+	params := map[string]any{"format": translate.FormatMatrix, "max_matrix_nodes": 1}
+	_, err = GenerateOutput(context.TODO(), root, params)
+	require.ErrorContains(t, err, "exceeds max_matrix_nodes 1")
+
+	params = map[string]any{"format": translate.FormatMatrix, "max_matrix_nodes": -1}
+	_, err = GenerateOutput(context.TODO(), root, params)
+	require.EqualError(t, err, "max_matrix_nodes must not be negative")
+}
+
+func TestLabelsFormat(t *testing.T) {
+	root, _ := fixtures.BlockWithMultiIBTestSet()
+
+	output, err := GenerateOutput(context.TODO(), root, map[string]any{"format": translate.FormatLabels, "exclude_nodes": "Node[401-403]"})
+	require.NoError(t, err)
+	var doc translate.NodeLabelsDocument
+	require.NoError(t, json.Unmarshal(output, &doc))
+	require.Equal(t, translate.NodeLabelsSchemaVersion, doc.SchemaVersion)
+	require.Len(t, doc.Nodes, 9)
+	require.Equal(t, map[string]string{
+		translate.LabelAccelerator: "B1",
+		translate.LabelBlock:       "S2",
+		translate.LabelSpine:       "S1",
+		translate.LabelDatacenter:  "ibRoot2",
+	}, doc.Nodes["Node104"].Labels)
+}
+
+func TestUnchangedOutput(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "topology.conf")
+	root, _ := fixtures.TreeTestSet()
+
+	output, err := GenerateOutput(context.TODO(), root, map[string]any{"topology_config_path": path})
+	require.NoError(t, err)
+	require.Equal(t, "OK\n", string(output))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	// the file is neither rewritten nor Slurm reconfigured
+	params := map[string]any{"topology_config_path": path, "reconfigure": true}
+	output, err = GenerateOutput(context.TODO(), root, params)
+	require.NoError(t, err)
+	require.Equal(t, "UNCHANGED\n", string(output))
+
+	require.NoError(t, os.WriteFile(path, []byte("SwitchName=S1 Nodes=Node1\n"), 0644))
+	output, err = GenerateOutput(context.TODO(), root, map[string]any{"topology_config_path": path})
+	require.NoError(t, err)
+	require.Equal(t, "OK\n", string(output))
+	changed, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, data, changed)
+}
+
+func TestFailedRemoteCopy(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "topology.conf")
+	root, _ := fixtures.TreeTestSet()
+
+	params := map[string]any{
+		"topology_config_path": path,
+		"ssh": map[string]any{
+			"host":        "127.0.0.1",
+			"user":        "slurm",
+			"key_path":    filepath.Join(dir, "missing_key"),
+			"known_hosts": filepath.Join(dir, "known_hosts"),
+			"remote_path": "/etc/slurm/topology.conf",
+		},
+	}
+
+	// the local config is not written, so that the copy is retried with the next request
+	_, err := GenerateOutput(context.TODO(), root, params)
+	require.ErrorContains(t, err, "failed to read SSH key")
+	require.NoFileExists(t, path)
+}
+
+func TestTopologyConfigs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "topology.conf")
+	root, _ := fixtures.TreeTestSet()
+	eng := &SlurmEngine{}
+
+	_, _, err := eng.TopologyConfigs(context.TODO(), root, nil)
+	require.ErrorIs(t, err, engines.ErrInvalidParams)
+	require.EqualError(t, err, "invalid engine parameters: missing topology_config_path parameter")
+
+	// nothing applied yet
+	params := map[string]any{"topology_config_path": path, "echo_params": true}
+	applied, generated, err := eng.TopologyConfigs(context.TODO(), root, params)
+	require.NoError(t, err)
+	require.Nil(t, applied)
+	_, err = os.Stat(path)
+	require.True(t, os.IsNotExist(err))
+
+	_, err = GenerateOutput(context.TODO(), root, map[string]any{"topology_config_path": path})
+	require.NoError(t, err)
+
+	applied, _, err = eng.TopologyConfigs(context.TODO(), root, params)
+	require.NoError(t, err)
+	require.Equal(t, string(generated), string(applied))
+}
+
+// TestDefaultParams checks that the parameter schema defaults match the resolved parameters
+func TestDefaultParams(t *testing.T) {
+	root, _ := fixtures.TreeTestSet()
+	defaults := DefaultParams()
+
+	resolve := func(params map[string]any) *ResolvedParams {
+		params["echo_params"] = true
+		output, err := GenerateOutput(context.TODO(), root, params)
+		require.NoError(t, err)
+		var res EchoResponse
+		require.NoError(t, json.Unmarshal(output, &res))
+		return res.Params
+	}
+
+	resolved := resolve(map[string]any{})
+	require.Equal(t, defaults.Plugin, resolved.Plugin)
+	require.Equal(t, defaults.Format, resolved.Format)
+
+	resolved = resolve(map[string]any{"format": translate.FormatYAML})
+	require.Equal(t, defaults.YAMLSchemaVersion, resolved.YAMLSchemaVersion)
+
+	// the explicit defaults produce the same output as the empty parameters
+	implicit, err := GenerateOutput(context.TODO(), root, map[string]any{})
+	require.NoError(t, err)
+	explicit, err := GenerateOutput(context.TODO(), root, map[string]any{
+		"plugin":              defaults.Plugin,
+		"format":              defaults.Format,
+		"block_size_strategy": defaults.BlockSizeStrategy,
+		"flat_mode":           defaults.FlatMode,
+		"yaml_schema_version": defaults.YAMLSchemaVersion,
+	})
+	require.NoError(t, err)
+	require.Equal(t, string(implicit), string(explicit))
+}
+
+func TestValidateNodes(t *testing.T) {
+	root, _ := fixtures.TreeTestSet()
+	root.Vertices[topology.TopologyTree].Vertices[topology.NoTopology] = &topology.Vertex{
+		ID: topology.NoTopology,
+		Vertices: map[string]*topology.Vertex{
+			"Node900": {Name: "Node900", ID: "i-900"},
+		},
+	}
+	unit := &translate.TopologyUnit{
+		Block: &translate.BlockTopo{
+			Blocks: []*translate.Block{
+				{Block: "B1", Nodes: "Node[201-202],Node205"},
+				{Block: "B2", Nodes: "Node[304-306]"},
+			},
+		},
+	}
+
+	testCases := []struct {
+		name       string
+		nodes      []string
+		maxMissing int
+		err        string
+	}{
+		{
+			name:  "Case 1: exact match",
+			nodes: []string{"Node201", "Node202", "Node205", "Node304", "Node305", "Node306", "Node900"},
+		},
+		{
+			name:       "Case 2: tolerated drift",
+			nodes:      []string{"Node201", "Node202", "Node205", "Node304", "Node305", "Node306", "Node900", "Node901"},
+			maxMissing: 1,
+		},
+		{
+			name:  "Case 3: rejection",
+			nodes: []string{"Node201", "Node202", "Node304", "Node305", "Node306", "Node900", "Node901"},
+			err:   "topology validation failed: 1 missing nodes [Node901], 1 extra nodes [Node205]",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateNodes(root, unit, tc.nodes, tc.maxMissing)
+			if len(tc.err) != 0 {
+				require.EqualError(t, err, tc.err)
+				require.ErrorIs(t, err, engines.ErrTopologyValidation)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+type testMapper struct {
+	region  string
+	regions map[string]string
+	calls   [][]string
+}
+
+func (m *testMapper) Instances2NodeMap(_ context.Context, nodes []string) (map[string]string, error) {
+	m.calls = append(m.calls, nodes)
+	i2n := make(map[string]string, len(nodes))
+	for _, node := range nodes {
+		i2n["i-"+node] = node
+	}
+	return i2n, nil
+}
+
+func (m *testMapper) GetComputeInstancesRegion() (string, error) {
+	return m.region, nil
+}
+
+func (m *testMapper) getCalls() [][]string {
+	return m.calls
+}
+
+type testRegionMapper struct {
+	testMapper
+}
+
+func (m *testRegionMapper) GetInstancesRegions(_ context.Context, _ []string) (map[string]string, error) {
+	return m.regions, nil
+}
+
+func TestGetComputeInstances(t *testing.T) {
+	nodes := []string{"n1", "n2", "n3", "n4"}
+
+	testCases := []struct {
+		name   string
+		mapper interface {
+			instanceMapper
+			getCalls() [][]string
+		}
+		cis   []topology.ComputeInstances
+		calls [][]string
+	}{
+		{
+			name:   "Case 1: single region",
+			mapper: &testMapper{region: "us-east-1"},
+			cis: []topology.ComputeInstances{
+				{
+					Region:    "us-east-1",
+					Instances: map[string]string{"i-n1": "n1", "i-n2": "n2", "i-n3": "n3", "i-n4": "n4"},
+				},
+			},
+			calls: [][]string{nodes},
+		},
+		{
+			name: "Case 2: two regions",
+			mapper: &testRegionMapper{testMapper{
+				region:  "us-east-1",
+				regions: map[string]string{"n1": "us-west-2", "n2": "us-east-1", "n3": "us-west-2", "n4": "us-east-1"},
+			}},
+			cis: []topology.ComputeInstances{
+				{
+					Region:    "us-east-1",
+					Instances: map[string]string{"i-n2": "n2", "i-n4": "n4"},
+				},
+				{
+					Region:    "us-west-2",
+					Instances: map[string]string{"i-n1": "n1", "i-n3": "n3"},
+				},
+			},
+			calls: [][]string{{"n2", "n4"}, {"n1", "n3"}},
+		},
+		{
+			name: "Case 3: missing region",
+			mapper: &testRegionMapper{testMapper{
+				region:  "us-east-1",
+				regions: map[string]string{"n1": "us-west-2", "n3": "us-west-2"},
+			}},
+			cis: []topology.ComputeInstances{
+				{
+					Region:    "us-east-1",
+					Instances: map[string]string{"i-n2": "n2", "i-n4": "n4"},
+				},
+				{
+					Region:    "us-west-2",
+					Instances: map[string]string{"i-n1": "n1", "i-n3": "n3"},
+				},
+			},
+			calls: [][]string{{"n2", "n4"}, {"n1", "n3"}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cis, err := getComputeInstances(context.TODO(), tc.mapper, nodes)
+			require.NoError(t, err)
+			require.Equal(t, tc.cis, cis)
+			require.Equal(t, tc.calls, tc.mapper.getCalls())
+		})
+	}
+}
+
+func TestExcludeNodes(t *testing.T) {
+	testCases := []struct {
+		name   string
+		root   func() *topology.Vertex
+		params map[string]any
+		output string
+	}{
+		{
+			name: "Case 1: tree topology",
+			root: func() *topology.Vertex {
+				root, _ := fixtures.TreeTestSet()
+				return root
+			},
+			params: map[string]any{"exclude_nodes": "Node201,Node[304-306]"},
+			output: "SwitchName=S1 Switches=S2\nSwitchName=S2 Nodes=Node202,Node205\n",
+		},
+		{
+			name: "Case 2: block topology",
+			root: func() *topology.Vertex {
+				root, _ := fixtures.BlockWithMultiIBTestSet()
+				return root
+			},
+			params: map[string]any{"plugin": topology.TopologyBlock, "exclude_nodes": "Node[104-106],Node201"},
+			output: "BlockName=B3 Nodes=Node[301-303]\nBlockName=B4 Nodes=Node[401-403]\nBlockName=B2 Nodes=Node202,Node205\nBlockSizes=2\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			output, err := GenerateOutput(context.TODO(), tc.root(), tc.params)
+			require.NoError(t, err)
+			require.Equal(t, tc.output, string(output))
+		})
+	}
+}
+
+func TestParseNodeLists(t *testing.T) {
+	nodes, err := parseNodeLists("login[01-02]\nviz1\nn/a\n")
+	require.NoError(t, err)
+	require.Equal(t, []string{"login01", "login02", "viz1"}, nodes)
+
+	require.Equal(t, []string{"n1", "n3"}, filterNodes([]string{"n1", "n2", "n3", "viz1"}, map[string]bool{"n2": true, "viz1": true}))
+}
+
+func TestScopedOutput(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "topology.conf")
+	root, _ := fixtures.TreeTestSet()
+
+	params := map[string]any{"topology_config_path": path, "reconfigure": true, "reservation": "resv1"}
+	output, err := GenerateOutput(context.TODO(), root, params)
+	require.NoError(t, err)
+	require.Contains(t, string(output), "SwitchName=S1 Switches=S[2-3]\n")
+	require.NoFileExists(t, path)
+
+	params = map[string]any{"reservation": "resv1", "job_id": "42"}
+	_, err = GenerateOutput(context.TODO(), root, params)
+	require.EqualError(t, err, "reservation and job_id are mutually exclusive")
+}
+
+func TestParseReservationNodes(t *testing.T) {
+	output := `ReservationName=resv1 StartTime=2025-01-01T00:00:00 EndTime=2025-01-02T00:00:00 Duration=1-00:00:00 Nodes=gpu[001-003],gpu010 NodeCnt=4 CoreCnt=512 Features=(null) PartitionName=batch Flags=SPEC_NODES TRES=cpu=512 Users=alice Groups=(null) Accounts=(null) Licenses=(null) State=ACTIVE BurstBuffer=(null) Watts=n/a MaxStartDelay=(null)
+ReservationName=empty StartTime=2025-01-01T00:00:00 EndTime=2025-01-02T00:00:00 Duration=1-00:00:00 Nodes=(null) NodeCnt=0
+`
+
+	testCases := []struct {
+		name        string
+		reservation string
+		nodes       []string
+		err         string
+	}{
+		{
+			name:        "Case 1: reservation nodes",
+			reservation: "resv1",
+			nodes:       []string{"gpu001", "gpu002", "gpu003", "gpu010"},
+		},
+		{
+			name:        "Case 2: reservation without nodes",
+			reservation: "empty",
+			err:         `no nodes in reservation "empty"`,
+		},
+		{
+			name:        "Case 3: missing reservation",
+			reservation: "resv2",
+			err:         `reservation "resv2" not found`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			nodes, err := parseReservationNodes(output, tc.reservation)
+			if len(tc.err) != 0 {
+				require.EqualError(t, err, tc.err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tc.nodes, nodes)
+			}
+		})
+	}
+}
@@ -22,15 +22,33 @@ import (
 	"fmt"
 	"regexp"
 	"sort"
+	"strconv"
 	"strings"
 
+	"github.com/NVIDIA/topograph/pkg/metrics"
 	"github.com/NVIDIA/topograph/pkg/topology"
 	"golang.org/x/exp/maps"
+	"k8s.io/klog/v2"
 )
 
 var (
 	reEmptyLine, reHCA, reSwitch, reConn, reSwitchName, reNodeName *regexp.Regexp
 	seen                                                           map[int]map[string]*Switch
+
+	reLinkSpeed = regexp.MustCompile(`^(\d+)x([A-Z]+\d*)$`)
+
+	// laneRates are the data rates per lane in Gb/s of the InfiniBand link speeds
+	laneRates = map[string]float64{
+		"SDR":   2.5,
+		"DDR":   5,
+		"QDR":   10,
+		"FDR10": 10,
+		"FDR":   14.0625,
+		"EDR":   25,
+		"HDR":   50,
+		"NDR":   100,
+		"XDR":   200,
+	}
 )
 
 func init() {
@@ -47,20 +65,73 @@ type Switch struct {
 	Name     string
 	Height   int
 	Conn     map[string]string  // ID:name
+	Links    map[string]int     // ID:number of parallel links
 	Parents  map[string]bool    // ID:
 	Children map[string]*Switch // ID:switch
 	Nodes    map[string]string  // ID:node name
+	// Secondary holds the IDs of the secondary leaf switches of multi-homed nodes
+	Secondary map[string][]string // node name:switch IDs
+	// Speeds holds the link speeds, e.g. 4xHDR, of the parallel links to each peer
+	Speeds map[string][]string // ID:link speeds
 }
 
-func GenerateTopologyConfig(data []byte) (*topology.Vertex, error) {
+// NewSwitch returns a switch without connections
+func NewSwitch(id, name string) *Switch {
+	return &Switch{
+		ID:       id,
+		Name:     name,
+		Conn:     make(map[string]string),
+		Links:    make(map[string]int),
+		Parents:  make(map[string]bool),
+		Children: make(map[string]*Switch),
+		Nodes:    make(map[string]string),
+		Speeds:   make(map[string][]string),
+	}
+}
+
+// GenerateTopologyConfig builds the topology tree from the ibnetdiscover output.
+// Nodes connected to more than one leaf switch are placed under the leaf with the lexically smallest ID,
+// unless failOnMultiHomed is set, in which case an error is returned.
+// Leaf switches closer to the top of the fabric than the others are reported, and if padAsymmetric is set,
+// pass-through switches are inserted above them so that all leaf switches are at the same depth.
+func GenerateTopologyConfig(data []byte, failOnMultiHomed, padAsymmetric bool) (*topology.Vertex, error) {
 	switches, hca, err := ParseIbnetdiscoverFile(data)
 	if err != nil {
 		return nil, fmt.Errorf("unable to parse ibnetdiscover file: %v", err)
 	}
+
+	return BuildTopology(switches, hca, failOnMultiHomed, padAsymmetric)
+}
+
+// BuildTopology builds the topology tree from the switches and their connections,
+// where hca maps the HCA IDs to the node names.
+func BuildTopology(switches map[string]*Switch, hca map[string]string, failOnMultiHomed, padAsymmetric bool) (*topology.Vertex, error) {
 	root, err := buildTree(switches, hca)
 	if err != nil {
 		return nil, fmt.Errorf("unable to build tree: %v", err)
 	}
+	multiHomed := resolveMultiHomed(switches)
+	metrics.SetMultiHomedNodes(len(multiHomed))
+	if len(multiHomed) != 0 {
+		if failOnMultiHomed {
+			return nil, fmt.Errorf("%d nodes connected to multiple leaf switches: %s",
+				len(multiHomed), strings.Join(multiHomed, ","))
+		}
+		klog.Warningf("%d nodes connected to multiple leaf switches: %s",
+			len(multiHomed), strings.Join(multiHomed, ","))
+	}
+	root.getHeight()
+	asymmetric := root.asymmetricLeaves()
+	metrics.SetAsymmetricLeafSwitches(asymmetric)
+	if len(asymmetric) != 0 {
+		ids := maps.Keys(asymmetric)
+		sort.Strings(ids)
+		klog.Warningf("%d leaf switches are connected to the fabric through fewer switch tiers than the others: %s",
+			len(ids), strings.Join(ids, ","))
+		if padAsymmetric {
+			root.pad()
+		}
+	}
 	seen = make(map[int]map[string]*Switch)
 	root.simplify(root.getHeight())
 	treeNode, err := root.toGraph()
@@ -73,14 +144,21 @@ func GenerateTopologyConfig(data []byte) (*topology.Vertex, error) {
 func (sw *Switch) toGraph() (*topology.Vertex, error) {
 	vertex := &topology.Vertex{
 		Vertices: make(map[string]*topology.Vertex),
+		Metadata: sw.getLinkMetadata(),
 	}
 	vertex.ID = sw.Name
 	if len(sw.Children) == 0 {
 		for id, name := range sw.Nodes {
-			vertex.Vertices[id] = &topology.Vertex{
+			node := &topology.Vertex{
 				Name: name,
 				ID:   id,
 			}
+			if secondary, ok := sw.Secondary[name]; ok {
+				node.Metadata = map[string]string{
+					topology.KeySecondarySwitches: strings.Join(secondary, ","),
+				}
+			}
+			vertex.Vertices[id] = node
 		}
 	} else {
 		for id, child := range sw.Children {
@@ -94,6 +172,50 @@ func (sw *Switch) toGraph() (*topology.Vertex, error) {
 	return vertex, nil
 }
 
+// getLinkMetadata returns the number of links from the switch toward its children and parents,
+// counting parallel links to the same peer, and their aggregate bandwidth in Gb/s if the link
+// speeds are known. For leaf switches it also returns the oversubscription ratio, defined as
+// the number of node links per uplink.
+func (sw *Switch) getLinkMetadata() map[string]string {
+	if len(sw.Links) == 0 {
+		return nil
+	}
+
+	var uplinks, downlinks int
+	var uplinkBandwidth, downlinkBandwidth float64
+	for id := range sw.Parents {
+		uplinks += sw.Links[id]
+		uplinkBandwidth += sw.bandwidth(id)
+	}
+	if len(sw.Children) == 0 {
+		for id := range sw.Nodes {
+			downlinks += sw.Links[id]
+			downlinkBandwidth += sw.bandwidth(id)
+		}
+	} else {
+		for id := range sw.Children {
+			downlinks += sw.Links[id]
+			downlinkBandwidth += sw.bandwidth(id)
+		}
+	}
+
+	metadata := map[string]string{
+		topology.KeyDownlinks: strconv.Itoa(downlinks),
+		topology.KeyUplinks:   strconv.Itoa(uplinks),
+	}
+	if len(sw.Children) == 0 && uplinks != 0 {
+		metadata[topology.KeyOversubscription] = strconv.FormatFloat(float64(downlinks)/float64(uplinks), 'f', 2, 64)
+	}
+	if downlinkBandwidth != 0 {
+		metadata[topology.KeyDownlinkBandwidth] = strconv.FormatFloat(downlinkBandwidth, 'f', -1, 64)
+	}
+	if uplinkBandwidth != 0 {
+		metadata[topology.KeyUplinkBandwidth] = strconv.FormatFloat(uplinkBandwidth, 'f', -1, 64)
+	}
+
+	return metadata
+}
+
 // getHeight returns the height of the switch in the cluster topology.
 // The height of a switch is defined as the maximum number of hops required to reach a leaf node from the switch.
 func (sw *Switch) getHeight() int {
@@ -140,14 +262,7 @@ func ParseIbnetdiscoverFile(data []byte) (map[string]*Switch, map[string]string,
 		}
 
 		if match := reSwitch.FindStringSubmatch(line); len(match) != 0 {
-			entry = &Switch{
-				ID:       match[1],
-				Name:     extractSwitchName(match[2]),
-				Conn:     make(map[string]string),
-				Parents:  make(map[string]bool),
-				Children: make(map[string]*Switch),
-				Nodes:    make(map[string]string),
-			}
+			entry = NewSwitch(match[1], extractSwitchName(match[2]))
 			continue
 		}
 
@@ -155,6 +270,10 @@ func ParseIbnetdiscoverFile(data []byte) (map[string]*Switch, map[string]string,
 			id := match[1]
 			destName := match[3]
 			entry.Conn[id] = destName
+			entry.Links[id]++
+			if speed, ok := parseLinkSpeed(line); ok {
+				entry.Speeds[id] = append(entry.Speeds[id], speed)
+			}
 		}
 	}
 
@@ -164,6 +283,43 @@ func ParseIbnetdiscoverFile(data []byte) (map[string]*Switch, map[string]string,
 	return switches, hca, nil
 }
 
+// parseLinkSpeed returns the link speed, e.g. 4xHDR, from the trailing token of a connection line
+func parseLinkSpeed(line string) (string, bool) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return "", false
+	}
+	speed := fields[len(fields)-1]
+	if _, ok := linkBandwidth(speed); !ok {
+		return "", false
+	}
+	return speed, true
+}
+
+// linkBandwidth returns the data rate in Gb/s of the link speed, e.g. 200 for 4xHDR
+func linkBandwidth(speed string) (float64, bool) {
+	match := reLinkSpeed.FindStringSubmatch(speed)
+	if len(match) == 0 {
+		return 0, false
+	}
+	rate, ok := laneRates[match[2]]
+	if !ok {
+		return 0, false
+	}
+	width, _ := strconv.Atoi(match[1])
+	return float64(width) * rate, true
+}
+
+// bandwidth returns the total data rate in Gb/s of the links to the peer
+func (sw *Switch) bandwidth(id string) float64 {
+	var total float64
+	for _, speed := range sw.Speeds[id] {
+		rate, _ := linkBandwidth(speed)
+		total += rate
+	}
+	return total
+}
+
 func buildPatternFromName(nodeName string) string {
 	pattern := ""
 	gettingDigits := false
@@ -237,51 +393,191 @@ func buildTree(switches map[string]*Switch, hca map[string]string) (*Switch, err
 			sw.Conn = nil
 		}
 	}
-	cnt := 1
+	// switches attached to a switch of an upper level
+	adopted := make(map[string]bool)
 	// next pass: complete the tree level by level
 	for {
-		// create an upper level
-		upper := make(map[string]*Switch)
+		// find the switches connected to the current level
+		candidates := make(map[string]*Switch)
 		for swID, sw := range switches {
 			if visited[swID] {
 				continue
 			}
 			for conID := range sw.Conn {
-				// find children if any
-				if child, ok := level[conID]; ok {
-					sw.Children[conID] = child
-					child.Parents[swID] = true
+				if _, ok := level[conID]; ok {
+					candidates[swID] = sw
+					break
 				}
 			}
-			if len(sw.Children) != 0 {
-				for conID := range sw.Conn {
-					if _, ok := sw.Children[conID]; !ok {
-						sw.Parents[conID] = true
-					}
+		}
+		for _, swID := range skippingTier(candidates, level) {
+			delete(candidates, swID)
+		}
+		// create an upper level.
+		// a switch adopts as children all connected switches of the lower levels,
+		// including those of the levels below the current one if it skips a tier.
+		upper := make(map[string]*Switch)
+		for swID, sw := range candidates {
+			for conID := range sw.Conn {
+				if visited[conID] {
+					child := switches[conID]
+					sw.Children[conID] = child
+					child.Parents[swID] = true
+					adopted[conID] = true
+				} else {
+					sw.Parents[conID] = true
 				}
-				upper[swID] = sw
-				visited[swID] = true
-				sw.Conn = nil
 			}
+			upper[swID] = sw
 		}
 		if len(upper) == 0 {
 			break
 		}
+		for swID, sw := range upper {
+			visited[swID] = true
+			sw.Conn = nil
+		}
 		// complete level
 		level = upper
-		cnt++
 	}
 
 	root := &Switch{
 		Children: make(map[string]*Switch),
 	}
-	for swID, sw := range level {
-		root.Children[swID] = sw
+	// the top of the tree holds the switches without parents in the tree,
+	// which are on the upper level unless the fabric is asymmetric
+	for swID := range visited {
+		if !adopted[swID] {
+			root.Children[swID] = switches[swID]
+		}
 	}
 
 	return root, nil
 }
 
+// skippingTier returns the IDs of the switches connected to the current level that belong to a higher tier.
+// Such a switch connects both to a switch of the current level, skipping a tier, and to another switch
+// connected to the current level. Of two connected switches, the one reaching more switches of the current level,
+// directly or through the switches it is connected to, is the upper one.
+func skippingTier(candidates, level map[string]*Switch) []string {
+	reach := make(map[string]int)
+	for swID, sw := range candidates {
+		reached := make(map[string]bool)
+		for conID := range sw.Conn {
+			if _, ok := level[conID]; ok {
+				reached[conID] = true
+			}
+			if peer, ok := candidates[conID]; ok {
+				for peerConID := range peer.Conn {
+					if _, ok := level[peerConID]; ok {
+						reached[peerConID] = true
+					}
+				}
+			}
+		}
+		reach[swID] = len(reached)
+	}
+
+	ids := []string{}
+	for swID, sw := range candidates {
+		for conID := range sw.Conn {
+			if _, ok := candidates[conID]; ok && reach[swID] > reach[conID] {
+				ids = append(ids, swID)
+				break
+			}
+		}
+	}
+	return ids
+}
+
+// leafDepths records the smallest depth of each leaf switch below the switch at the given depth
+func (sw *Switch) leafDepths(depth int, depths map[string]int) {
+	if len(sw.Children) == 0 {
+		if d, ok := depths[sw.ID]; !ok || depth < d {
+			depths[sw.ID] = depth
+		}
+		return
+	}
+	for _, child := range sw.Children {
+		child.leafDepths(depth+1, depths)
+	}
+}
+
+// asymmetricLeaves returns the number of switch tiers missing above each leaf switch
+// that is closer to the top of the tree than the deepest leaf switches.
+// It requires the heights of the switches to be set.
+func (sw *Switch) asymmetricLeaves() map[string]int {
+	depths := make(map[string]int)
+	sw.leafDepths(0, depths)
+
+	// the leaf switches of a symmetric tree are one level above its bottom
+	missing := make(map[string]int)
+	for id, depth := range depths {
+		if depth < sw.Height-1 {
+			missing[id] = sw.Height - 1 - depth
+		}
+	}
+	return missing
+}
+
+// pad inserts pass-through switches between the switch and its children of lower height,
+// so that all leaf switches below the switch are at the same depth.
+// It requires the heights of the switches to be set.
+func (sw *Switch) pad() {
+	for id, child := range sw.Children {
+		child.pad()
+		for child.Height < sw.Height-1 {
+			passThrough := NewSwitch(fmt.Sprintf("%s-%d", id, child.Height+1), "")
+			passThrough.Height = child.Height + 1
+			passThrough.Children[child.ID] = child
+			child = passThrough
+		}
+		sw.Children[id] = child
+	}
+}
+
+// resolveMultiHomed detaches the nodes connected to more than one leaf switch from all leaves
+// except the one with the lexically smallest ID, and records the other leaves as secondary.
+// It returns the sorted names of the multi-homed nodes.
+func resolveMultiHomed(switches map[string]*Switch) []string {
+	leaves := make(map[string]map[string]bool) // node name:leaf IDs
+	for swID, sw := range switches {
+		for _, name := range sw.Nodes {
+			if _, ok := leaves[name]; !ok {
+				leaves[name] = make(map[string]bool)
+			}
+			leaves[name][swID] = true
+		}
+	}
+
+	names := []string{}
+	for name, ids := range leaves {
+		if len(ids) < 2 {
+			continue
+		}
+		names = append(names, name)
+
+		swIDs := maps.Keys(ids)
+		sort.Strings(swIDs)
+		for _, swID := range swIDs[1:] {
+			sw := switches[swID]
+			for id, node := range sw.Nodes {
+				if node == name {
+					delete(sw.Nodes, id)
+				}
+			}
+		}
+		primary := switches[swIDs[0]]
+		if primary.Secondary == nil {
+			primary.Secondary = make(map[string][]string)
+		}
+		primary.Secondary[name] = swIDs[1:]
+	}
+	sort.Strings(names)
+
+	return names
+}
+
 func extractSwitchName(name string) string {
 	if m := reSwitchName.FindStringSubmatch(name); m != nil {
 		return m[1]
@@ -18,10 +18,16 @@ package ib
 
 import (
 	"reflect"
+	"sort"
+	"strings"
 	"testing"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	"golang.org/x/exp/maps"
+
+	"github.com/NVIDIA/topograph/pkg/topology"
 )
 
 func TestSimplifyTreeDupAt3(t *testing.T) {
@@ -245,9 +251,11 @@ Switch  1   "Switch-1"         # "switch1"
 					ID:       "Switch-1",
 					Name:     "switch1",
 					Conn:     make(map[string]string),
+					Links:    make(map[string]int),
 					Parents:  make(map[string]bool),
 					Children: make(map[string]*Switch),
 					Nodes:    make(map[string]string),
+					Speeds:   make(map[string][]string),
 				},
 			},
 			expectedHCA: map[string]string{},
@@ -272,6 +280,13 @@ Switch	41 "S-08c0eb03008cc87c"		# "MF0;IB-ComputeSpine-03:MQM8700/U1" enhanced p
 						"S-08c0eb0300539a5c": "MF0;IB-ComputeLeaf-101:MQM8700/U1",
 						"S-08c0eb0300539a9c": "MF0;IB-ComputeLeaf-102:MQM8700/U1",
 					},
+					Links: map[string]int{
+						"S-08c0eb0300539a5c": 1,
+						"S-08c0eb0300539a9c": 1,
+					},
+					Speeds: map[string][]string{
+						"S-08c0eb0300539a5c": {"4xHDR"},
+					},
 				},
 			},
 			expectedHCA: map[string]string{},
@@ -302,6 +317,13 @@ Switch	41 "S-b8cef603008032b8"		# "MF0;SJC3-A04-IB-ComputeLeaf-007:MQM8700/U1" e
 						"S-08c0eb0300539a5c": "MF0;IB-ComputeLeaf-101:MQM8700/U1",
 						"S-08c0eb0300539a9c": "MF0;IB-ComputeLeaf-102:MQM8700/U1",
 					},
+					Links: map[string]int{
+						"S-08c0eb0300539a5c": 1,
+						"S-08c0eb0300539a9c": 1,
+					},
+					Speeds: map[string][]string{
+						"S-08c0eb0300539a5c": {"4xHDR"},
+					},
 				},
 				"S-b8cef603008032b8": {
 					ID:   "S-b8cef603008032b8",
@@ -311,6 +333,16 @@ Switch	41 "S-b8cef603008032b8"		# "MF0;SJC3-A04-IB-ComputeLeaf-007:MQM8700/U1" e
 						"S-08c0eb03008ccb5c": "MF0;IB-ComputeSpine-02:MQM8700/U1",
 						"S-08c0eb03008cc87c": "MF0;IB-ComputeSpine-03:MQM8700/U1",
 					},
+					Links: map[string]int{
+						"S-08c0eb03008ccc3c": 1,
+						"S-08c0eb03008ccb5c": 1,
+						"S-08c0eb03008cc87c": 1,
+					},
+					Speeds: map[string][]string{
+						"S-08c0eb03008ccc3c": {"4xHDR"},
+						"S-08c0eb03008ccb5c": {"4xHDR"},
+						"S-08c0eb03008cc87c": {"4xHDR"},
+					},
 					Parents:  make(map[string]bool),
 					Children: make(map[string]*Switch),
 					Nodes:    make(map[string]string),
@@ -354,6 +386,286 @@ Switch	41 "S-b8cef603008032b8"		# "MF0;SJC3-A04-IB-ComputeLeaf-007:MQM8700/U1" e
 	}
 }
 
+func TestParallelLinks(t *testing.T) {
+	input := []byte(`
+Switch	41 "S-0000000000000001"		# "MF0;IB-ComputeSpine-01:MQM8700/U1" enhanced port 0 lid 1 lmc 0
+[1]	"S-0000000000000011"[31]		# "MF0;IB-ComputeLeaf-01:MQM8700/U1" lid 11 4xHDR
+[2]	"S-0000000000000011"[32]		# "MF0;IB-ComputeLeaf-01:MQM8700/U1" lid 11 4xHDR
+[3]	"S-0000000000000012"[31]		# "MF0;IB-ComputeLeaf-02:MQM8700/U1" lid 12 4xHDR
+
+Switch	41 "S-0000000000000011"		# "MF0;IB-ComputeLeaf-01:MQM8700/U1" enhanced port 0 lid 11 lmc 0
+[1]	"H-0000000000000101"[1](0000000000000101) 		# "node101 mlx5_0" lid 101 4xHDR
+[2]	"H-0000000000000102"[1](0000000000000102) 		# "node102 mlx5_0" lid 102 4xHDR
+[3]	"H-0000000000000103"[1](0000000000000103) 		# "node103 mlx5_0" lid 103 4xHDR
+[4]	"H-0000000000000104"[1](0000000000000104) 		# "node104 mlx5_0" lid 104 4xHDR
+[31]	"S-0000000000000001"[1]		# "MF0;IB-ComputeSpine-01:MQM8700/U1" lid 1 4xHDR
+[32]	"S-0000000000000001"[2]		# "MF0;IB-ComputeSpine-01:MQM8700/U1" lid 1 4xHDR
+
+Switch	41 "S-0000000000000012"		# "MF0;IB-ComputeLeaf-02:MQM8700/U1" enhanced port 0 lid 12 lmc 0
+[1]	"H-0000000000000201"[1](0000000000000201) 		# "node201 mlx5_0" lid 201 4xHDR
+[2]	"H-0000000000000202"[1](0000000000000202) 		# "node202 mlx5_0" lid 202 4xHDR
+[3]	"H-0000000000000203"[1](0000000000000203) 		# "node203 mlx5_0" lid 203 4xHDR
+[31]	"S-0000000000000001"[3]		# "MF0;IB-ComputeSpine-01:MQM8700/U1" lid 1 4xHDR
+
+Ca	1 "H-0000000000000101"		# "node101 mlx5_0"
+Ca	1 "H-0000000000000102"		# "node102 mlx5_0"
+Ca	1 "H-0000000000000103"		# "node103 mlx5_0"
+Ca	1 "H-0000000000000104"		# "node104 mlx5_0"
+Ca	1 "H-0000000000000201"		# "node201 mlx5_0"
+Ca	1 "H-0000000000000202"		# "node202 mlx5_0"
+Ca	1 "H-0000000000000203"		# "node203 mlx5_0"
+`)
+
+	switches, _, err := ParseIbnetdiscoverFile(input)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"S-0000000000000011": 2, "S-0000000000000012": 1}, switches["S-0000000000000001"].Links)
+	assert.Equal(t, 2, switches["S-0000000000000011"].Links["S-0000000000000001"])
+
+	root, err := GenerateTopologyConfig(input, false, false)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(root.Vertices))
+
+	spine := maps.Values(root.Vertices)[0]
+	assert.Equal(t, map[string]string{"downlinks": "3", "uplinks": "0", "downlink_bandwidth": "600"}, spine.Metadata)
+	assert.Equal(t, 2, len(spine.Vertices))
+
+	expected := map[string]map[string]string{
+		"node101": {"downlinks": "4", "uplinks": "2", "oversubscription": "2.00", "downlink_bandwidth": "800", "uplink_bandwidth": "400"},
+		"node201": {"downlinks": "3", "uplinks": "1", "oversubscription": "3.00", "downlink_bandwidth": "600", "uplink_bandwidth": "200"},
+	}
+	for _, leaf := range spine.Vertices {
+		for node, metadata := range expected {
+			if _, ok := leaf.Vertices[node]; ok {
+				assert.Equal(t, metadata, leaf.Metadata)
+			}
+		}
+	}
+}
+
+func TestLinkSpeeds(t *testing.T) {
+	input := []byte(`
+Switch	41 "S-0000000000000001"		# "MF0;IB-ComputeSpine-01:MQM9700/U1" enhanced port 0 lid 1 lmc 0
+[1]	"S-0000000000000011"[31]		# "MF0;IB-ComputeLeaf-01:MQM9700/U1" lid 11 4xNDR
+[2]	"S-0000000000000011"[32]		# "MF0;IB-ComputeLeaf-01:MQM9700/U1" lid 11 4xHDR
+[3]	"S-0000000000000012"[31]		# "MF0;IB-ComputeLeaf-02:MQM8700/U1" lid 12 4xHDR
+
+Switch	41 "S-0000000000000011"		# "MF0;IB-ComputeLeaf-01:MQM9700/U1" enhanced port 0 lid 11 lmc 0
+[1]	"H-0000000000000101"[1](0000000000000101) 		# "node101 mlx5_0" lid 101 4xNDR
+[2]	"H-0000000000000102"[1](0000000000000102) 		# "node102 mlx5_0" lid 102 2xNDR
+[31]	"S-0000000000000001"[1]		# "MF0;IB-ComputeSpine-01:MQM9700/U1" lid 1 4xNDR
+[32]	"S-0000000000000001"[2]		# "MF0;IB-ComputeSpine-01:MQM9700/U1" lid 1 4xHDR
+
+Switch	41 "S-0000000000000012"		# "MF0;IB-ComputeLeaf-02:MQM8700/U1" enhanced port 0 lid 12 lmc 0
+[1]	"H-0000000000000201"[1](0000000000000201) 		# "node201 mlx5_0" lid 201 4xFDR
+[2]	"H-0000000000000202"[1](0000000000000202) 		# "node202 mlx5_0" lid 202 4xEDR
+[3]	"H-0000000000000203"[1](0000000000000203) 		# "node203 mlx5_0" lid 203
+[31]	"S-0000000000000001"[3]		# "MF0;IB-ComputeSpine-01:MQM8700/U1" lid 1 4xHDR
+
+Ca	1 "H-0000000000000101"		# "node101 mlx5_0"
+Ca	1 "H-0000000000000102"		# "node102 mlx5_0"
+Ca	1 "H-0000000000000201"		# "node201 mlx5_0"
+Ca	1 "H-0000000000000202"		# "node202 mlx5_0"
+Ca	1 "H-0000000000000203"		# "node203 mlx5_0"
+`)
+
+	switches, _, err := ParseIbnetdiscoverFile(input)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string][]string{"S-0000000000000011": {"4xNDR", "4xHDR"}, "S-0000000000000012": {"4xHDR"}},
+		switches["S-0000000000000001"].Speeds)
+	// the link without speed is counted, but not in the bandwidth
+	assert.Equal(t, 1, switches["S-0000000000000012"].Links["H-0000000000000203"])
+	assert.NotContains(t, switches["S-0000000000000012"].Speeds, "H-0000000000000203")
+
+	root, err := GenerateTopologyConfig(input, false, false)
+	assert.NoError(t, err)
+	spine := maps.Values(root.Vertices)[0]
+	assert.Equal(t, map[string]string{"downlinks": "3", "uplinks": "0", "downlink_bandwidth": "800"}, spine.Metadata)
+
+	expected := map[string]map[string]string{
+		"node101": {"downlinks": "2", "uplinks": "2", "oversubscription": "1.00", "downlink_bandwidth": "600", "uplink_bandwidth": "600"},
+		"node201": {"downlinks": "3", "uplinks": "1", "oversubscription": "3.00", "downlink_bandwidth": "156.25", "uplink_bandwidth": "200"},
+	}
+	for _, leaf := range spine.Vertices {
+		for node, metadata := range expected {
+			if _, ok := leaf.Vertices[node]; ok {
+				assert.Equal(t, metadata, leaf.Metadata)
+			}
+		}
+	}
+}
+
+func TestLinkBandwidth(t *testing.T) {
+	testCases := []struct {
+		speed     string
+		bandwidth float64
+		ok        bool
+	}{
+		{speed: "4xHDR", bandwidth: 200, ok: true},
+		{speed: "4xNDR", bandwidth: 400, ok: true},
+		{speed: "1xNDR", bandwidth: 100, ok: true},
+		{speed: "4xFDR10", bandwidth: 40, ok: true},
+		{speed: "12xQDR", bandwidth: 120, ok: true},
+		{speed: "4xABC"},
+		{speed: "lid"},
+		{speed: "383"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.speed, func(t *testing.T) {
+			bandwidth, ok := linkBandwidth(tc.speed)
+			assert.Equal(t, tc.ok, ok)
+			assert.Equal(t, tc.bandwidth, bandwidth)
+		})
+	}
+}
+
+func TestMultiHomedNodes(t *testing.T) {
+	input := []byte(`
+Switch	41 "S-0000000000000001"		# "MF0;IB-ComputeSpine-01:MQM8700/U1" enhanced port 0 lid 1 lmc 0
+[1]	"S-0000000000000011"[31]		# "MF0;IB-ComputeLeaf-01:MQM8700/U1" lid 11 4xHDR
+[2]	"S-0000000000000012"[31]		# "MF0;IB-ComputeLeaf-02:MQM8700/U1" lid 12 4xHDR
+
+Switch	41 "S-0000000000000011"		# "MF0;IB-ComputeLeaf-01:MQM8700/U1" enhanced port 0 lid 11 lmc 0
+[1]	"H-0000000000000101"[1](0000000000000101) 		# "node101 mlx5_0" lid 101 4xHDR
+[2]	"H-0000000000000102"[1](0000000000000102) 		# "node102 mlx5_0" lid 102 4xHDR
+[3]	"H-0000000000000103"[1](0000000000000103) 		# "node103 mlx5_0" lid 103 4xHDR
+[31]	"S-0000000000000001"[1]		# "MF0;IB-ComputeSpine-01:MQM8700/U1" lid 1 4xHDR
+
+Switch	41 "S-0000000000000012"		# "MF0;IB-ComputeLeaf-02:MQM8700/U1" enhanced port 0 lid 12 lmc 0
+[1]	"H-0000000000000201"[1](0000000000000201) 		# "node201 mlx5_0" lid 201 4xHDR
+[2]	"H-0000000000000202"[1](0000000000000202) 		# "node202 mlx5_0" lid 202 4xHDR
+[3]	"H-0000000000001103"[1](0000000000001103) 		# "node103 mlx5_1" lid 1103 4xHDR
+[31]	"S-0000000000000001"[2]		# "MF0;IB-ComputeSpine-01:MQM8700/U1" lid 1 4xHDR
+
+Ca	1 "H-0000000000000101"		# "node101 mlx5_0"
+Ca	1 "H-0000000000000102"		# "node102 mlx5_0"
+Ca	1 "H-0000000000000103"		# "node103 mlx5_0"
+Ca	1 "H-0000000000000201"		# "node201 mlx5_0"
+Ca	1 "H-0000000000000202"		# "node202 mlx5_0"
+Ca	1 "H-0000000000001103"		# "node103 mlx5_1"
+`)
+
+	// the placement of the multi-homed node must not depend on map iteration order
+	for i := 0; i < 20; i++ {
+		root, err := GenerateTopologyConfig(input, false, false)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, len(root.Vertices))
+
+		spine := maps.Values(root.Vertices)[0]
+		assert.Equal(t, 2, len(spine.Vertices))
+
+		leaves := make(map[string]map[string]*topology.Vertex)
+		for _, leaf := range spine.Vertices {
+			names := []string{}
+			for _, node := range leaf.Vertices {
+				names = append(names, node.Name)
+			}
+			sort.Strings(names)
+			leaves[strings.Join(names, ",")] = leaf.Vertices
+		}
+		assert.Contains(t, leaves, "node101,node102,node103")
+		assert.Contains(t, leaves, "node201,node202")
+
+		node := leaves["node101,node102,node103"]["H-0000000000000103"]
+		assert.NotNil(t, node)
+		assert.Equal(t, map[string]string{topology.KeySecondarySwitches: "S-0000000000000012"}, node.Metadata)
+	}
+
+	_, err := GenerateTopologyConfig(input, true, false)
+	assert.EqualError(t, err, "1 nodes connected to multiple leaf switches: node103")
+}
+
+func TestAsymmetricFabric(t *testing.T) {
+	// leaf switch 13 hangs directly off the spine, skipping the tier of switches 21 and 22
+	input := []byte(`
+Switch	41 "S-0000000000000001"		# "MF0;IB-ComputeSpine-01:MQM8700/U1" enhanced port 0 lid 1 lmc 0
+[1]	"S-0000000000000021"[31]		# "MF0;IB-ComputeCore-01:MQM8700/U1" lid 21 4xHDR
+[2]	"S-0000000000000022"[31]		# "MF0;IB-ComputeCore-02:MQM8700/U1" lid 22 4xHDR
+[3]	"S-0000000000000013"[31]		# "MF0;IB-ComputeLeaf-03:MQM8700/U1" lid 13 4xHDR
+
+Switch	41 "S-0000000000000021"		# "MF0;IB-ComputeCore-01:MQM8700/U1" enhanced port 0 lid 21 lmc 0
+[1]	"S-0000000000000011"[31]		# "MF0;IB-ComputeLeaf-01:MQM8700/U1" lid 11 4xHDR
+[31]	"S-0000000000000001"[1]		# "MF0;IB-ComputeSpine-01:MQM8700/U1" lid 1 4xHDR
+
+Switch	41 "S-0000000000000022"		# "MF0;IB-ComputeCore-02:MQM8700/U1" enhanced port 0 lid 22 lmc 0
+[1]	"S-0000000000000012"[31]		# "MF0;IB-ComputeLeaf-02:MQM8700/U1" lid 12 4xHDR
+[31]	"S-0000000000000001"[2]		# "MF0;IB-ComputeSpine-01:MQM8700/U1" lid 1 4xHDR
+
+Switch	41 "S-0000000000000011"		# "MF0;IB-ComputeLeaf-01:MQM8700/U1" enhanced port 0 lid 11 lmc 0
+[1]	"H-0000000000000101"[1](0000000000000101) 		# "node101 mlx5_0" lid 101 4xHDR
+[2]	"H-0000000000000102"[1](0000000000000102) 		# "node102 mlx5_0" lid 102 4xHDR
+[31]	"S-0000000000000021"[1]		# "MF0;IB-ComputeCore-01:MQM8700/U1" lid 21 4xHDR
+
+Switch	41 "S-0000000000000012"		# "MF0;IB-ComputeLeaf-02:MQM8700/U1" enhanced port 0 lid 12 lmc 0
+[1]	"H-0000000000000201"[1](0000000000000201) 		# "node201 mlx5_0" lid 201 4xHDR
+[2]	"H-0000000000000202"[1](0000000000000202) 		# "node202 mlx5_0" lid 202 4xHDR
+[31]	"S-0000000000000022"[1]		# "MF0;IB-ComputeCore-02:MQM8700/U1" lid 22 4xHDR
+
+Switch	41 "S-0000000000000013"		# "MF0;IB-ComputeLeaf-03:MQM8700/U1" enhanced port 0 lid 13 lmc 0
+[1]	"H-0000000000000301"[1](0000000000000301) 		# "node301 mlx5_0" lid 301 4xHDR
+[2]	"H-0000000000000302"[1](0000000000000302) 		# "node302 mlx5_0" lid 302 4xHDR
+[31]	"S-0000000000000001"[3]		# "MF0;IB-ComputeSpine-01:MQM8700/U1" lid 1 4xHDR
+
+Ca	1 "H-0000000000000101"		# "node101 mlx5_0"
+Ca	1 "H-0000000000000102"		# "node102 mlx5_0"
+Ca	1 "H-0000000000000201"		# "node201 mlx5_0"
+Ca	1 "H-0000000000000202"		# "node202 mlx5_0"
+Ca	1 "H-0000000000000301"		# "node301 mlx5_0"
+Ca	1 "H-0000000000000302"		# "node302 mlx5_0"
+`)
+
+	testCases := []struct {
+		name     string
+		pad      bool
+		expected map[string]int
+	}{
+		{
+			name: "Case 1: asymmetric tree",
+			expected: map[string]int{
+				"node101": 4, "node102": 4, "node201": 4, "node202": 4, "node301": 3, "node302": 3,
+			},
+		},
+		{
+			name: "Case 2: padded tree",
+			pad:  true,
+			expected: map[string]int{
+				"node101": 4, "node102": 4, "node201": 4, "node202": 4, "node301": 4, "node302": 4,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			root, err := GenerateTopologyConfig(input, false, tc.pad)
+			assert.NoError(t, err)
+			assert.Equal(t, 1, len(root.Vertices))
+
+			depths := make(map[string]int)
+			nodeDepths(root, 0, depths)
+			assert.Equal(t, tc.expected, depths)
+
+			expected := `
+# HELP topograph_asymmetric_leaf_switches Number of switch tiers missing above the leaf switches that are closer to the top of the fabric than the others.
+# TYPE topograph_asymmetric_leaf_switches gauge
+topograph_asymmetric_leaf_switches{switch="S-0000000000000013"} 1
+`
+			err = testutil.GatherAndCompare(prometheus.DefaultGatherer, strings.NewReader(expected),
+				"topograph_asymmetric_leaf_switches")
+			assert.NoError(t, err)
+		})
+	}
+}
+
+// nodeDepths records the depth of the nodes below the vertex at the given depth
+func nodeDepths(v *topology.Vertex, depth int, depths map[string]int) {
+	if len(v.Name) != 0 {
+		depths[v.Name] = depth
+	}
+	for _, child := range v.Vertices {
+		nodeDepths(child, depth+1, depths)
+	}
+}
+
 func TestBuildTree(t *testing.T) {
 	// Simulate switches and HCAs
 	switches := map[string]*Switch{
@@ -18,9 +18,12 @@ package metrics
 
 import (
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/NVIDIA/topograph/pkg/topology"
 )
 
 var (
@@ -43,6 +46,16 @@ var (
 		[]string{"provider", "engine", "status"},
 	)
 
+	resultSize = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:      "result_size_bytes",
+			Help:      "Topology generator result size in bytes.",
+			Subsystem: "topograph",
+			Buckets:   prometheus.ExponentialBuckets(1024, 4, 10),
+		},
+		[]string{"provider", "engine"},
+	)
+
 	missingTopologyNodes = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name:      "missing_topology",
@@ -52,6 +65,56 @@ var (
 		[]string{"provider"},
 	)
 
+	missingBlockNodes = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:      "missing_block",
+			Help:      "Total number of nodes with missing block switch in the topology information.",
+			Subsystem: "topograph",
+		},
+		[]string{"provider"},
+	)
+
+	multiHomedNodes = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name:      "multi_homed_nodes",
+			Help:      "Number of nodes connected to more than one leaf switch.",
+			Subsystem: "topograph",
+		},
+	)
+
+	asymmetricLeafSwitches = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:      "asymmetric_leaf_switches",
+			Help:      "Number of switch tiers missing above the leaf switches that are closer to the top of the fabric than the others.",
+			Subsystem: "topograph",
+		},
+		[]string{"switch"},
+	)
+
+	staleNodes = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name:      "stale_nodes",
+			Help:      "Number of nodes whose topology labels were not refreshed within the staleness threshold.",
+			Subsystem: "topograph",
+		},
+	)
+
+	queueDepth = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name:      "queue_depth",
+			Help:      "Number of topology generation requests waiting to be processed.",
+			Subsystem: "topograph",
+		},
+	)
+
+	inFlightRequests = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name:      "in_flight_requests",
+			Help:      "Number of topology generation requests being processed.",
+			Subsystem: "topograph",
+		},
+	)
+
 	validationErrorsTotal = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Name:      "validation_error_total",
@@ -60,13 +123,109 @@ var (
 		},
 		[]string{"type"},
 	)
+
+	notificationErrorsTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name:      "notification_error_total",
+			Help:      "Total number of failed topology change notifications.",
+			Subsystem: "topograph",
+		},
+	)
+
+	topologyNodes = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:      "topology_nodes",
+			Help:      "Number of compute nodes in the generated topology.",
+			Subsystem: "topograph",
+		},
+		[]string{"provider", "engine"},
+	)
+
+	topologySwitches = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:      "topology_switches",
+			Help:      "Number of switches per tier in the generated topology; tier 1 holds the leaf switches.",
+			Subsystem: "topograph",
+		},
+		[]string{"provider", "engine", "tier"},
+	)
+
+	topologyDepth = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:      "topology_depth",
+			Help:      "Number of switch tiers in the generated topology.",
+			Subsystem: "topograph",
+		},
+		[]string{"provider", "engine"},
+	)
+
+	topologyNoTopologyNodes = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:      "topology_no_topology_nodes",
+			Help:      "Number of nodes without topology information in the generated topology.",
+			Subsystem: "topograph",
+		},
+		[]string{"provider", "engine"},
+	)
+
+	providerCacheHits = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name:      "provider_cache_hits_total",
+			Help:      "Total number of topology requests served from the provider cache.",
+			Subsystem: "topograph",
+		},
+		[]string{"provider"},
+	)
+
+	providerCacheMisses = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name:      "provider_cache_misses_total",
+			Help:      "Total number of topology requests missing the provider cache.",
+			Subsystem: "topograph",
+		},
+		[]string{"provider"},
+	)
+
+	providerCacheEvictions = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name:      "provider_cache_evictions_total",
+			Help:      "Total number of provider topologies evicted from the full provider cache.",
+			Subsystem: "topograph",
+		},
+		[]string{"provider"},
+	)
+
+	topologyBlocks = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:      "topology_blocks",
+			Help:      "Number of accelerator domains in the generated topology.",
+			Subsystem: "topograph",
+		},
+		[]string{"provider", "engine"},
+	)
 )
 
 func init() {
 	prometheus.MustRegister(httpRequestsTotal)
 	prometheus.MustRegister(httpRequestDuration)
+	prometheus.MustRegister(resultSize)
 	prometheus.MustRegister(missingTopologyNodes)
+	prometheus.MustRegister(missingBlockNodes)
+	prometheus.MustRegister(multiHomedNodes)
+	prometheus.MustRegister(asymmetricLeafSwitches)
+	prometheus.MustRegister(staleNodes)
 	prometheus.MustRegister(validationErrorsTotal)
+	prometheus.MustRegister(queueDepth)
+	prometheus.MustRegister(inFlightRequests)
+	prometheus.MustRegister(notificationErrorsTotal)
+	prometheus.MustRegister(topologyNodes)
+	prometheus.MustRegister(topologySwitches)
+	prometheus.MustRegister(topologyDepth)
+	prometheus.MustRegister(topologyNoTopologyNodes)
+	prometheus.MustRegister(topologyBlocks)
+	prometheus.MustRegister(providerCacheHits)
+	prometheus.MustRegister(providerCacheMisses)
+	prometheus.MustRegister(providerCacheEvictions)
 }
 
 func Add(provider, engine string, code int, duration time.Duration) {
@@ -75,10 +234,72 @@ func Add(provider, engine string, code int, duration time.Duration) {
 	httpRequestDuration.WithLabelValues(provider, engine, status).Observe(duration.Seconds())
 }
 
+func ObserveResultSize(provider, engine string, size int) {
+	resultSize.WithLabelValues(provider, engine).Observe(float64(size))
+}
+
 func SetMissingTopology(provider string, count int) {
 	missingTopologyNodes.WithLabelValues(provider).Set(float64(count))
 }
 
+func SetMissingBlock(provider string, count int) {
+	missingBlockNodes.WithLabelValues(provider).Set(float64(count))
+}
+
+func SetMultiHomedNodes(count int) {
+	multiHomedNodes.Set(float64(count))
+}
+
+// SetAsymmetricLeafSwitches replaces the gauges of the leaf switches with missing tiers above them
+func SetAsymmetricLeafSwitches(missingTiers map[string]int) {
+	asymmetricLeafSwitches.Reset()
+	for id, count := range missingTiers {
+		asymmetricLeafSwitches.WithLabelValues(id).Set(float64(count))
+	}
+}
+
+func SetStaleNodes(count int) {
+	staleNodes.Set(float64(count))
+}
+
+func SetQueueDepth(count int) {
+	queueDepth.Set(float64(count))
+}
+
+func SetInFlightRequests(count int) {
+	inFlightRequests.Set(float64(count))
+}
+
 func AddValidationError(errorType string) {
 	validationErrorsTotal.WithLabelValues(errorType).Inc()
 }
+
+func AddNotificationError() {
+	notificationErrorsTotal.Inc()
+}
+
+func AddProviderCacheHit(provider string) {
+	providerCacheHits.WithLabelValues(provider).Inc()
+}
+
+func AddProviderCacheMiss(provider string) {
+	providerCacheMisses.WithLabelValues(provider).Inc()
+}
+
+func AddProviderCacheEviction(provider string) {
+	providerCacheEvictions.WithLabelValues(provider).Inc()
+}
+
+// SetTopologyStats sets the gauges describing the topology generated for the provider and engine
+func SetTopologyStats(provider, engine string, stats *topology.Stats) {
+	topologyNodes.WithLabelValues(provider, engine).Set(float64(stats.Nodes))
+	topologyDepth.WithLabelValues(provider, engine).Set(float64(stats.Depth))
+	topologyNoTopologyNodes.WithLabelValues(provider, engine).Set(float64(stats.NoTopologyNodes))
+	topologyBlocks.WithLabelValues(provider, engine).Set(float64(stats.Blocks))
+
+	// drop the tiers of a previous, deeper topology
+	topologySwitches.DeletePartialMatch(prometheus.Labels{"provider": provider, "engine": engine})
+	for i, count := range stats.Switches {
+		topologySwitches.WithLabelValues(provider, engine, strconv.Itoa(i+1)).Set(float64(count))
+	}
+}
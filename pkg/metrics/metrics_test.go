@@ -0,0 +1,82 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metrics
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/NVIDIA/topograph/pkg/models"
+	"github.com/NVIDIA/topograph/pkg/topology"
+)
+
+func TestSetTopologyStats(t *testing.T) {
+	model, err := models.NewModelFromFile("../../tests/models/medium.yaml", nil)
+	require.NoError(t, err)
+	root, _ := model.ToGraph()
+
+	// a deeper topology generated earlier
+	SetTopologyStats("test", "slurm", &topology.Stats{Switches: []int{1, 1, 1, 1}})
+	SetTopologyStats("test", "slurm", root.Stats())
+
+	expected := `
+# HELP topograph_topology_blocks Number of accelerator domains in the generated topology.
+# TYPE topograph_topology_blocks gauge
+topograph_topology_blocks{engine="slurm",provider="test"} 4
+# HELP topograph_topology_depth Number of switch tiers in the generated topology.
+# TYPE topograph_topology_depth gauge
+topograph_topology_depth{engine="slurm",provider="test"} 3
+# HELP topograph_topology_no_topology_nodes Number of nodes without topology information in the generated topology.
+# TYPE topograph_topology_no_topology_nodes gauge
+topograph_topology_no_topology_nodes{engine="slurm",provider="test"} 0
+# HELP topograph_topology_nodes Number of compute nodes in the generated topology.
+# TYPE topograph_topology_nodes gauge
+topograph_topology_nodes{engine="slurm",provider="test"} 8
+# HELP topograph_topology_switches Number of switches per tier in the generated topology; tier 1 holds the leaf switches.
+# TYPE topograph_topology_switches gauge
+topograph_topology_switches{engine="slurm",provider="test",tier="1"} 4
+topograph_topology_switches{engine="slurm",provider="test",tier="2"} 2
+topograph_topology_switches{engine="slurm",provider="test",tier="3"} 1
+`
+	err = testutil.GatherAndCompare(prometheus.DefaultGatherer, strings.NewReader(expected),
+		"topograph_topology_blocks",
+		"topograph_topology_depth",
+		"topograph_topology_no_topology_nodes",
+		"topograph_topology_nodes",
+		"topograph_topology_switches",
+	)
+	require.NoError(t, err)
+}
+
+func TestProviderCacheMetrics(t *testing.T) {
+	hits := testutil.ToFloat64(providerCacheHits.WithLabelValues("test"))
+	misses := testutil.ToFloat64(providerCacheMisses.WithLabelValues("test"))
+	evictions := testutil.ToFloat64(providerCacheEvictions.WithLabelValues("test"))
+
+	AddProviderCacheMiss("test")
+	AddProviderCacheHit("test")
+	AddProviderCacheHit("test")
+	AddProviderCacheEviction("test")
+
+	require.Equal(t, hits+2, testutil.ToFloat64(providerCacheHits.WithLabelValues("test")))
+	require.Equal(t, misses+1, testutil.ToFloat64(providerCacheMisses.WithLabelValues("test")))
+	require.Equal(t, evictions+1, testutil.ToFloat64(providerCacheEvictions.WithLabelValues("test")))
+}
@@ -0,0 +1,214 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package models
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	DefaultMaxFileSize      = 32 << 20 // 32 MiB
+	DefaultMaxNodes         = 1 << 18
+	DefaultMaxSwitches      = 1 << 16
+	DefaultMaxAliases       = 1 << 10
+	DefaultMaxDepth         = 64
+	DefaultMaxExpandedNodes = 1 << 22
+)
+
+var (
+	// ErrLimitExceeded is returned when a model file exceeds the configured limits
+	ErrLimitExceeded = errors.New("model limit exceeded")
+	// ErrPathNotAllowed is returned when a model file is outside of the allowed directory
+	ErrPathNotAllowed = errors.New("model path not allowed")
+)
+
+// Limits restrict the size and the complexity of the model files.
+// Zero values fall back to the defaults.
+type Limits struct {
+	MaxFileSize      int64 `yaml:"max_file_size,omitempty"`
+	MaxNodes         int   `yaml:"max_nodes,omitempty"`
+	MaxSwitches      int   `yaml:"max_switches,omitempty"`
+	MaxAliases       int   `yaml:"max_aliases,omitempty"`
+	MaxDepth         int   `yaml:"max_depth,omitempty"`
+	MaxExpandedNodes int   `yaml:"max_expanded_nodes,omitempty"`
+
+	// Dir, if set, is the directory the model files must reside in.
+	// Relative model paths are resolved against it.
+	Dir string `yaml:"dir,omitempty"`
+}
+
+func (l *Limits) withDefaults() Limits {
+	var limits Limits
+	if l != nil {
+		limits = *l
+	}
+	if limits.MaxFileSize <= 0 {
+		limits.MaxFileSize = DefaultMaxFileSize
+	}
+	if limits.MaxNodes <= 0 {
+		limits.MaxNodes = DefaultMaxNodes
+	}
+	if limits.MaxSwitches <= 0 {
+		limits.MaxSwitches = DefaultMaxSwitches
+	}
+	if limits.MaxAliases <= 0 {
+		limits.MaxAliases = DefaultMaxAliases
+	}
+	if limits.MaxDepth <= 0 {
+		limits.MaxDepth = DefaultMaxDepth
+	}
+	if limits.MaxExpandedNodes <= 0 {
+		limits.MaxExpandedNodes = DefaultMaxExpandedNodes
+	}
+	return limits
+}
+
+// resolvePath returns the model path, rejecting the paths outside of the allowed directory
+func (l *Limits) resolvePath(fname string) (string, error) {
+	if len(l.Dir) == 0 {
+		return fname, nil
+	}
+
+	dir, err := filepath.Abs(l.Dir)
+	if err != nil {
+		return "", err
+	}
+	if dir, err = filepath.EvalSymlinks(dir); err != nil {
+		return "", err
+	}
+
+	path := fname
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	// resolve symlinks to prevent escaping the directory through a link
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+
+	rel, err := filepath.Rel(dir, filepath.Clean(path))
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%w: %q is outside of %q", ErrPathNotAllowed, fname, l.Dir)
+	}
+
+	return path, nil
+}
+
+// readFile reads the file, failing if its size exceeds the limit
+func (l *Limits) readFile(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	data, err := io.ReadAll(io.LimitReader(f, l.MaxFileSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > l.MaxFileSize {
+		return nil, fmt.Errorf("%w: file size exceeds %d bytes", ErrLimitExceeded, l.MaxFileSize)
+	}
+
+	return data, nil
+}
+
+// decode parses the YAML document, rejecting excessive nesting and alias expansion
+func (l *Limits) decode(data []byte, model *Model) error {
+	var doc yaml.Node
+	if err := yaml.NewDecoder(bytes.NewReader(data)).Decode(&doc); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		return err
+	}
+
+	c := &complexity{limits: l, sizes: make(map[*yaml.Node]int)}
+	size, err := c.walk(&doc, 0)
+	if err != nil {
+		return err
+	}
+	if size > l.MaxExpandedNodes {
+		return fmt.Errorf("%w: document expands to more than %d nodes", ErrLimitExceeded, l.MaxExpandedNodes)
+	}
+
+	return doc.Decode(model)
+}
+
+// checkCounts verifies the number of switches and nodes in the model
+func (l *Limits) checkCounts(model *Model) error {
+	if n := len(model.Switches); n > l.MaxSwitches {
+		return fmt.Errorf("%w: %d switches exceed the limit of %d", ErrLimitExceeded, n, l.MaxSwitches)
+	}
+
+	var nodes int
+	for _, cb := range model.CapacityBlocks {
+		nodes += len(cb.Nodes)
+	}
+	if nodes > l.MaxNodes {
+		return fmt.Errorf("%w: %d nodes exceed the limit of %d", ErrLimitExceeded, nodes, l.MaxNodes)
+	}
+
+	return nil
+}
+
+// complexity computes the size of the YAML document with the aliases expanded
+type complexity struct {
+	limits  *Limits
+	aliases int
+	sizes   map[*yaml.Node]int
+}
+
+func (c *complexity) walk(node *yaml.Node, depth int) (int, error) {
+	if depth > c.limits.MaxDepth {
+		return 0, fmt.Errorf("%w: document nesting exceeds %d levels", ErrLimitExceeded, c.limits.MaxDepth)
+	}
+
+	if node.Kind == yaml.AliasNode {
+		c.aliases++
+		if c.aliases > c.limits.MaxAliases {
+			return 0, fmt.Errorf("%w: document contains more than %d aliases", ErrLimitExceeded, c.limits.MaxAliases)
+		}
+		// the anchored node is walked where it is defined, before any alias to it
+		return c.sizes[node.Alias], nil
+	}
+
+	size := 1
+	for _, child := range node.Content {
+		n, err := c.walk(child, depth+1)
+		if err != nil {
+			return 0, err
+		}
+		size += n
+		if size > c.limits.MaxExpandedNodes {
+			return 0, fmt.Errorf("%w: document expands to more than %d nodes", ErrLimitExceeded, c.limits.MaxExpandedNodes)
+		}
+	}
+
+	if len(node.Anchor) != 0 {
+		c.sizes[node] = size
+	}
+
+	return size, nil
+}
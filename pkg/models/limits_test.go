@@ -0,0 +1,154 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package models
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testModel = `switches:
+- name: S1
+  capacity_blocks: [CB1]
+capacity_blocks:
+- name: CB1
+  type: H100
+  nodes: [n1,n2]
+`
+
+// aliasBomb returns a document expanding to 10^levels nodes
+func aliasBomb(levels int) string {
+	var sb strings.Builder
+	sb.WriteString("a0: &a0 [x,x,x,x,x,x,x,x,x,x]\n")
+	for i := 1; i <= levels; i++ {
+		fmt.Fprintf(&sb, "a%d: &a%d [", i, i)
+		for j := range 10 {
+			if j > 0 {
+				sb.WriteString(",")
+			}
+			fmt.Fprintf(&sb, "*a%d", i-1)
+		}
+		sb.WriteString("]\n")
+	}
+	return sb.String()
+}
+
+func TestNewModelFromFileLimits(t *testing.T) {
+	dir := t.TempDir()
+	outside := t.TempDir()
+
+	write := func(dir, name, data string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(data), 0644))
+		return path
+	}
+
+	valid := write(dir, "model.yaml", testModel)
+	escaped := write(outside, "model.yaml", testModel)
+	require.NoError(t, os.Symlink(escaped, filepath.Join(dir, "link.yaml")))
+
+	testCases := []struct {
+		name   string
+		fname  string
+		limits *Limits
+		err    error
+	}{
+		{
+			name:  "Case 1: default limits",
+			fname: valid,
+		},
+		{
+			name:   "Case 2: file too large",
+			fname:  valid,
+			limits: &Limits{MaxFileSize: 16},
+			err:    ErrLimitExceeded,
+		},
+		{
+			name:   "Case 3: too many nodes",
+			fname:  valid,
+			limits: &Limits{MaxNodes: 1},
+			err:    ErrLimitExceeded,
+		},
+		{
+			name:   "Case 4: too many switches",
+			fname:  write(dir, "switches.yaml", "switches:\n- name: S1\n- name: S2\n"),
+			limits: &Limits{MaxSwitches: 1},
+			err:    ErrLimitExceeded,
+		},
+		{
+			name:  "Case 5: alias bomb",
+			fname: write(dir, "bomb.yaml", aliasBomb(9)),
+			err:   ErrLimitExceeded,
+		},
+		{
+			name:   "Case 6: too many aliases",
+			fname:  write(dir, "aliases.yaml", aliasBomb(2)),
+			limits: &Limits{MaxAliases: 10},
+			err:    ErrLimitExceeded,
+		},
+		{
+			name:   "Case 7: nesting too deep",
+			fname:  write(dir, "deep.yaml", "switches: "+strings.Repeat("[", 20)+strings.Repeat("]", 20)+"\n"),
+			limits: &Limits{MaxDepth: 10},
+			err:    ErrLimitExceeded,
+		},
+		{
+			name:   "Case 8: relative path in the allowed directory",
+			fname:  "model.yaml",
+			limits: &Limits{Dir: dir},
+		},
+		{
+			name:   "Case 9: absolute path in the allowed directory",
+			fname:  valid,
+			limits: &Limits{Dir: dir},
+		},
+		{
+			name:   "Case 10: path traversal",
+			fname:  filepath.Join("..", filepath.Base(outside), "model.yaml"),
+			limits: &Limits{Dir: dir},
+			err:    ErrPathNotAllowed,
+		},
+		{
+			name:   "Case 11: absolute path outside of the allowed directory",
+			fname:  escaped,
+			limits: &Limits{Dir: dir},
+			err:    ErrPathNotAllowed,
+		},
+		{
+			name:   "Case 12: symlink outside of the allowed directory",
+			fname:  "link.yaml",
+			limits: &Limits{Dir: dir},
+			err:    ErrPathNotAllowed,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			model, err := NewModelFromFile(tc.fname, tc.limits)
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+			} else {
+				require.NoError(t, err)
+				require.Len(t, model.Nodes, 2)
+			}
+		})
+	}
+}
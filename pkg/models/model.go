@@ -18,11 +18,10 @@ package models
 
 import (
 	"fmt"
-	"os"
 	"sort"
+	"strconv"
 
-	"gopkg.in/yaml.v3"
-
+	"github.com/NVIDIA/topograph/internal/files"
 	"github.com/NVIDIA/topograph/pkg/topology"
 )
 
@@ -30,6 +29,9 @@ type Model struct {
 	Switches       []*Switch        `yaml:"switches"`
 	CapacityBlocks []*CapacityBlock `yaml:"capacity_blocks"`
 
+	// Metadata holds the model-wide attributes, e.g., the toposim directives
+	Metadata map[string]string `yaml:"metadata,omitempty"`
+
 	// derived
 	Nodes     map[string]*Node
 	Instances []topology.ComputeInstances
@@ -43,10 +45,11 @@ type Switch struct {
 }
 
 type CapacityBlock struct {
-	Name   string   `yaml:"name"`
-	Type   string   `yaml:"type"`
-	NVLink string   `yaml:"nvlink,omitempty"`
-	Nodes  []string `yaml:"nodes"`
+	Name       string   `yaml:"name"`
+	Type       string   `yaml:"type"`
+	NVLink     string   `yaml:"nvlink,omitempty"`
+	NVLinkName string   `yaml:"nvlink_name,omitempty"`
+	Nodes      []string `yaml:"nodes"`
 }
 
 type Node struct {
@@ -63,22 +66,56 @@ func (n *Node) String() string {
 		n.Name, n.Metadata, n.Type, n.NVLink, n.NetLayers, n.CapacityBlock)
 }
 
-func NewModelFromFile(fname string) (*Model, error) {
-	data, err := os.ReadFile(fname)
+// NewModelFromFile loads the model file, enforcing the limits.
+// A nil limits value applies the defaults.
+func NewModelFromFile(fname string, limits *Limits) (*Model, error) {
+	l := limits.withDefaults()
+
+	path, err := l.resolvePath(fname)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := l.readFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", fname, err)
+	}
+
+	return l.newModel(fname, data)
+}
+
+// NewModelFromData loads the model from the YAML or JSON document, enforcing the limits.
+// A nil limits value applies the defaults.
+func NewModelFromData(data []byte, limits *Limits) (*Model, error) {
+	l := limits.withDefaults()
+	if int64(len(data)) > l.MaxFileSize {
+		return nil, fmt.Errorf("%w: model size exceeds %d bytes", ErrLimitExceeded, l.MaxFileSize)
+	}
+
+	return l.newModel("inline model", data)
+}
+
+// newModel parses and validates the model document of the given name
+func (l *Limits) newModel(name string, data []byte) (*Model, error) {
+	data, err := files.Normalize(data)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read %s: %v", fname, err)
+		return nil, fmt.Errorf("failed to read %s: %w", name, err)
 	}
 
 	model := &Model{}
-	if err = yaml.Unmarshal(data, model); err != nil {
-		return nil, fmt.Errorf("failed to parse %s: %v", fname, err)
+	if err = l.decode(data, model); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", name, err)
+	}
+
+	if err = l.checkCounts(model); err != nil {
+		return nil, fmt.Errorf("failed to load %s: %w", name, err)
 	}
 
 	if err = model.setNodeMap(); err != nil {
 		return nil, err
 	}
 
-	return model, err
+	return model, nil
 }
 
 func (m *Model) setNodeMap() error {
@@ -87,8 +124,16 @@ func (m *Model) setNodeMap() error {
 	// capacity block map cb:switch
 	cbmap := make(map[string]*Switch)
 
+	switches := make(map[string]bool, len(m.Switches))
+	for _, sw := range m.Switches {
+		switches[sw.Name] = true
+	}
+
 	for _, parent := range m.Switches {
 		for _, sw := range parent.Switches {
+			if !switches[sw] {
+				return fmt.Errorf("switch %q has undefined child switch %q", parent.Name, sw)
+			}
 			if p, ok := swmap[sw]; ok {
 				// a child switch cannot have more than one parent switch
 				return fmt.Errorf("switch %q has two parent switches %q and %q", sw, parent.Name, p)
@@ -209,6 +254,9 @@ func (model *Model) ToGraph() (*topology.Vertex, map[string]string) {
 	// Initializes all the block vertices
 	for _, cb := range model.CapacityBlocks {
 		blockVertexMap[cb.Name] = &topology.Vertex{ID: cb.Name, Vertices: make(map[string]*topology.Vertex)}
+		if size := topology.ExpectedBlockSize(cb.Type); size > 0 {
+			blockVertexMap[cb.Name].Metadata = map[string]string{topology.KeyExpectedBlockSize: strconv.Itoa(size)}
+		}
 		for _, node := range cb.Nodes {
 			blockVertexMap[cb.Name].Vertices[node] = nodeVertexMap[node]
 		}
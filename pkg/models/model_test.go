@@ -25,7 +25,7 @@ import (
 )
 
 func TestNewModelFromFile(t *testing.T) {
-	cfg, err := NewModelFromFile("../../tests/models/medium.yaml")
+	cfg, err := NewModelFromFile("../../tests/models/medium.yaml", nil)
 	require.NoError(t, err)
 
 	expected := &Model{
@@ -68,16 +68,18 @@ func TestNewModelFromFile(t *testing.T) {
 		},
 		CapacityBlocks: []*CapacityBlock{
 			{
-				Name:   "cb11",
-				Type:   "GB200",
-				NVLink: "nvl1",
-				Nodes:  []string{"n11-1", "n11-2"},
+				Name:       "cb11",
+				Type:       "GB200",
+				NVLink:     "nvl1",
+				NVLinkName: "rack-1",
+				Nodes:      []string{"n11-1", "n11-2"},
 			},
 			{
-				Name:   "cb12",
-				Type:   "GB200",
-				NVLink: "nvl2",
-				Nodes:  []string{"n12-1", "n12-2"},
+				Name:       "cb12",
+				Type:       "GB200",
+				NVLink:     "nvl2",
+				NVLinkName: "rack-2",
+				Nodes:      []string{"n12-1", "n12-2"},
 			},
 			{
 				Name:   "cb13",
@@ -209,3 +211,61 @@ func TestNewModelFromFile(t *testing.T) {
 
 	require.Equal(t, expected, cfg)
 }
+
+func TestNewModelFromData(t *testing.T) {
+	testCases := []struct {
+		name  string
+		data  string
+		nodes []string
+		err   string
+	}{
+		{
+			name:  "Case 1: JSON model",
+			data:  `{"switches":[{"name":"sw1","capacity_blocks":["cb1"]}],"capacity_blocks":[{"name":"cb1","nodes":["n1","n2"]}]}`,
+			nodes: []string{"n1", "n2"},
+		},
+		{
+			name: "Case 2: YAML model",
+			data: `
+switches:
+- name: sw1
+  capacity_blocks: [cb1]
+capacity_blocks:
+- name: cb1
+  nodes: [n1]
+`,
+			nodes: []string{"n1"},
+		},
+		{
+			name: "Case 3: invalid document",
+			data: "switches: [\n",
+			err:  "failed to parse inline model: yaml: line 1: did not find expected node content",
+		},
+		{
+			name: "Case 4: undefined child switch",
+			data: `{"switches":[{"name":"sw1","switches":["sw2"]}]}`,
+			err:  `switch "sw1" has undefined child switch "sw2"`,
+		},
+		{
+			name: "Case 5: too many nodes",
+			data: `{"capacity_blocks":[{"name":"cb1","nodes":["n1","n2","n3"]}]}`,
+			err:  "failed to load inline model: model limit exceeded: 3 nodes exceed the limit of 2",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			model, err := NewModelFromData([]byte(tc.data), &Limits{MaxNodes: 2})
+			if len(tc.err) != 0 {
+				require.EqualError(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			nodes := make([]string, 0, len(model.Nodes))
+			for name := range model.Nodes {
+				nodes = append(nodes, name)
+			}
+			require.ElementsMatch(t, tc.nodes, nodes)
+		})
+	}
+}
@@ -18,9 +18,11 @@ package node_observer
 
 import (
 	"fmt"
-	"os"
+	"time"
 
 	"gopkg.in/yaml.v3"
+
+	"github.com/NVIDIA/topograph/internal/files"
 )
 
 type Config struct {
@@ -29,8 +31,21 @@ type Config struct {
 	NodeLabels           map[string]string `yaml:"node_labels"`
 	Provider             string            `yaml:"provider"`
 	Engine               string            `yaml:"engine"`
+	// Debounce collapses the node events arriving within this window into a single request
+	Debounce time.Duration `yaml:"debounce"`
+	// MaxRequestsPerMinute limits the rate of requests; zero disables the limit
+	MaxRequestsPerMinute int `yaml:"max_requests_per_minute"`
+	// StalenessThreshold is the age of the last-applied node annotation after which the node counts as stale;
+	// zero disables the staleness check
+	StalenessThreshold time.Duration `yaml:"staleness_threshold"`
+	// StalenessInterval is the period of the staleness check
+	StalenessInterval time.Duration `yaml:"staleness_interval"`
+	// MetricsAddress is the listen address of the metrics endpoint, e.g. ":9090"; empty disables the endpoint
+	MetricsAddress string `yaml:"metrics_address"`
 }
 
+const defaultStalenessInterval = time.Minute
+
 type TopologyConfigmap struct {
 	Name      string `yaml:"name"`
 	Namespace string `yaml:"namespace"`
@@ -38,7 +53,7 @@ type TopologyConfigmap struct {
 }
 
 func NewConfigFromFile(fname string) (*Config, error) {
-	data, err := os.ReadFile(fname)
+	data, err := files.ReadFile(fname)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read %s: %v", fname, err)
 	}
@@ -53,5 +68,21 @@ func NewConfigFromFile(fname string) (*Config, error) {
 		return nil, fmt.Errorf("must contain name and namespace for topology_configmap")
 	}
 
+	if cfg.Debounce < 0 {
+		return nil, fmt.Errorf("debounce must not be negative")
+	}
+
+	if cfg.MaxRequestsPerMinute < 0 {
+		return nil, fmt.Errorf("max_requests_per_minute must not be negative")
+	}
+
+	if cfg.StalenessThreshold < 0 || cfg.StalenessInterval < 0 {
+		return nil, fmt.Errorf("staleness_threshold and staleness_interval must not be negative")
+	}
+
+	if cfg.StalenessThreshold > 0 && cfg.StalenessInterval == 0 {
+		cfg.StalenessInterval = defaultStalenessInterval
+	}
+
 	return cfg, nil
 }
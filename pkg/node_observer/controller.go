@@ -23,6 +23,7 @@ import (
 	"fmt"
 	"net/http"
 
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/klog/v2"
 
@@ -35,42 +36,65 @@ type Controller struct {
 	client       kubernetes.Interface
 	cfg          *Config
 	nodeInformer *NodeInformer
+	staleness    *stalenessChecker // nil if the staleness check is disabled
+	cancel       context.CancelFunc
 }
 
 func NewController(ctx context.Context, client kubernetes.Interface, cfg *Config) (*Controller, error) {
-	var f httpreq.RequestFunc = func() (*http.Request, error) {
-		params := map[string]any{
-			topology.KeyTopoConfigPath:         cfg.TopologyConfigmap.Filename,
-			topology.KeyTopoConfigmapName:      cfg.TopologyConfigmap.Name,
-			topology.KeyTopoConfigmapNamespace: cfg.TopologyConfigmap.Namespace,
+	f := func(repairNodes []string) httpreq.RequestFunc {
+		return func() (*http.Request, error) {
+			params := map[string]any{
+				topology.KeyTopoConfigPath:         cfg.TopologyConfigmap.Filename,
+				topology.KeyTopoConfigmapName:      cfg.TopologyConfigmap.Name,
+				topology.KeyTopoConfigmapNamespace: cfg.TopologyConfigmap.Namespace,
+			}
+			// the staleness check relies on the last-applied node annotation
+			if cfg.StalenessThreshold > 0 {
+				params[topology.KeyAnnotate] = true
+			}
+			if len(repairNodes) != 0 {
+				params[topology.KeyRepairNodes] = repairNodes
+			}
+			payload := topology.NewRequest(cfg.Provider, nil, cfg.Engine, params)
+			data, err := json.Marshal(payload)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse payload: %v", err)
+			}
+			req, err := http.NewRequest("POST", cfg.TopologyGeneratorURL, bytes.NewBuffer(data))
+			if err != nil {
+				return nil, fmt.Errorf("failed to create HTTP request: %v", err)
+			}
+			req.Header.Set("Content-Type", "application/json")
+			return req, nil
 		}
-		payload := topology.NewRequest(cfg.Provider, nil, cfg.Engine, params)
-		data, err := json.Marshal(payload)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse payload: %v", err)
-		}
-		req, err := http.NewRequest("POST", cfg.TopologyGeneratorURL, bytes.NewBuffer(data))
-		if err != nil {
-			return nil, fmt.Errorf("failed to create HTTP request: %v", err)
-		}
-		req.Header.Set("Content-Type", "application/json")
-		return req, nil
 	}
-	return &Controller{
+	ctx, cancel := context.WithCancel(ctx)
+	c := &Controller{
 		ctx:          ctx,
 		client:       client,
 		cfg:          cfg,
-		nodeInformer: NewNodeInformer(ctx, client, cfg.NodeLabels, f),
-	}, nil
+		nodeInformer: NewNodeInformer(ctx, client, cfg, f),
+		cancel:       cancel,
+	}
+	if cfg.StalenessThreshold > 0 {
+		selector := labels.Set(cfg.NodeLabels).AsSelector().String()
+		c.staleness = newStalenessChecker(client, selector, cfg.StalenessThreshold, cfg.StalenessInterval)
+	}
+	return c, nil
 }
 
 func (c *Controller) Start() error {
 	klog.Infof("Starting state observer")
 
+	if c.staleness != nil {
+		go c.staleness.run(c.ctx)
+	}
+
 	return c.nodeInformer.Start()
 }
 
 func (c *Controller) Stop(err error) {
 	klog.Infof("Stopping state observer")
+	c.cancel()
 	c.nodeInformer.Stop(err)
 }
@@ -0,0 +1,121 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package node_observer
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+const rateLimitPeriod = time.Minute
+
+// debouncer collapses the events arriving within the debounce window into a single request,
+// and limits the number of requests per minute
+type debouncer struct {
+	window time.Duration
+	limit  int // maximum number of requests per minute; zero disables the limit
+	send   func(repairNodes []string)
+
+	mutex   sync.Mutex
+	timer   *time.Timer     // pending flush, if not nil
+	full    bool            // a pending event requires full topology generation
+	nodes   map[string]bool // nodes to repair
+	sent    []time.Time     // times of the requests sent within the rate limit period
+	stopped bool
+}
+
+func newDebouncer(window time.Duration, limit int, send func(repairNodes []string)) *debouncer {
+	return &debouncer{
+		window: window,
+		limit:  limit,
+		send:   send,
+		nodes:  make(map[string]bool),
+	}
+}
+
+// add registers an event; without repairNodes the event requires full topology generation
+func (d *debouncer) add(repairNodes ...string) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if d.stopped {
+		return
+	}
+
+	if len(repairNodes) == 0 {
+		d.full = true
+	}
+	for _, node := range repairNodes {
+		d.nodes[node] = true
+	}
+
+	if d.timer == nil {
+		d.timer = time.AfterFunc(d.window, d.flush)
+	}
+}
+
+// flush sends the request for the collected events, unless the rate limit is reached
+func (d *debouncer) flush() {
+	d.mutex.Lock()
+
+	if d.stopped {
+		d.mutex.Unlock()
+		return
+	}
+
+	now := time.Now()
+	for len(d.sent) != 0 && now.Sub(d.sent[0]) >= rateLimitPeriod {
+		d.sent = d.sent[1:]
+	}
+	if d.limit > 0 && len(d.sent) >= d.limit {
+		wait := d.sent[0].Add(rateLimitPeriod).Sub(now)
+		klog.V(4).Infof("Request rate limit reached; delaying request by %s", wait.String())
+		d.timer = time.AfterFunc(wait, d.flush)
+		d.mutex.Unlock()
+		return
+	}
+
+	var nodes []string
+	if !d.full {
+		nodes = make([]string, 0, len(d.nodes))
+		for node := range d.nodes {
+			nodes = append(nodes, node)
+		}
+		sort.Strings(nodes)
+	}
+	d.full = false
+	d.nodes = make(map[string]bool)
+	d.timer = nil
+	d.sent = append(d.sent, now)
+	d.mutex.Unlock()
+
+	d.send(nodes)
+}
+
+// stop cancels the pending request
+func (d *debouncer) stop() {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.stopped = true
+	if d.timer != nil {
+		d.timer.Stop()
+		d.timer = nil
+	}
+}
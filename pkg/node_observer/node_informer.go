@@ -18,6 +18,7 @@ package node_observer
 
 import (
 	"context"
+	"time"
 
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -30,24 +31,41 @@ import (
 	"github.com/NVIDIA/topograph/internal/httpreq"
 )
 
+// requestRetryPolicy retries the topology requests rejected by a busy or rate-limiting topology generator
+var requestRetryPolicy = httpreq.RetryPolicy{
+	MaxAttempts:    5,
+	BaseDelay:      2 * time.Second,
+	MaxDelay:       time.Minute,
+	MaxElapsedTime: 5 * time.Minute,
+}
+
+// RequestFuncBuilder returns the topology request function.
+// Non-empty repairNodes limit the request to re-applying the placement of the listed nodes.
+type RequestFuncBuilder func(repairNodes []string) httpreq.RequestFunc
+
 type NodeInformer struct {
-	ctx     context.Context
-	client  kubernetes.Interface
-	reqFunc httpreq.RequestFunc
-	factory informers.SharedInformerFactory
+	ctx       context.Context
+	client    kubernetes.Interface
+	reqFunc   RequestFuncBuilder
+	factory   informers.SharedInformerFactory
+	debouncer *debouncer
 }
 
-func NewNodeInformer(ctx context.Context, client kubernetes.Interface, nodeLabels map[string]string, reqFunc httpreq.RequestFunc) *NodeInformer {
-	klog.Infof("Configuring node informer with labels %v", nodeLabels)
+func NewNodeInformer(ctx context.Context, client kubernetes.Interface, cfg *Config, reqFunc RequestFuncBuilder) *NodeInformer {
+	klog.Infof("Configuring node informer with labels %v, debounce %s, max requests per minute %d",
+		cfg.NodeLabels, cfg.Debounce.String(), cfg.MaxRequestsPerMinute)
 	listOptionsFunc := func(options *metav1.ListOptions) {
-		options.LabelSelector = labels.Set(nodeLabels).AsSelector().String()
+		options.LabelSelector = labels.Set(cfg.NodeLabels).AsSelector().String()
 	}
-	return &NodeInformer{
+	n := &NodeInformer{
 		ctx:     ctx,
 		client:  client,
 		reqFunc: reqFunc,
 		factory: informers.NewSharedInformerFactoryWithOptions(client, 0, informers.WithTweakListOptions(listOptionsFunc)),
 	}
+	n.debouncer = newDebouncer(cfg.Debounce, cfg.MaxRequestsPerMinute, n.SendRequest)
+
+	return n
 }
 
 func (n *NodeInformer) Start() error {
@@ -59,7 +77,8 @@ func (n *NodeInformer) Start() error {
 		AddFunc: func(obj interface{}) {
 			node := obj.(*v1.Node)
 			klog.V(4).Infof("Node informer added node %s", node.Name)
-			n.SendRequest()
+			// a recreated node only needs its labels re-applied; unknown nodes trigger a full run
+			n.debouncer.add(node.Name)
 		},
 		UpdateFunc: func(_, obj interface{}) {
 			// TODO: clarify the change in node spec that would warrant topology update
@@ -68,7 +87,7 @@ func (n *NodeInformer) Start() error {
 		DeleteFunc: func(obj interface{}) {
 			node := obj.(*v1.Node)
 			klog.V(4).Infof("Node informer deleted node %s", node.Name)
-			n.SendRequest()
+			n.debouncer.add()
 		},
 	})
 	if err != nil {
@@ -82,11 +101,13 @@ func (n *NodeInformer) Start() error {
 
 func (n *NodeInformer) Stop(_ error) {
 	klog.Infof("Stopping node informer")
+	n.debouncer.stop()
 	n.factory.Shutdown()
 }
 
-func (n *NodeInformer) SendRequest() {
-	_, _, err := httpreq.DoRequestWithRetries(n.reqFunc)
+// SendRequest sends the topology request; with repairNodes, for these nodes only
+func (n *NodeInformer) SendRequest(repairNodes []string) {
+	_, _, err := httpreq.DoRequestWithPolicy(nil, n.reqFunc(repairNodes), requestRetryPolicy)
 	if err != nil {
 		klog.Errorf("failed to send HTTP request: %v", err)
 	}
@@ -0,0 +1,119 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package node_observer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/NVIDIA/topograph/pkg/topology"
+)
+
+func TestDebounce(t *testing.T) {
+	testCases := []struct {
+		name      string
+		deletions int
+		expected  []any
+	}{
+		{
+			name:     "Case 1: burst of node additions",
+			expected: []any{"node00", "node01", "node02"},
+		},
+		{
+			name:      "Case 2: burst with node deletion",
+			deletions: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var mutex sync.Mutex
+			var requests []*topology.Request
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				tr := &topology.Request{}
+				_ = json.Unmarshal(body, tr)
+				mutex.Lock()
+				requests = append(requests, tr)
+				mutex.Unlock()
+				w.WriteHeader(http.StatusAccepted)
+			}))
+			defer ts.Close()
+
+			cfg := &Config{
+				TopologyGeneratorURL: ts.URL,
+				TopologyConfigmap:    TopologyConfigmap{Name: "topology-config", Namespace: "default"},
+				Engine:               "k8s",
+				Debounce:             300 * time.Millisecond,
+			}
+			c, err := NewController(context.TODO(), fake.NewSimpleClientset(), cfg)
+			require.NoError(t, err)
+			defer c.Stop(nil)
+
+			for i := 0; i < 50; i++ {
+				c.nodeInformer.debouncer.add(fmt.Sprintf("node%02d", i%3))
+			}
+			for i := 0; i < tc.deletions; i++ {
+				c.nodeInformer.debouncer.add()
+			}
+
+			time.Sleep(100 * time.Millisecond)
+			mutex.Lock()
+			require.Empty(t, requests)
+			mutex.Unlock()
+
+			time.Sleep(500 * time.Millisecond)
+			mutex.Lock()
+			defer mutex.Unlock()
+			require.Len(t, requests, 1)
+			if tc.expected == nil {
+				require.NotContains(t, requests[0].Engine.Params, topology.KeyRepairNodes)
+			} else {
+				require.Equal(t, tc.expected, requests[0].Engine.Params[topology.KeyRepairNodes])
+			}
+		})
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	var mutex sync.Mutex
+	var sent int
+	d := newDebouncer(10*time.Millisecond, 2, func(_ []string) {
+		mutex.Lock()
+		sent++
+		mutex.Unlock()
+	})
+	defer d.stop()
+
+	for i := 0; i < 3; i++ {
+		d.add()
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	require.Equal(t, 2, sent)
+}
@@ -0,0 +1,92 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package node_observer
+
+import (
+	"context"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+
+	"github.com/NVIDIA/topograph/pkg/engines/k8s"
+	"github.com/NVIDIA/topograph/pkg/metrics"
+)
+
+// stalenessChecker periodically counts the nodes whose topology labels were not refreshed within the threshold
+type stalenessChecker struct {
+	client    kubernetes.Interface
+	selector  string
+	threshold time.Duration
+	interval  time.Duration
+	now       func() time.Time
+}
+
+func newStalenessChecker(client kubernetes.Interface, selector string, threshold, interval time.Duration) *stalenessChecker {
+	return &stalenessChecker{
+		client:    client,
+		selector:  selector,
+		threshold: threshold,
+		interval:  interval,
+		now:       time.Now,
+	}
+}
+
+// run exports the number of stale nodes every interval until the context is done
+func (s *stalenessChecker) run(ctx context.Context) {
+	klog.Infof("Starting staleness checker with threshold %s, interval %s", s.threshold.String(), s.interval.String())
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		if count, err := s.check(ctx); err != nil {
+			klog.Errorf("failed to check node staleness: %v", err)
+		} else {
+			metrics.SetStaleNodes(count)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// check returns the number of stale nodes
+func (s *stalenessChecker) check(ctx context.Context) (int, error) {
+	nodeList, err := s.client.CoreV1().Nodes().List(ctx, metav1.ListOptions{LabelSelector: s.selector})
+	if err != nil {
+		return 0, err
+	}
+	return countStaleNodes(nodeList.Items, s.now(), s.threshold), nil
+}
+
+// countStaleNodes counts the nodes whose last-applied annotation is older than the threshold.
+// Nodes without a valid annotation have never been stamped and count as stale.
+func countStaleNodes(nodes []v1.Node, now time.Time, threshold time.Duration) int {
+	count := 0
+	for _, node := range nodes {
+		ts, err := time.Parse(time.RFC3339, node.Annotations[k8s.AnnotationLastApplied])
+		if err != nil || now.Sub(ts) > threshold {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,102 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package node_observer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/NVIDIA/topograph/pkg/engines/k8s"
+)
+
+func testNode(name string, nodeLabels map[string]string, lastApplied string) *v1.Node {
+	node := &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: nodeLabels}}
+	if len(lastApplied) != 0 {
+		node.Annotations = map[string]string{k8s.AnnotationLastApplied: lastApplied}
+	}
+	return node
+}
+
+func TestCountStaleNodes(t *testing.T) {
+	now := time.Date(2024, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name      string
+		nodes     []v1.Node
+		threshold time.Duration
+		count     int
+	}{
+		{
+			name:      "Case 1: no nodes",
+			threshold: time.Hour,
+		},
+		{
+			name: "Case 2: fresh and stale nodes",
+			nodes: []v1.Node{
+				*testNode("node1", nil, "2024-10-01T11:30:00Z"),
+				*testNode("node2", nil, "2024-10-01T10:59:59Z"),
+				*testNode("node3", nil, "2024-10-01T11:00:00Z"),
+			},
+			threshold: time.Hour,
+			count:     1,
+		},
+		{
+			name: "Case 3: missing and invalid annotations",
+			nodes: []v1.Node{
+				*testNode("node1", nil, ""),
+				*testNode("node2", nil, "yesterday"),
+				*testNode("node3", nil, "2024-10-01T04:59:00-07:00"),
+			},
+			threshold: time.Hour,
+			count:     2,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.count, countStaleNodes(tc.nodes, now, tc.threshold))
+		})
+	}
+}
+
+func TestStalenessCheck(t *testing.T) {
+	gpu := map[string]string{"gpu": "true"}
+	client := fake.NewSimpleClientset(
+		testNode("node1", gpu, "2024-10-01T11:30:00Z"),
+		testNode("node2", gpu, "2024-10-01T10:00:00Z"),
+		testNode("node3", nil, "2024-10-01T10:00:00Z"),
+	)
+
+	now := time.Date(2024, 10, 1, 12, 0, 0, 0, time.UTC)
+	s := newStalenessChecker(client, "gpu=true", time.Hour, time.Minute)
+	s.now = func() time.Time { return now }
+
+	count, err := s.check(context.TODO())
+	require.NoError(t, err)
+	require.Equal(t, 1, count)
+
+	// advancing the clock makes the fresh node stale
+	now = now.Add(time.Hour)
+	count, err = s.check(context.TODO())
+	require.NoError(t, err)
+	require.Equal(t, 2, count)
+}
@@ -0,0 +1,178 @@
+//
+// Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v5.27.0
+// source: topograph.proto
+
+package protos
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	TopographService_Generate_FullMethodName    = "/topograph.TopographService/Generate"
+	TopographService_GetTopology_FullMethodName = "/topograph.TopographService/GetTopology"
+)
+
+// TopographServiceClient is the client API for TopographService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type TopographServiceClient interface {
+	Generate(ctx context.Context, in *GenerateRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[TopologyStatus], error)
+	GetTopology(ctx context.Context, in *TopologyUID, opts ...grpc.CallOption) (*TopologyConfig, error)
+}
+
+type topographServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewTopographServiceClient(cc grpc.ClientConnInterface) TopographServiceClient {
+	return &topographServiceClient{cc}
+}
+
+func (c *topographServiceClient) Generate(ctx context.Context, in *GenerateRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[TopologyStatus], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &TopographService_ServiceDesc.Streams[0], TopographService_Generate_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[GenerateRequest, TopologyStatus]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type TopographService_GenerateClient = grpc.ServerStreamingClient[TopologyStatus]
+
+func (c *topographServiceClient) GetTopology(ctx context.Context, in *TopologyUID, opts ...grpc.CallOption) (*TopologyConfig, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TopologyConfig)
+	err := c.cc.Invoke(ctx, TopographService_GetTopology_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// TopographServiceServer is the server API for TopographService service.
+// All implementations must embed UnimplementedTopographServiceServer
+// for forward compatibility.
+type TopographServiceServer interface {
+	Generate(*GenerateRequest, grpc.ServerStreamingServer[TopologyStatus]) error
+	GetTopology(context.Context, *TopologyUID) (*TopologyConfig, error)
+	mustEmbedUnimplementedTopographServiceServer()
+}
+
+// UnimplementedTopographServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedTopographServiceServer struct{}
+
+func (UnimplementedTopographServiceServer) Generate(*GenerateRequest, grpc.ServerStreamingServer[TopologyStatus]) error {
+	return status.Errorf(codes.Unimplemented, "method Generate not implemented")
+}
+func (UnimplementedTopographServiceServer) GetTopology(context.Context, *TopologyUID) (*TopologyConfig, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTopology not implemented")
+}
+func (UnimplementedTopographServiceServer) mustEmbedUnimplementedTopographServiceServer() {}
+func (UnimplementedTopographServiceServer) testEmbeddedByValue()                          {}
+
+// UnsafeTopographServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to TopographServiceServer will
+// result in compilation errors.
+type UnsafeTopographServiceServer interface {
+	mustEmbedUnimplementedTopographServiceServer()
+}
+
+func RegisterTopographServiceServer(s grpc.ServiceRegistrar, srv TopographServiceServer) {
+	// If the following call pancis, it indicates UnimplementedTopographServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&TopographService_ServiceDesc, srv)
+}
+
+func _TopographService_Generate_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(GenerateRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(TopographServiceServer).Generate(m, &grpc.GenericServerStream[GenerateRequest, TopologyStatus]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type TopographService_GenerateServer = grpc.ServerStreamingServer[TopologyStatus]
+
+func _TopographService_GetTopology_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TopologyUID)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TopographServiceServer).GetTopology(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TopographService_GetTopology_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TopographServiceServer).GetTopology(ctx, req.(*TopologyUID))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// TopographService_ServiceDesc is the grpc.ServiceDesc for TopographService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var TopographService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "topograph.TopographService",
+	HandlerType: (*TopographServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetTopology",
+			Handler:    _TopographService_GetTopology_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Generate",
+			Handler:       _TopographService_Generate_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "topograph.proto",
+}
@@ -18,40 +18,155 @@ package aws
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
 	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	"k8s.io/klog/v2"
 
 	"github.com/NVIDIA/topograph/pkg/metrics"
+	"github.com/NVIDIA/topograph/pkg/providers"
 	"github.com/NVIDIA/topograph/pkg/topology"
 	"github.com/NVIDIA/topograph/pkg/translate"
 )
 
 var defaultPageSize int32 = 100
 
-func (p *baseProvider) generateInstanceTopology(ctx context.Context, pageSize *int, cis []topology.ComputeInstances) ([]types.InstanceTopology, error) {
+// DomainNameTag is the resource tag holding the display name of a capacity block
+const DomainNameTag = "Name"
+
+// maxCredentialRefreshes is the number of credential refreshes within a region sweep
+const maxCredentialRefreshes = 3
+
+// ErrCredentialsExpired indicates that the AWS credentials expired and could not be refreshed
+var ErrCredentialsExpired = errors.New("AWS credentials expired")
+
+// isCredentialsExpired returns true if the AWS request was rejected due to expired or invalid credentials
+func isCredentialsExpired(err error) bool {
+	var apiErr interface{ ErrorCode() string }
+	if errors.As(err, &apiErr) {
+		switch apiErr.ErrorCode() {
+		case "ExpiredToken", "ExpiredTokenException", "RequestExpired":
+			return true
+		}
+	}
+	var respErr interface{ HTTPStatusCode() int }
+	return errors.As(err, &respErr) && respErr.HTTPStatusCode() == http.StatusUnauthorized
+}
+
+func (p *baseProvider) generateInstanceTopology(ctx context.Context, pageSize *int, cis []topology.ComputeInstances) ([]types.InstanceTopology, map[string]string, error) {
 	var (
-		err      error
-		topology []types.InstanceTopology
-		limit    int32
+		instances   []types.InstanceTopology
+		limit       int32
+		domainNames map[string]string
+		concurrency int
 	)
 
-	if pageSize != nil {
-		limit = int32(*pageSize)
-	} else {
-		limit = defaultPageSize
+	var paramSize int
+	if p.params != nil {
+		paramSize = p.params.PageSize
+		concurrency = p.params.RegionConcurrency
 	}
+	limit = int32(providers.GetPageSize(paramSize, pageSize, int(defaultPageSize)))
 
-	for _, ci := range cis {
-		if topology, err = p.generateInstanceTopologyForRegionInstances(ctx, limit, &ci, topology); err != nil {
-			return nil, err
+	fetchTags := p.params != nil && p.params.FetchTags
+
+	// the regions are requested concurrently, and their results merged in the region order
+	regionTopology := make([][]types.InstanceTopology, len(cis))
+	regionDomainNames := make([]map[string]string, len(cis))
+	err := providers.ForEachRegion(ctx, cis, concurrency, func(ctx context.Context, i int, ci *topology.ComputeInstances) error {
+		top, err := p.generateInstanceTopologyForRegionInstances(ctx, limit, ci, nil)
+		if err != nil {
+			return err
 		}
+		regionTopology[i] = top
+		if fetchTags {
+			regionDomainNames[i] = make(map[string]string)
+			return p.getDomainNames(ctx, ci.Region, top, regionDomainNames[i])
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
 	}
 
-	return topology, nil
+	if fetchTags {
+		domainNames = make(map[string]string)
+	}
+	for i := range cis {
+		instances = append(instances, regionTopology[i]...)
+		for id, name := range regionDomainNames[i] {
+			if len(name) != 0 || len(domainNames[id]) == 0 {
+				domainNames[id] = name
+			}
+		}
+	}
+
+	return instances, domainNames, nil
+}
+
+// getDomainNames collects display names of the capacity blocks from their resource tags
+func (p *baseProvider) getDomainNames(ctx context.Context, region string, top []types.InstanceTopology, domainNames map[string]string) error {
+	ids := []string{}
+	for _, inst := range top {
+		id := capacityBlockID(&inst)
+		if len(id) == 0 {
+			continue
+		}
+		if _, ok := domainNames[id]; !ok {
+			domainNames[id] = ""
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	client, err := p.clientFactory(region)
+	if err != nil {
+		return err
+	}
+
+	input := &ec2.DescribeTagsInput{
+		Filters: []types.Filter{
+			{Name: aws.String("resource-id"), Values: ids},
+			{Name: aws.String("key"), Values: []string{DomainNameTag}},
+		},
+	}
+	for {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("interrupted tag pagination: %w", err)
+		}
+		output, err := client.EC2.DescribeTags(ctx, input)
+		if err != nil {
+			return fmt.Errorf("failed to describe tags: %w", err)
+		}
+		for _, tag := range output.Tags {
+			if tag.ResourceId != nil && tag.Value != nil {
+				domainNames[*tag.ResourceId] = *tag.Value
+			}
+		}
+		if output.NextToken == nil {
+			break
+		}
+		input.NextToken = output.NextToken
+	}
+	klog.V(4).Infof("Capacity block names %v", domainNames)
+
+	return nil
+}
+
+// capacityBlockID returns the capacity block of the instance, i.e. its NVLink domain,
+// or an empty string if the instance is not in a capacity block
+func capacityBlockID(inst *types.InstanceTopology) string {
+	if inst.CapacityBlockId == nil {
+		return ""
+	}
+	return *inst.CapacityBlockId
 }
 
 func (p *baseProvider) generateInstanceTopologyForRegionInstances(ctx context.Context, pageSize int32, ci *topology.ComputeInstances, topology []types.InstanceTopology) ([]types.InstanceTopology, error) {
@@ -81,15 +196,33 @@ func (p *baseProvider) generateInstanceTopologyForRegionInstances(ctx context.Co
 		input.MaxResults = &pageSize
 	}
 
-	var cycle, total int
+	var cycle, total, refreshes int
 	for {
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("interrupted instance topology pagination after %d cycles: %w", cycle, err)
+		}
 		cycle++
 		klog.V(4).Infof("Starting cycle %d", cycle)
 		start := time.Now()
 		output, err := client.EC2.DescribeInstanceTopology(ctx, input)
+		if err != nil && isCredentialsExpired(err) {
+			apiLatency.WithLabelValues(ci.Region, "CredentialsExpired").Observe(time.Since(start).Seconds())
+			if client.RefreshCredentials == nil || refreshes == maxCredentialRefreshes {
+				return nil, fmt.Errorf("failed to describe instance topology: %w: %v", ErrCredentialsExpired, err)
+			}
+			refreshes++
+			// resume from the current page with a client using new credentials
+			klog.Warningf("AWS credentials expired in cycle %d; refreshing", cycle)
+			client.RefreshCredentials()
+			if client, err = p.clientFactory(ci.Region); err != nil {
+				return nil, err
+			}
+			cycle--
+			continue
+		}
 		if err != nil {
 			apiLatency.WithLabelValues(ci.Region, "Error").Observe(time.Since(start).Seconds())
-			return nil, fmt.Errorf("failed to describe instance topology: %v", err)
+			return nil, fmt.Errorf("failed to describe instance topology: %w", err)
 		}
 		apiLatency.WithLabelValues(ci.Region, "Success").Observe(time.Since(start).Seconds())
 		total += len(output.Instances)
@@ -111,7 +244,7 @@ func (p *baseProvider) generateInstanceTopologyForRegionInstances(ctx context.Co
 	return topology, nil
 }
 
-func toGraph(top []types.InstanceTopology, cis []topology.ComputeInstances) (*topology.Vertex, error) {
+func toGraph(top []types.InstanceTopology, cis []topology.ComputeInstances, domainNames map[string]string, missingBlockPolicy, groupUnplaced string) (*topology.Vertex, error) {
 	i2n := make(map[string]string)
 	for _, ci := range cis {
 		for instance, node := range ci.Instances {
@@ -123,6 +256,8 @@ func toGraph(top []types.InstanceTopology, cis []topology.ComputeInstances) (*to
 	forest := make(map[string]*topology.Vertex)
 	nodes := make(map[string]*topology.Vertex)
 	domainMap := translate.NewDomainMap()
+	instanceTypes := make(map[string]string)
+	var missingBlock int
 
 	for _, inst := range top {
 		//klog.V(4).Infof("Checking instance %q", c.InstanceId)
@@ -131,69 +266,65 @@ func toGraph(top []types.InstanceTopology, cis []topology.ComputeInstances) (*to
 			continue
 		}
 		klog.V(4).Infof("Found node %q instance %q", nodeName, *inst.InstanceId)
-		delete(i2n, *inst.InstanceId)
-
-		// update domain map
-		if inst.CapacityBlockId != nil {
-			domainMap.AddHost(*inst.CapacityBlockId, nodeName)
+		if inst.InstanceType != nil {
+			instanceTypes[*inst.InstanceId] = *inst.InstanceType
 		}
 
-		instance := &topology.Vertex{
-			Name: nodeName,
-			ID:   *inst.InstanceId,
-		}
-		// process level 3 node
-		id3 := inst.NetworkNodes[2]
-		sw3, ok := nodes[id3]
-		if !ok { //
-			sw3 = &topology.Vertex{
-				ID:       id3,
-				Vertices: make(map[string]*topology.Vertex),
+		// update domain map; instances outside of capacity blocks are in the tree topology only
+		if domain := capacityBlockID(&inst); len(domain) != 0 {
+			domainMap.AddHost(domain, nodeName)
+			if inst.InstanceType != nil {
+				if size := topology.ExpectedBlockSize(*inst.InstanceType); size > 0 {
+					domainMap.SetExpectedSize(domain, size)
+				}
 			}
-			nodes[id3] = sw3
 		}
-		sw3.Vertices[instance.ID] = instance
 
-		// process level 2 node
-		id2 := inst.NetworkNodes[1]
-		sw2, ok := nodes[id2]
-		if !ok { //
-			sw2 = &topology.Vertex{
-				ID:       id2,
-				Vertices: make(map[string]*topology.Vertex),
-			}
-			nodes[id2] = sw2
+		// switch IDs starting from the lowest tier
+		var switchIDs []string
+		switch len(inst.NetworkNodes) {
+		case 3:
+			switchIDs = []string{inst.NetworkNodes[2], inst.NetworkNodes[1], inst.NetworkNodes[0]}
+		case 2:
+			klog.Warningf("Block network node is missing for instance %q", *inst.InstanceId)
+			missingBlock++
+			switchIDs = topology.ResolveMissingBlock(missingBlockPolicy, []string{inst.NetworkNodes[1], inst.NetworkNodes[0]})
+		default:
+			klog.Warningf("Unexpected number of network nodes %d for instance %q", len(inst.NetworkNodes), *inst.InstanceId)
 		}
-		sw2.Vertices[id3] = sw3
+		if len(switchIDs) == 0 {
+			continue
+		}
+		delete(i2n, *inst.InstanceId)
 
-		// process level 1 node
-		id1 := inst.NetworkNodes[0]
-		sw1, ok := nodes[id1]
-		if !ok { //
-			sw1 = &topology.Vertex{
-				ID:       id1,
-				Vertices: make(map[string]*topology.Vertex),
+		child := &topology.Vertex{
+			Name: nodeName,
+			ID:   *inst.InstanceId,
+		}
+		for i, id := range switchIDs {
+			sw, ok := nodes[id]
+			if !ok {
+				sw = &topology.Vertex{
+					ID:       id,
+					Vertices: make(map[string]*topology.Vertex),
+				}
+				nodes[id] = sw
+				if i == len(switchIDs)-1 {
+					forest[id] = sw
+				}
 			}
-			nodes[id1] = sw1
-			forest[id1] = sw1
+			sw.Vertices[child.ID] = child
+			child = sw
 		}
-		sw1.Vertices[id2] = sw2
 	}
+	metrics.SetMissingBlock(NAME, missingBlock)
 
 	if len(i2n) != 0 {
 		klog.V(4).Infof("Adding nodes w/o topology: %v", i2n)
 		metrics.SetMissingTopology(NAME, len(i2n))
-		sw := &topology.Vertex{
-			ID:       topology.NoTopology,
-			Vertices: make(map[string]*topology.Vertex),
-		}
-		for instanceID, nodeName := range i2n {
-			sw.Vertices[instanceID] = &topology.Vertex{
-				Name: nodeName,
-				ID:   instanceID,
-			}
+		for id, sw := range topology.NoTopologySwitches(i2n, instanceTypes, groupUnplaced) {
+			forest[id] = sw
 		}
-		forest[topology.NoTopology] = sw
 	}
 
 	treeRoot := &topology.Vertex{
@@ -207,6 +338,9 @@ func toGraph(top []types.InstanceTopology, cis []topology.ComputeInstances) (*to
 		Vertices: map[string]*topology.Vertex{topology.TopologyTree: treeRoot},
 	}
 	if len(domainMap) != 0 {
+		for domain, name := range domainNames {
+			domainMap.SetDisplayName(domain, name)
+		}
 		root.Vertices[topology.TopologyBlock] = domainMap.ToBlocks()
 	}
 
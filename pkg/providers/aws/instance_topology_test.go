@@ -114,7 +114,231 @@ func TestNewInstanceTopology(t *testing.T) {
 		Vertices: map[string]*topology.Vertex{topology.TopologyTree: v0},
 	}
 
-	tree, err := toGraph(top, []topology.ComputeInstances{{Instances: i2n}})
+	tree, err := toGraph(top, []topology.ComputeInstances{{Instances: i2n}}, nil, "", "")
 	require.NoError(t, err)
 	require.Equal(t, expected, tree)
 }
+
+func TestMissingBlockPolicy(t *testing.T) {
+	top := []types.InstanceTopology{
+		{
+			InstanceId:   aws.String("i1"),
+			NetworkNodes: []string{"nn-core", "nn-spine", "nn-block"},
+		},
+		{
+			InstanceId:   aws.String("i2"),
+			NetworkNodes: []string{"nn-core", "nn-spine"},
+		},
+	}
+	cis := []topology.ComputeInstances{{Instances: map[string]string{"i1": "node1", "i2": "node2"}}}
+
+	n1 := &topology.Vertex{ID: "i1", Name: "node1"}
+	n2 := &topology.Vertex{ID: "i2", Name: "node2"}
+	block := &topology.Vertex{ID: "nn-block", Vertices: map[string]*topology.Vertex{"i1": n1}}
+
+	toRoot := func(spine *topology.Vertex, extra map[string]*topology.Vertex) *topology.Vertex {
+		forest := map[string]*topology.Vertex{
+			"nn-core": {ID: "nn-core", Vertices: map[string]*topology.Vertex{"nn-spine": spine}},
+		}
+		for id, v := range extra {
+			forest[id] = v
+		}
+		return &topology.Vertex{
+			Vertices: map[string]*topology.Vertex{topology.TopologyTree: {Vertices: forest}},
+		}
+	}
+
+	testCases := []struct {
+		name     string
+		policy   string
+		expected *topology.Vertex
+	}{
+		{
+			name:   "Case 1: default policy",
+			policy: "",
+			expected: toRoot(
+				&topology.Vertex{ID: "nn-spine", Vertices: map[string]*topology.Vertex{"nn-block": block}},
+				map[string]*topology.Vertex{
+					topology.NoTopology: {ID: topology.NoTopology, Vertices: map[string]*topology.Vertex{"i2": n2}},
+				}),
+		},
+		{
+			name:   "Case 2: no topology",
+			policy: topology.MissingBlockNoTopology,
+			expected: toRoot(
+				&topology.Vertex{ID: "nn-spine", Vertices: map[string]*topology.Vertex{"nn-block": block}},
+				map[string]*topology.Vertex{
+					topology.NoTopology: {ID: topology.NoTopology, Vertices: map[string]*topology.Vertex{"i2": n2}},
+				}),
+		},
+		{
+			name:   "Case 3: parent to spine",
+			policy: topology.MissingBlockParentToSpine,
+			expected: toRoot(
+				&topology.Vertex{ID: "nn-spine", Vertices: map[string]*topology.Vertex{"nn-block": block, "i2": n2}},
+				nil),
+		},
+		{
+			name:   "Case 4: synthetic block",
+			policy: topology.MissingBlockSyntheticBlock,
+			expected: toRoot(
+				&topology.Vertex{ID: "nn-spine", Vertices: map[string]*topology.Vertex{
+					"nn-block":     block,
+					"nn-spine_nil": {ID: "nn-spine_nil", Vertices: map[string]*topology.Vertex{"i2": n2}},
+				}},
+				nil),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tree, err := toGraph(top, cis, nil, tc.policy, "")
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, tree)
+		})
+	}
+}
+
+func TestGroupUnplaced(t *testing.T) {
+	top := []types.InstanceTopology{
+		{
+			InstanceId:   aws.String("i1"),
+			InstanceType: aws.String("p5.48xlarge"),
+			NetworkNodes: []string{"nn-core", "nn-spine", "nn-block"},
+		},
+		{
+			InstanceId:   aws.String("i2"),
+			InstanceType: aws.String("p5.48xlarge"),
+			NetworkNodes: []string{"nn-core"},
+		},
+	}
+	// i3 and i4 are not reported by the topology API
+	cis := []topology.ComputeInstances{{Instances: map[string]string{
+		"i1": "gpu-1", "i2": "gpu-2", "i3": "cpu-1", "i4": "cpu-2",
+	}}}
+
+	n1 := &topology.Vertex{ID: "i1", Name: "gpu-1"}
+	n2 := &topology.Vertex{ID: "i2", Name: "gpu-2"}
+	n3 := &topology.Vertex{ID: "i3", Name: "cpu-1"}
+	n4 := &topology.Vertex{ID: "i4", Name: "cpu-2"}
+
+	toRoot := func(unplaced map[string]*topology.Vertex) *topology.Vertex {
+		forest := map[string]*topology.Vertex{
+			"nn-core": {ID: "nn-core", Vertices: map[string]*topology.Vertex{
+				"nn-spine": {ID: "nn-spine", Vertices: map[string]*topology.Vertex{
+					"nn-block": {ID: "nn-block", Vertices: map[string]*topology.Vertex{"i1": n1}},
+				}},
+			}},
+		}
+		for id, v := range unplaced {
+			forest[id] = v
+		}
+		return &topology.Vertex{
+			Vertices: map[string]*topology.Vertex{topology.TopologyTree: {Vertices: forest}},
+		}
+	}
+
+	testCases := []struct {
+		name     string
+		mode     string
+		expected *topology.Vertex
+	}{
+		{
+			name: "Case 1: default grouping",
+			expected: toRoot(map[string]*topology.Vertex{
+				topology.NoTopology: {ID: topology.NoTopology, Vertices: map[string]*topology.Vertex{"i2": n2, "i3": n3, "i4": n4}},
+			}),
+		},
+		{
+			name: "Case 2: group by instance type",
+			mode: topology.GroupUnplacedInstanceType,
+			expected: toRoot(map[string]*topology.Vertex{
+				"no-topology-p5.48xlarge": {ID: "no-topology-p5.48xlarge", Vertices: map[string]*topology.Vertex{"i2": n2}},
+				topology.NoTopology:       {ID: topology.NoTopology, Vertices: map[string]*topology.Vertex{"i3": n3, "i4": n4}},
+			}),
+		},
+		{
+			name: "Case 3: group by node name prefix",
+			mode: topology.GroupUnplacedPrefix,
+			expected: toRoot(map[string]*topology.Vertex{
+				"no-topology-gpu": {ID: "no-topology-gpu", Vertices: map[string]*topology.Vertex{"i2": n2}},
+				"no-topology-cpu": {ID: "no-topology-cpu", Vertices: map[string]*topology.Vertex{"i3": n3, "i4": n4}},
+			}),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tree, err := toGraph(top, cis, nil, "", tc.mode)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, tree)
+		})
+	}
+}
+
+func TestCapacityBlock(t *testing.T) {
+	instance := func(id string, capacityBlockID *string) types.InstanceTopology {
+		return types.InstanceTopology{
+			InstanceId:      aws.String(id),
+			InstanceType:    aws.String("p5.48xlarge"),
+			NetworkNodes:    []string{"nn-core", "nn-spine", "nn-block"},
+			CapacityBlockId: capacityBlockID,
+		}
+	}
+	cis := []topology.ComputeInstances{{Instances: map[string]string{
+		"i1": "node1", "i2": "node2", "i3": "node3", "i4": "node4",
+	}}}
+
+	testCases := []struct {
+		name        string
+		top         []types.InstanceTopology
+		domainNames map[string]string
+		blocks      *topology.Vertex
+	}{
+		{
+			name: "Case 1: instances with and without capacity block",
+			top: []types.InstanceTopology{
+				instance("i1", aws.String("cb-1")),
+				instance("i2", aws.String("cb-1")),
+				instance("i3", nil),
+				instance("i4", aws.String("")),
+			},
+			blocks: &topology.Vertex{Vertices: map[string]*topology.Vertex{
+				"cb-1": {ID: "block001", Name: "cb-1", Vertices: map[string]*topology.Vertex{
+					"node1": {ID: "node1", Name: "node1"},
+					"node2": {ID: "node2", Name: "node2"},
+				}},
+			}},
+		},
+		{
+			name: "Case 2: capacity block names",
+			top: []types.InstanceTopology{
+				instance("i1", aws.String("cb-1")),
+				instance("i2", aws.String("cb-2")),
+			},
+			domainNames: map[string]string{"cb-1": "rack-a"},
+			blocks: &topology.Vertex{Vertices: map[string]*topology.Vertex{
+				"cb-1": {ID: "block001", Name: "cb-1", Metadata: map[string]string{topology.KeyDisplayName: "rack-a"},
+					Vertices: map[string]*topology.Vertex{"node1": {ID: "node1", Name: "node1"}}},
+				"cb-2": {ID: "block002", Name: "cb-2",
+					Vertices: map[string]*topology.Vertex{"node2": {ID: "node2", Name: "node2"}}},
+			}},
+		},
+		{
+			name: "Case 3: instances without capacity block",
+			top: []types.InstanceTopology{
+				instance("i1", nil),
+				instance("i2", aws.String("")),
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			root, err := toGraph(tc.top, cis, tc.domainNames, "", "")
+			require.NoError(t, err)
+			require.Contains(t, root.Vertices, topology.TopologyTree)
+			require.Equal(t, tc.blocks, root.Vertices[topology.TopologyBlock])
+		})
+	}
+}
@@ -33,6 +33,7 @@ import (
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/klog/v2"
 
+	topoconfig "github.com/NVIDIA/topograph/internal/config"
 	"github.com/NVIDIA/topograph/internal/exec"
 	"github.com/NVIDIA/topograph/pkg/providers"
 	"github.com/NVIDIA/topograph/pkg/topology"
@@ -46,15 +47,32 @@ const (
 	IMDS_URL       = IMDS + "/latest/meta-data"
 
 	tokenTimeDelay = 15 * time.Second
+
+	// healthCheckMaxResults is the smallest page size of DescribeInstanceTopology
+	healthCheckMaxResults = 5
 )
 
 type baseProvider struct {
 	clientFactory ClientFactory
 	imdsClient    IDMSClient
+	params        *Params
+}
+
+type Params struct {
+	// FetchTags enables lookup of the capacity block display names from the resource tags
+	FetchTags bool `mapstructure:"fetch_tags"`
+	// MissingBlockPolicy defines the placement of instances with missing block switch
+	MissingBlockPolicy string `mapstructure:"missing_block_policy"`
+	// GroupUnplacedBy defines the grouping of the instances without topology
+	GroupUnplacedBy string `mapstructure:"group_unplaced_by"`
+
+	providers.PageParams   `mapstructure:",squash"`
+	providers.RegionParams `mapstructure:",squash"`
 }
 
 type EC2Client interface {
 	DescribeInstanceTopology(ctx context.Context, params *ec2.DescribeInstanceTopologyInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstanceTopologyOutput, error)
+	DescribeTags(ctx context.Context, params *ec2.DescribeTagsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeTagsOutput, error)
 }
 
 type IDMSClient interface {
@@ -69,6 +87,9 @@ type ClientFactory func(region string) (*Client, error)
 
 type Client struct {
 	EC2 EC2Client
+	// RefreshCredentials, if set, discards the cached credentials,
+	// so that the clients created afterwards retrieve new ones
+	RefreshCredentials func()
 }
 
 type Credentials struct {
@@ -89,7 +110,12 @@ func Loader(ctx context.Context, cfg providers.Config) (providers.Provider, erro
 
 	imdsClient := imds.NewFromConfig(defaultCfg)
 
-	creds, err := getCredentials(ctx, cfg.Creds)
+	p, err := getParams(cfg.Params)
+	if err != nil {
+		return nil, err
+	}
+
+	credsProvider, refreshable, err := getCredentialsProvider(ctx, cfg.Creds)
 	if err != nil {
 		return nil, err
 	}
@@ -97,26 +123,71 @@ func Loader(ctx context.Context, cfg providers.Config) (providers.Provider, erro
 	clientFactory := func(region string) (*Client, error) {
 		opts := []func(*config.LoadOptions) error{
 			config.WithRegion(region),
-			config.WithCredentialsProvider(
-				credentials.NewStaticCredentialsProvider(creds.AccessKeyId, creds.SecretAccessKey, creds.Token),
-			)}
+			config.WithCredentialsProvider(credsProvider),
+		}
 
 		awsCfg, err := config.LoadDefaultConfig(ctx, opts...)
 		if err != nil {
 			return nil, fmt.Errorf("unable to load SDK config, %v", err)
 		}
 
-		ec2Client := ec2.NewFromConfig(awsCfg)
+		client := &Client{
+			EC2: ec2.NewFromConfig(awsCfg),
+		}
+		if refreshable {
+			client.RefreshCredentials = credsProvider.Invalidate
+		}
+		return client, nil
+	}
+
+	return New(clientFactory, imdsClient, p), nil
+}
 
-		return &Client{
-			EC2: ec2Client,
-		}, nil
+func getParams(params map[string]any) (*Params, error) {
+	var p Params
+	if err := topoconfig.Decode(params, &p); err != nil {
+		return nil, fmt.Errorf("error decoding params: %w", err)
+	}
+	if err := topology.ValidateMissingBlockPolicy(p.MissingBlockPolicy); err != nil {
+		return nil, err
+	}
+	if err := topology.ValidateGroupUnplaced(p.GroupUnplacedBy); err != nil {
+		return nil, err
+	}
+	if err := p.PageParams.Validate(); err != nil {
+		return nil, err
+	}
+	if err := p.RegionParams.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &p, nil
+}
+
+// getCredentialsProvider returns the provider of the payload, shell or node credentials.
+// Only the node credentials are refreshable.
+func getCredentialsProvider(ctx context.Context, creds map[string]string) (*aws.CredentialsCache, bool, error) {
+	if len(creds) != 0 || (len(os.Getenv("AWS_ACCESS_KEY_ID")) != 0 && len(os.Getenv("AWS_SECRET_ACCESS_KEY")) != 0) {
+		c, err := getCredentials(creds)
+		if err != nil {
+			return nil, false, err
+		}
+		return aws.NewCredentialsCache(credentials.NewStaticCredentialsProvider(c.AccessKeyId, c.SecretAccessKey, c.Token)), false, nil
 	}
 
-	return New(clientFactory, imdsClient), nil
+	klog.Infof("Using node AWS access credentials")
+	provider := aws.NewCredentialsCache(ec2rolecreds.New(), func(o *aws.CredentialsCacheOptions) {
+		o.ExpiryWindow = tokenTimeDelay
+	})
+	// fail early if the node credentials are not available
+	if _, err := provider.Retrieve(ctx); err != nil {
+		return nil, false, err
+	}
+	return provider, true, nil
 }
 
-func getCredentials(ctx context.Context, creds map[string]string) (*Credentials, error) {
+// getCredentials returns the payload or shell credentials
+func getCredentials(creds map[string]string) (*Credentials, error) {
 	var accessKeyID, secretAccessKey, sessionToken string
 
 	if len(creds) != 0 {
@@ -128,20 +199,11 @@ func getCredentials(ctx context.Context, creds map[string]string) (*Credentials,
 			return nil, fmt.Errorf("credentials error: missing secret_access_key")
 		}
 		sessionToken = creds["token"]
-	} else if len(os.Getenv("AWS_ACCESS_KEY_ID")) != 0 && len(os.Getenv("AWS_SECRET_ACCESS_KEY")) != 0 {
+	} else {
 		klog.Infof("Using shell AWS credentials")
 		accessKeyID = os.Getenv("AWS_ACCESS_KEY_ID")
 		secretAccessKey = os.Getenv("AWS_SECRET_ACCESS_KEY")
 		sessionToken = os.Getenv("AWS_SESSION_TOKEN")
-	} else {
-		klog.Infof("Using node AWS access credentials")
-		creds, err := getCredentialsFromProvider(ctx)
-		if err != nil {
-			return nil, err
-		}
-		accessKeyID = creds.AccessKeyID
-		secretAccessKey = creds.SecretAccessKey
-		sessionToken = creds.SessionToken
 	}
 
 	return &Credentials{
@@ -151,45 +213,53 @@ func getCredentials(ctx context.Context, creds map[string]string) (*Credentials,
 	}, nil
 }
 
-func getCredentialsFromProvider(ctx context.Context) (creds aws.Credentials, err error) {
-	credsClient := ec2rolecreds.New()
-
-	for {
-		creds, err = credsClient.Retrieve(ctx)
-		if err != nil {
-			return creds, err
-		}
+func (p *baseProvider) GenerateTopologyConfig(ctx context.Context, pageSize *int, instances []topology.ComputeInstances) (*topology.Vertex, error) {
+	topology, domainNames, err := p.generateInstanceTopology(ctx, pageSize, instances)
+	if err != nil {
+		return nil, err
+	}
 
-		if time.Now().Add(tokenTimeDelay).After(creds.Expires) {
-			klog.V(4).Infof("Waiting %s for new token", tokenTimeDelay.String())
-			time.Sleep(tokenTimeDelay)
-			continue
-		}
+	klog.Infof("Extracted topology for %d instances", len(topology))
 
-		return creds, nil
+	var missingBlockPolicy, groupUnplaced string
+	if p.params != nil {
+		missingBlockPolicy = p.params.MissingBlockPolicy
+		groupUnplaced = p.params.GroupUnplacedBy
 	}
+
+	return toGraph(topology, instances, domainNames, missingBlockPolicy, groupUnplaced)
 }
 
-func (p *baseProvider) GenerateTopologyConfig(ctx context.Context, pageSize *int, instances []topology.ComputeInstances) (*topology.Vertex, error) {
-	topology, err := p.generateInstanceTopology(ctx, pageSize, instances)
+// healthCheck lists the topology of a few instances in the region
+func (p *baseProvider) healthCheck(ctx context.Context, region string) error {
+	client, err := p.clientFactory(region)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	klog.Infof("Extracted topology for %d instances", len(topology))
-
-	return toGraph(topology, instances)
+	_, err = client.EC2.DescribeInstanceTopology(ctx, &ec2.DescribeInstanceTopologyInput{MaxResults: aws.Int32(healthCheckMaxResults)})
+	return err
 }
 
 type Provider struct {
 	baseProvider
 }
 
-func New(clientFactory ClientFactory, imdsClient IDMSClient) *Provider {
+// HealthCheck implements providers.HealthChecker
+func (p *Provider) HealthCheck(ctx context.Context) error {
+	output, err := p.imdsClient.GetRegion(ctx, &imds.GetRegionInput{})
+	if err != nil {
+		return fmt.Errorf("unable to get region: %w", err)
+	}
+	return p.healthCheck(ctx, output.Region)
+}
+
+func New(clientFactory ClientFactory, imdsClient IDMSClient, params *Params) *Provider {
 	return &Provider{
 		baseProvider: baseProvider{
 			clientFactory: clientFactory,
 			imdsClient:    imdsClient,
+			params:        params,
 		},
 	}
 }
@@ -233,6 +303,13 @@ func (p *Provider) GetComputeInstancesRegion() (string, error) {
 	return output.Region, nil
 }
 
+// GetInstancesRegions implements slurm.regionMapper
+func (p *Provider) GetInstancesRegions(ctx context.Context, nodes []string) (map[string]string, error) {
+	cmd := fmt.Sprintf("TOKEN=$(curl -s -X PUT -H \"X-aws-ec2-metadata-token-ttl-seconds: 21600\" %s); echo $(curl -s -H \"X-aws-ec2-metadata-token: $TOKEN\" %s/placement/region)", IMDS_TOKEN_URL, IMDS_URL)
+
+	return exec.Pdsh(ctx, nodes, cmd)
+}
+
 // GetNodeRegion implements k8s.k8sNodeInfo
 func (p *Provider) GetNodeRegion(node *v1.Node) (string, error) {
 	return node.Labels["topology.kubernetes.io/region"], nil
@@ -19,8 +19,12 @@ package aws
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strconv"
+	"sync"
+	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/feature/ec2/imds"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
@@ -44,12 +48,46 @@ type SimClient struct {
 	Model      *models.Model
 	Outputs    map[string]([]types.InstanceTopology)
 	NextTokens map[string]string
+	// PageSize, if set, overrides the number of instances per page
+	PageSize int
+	// ExpireAfterPages, if set, makes the credentials expire after the given number of pages,
+	// until RefreshCredentials is called
+	ExpireAfterPages int
+	// PageDelay, if set, simulates the latency of each page request
+	PageDelay time.Duration
+
+	pages     int // number of pages returned
+	sweeps    int // number of requests without a token
+	refreshed bool
+}
+
+// ExpiredTokenError is the simulated error of a request with expired credentials
+type ExpiredTokenError struct{}
+
+func (e *ExpiredTokenError) Error() string {
+	return "ExpiredTokenException: the security token included in the request is expired"
+}
+
+func (e *ExpiredTokenError) ErrorCode() string {
+	return "ExpiredTokenException"
+}
+
+// RefreshCredentials simulates the credential refresh
+func (client *SimClient) RefreshCredentials() {
+	client.refreshed = true
 }
 
 func (client *SimClient) DescribeInstanceTopology(ctx context.Context, params *ec2.DescribeInstanceTopologyInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstanceTopologyOutput, error) {
+	if client.ExpireAfterPages > 0 && client.pages == client.ExpireAfterPages && !client.refreshed {
+		return nil, &ExpiredTokenError{}
+	}
+	client.pages++
+	time.Sleep(client.PageDelay)
+
 	// If we need to calculate new results (a previous token was not given)
 	givenToken := params.NextToken
 	if givenToken == nil {
+		client.sweeps++
 		// Refreshes the clients internal storage for outputs
 		client.Outputs = make(map[string][]types.InstanceTopology)
 		client.NextTokens = make(map[string]string)
@@ -59,17 +97,30 @@ func (client *SimClient) DescribeInstanceTopology(ctx context.Context, params *e
 		if params.MaxResults != nil {
 			maxResults = int(*params.MaxResults)
 		}
+		if client.PageSize > 0 {
+			maxResults = client.PageSize
+		}
+
+		// Creates the list of instances whose topology is requested;
+		// without instance IDs, the topology of all the instances is listed
+		instanceIds := params.InstanceIds
+		if len(instanceIds) == 0 {
+			instanceIds = make([]string, 0, len(client.Model.Nodes))
+			for instanceId := range client.Model.Nodes {
+				instanceIds = append(instanceIds, instanceId)
+			}
+			sort.Strings(instanceIds)
+		}
 
-		// Creates the list of instances whose topology is requested
 		var firstToken string
 		var instanceIdx int = 0
-		for instanceIdx < len(params.InstanceIds) {
+		for instanceIdx < len(instanceIds) {
 			// Only collect a list up to params.MaxResults
 			var instances []types.InstanceTopology
 			var i int
-			for i = 0; i < maxResults && i+instanceIdx < len(params.InstanceIds); i++ {
+			for i = 0; i < maxResults && i+instanceIdx < len(instanceIds); i++ {
 				// Gets the instance ID
-				instanceId := params.InstanceIds[instanceIdx+i]
+				instanceId := instanceIds[instanceIdx+i]
 
 				// Gets the availability zone and placement group of the instance
 				node := client.Model.Nodes[instanceId]
@@ -108,7 +159,7 @@ func (client *SimClient) DescribeInstanceTopology(ctx context.Context, params *e
 			}
 			client.Outputs[token] = instances
 			instanceIdx += i
-			if instanceIdx < len(params.InstanceIds) {
+			if instanceIdx < len(instanceIds) {
 				var nextToken string = strconv.Itoa(instanceIdx)
 				client.NextTokens[token] = nextToken
 			}
@@ -129,6 +180,33 @@ func (client *SimClient) DescribeInstanceTopology(ctx context.Context, params *e
 	return &output, nil
 }
 
+func (client *SimClient) DescribeTags(ctx context.Context, params *ec2.DescribeTagsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeTagsOutput, error) {
+	resources := make(map[string]bool)
+	for _, filter := range params.Filters {
+		if filter.Name != nil && *filter.Name == "resource-id" {
+			for _, id := range filter.Values {
+				resources[id] = true
+			}
+		}
+	}
+
+	// the display name of the NVLink domain is reported as the "Name" tag of the capacity block
+	output := &ec2.DescribeTagsOutput{}
+	for _, cb := range client.Model.CapacityBlocks {
+		if len(cb.NVLinkName) == 0 || !resources[cb.NVLink] {
+			continue
+		}
+		delete(resources, cb.NVLink)
+		output.Tags = append(output.Tags, types.TagDescription{
+			Key:        aws.String(DomainNameTag),
+			ResourceId: aws.String(cb.NVLink),
+			Value:      aws.String(cb.NVLinkName),
+		})
+	}
+
+	return output, nil
+}
+
 func NamedLoaderSim() (string, providers.Loader) {
 	return NAME_SIM, LoaderSim
 }
@@ -146,32 +224,48 @@ func LoaderSim(ctx context.Context, cfg providers.Config) (providers.Provider, e
 		return nil, err
 	}
 
-	csp_model, err := models.NewModelFromFile(p.ModelPath)
+	params, err := getParams(cfg.Params)
 	if err != nil {
-		return nil, fmt.Errorf("unable to load model file for AWS simulation, %v", err)
+		return nil, err
 	}
-	simClient := &SimClient{Model: csp_model}
 
-	client := &Client{
-		EC2: simClient,
+	csp_model, err := models.NewModelFromFile(p.ModelPath, cfg.Models)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load model file for AWS simulation, %w", err)
 	}
-
+	// each region has its own simulated client, since the regions are requested concurrently
+	var mutex sync.Mutex
+	clients := make(map[string]*Client)
 	clientFactory := func(region string) (*Client, error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		client, ok := clients[region]
+		if !ok {
+			client = &Client{EC2: &SimClient{Model: csp_model}}
+			clients[region] = client
+		}
 		return client, nil
 	}
 
-	return NewSim(clientFactory, imdsClient), nil
+	return NewSim(clientFactory, imdsClient, params), nil
 }
 
 type SimProvider struct {
 	baseProvider
 }
 
-func NewSim(clientFactory ClientFactory, imdsClient IDMSClient) *SimProvider {
+// HealthCheck implements providers.HealthChecker
+func (p *SimProvider) HealthCheck(ctx context.Context) error {
+	return p.healthCheck(ctx, "")
+}
+
+func NewSim(clientFactory ClientFactory, imdsClient IDMSClient, params *Params) *SimProvider {
 	return &SimProvider{
 		baseProvider: baseProvider{
 			clientFactory: clientFactory,
 			imdsClient:    imdsClient,
+			params:        params,
 		},
 	}
 }
@@ -0,0 +1,257 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package aws
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/NVIDIA/topograph/pkg/models"
+	"github.com/NVIDIA/topograph/pkg/providers"
+	"github.com/NVIDIA/topograph/pkg/topology"
+)
+
+func TestSimDomainNames(t *testing.T) {
+	model, err := models.NewModelFromFile("../../../tests/models/medium.yaml", nil)
+	require.NoError(t, err)
+
+	client := &Client{EC2: &SimClient{Model: model}}
+	clientFactory := func(region string) (*Client, error) {
+		return client, nil
+	}
+
+	testCases := []struct {
+		name     string
+		params   *Params
+		expected map[string]map[string]string
+	}{
+		{
+			name:   "Case 1: tags not fetched",
+			params: &Params{},
+			expected: map[string]map[string]string{
+				"nvl1": {topology.KeyExpectedBlockSize: "18"},
+				"nvl2": {topology.KeyExpectedBlockSize: "18"},
+				"nvl3": {topology.KeyExpectedBlockSize: "18"},
+				"nvl4": {topology.KeyExpectedBlockSize: "18"},
+			},
+		},
+		{
+			name:   "Case 2: model-provided names with fallback",
+			params: &Params{FetchTags: true},
+			expected: map[string]map[string]string{
+				"nvl1": {topology.KeyDisplayName: "rack-1", topology.KeyExpectedBlockSize: "18"},
+				"nvl2": {topology.KeyDisplayName: "rack-2", topology.KeyExpectedBlockSize: "18"},
+				"nvl3": {topology.KeyExpectedBlockSize: "18"},
+				"nvl4": {topology.KeyExpectedBlockSize: "18"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := NewSim(clientFactory, nil, tc.params)
+			root, err := p.GenerateTopologyConfig(context.TODO(), nil, model.Instances)
+			require.NoError(t, err)
+
+			blockRoot, ok := root.Vertices[topology.TopologyBlock]
+			require.True(t, ok)
+			require.Len(t, blockRoot.Vertices, len(tc.expected))
+			for domain, metadata := range tc.expected {
+				block, ok := blockRoot.Vertices[domain]
+				require.True(t, ok)
+				require.Equal(t, metadata, block.Metadata)
+			}
+		})
+	}
+}
+
+func TestSimRegionConcurrency(t *testing.T) {
+	model, err := models.NewModelFromFile("../../../tests/models/medium.yaml", nil)
+	require.NoError(t, err)
+
+	// spread the instances over four regions
+	instanceIDs := []string{}
+	for _, ci := range model.Instances {
+		for instanceID := range ci.Instances {
+			instanceIDs = append(instanceIDs, instanceID)
+		}
+	}
+	sort.Strings(instanceIDs)
+	cis := make([]topology.ComputeInstances, 4)
+	for i, instanceID := range instanceIDs {
+		ci := &cis[i%len(cis)]
+		if ci.Instances == nil {
+			ci.Region = fmt.Sprintf("region-%d", i%len(cis))
+			ci.Instances = make(map[string]string)
+		}
+		ci.Instances[instanceID] = instanceID
+	}
+
+	// each region has its own simulated client
+	clientFactory := func(region string) (*Client, error) {
+		return &Client{EC2: &SimClient{Model: model, PageDelay: time.Millisecond}}, nil
+	}
+
+	generate := func(concurrency int) *topology.Vertex {
+		params := &Params{FetchTags: true, RegionParams: providers.RegionParams{RegionConcurrency: concurrency}}
+		root, err := NewSim(clientFactory, nil, params).GenerateTopologyConfig(context.TODO(), nil, cis)
+		require.NoError(t, err)
+		return root
+	}
+
+	sequential := generate(1)
+	for _, concurrency := range []int{0, 2, 4} {
+		require.Equal(t, sequential, generate(concurrency), "region concurrency %d", concurrency)
+	}
+}
+
+func TestSimCredentialsExpiry(t *testing.T) {
+	model, err := models.NewModelFromFile("../../../tests/models/medium.yaml", nil)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name        string
+		refreshable bool
+		err         string
+	}{
+		{
+			name:        "Case 1: refreshed credentials resume the sweep",
+			refreshable: true,
+		},
+		{
+			name: "Case 2: static credentials fail",
+			err:  "failed to describe instance topology: AWS credentials expired: ExpiredTokenException: the security token included in the request is expired",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sim := &SimClient{Model: model, PageSize: 3, ExpireAfterPages: 2}
+			clients := 0
+			clientFactory := func(region string) (*Client, error) {
+				clients++
+				client := &Client{EC2: sim}
+				if tc.refreshable {
+					client.RefreshCredentials = sim.RefreshCredentials
+				}
+				return client, nil
+			}
+
+			p := NewSim(clientFactory, nil, &Params{})
+			ci := model.Instances[0]
+			top, err := p.generateInstanceTopologyForRegionInstances(context.TODO(), 0, &ci, nil)
+			if len(tc.err) != 0 {
+				require.EqualError(t, err, tc.err)
+				require.ErrorIs(t, err, ErrCredentialsExpired)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, top, len(ci.Instances))
+			// the client was rebuilt once, and the sweep was not restarted
+			require.Equal(t, 2, clients)
+			require.Equal(t, 1, sim.sweeps)
+			require.Equal(t, 3, sim.pages)
+		})
+	}
+}
+
+func TestSimPaginationTimeout(t *testing.T) {
+	model, err := models.NewModelFromFile("../../../tests/models/medium.yaml", nil)
+	require.NoError(t, err)
+
+	sim := &SimClient{Model: model, PageSize: 1, PageDelay: 20 * time.Millisecond}
+	clientFactory := func(region string) (*Client, error) {
+		return &Client{EC2: sim}, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	p := NewSim(clientFactory, nil, &Params{})
+	ci := model.Instances[0]
+	start := time.Now()
+	_, err = p.generateInstanceTopologyForRegionInstances(ctx, 0, &ci, nil)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	// the sweep stops at the first page boundary after the deadline
+	require.Less(t, time.Since(start), time.Second)
+	require.Less(t, sim.pages, len(ci.Instances))
+}
+
+func TestSimPageSize(t *testing.T) {
+	model, err := models.NewModelFromFile("../../../tests/models/medium.yaml", nil)
+	require.NoError(t, err)
+
+	// page sizes apply to the requests without instance IDs, i.e., with more than 100 instances
+	ci := topology.ComputeInstances{Region: model.Instances[0].Region, Instances: make(map[string]string)}
+	for instanceID, node := range model.Instances[0].Instances {
+		ci.Instances[instanceID] = node
+	}
+	for i := len(ci.Instances); i <= 100; i++ {
+		ci.Instances[fmt.Sprintf("absent-%d", i)] = fmt.Sprintf("absent-%d", i)
+	}
+	nodes := len(model.Nodes)
+	intPtr := func(n int) *int { return &n }
+
+	testCases := []struct {
+		name       string
+		paramSize  int
+		serverSize *int
+		pageSize   int
+	}{
+		{
+			name:     "Case 1: provider default",
+			pageSize: int(defaultPageSize),
+		},
+		{
+			name:       "Case 2: server page size",
+			serverSize: intPtr(3),
+			pageSize:   3,
+		},
+		{
+			name:       "Case 3: request parameter over server page size",
+			paramSize:  2,
+			serverSize: intPtr(3),
+			pageSize:   2,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sim := &SimClient{Model: model}
+			clientFactory := func(region string) (*Client, error) {
+				return &Client{EC2: sim}, nil
+			}
+
+			params := &Params{}
+			params.PageSize = tc.paramSize
+			p := NewSim(clientFactory, nil, params)
+			top, _, err := p.generateInstanceTopology(context.TODO(), tc.serverSize, []topology.ComputeInstances{ci})
+			require.NoError(t, err)
+			require.Len(t, top, len(model.Instances[0].Instances))
+
+			require.Equal(t, (nodes+tc.pageSize-1)/tc.pageSize, sim.pages)
+			for _, page := range sim.Outputs {
+				require.LessOrEqual(t, len(page), tc.pageSize)
+			}
+		})
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/NVIDIA/topograph/internal/cluset"
 	"github.com/NVIDIA/topograph/internal/exec"
 	"github.com/NVIDIA/topograph/pkg/ib"
 	"github.com/NVIDIA/topograph/pkg/topology"
@@ -52,9 +53,9 @@ func populatePartitions(stdout *bytes.Buffer) (map[string][]string, error) {
 		}
 		partitionName := strings.TrimSpace(arr[0])
 		nodeList := strings.TrimSpace(arr[5])
-		nodesArr, err := deCompressNodeNames(nodeList)
+		nodesArr, err := cluset.Expand(nodeList)
 		if err != nil {
-			return nil, fmt.Errorf("deCompressNodeNames failed : %v", err)
+			return nil, fmt.Errorf("failed to expand node list: %v", err)
 		}
 		// map of slurm partition name  -> node names
 		partitionNodeMap[partitionName] = append(partitionNodeMap[partitionName], nodesArr...)
@@ -97,7 +98,7 @@ func getIbTree(ctx context.Context, _ []string) (*topology.Vertex, error) {
 							nodeVisited[nodeName] = true
 						}
 						partitionVisitedMap[pName] = true
-						ibRoot, err := ib.GenerateTopologyConfig(stdout.Bytes())
+						ibRoot, err := ib.GenerateTopologyConfig(stdout.Bytes(), false, false)
 						if err != nil {
 							return nil, fmt.Errorf("IB GenerateTopologyConfig failed: %v", err)
 						}
@@ -117,95 +118,6 @@ func getIbTree(ctx context.Context, _ []string) (*topology.Vertex, error) {
 	return treeRoot, nil
 }
 
-// deCompressNodeNames returns array of node names
-func deCompressNodeNames(nodeList string) ([]string, error) {
-	nodeArr := []string{}
-	// split entries by comma
-	// example : nodename-1-[001-004,007,91-99,100],nodename-2-89
-	arr := strings.Split(nodeList, ",")
-	prefix := ""
-	var nodeName string
-	resetPrefix := false
-
-	// example : nodename-1-[001-004 , 007, 91-99 , 100], nodename-2-89
-	for _, entry := range arr {
-		// example : nodename-1-[001-004
-		if strings.Contains(entry, "[") {
-			// example : nodename-1-[001-004]
-			entryWithoutSuffix := strings.TrimSuffix(entry, "]")
-			tuple := strings.Split(entryWithoutSuffix, "[")
-			prefix = tuple[0]
-			resetPrefix = false
-			// example : nodename-1-[001-004
-			if strings.Contains(tuple[1], "-") {
-				nr := strings.Split(tuple[1], "-")
-				w := len(nr[0])
-				start, err := strconv.Atoi(nr[0])
-				if err != nil {
-					return nil, fmt.Errorf("Atoi err for range start: %v", err)
-				}
-				end, err := strconv.Atoi(nr[1])
-				if err != nil {
-					return nil, fmt.Errorf("Atoi err for range end: %v", err)
-				}
-				for i := start; i <= end; i++ {
-					suffixNum := fmt.Sprintf(fmt.Sprintf("%%0%dd", w), i)
-					nodeName = prefix + suffixNum
-					nodeArr = append(nodeArr, nodeName)
-				}
-				// avoid another nodename append at the end
-				continue
-			} else {
-				// example : nodename-1-[001
-				nv := tuple[1]
-				nodeName = prefix + nv
-			}
-		} else { // no [ means, this could be whole nodename or suffix
-			// example: 100], nodename-2-89, 90
-			if len(prefix) > 0 { //prefix exists, so must be a suffix.
-				if strings.HasSuffix(entry, "]") { //if suffix has ], reset prefix
-					entry = strings.TrimSuffix(entry, "]")
-					resetPrefix = true
-				}
-				if strings.Contains(entry, "-") { // suffix containing range of nodes
-					// example: 100-102]
-					nr := strings.Split(entry, "-")
-					w := len(nr[0])
-					start, err := strconv.Atoi(nr[0])
-					if err != nil {
-						return nil, fmt.Errorf("Atoi err for range start when prefix is set: %v", err)
-					}
-					end, err := strconv.Atoi(nr[1])
-					if err != nil {
-						return nil, fmt.Errorf("Atoi err for range end when prefix is set: %v", err)
-					}
-					for i := start; i <= end; i++ {
-						suffixNum := fmt.Sprintf(fmt.Sprintf("%%0%dd", w), i)
-						nodeName = prefix + suffixNum
-						nodeArr = append(nodeArr, nodeName)
-					}
-					if resetPrefix {
-						prefix = ""
-					}
-					// avoid another nodename append at the end
-					continue
-				} else {
-					//example: 90
-					nodeName = prefix + entry
-					if resetPrefix {
-						prefix = ""
-					}
-				}
-			} else { // no prefix yet, must be whole nodename
-				//example: nodename-2-89
-				nodeName = entry
-			}
-		}
-		nodeArr = append(nodeArr, nodeName)
-	}
-	return nodeArr, nil
-}
-
 func populateDomains(stdout *bytes.Buffer) (map[string]domain, error) {
 	domainMap := make(map[string]domain) // domainID: domain
 	scanner := bufio.NewScanner(stdout)
@@ -0,0 +1,34 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package composite
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var mergeConflicts = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name:      "merge_conflicts_total",
+		Help:      "Nodes placed under a different leaf switch by a lower-priority provider",
+		Subsystem: "topograph_composite",
+	},
+	[]string{"provider"},
+)
+
+func init() {
+	prometheus.MustRegister(mergeConflicts)
+}
@@ -0,0 +1,165 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package composite
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/klog/v2"
+
+	"github.com/NVIDIA/topograph/internal/config"
+	"github.com/NVIDIA/topograph/pkg/providers"
+	"github.com/NVIDIA/topograph/pkg/topology"
+)
+
+const NAME = "composite"
+
+// Provider merges the topologies of several providers, given in priority order
+type Provider struct {
+	names   []string
+	sources []providers.Provider
+}
+
+type Params struct {
+	// Providers lists the topology sources in priority order
+	Providers []Source `mapstructure:"providers"`
+}
+
+// Source is a provider whose topology is merged
+type Source struct {
+	Name   string         `mapstructure:"name"`
+	Params map[string]any `mapstructure:"params"`
+}
+
+// Lookup returns the loader of the named provider
+type Lookup func(name string) (providers.Loader, error)
+
+// NamedLoader returns the loader of the composite provider, which loads its sources with lookup
+func NamedLoader(lookup Lookup) providers.NamedLoader {
+	return func() (string, providers.Loader) {
+		return NAME, func(ctx context.Context, cfg providers.Config) (providers.Provider, error) {
+			return Loader(ctx, cfg, lookup)
+		}
+	}
+}
+
+// Loader loads the sources of the composite provider. Each source gets the credentials
+// and the model limits of the composite provider.
+func Loader(ctx context.Context, cfg providers.Config, lookup Lookup) (*Provider, error) {
+	p, err := getParams(cfg.Params)
+	if err != nil {
+		return nil, err
+	}
+
+	prv := &Provider{}
+	for _, src := range p.Providers {
+		loader, err := lookup(src.Name)
+		if err != nil {
+			return nil, err
+		}
+		source, err := loader(ctx, providers.Config{
+			Creds:  cfg.Creds,
+			Params: src.Params,
+			Models: cfg.Models,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to load provider %q: %w", src.Name, err)
+		}
+		prv.names = append(prv.names, src.Name)
+		prv.sources = append(prv.sources, source)
+	}
+
+	return prv, nil
+}
+
+func getParams(params map[string]any) (*Params, error) {
+	var p Params
+	if err := config.Decode(params, &p); err != nil {
+		return nil, fmt.Errorf("error decoding params: %w", err)
+	}
+
+	if len(p.Providers) < 2 {
+		return nil, fmt.Errorf("at least two providers must be set")
+	}
+	for _, src := range p.Providers {
+		switch src.Name {
+		case "":
+			return nil, fmt.Errorf("missing provider name")
+		case NAME:
+			return nil, fmt.Errorf("%s provider cannot be nested", NAME)
+		}
+	}
+
+	return &p, nil
+}
+
+func (p *Provider) GenerateTopologyConfig(ctx context.Context, pageSize *int, instances []topology.ComputeInstances) (*topology.Vertex, error) {
+	roots := make([]*topology.Vertex, 0, len(p.sources))
+	for i, source := range p.sources {
+		root, err := source.GenerateTopologyConfig(ctx, pageSize, instances)
+		if err != nil {
+			return nil, fmt.Errorf("provider %q failed: %w", p.names[i], err)
+		}
+		roots = append(roots, root)
+	}
+
+	root, conflicts := topology.Merge(roots...)
+	for _, c := range conflicts {
+		klog.Warningf("Conflicting placement of node %s: keeping switch %s over switch %s of provider %q",
+			c.Node, c.Kept, c.Dropped, p.names[c.Source])
+		mergeConflicts.WithLabelValues(p.names[c.Source]).Inc()
+	}
+	klog.Infof("Merged topologies of providers %v with %d conflicts", p.names, len(conflicts))
+
+	return root, nil
+}
+
+// Engine support
+
+// instanceMapper is implemented by the providers supporting the slurm engine
+type instanceMapper interface {
+	Instances2NodeMap(ctx context.Context, nodes []string) (map[string]string, error)
+	GetComputeInstancesRegion() (string, error)
+}
+
+// Instances2NodeMap implements slurm.instanceMapper with the first source supporting it
+func (p *Provider) Instances2NodeMap(ctx context.Context, nodes []string) (map[string]string, error) {
+	mapper, err := p.instanceMapper()
+	if err != nil {
+		return nil, err
+	}
+	return mapper.Instances2NodeMap(ctx, nodes)
+}
+
+// GetComputeInstancesRegion implements slurm.instanceMapper with the first source supporting it
+func (p *Provider) GetComputeInstancesRegion() (string, error) {
+	mapper, err := p.instanceMapper()
+	if err != nil {
+		return "", err
+	}
+	return mapper.GetComputeInstancesRegion()
+}
+
+func (p *Provider) instanceMapper() (instanceMapper, error) {
+	for _, source := range p.sources {
+		if mapper, ok := source.(instanceMapper); ok {
+			return mapper, nil
+		}
+	}
+	return nil, fmt.Errorf("none of the providers %v maps instances to nodes", p.names)
+}
@@ -0,0 +1,174 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package composite
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/NVIDIA/topograph/internal/fixtures"
+	"github.com/NVIDIA/topograph/pkg/providers"
+	"github.com/NVIDIA/topograph/pkg/providers/fake"
+	"github.com/NVIDIA/topograph/pkg/topology"
+)
+
+// leafTopology returns a tree topology with leaf switches only
+func leafTopology(leaves map[string][]*topology.Vertex) *topology.Vertex {
+	treeRoot := &topology.Vertex{Vertices: map[string]*topology.Vertex{}}
+	for id, nodes := range leaves {
+		leaf := &topology.Vertex{ID: id, Vertices: map[string]*topology.Vertex{}}
+		for _, node := range nodes {
+			leaf.Vertices[node.ID] = node
+		}
+		treeRoot.Vertices[id] = leaf
+	}
+	return &topology.Vertex{Vertices: map[string]*topology.Vertex{topology.TopologyTree: treeRoot}}
+}
+
+func node(id, name string) *topology.Vertex {
+	return &topology.Vertex{ID: id, Name: name}
+}
+
+func load(t *testing.T, sources map[string]*fake.Provider, names ...string) (*Provider, error) {
+	t.Helper()
+	registry := providers.NewRegistry()
+	for name, prv := range sources {
+		registry.Register(prv.NamedLoader(name))
+	}
+
+	params := map[string]any{}
+	var list []any
+	for _, name := range names {
+		list = append(list, map[string]any{"name": name})
+	}
+	params["providers"] = list
+
+	return Loader(context.TODO(), providers.Config{Params: params}, registry.Get)
+}
+
+func TestParams(t *testing.T) {
+	sources := map[string]*fake.Provider{"ib": fake.NewTree(), "csp": fake.NewBlock()}
+
+	testCases := []struct {
+		name  string
+		names []string
+		err   string
+	}{
+		{
+			name:  "Case 1: valid sources",
+			names: []string{"ib", "csp"},
+		},
+		{
+			name:  "Case 2: single source",
+			names: []string{"ib"},
+			err:   "at least two providers must be set",
+		},
+		{
+			name:  "Case 3: missing name",
+			names: []string{"ib", ""},
+			err:   "missing provider name",
+		},
+		{
+			name:  "Case 4: nested composite provider",
+			names: []string{"ib", NAME},
+			err:   "composite provider cannot be nested",
+		},
+		{
+			name:  "Case 5: unknown provider",
+			names: []string{"ib", "unknown"},
+			err:   `unsupported provider "unknown", unsupported provider`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			prv, err := load(t, sources, tc.names...)
+			if len(tc.err) != 0 {
+				require.EqualError(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.names, prv.names)
+		})
+	}
+}
+
+func TestGenerateTopologyConfig(t *testing.T) {
+	// the leaf switches of the primary source, and the upper tiers and blocks of the secondary source
+	primary := leafTopology(map[string][]*topology.Vertex{
+		"L1": {node("I14", "Node104"), node("I15", "Node105"), node("I16", "Node106")},
+		"L2": {node("I21", "Node201"), node("I22", "Node202"), node("I25", "Node205")},
+	})
+	secondary, _ := fixtures.BlockWithMultiIBTestSet()
+
+	prv, err := load(t, map[string]*fake.Provider{
+		"ib":  fake.New(fake.Result{Root: primary}),
+		"csp": fake.New(fake.Result{Root: secondary}),
+	}, "ib", "csp")
+	require.NoError(t, err)
+
+	root, err := prv.GenerateTopologyConfig(context.TODO(), nil, nil)
+	require.NoError(t, err)
+
+	treeRoot := root.Vertices[topology.TopologyTree]
+	require.Len(t, treeRoot.Vertices, 2)
+	sw1 := treeRoot.Vertices["ibRoot2"].Vertices["S1"]
+	require.Len(t, sw1.Vertices, 2)
+	require.Contains(t, sw1.Vertices["L1"].Vertices, "I15")
+	require.Contains(t, sw1.Vertices["L2"].Vertices, "I25")
+	require.Contains(t, treeRoot.Vertices["ibRoot1"].Vertices["S4"].Vertices["S5"].Vertices, "I31")
+	require.Equal(t, &topology.Stats{Nodes: 12, Switches: []int{4, 2, 2}, Depth: 3, Blocks: 4}, root.Stats())
+}
+
+func TestMergeConflicts(t *testing.T) {
+	// the secondary source places Node205 under S2 instead of S3
+	primary, _ := fixtures.BlockWithMultiIBTestSet()
+	secondary := leafTopology(map[string][]*topology.Vertex{
+		"S2": {node("I14", "Node104"), node("I25", "Node205")},
+	})
+
+	prv, err := load(t, map[string]*fake.Provider{
+		"ib":  fake.New(fake.Result{Root: primary}),
+		"csp": fake.New(fake.Result{Root: secondary}),
+	}, "ib", "csp")
+	require.NoError(t, err)
+
+	before := testutil.ToFloat64(mergeConflicts.WithLabelValues("csp"))
+	root, err := prv.GenerateTopologyConfig(context.TODO(), nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, before+1, testutil.ToFloat64(mergeConflicts.WithLabelValues("csp")))
+
+	// the higher-priority placement wins
+	sw1 := root.Vertices[topology.TopologyTree].Vertices["ibRoot2"].Vertices["S1"]
+	require.Contains(t, sw1.Vertices["S3"].Vertices, "I25")
+	require.NotContains(t, sw1.Vertices["S2"].Vertices, "I25")
+}
+
+func TestSourceError(t *testing.T) {
+	prv, err := load(t, map[string]*fake.Provider{
+		"ib":  fake.NewTree(),
+		"csp": fake.New(fake.Result{Err: errors.New("API failure")}),
+	}, "ib", "csp")
+	require.NoError(t, err)
+
+	_, err = prv.GenerateTopologyConfig(context.TODO(), nil, nil)
+	require.EqualError(t, err, `provider "csp" failed: API failure`)
+}
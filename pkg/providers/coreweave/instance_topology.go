@@ -0,0 +1,142 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package coreweave
+
+import (
+	"context"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/NVIDIA/topograph/pkg/metrics"
+	"github.com/NVIDIA/topograph/pkg/topology"
+)
+
+// InstanceTopology is the placement of an instance, read from the labels of its node
+type InstanceTopology struct {
+	Instance   string
+	Leaf       string
+	Spine      string
+	Datacenter string
+}
+
+// generateInstanceTopology reads the switches of the cluster nodes from their labels
+func (p *Provider) generateInstanceTopology(ctx context.Context) (map[string]*InstanceTopology, error) {
+	nodeList, err := p.client.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("unable to list nodes in the cluster: %v", err)
+	}
+
+	topo := make(map[string]*InstanceTopology)
+	for _, node := range nodeList.Items {
+		instance, _ := p.GetNodeInstance(&node)
+		if len(instance) == 0 {
+			instance = node.Name
+		}
+		leaf := node.Labels[p.params.LeafLabel]
+		if len(leaf) == 0 {
+			klog.V(4).Infof("Missing label %s on node %s", p.params.LeafLabel, node.Name)
+			continue
+		}
+		topo[instance] = &InstanceTopology{
+			Instance:   instance,
+			Leaf:       leaf,
+			Spine:      node.Labels[p.params.SpineLabel],
+			Datacenter: node.Labels[p.params.DatacenterLabel],
+		}
+	}
+
+	return topo, nil
+}
+
+// toGraph builds the three-tier topology graph of the requested instances.
+// Missing upper tiers are skipped; instances without the leaf label are placed under NoTopology.
+func toGraph(topo map[string]*InstanceTopology, cis []topology.ComputeInstances) *topology.Vertex {
+	i2n := make(map[string]string)
+	for _, ci := range cis {
+		for instance, node := range ci.Instances {
+			i2n[instance] = node
+		}
+	}
+	klog.V(4).Infof("Instance/Node map %v", i2n)
+
+	forest := make(map[string]*topology.Vertex)
+	nodes := make(map[string]*topology.Vertex)
+
+	for instance, nodeName := range i2n {
+		inst, ok := topo[instance]
+		if !ok {
+			continue
+		}
+		delete(i2n, instance)
+
+		// switch IDs starting from the lowest tier
+		switchIDs := []string{inst.Leaf}
+		for _, id := range []string{inst.Spine, inst.Datacenter} {
+			if len(id) != 0 {
+				switchIDs = append(switchIDs, id)
+			}
+		}
+
+		child := &topology.Vertex{
+			Name: nodeName,
+			ID:   instance,
+		}
+		for i, id := range switchIDs {
+			sw, ok := nodes[id]
+			if !ok {
+				sw = &topology.Vertex{
+					ID:       id,
+					Vertices: make(map[string]*topology.Vertex),
+				}
+				nodes[id] = sw
+				if i == len(switchIDs)-1 {
+					forest[id] = sw
+				}
+			}
+			sw.Vertices[child.ID] = child
+			child = sw
+		}
+	}
+
+	metrics.SetMissingTopology(NAME, len(i2n))
+	if len(i2n) != 0 {
+		klog.V(4).Infof("Adding nodes w/o topology: %v", i2n)
+		sw := &topology.Vertex{
+			ID:       topology.NoTopology,
+			Vertices: make(map[string]*topology.Vertex),
+		}
+		for instanceID, nodeName := range i2n {
+			sw.Vertices[instanceID] = &topology.Vertex{
+				Name: nodeName,
+				ID:   instanceID,
+			}
+		}
+		forest[topology.NoTopology] = sw
+	}
+
+	treeRoot := &topology.Vertex{
+		Vertices: make(map[string]*topology.Vertex),
+	}
+	for name, node := range forest {
+		treeRoot.Vertices[name] = node
+	}
+
+	return &topology.Vertex{
+		Vertices: map[string]*topology.Vertex{topology.TopologyTree: treeRoot},
+	}
+}
@@ -0,0 +1,124 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package coreweave
+
+import (
+	"context"
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+
+	"github.com/NVIDIA/topograph/internal/config"
+	"github.com/NVIDIA/topograph/pkg/providers"
+	"github.com/NVIDIA/topograph/pkg/topology"
+)
+
+const NAME = "coreweave"
+
+// default node labels holding the switches of the node
+const (
+	DefaultLeafLabel       = "ib.coreweave.cloud/leaf"
+	DefaultSpineLabel      = "ib.coreweave.cloud/spine"
+	DefaultDatacenterLabel = "topology.kubernetes.io/zone"
+)
+
+type Provider struct {
+	client kubernetes.Interface
+	params *Params
+}
+
+type Params struct {
+	// LeafLabel is the node label holding the leaf switch
+	LeafLabel string `mapstructure:"leaf_label"`
+	// SpineLabel is the node label holding the spine switch
+	SpineLabel string `mapstructure:"spine_label"`
+	// DatacenterLabel is the node label holding the datacenter (core) switch tier
+	DatacenterLabel string `mapstructure:"datacenter_label"`
+}
+
+func NamedLoader() (string, providers.Loader) {
+	return NAME, Loader
+}
+
+func Loader(ctx context.Context, cfg providers.Config) (providers.Provider, error) {
+	p, err := getParams(cfg.Params)
+	if err != nil {
+		return nil, err
+	}
+
+	konfig, err := rest.InClusterConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := kubernetes.NewForConfig(konfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return New(client, p), nil
+}
+
+// DefaultParams returns the parameters with their defaults
+func DefaultParams() Params {
+	return Params{
+		LeafLabel:       DefaultLeafLabel,
+		SpineLabel:      DefaultSpineLabel,
+		DatacenterLabel: DefaultDatacenterLabel,
+	}
+}
+
+func getParams(params map[string]any) (*Params, error) {
+	p := DefaultParams()
+	if err := config.Decode(params, &p); err != nil {
+		return nil, fmt.Errorf("error decoding params: %w", err)
+	}
+	if len(p.LeafLabel) == 0 {
+		return nil, fmt.Errorf("missing leaf_label")
+	}
+
+	return &p, nil
+}
+
+func New(client kubernetes.Interface, params *Params) *Provider {
+	return &Provider{
+		client: client,
+		params: params,
+	}
+}
+
+func (p *Provider) GenerateTopologyConfig(ctx context.Context, _ *int, instances []topology.ComputeInstances) (*topology.Vertex, error) {
+	topo, err := p.generateInstanceTopology(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return toGraph(topo, instances), nil
+}
+
+// Engine support
+
+// GetNodeRegion implements k8s.k8sNodeInfo
+func (p *Provider) GetNodeRegion(node *v1.Node) (string, error) {
+	return node.Labels["topology.kubernetes.io/region"], nil
+}
+
+// GetNodeInstance implements k8s.k8sNodeInfo
+func (p *Provider) GetNodeInstance(node *v1.Node) (string, error) {
+	return node.Labels["kubernetes.io/hostname"], nil
+}
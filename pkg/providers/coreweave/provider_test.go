@@ -0,0 +1,135 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package coreweave
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/NVIDIA/topograph/pkg/topology"
+)
+
+func testNode(name string, labels map[string]string) *v1.Node {
+	if labels == nil {
+		labels = make(map[string]string)
+	}
+	labels["kubernetes.io/hostname"] = name
+	return &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}}
+}
+
+func TestGetParams(t *testing.T) {
+	testCases := []struct {
+		name   string
+		params map[string]any
+		ret    *Params
+		err    string
+	}{
+		{
+			name: "Case 1: defaults",
+			ret: &Params{
+				LeafLabel:       DefaultLeafLabel,
+				SpineLabel:      DefaultSpineLabel,
+				DatacenterLabel: DefaultDatacenterLabel,
+			},
+		},
+		{
+			name:   "Case 2: custom labels",
+			params: map[string]any{"leaf_label": "leaf", "spine_label": "spine"},
+			ret: &Params{
+				LeafLabel:       "leaf",
+				SpineLabel:      "spine",
+				DatacenterLabel: DefaultDatacenterLabel,
+			},
+		},
+		{
+			name:   "Case 3: empty leaf label",
+			params: map[string]any{"leaf_label": ""},
+			err:    "missing leaf_label",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := getParams(tc.params)
+			if len(tc.err) != 0 {
+				require.EqualError(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.ret, p)
+		})
+	}
+}
+
+func TestGenerateTopologyConfig(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		testNode("node1", map[string]string{DefaultLeafLabel: "leaf1", DefaultSpineLabel: "spine1", DefaultDatacenterLabel: "dc1"}),
+		testNode("node2", map[string]string{DefaultLeafLabel: "leaf1", DefaultSpineLabel: "spine1", DefaultDatacenterLabel: "dc1"}),
+		testNode("node3", map[string]string{DefaultLeafLabel: "leaf2", DefaultSpineLabel: "spine1", DefaultDatacenterLabel: "dc1"}),
+		testNode("node4", map[string]string{DefaultLeafLabel: "leaf3"}),
+		testNode("node5", map[string]string{DefaultSpineLabel: "spine1"}),
+		testNode("node6", nil),
+		testNode("node7", map[string]string{DefaultLeafLabel: "leaf3"}),
+	)
+	params, err := getParams(nil)
+	require.NoError(t, err)
+	p := New(client, params)
+
+	cis := []topology.ComputeInstances{
+		{
+			Instances: map[string]string{
+				"node1": "node1",
+				"node2": "node2",
+				"node3": "node3",
+				"node4": "node4",
+				"node5": "node5",
+				"node6": "node6",
+			},
+		},
+	}
+
+	node := func(name string) *topology.Vertex { return &topology.Vertex{Name: name, ID: name} }
+
+	leaf1 := &topology.Vertex{ID: "leaf1", Vertices: map[string]*topology.Vertex{"node1": node("node1"), "node2": node("node2")}}
+	leaf2 := &topology.Vertex{ID: "leaf2", Vertices: map[string]*topology.Vertex{"node3": node("node3")}}
+	leaf3 := &topology.Vertex{ID: "leaf3", Vertices: map[string]*topology.Vertex{"node4": node("node4")}}
+	spine1 := &topology.Vertex{ID: "spine1", Vertices: map[string]*topology.Vertex{"leaf1": leaf1, "leaf2": leaf2}}
+	dc1 := &topology.Vertex{ID: "dc1", Vertices: map[string]*topology.Vertex{"spine1": spine1}}
+	noTopology := &topology.Vertex{
+		ID:       topology.NoTopology,
+		Vertices: map[string]*topology.Vertex{"node5": node("node5"), "node6": node("node6")},
+	}
+	expected := &topology.Vertex{
+		Vertices: map[string]*topology.Vertex{
+			topology.TopologyTree: {
+				Vertices: map[string]*topology.Vertex{
+					"dc1":               dc1,
+					"leaf3":             leaf3,
+					topology.NoTopology: noTopology,
+				},
+			},
+		},
+	}
+
+	root, err := p.GenerateTopologyConfig(context.TODO(), nil, cis)
+	require.NoError(t, err)
+	require.Equal(t, expected, root)
+}
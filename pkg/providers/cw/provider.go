@@ -20,28 +20,84 @@ import (
 	"context"
 	"fmt"
 	"os/exec"
+	"time"
 
 	v1 "k8s.io/api/core/v1"
 
+	"github.com/NVIDIA/topograph/internal/config"
+	"github.com/NVIDIA/topograph/internal/remote"
 	"github.com/NVIDIA/topograph/pkg/ib"
 	"github.com/NVIDIA/topograph/pkg/providers"
 	"github.com/NVIDIA/topograph/pkg/topology"
 )
 
-const NAME = "cw"
+const (
+	NAME = "cw"
 
-type Provider struct{}
+	// defaultCommand is the command run on the fabric management host
+	defaultCommand = "ibnetdiscover"
+)
+
+type Provider struct {
+	params *Params
+}
+
+type Params struct {
+	// FailOnMultiHomed fails the request if a node is connected to more than one leaf switch
+	FailOnMultiHomed bool `mapstructure:"fail_on_multi_homed"`
+	// PadAsymmetric inserts pass-through switches above the leaf switches closer to the top of the fabric than the others
+	PadAsymmetric bool `mapstructure:"pad_asymmetric"`
+
+	// SSHHost, if set, is the address of the fabric management host running ibnetdiscover, with optional port
+	SSHHost string `mapstructure:"ssh_host"`
+	SSHUser string `mapstructure:"ssh_user"`
+	// SSHKeyPath is the path of the private key file. If empty, the SSH agent is used
+	SSHKeyPath string `mapstructure:"ssh_key_path"`
+	// SSHKnownHosts is the path of the known_hosts file used to verify the host key
+	SSHKnownHosts string `mapstructure:"ssh_known_hosts"`
+	// SSHInsecureHostKey skips the host key verification
+	SSHInsecureHostKey bool `mapstructure:"ssh_insecure_host_key"`
+	// SSHTimeout is the deadline of the remote command
+	SSHTimeout time.Duration `mapstructure:"ssh_timeout"`
+	// Command overrides the remote ibnetdiscover command, e.g. "sudo ibnetdiscover -p"
+	Command string `mapstructure:"command"`
+}
 
 func NamedLoader() (string, providers.Loader) {
 	return NAME, Loader
 }
 
-func Loader(ctx context.Context, config providers.Config) (providers.Provider, error) {
-	return New()
+func Loader(ctx context.Context, cfg providers.Config) (providers.Provider, error) {
+	var p Params
+	if err := config.Decode(cfg.Params, &p); err != nil {
+		return nil, fmt.Errorf("error decoding params: %w", err)
+	}
+
+	return New(&p)
 }
 
-func New() (*Provider, error) {
-	return &Provider{}, nil
+func New(params *Params) (*Provider, error) {
+	if len(params.SSHHost) == 0 {
+		if len(params.Command) != 0 {
+			return nil, fmt.Errorf("command requires ssh_host")
+		}
+		return &Provider{params: params}, nil
+	}
+
+	if len(params.SSHUser) == 0 {
+		return nil, fmt.Errorf("missing ssh_user")
+	}
+	if len(params.SSHKnownHosts) == 0 && !params.SSHInsecureHostKey {
+		return nil, fmt.Errorf("missing ssh_known_hosts; set ssh_insecure_host_key to skip the host key verification")
+	}
+	if params.SSHTimeout < 0 {
+		return nil, fmt.Errorf("ssh_timeout must not be negative")
+	}
+	if len(params.Command) == 0 {
+		params.Command = defaultCommand
+	}
+
+	return &Provider{params: params}, nil
 }
 
 func (p *Provider) GenerateTopologyConfig(ctx context.Context, _ *int, instances []topology.ComputeInstances) (*topology.Vertex, error) {
@@ -49,14 +105,33 @@ func (p *Provider) GenerateTopologyConfig(ctx context.Context, _ *int, instances
 		return nil, fmt.Errorf("CW does not support mult-region topology requests")
 	}
 
-	cmd := exec.CommandContext(ctx, "ibnetdiscover")
-
-	output, err := cmd.Output()
+	output, err := p.ibnetdiscover(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	return ib.GenerateTopologyConfig(output)
+	return ib.GenerateTopologyConfig(output, p.params.FailOnMultiHomed, p.params.PadAsymmetric)
+}
+
+// ibnetdiscover returns the ibnetdiscover output, run locally or on the fabric management host
+func (p *Provider) ibnetdiscover(ctx context.Context) ([]byte, error) {
+	if len(p.params.SSHHost) == 0 {
+		return exec.CommandContext(ctx, "ibnetdiscover").Output()
+	}
+
+	if p.params.SSHTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.params.SSHTimeout)
+		defer cancel()
+	}
+
+	return remote.Output(ctx, &remote.Host{
+		Addr:                  p.params.SSHHost,
+		User:                  p.params.SSHUser,
+		KeyPath:               p.params.SSHKeyPath,
+		KnownHosts:            p.params.SSHKnownHosts,
+		InsecureIgnoreHostKey: p.params.SSHInsecureHostKey,
+	}, p.params.Command)
 }
 
 // Engine support
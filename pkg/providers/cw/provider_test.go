@@ -0,0 +1,107 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cw
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/NVIDIA/topograph/pkg/providers"
+)
+
+func TestLoader(t *testing.T) {
+	testCases := []struct {
+		name     string
+		params   map[string]any
+		expected *Params
+		err      string
+	}{
+		{
+			name:     "Case 1: local ibnetdiscover",
+			params:   map[string]any{"pad_asymmetric": true},
+			expected: &Params{PadAsymmetric: true},
+		},
+		{
+			name: "Case 2: remote ibnetdiscover with default command",
+			params: map[string]any{
+				"ssh_host":        "fabric-mgr",
+				"ssh_user":        "ib",
+				"ssh_key_path":    "/etc/topograph/ssh/id_ed25519",
+				"ssh_known_hosts": "/etc/topograph/ssh/known_hosts",
+				"ssh_timeout":     "30s",
+			},
+			expected: &Params{
+				SSHHost:       "fabric-mgr",
+				SSHUser:       "ib",
+				SSHKeyPath:    "/etc/topograph/ssh/id_ed25519",
+				SSHKnownHosts: "/etc/topograph/ssh/known_hosts",
+				SSHTimeout:    30 * time.Second,
+				Command:       "ibnetdiscover",
+			},
+		},
+		{
+			name: "Case 3: remote command with SSH agent and insecure host key",
+			params: map[string]any{
+				"ssh_host":              "fabric-mgr:2222",
+				"ssh_user":              "ib",
+				"ssh_insecure_host_key": true,
+				"command":               "sudo ibnetdiscover -p",
+			},
+			expected: &Params{
+				SSHHost:            "fabric-mgr:2222",
+				SSHUser:            "ib",
+				SSHInsecureHostKey: true,
+				Command:            "sudo ibnetdiscover -p",
+			},
+		},
+		{
+			name:   "Case 4: command without ssh_host",
+			params: map[string]any{"command": "ibnetdiscover -p"},
+			err:    "command requires ssh_host",
+		},
+		{
+			name:   "Case 5: missing ssh_user",
+			params: map[string]any{"ssh_host": "fabric-mgr", "ssh_known_hosts": "/etc/ssh/known_hosts"},
+			err:    "missing ssh_user",
+		},
+		{
+			name:   "Case 6: missing host key policy",
+			params: map[string]any{"ssh_host": "fabric-mgr", "ssh_user": "ib"},
+			err:    "missing ssh_known_hosts; set ssh_insecure_host_key to skip the host key verification",
+		},
+		{
+			name:   "Case 7: negative timeout",
+			params: map[string]any{"ssh_host": "fabric-mgr", "ssh_user": "ib", "ssh_insecure_host_key": true, "ssh_timeout": "-1s"},
+			err:    "ssh_timeout must not be negative",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			prv, err := Loader(context.TODO(), providers.Config{Params: tc.params})
+			if len(tc.err) != 0 {
+				require.EqualError(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, prv.(*Provider).params)
+		})
+	}
+}
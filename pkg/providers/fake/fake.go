@@ -0,0 +1,136 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+// Package fake provides a configurable implementation of providers.Provider for tests.
+package fake
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/NVIDIA/topograph/internal/component"
+	"github.com/NVIDIA/topograph/internal/fixtures"
+	"github.com/NVIDIA/topograph/pkg/providers"
+	"github.com/NVIDIA/topograph/pkg/topology"
+)
+
+// ErrNoResult is returned by GenerateTopologyConfig if no result is scripted
+var ErrNoResult = errors.New("no scripted result")
+
+// Result is a scripted result of GenerateTopologyConfig
+type Result struct {
+	Root *topology.Vertex
+	Err  error
+}
+
+// Provider returns the scripted results in order, repeating the last one,
+// and records the calls of GenerateTopologyConfig
+type Provider struct {
+	mutex     sync.Mutex
+	results   []Result
+	instances []topology.ComputeInstances
+	calls     [][]topology.ComputeInstances
+	health    error
+}
+
+// New returns a provider with the scripted results of GenerateTopologyConfig
+func New(results ...Result) *Provider {
+	return &Provider{results: results}
+}
+
+// NewTree returns a provider serving the tree test topology
+func NewTree() *Provider {
+	root, i2n := fixtures.TreeTestSet()
+	return New(Result{Root: root}).WithComputeInstances(topology.ComputeInstances{Instances: i2n})
+}
+
+// NewBlock returns a provider serving the block test topology with two IB fabrics
+func NewBlock() *Provider {
+	root, i2n := fixtures.BlockWithMultiIBTestSet()
+	return New(Result{Root: root}).WithComputeInstances(topology.ComputeInstances{Instances: i2n})
+}
+
+// WithComputeInstances sets the compute instances returned by GetComputeInstances
+func (p *Provider) WithComputeInstances(cis ...topology.ComputeInstances) *Provider {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.instances = cis
+	return p
+}
+
+// WithHealthCheck sets the error returned by HealthCheck
+func (p *Provider) WithHealthCheck(err error) *Provider {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.health = err
+	return p
+}
+
+// NamedLoader returns the loader of this provider instance under the given name
+func (p *Provider) NamedLoader(name string) providers.NamedLoader {
+	return component.Named(name, func(context.Context, providers.Config) (providers.Provider, error) {
+		return p, nil
+	})
+}
+
+// GetComputeInstances returns the configured compute instances
+func (p *Provider) GetComputeInstances(_ context.Context) ([]topology.ComputeInstances, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return p.instances, nil
+}
+
+// HealthCheck implements providers.HealthChecker
+func (p *Provider) HealthCheck(_ context.Context) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return p.health
+}
+
+// GenerateTopologyConfig implements providers.Provider
+func (p *Provider) GenerateTopologyConfig(_ context.Context, _ *int, instances []topology.ComputeInstances) (*topology.Vertex, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.calls = append(p.calls, instances)
+	if len(p.results) == 0 {
+		return nil, ErrNoResult
+	}
+
+	res := p.results[min(len(p.calls), len(p.results))-1]
+	return res.Root, res.Err
+}
+
+// Calls returns the compute instances passed to each call of GenerateTopologyConfig
+func (p *Provider) Calls() [][]topology.ComputeInstances {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return append([][]topology.ComputeInstances{}, p.calls...)
+}
+
+// RequireCalls fails the test unless GenerateTopologyConfig was called n times
+func (p *Provider) RequireCalls(t testing.TB, n int) {
+	t.Helper()
+	if calls := len(p.Calls()); calls != n {
+		t.Fatalf("expected %d GenerateTopologyConfig calls, got %d", n, calls)
+	}
+}
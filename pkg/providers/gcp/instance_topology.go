@@ -29,17 +29,23 @@ import (
 	"github.com/NVIDIA/topograph/pkg/topology"
 )
 
+// DefaultPageSize is the default number of instances per page of the instance list
+const DefaultPageSize = 500
+
+// InstanceTopology is the topology graph, built incrementally as the pages of the instance list arrive
 type InstanceTopology struct {
-	instances []*InstanceInfo
+	forest map[string]*topology.Vertex
+	nodes  map[string]*topology.Vertex
 }
 
-type InstanceInfo struct {
-	clusterID string
-	rackID    string
-	name      string
+func newInstanceTopology() *InstanceTopology {
+	return &InstanceTopology{
+		forest: make(map[string]*topology.Vertex),
+		nodes:  make(map[string]*topology.Vertex),
+	}
 }
 
-func (p *Provider) generateInstanceTopology(ctx context.Context, instanceToNodeMap map[string]string) (*InstanceTopology, error) {
+func (p *Provider) generateInstanceTopology(ctx context.Context, pageSize int, instanceToNodeMap map[string]string) (*InstanceTopology, error) {
 	client, err := p.clientFactory()
 	if err != nil {
 		return nil, err
@@ -61,45 +67,39 @@ func (p *Provider) generateInstanceTopology(ctx context.Context, instanceToNodeM
 		if err == iterator.Done {
 			break
 		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to list zones: %s", err.Error())
+		}
 		zones = append(zones, *zone.Name)
 	}
 
-	instanceTopology := &InstanceTopology{instances: make([]*InstanceInfo, 0)}
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+	instanceTopology := newInstanceTopology()
 
 	for _, zone := range zones {
-		timeNow := time.Now()
 		listInstanceRequest := computepb.ListInstancesRequest{Project: projectID, Zone: zone}
-		requestLatency.WithLabelValues("ListInstances").Observe(time.Since(timeNow).Seconds())
+		pager := iterator.NewPager(client.Instances.List(ctx, &listInstanceRequest), pageSize, "")
 
-		resInstance := client.Instances.List(ctx, &listInstanceRequest)
+		// process each page as it arrives, reusing the page buffer
+		var page []*computepb.Instance
 		for {
-			instance, err := resInstance.Next()
-			if err == iterator.Done {
-				break
+			if err := ctx.Err(); err != nil {
+				return nil, fmt.Errorf("interrupted instance pagination in zone %s: %w", zone, err)
 			}
-			_, isNodeInCluster := instanceToNodeMap[*instance.Name]
-
-			if instance.ResourceStatus == nil {
-				resourceStatusNotFound.WithLabelValues(*instance.Name).Set(1)
-				continue
+			page = page[:0]
+			timeNow := time.Now()
+			nextPageToken, err := pager.NextPage(&page)
+			requestLatency.WithLabelValues("ListInstances").Observe(time.Since(timeNow).Seconds())
+			if err != nil {
+				return nil, fmt.Errorf("unable to list instances in zone %s: %w", zone, err)
 			}
-			resourceStatusNotFound.WithLabelValues(*instance.Name).Set(0)
 
-			if instance.ResourceStatus.PhysicalHost == nil {
-				physicalHostNotFound.WithLabelValues(*instance.Name).Set(1)
-				continue
-			}
-			physicalHostNotFound.WithLabelValues(*instance.Name).Set(0)
-
-			if isNodeInCluster {
-				tokens := strings.Split(*instance.ResourceStatus.PhysicalHost, "/")
-				physicalHostIDChunks.WithLabelValues(*instance.Name).Set(float64(getTokenCount(tokens)))
-				instanceObj := &InstanceInfo{
-					name:      *instance.Name,
-					clusterID: tokens[1],
-					rackID:    tokens[2],
-				}
-				instanceTopology.instances = append(instanceTopology.instances, instanceObj)
+			instanceTopology.addPage(page, instanceToNodeMap)
+
+			if len(nextPageToken) == 0 {
+				break
 			}
 		}
 	}
@@ -107,44 +107,78 @@ func (p *Provider) generateInstanceTopology(ctx context.Context, instanceToNodeM
 	return instanceTopology, nil
 }
 
-func (cfg *InstanceTopology) toGraph() (*topology.Vertex, error) {
-	forest := make(map[string]*topology.Vertex)
-	nodes := make(map[string]*topology.Vertex)
+// addPage adds the cluster instances from a page of the instance list to the topology graph
+func (cfg *InstanceTopology) addPage(page []*computepb.Instance, instanceToNodeMap map[string]string) {
+	for _, instance := range page {
+		_, isNodeInCluster := instanceToNodeMap[*instance.Name]
 
-	for _, c := range cfg.instances {
-		instance := &topology.Vertex{
-			Name: c.name,
-			ID:   c.name,
+		if instance.ResourceStatus == nil {
+			resourceStatusNotFound.WithLabelValues(*instance.Name).Set(1)
+			continue
 		}
+		resourceStatusNotFound.WithLabelValues(*instance.Name).Set(0)
 
-		id2 := c.rackID
-		sw2, ok := nodes[id2]
-		if !ok {
-			sw2 = &topology.Vertex{
-				ID:       id2,
-				Vertices: make(map[string]*topology.Vertex),
+		if instance.ResourceStatus.PhysicalHost == nil {
+			physicalHostNotFound.WithLabelValues(*instance.Name).Set(1)
+			continue
+		}
+		physicalHostNotFound.WithLabelValues(*instance.Name).Set(0)
+
+		if isNodeInCluster {
+			tokens := strings.Split(*instance.ResourceStatus.PhysicalHost, "/")
+			physicalHostIDChunks.WithLabelValues(*instance.Name).Set(float64(getTokenCount(tokens)))
+			if len(tokens) < 3 {
+				continue
 			}
-			nodes[id2] = sw2
+			cfg.addInstance(*instance.Name, getSwitchIDs(tokens))
 		}
-		sw2.Vertices[instance.ID] = instance
+	}
+}
+
+// getSwitchIDs returns the switch IDs of the physical host tokens, starting from the top tier.
+// The physical host is "/<cluster>/<rack>/<host>", or "/<cluster>/<sub-block>/<rack>/<host>"
+// with an additional sub-block tier, e.g. for a3-mega instances.
+func getSwitchIDs(tokens []string) []string {
+	if len(tokens) > 4 {
+		return tokens[1:4]
+	}
+	return tokens[1:3]
+}
+
+// addInstance adds the instance and its switches, starting from the top tier, to the topology graph
+func (cfg *InstanceTopology) addInstance(name string, switchIDs []string) {
+	child := &topology.Vertex{
+		Name: name,
+		ID:   name,
+	}
 
-		id1 := c.clusterID
-		sw1, ok := nodes[id1]
+	for i := len(switchIDs) - 1; i >= 0; i-- {
+		id := switchIDs[i]
+		sw, ok := cfg.nodes[id]
 		if !ok {
-			sw1 = &topology.Vertex{
-				ID:       id1,
+			sw = &topology.Vertex{
+				ID:       id,
 				Vertices: make(map[string]*topology.Vertex),
 			}
-			nodes[id1] = sw1
-			forest[id1] = sw1
+			cfg.nodes[id] = sw
+			if i == 0 {
+				cfg.forest[id] = sw
+			}
 		}
-		sw1.Vertices[id2] = sw2
+		sw.Vertices[child.ID] = child
+		if ok {
+			// the ancestors of an existing switch are already set
+			return
+		}
+		child = sw
 	}
+}
 
+func (cfg *InstanceTopology) toGraph() (*topology.Vertex, error) {
 	treeRoot := &topology.Vertex{
 		Vertices: make(map[string]*topology.Vertex),
 	}
-	for name, node := range forest {
+	for name, node := range cfg.forest {
 		treeRoot.Vertices[name] = node
 	}
 
@@ -17,10 +17,18 @@
 package gcp
 
 import (
+	"bytes"
+	"context"
+	"fmt"
 	"strings"
 	"testing"
 
+	"cloud.google.com/go/compute/apiv1/computepb"
 	"github.com/stretchr/testify/require"
+
+	"github.com/NVIDIA/topograph/pkg/models"
+	"github.com/NVIDIA/topograph/pkg/topology"
+	"github.com/NVIDIA/topograph/pkg/translate"
 )
 
 func TestGetTokenCount(t *testing.T) {
@@ -58,3 +66,137 @@ func TestGetTokenCount(t *testing.T) {
 		})
 	}
 }
+
+func getTestInstancePages(numInstances, pageSize int) ([][]*computepb.Instance, map[string]string) {
+	pages := [][]*computepb.Instance{}
+	instanceToNodeMap := make(map[string]string)
+	var page []*computepb.Instance
+	for i := 0; i < numInstances; i++ {
+		name := fmt.Sprintf("n%05d", i)
+		physicalHost := fmt.Sprintf("/cluster%d/rack%d/host%d", i/1000, i/50, i)
+		page = append(page, &computepb.Instance{
+			Name:           &name,
+			ResourceStatus: &computepb.ResourceStatus{PhysicalHost: &physicalHost},
+		})
+		instanceToNodeMap[name] = name
+		if len(page) == pageSize {
+			pages = append(pages, page)
+			page = nil
+		}
+	}
+	if len(page) != 0 {
+		pages = append(pages, page)
+	}
+	return pages, instanceToNodeMap
+}
+
+func TestAddPages(t *testing.T) {
+	pages, instanceToNodeMap := getTestInstancePages(10000, 500)
+	require.Len(t, pages, 20)
+
+	// instances outside of the cluster and without physical host are skipped
+	missing := "missing"
+	pages[0] = append(pages[0], &computepb.Instance{Name: &missing, ResourceStatus: &computepb.ResourceStatus{}})
+	unknown, host := "unknown", "/clusterX/rackX/hostX"
+	pages[0] = append(pages[0], &computepb.Instance{Name: &unknown, ResourceStatus: &computepb.ResourceStatus{PhysicalHost: &host}})
+
+	cfg := newInstanceTopology()
+	for _, page := range pages {
+		cfg.addPage(page, instanceToNodeMap)
+	}
+
+	root, err := cfg.toGraph()
+	require.NoError(t, err)
+
+	clusters := root.Vertices[topology.TopologyTree].Vertices
+	require.Len(t, clusters, 10)
+	var racks, instances int
+	for _, cluster := range clusters {
+		require.Len(t, cluster.Vertices, 20)
+		for _, rack := range cluster.Vertices {
+			racks++
+			require.Len(t, rack.Vertices, 50)
+			instances += len(rack.Vertices)
+		}
+	}
+	require.Equal(t, 200, racks)
+	require.Equal(t, 10000, instances)
+}
+
+func TestAddPagesSubBlock(t *testing.T) {
+	model, err := models.NewModelFromFile("../../../tests/models/four-tier.yaml", nil)
+	require.NoError(t, err)
+
+	// the physical host lists the network layers of the model from the top tier
+	var page []*computepb.Instance
+	instanceToNodeMap := make(map[string]string)
+	for _, node := range model.Nodes {
+		layers := make([]string, 0, len(node.NetLayers))
+		for i := len(node.NetLayers) - 1; i >= 0; i-- {
+			layers = append(layers, node.NetLayers[i])
+		}
+		name := node.Name
+		physicalHost := fmt.Sprintf("/%s/host-%s", strings.Join(layers, "/"), name)
+		page = append(page, &computepb.Instance{
+			Name:           &name,
+			ResourceStatus: &computepb.ResourceStatus{PhysicalHost: &physicalHost},
+		})
+		instanceToNodeMap[name] = name
+	}
+
+	cfg := newInstanceTopology()
+	cfg.addPage(page, instanceToNodeMap)
+	root, err := cfg.toGraph()
+	require.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	err = translate.Write(context.TODO(), buf, root)
+	require.NoError(t, err)
+	require.Equal(t, `SwitchName=C1 Switches=SB[1-2]
+SwitchName=SB1 Switches=R[1-2]
+SwitchName=SB2 Switches=R3
+SwitchName=R1 Nodes=n[11-12]
+SwitchName=R2 Nodes=n[21-22]
+SwitchName=R3 Nodes=n[31-32]
+`, buf.String())
+}
+
+func TestGetSwitchIDs(t *testing.T) {
+	testCases := []struct {
+		name         string
+		physicalHost string
+		switchIDs    []string
+	}{
+		{
+			name:         "Case 1: cluster, rack and host",
+			physicalHost: "/AA/BB/CC",
+			switchIDs:    []string{"AA", "BB"},
+		},
+		{
+			name:         "Case 2: missing host",
+			physicalHost: "/AA/BB",
+			switchIDs:    []string{"AA", "BB"},
+		},
+		{
+			name:         "Case 3: cluster, sub-block, rack and host",
+			physicalHost: "/AA/SB/BB/CC",
+			switchIDs:    []string{"AA", "SB", "BB"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.switchIDs, getSwitchIDs(strings.Split(tc.physicalHost, "/")))
+		})
+	}
+}
+
+func BenchmarkAddPages(b *testing.B) {
+	pages, instanceToNodeMap := getTestInstancePages(10000, 500)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		cfg := newInstanceTopology()
+		for _, page := range pages {
+			cfg.addPage(page, instanceToNodeMap)
+		}
+	}
+}
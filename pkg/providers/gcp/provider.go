@@ -19,20 +19,34 @@ package gcp
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	compute_v1 "cloud.google.com/go/compute/apiv1"
 	computepb "cloud.google.com/go/compute/apiv1/computepb"
+	"cloud.google.com/go/compute/metadata"
 	gax "github.com/googleapis/gax-go/v2"
+	"google.golang.org/api/iterator"
 	v1 "k8s.io/api/core/v1"
 
+	topoconfig "github.com/NVIDIA/topograph/internal/config"
+	"github.com/NVIDIA/topograph/internal/exec"
 	"github.com/NVIDIA/topograph/pkg/providers"
 	"github.com/NVIDIA/topograph/pkg/topology"
 )
 
-const NAME = "gcp"
+const (
+	NAME = "gcp"
+
+	metadataZoneURL = "http://metadata.google.internal/computeMetadata/v1/instance/zone"
+)
 
 type Provider struct {
 	clientFactory ClientFactory
+	params        *Params
+}
+
+type Params struct {
+	providers.PageParams `mapstructure:",squash"`
 }
 
 type ClientFactory func() (*Client, error)
@@ -55,6 +69,11 @@ func NamedLoader() (string, providers.Loader) {
 }
 
 func Loader(ctx context.Context, config providers.Config) (providers.Provider, error) {
+	p, err := getParams(config.Params)
+	if err != nil {
+		return nil, err
+	}
+
 	clientFactory := func() (*Client, error) {
 		zonesClient, err := compute_v1.NewZonesRESTClient(ctx)
 		if err != nil {
@@ -72,16 +91,29 @@ func Loader(ctx context.Context, config providers.Config) (providers.Provider, e
 		}, nil
 	}
 
-	return New(clientFactory)
+	return New(clientFactory, p)
+}
+
+func getParams(params map[string]any) (*Params, error) {
+	var p Params
+	if err := topoconfig.Decode(params, &p); err != nil {
+		return nil, fmt.Errorf("error decoding params: %w", err)
+	}
+	if err := p.PageParams.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &p, nil
 }
 
-func New(clientFactory ClientFactory) (*Provider, error) {
+func New(clientFactory ClientFactory, params *Params) (*Provider, error) {
 	return &Provider{
 		clientFactory: clientFactory,
+		params:        params,
 	}, nil
 }
 
-func (p *Provider) GenerateTopologyConfig(ctx context.Context, _ *int, instances []topology.ComputeInstances) (*topology.Vertex, error) {
+func (p *Provider) GenerateTopologyConfig(ctx context.Context, pageSize *int, instances []topology.ComputeInstances) (*topology.Vertex, error) {
 	if len(instances) > 1 {
 		return nil, fmt.Errorf("GCP does not support mult-region topology requests")
 	}
@@ -91,7 +123,13 @@ func (p *Provider) GenerateTopologyConfig(ctx context.Context, _ *int, instances
 		instanceToNode = instances[0].Instances
 	}
 
-	cfg, err := p.generateInstanceTopology(ctx, instanceToNode)
+	var paramSize int
+	if p.params != nil {
+		paramSize = p.params.PageSize
+	}
+	size := providers.GetPageSize(paramSize, pageSize, DefaultPageSize)
+
+	cfg, err := p.generateInstanceTopology(ctx, size, instanceToNode)
 	if err != nil {
 		return nil, err
 	}
@@ -99,6 +137,27 @@ func (p *Provider) GenerateTopologyConfig(ctx context.Context, _ *int, instances
 	return cfg.toGraph()
 }
 
+// HealthCheck implements providers.HealthChecker.
+// It looks up the project of the instance and lists a single zone of the project.
+func (p *Provider) HealthCheck(ctx context.Context) error {
+	client, err := p.clientFactory()
+	if err != nil {
+		return err
+	}
+
+	projectID, err := metadata.ProjectIDWithContext(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to get project ID: %w", err)
+	}
+
+	maxResults := uint32(1)
+	_, err = client.Zones.List(ctx, &computepb.ListZonesRequest{Project: projectID, MaxResults: &maxResults}).Next()
+	if err != nil && err != iterator.Done {
+		return fmt.Errorf("unable to list zones: %w", err)
+	}
+	return nil
+}
+
 // Engine support
 
 // Instances2NodeMap implements slurm.instanceMapper
@@ -116,6 +175,30 @@ func (p *Provider) GetComputeInstancesRegion() (string, error) {
 	return "", nil
 }
 
+// GetInstancesRegions implements slurm.regionMapper
+func (p *Provider) GetInstancesRegions(ctx context.Context, nodes []string) (map[string]string, error) {
+	n2z, err := exec.Pdsh(ctx, nodes, fmt.Sprintf("echo $(curl -s -H \"Metadata-Flavor: Google\" %s)", metadataZoneURL))
+	if err != nil {
+		return nil, err
+	}
+
+	n2r := make(map[string]string, len(n2z))
+	for node, zone := range n2z {
+		n2r[node] = zoneToRegion(zone)
+	}
+
+	return n2r, nil
+}
+
+// zoneToRegion converts "projects/<project-number>/zones/<region>-<zone>" into "<region>"
+func zoneToRegion(zone string) string {
+	zone = zone[strings.LastIndex(zone, "/")+1:]
+	if indx := strings.LastIndex(zone, "-"); indx > 0 {
+		return zone[:indx]
+	}
+	return zone
+}
+
 // GetNodeRegion implements k8s.k8sNodeInfo
 func (p *Provider) GetNodeRegion(node *v1.Node) (string, error) {
 	return node.Labels["topology.kubernetes.io/region"], nil
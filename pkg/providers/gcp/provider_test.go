@@ -0,0 +1,37 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package gcp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestZoneToRegion(t *testing.T) {
+	testCases := []struct {
+		zone   string
+		region string
+	}{
+		{zone: "projects/123456789/zones/us-central1-a", region: "us-central1"},
+		{zone: "europe-west4-b", region: "europe-west4"},
+		{zone: "local", region: "local"},
+	}
+
+	for _, tc := range testCases {
+		require.Equal(t, tc.region, zoneToRegion(tc.zone))
+	}
+}
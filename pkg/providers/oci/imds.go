@@ -18,11 +18,14 @@ package oci
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"os/exec"
 	"strings"
 
 	"k8s.io/klog/v2"
+
+	topoexec "github.com/NVIDIA/topograph/internal/exec"
 )
 
 const (
@@ -63,3 +66,7 @@ func instanceToNodeMap(nodes []string) (map[string]string, error) {
 
 	return i2n, nil
 }
+
+func instanceToRegionMap(ctx context.Context, nodes []string) (map[string]string, error) {
+	return topoexec.Pdsh(ctx, nodes, fmt.Sprintf("echo $(curl -s -H \"Authorization: Bearer Oracle\" -L %s/canonicalRegionName)", IMDSURL))
+}
@@ -21,37 +21,54 @@ import (
 	"fmt"
 	"net/http"
 	"sort"
+	"strconv"
 	"time"
 
+	OCICommon "github.com/oracle/oci-go-sdk/v65/common"
 	"github.com/oracle/oci-go-sdk/v65/core"
 	"github.com/oracle/oci-go-sdk/v65/identity"
 	"k8s.io/klog/v2"
 
 	"github.com/NVIDIA/topograph/pkg/metrics"
+	"github.com/NVIDIA/topograph/pkg/providers"
 	"github.com/NVIDIA/topograph/pkg/topology"
 )
 
 type level int
 
+var (
+	// retryBaseDelay is the delay before the first retry of a failed request, doubled on each retry
+	retryBaseDelay = time.Second
+	maxRetryDelay  = 30 * time.Second
+)
+
 const (
 	localBlockLevel level = iota + 1
 	networkBlockLevel
 	hpcIslandLevel
 )
 
-func GenerateInstanceTopology(ctx context.Context, factory ClientFactory, cis []topology.ComputeInstances) ([]*core.ComputeBareMetalHostSummary, error) {
-	var err error
-	bareMetalHostSummaries := []*core.ComputeBareMetalHostSummary{}
-	for _, ci := range cis {
-		if bareMetalHostSummaries, err = generateInstanceTopology(ctx, factory, &ci, bareMetalHostSummaries); err != nil {
-			return nil, err
-		}
+// GenerateInstanceTopology returns the bare metal hosts of the regions of the compute instances.
+// Up to concurrency regions are requested at a time, and the hosts are returned in the region order.
+func GenerateInstanceTopology(ctx context.Context, factory ClientFactory, retries, pageSize, concurrency int, cis []topology.ComputeInstances) ([]*core.ComputeBareMetalHostSummary, error) {
+	regionHosts := make([][]*core.ComputeBareMetalHostSummary, len(cis))
+	err := providers.ForEachRegion(ctx, cis, concurrency, func(ctx context.Context, i int, ci *topology.ComputeInstances) error {
+		var err error
+		regionHosts[i], err = generateInstanceTopology(ctx, factory, retries, pageSize, ci, nil)
+		return err
+	})
+	if err != nil {
+		return nil, err
 	}
 
+	bareMetalHostSummaries := []*core.ComputeBareMetalHostSummary{}
+	for _, hosts := range regionHosts {
+		bareMetalHostSummaries = append(bareMetalHostSummaries, hosts...)
+	}
 	return bareMetalHostSummaries, nil
 }
 
-func getComputeCapacityTopologies(ctx context.Context, client Client) (cct []core.ComputeCapacityTopologySummary, err error) {
+func getComputeCapacityTopologies(ctx context.Context, client Client, pageSize int) (cct []core.ComputeCapacityTopologySummary, err error) {
 	compartmentId := client.TenancyOCID()
 
 	adRequest := identity.ListAvailabilityDomainsRequest{
@@ -69,9 +86,13 @@ func getComputeCapacityTopologies(ctx context.Context, client Client) (cct []cor
 		cctRequest := core.ListComputeCapacityTopologiesRequest{
 			CompartmentId:      &compartmentId,
 			AvailabilityDomain: ad.Name,
+			Limit:              pageLimit(pageSize),
 		}
 
 		for {
+			if err := ctx.Err(); err != nil {
+				return cct, fmt.Errorf("interrupted compute capacity topology pagination in %s: %w", *ad.Name, err)
+			}
 			timeStart := time.Now()
 			resp, err := client.ListComputeCapacityTopologies(ctx, cctRequest)
 			requestLatency.WithLabelValues("ListComputeCapacityTopologies", resp.HTTPResponse().Status).Observe(time.Since(timeStart).Seconds())
@@ -79,7 +100,7 @@ func getComputeCapacityTopologies(ctx context.Context, client Client) (cct []cor
 				if resp.HTTPResponse().StatusCode == http.StatusNotFound {
 					return cct, fmt.Errorf("%v for getting ComputeCapacityTopology in %s: %v", resp.HTTPResponse().StatusCode, *ad.Name, err)
 				} else {
-					return cct, fmt.Errorf("unable to get ComputeCapacity Topologies in %s : %v", *ad.Name, err)
+					return cct, fmt.Errorf("unable to get ComputeCapacity Topologies in %s : %w", *ad.Name, err)
 				}
 			}
 			cct = append(cct, resp.Items...)
@@ -95,19 +116,20 @@ func getComputeCapacityTopologies(ctx context.Context, client Client) (cct []cor
 	return cct, nil
 }
 
-func getBMHSummaryPerComputeCapacityTopology(ctx context.Context, client Client, topologyID string) (bmhSummary []core.ComputeBareMetalHostSummary, err error) {
+func getBMHSummaryPerComputeCapacityTopology(ctx context.Context, client Client, topologyID string, retries, pageSize int) (bmhSummary []core.ComputeBareMetalHostSummary, err error) {
 	compartmentId := client.TenancyOCID()
 	request := core.ListComputeCapacityTopologyComputeBareMetalHostsRequest{
 		ComputeCapacityTopologyId: &topologyID,
 		CompartmentId:             &compartmentId,
+		Limit:                     pageLimit(pageSize),
 	}
 	for {
-		timeStart := time.Now()
-		response, err := client.ListComputeCapacityTopologyComputeBareMetalHosts(ctx, request)
-		requestLatency.WithLabelValues("ListComputeCapacityTopologyComputeBareMetalHosts", response.HTTPResponse().Status).Observe(time.Since(timeStart).Seconds())
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("interrupted bare metal host pagination: %w", err)
+		}
+		response, err := listBareMetalHosts(ctx, client, request, retries)
 		if err != nil {
-			klog.Errorln(err.Error())
-			break
+			return nil, err
 		}
 
 		bmhSummary = append(bmhSummary, response.Items...)
@@ -121,18 +143,84 @@ func getBMHSummaryPerComputeCapacityTopology(ctx context.Context, client Client,
 	return bmhSummary, nil
 }
 
-func getBareMetalHostSummaries(ctx context.Context, client Client) ([]core.ComputeBareMetalHostSummary, error) {
-	computeCapacityTopology, err := getComputeCapacityTopologies(ctx, client)
+// listBareMetalHosts requests a page of the bare metal hosts,
+// retrying the transient errors with exponential backoff
+func listBareMetalHosts(ctx context.Context, client Client, request core.ListComputeCapacityTopologyComputeBareMetalHostsRequest, retries int) (core.ListComputeCapacityTopologyComputeBareMetalHostsResponse, error) {
+	const method = "ListComputeCapacityTopologyComputeBareMetalHosts"
+
+	delay := retryBaseDelay
+	for attempt := 0; ; attempt++ {
+		timeStart := time.Now()
+		response, err := client.ListComputeCapacityTopologyComputeBareMetalHosts(ctx, request)
+		code := statusCode(response.RawResponse, err)
+		requestLatency.WithLabelValues(method, status(response.RawResponse, code)).Observe(time.Since(timeStart).Seconds())
+		if err == nil {
+			return response, nil
+		}
+
+		if !isTransient(code) {
+			pageErrors.WithLabelValues(method, "failed").Inc()
+			return response, fmt.Errorf("%w: %s: %v", providers.ErrProviderAPI, method, err)
+		}
+		if attempt >= retries {
+			pageErrors.WithLabelValues(method, "failed").Inc()
+			return response, fmt.Errorf("%w: %s failed after %d retries: %v", providers.ErrProviderAPI, method, retries, err)
+		}
+
+		pageErrors.WithLabelValues(method, "retried").Inc()
+		klog.Warningf("%s failed with status %d: %v; retrying in %s", method, code, err, delay.String())
+		select {
+		case <-ctx.Done():
+			return response, ctx.Err()
+		case <-time.After(delay):
+		}
+		delay = min(2*delay, maxRetryDelay)
+	}
+}
+
+// pageLimit returns the page size limit of the list requests, or nil for the service default
+func pageLimit(pageSize int) *int {
+	if pageSize <= 0 {
+		return nil
+	}
+	return &pageSize
+}
+
+// statusCode returns the HTTP status code of the failed or completed request, or 0 if unknown
+func statusCode(resp *http.Response, err error) int {
+	if svcErr, ok := OCICommon.IsServiceError(err); ok {
+		return svcErr.GetHTTPStatusCode()
+	}
+	if resp != nil {
+		return resp.StatusCode
+	}
+	return 0
+}
+
+func status(resp *http.Response, code int) string {
+	if resp != nil {
+		return resp.Status
+	}
+	return strconv.Itoa(code)
+}
+
+// isTransient returns true for the throttled requests and the server errors
+func isTransient(code int) bool {
+	return code == http.StatusTooManyRequests || code >= http.StatusInternalServerError
+}
+
+func getBareMetalHostSummaries(ctx context.Context, client Client, retries, pageSize int) ([]core.ComputeBareMetalHostSummary, error) {
+	computeCapacityTopology, err := getComputeCapacityTopologies(ctx, client, pageSize)
 	if err != nil {
-		return nil, fmt.Errorf("unable to get compute capacity topologies: %s", err.Error())
+		return nil, fmt.Errorf("unable to get compute capacity topologies: %w", err)
 	}
 	klog.V(4).Infof("Received computeCapacityTopology for %d groups", len(computeCapacityTopology))
 
 	var bareMetalHostSummaries []core.ComputeBareMetalHostSummary
 	for _, cct := range computeCapacityTopology {
-		bareMetalHostSummary, err := getBMHSummaryPerComputeCapacityTopology(ctx, client, *cct.Id)
+		bareMetalHostSummary, err := getBMHSummaryPerComputeCapacityTopology(ctx, client, *cct.Id, retries, pageSize)
 		if err != nil {
-			return nil, fmt.Errorf("unable to get bare metal hosts info: %s", err.Error())
+			return nil, fmt.Errorf("unable to get bare metal hosts info: %w", err)
 		}
 		bareMetalHostSummaries = append(bareMetalHostSummaries, bareMetalHostSummary...)
 	}
@@ -141,7 +229,9 @@ func getBareMetalHostSummaries(ctx context.Context, client Client) ([]core.Compu
 	return bareMetalHostSummaries, nil
 }
 
-func toGraph(bareMetalHostSummaries []*core.ComputeBareMetalHostSummary, cis []topology.ComputeInstances) (*topology.Vertex, error) {
+// The nodes without topology are grouped by the groupUnplaced mode. The instance types are not reported
+// by the bare metal host summaries, so only the node name prefix groups them.
+func toGraph(bareMetalHostSummaries []*core.ComputeBareMetalHostSummary, cis []topology.ComputeInstances, localBlockThreshold float64, missingBlockPolicy, groupUnplaced string) (*topology.Vertex, error) {
 	instanceToNodeMap := make(map[string]string)
 	for _, ci := range cis {
 		for instance, node := range ci.Instances {
@@ -153,8 +243,38 @@ func toGraph(bareMetalHostSummaries []*core.ComputeBareMetalHostSummary, cis []t
 	nodes := make(map[string]*topology.Vertex)
 	forest := make(map[string]*topology.Vertex)
 	levelWiseSwitchCount := map[level]int{localBlockLevel: 0, networkBlockLevel: 0, hpcIslandLevel: 0}
-	bareMetalHostSummaries = filterAndSort(bareMetalHostSummaries, instanceToNodeMap)
+	noLocalBlock := isLocalBlockAbsent(bareMetalHostSummaries, instanceToNodeMap, localBlockThreshold)
+	bareMetalHostSummaries = filterAndSort(bareMetalHostSummaries, instanceToNodeMap, noLocalBlock)
+	var missingBlock int
 	for _, bmhSummary := range bareMetalHostSummaries {
+		// switch IDs starting from the lowest tier.
+		// If the local block is systematically absent, the network block becomes the lowest tier.
+		// If the local block is sporadically absent, the switch IDs are defined by the missing block policy.
+		// If the network block or HPC island is absent, the instance is attached to the lowest known switch.
+		var switchIDs []string
+		var levels []level
+		if bmhSummary.ComputeLocalBlockId == nil && !noLocalBlock && isTopologyComplete(bmhSummary) {
+			missingBlock++
+			switchIDs = topology.ResolveMissingBlock(missingBlockPolicy, []string{*bmhSummary.ComputeNetworkBlockId, *bmhSummary.ComputeHpcIslandId})
+			if len(switchIDs) == 0 {
+				continue
+			}
+			for i := range switchIDs {
+				levels = append(levels, hpcIslandLevel-level(len(switchIDs)-1-i))
+			}
+		} else {
+			ancestors := []*string{bmhSummary.ComputeLocalBlockId, bmhSummary.ComputeNetworkBlockId, bmhSummary.ComputeHpcIslandId}
+			if noLocalBlock {
+				ancestors = ancestors[1:]
+			}
+			for i, id := range ancestors {
+				if id != nil {
+					switchIDs = append(switchIDs, *id)
+					levels = append(levels, localBlockLevel+level(i))
+				}
+			}
+		}
+
 		nodeName := instanceToNodeMap[*bmhSummary.InstanceId]
 		delete(instanceToNodeMap, *bmhSummary.InstanceId)
 
@@ -163,61 +283,38 @@ func toGraph(bareMetalHostSummaries []*core.ComputeBareMetalHostSummary, cis []t
 			ID:   *bmhSummary.InstanceId,
 		}
 
-		localBlockId := *bmhSummary.ComputeLocalBlockId
-		localBlock, ok := nodes[localBlockId]
-		if !ok {
-			levelWiseSwitchCount[localBlockLevel]++
-			localBlock = &topology.Vertex{
-				ID:       localBlockId,
-				Vertices: make(map[string]*topology.Vertex),
-				Name:     fmt.Sprintf("Switch.%d.%d", localBlockLevel, levelWiseSwitchCount[localBlockLevel]),
+		child := instance
+		for i, id := range switchIDs {
+			lvl := levels[i]
+			sw, ok := nodes[id]
+			if ok {
+				// the ancestors of an existing switch are already set
+				sw.Vertices[child.ID] = child
+				break
 			}
-			nodes[localBlockId] = localBlock
-		}
-		localBlock.Vertices[instance.ID] = instance
-
-		networkBlockId := *bmhSummary.ComputeNetworkBlockId
-		networkBlock, ok := nodes[networkBlockId]
-		if !ok {
-			levelWiseSwitchCount[networkBlockLevel]++
-			networkBlock = &topology.Vertex{
-				ID:       networkBlockId,
+			levelWiseSwitchCount[lvl]++
+			sw = &topology.Vertex{
+				ID:       id,
 				Vertices: make(map[string]*topology.Vertex),
-				Name:     fmt.Sprintf("Switch.%d.%d", networkBlockLevel, levelWiseSwitchCount[networkBlockLevel]),
+				Name:     fmt.Sprintf("Switch.%d.%d", lvl, levelWiseSwitchCount[lvl]),
 			}
-			nodes[networkBlockId] = networkBlock
-		}
-		networkBlock.Vertices[localBlockId] = localBlock
-
-		hpcIslandId := *bmhSummary.ComputeHpcIslandId
-		hpcIsland, ok := nodes[hpcIslandId]
-		if !ok {
-			levelWiseSwitchCount[hpcIslandLevel]++
-			hpcIsland = &topology.Vertex{
-				ID:       hpcIslandId,
-				Vertices: make(map[string]*topology.Vertex),
-				Name:     fmt.Sprintf("Switch.%d.%d", hpcIslandLevel, levelWiseSwitchCount[hpcIslandLevel]),
+			nodes[id] = sw
+			if i == len(switchIDs)-1 {
+				forest[id] = sw
 			}
-			nodes[hpcIslandId] = hpcIsland
-			forest[hpcIslandId] = hpcIsland
+			sw.Vertices[child.ID] = child
+			child = sw
 		}
-		hpcIsland.Vertices[networkBlockId] = networkBlock
 	}
 
+	metrics.SetMissingBlock(NAME, missingBlock)
+
 	if len(instanceToNodeMap) != 0 {
 		klog.V(4).Infof("Adding nodes w/o topology: %v", instanceToNodeMap)
 		metrics.SetMissingTopology(NAME, len(instanceToNodeMap))
-		sw := &topology.Vertex{
-			ID:       topology.NoTopology,
-			Vertices: make(map[string]*topology.Vertex),
-		}
-		for instanceID, nodeName := range instanceToNodeMap {
-			sw.Vertices[instanceID] = &topology.Vertex{
-				Name: nodeName,
-				ID:   instanceID,
-			}
+		for id, sw := range topology.NoTopologySwitches(instanceToNodeMap, nil, groupUnplaced) {
+			forest[id] = sw
 		}
-		forest[topology.NoTopology] = sw
 	}
 
 	treeRoot := &topology.Vertex{
@@ -235,67 +332,163 @@ func toGraph(bareMetalHostSummaries []*core.ComputeBareMetalHostSummary, cis []t
 
 }
 
-func filterAndSort(bareMetalHostSummaries []*core.ComputeBareMetalHostSummary, instanceToNodeMap map[string]string) []*core.ComputeBareMetalHostSummary {
-	var filtered []*core.ComputeBareMetalHostSummary
+// isLocalBlockAbsent returns true if the fraction of the hosts that report the network block and HPC island
+// but not the local block is above the threshold. Such tenancies do not populate the local block at all.
+func isLocalBlockAbsent(bareMetalHostSummaries []*core.ComputeBareMetalHostSummary, instanceToNodeMap map[string]string, threshold float64) bool {
+	var total, missing int
 	for _, bmh := range bareMetalHostSummaries {
 		if bmh.InstanceId == nil {
-			klog.V(5).Infof("Instance ID is nil for bmhSummary %s", bmh.String())
 			continue
 		}
-
-		if bmh.ComputeLocalBlockId == nil {
-			klog.Warningf("ComputeLocalBlockId is nil for instance %q", *bmh.InstanceId)
-			missingAncestor.WithLabelValues("localBlock", *bmh.InstanceId).Add(float64(1))
+		if _, ok := instanceToNodeMap[*bmh.InstanceId]; !ok {
 			continue
 		}
+		total++
+		if bmh.ComputeLocalBlockId == nil && bmh.ComputeNetworkBlockId != nil && bmh.ComputeHpcIslandId != nil {
+			missing++
+		}
+	}
+
+	if total == 0 || float64(missing)/float64(total) <= threshold {
+		return false
+	}
 
-		if bmh.ComputeNetworkBlockId == nil {
-			klog.Warningf("ComputeNetworkBlockId is nil for instance %q", *bmh.InstanceId)
-			missingAncestor.WithLabelValues("networkBlock", *bmh.InstanceId).Add(float64(1))
+	klog.Warningf("ComputeLocalBlockId is missing for %d out of %d instances; using network block as the lowest tier", missing, total)
+	return true
+}
+
+// filterAndSort returns the cluster hosts with known switches, sorted by HPC island, network block,
+// local block and instance ID, so that the graph and its switch names do not depend on the listing order.
+// A host listed more than once, e.g. after moving between pages of consecutive sweeps, is kept once.
+func filterAndSort(bareMetalHostSummaries []*core.ComputeBareMetalHostSummary, instanceToNodeMap map[string]string, noLocalBlock bool) []*core.ComputeBareMetalHostSummary {
+	hosts := make(map[string]*core.ComputeBareMetalHostSummary)
+	for _, bmh := range bareMetalHostSummaries {
+		if bmh.InstanceId == nil {
+			klog.V(5).Infof("Instance ID is nil for bmhSummary %s", bmh.String())
 			continue
 		}
 
-		if bmh.ComputeHpcIslandId == nil {
-			klog.Warningf("ComputeHpcIslandId is nil for instance %q", *bmh.InstanceId)
-			missingAncestor.WithLabelValues("hpcIsland", *bmh.InstanceId).Add(float64(1))
+		if !hasTopology(bmh, noLocalBlock) {
+			klog.Warningf("Topology is missing for instance %q", *bmh.InstanceId)
+			reportMissingAncestors(bmh, noLocalBlock, placementNone)
 			continue
 		}
 
-		if _, ok := instanceToNodeMap[*bmh.InstanceId]; ok {
-			klog.V(4).Infof("Adding bmhSummary %s", bmh.String())
-			filtered = append(filtered, bmh)
+		// hosts with missing local block are placed according to the missing block policy,
+		// and hosts with missing network block or HPC island are attached to the lowest known switch
+		if isTopologyComplete(bmh) {
+			reportMissingAncestors(bmh, noLocalBlock, placementPolicy)
 		} else {
-			klog.V(4).Infof("Skipping bmhSummary %s", bmh.String())
+			reportMissingAncestors(bmh, noLocalBlock, placementPartial)
 		}
-	}
 
-	sort.Slice(filtered, func(i, j int) bool {
-		if filtered[i].ComputeHpcIslandId != filtered[j].ComputeHpcIslandId {
-			return *filtered[i].ComputeHpcIslandId < *filtered[j].ComputeHpcIslandId
+		if _, ok := instanceToNodeMap[*bmh.InstanceId]; !ok {
+			klog.V(4).Infof("Skipping bmhSummary %s", bmh.String())
+			continue
 		}
 
-		if filtered[i].ComputeNetworkBlockId != filtered[j].ComputeNetworkBlockId {
-			return *filtered[i].ComputeNetworkBlockId < *filtered[j].ComputeNetworkBlockId
+		if prev, ok := hosts[*bmh.InstanceId]; ok {
+			klog.Warningf("Instance %q is listed more than once", *bmh.InstanceId)
+			// keep the same entry regardless of the listing order
+			if !hostLess(bmh, prev, noLocalBlock) {
+				continue
+			}
 		}
+		klog.V(4).Infof("Adding bmhSummary %s", bmh.String())
+		hosts[*bmh.InstanceId] = bmh
+	}
 
-		if filtered[i].ComputeLocalBlockId != filtered[j].ComputeLocalBlockId {
-			return *filtered[i].ComputeLocalBlockId < *filtered[j].ComputeLocalBlockId
-		}
+	filtered := make([]*core.ComputeBareMetalHostSummary, 0, len(hosts))
+	for _, bmh := range hosts {
+		filtered = append(filtered, bmh)
+	}
 
-		return *filtered[i].InstanceId < *filtered[j].InstanceId
+	sort.Slice(filtered, func(i, j int) bool {
+		return hostLess(filtered[i], filtered[j], noLocalBlock)
 	})
 	return filtered
 }
 
-func generateInstanceTopology(ctx context.Context, factory ClientFactory, ci *topology.ComputeInstances, bareMetalHostSummaries []*core.ComputeBareMetalHostSummary) ([]*core.ComputeBareMetalHostSummary, error) {
+// hostLess orders the hosts by the number of missing upper ancestors, HPC island, network block, local block (unless absent in the tenancy)
+// and instance ID
+func hostLess(a, b *core.ComputeBareMetalHostSummary, noLocalBlock bool) bool {
+	// hosts with complete topology go first, so that their switches define the ancestors
+	// of the switches shared with hosts of partial topology
+	if x, y := missingUpperAncestors(a), missingUpperAncestors(b); x != y {
+		return x < y
+	}
+
+	if x, y := stringValue(a.ComputeHpcIslandId), stringValue(b.ComputeHpcIslandId); x != y {
+		return x < y
+	}
+
+	if x, y := stringValue(a.ComputeNetworkBlockId), stringValue(b.ComputeNetworkBlockId); x != y {
+		return x < y
+	}
+
+	if !noLocalBlock {
+		if x, y := stringValue(a.ComputeLocalBlockId), stringValue(b.ComputeLocalBlockId); x != y {
+			return x < y
+		}
+	}
+
+	return *a.InstanceId < *b.InstanceId
+}
+
+// isTopologyComplete returns true if the host reports both the network block and the HPC island
+func isTopologyComplete(bmh *core.ComputeBareMetalHostSummary) bool {
+	return missingUpperAncestors(bmh) == 0
+}
+
+// missingUpperAncestors returns the number of missing ancestors above the local block
+func missingUpperAncestors(bmh *core.ComputeBareMetalHostSummary) int {
+	var n int
+	if bmh.ComputeNetworkBlockId == nil {
+		n++
+	}
+	if bmh.ComputeHpcIslandId == nil {
+		n++
+	}
+	return n
+}
+
+// hasTopology returns true if the host reports at least one switch used in the topology
+func hasTopology(bmh *core.ComputeBareMetalHostSummary, noLocalBlock bool) bool {
+	return (bmh.ComputeLocalBlockId != nil && !noLocalBlock) || missingUpperAncestors(bmh) < 2
+}
+
+// reportMissingAncestors counts the missing ancestors of the host, labeled by the host placement
+func reportMissingAncestors(bmh *core.ComputeBareMetalHostSummary, noLocalBlock bool, placement string) {
+	if bmh.ComputeLocalBlockId == nil && !noLocalBlock {
+		klog.Warningf("ComputeLocalBlockId is nil for instance %q", *bmh.InstanceId)
+		missingAncestor.WithLabelValues("localBlock", *bmh.InstanceId, placement).Add(float64(1))
+	}
+	if bmh.ComputeNetworkBlockId == nil {
+		klog.Warningf("ComputeNetworkBlockId is nil for instance %q", *bmh.InstanceId)
+		missingAncestor.WithLabelValues("networkBlock", *bmh.InstanceId, placement).Add(float64(1))
+	}
+	if bmh.ComputeHpcIslandId == nil {
+		klog.Warningf("ComputeHpcIslandId is nil for instance %q", *bmh.InstanceId)
+		missingAncestor.WithLabelValues("hpcIsland", *bmh.InstanceId, placement).Add(float64(1))
+	}
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func generateInstanceTopology(ctx context.Context, factory ClientFactory, retries, pageSize int, ci *topology.ComputeInstances, bareMetalHostSummaries []*core.ComputeBareMetalHostSummary) ([]*core.ComputeBareMetalHostSummary, error) {
 	client, err := factory(ci.Region)
 	if err != nil {
 		return nil, err
 	}
 
-	bmh, err := getBareMetalHostSummaries(ctx, client)
+	bmh, err := getBareMetalHostSummaries(ctx, client, retries, pageSize)
 	if err != nil {
-		return nil, fmt.Errorf("unable to populate compute capacity topology: %s", err.Error())
+		return nil, fmt.Errorf("unable to populate compute capacity topology: %w", err)
 	}
 
 	for _, bm := range bmh {
@@ -0,0 +1,788 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package oci
+
+import (
+	"bytes"
+	"context"
+	"math/rand"
+	"net/http"
+	"testing"
+	"time"
+
+	OCICommon "github.com/oracle/oci-go-sdk/v65/common"
+	"github.com/oracle/oci-go-sdk/v65/core"
+	"github.com/oracle/oci-go-sdk/v65/identity"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/NVIDIA/topograph/pkg/providers"
+	"github.com/NVIDIA/topograph/pkg/topology"
+	"github.com/NVIDIA/topograph/pkg/translate"
+)
+
+// newHostSummary returns the bare metal host summary, with empty ancestor IDs left nil
+func newHostSummary(instance, localBlock, networkBlock, hpcIsland string) *core.ComputeBareMetalHostSummary {
+	bmh := &core.ComputeBareMetalHostSummary{
+		InstanceId: OCICommon.String(instance),
+	}
+	if len(localBlock) != 0 {
+		bmh.ComputeLocalBlockId = OCICommon.String(localBlock)
+	}
+	if len(networkBlock) != 0 {
+		bmh.ComputeNetworkBlockId = OCICommon.String(networkBlock)
+	}
+	if len(hpcIsland) != 0 {
+		bmh.ComputeHpcIslandId = OCICommon.String(hpcIsland)
+	}
+	return bmh
+}
+
+func TestToGraphMissingLocalBlock(t *testing.T) {
+	cis := []topology.ComputeInstances{
+		{
+			Instances: map[string]string{
+				"i1": "node1",
+				"i2": "node2",
+				"i3": "node3",
+				"i4": "node4",
+			},
+		},
+	}
+
+	n1 := &topology.Vertex{ID: "i1", Name: "node1"}
+	n2 := &topology.Vertex{ID: "i2", Name: "node2"}
+	n3 := &topology.Vertex{ID: "i3", Name: "node3"}
+	n4 := &topology.Vertex{ID: "i4", Name: "node4"}
+
+	testCases := []struct {
+		name     string
+		hosts    []*core.ComputeBareMetalHostSummary
+		policy   string
+		expected *topology.Vertex
+	}{
+		{
+			name: "Case 1: sporadic missing local block",
+			hosts: []*core.ComputeBareMetalHostSummary{
+				newHostSummary("i1", "lb1", "nb1", "hpc1"),
+				newHostSummary("i2", "lb1", "nb1", "hpc1"),
+				newHostSummary("i3", "lb2", "nb2", "hpc1"),
+				newHostSummary("i4", "", "nb2", "hpc1"),
+			},
+			expected: &topology.Vertex{
+				Vertices: map[string]*topology.Vertex{
+					topology.TopologyTree: {
+						Vertices: map[string]*topology.Vertex{
+							"hpc1": {
+								ID:   "hpc1",
+								Name: "Switch.3.1",
+								Vertices: map[string]*topology.Vertex{
+									"nb1": {
+										ID:   "nb1",
+										Name: "Switch.2.1",
+										Vertices: map[string]*topology.Vertex{
+											"lb1": {
+												ID:       "lb1",
+												Name:     "Switch.1.1",
+												Vertices: map[string]*topology.Vertex{"i1": n1, "i2": n2},
+											},
+										},
+									},
+									"nb2": {
+										ID:   "nb2",
+										Name: "Switch.2.2",
+										Vertices: map[string]*topology.Vertex{
+											"lb2": {
+												ID:       "lb2",
+												Name:     "Switch.1.2",
+												Vertices: map[string]*topology.Vertex{"i3": n3},
+											},
+										},
+									},
+								},
+							},
+							topology.NoTopology: {
+								ID:       topology.NoTopology,
+								Vertices: map[string]*topology.Vertex{"i4": n4},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "Case 2: systematic missing local block",
+			hosts: []*core.ComputeBareMetalHostSummary{
+				newHostSummary("i1", "", "nb1", "hpc1"),
+				newHostSummary("i2", "", "nb1", "hpc1"),
+				newHostSummary("i3", "", "nb2", "hpc1"),
+				newHostSummary("i4", "", "nb2", "hpc1"),
+			},
+			expected: &topology.Vertex{
+				Vertices: map[string]*topology.Vertex{
+					topology.TopologyTree: {
+						Vertices: map[string]*topology.Vertex{
+							"hpc1": {
+								ID:   "hpc1",
+								Name: "Switch.2.1",
+								Vertices: map[string]*topology.Vertex{
+									"nb1": {
+										ID:       "nb1",
+										Name:     "Switch.1.1",
+										Vertices: map[string]*topology.Vertex{"i1": n1, "i2": n2},
+									},
+									"nb2": {
+										ID:       "nb2",
+										Name:     "Switch.1.2",
+										Vertices: map[string]*topology.Vertex{"i3": n3, "i4": n4},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "Case 3: sporadic missing local block with parent_to_spine policy",
+			hosts: []*core.ComputeBareMetalHostSummary{
+				newHostSummary("i1", "lb1", "nb1", "hpc1"),
+				newHostSummary("i2", "lb1", "nb1", "hpc1"),
+				newHostSummary("i3", "lb2", "nb2", "hpc1"),
+				newHostSummary("i4", "", "nb2", "hpc1"),
+			},
+			policy: topology.MissingBlockParentToSpine,
+			expected: &topology.Vertex{
+				Vertices: map[string]*topology.Vertex{
+					topology.TopologyTree: {
+						Vertices: map[string]*topology.Vertex{
+							"hpc1": {
+								ID:   "hpc1",
+								Name: "Switch.3.1",
+								Vertices: map[string]*topology.Vertex{
+									"nb1": {
+										ID:   "nb1",
+										Name: "Switch.2.1",
+										Vertices: map[string]*topology.Vertex{
+											"lb1": {
+												ID:       "lb1",
+												Name:     "Switch.1.1",
+												Vertices: map[string]*topology.Vertex{"i1": n1, "i2": n2},
+											},
+										},
+									},
+									"nb2": {
+										ID:   "nb2",
+										Name: "Switch.2.2",
+										Vertices: map[string]*topology.Vertex{
+											"i4": n4,
+											"lb2": {
+												ID:       "lb2",
+												Name:     "Switch.1.2",
+												Vertices: map[string]*topology.Vertex{"i3": n3},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "Case 4: sporadic missing local block with synthetic_block policy",
+			hosts: []*core.ComputeBareMetalHostSummary{
+				newHostSummary("i1", "lb1", "nb1", "hpc1"),
+				newHostSummary("i2", "lb1", "nb1", "hpc1"),
+				newHostSummary("i3", "lb2", "nb2", "hpc1"),
+				newHostSummary("i4", "", "nb2", "hpc1"),
+			},
+			policy: topology.MissingBlockSyntheticBlock,
+			expected: &topology.Vertex{
+				Vertices: map[string]*topology.Vertex{
+					topology.TopologyTree: {
+						Vertices: map[string]*topology.Vertex{
+							"hpc1": {
+								ID:   "hpc1",
+								Name: "Switch.3.1",
+								Vertices: map[string]*topology.Vertex{
+									"nb1": {
+										ID:   "nb1",
+										Name: "Switch.2.1",
+										Vertices: map[string]*topology.Vertex{
+											"lb1": {
+												ID:       "lb1",
+												Name:     "Switch.1.1",
+												Vertices: map[string]*topology.Vertex{"i1": n1, "i2": n2},
+											},
+										},
+									},
+									"nb2": {
+										ID:   "nb2",
+										Name: "Switch.2.2",
+										Vertices: map[string]*topology.Vertex{
+											"nb2_nil": {
+												ID:       "nb2_nil",
+												Name:     "Switch.1.2",
+												Vertices: map[string]*topology.Vertex{"i4": n4},
+											},
+											"lb2": {
+												ID:       "lb2",
+												Name:     "Switch.1.3",
+												Vertices: map[string]*topology.Vertex{"i3": n3},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			root, err := toGraph(tc.hosts, cis, DefaultLocalBlockThreshold, tc.policy, "")
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, root)
+		})
+	}
+}
+
+func TestToGraphPartialTopology(t *testing.T) {
+	cis := []topology.ComputeInstances{
+		{
+			Instances: map[string]string{
+				"i1": "node1",
+				"i2": "node2",
+				"i3": "node3",
+				"i4": "node4",
+			},
+		},
+	}
+
+	n1 := &topology.Vertex{ID: "i1", Name: "node1"}
+	n2 := &topology.Vertex{ID: "i2", Name: "node2"}
+	n3 := &topology.Vertex{ID: "i3", Name: "node3"}
+	n4 := &topology.Vertex{ID: "i4", Name: "node4"}
+
+	// tree returns the graph of the hosts i1 and i2 under lb1/nb1/hpc1, extended by the given switches
+	tree := func(hpc1, nb1, lb1 map[string]*topology.Vertex, forest map[string]*topology.Vertex) *topology.Vertex {
+		lb1["i1"], lb1["i2"] = n1, n2
+		nb1["lb1"] = &topology.Vertex{ID: "lb1", Name: "Switch.1.1", Vertices: lb1}
+		hpc1["nb1"] = &topology.Vertex{ID: "nb1", Name: "Switch.2.1", Vertices: nb1}
+		forest["hpc1"] = &topology.Vertex{ID: "hpc1", Name: "Switch.3.1", Vertices: hpc1}
+		return &topology.Vertex{
+			Vertices: map[string]*topology.Vertex{
+				topology.TopologyTree: {Vertices: forest},
+			},
+		}
+	}
+
+	testCases := []struct {
+		name     string
+		hosts    []*core.ComputeBareMetalHostSummary
+		expected *topology.Vertex
+	}{
+		{
+			name: "Case 1: missing network block",
+			hosts: []*core.ComputeBareMetalHostSummary{
+				newHostSummary("i1", "lb1", "nb1", "hpc1"),
+				newHostSummary("i2", "lb1", "nb1", "hpc1"),
+				newHostSummary("i3", "lb2", "", "hpc1"),
+				newHostSummary("i4", "", "", "hpc1"),
+			},
+			expected: tree(
+				map[string]*topology.Vertex{
+					"i4":  n4,
+					"lb2": {ID: "lb2", Name: "Switch.1.2", Vertices: map[string]*topology.Vertex{"i3": n3}},
+				},
+				map[string]*topology.Vertex{},
+				map[string]*topology.Vertex{},
+				map[string]*topology.Vertex{},
+			),
+		},
+		{
+			name: "Case 2: missing HPC island",
+			hosts: []*core.ComputeBareMetalHostSummary{
+				newHostSummary("i1", "lb1", "nb1", "hpc1"),
+				newHostSummary("i2", "lb1", "nb1", "hpc1"),
+				newHostSummary("i3", "lb2", "nb1", ""),
+				newHostSummary("i4", "lb3", "nb2", ""),
+			},
+			expected: tree(
+				map[string]*topology.Vertex{},
+				map[string]*topology.Vertex{
+					"lb2": {ID: "lb2", Name: "Switch.1.2", Vertices: map[string]*topology.Vertex{"i3": n3}},
+				},
+				map[string]*topology.Vertex{},
+				map[string]*topology.Vertex{
+					"nb2": {
+						ID:   "nb2",
+						Name: "Switch.2.2",
+						Vertices: map[string]*topology.Vertex{
+							"lb3": {ID: "lb3", Name: "Switch.1.3", Vertices: map[string]*topology.Vertex{"i4": n4}},
+						},
+					},
+				},
+			),
+		},
+		{
+			name: "Case 3: missing local block and HPC island",
+			hosts: []*core.ComputeBareMetalHostSummary{
+				newHostSummary("i1", "lb1", "nb1", "hpc1"),
+				newHostSummary("i2", "lb1", "nb1", "hpc1"),
+				newHostSummary("i3", "", "nb2", ""),
+				newHostSummary("i4", "", "nb1", ""),
+			},
+			expected: tree(
+				map[string]*topology.Vertex{},
+				map[string]*topology.Vertex{"i4": n4},
+				map[string]*topology.Vertex{},
+				map[string]*topology.Vertex{
+					"nb2": {ID: "nb2", Name: "Switch.2.2", Vertices: map[string]*topology.Vertex{"i3": n3}},
+				},
+			),
+		},
+		{
+			name: "Case 4: missing network block and HPC island",
+			hosts: []*core.ComputeBareMetalHostSummary{
+				newHostSummary("i1", "lb1", "nb1", "hpc1"),
+				newHostSummary("i2", "lb1", "nb1", "hpc1"),
+				newHostSummary("i3", "lb1", "", ""),
+				newHostSummary("i4", "lb3", "", ""),
+			},
+			expected: tree(
+				map[string]*topology.Vertex{},
+				map[string]*topology.Vertex{},
+				map[string]*topology.Vertex{"i3": n3},
+				map[string]*topology.Vertex{
+					"lb3": {ID: "lb3", Name: "Switch.1.2", Vertices: map[string]*topology.Vertex{"i4": n4}},
+				},
+			),
+		},
+		{
+			name: "Case 5: missing all ancestors",
+			hosts: []*core.ComputeBareMetalHostSummary{
+				newHostSummary("i1", "lb1", "nb1", "hpc1"),
+				newHostSummary("i2", "lb1", "nb1", "hpc1"),
+				newHostSummary("i3", "", "", ""),
+				newHostSummary("i4", "", "", ""),
+			},
+			expected: tree(
+				map[string]*topology.Vertex{},
+				map[string]*topology.Vertex{},
+				map[string]*topology.Vertex{},
+				map[string]*topology.Vertex{
+					topology.NoTopology: {
+						ID:       topology.NoTopology,
+						Vertices: map[string]*topology.Vertex{"i3": n3, "i4": n4},
+					},
+				},
+			),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			root, err := toGraph(tc.hosts, cis, DefaultLocalBlockThreshold, topology.MissingBlockNoTopology, "")
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, root)
+		})
+	}
+}
+
+func TestMissingAncestorPlacement(t *testing.T) {
+	cis := []topology.ComputeInstances{
+		{
+			Instances: map[string]string{
+				"m1": "node1",
+				"m2": "node2",
+				"m3": "node3",
+				"m4": "node4",
+			},
+		},
+	}
+
+	hosts := []*core.ComputeBareMetalHostSummary{
+		newHostSummary("m1", "lb1", "nb1", "hpc1"),
+		newHostSummary("m2", "", "nb1", "hpc1"),
+		newHostSummary("m3", "lb1", "", "hpc1"),
+		newHostSummary("m4", "", "", ""),
+	}
+
+	_, err := toGraph(hosts, cis, DefaultLocalBlockThreshold, topology.MissingBlockNoTopology, "")
+	require.NoError(t, err)
+
+	testCases := []struct {
+		ancestor  string
+		node      string
+		placement string
+		count     float64
+	}{
+		{ancestor: "localBlock", node: "m1", placement: placementPolicy, count: 0},
+		{ancestor: "localBlock", node: "m2", placement: placementPolicy, count: 1},
+		{ancestor: "networkBlock", node: "m3", placement: placementPartial, count: 1},
+		{ancestor: "localBlock", node: "m4", placement: placementNone, count: 1},
+		{ancestor: "networkBlock", node: "m4", placement: placementNone, count: 1},
+		{ancestor: "hpcIsland", node: "m4", placement: placementNone, count: 1},
+	}
+
+	for _, tc := range testCases {
+		require.Equal(t, tc.count, testutil.ToFloat64(missingAncestor.WithLabelValues(tc.ancestor, tc.node, tc.placement)), tc.ancestor+"/"+tc.node)
+	}
+}
+
+func TestToGraphDeterminism(t *testing.T) {
+	cis := []topology.ComputeInstances{
+		{
+			Instances: map[string]string{
+				"i1": "node1",
+				"i2": "node2",
+				"i3": "node3",
+				"i4": "node4",
+				"i5": "node5",
+				"i6": "node6",
+			},
+		},
+	}
+
+	hosts := []*core.ComputeBareMetalHostSummary{
+		newHostSummary("i1", "lb1", "nb1", "hpc1"),
+		newHostSummary("i2", "lb2", "nb1", "hpc1"),
+		newHostSummary("i3", "lb3", "nb2", "hpc1"),
+		newHostSummary("i4", "lb4", "nb3", "hpc2"),
+		newHostSummary("i5", "", "nb3", "hpc2"),
+		newHostSummary("i6", "lb1", "nb1", "hpc1"),
+		// the host moved between the pages of consecutive sweeps and is listed twice
+		newHostSummary("i3", "lb4", "nb3", "hpc2"),
+	}
+
+	var expected string
+	for i := 0; i < 10; i++ {
+		shuffled := make([]*core.ComputeBareMetalHostSummary, len(hosts))
+		copy(shuffled, hosts)
+		rnd := rand.New(rand.NewSource(int64(i)))
+		rnd.Shuffle(len(shuffled), func(a, b int) { shuffled[a], shuffled[b] = shuffled[b], shuffled[a] })
+
+		root, err := toGraph(shuffled, cis, DefaultLocalBlockThreshold, topology.MissingBlockSyntheticBlock, "")
+		require.NoError(t, err)
+		buf := &bytes.Buffer{}
+		require.NoError(t, translate.Write(context.TODO(), buf, root))
+
+		if i == 0 {
+			expected = buf.String()
+			continue
+		}
+		require.Equal(t, expected, buf.String())
+	}
+	require.Contains(t, expected, "Nodes=node3\n")
+}
+
+const bmhMethod = "ListComputeCapacityTopologyComputeBareMetalHosts"
+
+type serviceError struct {
+	code int
+}
+
+func (e *serviceError) Error() string           { return http.StatusText(e.code) }
+func (e *serviceError) GetHTTPStatusCode() int  { return e.code }
+func (e *serviceError) GetMessage() string      { return http.StatusText(e.code) }
+func (e *serviceError) GetCode() string         { return http.StatusText(e.code) }
+func (e *serviceError) GetOpcRequestID() string { return "" }
+
+// pagingClient serves two pages of bare metal hosts, failing the requests of the second page
+// with the given status codes before serving it. If set, cancel is called after the first page.
+type pagingClient struct {
+	failures []int
+	calls    int
+	cancel   context.CancelFunc
+	// limits holds the page size limit of each request
+	limits []*int
+}
+
+func (c *pagingClient) TenancyOCID() string { return "tenancy" }
+
+func (c *pagingClient) ListAvailabilityDomains(context.Context, identity.ListAvailabilityDomainsRequest) (identity.ListAvailabilityDomainsResponse, error) {
+	return identity.ListAvailabilityDomainsResponse{}, nil
+}
+
+func (c *pagingClient) ListComputeCapacityTopologies(context.Context, core.ListComputeCapacityTopologiesRequest) (core.ListComputeCapacityTopologiesResponse, error) {
+	return core.ListComputeCapacityTopologiesResponse{}, nil
+}
+
+func (c *pagingClient) ListComputeCapacityTopologyComputeBareMetalHosts(_ context.Context, request core.ListComputeCapacityTopologyComputeBareMetalHostsRequest) (core.ListComputeCapacityTopologyComputeBareMetalHostsResponse, error) {
+	c.calls++
+	c.limits = append(c.limits, request.Limit)
+	if request.Page == nil {
+		if c.cancel != nil {
+			c.cancel()
+		}
+		return core.ListComputeCapacityTopologyComputeBareMetalHostsResponse{
+			RawResponse: &http.Response{StatusCode: http.StatusOK, Status: "200 OK"},
+			ComputeBareMetalHostCollection: core.ComputeBareMetalHostCollection{
+				Items: []core.ComputeBareMetalHostSummary{*newHostSummary("i1", "lb1", "nb1", "hpc1")},
+			},
+			OpcNextPage: OCICommon.String("page2"),
+		}, nil
+	}
+
+	if len(c.failures) != 0 {
+		code := c.failures[0]
+		c.failures = c.failures[1:]
+		return core.ListComputeCapacityTopologyComputeBareMetalHostsResponse{}, &serviceError{code: code}
+	}
+
+	return core.ListComputeCapacityTopologyComputeBareMetalHostsResponse{
+		RawResponse: &http.Response{StatusCode: http.StatusOK, Status: "200 OK"},
+		ComputeBareMetalHostCollection: core.ComputeBareMetalHostCollection{
+			Items: []core.ComputeBareMetalHostSummary{*newHostSummary("i2", "lb1", "nb1", "hpc1")},
+		},
+	}, nil
+}
+
+func TestGetBMHSummaryRetries(t *testing.T) {
+	defer func(delay time.Duration) { retryBaseDelay = delay }(retryBaseDelay)
+	retryBaseDelay = time.Millisecond
+
+	testCases := []struct {
+		name     string
+		failures []int
+		retries  int
+		hosts    int
+		calls    int
+		retried  float64
+		failed   float64
+		err      string
+	}{
+		{
+			name:  "Case 1: no failures",
+			hosts: 2,
+			calls: 2,
+		},
+		{
+			name:     "Case 2: transient failures of the second page",
+			failures: []int{http.StatusTooManyRequests, http.StatusServiceUnavailable},
+			retries:  3,
+			hosts:    2,
+			calls:    4,
+			retried:  2,
+		},
+		{
+			name:     "Case 3: persistent failure of the second page",
+			failures: []int{http.StatusInternalServerError, http.StatusInternalServerError, http.StatusInternalServerError},
+			retries:  2,
+			calls:    4,
+			retried:  2,
+			failed:   1,
+			err:      "provider API error: ListComputeCapacityTopologyComputeBareMetalHosts failed after 2 retries: Internal Server Error",
+		},
+		{
+			name:     "Case 4: non-transient failure of the second page",
+			failures: []int{http.StatusNotFound},
+			retries:  3,
+			calls:    2,
+			failed:   1,
+			err:      "provider API error: ListComputeCapacityTopologyComputeBareMetalHosts: Not Found",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			pageErrors.Reset()
+			client := &pagingClient{failures: tc.failures}
+
+			hosts, err := getBMHSummaryPerComputeCapacityTopology(context.TODO(), client, "cct", tc.retries, 0)
+			if len(tc.err) != 0 {
+				require.ErrorIs(t, err, providers.ErrProviderAPI)
+				require.EqualError(t, err, tc.err)
+				require.Nil(t, hosts)
+			} else {
+				require.NoError(t, err)
+				require.Len(t, hosts, tc.hosts)
+			}
+			require.Equal(t, tc.calls, client.calls)
+			require.Equal(t, tc.retried, testutil.ToFloat64(pageErrors.WithLabelValues(bmhMethod, "retried")))
+			require.Equal(t, tc.failed, testutil.ToFloat64(pageErrors.WithLabelValues(bmhMethod, "failed")))
+		})
+	}
+}
+
+func TestGetBMHSummaryPageSize(t *testing.T) {
+	testCases := []struct {
+		name     string
+		pageSize int
+		limit    *int
+	}{
+		{
+			name: "Case 1: service default",
+		},
+		{
+			name:     "Case 2: page size",
+			pageSize: 1,
+			limit:    OCICommon.Int(1),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := &pagingClient{}
+			hosts, err := getBMHSummaryPerComputeCapacityTopology(context.TODO(), client, "cct", 0, tc.pageSize)
+			require.NoError(t, err)
+			require.Len(t, hosts, 2)
+			require.Equal(t, []*int{tc.limit, tc.limit}, client.limits)
+		})
+	}
+}
+
+func TestGetBMHSummaryCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	client := &pagingClient{
+		failures: []int{http.StatusServiceUnavailable},
+		cancel:   cancel,
+	}
+
+	start := time.Now()
+	hosts, err := getBMHSummaryPerComputeCapacityTopology(ctx, client, "cct", DefaultAPIRetries, 0)
+	require.ErrorIs(t, err, context.Canceled)
+	require.Nil(t, hosts)
+	// the second page is not requested, and no retry delay is observed
+	require.Equal(t, 1, client.calls)
+	require.Less(t, time.Since(start), retryBaseDelay)
+}
+
+// regionClient serves the bare metal hosts of a single region from one availability domain
+type regionClient struct {
+	hosts []core.ComputeBareMetalHostSummary
+}
+
+func (c *regionClient) TenancyOCID() string { return "tenancy" }
+
+func (c *regionClient) ListAvailabilityDomains(context.Context, identity.ListAvailabilityDomainsRequest) (identity.ListAvailabilityDomainsResponse, error) {
+	return identity.ListAvailabilityDomainsResponse{
+		RawResponse: &http.Response{StatusCode: http.StatusOK, Status: "200 OK"},
+		Items:       []identity.AvailabilityDomain{{Name: OCICommon.String("ad1")}},
+	}, nil
+}
+
+func (c *regionClient) ListComputeCapacityTopologies(context.Context, core.ListComputeCapacityTopologiesRequest) (core.ListComputeCapacityTopologiesResponse, error) {
+	return core.ListComputeCapacityTopologiesResponse{
+		RawResponse: &http.Response{StatusCode: http.StatusOK, Status: "200 OK"},
+		ComputeCapacityTopologyCollection: core.ComputeCapacityTopologyCollection{
+			Items: []core.ComputeCapacityTopologySummary{{Id: OCICommon.String("cct1")}},
+		},
+	}, nil
+}
+
+func (c *regionClient) ListComputeCapacityTopologyComputeBareMetalHosts(context.Context, core.ListComputeCapacityTopologyComputeBareMetalHostsRequest) (core.ListComputeCapacityTopologyComputeBareMetalHostsResponse, error) {
+	return core.ListComputeCapacityTopologyComputeBareMetalHostsResponse{
+		RawResponse: &http.Response{StatusCode: http.StatusOK, Status: "200 OK"},
+		ComputeBareMetalHostCollection: core.ComputeBareMetalHostCollection{
+			Items: c.hosts,
+		},
+	}, nil
+}
+
+func TestRegionConcurrency(t *testing.T) {
+	clients := map[string]*regionClient{
+		"region1": {hosts: []core.ComputeBareMetalHostSummary{
+			*newHostSummary("i1", "lb1", "nb1", "hpc1"),
+			*newHostSummary("i2", "lb1", "nb1", "hpc1"),
+		}},
+		"region2": {hosts: []core.ComputeBareMetalHostSummary{
+			*newHostSummary("i3", "lb2", "nb1", "hpc1"),
+		}},
+		"region3": {hosts: []core.ComputeBareMetalHostSummary{
+			*newHostSummary("i4", "lb3", "nb2", "hpc1"),
+			*newHostSummary("i5", "lb3", "nb2", "hpc1"),
+		}},
+		"region4": {hosts: []core.ComputeBareMetalHostSummary{
+			*newHostSummary("i6", "lb4", "nb3", "hpc2"),
+		}},
+	}
+	factory := func(region string) (Client, error) {
+		return clients[region], nil
+	}
+
+	cis := []topology.ComputeInstances{
+		{Region: "region1", Instances: map[string]string{"i1": "node1", "i2": "node2"}},
+		{Region: "region2", Instances: map[string]string{"i3": "node3"}},
+		{Region: "region3", Instances: map[string]string{"i4": "node4", "i5": "node5"}},
+		{Region: "region4", Instances: map[string]string{"i6": "node6"}},
+	}
+
+	generate := func(concurrency int) ([]*core.ComputeBareMetalHostSummary, string) {
+		hosts, err := GenerateInstanceTopology(context.TODO(), factory, 0, 0, concurrency, cis)
+		require.NoError(t, err)
+
+		root, err := toGraph(hosts, cis, DefaultLocalBlockThreshold, topology.MissingBlockNoTopology, "")
+		require.NoError(t, err)
+
+		buf := &bytes.Buffer{}
+		err = translate.Write(context.TODO(), buf, root)
+		require.NoError(t, err)
+		return hosts, buf.String()
+	}
+
+	// the sequential path is the reference
+	sequentialHosts, sequential := generate(1)
+	require.Len(t, sequentialHosts, 6)
+
+	for _, concurrency := range []int{0, 2, 4} {
+		hosts, config := generate(concurrency)
+		require.Equal(t, sequentialHosts, hosts, "concurrency %d", concurrency)
+		require.Equal(t, sequential, config, "concurrency %d", concurrency)
+	}
+}
+
+func TestGroupUnplaced(t *testing.T) {
+	cis := []topology.ComputeInstances{
+		{
+			Instances: map[string]string{
+				"i1": "gpu-001",
+				"i2": "gpu-002",
+				"i3": "cpu-001",
+				"i4": "cpu-002",
+			},
+		},
+	}
+	hosts := []*core.ComputeBareMetalHostSummary{
+		newHostSummary("i1", "lb1", "nb1", "hpc1"),
+		newHostSummary("i2", "", "", ""),
+	}
+
+	root, err := toGraph(hosts, cis, DefaultLocalBlockThreshold, topology.MissingBlockNoTopology, topology.GroupUnplacedPrefix)
+	require.NoError(t, err)
+
+	treeRoot := root.Vertices[topology.TopologyTree]
+	require.Equal(t, &topology.Vertex{
+		ID:       "no-topology-gpu",
+		Vertices: map[string]*topology.Vertex{"i2": {ID: "i2", Name: "gpu-002"}},
+	}, treeRoot.Vertices["no-topology-gpu"])
+	require.Equal(t, &topology.Vertex{
+		ID: "no-topology-cpu",
+		Vertices: map[string]*topology.Vertex{
+			"i3": {ID: "i3", Name: "cpu-001"},
+			"i4": {ID: "i4", Name: "cpu-002"},
+		},
+	}, treeRoot.Vertices["no-topology-cpu"])
+	require.NotContains(t, treeRoot.Vertices, topology.NoTopology)
+}
@@ -36,10 +36,30 @@ var missingAncestor = prometheus.NewCounterVec(
 		Help:      "Missing ancestor nodes",
 		Subsystem: "topograph_oci",
 	},
-	[]string{"ancestor_level", "node_name"},
+	[]string{"ancestor_level", "node_name", "placement"},
+)
+
+// placement values of the missing ancestor metric
+const (
+	// the host is placed according to the missing block policy
+	placementPolicy = "policy"
+	// the host is attached to the lowest known switch
+	placementPartial = "partial"
+	// the host is placed under the no-topology switch
+	placementNone = "none"
+)
+
+var pageErrors = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name:      "page_errors_total",
+		Help:      "Pages of paginated requests that were retried or failed",
+		Subsystem: "topograph_oci",
+	},
+	[]string{"method", "result"},
 )
 
 func init() {
 	prometheus.MustRegister(requestLatency)
 	prometheus.MustRegister(missingAncestor)
+	prometheus.MustRegister(pageErrors)
 }
@@ -27,14 +27,33 @@ import (
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/klog/v2"
 
+	"github.com/NVIDIA/topograph/internal/config"
 	"github.com/NVIDIA/topograph/pkg/providers"
 	"github.com/NVIDIA/topograph/pkg/topology"
 )
 
 const NAME = "oci"
 
+// DefaultLocalBlockThreshold is the default fraction of hosts with missing local block,
+// above which the local block tier is considered systematically absent
+const DefaultLocalBlockThreshold = 0.9
+
+// DefaultAPIRetries is the default number of retries of a page request failing with a transient error
+const DefaultAPIRetries = 5
+
 type Provider struct {
 	clientFactory ClientFactory
+	params        *Params
+}
+
+type Params struct {
+	LocalBlockThreshold float64 `mapstructure:"local_block_threshold"`
+	MissingBlockPolicy  string  `mapstructure:"missing_block_policy"`
+	GroupUnplacedBy     string  `mapstructure:"group_unplaced_by"`
+	APIRetries          int     `mapstructure:"api_retries"`
+
+	providers.PageParams   `mapstructure:",squash"`
+	providers.RegionParams `mapstructure:",squash"`
 }
 
 type ClientFactory func(region string) (Client, error)
@@ -60,8 +79,13 @@ func NamedLoader() (string, providers.Loader) {
 	return NAME, Loader
 }
 
-func Loader(ctx context.Context, config providers.Config) (providers.Provider, error) {
-	provider, err := getConfigurationProvider(config.Creds)
+func Loader(ctx context.Context, cfg providers.Config) (providers.Provider, error) {
+	p, err := getParams(cfg.Params)
+	if err != nil {
+		return nil, err
+	}
+
+	provider, err := getConfigurationProvider(cfg.Creds)
 	if err != nil {
 		return nil, err
 	}
@@ -95,7 +119,39 @@ func Loader(ctx context.Context, config providers.Config) (providers.Provider, e
 		}, nil
 	}
 
-	return New(clientFactory), nil
+	return New(clientFactory, p), nil
+}
+
+// DefaultParams returns the parameters with their defaults
+func DefaultParams() Params {
+	return Params{LocalBlockThreshold: DefaultLocalBlockThreshold, APIRetries: DefaultAPIRetries}
+}
+
+func getParams(params map[string]any) (*Params, error) {
+	p := DefaultParams()
+	if err := config.Decode(params, &p); err != nil {
+		return nil, fmt.Errorf("error decoding params: %w", err)
+	}
+	if p.LocalBlockThreshold < 0 || p.LocalBlockThreshold > 1 {
+		return nil, fmt.Errorf("local_block_threshold must be between 0 and 1")
+	}
+	if p.APIRetries < 0 {
+		return nil, fmt.Errorf("api_retries must not be negative")
+	}
+	if err := topology.ValidateMissingBlockPolicy(p.MissingBlockPolicy); err != nil {
+		return nil, err
+	}
+	if err := topology.ValidateGroupUnplaced(p.GroupUnplacedBy); err != nil {
+		return nil, err
+	}
+	if err := p.PageParams.Validate(); err != nil {
+		return nil, err
+	}
+	if err := p.RegionParams.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &p, nil
 }
 
 func getConfigurationProvider(creds map[string]string) (OCICommon.ConfigurationProvider, error) {
@@ -139,19 +195,33 @@ func getConfigurationProvider(creds map[string]string) (OCICommon.ConfigurationP
 	return configProvider, nil
 }
 
-func New(ociClientFactory ClientFactory) *Provider {
+func New(ociClientFactory ClientFactory, params *Params) *Provider {
 	return &Provider{
 		clientFactory: ociClientFactory,
+		params:        params,
+	}
+}
+
+// HealthCheck implements providers.HealthChecker
+func (p *Provider) HealthCheck(ctx context.Context) error {
+	client, err := p.clientFactory("")
+	if err != nil {
+		return err
 	}
+
+	compartmentId := client.TenancyOCID()
+	_, err = client.ListAvailabilityDomains(ctx, identity.ListAvailabilityDomainsRequest{CompartmentId: &compartmentId})
+	return err
 }
 
-func (p *Provider) GenerateTopologyConfig(ctx context.Context, _ *int, instances []topology.ComputeInstances) (*topology.Vertex, error) {
-	cfg, err := GenerateInstanceTopology(ctx, p.clientFactory, instances)
+func (p *Provider) GenerateTopologyConfig(ctx context.Context, pageSize *int, instances []topology.ComputeInstances) (*topology.Vertex, error) {
+	size := providers.GetPageSize(p.params.PageSize, pageSize, 0)
+	cfg, err := GenerateInstanceTopology(ctx, p.clientFactory, p.params.APIRetries, size, p.params.RegionConcurrency, instances)
 	if err != nil {
 		return nil, err
 	}
 
-	return toGraph(cfg, instances)
+	return toGraph(cfg, instances, p.params.LocalBlockThreshold, p.params.MissingBlockPolicy, p.params.GroupUnplacedBy)
 }
 
 // Engine support
@@ -166,6 +236,11 @@ func (p *Provider) GetComputeInstancesRegion() (string, error) {
 	return "", nil
 }
 
+// GetInstancesRegions implements slurm.regionMapper
+func (p *Provider) GetInstancesRegions(ctx context.Context, nodes []string) (map[string]string, error) {
+	return instanceToRegionMap(ctx, nodes)
+}
+
 // GetNodeRegion implements k8s.k8sNodeInfo
 func (p *Provider) GetNodeRegion(node *v1.Node) (string, error) {
 	return node.Labels["topology.kubernetes.io/region"], nil
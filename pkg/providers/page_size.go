@@ -0,0 +1,61 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package providers
+
+import (
+	"fmt"
+
+	"github.com/NVIDIA/topograph/internal/config"
+)
+
+// PageParams holds the page size parameter of the providers with paginated API requests.
+// The providers embed it in their parameters with the `mapstructure:",squash"` tag.
+type PageParams struct {
+	// PageSize is the number of items per page of the provider API requests
+	PageSize int `mapstructure:"page_size"`
+}
+
+func GetPageParams(params map[string]any) (*PageParams, error) {
+	var p PageParams
+	if err := config.Decode(params, &p); err != nil {
+		return nil, fmt.Errorf("error decoding params: %w", err)
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &p, nil
+}
+
+func (p *PageParams) Validate() error {
+	if p.PageSize < 0 {
+		return fmt.Errorf("page_size must be positive")
+	}
+	return nil
+}
+
+// GetPageSize returns the page size of the provider API requests. The page_size parameter
+// of the request takes precedence over the server page size, which takes precedence over
+// the provider default.
+func GetPageSize(paramSize int, serverSize *int, defaultSize int) int {
+	if paramSize > 0 {
+		return paramSize
+	}
+	if serverSize != nil && *serverSize > 0 {
+		return *serverSize
+	}
+	return defaultSize
+}
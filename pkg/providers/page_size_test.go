@@ -0,0 +1,107 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package providers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetPageParams(t *testing.T) {
+	testCases := []struct {
+		name   string
+		params map[string]any
+		page   *PageParams
+		err    string
+	}{
+		{
+			name:   "Case 1: no input",
+			params: nil,
+			page:   &PageParams{},
+		},
+		{
+			name:   "Case 2: valid input",
+			params: map[string]any{"page_size": 50},
+			page:   &PageParams{PageSize: 50},
+		},
+		{
+			name:   "Case 3: string input",
+			params: map[string]any{"page_size": "50"},
+			page:   &PageParams{PageSize: 50},
+		},
+		{
+			name:   "Case 4: negative page size",
+			params: map[string]any{"page_size": -1},
+			err:    "page_size must be positive",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			page, err := GetPageParams(tc.params)
+			if len(tc.err) != 0 {
+				require.EqualError(t, err, tc.err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tc.page, page)
+			}
+		})
+	}
+}
+
+func TestGetPageSize(t *testing.T) {
+	intPtr := func(n int) *int { return &n }
+
+	testCases := []struct {
+		name       string
+		paramSize  int
+		serverSize *int
+		expected   int
+	}{
+		{
+			name:     "Case 1: provider default",
+			expected: 100,
+		},
+		{
+			name:       "Case 2: server page size",
+			serverSize: intPtr(20),
+			expected:   20,
+		},
+		{
+			name:       "Case 3: request parameter over server page size",
+			paramSize:  5,
+			serverSize: intPtr(20),
+			expected:   5,
+		},
+		{
+			name:      "Case 4: request parameter",
+			paramSize: 5,
+			expected:  5,
+		},
+		{
+			name:       "Case 5: unset server page size",
+			serverSize: intPtr(0),
+			expected:   100,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, GetPageSize(tc.paramSize, tc.serverSize, 100))
+		})
+	}
+}
@@ -22,6 +22,7 @@ import (
 	"fmt"
 
 	"github.com/NVIDIA/topograph/internal/component"
+	"github.com/NVIDIA/topograph/pkg/models"
 	"github.com/NVIDIA/topograph/pkg/topology"
 )
 
@@ -29,9 +30,18 @@ type Provider interface {
 	GenerateTopologyConfig(ctx context.Context, pageSize *int, instances []topology.ComputeInstances) (*topology.Vertex, error)
 }
 
+// HealthChecker is implemented by the providers able to validate their credentials
+// and API access with a cheap API request
+type HealthChecker interface {
+	HealthCheck(ctx context.Context) error
+}
+
 type Config struct {
 	Creds  map[string]string
 	Params map[string]any
+
+	// Models limits the model files loaded by the simulation providers
+	Models *models.Limits
 }
 type NamedLoader = component.NamedLoader[Provider, Config]
 type Loader = component.Loader[Provider, Config]
@@ -39,10 +49,18 @@ type Registry component.Registry[Provider, Config]
 
 var ErrUnsupportedProvider = errors.New("unsupported provider")
 
+// ErrProviderAPI is returned when the provider API keeps failing, and the topology cannot be complete
+var ErrProviderAPI = errors.New("provider API error")
+
 func NewRegistry(namedLoaders ...NamedLoader) Registry {
 	return Registry(component.NewRegistry(namedLoaders...))
 }
 
+// Register adds the named loaders to the registry
+func (r Registry) Register(namedLoaders ...NamedLoader) {
+	component.Registry[Provider, Config](r).Register(namedLoaders...)
+}
+
 func (r Registry) Get(name string) (Loader, error) {
 	loader, ok := r[name]
 	if !ok {
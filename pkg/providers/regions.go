@@ -0,0 +1,63 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package providers
+
+import (
+	"context"
+	"fmt"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/NVIDIA/topograph/pkg/topology"
+)
+
+// DefaultRegionConcurrency is the default number of regions whose topology is requested concurrently
+const DefaultRegionConcurrency = 2
+
+// RegionParams holds the region concurrency parameter of the providers requesting the topology per region.
+// The providers embed it in their parameters with the `mapstructure:",squash"` tag.
+type RegionParams struct {
+	// RegionConcurrency is the number of regions whose topology is requested concurrently;
+	// 0 selects the default, and 1 requests the regions one after another
+	RegionConcurrency int `mapstructure:"region_concurrency"`
+}
+
+func (p *RegionParams) Validate() error {
+	if p.RegionConcurrency < 0 {
+		return fmt.Errorf("region_concurrency must be positive")
+	}
+	return nil
+}
+
+// ForEachRegion calls fn for the compute instances of each region, with up to concurrency calls at a time,
+// and returns the first error. The context passed to fn is cancelled once a call fails.
+// The callers collect the results by the index of the region, so that they do not depend on the scheduling.
+func ForEachRegion(ctx context.Context, cis []topology.ComputeInstances, concurrency int,
+	fn func(ctx context.Context, i int, ci *topology.ComputeInstances) error) error {
+	if concurrency <= 0 {
+		concurrency = DefaultRegionConcurrency
+	}
+
+	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(concurrency)
+	for i := range cis {
+		g.Go(func() error {
+			return fn(ctx, i, &cis[i])
+		})
+	}
+	return g.Wait()
+}
@@ -0,0 +1,100 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package providers
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/NVIDIA/topograph/pkg/topology"
+)
+
+func TestForEachRegion(t *testing.T) {
+	cis := []topology.ComputeInstances{{Region: "r1"}, {Region: "r2"}, {Region: "r3"}, {Region: "r4"}, {Region: "r5"}}
+
+	testCases := []struct {
+		name        string
+		concurrency int
+		peak        int
+	}{
+		{
+			name: "Case 1: default concurrency",
+			peak: DefaultRegionConcurrency,
+		},
+		{
+			name:        "Case 2: sequential",
+			concurrency: 1,
+			peak:        1,
+		},
+		{
+			name:        "Case 3: all regions at once",
+			concurrency: 8,
+			peak:        len(cis),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var mutex sync.Mutex
+			var running, peak int
+			regions := make([]string, len(cis))
+			err := ForEachRegion(context.TODO(), cis, tc.concurrency, func(_ context.Context, i int, ci *topology.ComputeInstances) error {
+				mutex.Lock()
+				running++
+				peak = max(peak, running)
+				mutex.Unlock()
+
+				time.Sleep(10 * time.Millisecond)
+				regions[i] = ci.Region
+
+				mutex.Lock()
+				running--
+				mutex.Unlock()
+				return nil
+			})
+			require.NoError(t, err)
+			require.Equal(t, []string{"r1", "r2", "r3", "r4", "r5"}, regions)
+			require.LessOrEqual(t, peak, tc.peak)
+			require.Greater(t, peak, 0)
+		})
+	}
+}
+
+func TestForEachRegionError(t *testing.T) {
+	cis := []topology.ComputeInstances{{Region: "r1"}, {Region: "r2"}, {Region: "r3"}}
+
+	err := ForEachRegion(context.TODO(), cis, 1, func(ctx context.Context, i int, ci *topology.ComputeInstances) error {
+		if ci.Region == "r2" {
+			return errors.New("region r2 failed")
+		}
+		return ctx.Err()
+	})
+	require.EqualError(t, err, "region r2 failed")
+}
+
+func TestRegionParams(t *testing.T) {
+	p := &RegionParams{RegionConcurrency: -1}
+	require.EqualError(t, p.Validate(), "region_concurrency must be positive")
+
+	p.RegionConcurrency = 0
+	require.NoError(t, p.Validate())
+}
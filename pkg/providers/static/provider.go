@@ -0,0 +1,230 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package static
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"github.com/NVIDIA/topograph/internal/config"
+	"github.com/NVIDIA/topograph/internal/httpreq"
+	"github.com/NVIDIA/topograph/pkg/models"
+	"github.com/NVIDIA/topograph/pkg/providers"
+	"github.com/NVIDIA/topograph/pkg/topology"
+)
+
+const NAME = "static"
+
+// regionKey is the switch metadata key holding the region of the nodes below the switch
+const regionKey = "region"
+
+type Provider struct {
+	model *models.Model
+}
+
+type Params struct {
+	// TopologyPath is the file path or the HTTP(S) URL of the topology document in the models format
+	TopologyPath string `mapstructure:"topology_path" validate:"required"`
+	// RefreshInterval is the minimum time between reloads of the topology document.
+	// By default, the document is reloaded on every request.
+	RefreshInterval time.Duration `mapstructure:"refresh_interval"`
+}
+
+// documents caches the topology documents by path across the topology requests
+var documents = &documentCache{
+	entries: make(map[string]*document),
+	now:     time.Now,
+}
+
+// documentCache guards the map of the documents with its mutex, and every document with its own mutex,
+// so that a slow load delays only the requests of the same path
+type documentCache struct {
+	mutex   sync.Mutex
+	entries map[string]*document
+	now     func() time.Time
+}
+
+type document struct {
+	mutex  sync.Mutex
+	model  *models.Model
+	loaded time.Time
+}
+
+func NamedLoader() (string, providers.Loader) {
+	return NAME, Loader
+}
+
+func Loader(ctx context.Context, cfg providers.Config) (providers.Provider, error) {
+	p, err := getParams(cfg.Params)
+	if err != nil {
+		return nil, err
+	}
+
+	model, err := documents.get(ctx, p, cfg.Models)
+	if err != nil {
+		return nil, err
+	}
+
+	return New(model), nil
+}
+
+func getParams(params map[string]any) (*Params, error) {
+	var p Params
+	if err := config.Decode(params, &p); err != nil {
+		return nil, fmt.Errorf("error decoding params: %w", err)
+	}
+	if p.RefreshInterval < 0 {
+		return nil, fmt.Errorf("refresh_interval must not be negative")
+	}
+
+	return &p, nil
+}
+
+func New(model *models.Model) *Provider {
+	return &Provider{model: model}
+}
+
+// get returns the model of the topology document, reloading the document once the refresh interval expires
+func (c *documentCache) get(ctx context.Context, p *Params, limits *models.Limits) (*models.Model, error) {
+	doc := c.document(p.TopologyPath)
+	doc.mutex.Lock()
+	defer doc.mutex.Unlock()
+
+	now := c.now()
+	if doc.model != nil && now.Sub(doc.loaded) < p.RefreshInterval {
+		return doc.model, nil
+	}
+
+	model, err := loadModel(ctx, p.TopologyPath, limits)
+	if err != nil {
+		return nil, err
+	}
+	doc.model, doc.loaded = model, now
+
+	return model, nil
+}
+
+// document returns the document of the path, adding an empty one if missing
+func (c *documentCache) document(path string) *document {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	doc, ok := c.entries[path]
+	if !ok {
+		doc = &document{}
+		c.entries[path] = doc
+	}
+	return doc
+}
+
+// loadModel reads the topology document from the file or the URL
+func loadModel(ctx context.Context, path string, limits *models.Limits) (*models.Model, error) {
+	u, err := url.Parse(path)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		klog.InfoS("Loading static topology", "path", path)
+		return models.NewModelFromFile(path, limits)
+	}
+
+	klog.InfoS("Loading static topology", "url", path)
+	f := func() (*http.Request, error) {
+		return http.NewRequestWithContext(ctx, http.MethodGet, path, nil)
+	}
+	_, data, err := httpreq.DoRequestWithRetries(nil, f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", path, err)
+	}
+
+	model, err := models.NewModelFromData(data, limits)
+	if err != nil {
+		return nil, fmt.Errorf("invalid topology %s: %w", path, err)
+	}
+	return model, nil
+}
+
+// GenerateTopologyConfig returns the topology graph of the document, restricted to the requested instances
+func (p *Provider) GenerateTopologyConfig(_ context.Context, _ *int, instances []topology.ComputeInstances) (*topology.Vertex, error) {
+	root, _ := p.model.ToGraph()
+	if len(instances) == 0 {
+		return root, nil
+	}
+
+	requested := make(map[string]bool)
+	for _, ci := range instances {
+		for instance := range ci.Instances {
+			requested[instance] = true
+		}
+	}
+
+	excluded := make(map[string]bool)
+	for name := range p.model.Nodes {
+		if !requested[name] {
+			excluded[name] = true
+		}
+	}
+	root.ExcludeNodes(excluded)
+
+	return root, nil
+}
+
+// Engine support
+
+// Instances2NodeMap implements slurm.instanceMapper
+func (p *Provider) Instances2NodeMap(ctx context.Context, nodes []string) (map[string]string, error) {
+	i2n := make(map[string]string)
+	for _, node := range nodes {
+		i2n[node] = node
+	}
+
+	return i2n, nil
+}
+
+// GetComputeInstancesRegion implements slurm.instanceMapper.
+// It returns the region of the document if all its nodes share the same region.
+func (p *Provider) GetComputeInstancesRegion() (string, error) {
+	var region string
+	first := true
+	for _, node := range p.model.Nodes {
+		r := node.Metadata[regionKey]
+		if first {
+			region, first = r, false
+		} else if r != region {
+			return "", nil
+		}
+	}
+
+	return region, nil
+}
+
+// GetInstancesRegions implements slurm.regionMapper
+func (p *Provider) GetInstancesRegions(_ context.Context, nodes []string) (map[string]string, error) {
+	regions := make(map[string]string)
+	for _, name := range nodes {
+		if node, ok := p.model.Nodes[name]; ok {
+			if region := node.Metadata[regionKey]; len(region) != 0 {
+				regions[name] = region
+			}
+		}
+	}
+
+	return regions, nil
+}
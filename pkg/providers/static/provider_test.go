@@ -0,0 +1,259 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package static
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/NVIDIA/topograph/pkg/providers"
+	"github.com/NVIDIA/topograph/pkg/topology"
+)
+
+const mediumModel = "../../../tests/models/medium.yaml"
+
+const regionalModel = `
+switches:
+- name: sw1
+  metadata:
+    region: us-east
+  capacity_blocks: [cb1]
+- name: sw2
+  metadata:
+    region: us-west
+  capacity_blocks: [cb2]
+capacity_blocks:
+- name: cb1
+  nodes: [n1, n2]
+- name: cb2
+  nodes: [n3]
+`
+
+func TestLoader(t *testing.T) {
+	testCases := []struct {
+		name   string
+		params map[string]any
+		err    string
+	}{
+		{
+			name: "Case 1: missing topology path",
+			err:  "'TopologyPath' failed on the 'required' tag",
+		},
+		{
+			name:   "Case 2: negative refresh interval",
+			params: map[string]any{"topology_path": mediumModel, "refresh_interval": "-1s"},
+			err:    "refresh_interval must not be negative",
+		},
+		{
+			name:   "Case 3: missing file",
+			params: map[string]any{"topology_path": "missing.yaml"},
+			err:    "failed to read missing.yaml: open missing.yaml: no such file or directory",
+		},
+		{
+			name:   "Case 4: valid file",
+			params: map[string]any{"topology_path": mediumModel},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Loader(context.TODO(), providers.Config{Params: tc.params})
+			if len(tc.err) != 0 {
+				require.ErrorContains(t, err, tc.err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestGenerateTopologyConfig(t *testing.T) {
+	prv, err := Loader(context.TODO(), providers.Config{Params: map[string]any{"topology_path": mediumModel}})
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name      string
+		instances []topology.ComputeInstances
+		nodes     []string
+	}{
+		{
+			name:  "Case 1: all nodes",
+			nodes: []string{"n11-1", "n11-2", "n12-1", "n12-2", "n13-1", "n13-2", "n14-1", "n14-2"},
+		},
+		{
+			name: "Case 2: requested nodes",
+			instances: []topology.ComputeInstances{
+				{Region: "us-west", Instances: map[string]string{"n11-1": "n11-1", "n12-2": "n12-2"}},
+				{Region: "none", Instances: map[string]string{"n14-1": "n14-1", "unknown": "unknown"}},
+			},
+			nodes: []string{"n11-1", "n12-2", "n14-1"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			root, err := prv.GenerateTopologyConfig(context.TODO(), nil, tc.instances)
+			require.NoError(t, err)
+			require.Equal(t, tc.nodes, leaves(root.Vertices[topology.TopologyTree]))
+			require.Equal(t, tc.nodes, leaves(root.Vertices[topology.TopologyBlock]))
+		})
+	}
+}
+
+func TestRegions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "topology.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(regionalModel), 0o644))
+
+	prv, err := Loader(context.TODO(), providers.Config{Params: map[string]any{"topology_path": path}})
+	require.NoError(t, err)
+	p := prv.(*Provider)
+
+	i2n, err := p.Instances2NodeMap(context.TODO(), []string{"n1", "n3"})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"n1": "n1", "n3": "n3"}, i2n)
+
+	// the nodes span two regions
+	region, err := p.GetComputeInstancesRegion()
+	require.NoError(t, err)
+	require.Empty(t, region)
+
+	regions, err := p.GetInstancesRegions(context.TODO(), []string{"n1", "n3", "n4"})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"n1": "us-east", "n3": "us-west"}, regions)
+
+	prv, err = Loader(context.TODO(), providers.Config{Params: map[string]any{"topology_path": mediumModel}})
+	require.NoError(t, err)
+	region, err = prv.(*Provider).GetComputeInstancesRegion()
+	require.NoError(t, err)
+	require.Equal(t, "us-west", region)
+}
+
+func TestRefresh(t *testing.T) {
+	now := time.Now()
+	documents.now = func() time.Time { return now }
+	defer func() { documents.now = time.Now }()
+
+	dir := t.TempDir()
+	write := func(path, nodes string) {
+		data := "switches:\n- name: sw1\n  capacity_blocks: [cb1]\ncapacity_blocks:\n- name: cb1\n  nodes: " + nodes + "\n"
+		require.NoError(t, os.WriteFile(path, []byte(data), 0o644))
+	}
+	load := func(params map[string]any) []string {
+		prv, err := Loader(context.TODO(), providers.Config{Params: params})
+		require.NoError(t, err)
+		root, err := prv.GenerateTopologyConfig(context.TODO(), nil, nil)
+		require.NoError(t, err)
+		return leaves(root.Vertices[topology.TopologyTree])
+	}
+
+	// the file is reloaded on every request by default
+	path := filepath.Join(dir, "default.yaml")
+	write(path, "[n1]")
+	require.Equal(t, []string{"n1"}, load(map[string]any{"topology_path": path}))
+	write(path, "[n1, n2]")
+	require.Equal(t, []string{"n1", "n2"}, load(map[string]any{"topology_path": path}))
+
+	// the file is reloaded once the refresh interval expires
+	path = filepath.Join(dir, "refresh.yaml")
+	params := map[string]any{"topology_path": path, "refresh_interval": "1m"}
+	write(path, "[n3]")
+	require.Equal(t, []string{"n3"}, load(params))
+	write(path, "[n4]")
+	now = now.Add(30 * time.Second)
+	require.Equal(t, []string{"n3"}, load(params))
+	now = now.Add(time.Minute)
+	require.Equal(t, []string{"n4"}, load(params))
+}
+
+func TestURL(t *testing.T) {
+	data, err := os.ReadFile(mediumModel)
+	require.NoError(t, err)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/topology.yaml":
+			_, _ = w.Write(data)
+		case "/invalid.yaml":
+			_, _ = w.Write([]byte("switches: [\n"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	prv, err := Loader(context.TODO(), providers.Config{Params: map[string]any{"topology_path": server.URL + "/topology.yaml"}})
+	require.NoError(t, err)
+	root, err := prv.GenerateTopologyConfig(context.TODO(), nil, nil)
+	require.NoError(t, err)
+	require.Len(t, leaves(root.Vertices[topology.TopologyTree]), 8)
+
+	_, err = Loader(context.TODO(), providers.Config{Params: map[string]any{"topology_path": server.URL + "/invalid.yaml"}})
+	require.ErrorContains(t, err, "invalid topology")
+
+	_, err = Loader(context.TODO(), providers.Config{Params: map[string]any{"topology_path": server.URL + "/missing.yaml"}})
+	require.ErrorContains(t, err, "HTTP 404")
+}
+
+func TestConcurrentLoads(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	errs := make(chan error)
+	go func() {
+		_, err := Loader(context.TODO(), providers.Config{Params: map[string]any{"topology_path": server.URL + "/slow.yaml"}})
+		errs <- err
+	}()
+	<-started
+
+	// the pending download does not block the documents of other paths
+	_, err := Loader(context.TODO(), providers.Config{Params: map[string]any{"topology_path": mediumModel}})
+	require.NoError(t, err)
+
+	close(release)
+	require.ErrorContains(t, <-errs, "HTTP 404")
+}
+
+// leaves returns the sorted names of the compute nodes under the vertex
+func leaves(v *topology.Vertex) []string {
+	var names []string
+	var walk func(*topology.Vertex)
+	walk = func(v *topology.Vertex) {
+		if len(v.Vertices) == 0 {
+			names = append(names, v.Name)
+			return
+		}
+		for _, w := range v.Vertices {
+			walk(w)
+		}
+	}
+	walk(v)
+	sort.Strings(names)
+	return names
+}
@@ -18,13 +18,14 @@ package test
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 
 	"github.com/NVIDIA/topograph/internal/config"
+	"github.com/NVIDIA/topograph/internal/fixtures"
 	"github.com/NVIDIA/topograph/pkg/models"
 	"github.com/NVIDIA/topograph/pkg/providers"
 	"github.com/NVIDIA/topograph/pkg/topology"
-	"github.com/NVIDIA/topograph/pkg/translate"
 	"k8s.io/klog/v2"
 )
 
@@ -37,6 +38,8 @@ type Provider struct {
 
 type Params struct {
 	ModelPath string `mapstructure:"model_path"`
+	// Topology is the inline model, either a YAML or JSON string or a JSON object
+	Topology any `mapstructure:"topology"`
 }
 
 func NamedLoader() (string, providers.Loader) {
@@ -54,19 +57,51 @@ func New(cfg providers.Config) (*Provider, error) {
 	}
 	provider := &Provider{}
 
-	if len(p.ModelPath) == 0 {
-		provider.tree, provider.instance2node = translate.GetTreeTestSet(false)
-	} else {
+	switch {
+	case len(p.ModelPath) != 0 && p.Topology != nil:
+		return nil, fmt.Errorf("model_path and topology are mutually exclusive")
+	case p.Topology != nil:
+		klog.InfoS("Using inline topology")
+		model, err := inlineModel(p.Topology, cfg.Models)
+		if err != nil {
+			return nil, err
+		}
+		provider.tree, provider.instance2node = model.ToGraph()
+	case len(p.ModelPath) != 0:
 		klog.InfoS("Using simulated topology", "model path", p.ModelPath)
-		model, err := models.NewModelFromFile(p.ModelPath)
+		model, err := models.NewModelFromFile(p.ModelPath, cfg.Models)
 		if err != nil {
 			return nil, err // Wrapped by models.NewModelFromFile
 		}
 		provider.tree, provider.instance2node = model.ToGraph()
+	default:
+		provider.tree, provider.instance2node = fixtures.TreeTestSet()
 	}
 	return provider, nil
 }
 
+// inlineModel loads the model of the topology parameter
+func inlineModel(v any, limits *models.Limits) (*models.Model, error) {
+	var data []byte
+	switch t := v.(type) {
+	case string:
+		data = []byte(t)
+	case map[string]any:
+		var err error
+		if data, err = json.Marshal(t); err != nil {
+			return nil, fmt.Errorf("invalid topology: %v", err)
+		}
+	default:
+		return nil, fmt.Errorf("invalid topology: expected a string or an object, got %T", v)
+	}
+
+	model, err := models.NewModelFromData(data, limits)
+	if err != nil {
+		return nil, fmt.Errorf("invalid topology: %w", err)
+	}
+	return model, nil
+}
+
 func (p *Provider) GetComputeInstances(_ context.Context) ([]topology.ComputeInstances, error) {
 	return []topology.ComputeInstances{
 		{
@@ -0,0 +1,126 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package ufm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"k8s.io/klog/v2"
+
+	"github.com/NVIDIA/topograph/internal/httpreq"
+)
+
+const (
+	// basicAuthPath is the REST API prefix for username/password authentication
+	basicAuthPath = "/ufmRest"
+	// tokenAuthPath is the REST API prefix for access token authentication
+	tokenAuthPath = "/ufmRestV3"
+
+	systemsPath = "/resources/systems"
+	linksPath   = "/resources/links"
+)
+
+// Client is a client of the UFM REST API
+type Client struct {
+	baseURL  string
+	username string
+	password string
+	token    string
+	// httpClient sends the requests; the default transport is used if nil
+	httpClient *http.Client
+}
+
+// System is a switch or a host in the fabric
+type System struct {
+	GUID string `json:"guid"`
+	Name string `json:"system_name"`
+	Type string `json:"type"`
+}
+
+// Link is a connection between the ports of two systems
+type Link struct {
+	SourceGUID      string `json:"source_guid"`
+	DestinationGUID string `json:"destination_guid"`
+}
+
+// NewClient returns a UFM client authenticated either with an access token
+// or with a username and password
+func NewClient(apiURL string, creds map[string]string) (*Client, error) {
+	apiURL = strings.TrimSuffix(apiURL, "/")
+
+	if token := creds["token"]; len(token) != 0 {
+		klog.Info("Using UFM access token")
+		return &Client{baseURL: apiURL + tokenAuthPath, token: token}, nil
+	}
+
+	var username, password string
+	if username = creds["username"]; len(username) == 0 {
+		return nil, fmt.Errorf("credentials error: missing username")
+	}
+	if password = creds["password"]; len(password) == 0 {
+		return nil, fmt.Errorf("credentials error: missing password")
+	}
+
+	return &Client{baseURL: apiURL + basicAuthPath, username: username, password: password}, nil
+}
+
+// Systems returns the switches and hosts in the fabric
+func (c *Client) Systems(ctx context.Context) ([]System, error) {
+	var systems []System
+	if err := c.get(ctx, systemsPath, &systems); err != nil {
+		return nil, err
+	}
+	return systems, nil
+}
+
+// Links returns the links in the fabric
+func (c *Client) Links(ctx context.Context) ([]Link, error) {
+	var links []Link
+	if err := c.get(ctx, linksPath, &links); err != nil {
+		return nil, err
+	}
+	return links, nil
+}
+
+func (c *Client) get(ctx context.Context, path string, out any) error {
+	f := func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+path, nil)
+		if err != nil {
+			return nil, err
+		}
+		if len(c.token) != 0 {
+			req.Header.Set("Authorization", "Basic "+c.token)
+		} else {
+			req.SetBasicAuth(c.username, c.password)
+		}
+		return req, nil
+	}
+
+	_, body, err := httpreq.DoRequestWithRetries(c.httpClient, f)
+	if err != nil {
+		return err
+	}
+
+	if err = json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("failed to parse response of %s: %v", path, err)
+	}
+
+	return nil
+}
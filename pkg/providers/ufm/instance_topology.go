@@ -0,0 +1,77 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package ufm
+
+import (
+	"github.com/NVIDIA/topograph/pkg/ib"
+	"github.com/NVIDIA/topograph/pkg/topology"
+)
+
+const (
+	systemTypeSwitch = "switch"
+	systemTypeHost   = "host"
+)
+
+// toGraph reconstructs the switch hierarchy from the UFM systems and links.
+// Hosts that are not among the compute instances are dropped from the leaf switches.
+func toGraph(systems []System, links []Link, cis []topology.ComputeInstances, failOnMultiHomed, padAsymmetric bool) (*topology.Vertex, error) {
+	nodes := make(map[string]bool)
+	for _, ci := range cis {
+		for _, node := range ci.Instances {
+			nodes[node] = true
+		}
+	}
+
+	names := make(map[string]string)        // GUID:system name
+	switches := make(map[string]*ib.Switch) // GUID:switch
+	hca := make(map[string]string)          // GUID:node name
+	for _, system := range systems {
+		switch system.Type {
+		case systemTypeSwitch:
+			switches[system.GUID] = ib.NewSwitch(system.GUID, system.Name)
+		case systemTypeHost:
+			if nodes[system.Name] {
+				hca[system.GUID] = system.Name
+			} else {
+				hca[system.GUID] = ""
+			}
+		default:
+			continue
+		}
+		names[system.GUID] = system.Name
+	}
+
+	for _, link := range links {
+		connect(switches, names, link.SourceGUID, link.DestinationGUID)
+		connect(switches, names, link.DestinationGUID, link.SourceGUID)
+	}
+
+	return ib.BuildTopology(switches, hca, failOnMultiHomed, padAsymmetric)
+}
+
+// connect adds the link from a switch to a known system
+func connect(switches map[string]*ib.Switch, names map[string]string, from, to string) {
+	sw, ok := switches[from]
+	if !ok {
+		return
+	}
+	name, ok := names[to]
+	if !ok {
+		return
+	}
+	sw.Conn[to] = name
+	sw.Links[to]++
+}
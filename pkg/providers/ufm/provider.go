@@ -0,0 +1,131 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package ufm
+
+import (
+	"context"
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/NVIDIA/topograph/internal/config"
+	"github.com/NVIDIA/topograph/internal/httpreq"
+	"github.com/NVIDIA/topograph/pkg/providers"
+	"github.com/NVIDIA/topograph/pkg/topology"
+)
+
+const NAME = "ufm"
+
+type Provider struct {
+	client *Client
+	params *Params
+}
+
+type Params struct {
+	// APIURL is the base URL of the UFM server, e.g. https://ufm.example.com
+	APIURL string `mapstructure:"api_url" validate:"required"`
+	// FailOnMultiHomed fails the request if a node is connected to more than one leaf switch
+	FailOnMultiHomed bool `mapstructure:"fail_on_multi_homed"`
+	// PadAsymmetric inserts pass-through switches above the leaf switches closer to the top of the fabric than the others
+	PadAsymmetric bool `mapstructure:"pad_asymmetric"`
+	// TLS is the TLS configuration of the UFM API client
+	TLS *httpreq.TLS `mapstructure:"tls"`
+	// ProxyURL is the URL of the HTTP proxy to the UFM server; taken from the environment if empty
+	ProxyURL string `mapstructure:"proxy_url"`
+}
+
+func NamedLoader() (string, providers.Loader) {
+	return NAME, Loader
+}
+
+func Loader(ctx context.Context, cfg providers.Config) (providers.Provider, error) {
+	p, err := getParams(cfg.Params)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := NewClient(p.APIURL, cfg.Creds)
+	if err != nil {
+		return nil, err
+	}
+
+	if client.httpClient, err = httpreq.NewClient(p.TLS, p.ProxyURL); err != nil {
+		return nil, err
+	}
+
+	return New(client, p), nil
+}
+
+func getParams(params map[string]any) (*Params, error) {
+	var p Params
+	if err := config.Decode(params, &p); err != nil {
+		return nil, fmt.Errorf("error decoding params: %w", err)
+	}
+
+	return &p, nil
+}
+
+func New(client *Client, params *Params) *Provider {
+	return &Provider{
+		client: client,
+		params: params,
+	}
+}
+
+func (p *Provider) GenerateTopologyConfig(ctx context.Context, _ *int, instances []topology.ComputeInstances) (*topology.Vertex, error) {
+	if len(instances) > 1 {
+		return nil, fmt.Errorf("UFM does not support multi-region topology requests")
+	}
+
+	systems, err := p.client.Systems(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get UFM systems: %v", err)
+	}
+
+	links, err := p.client.Links(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get UFM links: %v", err)
+	}
+
+	return toGraph(systems, links, instances, p.params.FailOnMultiHomed, p.params.PadAsymmetric)
+}
+
+// Engine support
+
+// Instances2NodeMap implements slurm.instanceMapper
+func (p *Provider) Instances2NodeMap(ctx context.Context, nodes []string) (map[string]string, error) {
+	i2n := make(map[string]string)
+	for _, node := range nodes {
+		i2n[node] = node
+	}
+
+	return i2n, nil
+}
+
+// GetComputeInstancesRegion implements slurm.instanceMapper
+func (p *Provider) GetComputeInstancesRegion() (string, error) {
+	return "", nil
+}
+
+// GetNodeRegion implements k8s.k8sNodeInfo
+func (p *Provider) GetNodeRegion(node *v1.Node) (string, error) {
+	return node.Labels["topology.kubernetes.io/region"], nil
+}
+
+// GetNodeInstance implements k8s.k8sNodeInfo
+func (p *Provider) GetNodeInstance(node *v1.Node) (string, error) {
+	return node.Labels["kubernetes.io/hostname"], nil
+}
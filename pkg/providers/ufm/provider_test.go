@@ -0,0 +1,227 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package ufm
+
+import (
+	"context"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/NVIDIA/topograph/pkg/providers"
+	"github.com/NVIDIA/topograph/pkg/topology"
+)
+
+const fixturesDir = "../../../tests/output/ufm/"
+
+func TestNewClient(t *testing.T) {
+	testCases := []struct {
+		name  string
+		creds map[string]string
+		url   string
+		err   string
+	}{
+		{
+			name:  "Case 1: token",
+			creds: map[string]string{"token": "abc"},
+			url:   "https://ufm/ufmRestV3",
+		},
+		{
+			name:  "Case 2: username and password",
+			creds: map[string]string{"username": "admin", "password": "secret"},
+			url:   "https://ufm/ufmRest",
+		},
+		{
+			name:  "Case 3: missing username",
+			creds: map[string]string{"password": "secret"},
+			err:   "credentials error: missing username",
+		},
+		{
+			name:  "Case 4: missing password",
+			creds: map[string]string{"username": "admin"},
+			err:   "credentials error: missing password",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client, err := NewClient("https://ufm/", tc.creds)
+			if len(tc.err) != 0 {
+				require.EqualError(t, err, tc.err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tc.url, client.baseURL)
+			}
+		})
+	}
+}
+
+func TestGenerateTopologyConfig(t *testing.T) {
+	systems, err := os.ReadFile(fixturesDir + "systems.json")
+	require.NoError(t, err)
+	links, err := os.ReadFile(fixturesDir + "links.json")
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name      string
+		failures  int
+		instances map[string]string
+		leaves    []string
+		err       string
+	}{
+		{
+			name:      "Case 1: all nodes",
+			instances: map[string]string{"node101": "node101", "node102": "node102", "node201": "node201", "node202": "node202"},
+			leaves:    []string{"node101,node102", "node201,node202"},
+		},
+		{
+			name:      "Case 2: subset of nodes",
+			instances: map[string]string{"node101": "node101", "node102": "node102", "node201": "node201"},
+			leaves:    []string{"node101,node102", "node201"},
+		},
+		{
+			name:      "Case 3: transient errors",
+			failures:  2,
+			instances: map[string]string{"node101": "node101", "node201": "node201"},
+			leaves:    []string{"node101", "node201"},
+		},
+		{
+			name:      "Case 4: persistent errors",
+			failures:  10,
+			instances: map[string]string{"node101": "node101"},
+			err:       "failed to get UFM systems: HTTP 503",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			failures := tc.failures
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if user, pass, ok := r.BasicAuth(); !ok || user != "admin" || pass != "secret" {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				if failures > 0 {
+					failures--
+					w.WriteHeader(http.StatusServiceUnavailable)
+					return
+				}
+				switch r.URL.Path {
+				case basicAuthPath + systemsPath:
+					_, _ = w.Write(systems)
+				case basicAuthPath + linksPath:
+					_, _ = w.Write(links)
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+			defer ts.Close()
+
+			client, err := NewClient(ts.URL, map[string]string{"username": "admin", "password": "secret"})
+			require.NoError(t, err)
+
+			p := New(client, &Params{APIURL: ts.URL})
+			root, err := p.GenerateTopologyConfig(context.TODO(), nil, []topology.ComputeInstances{{Instances: tc.instances}})
+			if len(tc.err) != 0 {
+				require.ErrorContains(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, root.Vertices, 1)
+
+			var leaves []string
+			for _, spine := range root.Vertices {
+				for _, leaf := range spine.Vertices {
+					var nodes []string
+					for _, node := range leaf.Vertices {
+						nodes = append(nodes, node.Name)
+					}
+					sort.Strings(nodes)
+					leaves = append(leaves, strings.Join(nodes, ","))
+				}
+			}
+			sort.Strings(leaves)
+			require.Equal(t, tc.leaves, leaves)
+		})
+	}
+}
+
+func TestLoaderTLS(t *testing.T) {
+	systems, err := os.ReadFile(fixturesDir + "systems.json")
+	require.NoError(t, err)
+	links, err := os.ReadFile(fixturesDir + "links.json")
+	require.NoError(t, err)
+
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case tokenAuthPath + systemsPath:
+			_, _ = w.Write(systems)
+		case tokenAuthPath + linksPath:
+			_, _ = w.Write(links)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	caCert := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw}))
+
+	testCases := []struct {
+		name   string
+		params map[string]any
+		err    string
+	}{
+		{
+			name:   "Case 1: missing CA",
+			params: map[string]any{"api_url": ts.URL},
+			err:    "failed to get UFM systems: failed to send HTTP request",
+		},
+		{
+			name:   "Case 2: CA",
+			params: map[string]any{"api_url": ts.URL, "tls": map[string]any{"ca_cert": caCert}},
+		},
+		{
+			name:   "Case 3: insecure",
+			params: map[string]any{"api_url": ts.URL, "tls": map[string]any{"insecure_skip_verify": true}},
+		},
+		{
+			name:   "Case 4: invalid CA",
+			params: map[string]any{"api_url": ts.URL, "tls": map[string]any{"ca_cert": "/missing/ca.pem"}},
+			err:    "failed to read CA cert",
+		},
+	}
+
+	instances := []topology.ComputeInstances{{Instances: map[string]string{"node101": "node101"}}}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := Loader(context.TODO(), providers.Config{Creds: map[string]string{"token": "abc"}, Params: tc.params})
+			if err == nil {
+				_, err = p.GenerateTopologyConfig(context.TODO(), nil, instances)
+			}
+			if len(tc.err) != 0 {
+				require.ErrorContains(t, err, tc.err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
@@ -24,22 +24,34 @@ import (
 	"github.com/NVIDIA/topograph/pkg/providers"
 	"github.com/NVIDIA/topograph/pkg/providers/aws"
 	"github.com/NVIDIA/topograph/pkg/providers/baremetal"
+	"github.com/NVIDIA/topograph/pkg/providers/composite"
+	"github.com/NVIDIA/topograph/pkg/providers/coreweave"
 	"github.com/NVIDIA/topograph/pkg/providers/cw"
 	"github.com/NVIDIA/topograph/pkg/providers/gcp"
 	"github.com/NVIDIA/topograph/pkg/providers/oci"
+	"github.com/NVIDIA/topograph/pkg/providers/static"
 	provider_test "github.com/NVIDIA/topograph/pkg/providers/test"
+	"github.com/NVIDIA/topograph/pkg/providers/ufm"
 )
 
 var Providers = providers.NewRegistry(
 	aws.NamedLoader,
 	aws.NamedLoaderSim,
 	baremetal.NamedLoader,
+	coreweave.NamedLoader,
 	cw.NamedLoader,
 	gcp.NamedLoader,
 	oci.NamedLoader,
+	static.NamedLoader,
 	provider_test.NamedLoader,
+	ufm.NamedLoader,
 )
 
+func init() {
+	// the composite provider loads its sources from the registry
+	Providers.Register(composite.NamedLoader(Providers.Get))
+}
+
 var Engines = engines.NewRegistry(
 	k8s.NamedLoader,
 	slurm.NamedLoader,
@@ -0,0 +1,85 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package registry
+
+import (
+	"github.com/NVIDIA/topograph/internal/config"
+	"github.com/NVIDIA/topograph/pkg/engines/k8s"
+	"github.com/NVIDIA/topograph/pkg/engines/slurm"
+	"github.com/NVIDIA/topograph/pkg/providers"
+	"github.com/NVIDIA/topograph/pkg/providers/aws"
+	"github.com/NVIDIA/topograph/pkg/providers/composite"
+	"github.com/NVIDIA/topograph/pkg/providers/coreweave"
+	"github.com/NVIDIA/topograph/pkg/providers/cw"
+	"github.com/NVIDIA/topograph/pkg/providers/gcp"
+	"github.com/NVIDIA/topograph/pkg/providers/oci"
+	"github.com/NVIDIA/topograph/pkg/providers/static"
+	provider_test "github.com/NVIDIA/topograph/pkg/providers/test"
+	"github.com/NVIDIA/topograph/pkg/providers/ufm"
+)
+
+// ProviderParams and EngineParams hold the default parameters of the registered components
+// by name, from which the parameter schema is generated. Out-of-tree components can add their own.
+var ProviderParams = map[string]any{
+	aws.NAME: aws.Params{},
+	aws.NAME_SIM: struct {
+		providers.SimulationParams `mapstructure:",squash"`
+		aws.Params                 `mapstructure:",squash"`
+	}{},
+	composite.NAME:     composite.Params{},
+	coreweave.NAME:     coreweave.DefaultParams(),
+	cw.NAME:            cw.Params{},
+	gcp.NAME:           gcp.Params{},
+	oci.NAME:           oci.DefaultParams(),
+	static.NAME:        static.Params{},
+	provider_test.NAME: provider_test.Params{},
+	ufm.NAME:           ufm.Params{},
+}
+
+var EngineParams = map[string]any{
+	k8s.NAME:   k8s.DefaultParams(),
+	slurm.NAME: slurm.DefaultParams(),
+}
+
+// Schema returns the JSON schema of the parameters of the registered providers and engines.
+// The parameters of components without registered defaults are not restricted.
+func Schema() *config.Schema {
+	providerNames := make([]string, 0, len(Providers))
+	for name := range Providers {
+		providerNames = append(providerNames, name)
+	}
+	engineNames := make([]string, 0, len(Engines))
+	for name := range Engines {
+		engineNames = append(engineNames, name)
+	}
+
+	return &config.Schema{
+		Schema: config.SchemaURI,
+		Type:   "object",
+		Properties: map[string]*config.Schema{
+			"providers": componentsSchema(providerNames, ProviderParams),
+			"engines":   componentsSchema(engineNames, EngineParams),
+		},
+	}
+}
+
+func componentsSchema(names []string, params map[string]any) *config.Schema {
+	schema := &config.Schema{Type: "object", Properties: make(map[string]*config.Schema, len(names))}
+	for _, name := range names {
+		schema.Properties[name] = config.ParamsSchema(params[name])
+	}
+	return schema
+}
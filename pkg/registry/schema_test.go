@@ -0,0 +1,80 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package registry
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/NVIDIA/topograph/internal/config"
+	enginefake "github.com/NVIDIA/topograph/pkg/engines/fake"
+)
+
+// TestSchemaDefaultsRoundTrip checks that the schema defaults decode into the default parameters
+func TestSchemaDefaultsRoundTrip(t *testing.T) {
+	for _, params := range []map[string]any{ProviderParams, EngineParams} {
+		for name, defaults := range params {
+			t.Run(name, func(t *testing.T) {
+				input := make(map[string]any)
+				for prop, schema := range config.ParamsSchema(defaults).Properties {
+					if schema.Default != nil {
+						input[prop] = schema.Default
+					}
+				}
+
+				decoded := reflect.New(reflect.TypeOf(defaults))
+				require.NoError(t, config.Decode(input, decoded.Interface()))
+				require.Equal(t, defaults, decoded.Elem().Interface())
+			})
+		}
+	}
+}
+
+func TestSchema(t *testing.T) {
+	type fakeParams struct {
+		Mode string `mapstructure:"mode" validate:"required"`
+	}
+	Engines.Register(enginefake.New().NamedLoader("fake"))
+	EngineParams["fake"] = fakeParams{}
+	Engines.Register(enginefake.New().NamedLoader("fake-any"))
+	defer func() {
+		delete(Engines, "fake")
+		delete(Engines, "fake-any")
+		delete(EngineParams, "fake")
+	}()
+
+	schema := Schema()
+	require.Equal(t, config.SchemaURI, schema.Schema)
+
+	providers := schema.Properties["providers"].Properties
+	require.Len(t, providers, len(Providers))
+	require.Equal(t, &config.Schema{Type: "object"}, providers["baremetal"])
+	require.Equal(t, &config.Schema{Type: "number", Default: 0.9}, providers["oci"].Properties["local_block_threshold"])
+	require.Equal(t, []string{"api_url"}, providers["ufm"].Required)
+	require.Contains(t, providers["aws-sim"].Properties, "model_path")
+	require.Contains(t, providers["aws-sim"].Properties, "fetch_tags")
+
+	engines := schema.Properties["engines"].Properties
+	require.Equal(t, &config.Schema{Type: "string", Default: "topology/tree"}, engines["slurm"].Properties["plugin"])
+	require.Equal(t, &config.Schema{
+		Type:       "object",
+		Properties: map[string]*config.Schema{"mode": {Type: "string"}},
+		Required:   []string{"mode"},
+	}, engines["fake"])
+	require.Equal(t, &config.Schema{Type: "object"}, engines["fake-any"])
+}
@@ -0,0 +1,118 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/klog/v2"
+)
+
+// secretCredentialsTTL is the time the credentials read from the Secret are reused
+const secretCredentialsTTL = time.Minute
+
+// secretCredentials reads the provider credentials from a Kubernetes Secret,
+// so that rotated credentials are picked up without a restart
+type secretCredentials struct {
+	namespace string
+	name      string
+	ttl       time.Duration
+	now       func() time.Time
+	newClient func() (kubernetes.Interface, error)
+
+	mutex  sync.Mutex
+	client kubernetes.Interface
+	creds  map[string]string
+	expiry time.Time
+}
+
+func newSecretCredentials(namespace, name string) *secretCredentials {
+	return &secretCredentials{
+		namespace: namespace,
+		name:      name,
+		ttl:       secretCredentialsTTL,
+		now:       time.Now,
+		newClient: inClusterClient,
+	}
+}
+
+func inClusterClient() (kubernetes.Interface, error) {
+	konfig, err := rest.InClusterConfig()
+	if err != nil {
+		return nil, err
+	}
+	return kubernetes.NewForConfig(konfig)
+}
+
+// get returns the key/value pairs of the Secret, read at most once per TTL
+func (s *secretCredentials) get(ctx context.Context) (map[string]string, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.creds != nil && s.now().Before(s.expiry) {
+		return s.creds, nil
+	}
+
+	if s.client == nil {
+		client, err := s.newClient()
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Kubernetes client for credentials secret %s/%s: %v", s.namespace, s.name, err)
+		}
+		s.client = client
+	}
+
+	secret, err := s.client.CoreV1().Secrets(s.namespace).Get(ctx, s.name, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, fmt.Errorf("credentials secret %s/%s not found", s.namespace, s.name)
+		}
+		return nil, fmt.Errorf("failed to read credentials secret %s/%s: %v", s.namespace, s.name, err)
+	}
+
+	creds := make(map[string]string, len(secret.Data))
+	for k, v := range secret.Data {
+		creds[k] = string(v)
+	}
+	klog.V(4).Infof("Read %d credentials from secret %s/%s", len(creds), s.namespace, s.name)
+
+	s.creds = creds
+	s.expiry = s.now().Add(s.ttl)
+
+	return creds, nil
+}
+
+// getCredentials returns the credentials of the request: the payload credentials, if given,
+// or the credentials of the credentials secret or file
+func getCredentials(ctx context.Context, payloadCreds map[string]string) (map[string]string, *HTTPError) {
+	if len(payloadCreds) != 0 || srv.secretCreds == nil {
+		return checkCredentials(payloadCreds, srv.cfg.Credentials), nil
+	}
+
+	creds, err := srv.secretCreds.get(ctx)
+	if err != nil {
+		klog.Error(err.Error())
+		return nil, NewHTTPError(http.StatusBadGateway, err.Error())
+	}
+	return creds, nil
+}
@@ -0,0 +1,141 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package server
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/NVIDIA/topograph/pkg/config"
+)
+
+func newTestSecret(data map[string]string) *v1.Secret {
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "creds", Namespace: "topograph"},
+		Data:       make(map[string][]byte),
+	}
+	for k, v := range data {
+		secret.Data[k] = []byte(v)
+	}
+	return secret
+}
+
+func TestSecretCredentials(t *testing.T) {
+	client := k8sfake.NewSimpleClientset(newTestSecret(map[string]string{"access_key_id": "id1", "secret_access_key": "key1"}))
+	now := time.Date(2024, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	s := newSecretCredentials("topograph", "creds")
+	s.now = func() time.Time { return now }
+	s.newClient = func() (kubernetes.Interface, error) { return client, nil }
+
+	creds, err := s.get(context.TODO())
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"access_key_id": "id1", "secret_access_key": "key1"}, creds)
+
+	// rotate the credentials
+	_, err = client.CoreV1().Secrets("topograph").Update(context.TODO(),
+		newTestSecret(map[string]string{"access_key_id": "id2", "secret_access_key": "key2"}), metav1.UpdateOptions{})
+	require.NoError(t, err)
+
+	// the cached credentials are served until expiry
+	now = now.Add(30 * time.Second)
+	creds, err = s.get(context.TODO())
+	require.NoError(t, err)
+	require.Equal(t, "id1", creds["access_key_id"])
+
+	now = now.Add(time.Minute)
+	creds, err = s.get(context.TODO())
+	require.NoError(t, err)
+	require.Equal(t, "id2", creds["access_key_id"])
+
+	// the deleted secret fails the request after expiry
+	require.NoError(t, client.CoreV1().Secrets("topograph").Delete(context.TODO(), "creds", metav1.DeleteOptions{}))
+	now = now.Add(time.Minute)
+	_, err = s.get(context.TODO())
+	require.EqualError(t, err, "credentials secret topograph/creds not found")
+}
+
+func TestGetCredentials(t *testing.T) {
+	cfgCreds := map[string]string{"access_key_id": "cfg"}
+	payloadCreds := map[string]string{"access_key_id": "payload"}
+
+	testCases := []struct {
+		name      string
+		useSecret bool
+		secret    *v1.Secret
+		payload   map[string]string
+		creds     map[string]string
+		code      int
+		err       string
+	}{
+		{
+			name:  "Case 1: config credentials",
+			creds: cfgCreds,
+		},
+		{
+			name:      "Case 2: payload credentials",
+			useSecret: true,
+			payload:   payloadCreds,
+			creds:     payloadCreds,
+		},
+		{
+			name:      "Case 3: secret credentials",
+			useSecret: true,
+			secret:    newTestSecret(map[string]string{"access_key_id": "secret"}),
+			creds:     map[string]string{"access_key_id": "secret"},
+		},
+		{
+			name:      "Case 4: missing secret",
+			useSecret: true,
+			code:      http.StatusBadGateway,
+			err:       "credentials secret topograph/creds not found",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			srv = &HttpServer{cfg: &config.Config{Credentials: cfgCreds}}
+			defer func() { srv = nil }()
+
+			if tc.useSecret {
+				client := k8sfake.NewSimpleClientset()
+				if tc.secret != nil {
+					client = k8sfake.NewSimpleClientset(tc.secret)
+				}
+				srv.secretCreds = newSecretCredentials("topograph", "creds")
+				srv.secretCreds.newClient = func() (kubernetes.Interface, error) { return client, nil }
+			}
+
+			creds, httpErr := getCredentials(context.TODO(), tc.payload)
+			if len(tc.err) != 0 {
+				require.NotNil(t, httpErr)
+				require.Equal(t, tc.code, httpErr.Code)
+				require.Equal(t, tc.err, httpErr.Message)
+			} else {
+				require.Nil(t, httpErr)
+				require.Equal(t, tc.creds, creds)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"github.com/NVIDIA/topograph/pkg/engines"
+	"github.com/NVIDIA/topograph/pkg/topology"
+	"github.com/NVIDIA/topograph/pkg/translate"
+)
+
+// diff compares the topology generated for the request with the topology config applied by the engine.
+// Unlike generate, it runs synchronously and applies nothing. The provider discovery runs outside
+// the request queue, concurrently with the queued requests, within the provider timeout.
+func diff(w http.ResponseWriter, r *http.Request) {
+	tr := readRequest(w, r)
+	if tr == nil {
+		return
+	}
+
+	start := time.Now()
+	res, httpErr := diffTopology(r, tr)
+	if httpErr != nil {
+		httpError(w, tr.Provider.Name, tr.Engine.Name, httpErr.Message, httpErr.Code, time.Since(start))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(res)
+}
+
+func diffTopology(r *http.Request, tr *topology.Request) (*translate.TopologyDiff, *HTTPError) {
+	klog.InfoS("Comparing topology config", "provider", tr.Provider.Name, "engine", tr.Engine.Name)
+	ctx := r.Context()
+
+	if len(tr.Engines) != 0 {
+		return nil, NewHTTPError(http.StatusBadRequest, "topology diff supports a single engine")
+	}
+
+	eng, httpErr := loadEngine(ctx, tr.Engine)
+	if httpErr != nil {
+		return nil, httpErr
+	}
+	differ, ok := eng.(engines.ConfigDiffer)
+	if !ok {
+		return nil, NewHTTPError(http.StatusBadRequest, fmt.Sprintf("engine %s does not support topology diff", tr.Engine.Name))
+	}
+
+	root, httpErr := generateTopology(ctx, tr, eng, func(string) {})
+	if httpErr != nil {
+		return nil, httpErr
+	}
+
+	appliedData, generatedData, err := differ.TopologyConfigs(ctx, root, tr.Engine.Params)
+	if err != nil {
+		klog.Error(err.Error())
+		if errors.Is(err, engines.ErrInvalidParams) {
+			return nil, NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		return nil, NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	applied, err := translate.ParseTopology(appliedData)
+	if err != nil {
+		return nil, NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("failed to parse applied topology config: %v", err))
+	}
+	generated, err := translate.ParseTopology(generatedData)
+	if err != nil {
+		return nil, NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("failed to parse generated topology config: %v", err))
+	}
+
+	return translate.Diff(applied, generated), nil
+}
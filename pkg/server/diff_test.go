@@ -0,0 +1,113 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/NVIDIA/topograph/pkg/config"
+	"github.com/NVIDIA/topograph/pkg/engines"
+	enginefake "github.com/NVIDIA/topograph/pkg/engines/fake"
+	"github.com/NVIDIA/topograph/pkg/providers/fake"
+	"github.com/NVIDIA/topograph/pkg/translate"
+)
+
+func TestDiff(t *testing.T) {
+	srv = &HttpServer{
+		cfg:        &config.Config{},
+		placements: newPlacements(),
+	}
+	defer func() { srv = nil }()
+
+	prv := fake.NewTree()
+	eng := enginefake.New().WithAppliedConfig([]byte("SwitchName=S2 Nodes=Node[201-202],Node[304-305]\n"))
+	registerFakes(t, prv, eng)
+
+	testCases := []struct {
+		name   string
+		method string
+		err    error
+		code   int
+		diff   *translate.TopologyDiff
+	}{
+		{
+			name:   "Case 1: diff against the applied config",
+			method: http.MethodPost,
+			code:   http.StatusOK,
+			diff: &translate.TopologyDiff{
+				NodesAdded: []string{"Node205", "Node306"},
+				NodesMoved: []*translate.NodeMove{
+					{
+						Node: "Node304",
+						From: &translate.NodeLocation{Topology: "topology/tree", Switch: "S2"},
+						To:   &translate.NodeLocation{Topology: "topology/tree", Switch: "S3"},
+					},
+					{
+						Node: "Node305",
+						From: &translate.NodeLocation{Topology: "topology/tree", Switch: "S2"},
+						To:   &translate.NodeLocation{Topology: "topology/tree", Switch: "S3"},
+					},
+				},
+				SwitchesAdded: []string{"S1", "S3"},
+			},
+		},
+		{
+			name:   "Case 2: invalid method",
+			method: http.MethodGet,
+			code:   http.StatusMethodNotAllowed,
+		},
+		{
+			name:   "Case 3: invalid engine parameters",
+			method: http.MethodPost,
+			err:    fmt.Errorf("%w: missing topology_config_path parameter", engines.ErrInvalidParams),
+			code:   http.StatusBadRequest,
+		},
+		{
+			name:   "Case 4: engine failure",
+			method: http.MethodPost,
+			err:    errors.New("failed to read topology config"),
+			code:   http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			eng.WithOutput([]byte("OK\n"), tc.err)
+			payload := `{"provider":{"name":"fake"},"engine":{"name":"fake"}}`
+			req := httptest.NewRequest(tc.method, "/v1/diff", strings.NewReader(payload))
+			rec := httptest.NewRecorder()
+			diff(rec, req)
+			require.Equal(t, tc.code, rec.Code)
+			if tc.diff == nil {
+				return
+			}
+
+			res := &translate.TopologyDiff{}
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), res))
+			require.Equal(t, tc.diff, res)
+			// nothing is applied
+			eng.RequireOutputCalls(t, 0)
+		})
+	}
+}
@@ -19,6 +19,7 @@ package server
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net/http"
 	"time"
 
@@ -28,13 +29,43 @@ import (
 	"github.com/NVIDIA/topograph/pkg/metrics"
 	"github.com/NVIDIA/topograph/pkg/providers"
 	"github.com/NVIDIA/topograph/pkg/registry"
+	"github.com/NVIDIA/topograph/pkg/topograph"
 	"github.com/NVIDIA/topograph/pkg/topology"
+	"github.com/NVIDIA/topograph/pkg/translate"
 )
 
+// processing stages of a topology request
+const (
+	stageInit             = "init"
+	stageComputeInstances = "compute_instances"
+	stageTopology         = "topology"
+	stageOutput           = "output"
+)
+
+// topologyResult is the result of a topology request
+type topologyResult struct {
+	data []byte           // engine output
+	root *topology.Vertex // topology graph
+}
+
 type asyncController struct {
 	queue *TrailingDelayQueue
 }
 
+func setStage(stage string) {
+	if srv != nil && srv.async != nil {
+		srv.async.queue.SetStage(stage)
+	}
+}
+
+// inFlightUID returns the UID of the request being processed, if any
+func inFlightUID() string {
+	if srv != nil && srv.async != nil {
+		return srv.async.queue.InFlightUID()
+	}
+	return ""
+}
+
 func processRequest(item interface{}) (interface{}, *HTTPError) {
 	tr := item.(*topology.Request)
 	var code int
@@ -48,70 +79,182 @@ func processRequest(item interface{}) (interface{}, *HTTPError) {
 	}
 	metrics.Add(tr.Provider.Name, tr.Engine.Name, code, time.Since(start))
 
-	return ret, err
+	if err != nil {
+		return nil, err
+	}
+	metrics.ObserveResultSize(tr.Provider.Name, tr.Engine.Name, len(ret.data))
+	if srv != nil && srv.notifier != nil {
+		n := srv.notifier.newNotification(srv.async.queue.InFlightUID(), tr, ret.data)
+		go srv.notifier.send(n)
+	}
+	return ret, nil
 }
 
-func processTopologyRequest(tr *topology.Request) ([]byte, *HTTPError) {
+func processTopologyRequest(tr *topology.Request) (*topologyResult, *HTTPError) {
 	klog.InfoS("Creating topology config", "provider", tr.Provider.Name, "engine", tr.Engine.Name)
 	defer klog.Info("Topology request completed")
 
-	engLoader, err := registry.Engines.Get(tr.Engine.Name)
+	setStage(stageInit)
+
+	ctx := engines.WithRequestUID(context.Background(), inFlightUID())
+	if len(tr.Nodes) != 0 {
+		ctx = engines.WithRequestNodes(ctx, requestNodes(tr.Nodes))
+	}
+
+	if len(tr.Engines) != 0 {
+		return processMultiEngineRequest(ctx, tr)
+	}
+
+	eng, httpErr := loadEngine(ctx, tr.Engine)
+	if httpErr != nil {
+		return nil, httpErr
+	}
+
+	// re-apply the stored placement of known nodes without querying the provider
+	if nodes := getRepairNodes(tr.Engine.Params); len(nodes) != 0 {
+		if root := srv.placements.get(tr); coversNodes(root, nodes) {
+			klog.Infof("Repairing nodes %v from the stored topology", nodes)
+			setStage(stageOutput)
+			data, err := eng.GenerateOutput(ctx, root, tr.Engine.Params)
+			if err != nil {
+				klog.Error(err.Error())
+				return nil, NewHTTPError(http.StatusInternalServerError, err.Error())
+			}
+			return &topologyResult{data: data, root: root}, nil
+		}
+		klog.Infof("Nodes %v not found in the stored topology; generating full topology", nodes)
+		tr.Engine.Params = withoutRepairNodes(tr.Engine.Params)
+	}
+
+	root, httpErr := generateTopology(ctx, tr, eng, setStage)
+	if httpErr != nil {
+		return nil, httpErr
+	}
+	metrics.SetTopologyStats(tr.Provider.Name, tr.Engine.Name, root.Stats())
+
+	saveSnapshot(ctx, tr, root)
+
+	setStage(stageOutput)
+	data, err := eng.GenerateOutput(ctx, root, tr.Engine.Params)
 	if err != nil {
-		klog.Error(err.Error())
-		if errors.Is(err, engines.ErrUnsupportedEngine) {
-			return nil, NewHTTPError(http.StatusBadRequest, err.Error())
+		return nil, outputError(err)
+	}
+
+	srv.placements.set(tr, root)
+
+	return &topologyResult{data: data, root: root}, nil
+}
+
+// requestNodes returns the names of the nodes of the compute instances
+func requestNodes(cis []topology.ComputeInstances) []string {
+	var nodes []string
+	for _, ci := range cis {
+		for _, node := range ci.Instances {
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes
+}
+
+// saveSnapshot keeps the provider topology before the engine processes it
+func saveSnapshot(ctx context.Context, tr *topology.Request, root *topology.Vertex) {
+	if uid := engines.RequestUID(ctx); srv.snapshots != nil && len(uid) != 0 {
+		if err := srv.snapshots.save(uid, tr, root); err != nil {
+			klog.Errorf("Failed to save topology snapshot of request %s: %v", uid, err)
 		}
-		return nil, NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
+}
+
+// outputError returns the HTTP error of the engine output error
+func outputError(err error) *HTTPError {
+	klog.Error(err.Error())
+	if errors.Is(err, engines.ErrTopologyValidation) {
+		return NewHTTPError(http.StatusBadGateway, err.Error())
+	}
+	if errors.Is(err, translate.ErrDuplicateNodes) {
+		return NewHTTPError(http.StatusUnprocessableEntity, err.Error())
+	}
+	return NewHTTPError(http.StatusInternalServerError, err.Error())
+}
 
-	prvLoader, err := registry.Providers.Get(tr.Provider.Name)
+// loadEngine returns the engine of the topology request
+func loadEngine(ctx context.Context, e topology.Engine) (engines.Engine, *HTTPError) {
+	engLoader, err := registry.Engines.Get(e.Name)
 	if err != nil {
 		klog.Error(err.Error())
-		if errors.Is(err, providers.ErrUnsupportedProvider) {
+		if errors.Is(err, engines.ErrUnsupportedEngine) {
 			return nil, NewHTTPError(http.StatusBadRequest, err.Error())
 		}
 		return nil, NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
 
-	ctx := context.Background()
-
-	eng, err := engLoader(ctx, engines.Config{})
+	eng, err := engLoader(ctx, engines.Config{Params: e.Params})
 	if err != nil {
 		// TODO: Logic to determine between StatusBadRequest and StatusInternalServerError
 		return nil, NewHTTPError(http.StatusBadRequest, err.Error())
 	}
 
-	prv, err := prvLoader(ctx, providers.Config{
-		Creds:  checkCredentials(tr.Provider.Creds, srv.cfg.Credentials),
+	return eng, nil
+}
+
+// generateTopology returns the topology graph of the cluster from the provider of the request,
+// reporting the processing stages to the stage callback.
+// The engine lists the compute instances, unless they are given in the request or listed by the provider.
+// With the provider cache enabled, the topology of the same compute instances is reused within the TTL.
+func generateTopology(ctx context.Context, tr *topology.Request, eng engines.Engine, stage func(string)) (*topology.Vertex, *HTTPError) {
+	creds, httpErr := getCredentials(ctx, tr.Provider.Creds)
+	if httpErr != nil {
+		return nil, httpErr
+	}
+
+	prv, err := topograph.NewProvider(ctx, tr.Provider.Name, providers.Config{
+		Creds:  creds,
 		Params: tr.Provider.Params,
+		Models: srv.cfg.Models,
 	})
 	if err != nil {
+		klog.Error(err.Error())
 		// TODO: Logic to determine between StatusBadRequest and StatusInternalServerError
 		return nil, NewHTTPError(http.StatusBadRequest, err.Error())
 	}
 
-	// Optional provider interface if it directly supports getting compute instances.
-	// (e.g., Test provider)
-	type simpleGetComputeInstances interface {
-		GetComputeInstances(ctx context.Context) ([]topology.ComputeInstances, error)
+	// the deadline covers the compute instance listing and the topology request
+	if srv.cfg.ProviderTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, srv.cfg.ProviderTimeout)
+		defer cancel()
 	}
 
 	// if the instance/node mapping is not provided in the payload, get the mapping from the provider
 	computeInstances := tr.Nodes
 	if len(computeInstances) == 0 {
-		var err error
-		switch t := prv.(type) {
-		case simpleGetComputeInstances:
-			computeInstances, err = t.GetComputeInstances(ctx)
-		default:
-			computeInstances, err = eng.GetComputeInstances(ctx, prv)
-		}
-
+		stage(stageComputeInstances)
+		computeInstances, err = topograph.ComputeInstances(ctx, prv, eng)
 		if err != nil {
+			if errors.Is(err, topograph.ErrNoEngine) {
+				return nil, NewHTTPError(http.StatusBadRequest,
+					fmt.Sprintf("engines %s list the compute instances differently; set the nodes in the request", tr.Engine.Name))
+			}
+			if errors.Is(err, context.DeadlineExceeded) {
+				return nil, NewHTTPError(http.StatusGatewayTimeout, err.Error())
+			}
 			return nil, NewHTTPError(http.StatusInternalServerError, err.Error())
 		}
 	}
 
+	// reuse the topology of the same compute instances, unless the request asks to refresh it
+	var cacheKey string
+	if srv.providerCache != nil {
+		cacheKey = providerCacheKey(tr.Provider, computeInstances)
+	}
+	if len(cacheKey) != 0 && !tr.Refresh {
+		if root := srv.providerCache.get(tr.Provider.Name, cacheKey); root != nil {
+			klog.InfoS("Using cached provider topology", "provider", tr.Provider.Name)
+			return root, nil
+		}
+	}
+
+	stage(stageTopology)
 	var root *topology.Vertex
 	if srv.cfg.FwdSvcURL != nil {
 		// forward the request to the global service
@@ -121,16 +264,20 @@ func processTopologyRequest(tr *topology.Request) ([]byte, *HTTPError) {
 	}
 	if err != nil {
 		klog.Error(err.Error())
+		if errors.Is(err, providers.ErrProviderAPI) {
+			return nil, NewHTTPError(http.StatusBadGateway, err.Error())
+		}
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, NewHTTPError(http.StatusGatewayTimeout, err.Error())
+		}
 		return nil, NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
 
-	data, err := eng.GenerateOutput(ctx, root, tr.Engine.Params)
-	if err != nil {
-		klog.Error(err.Error())
-		return nil, NewHTTPError(http.StatusInternalServerError, err.Error())
+	if len(cacheKey) != 0 {
+		srv.providerCache.set(tr.Provider.Name, cacheKey, root)
 	}
 
-	return data, nil
+	return root, nil
 }
 
 func checkCredentials(payloadCreds, cfgCreds map[string]string) map[string]string {
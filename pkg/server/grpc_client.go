@@ -19,9 +19,12 @@ package server
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
 	"k8s.io/klog/v2"
 
 	"github.com/NVIDIA/topograph/pkg/metrics"
@@ -29,6 +32,12 @@ import (
 	"github.com/NVIDIA/topograph/pkg/topology"
 )
 
+// retries of the requests forwarded to the topology service
+var (
+	forwardRetries    = 5
+	forwardRetryDelay = time.Second
+)
+
 func forwardRequest(ctx context.Context, tr *topology.Request, url string, cis []topology.ComputeInstances) (*topology.Vertex, error) {
 	klog.Infof("Forwarding request to %s", url)
 	conn, err := grpc.NewClient(url, grpc.WithTransportCredentials(insecure.NewCredentials()))
@@ -48,7 +57,7 @@ func forwardRequest(ctx context.Context, tr *topology.Request, url string, cis [
 
 	klog.Infof("Getting topology for instances %v", ids)
 
-	response, err := client.DescribeTopology(ctx, &pb.TopologyRequest{
+	response, err := describeTopology(ctx, client, &pb.TopologyRequest{
 		Provider:    tr.Provider.Name,
 		Region:      "",
 		InstanceIds: ids,
@@ -62,6 +71,34 @@ func forwardRequest(ctx context.Context, tr *topology.Request, url string, cis [
 	return toGraph(response, cis, getTopologyFormat(tr.Engine.Params)), nil
 }
 
+// describeTopology calls the service, retrying the transient errors with exponential backoff
+func describeTopology(ctx context.Context, client pb.TopologyServiceClient, in *pb.TopologyRequest) (*pb.TopologyResponse, error) {
+	delay := forwardRetryDelay
+	for attempt := 0; ; attempt++ {
+		response, err := client.DescribeTopology(ctx, in)
+		if err == nil || attempt >= forwardRetries || !isTransient(err) {
+			return response, err
+		}
+
+		klog.Warningf("Forwarded request failed: %v; retrying in %s", err, delay.String())
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		delay *= 2
+	}
+}
+
+// isTransient returns true if the call may succeed on retry
+func isTransient(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.ResourceExhausted, codes.Aborted:
+		return true
+	}
+	return false
+}
+
 // getTopologyFormat derives topology format from engine parameters: tree (default) or block
 func getTopologyFormat(params map[string]any) string {
 	if len(params) != 0 {
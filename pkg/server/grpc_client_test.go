@@ -17,14 +17,20 @@
 package server
 
 import (
+	"context"
 	"flag"
+	"math/rand"
+	"net"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
 	"k8s.io/klog/v2"
 
 	pb "github.com/NVIDIA/topograph/pkg/protos"
 	"github.com/NVIDIA/topograph/pkg/topology"
+	"github.com/NVIDIA/topograph/pkg/toposim"
 )
 
 // follow example in pkg/toposim/testdata/toposim.yaml
@@ -184,3 +190,60 @@ func TestGetTopologyFormat(t *testing.T) {
 		})
 	}
 }
+
+func TestForwardRequestRetries(t *testing.T) {
+	defer func(retries int, delay time.Duration) {
+		forwardRetries, forwardRetryDelay = retries, delay
+	}(forwardRetries, forwardRetryDelay)
+	forwardRetryDelay = time.Millisecond
+
+	testCases := []struct {
+		name      string
+		errorRate float64
+		retries   int
+		errors    int64
+		err       string
+	}{
+		{
+			// with seed 42, the first two calls fail
+			name:      "Case 1: retry until success",
+			errorRate: 50,
+			retries:   5,
+			errors:    2,
+		},
+		{
+			name:      "Case 2: retries exhausted",
+			errorRate: 100,
+			retries:   2,
+			errors:    3,
+			err:       "failed to forward request: rpc error: code = Unavailable desc = simulated error",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			forwardRetries = tc.retries
+
+			sim, err := toposim.NewServer("../../tests/models/small-tree.yaml", 0,
+				toposim.WithErrorRate(tc.errorRate), toposim.WithRand(rand.New(rand.NewSource(42))))
+			require.NoError(t, err)
+
+			lis, err := net.Listen("tcp", "127.0.0.1:0")
+			require.NoError(t, err)
+			grpcServer := grpc.NewServer()
+			pb.RegisterTopologyServiceServer(grpcServer, sim)
+			go func() { _ = grpcServer.Serve(lis) }()
+			defer grpcServer.Stop()
+
+			cis := []topology.ComputeInstances{{Instances: map[string]string{"I21": "Node201"}}}
+			root, err := forwardRequest(context.TODO(), topology.NewRequest("test", nil, "slurm", nil), lis.Addr().String(), cis)
+			if len(tc.err) != 0 {
+				require.EqualError(t, err, tc.err)
+			} else {
+				require.NoError(t, err)
+				require.NotNil(t, root)
+			}
+			require.Equal(t, tc.errors, sim.SimulatedErrors())
+		})
+	}
+}
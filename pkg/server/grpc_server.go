@@ -0,0 +1,205 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"k8s.io/klog/v2"
+
+	"github.com/NVIDIA/topograph/pkg/config"
+	"github.com/NVIDIA/topograph/pkg/metrics"
+	pb "github.com/NVIDIA/topograph/pkg/protos"
+)
+
+// grpcStatusInterval is the polling interval of the status streamed by Generate
+var grpcStatusInterval = 500 * time.Millisecond
+
+// grpcServer serves the topology requests over gRPC, sharing the request queue of the HTTP server
+type grpcServer struct {
+	pb.UnimplementedTopographServiceServer
+
+	port int
+	srv  *grpc.Server
+}
+
+var grpcSrv *grpcServer
+
+func InitGRPCServer(cfg *config.Config) error {
+	s, err := newGRPCServer(cfg)
+	if err != nil {
+		return err
+	}
+	grpcSrv = s
+	return nil
+}
+
+func newGRPCServer(cfg *config.Config) (*grpcServer, error) {
+	var opts []grpc.ServerOption
+	if cfg.GRPC.SSL {
+		cert, err := tls.LoadX509KeyPair(cfg.SSL.Cert, cfg.SSL.Key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load gRPC server certificate: %v", err)
+		}
+		tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+		if cfg.GRPC.MTLS {
+			if tlsConfig, err = mtlsConfig(cfg.SSL.CaCert); err != nil {
+				return nil, err
+			}
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+	if len(cfg.GRPC.AllowedClients) != 0 {
+		unary, stream := allowGRPCClients(cfg.GRPC.AllowedClients)
+		opts = append(opts, grpc.UnaryInterceptor(unary), grpc.StreamInterceptor(stream))
+	}
+
+	s := &grpcServer{
+		port: cfg.GRPC.Port,
+		srv:  grpc.NewServer(opts...),
+	}
+	pb.RegisterTopographServiceServer(s.srv, s)
+
+	return s, nil
+}
+
+func GetGRPCRunGroup() (func() error, func(error)) {
+	return grpcSrv.Start, grpcSrv.Stop
+}
+
+func (s *grpcServer) Start() error {
+	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", s.port))
+	if err != nil {
+		return err
+	}
+	klog.Infof("Starting gRPC server on port %d", s.port)
+	return s.srv.Serve(lis)
+}
+
+func (s *grpcServer) Stop(err error) {
+	klog.Infof("Stopping gRPC server: %v", err)
+	s.srv.GracefulStop()
+	klog.Infof("Stopped gRPC server")
+}
+
+// Generate submits the topology request and streams its status until the request completes
+func (s *grpcServer) Generate(in *pb.GenerateRequest, stream pb.TopographService_GenerateServer) error {
+	start := time.Now()
+
+	tr, err := parseRequest(in.Payload)
+	if err != nil {
+		var provider, engine string
+		if tr != nil {
+			provider, engine = tr.Provider.Name, tr.Engine.Name
+		}
+		metrics.Add(provider, engine, http.StatusBadRequest, time.Since(start))
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	uid := srv.async.queue.Submit(tr)
+
+	ticker := time.NewTicker(grpcStatusInterval)
+	defer ticker.Stop()
+
+	var last *pb.TopologyStatus
+	for {
+		st := getTopologyStatus(uid)
+		if !proto.Equal(st, last) {
+			if err := stream.Send(st); err != nil {
+				return err
+			}
+			last = st
+		}
+
+		if st.State == stateSucceeded || st.State == stateFailed {
+			return nil
+		}
+
+		select {
+		case <-stream.Context().Done():
+			return status.FromContextError(stream.Context().Err()).Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// GetTopology returns the topology config of the request, mirroring the /v1/topology endpoint
+func (s *grpcServer) GetTopology(ctx context.Context, in *pb.TopologyUID) (*pb.TopologyConfig, error) {
+	if len(in.Uid) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "must specify request uid")
+	}
+
+	res := srv.async.queue.Get(in.Uid)
+	if res.Status == http.StatusNotFound {
+		return nil, status.Error(codes.NotFound, res.Message)
+	}
+
+	cfg := &pb.TopologyConfig{
+		Uid:     in.Uid,
+		Status:  int32(res.Status),
+		Message: res.Message,
+	}
+	if len(res.Message) == 0 {
+		cfg.Config = res.Ret.(*topologyResult).data
+	}
+
+	return cfg, nil
+}
+
+// getTopologyStatus returns the status of the request from the queue history,
+// falling back to the result store once the request is pruned from the history
+func getTopologyStatus(uid string) *pb.TopologyStatus {
+	for _, info := range getRequests(srv.async.queue.Status()) {
+		if info.UID == uid {
+			return &pb.TopologyStatus{
+				Uid:     uid,
+				State:   info.State,
+				Stage:   info.Stage,
+				Status:  int32(info.Status),
+				Message: info.Message,
+			}
+		}
+	}
+
+	res := srv.async.queue.Get(uid)
+	st := &pb.TopologyStatus{
+		Uid:     uid,
+		Status:  int32(res.Status),
+		Message: res.Message,
+	}
+	switch res.Status {
+	case http.StatusOK:
+		st.State = stateSucceeded
+	case http.StatusAccepted:
+		st.State = statePending
+		st.Message = ""
+	default:
+		st.State = stateFailed
+	}
+
+	return st
+}
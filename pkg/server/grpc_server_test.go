@@ -0,0 +1,207 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+
+	"github.com/NVIDIA/topograph/pkg/config"
+	pb "github.com/NVIDIA/topograph/pkg/protos"
+)
+
+func TestGRPCServer(t *testing.T) {
+	httpPort, err := getAvailablePort()
+	require.NoError(t, err)
+	grpcPort, err := getAvailablePort()
+	require.NoError(t, err)
+
+	cfg := &config.Config{
+		HTTP: config.Endpoint{
+			Port: httpPort,
+		},
+		GRPC: &config.Endpoint{
+			Port: grpcPort,
+		},
+		RequestAggregationDelay: time.Second,
+	}
+
+	srv = initHttpServer(context.TODO(), cfg)
+	defer srv.async.queue.Shutdown()
+
+	gs, err := newGRPCServer(cfg)
+	require.NoError(t, err)
+	defer gs.Stop(nil)
+	go func() { _ = gs.Start() }()
+
+	conn, err := grpc.NewClient(fmt.Sprintf("localhost:%d", grpcPort), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer func() { _ = conn.Close() }()
+
+	client := pb.NewTopographServiceClient(conn)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// submit the request and stream its status until completion
+	stream, err := client.Generate(ctx, &pb.GenerateRequest{
+		Payload: []byte(`{"provider":{"name":"test"},"engine":{"name":"slurm"}}`),
+	}, grpc.WaitForReady(true))
+	require.NoError(t, err)
+
+	var states []string
+	var last *pb.TopologyStatus
+	for {
+		st, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		states = append(states, st.State)
+		last = st
+	}
+	require.NotNil(t, last)
+	require.Equal(t, statePending, states[0])
+	require.Equal(t, stateSucceeded, last.State)
+	require.Equal(t, int32(http.StatusOK), last.Status)
+
+	res, err := client.GetTopology(ctx, &pb.TopologyUID{Uid: last.Uid})
+	require.NoError(t, err)
+	require.Equal(t, int32(http.StatusOK), res.Status)
+	require.Equal(t, `SwitchName=S1 Switches=S[2-3]
+SwitchName=S2 Nodes=Node[201-202],Node205
+SwitchName=S3 Nodes=Node[304-306]
+`, string(res.Config))
+
+	// invalid request
+	stream, err = client.Generate(ctx, &pb.GenerateRequest{
+		Payload: []byte(`{"provider":{"name":"unknown"},"engine":{"name":"slurm"}}`),
+	})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+	require.Equal(t, "unsupported provider unknown", status.Convert(err).Message())
+
+	// unknown request ID
+	_, err = client.GetTopology(ctx, &pb.TopologyUID{Uid: "unknown"})
+	require.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestGRPCMTLS(t *testing.T) {
+	ca := newTestCA(t)
+	dir := t.TempDir()
+	caPath := filepath.Join(dir, "ca-cert.pem")
+	require.NoError(t, os.WriteFile(caPath, ca.pem, 0600))
+	certPath, keyPath := ca.serverCert(t, dir)
+
+	httpPort, err := getAvailablePort()
+	require.NoError(t, err)
+	grpcPort, err := getAvailablePort()
+	require.NoError(t, err)
+
+	cfg := &config.Config{
+		HTTP: config.Endpoint{
+			Port: httpPort,
+		},
+		GRPC: &config.Endpoint{
+			Port:           grpcPort,
+			SSL:            true,
+			MTLS:           true,
+			AllowedClients: []string{"slurmctld"},
+		},
+		SSL:                     &config.SSL{Cert: certPath, Key: keyPath, CaCert: caPath},
+		RequestAggregationDelay: time.Second,
+	}
+
+	srv = initHttpServer(context.TODO(), cfg)
+	defer srv.async.queue.Shutdown()
+
+	gs, err := newGRPCServer(cfg)
+	require.NoError(t, err)
+	defer gs.Stop(nil)
+	go func() { _ = gs.Start() }()
+
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+
+	testCases := []struct {
+		name      string
+		certs     []tls.Certificate
+		code      codes.Code
+		handshake bool
+	}{
+		{
+			name:  "Case 1: allowed client",
+			certs: []tls.Certificate{ca.clientCert(t, "slurmctld")},
+			code:  codes.NotFound,
+		},
+		{
+			name:  "Case 2: client not allowed",
+			certs: []tls.Certificate{ca.clientCert(t, "intruder")},
+			code:  codes.PermissionDenied,
+		},
+		{
+			name:      "Case 3: no client certificate",
+			handshake: true,
+		},
+		{
+			name:      "Case 4: client certificate of another CA",
+			certs:     []tls.Certificate{newTestCA(t).clientCert(t, "slurmctld")},
+			handshake: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			creds := credentials.NewTLS(&tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				RootCAs:      pool,
+				Certificates: tc.certs,
+				ServerName:   "localhost",
+			})
+			conn, err := grpc.NewClient(fmt.Sprintf("localhost:%d", grpcPort), grpc.WithTransportCredentials(creds))
+			require.NoError(t, err)
+			defer func() { _ = conn.Close() }()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			// the unknown request ID is reported only to the allowed client
+			_, err = pb.NewTopographServiceClient(conn).GetTopology(ctx, &pb.TopologyUID{Uid: "unknown"})
+			if tc.handshake {
+				// the connection is rejected by the TLS handshake
+				require.Error(t, err)
+				require.NotEqual(t, codes.NotFound, status.Code(err))
+				return
+			}
+			require.Equal(t, tc.code, status.Code(err))
+		})
+	}
+}
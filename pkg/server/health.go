@@ -0,0 +1,128 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"github.com/NVIDIA/topograph/pkg/providers"
+	"github.com/NVIDIA/topograph/pkg/registry"
+)
+
+// ProviderHealth is the response of the /v1/providers/{name}/healthz endpoint
+type ProviderHealth struct {
+	Provider string  `json:"provider"`
+	Latency  float64 `json:"latency_seconds"`
+}
+
+// providerHealthz loads the provider with the configured credentials and validates its API access
+// with a cheap API request. The query parameters are passed as the provider parameters.
+func providerHealthz(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	name := r.PathValue("name")
+	params := make(map[string]any)
+	for key, values := range r.URL.Query() {
+		params[key] = values[len(values)-1]
+	}
+
+	latency, httpErr := checkProviderHealth(r.Context(), name, params)
+	if httpErr != nil {
+		http.Error(w, httpErr.Message, httpErr.Code)
+		return
+	}
+
+	writeJSON(w, &ProviderHealth{Provider: name, Latency: latency.Seconds()})
+}
+
+// checkProviderHealth returns the latency of the provider health check
+func checkProviderHealth(ctx context.Context, name string, params map[string]any) (time.Duration, *HTTPError) {
+	prvLoader, err := registry.Providers.Get(name)
+	if err != nil {
+		if errors.Is(err, providers.ErrUnsupportedProvider) {
+			return 0, NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		return 0, NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	creds, httpErr := getCredentials(ctx, nil)
+	if httpErr != nil {
+		return 0, httpErr
+	}
+
+	prv, err := prvLoader(ctx, providers.Config{
+		Creds:  creds,
+		Params: params,
+		Models: srv.cfg.Models,
+	})
+	if err != nil {
+		return 0, NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	checker, ok := prv.(providers.HealthChecker)
+	if !ok {
+		return 0, NewHTTPError(http.StatusNotImplemented, fmt.Sprintf("provider %s does not support health checks", name))
+	}
+
+	if srv.cfg.ProviderTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, srv.cfg.ProviderTimeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	if err := checker.HealthCheck(ctx); err != nil {
+		klog.Errorf("Health check of provider %s failed: %v", name, err)
+		return 0, NewHTTPError(upstreamStatus(err), err.Error())
+	}
+
+	return time.Since(start), nil
+}
+
+// upstreamStatus returns the HTTP status of a failed provider API request:
+// the status of the provider API response, if known, 504 on timeout, and 502 otherwise
+func upstreamStatus(err error) int {
+	// AWS SDK response errors
+	var awsErr interface{ HTTPStatusCode() int }
+	if errors.As(err, &awsErr) && awsErr.HTTPStatusCode() >= http.StatusBadRequest {
+		return awsErr.HTTPStatusCode()
+	}
+	// OCI SDK service errors
+	var ociErr interface{ GetHTTPStatusCode() int }
+	if errors.As(err, &ociErr) && ociErr.GetHTTPStatusCode() >= http.StatusBadRequest {
+		return ociErr.GetHTTPStatusCode()
+	}
+	// Google API errors
+	var gcpErr interface{ HTTPCode() int }
+	if errors.As(err, &gcpErr) && gcpErr.HTTPCode() >= http.StatusBadRequest {
+		return gcpErr.HTTPCode()
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return http.StatusGatewayTimeout
+	}
+	return http.StatusBadGateway
+}
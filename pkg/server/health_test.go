@@ -0,0 +1,143 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/NVIDIA/topograph/internal/component"
+	"github.com/NVIDIA/topograph/pkg/config"
+	enginefake "github.com/NVIDIA/topograph/pkg/engines/fake"
+	"github.com/NVIDIA/topograph/pkg/providers"
+	"github.com/NVIDIA/topograph/pkg/providers/fake"
+	"github.com/NVIDIA/topograph/pkg/registry"
+)
+
+// responseError is an upstream API error with the HTTP status of the response
+type responseError struct {
+	code int
+}
+
+func (e *responseError) Error() string {
+	return "AccessDenied: not authorized"
+}
+
+func (e *responseError) HTTPStatusCode() int {
+	return e.code
+}
+
+func TestProviderHealthz(t *testing.T) {
+	srv = &HttpServer{
+		cfg:        &config.Config{},
+		placements: newPlacements(),
+	}
+	defer func() { srv = nil }()
+
+	prv := fake.NewTree()
+	registerFakes(t, prv, enginefake.New())
+	registry.Providers.Register(component.Named("blocking", func(context.Context, providers.Config) (providers.Provider, error) {
+		return blockingProvider{}, nil
+	}))
+	t.Cleanup(func() { delete(registry.Providers, "blocking") })
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/providers/{name}/healthz", providerHealthz)
+
+	testCases := []struct {
+		name   string
+		method string
+		prv    string
+		query  string
+		health error
+		code   int
+	}{
+		{
+			name:   "Case 1: invalid method",
+			method: http.MethodPost,
+			prv:    "fake",
+			code:   http.StatusMethodNotAllowed,
+		},
+		{
+			name:   "Case 2: unknown provider",
+			method: http.MethodGet,
+			prv:    "unknown",
+			code:   http.StatusNotFound,
+		},
+		{
+			name:   "Case 3: health check not supported",
+			method: http.MethodGet,
+			prv:    "blocking",
+			code:   http.StatusNotImplemented,
+		},
+		{
+			name:   "Case 4: healthy provider",
+			method: http.MethodGet,
+			prv:    "fake",
+			code:   http.StatusOK,
+		},
+		{
+			name:   "Case 5: upstream error",
+			method: http.MethodGet,
+			prv:    "fake",
+			health: &responseError{code: http.StatusForbidden},
+			code:   http.StatusForbidden,
+		},
+		{
+			name:   "Case 6: unknown error",
+			method: http.MethodGet,
+			prv:    "fake",
+			health: errors.New("connection refused"),
+			code:   http.StatusBadGateway,
+		},
+		{
+			name:   "Case 7: simulation provider",
+			method: http.MethodGet,
+			prv:    "aws-sim",
+			query:  "model_path=../../tests/models/medium.yaml",
+			code:   http.StatusOK,
+		},
+		{
+			name:   "Case 8: simulation provider without model",
+			method: http.MethodGet,
+			prv:    "aws-sim",
+			code:   http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			prv.WithHealthCheck(tc.health)
+
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, httptest.NewRequest(tc.method, "/v1/providers/"+tc.prv+"/healthz?"+tc.query, nil))
+			require.Equal(t, tc.code, w.Code, w.Body.String())
+
+			if tc.code == http.StatusOK {
+				var health ProviderHealth
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &health))
+				require.Equal(t, tc.prv, health.Provider)
+			}
+		})
+	}
+}
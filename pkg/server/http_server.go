@@ -17,10 +17,13 @@
 package server
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -30,17 +33,32 @@ import (
 	"github.com/NVIDIA/topograph/pkg/metrics"
 	"github.com/NVIDIA/topograph/pkg/registry"
 	"github.com/NVIDIA/topograph/pkg/topology"
+	"github.com/NVIDIA/topograph/pkg/translate"
 )
 
 type HttpServer struct {
-	ctx   context.Context
-	cfg   *config.Config
-	srv   *http.Server
-	async *asyncController
+	ctx        context.Context
+	cfg        *config.Config
+	srv        *http.Server
+	async      *asyncController
+	notifier   *notifier
+	placements *placements
+	snapshots  *snapshotStore
+
+	// providerCache, if set, keeps the provider topology for the provider_cache_ttl
+	providerCache *providerCache
+
+	// secretCreds, if set, reads the provider credentials from a Kubernetes Secret
+	secretCreds *secretCredentials
 }
 
 var srv *HttpServer
 
+const (
+	streamChunkSize    = 1 << 20
+	streamWriteTimeout = 10 * time.Minute
+)
+
 func InitHttpServer(ctx context.Context, cfg *config.Config) {
 	srv = initHttpServer(ctx, cfg)
 }
@@ -49,20 +67,51 @@ func initHttpServer(ctx context.Context, cfg *config.Config) *HttpServer {
 	mux := http.NewServeMux()
 
 	mux.HandleFunc("/v1/generate", generate)
+	mux.HandleFunc("/v1/diff", diff)
+	mux.HandleFunc("/v1/rollback", rollback)
 	mux.HandleFunc("/v1/topology", getresult)
+	mux.HandleFunc("/v1/topology/dot", getdot)
+	mux.HandleFunc("/v1/status", getstatus)
+	mux.HandleFunc("/v1/requests", getrequests)
+	mux.HandleFunc("/v1/requests/{uid}", getrequest)
+	mux.HandleFunc("/v1/flush", flush)
+	mux.HandleFunc("/v1/schema", getschema)
+	mux.HandleFunc("/v1/snapshot", getsnapshot)
+	mux.HandleFunc("/v1/providers/{name}/healthz", providerHealthz)
 	mux.HandleFunc("/healthz", healthz)
 	mux.Handle("/metrics", promhttp.Handler())
 
+	queue := NewTrailingDelayQueue(processRequest, cfg.RequestAggregationDelay)
+	queue.SetHistoryTTL(cfg.RequestHistoryTTL)
+	queue.SetMergeFunc(mergeRequests)
+
+	var handler http.Handler = mux
+	if len(cfg.HTTP.AllowedClients) != 0 {
+		handler = allowClients(mux, cfg.HTTP.AllowedClients)
+	}
+
+	var secretCreds *secretCredentials
+	if cfg.CredsSecret != nil {
+		// the reference is checked by the config validation
+		namespace, name, _ := config.SplitSecretRef(*cfg.CredsSecret)
+		secretCreds = newSecretCredentials(namespace, name)
+	}
+
 	return &HttpServer{
 		ctx: ctx,
 		cfg: cfg,
 		srv: &http.Server{
 			Addr:    fmt.Sprintf(":%d", cfg.HTTP.Port),
-			Handler: mux,
+			Handler: handler,
 		},
 		async: &asyncController{
-			queue: NewTrailingDelayQueue(processRequest, cfg.RequestAggregationDelay),
+			queue: queue,
 		},
+		notifier:      newNotifier(cfg.Notify),
+		placements:    newPlacements(),
+		snapshots:     newSnapshotStore(cfg.Snapshot),
+		providerCache: newProviderCache(cfg.ProviderCacheTTL, cfg.ProviderCacheMaxEntries),
+		secretCreds:   secretCreds,
 	}
 }
 
@@ -72,6 +121,13 @@ func GetRunGroup() (func() error, func(error)) {
 
 func (s *HttpServer) Start() error {
 	if s.cfg.HTTP.SSL {
+		if s.cfg.HTTP.MTLS {
+			tlsConfig, err := mtlsConfig(s.cfg.SSL.CaCert)
+			if err != nil {
+				return err
+			}
+			s.srv.TLSConfig = tlsConfig
+		}
 		klog.Infof("Starting HTTPS server on port %d", s.cfg.HTTP.Port)
 		return s.srv.ListenAndServeTLS(s.cfg.SSL.Cert, s.cfg.SSL.Key)
 	}
@@ -117,26 +173,37 @@ func readRequest(w http.ResponseWriter, r *http.Request) *topology.Request {
 	}
 	defer func() { _ = r.Body.Close() }()
 
+	tr, err := parseRequest(body)
+	if err != nil {
+		var provider, engine string
+		if tr != nil {
+			provider, engine = tr.Provider.Name, tr.Engine.Name
+		}
+		return httpError(w, provider, engine, err.Error(), http.StatusBadRequest, time.Since(start))
+	}
+
+	return tr
+}
+
+// parseRequest decodes and validates the topology request payload.
+// The returned request is set on validation errors, and nil on decoding errors.
+func parseRequest(body []byte) (*topology.Request, error) {
 	tr, err := topology.GetTopologyRequest(body)
 	if err != nil {
-		return httpError(w, "", "", err.Error(), http.StatusBadRequest, time.Since(start))
+		return nil, err
 	}
 
 	// If provider and engine are not passed in the payload, use the ones specified in the config
 	if len(tr.Provider.Name) == 0 {
 		tr.Provider.Name = srv.cfg.Provider
 	}
-	if len(tr.Engine.Name) == 0 {
+	if len(tr.Engine.Name) == 0 && len(tr.Engines) == 0 {
 		tr.Engine.Name = srv.cfg.Engine
 	}
 
 	klog.Info(tr.String())
 
-	if err = validate(tr); err != nil {
-		return httpError(w, tr.Provider.Name, tr.Engine.Name, err.Error(), http.StatusBadRequest, time.Since(start))
-	}
-
-	return tr
+	return tr, validate(tr)
 }
 
 func validate(tr *topology.Request) error {
@@ -150,9 +217,22 @@ func validate(tr *topology.Request) error {
 		}
 	}
 
-	_, exists = registry.Engines[tr.Engine.Name]
+	if len(tr.Engines) == 0 {
+		return validateEngine(tr.Engine.Name)
+	}
+	for _, eng := range tr.Engines {
+		if err := validateEngine(eng.Name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func validateEngine(name string) error {
+	_, exists := registry.Engines[name]
 	if !exists {
-		switch tr.Engine.Name {
+		switch name {
 
 		// case common.EngineSLURM, common.EngineTest:
 		// 	//nop
@@ -165,7 +245,7 @@ func validate(tr *topology.Request) error {
 		case "":
 			return fmt.Errorf("no engine given for topology request")
 		default:
-			return fmt.Errorf("unsupported engine %s", tr.Engine.Name)
+			return fmt.Errorf("unsupported engine %s", name)
 		}
 	}
 	// TODO: Validate K8s params
@@ -189,10 +269,80 @@ func getresult(w http.ResponseWriter, r *http.Request) {
 	res := srv.async.queue.Get(uid)
 	if len(res.Message) != 0 {
 		http.Error(w, res.Message, res.Status)
-	} else {
-		w.WriteHeader(res.Status)
-		_, _ = w.Write(res.Ret.([]byte))
+		return
+	}
+
+	data := res.Ret.(*topologyResult).data
+	if r.URL.Query().Get("stream") == "true" {
+		streamResult(w, data)
+		return
+	}
+
+	// the result is immutable per request ID, so it is safe to serve byte ranges
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(data))
+}
+
+// streamResult writes the result in chunks, flushing after each chunk
+func streamResult(w http.ResponseWriter, data []byte) {
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Now().Add(streamWriteTimeout)); err != nil {
+		klog.V(4).Infof("Unable to set write deadline: %v", err)
+	}
+
+	w.Header().Set("Accept-Ranges", "bytes")
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.WriteHeader(http.StatusOK)
+
+	for len(data) > 0 {
+		n := min(len(data), streamChunkSize)
+		if _, err := w.Write(data[:n]); err != nil {
+			klog.Errorf("Failed to stream topology result: %v", err)
+			return
+		}
+		if err := rc.Flush(); err != nil {
+			klog.V(4).Infof("Unable to flush response: %v", err)
+		}
+		data = data[n:]
+	}
+}
+
+func getdot(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	uid := r.URL.Query().Get(topology.KeyUID)
+	if len(uid) == 0 {
+		http.Error(w, "must specify request uid", http.StatusBadRequest)
+		return
+	}
+
+	res := srv.async.queue.Get(uid)
+	if len(res.Message) != 0 {
+		http.Error(w, res.Message, res.Status)
+		return
+	}
+
+	buf := &bytes.Buffer{}
+	if err := translate.ToDOT(buf, res.Ret.(*topologyResult).root); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
 	}
+	w.Header().Set("Content-Type", "text/vnd.graphviz")
+	w.WriteHeader(res.Status)
+	_, _ = w.Write(buf.Bytes())
+}
+
+func getschema(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(registry.Schema())
 }
 
 func httpError(w http.ResponseWriter, provider, engine, msg string, code int, duration time.Duration) *topology.Request {
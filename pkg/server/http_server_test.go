@@ -19,15 +19,22 @@ package server
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
 	"testing"
 	"time"
 
 	"github.com/NVIDIA/topograph/pkg/config"
+	"github.com/NVIDIA/topograph/pkg/registry"
+	"github.com/NVIDIA/topograph/pkg/topology"
 	"github.com/stretchr/testify/require"
 )
 
@@ -60,6 +67,8 @@ func TestServer(t *testing.T) {
 	// let the server start
 	time.Sleep(time.Second)
 
+	dryRunPath := filepath.Join(t.TempDir(), "topology.conf")
+
 	testCases := []struct {
 		name     string
 		endpoint string
@@ -155,30 +164,279 @@ SwitchName=sw14 Nodes=n14-[1-2]
 }
 `,
 			expected: `# block001=nvl-1-1
-BlockName=block001 Nodes=n1-1-0[1-8]
+BlockName=block001 Nodes=n1-1-[01-08]
 # block002=nvl-1-2
-BlockName=block002 Nodes=n1-2-0[1-8]
+BlockName=block002 Nodes=n1-2-[01-08]
 # block003=nvl-2-1
-BlockName=block003 Nodes=n2-1-0[1-8]
+BlockName=block003 Nodes=n2-1-[01-08]
 # block004=nvl-2-2
-BlockName=block004 Nodes=n2-2-0[1-8]
+BlockName=block004 Nodes=n2-2-[01-08]
 # block005=nvl-3-1
-BlockName=block005 Nodes=n3-1-0[1-8]
+BlockName=block005 Nodes=n3-1-[01-08]
 # block006=nvl-3-2
-BlockName=block006 Nodes=n3-2-0[1-8]
+BlockName=block006 Nodes=n3-2-[01-08]
 # block007=nvl-4-1
-BlockName=block007 Nodes=n4-1-0[1-8]
+BlockName=block007 Nodes=n4-1-[01-08]
 # block008=nvl-4-2
-BlockName=block008 Nodes=n4-2-0[1-8]
+BlockName=block008 Nodes=n4-2-[01-08]
 # block009=nvl-5-1
-BlockName=block009 Nodes=n5-1-0[1-8]
+BlockName=block009 Nodes=n5-1-[01-08]
 # block010=nvl-5-2
-BlockName=block010 Nodes=n5-2-0[1-8]
+BlockName=block010 Nodes=n5-2-[01-08]
 # block011=nvl-6-1
-BlockName=block011 Nodes=n6-1-0[1-8]
+BlockName=block011 Nodes=n6-1-[01-08]
 # block012=nvl-6-2
-BlockName=block012 Nodes=n6-2-0[1-8]
+BlockName=block012 Nodes=n6-2-[01-08]
 BlockSizes=8,16,32
+`,
+		},
+		{
+			name:     "Case 6: send dry-run request for tree topology",
+			endpoint: "generate",
+			payload: fmt.Sprintf(`
+{
+  "provider": {
+    "name": "test"
+  },
+  "engine": {
+    "name": "slurm",
+    "params": {
+      "topology_config_path": %q,
+      "reconfigure": true,
+      "dry_run": true
+    }
+  }
+}
+`, dryRunPath),
+			expected: `
+###############################################################
+# Slurm's network topology configuration file for use with the
+# topology/tree plugin
+###############################################################
+SwitchName=S1 Switches=S[2-3]
+SwitchName=S2 Nodes=Node[201-202],Node205
+SwitchName=S3 Nodes=Node[304-306]
+`,
+		},
+		{
+			name:     "Case 7: send test request for topology graph",
+			endpoint: "dot",
+			payload: `
+{
+  "provider": {
+    "name": "test"
+  },
+  "engine": {
+    "name": "slurm"
+  }
+}
+`,
+			expected: `digraph topology {
+	"S1" [shape=box];
+	"S2" [shape=box];
+	"S3" [shape=box];
+	"Node201" [shape=ellipse];
+	"Node202" [shape=ellipse];
+	"Node205" [shape=ellipse];
+	"Node304" [shape=ellipse];
+	"Node305" [shape=ellipse];
+	"Node306" [shape=ellipse];
+	"S1" -> "S2";
+	"S1" -> "S3";
+	"S2" -> "Node201";
+	"S2" -> "Node202";
+	"S2" -> "Node205";
+	"S3" -> "Node304";
+	"S3" -> "Node305";
+	"S3" -> "Node306";
+}
+`,
+		},
+		{
+			name:     "Case 8: model-backed request for block topology",
+			endpoint: "generate",
+			payload: `
+{
+  "provider": {
+    "name": "test",
+    "params": {
+      "model_path": "../../tests/models/medium.yaml"
+    }
+  },
+  "engine": {
+    "name": "slurm",
+    "params": {
+      "plugin": "topology/block",
+      "block_sizes": "2,4"
+    }
+  }
+}
+`,
+			expected: `BlockName=cb11 Nodes=n11-[1-2]
+BlockName=cb12 Nodes=n12-[1-2]
+BlockName=cb13 Nodes=n13-[1-2]
+BlockName=cb14 Nodes=n14-[1-2]
+BlockSizes=2,4
+`,
+		},
+		{
+			name:     "Case 9: model-backed request for block topology in yaml format",
+			endpoint: "generate",
+			payload: `
+{
+  "provider": {
+    "name": "test",
+    "params": {
+      "model_path": "../../tests/models/medium.yaml"
+    }
+  },
+  "engine": {
+    "name": "slurm",
+    "params": {
+      "plugin": "topology/block",
+      "block_sizes": "2,4",
+      "format": "yaml"
+    }
+  }
+}
+`,
+			expected: `- topology: block
+  cluster_default: true
+  block:
+    blocks:
+      - block: cb11
+        nodes: n11-[1-2]
+      - block: cb12
+        nodes: n12-[1-2]
+      - block: cb13
+        nodes: n13-[1-2]
+      - block: cb14
+        nodes: n14-[1-2]
+    block_sizes:
+      - 2
+      - 4
+`,
+		},
+		{
+			name:     "Case 10: model-backed request for tree topology in yaml format",
+			endpoint: "generate",
+			payload: `
+{
+  "provider": {
+    "name": "test",
+    "params": {
+      "model_path": "../../tests/models/medium.yaml"
+    }
+  },
+  "engine": {
+    "name": "slurm",
+    "params": {
+      "format": "yaml"
+    }
+  }
+}
+`,
+			expected: `- topology: tree
+  cluster_default: true
+  tree:
+    switches:
+      - switch: sw3
+        children: sw[21-22]
+      - switch: sw21
+        children: sw[11-12]
+      - switch: sw22
+        children: sw[13-14]
+      - switch: sw11
+        nodes: n11-[1-2]
+      - switch: sw12
+        nodes: n12-[1-2]
+      - switch: sw13
+        nodes: n13-[1-2]
+      - switch: sw14
+        nodes: n14-[1-2]
+`,
+		},
+		{
+			name:     "Case 11: send test request with node pattern",
+			endpoint: "generate",
+			payload: `
+{
+  "provider": {
+    "name": "test"
+  },
+  "engine": {
+    "name": "slurm"
+  },
+  "nodes": [
+    {
+      "region": "region1",
+      "node_pattern": "Node[201-202],Node205,Node[304-306]"
+    }
+  ]
+}
+`,
+			expected: `SwitchName=S1 Switches=S[2-3]
+SwitchName=S2 Nodes=Node[201-202],Node205
+SwitchName=S3 Nodes=Node[304-306]
+`,
+		},
+		{
+			name:     "Case 12: send test request with inline topology",
+			endpoint: "generate",
+			payload: `
+{
+  "provider": {
+    "name": "test",
+    "params": {
+      "topology": {
+        "switches": [
+          {"name": "sw1", "switches": ["sw2", "sw3"]},
+          {"name": "sw2", "capacity_blocks": ["cb1"]},
+          {"name": "sw3", "capacity_blocks": ["cb2"]}
+        ],
+        "capacity_blocks": [
+          {"name": "cb1", "nvlink": "nvl1", "nodes": ["n1", "n2"]},
+          {"name": "cb2", "nvlink": "nvl2", "nodes": ["n3"]}
+        ]
+      }
+    }
+  },
+  "engine": {
+    "name": "slurm"
+  }
+}
+`,
+			expected: `SwitchName=sw1 Switches=sw[2-3]
+SwitchName=sw2 Nodes=n[1-2]
+SwitchName=sw3 Nodes=n3
+`,
+		},
+		{
+			name:     "Case 13: send static provider request for a subset of nodes",
+			endpoint: "generate",
+			payload: `
+{
+  "provider": {
+    "name": "static",
+    "params": {
+      "topology_path": "../../tests/models/medium.yaml"
+    }
+  },
+  "engine": {
+    "name": "slurm"
+  },
+  "nodes": [
+    {
+      "region": "us-west",
+      "node_pattern": "n11-[1-2],n12-1"
+    }
+  ]
+}
+`,
+			expected: `SwitchName=sw3 Switches=sw21
+SwitchName=sw21 Switches=sw[11-12]
+SwitchName=sw11 Nodes=n11-[1-2]
+SwitchName=sw12 Nodes=n12-1
 `,
 		},
 	}
@@ -191,7 +449,7 @@ BlockSizes=8,16,32
 			resp, err = http.Get(baseURL + "/invalid")
 		case "healthz":
 			resp, err = http.Get(baseURL + "/healthz")
-		case "generate":
+		case "generate", "dot":
 			// send topology request
 			resp, err = http.Post(baseURL+"/v1/generate", "application/json", bytes.NewBuffer([]byte(tc.payload)))
 			require.NoError(t, err)
@@ -210,7 +468,11 @@ BlockSizes=8,16,32
 			params := url.Values{}
 			params.Add("uid", out)
 
-			fullURL := fmt.Sprintf("%s?%s", baseURL+"/v1/topology", params.Encode())
+			path := "/v1/topology"
+			if tc.endpoint == "dot" {
+				path = "/v1/topology/dot"
+			}
+			fullURL := fmt.Sprintf("%s?%s", baseURL+path, params.Encode())
 			resp, err = http.Get(fullURL)
 
 		default:
@@ -224,4 +486,239 @@ BlockSizes=8,16,32
 		require.NoError(t, err)
 		require.Equal(t, tc.expected, string(body))
 	}
+
+	// dry run must not write the topology config
+	require.NoFileExists(t, dryRunPath)
+}
+
+func getTestResult(t *testing.T, uid string, query string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/v1/topology?uid="+uid+query, nil)
+	for key, val := range headers {
+		req.Header.Set(key, val)
+	}
+	rec := httptest.NewRecorder()
+	getresult(rec, req)
+	return rec
+}
+
+func TestGetResult(t *testing.T) {
+	cfg := &config.Config{
+		RequestAggregationDelay: time.Second,
+	}
+	srv = initHttpServer(context.TODO(), cfg)
+	defer srv.async.queue.Shutdown()
+
+	data := bytes.Repeat([]byte("SwitchName=S1 Nodes=Node[001-100]\n"), 100000)
+	uid := "test-uid"
+	srv.async.queue.store.Add(uid, &Completion{Ret: &topologyResult{data: data}, Status: http.StatusOK})
+
+	// full download
+	rec := getTestResult(t, uid, "", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "bytes", rec.Header().Get("Accept-Ranges"))
+	require.Equal(t, data, rec.Body.Bytes())
+
+	// ranged request resuming after a partial read
+	partial := 1000
+	rec = getTestResult(t, uid, "", map[string]string{"Range": fmt.Sprintf("bytes=%d-", partial)})
+	require.Equal(t, http.StatusPartialContent, rec.Code)
+	require.Equal(t, fmt.Sprintf("bytes %d-%d/%d", partial, len(data)-1, len(data)), rec.Header().Get("Content-Range"))
+	require.Equal(t, data, append(data[:partial:partial], rec.Body.Bytes()...))
+
+	// streamed download
+	rec = getTestResult(t, uid, "&stream=true", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, strconv.Itoa(len(data)), rec.Header().Get("Content-Length"))
+	require.True(t, rec.Flushed)
+	require.Equal(t, data, rec.Body.Bytes())
+
+	// unknown request ID
+	rec = getTestResult(t, "unknown", "", nil)
+	require.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestGetSchema(t *testing.T) {
+	rec := httptest.NewRecorder()
+	getschema(rec, httptest.NewRequest(http.MethodGet, "/v1/schema", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	expected, err := json.Marshal(registry.Schema())
+	require.NoError(t, err)
+	require.JSONEq(t, string(expected), rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	getschema(rec, httptest.NewRequest(http.MethodPost, "/v1/schema", nil))
+	require.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
+
+func TestGenerateNodePattern(t *testing.T) {
+	srv = &HttpServer{cfg: &config.Config{}}
+	defer func() { srv = nil }()
+
+	payload := `
+{
+  "provider": {
+    "name": "test"
+  },
+  "engine": {
+    "name": "slurm"
+  },
+  "nodes": [
+    {
+      "region": "region1",
+      "instances": {
+        "Node201": "Node201"
+      },
+      "node_pattern": "Node[201-202]"
+    }
+  ]
+}
+`
+	req := httptest.NewRequest(http.MethodPost, "/v1/generate", bytes.NewBufferString(payload))
+	rec := httptest.NewRecorder()
+	generate(rec, req)
+
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	require.Equal(t, "failed to parse payload: region \"region1\": instances and node_pattern are mutually exclusive\n", rec.Body.String())
+}
+
+func TestInlineTopology(t *testing.T) {
+	srv = &HttpServer{
+		cfg:        &config.Config{},
+		placements: newPlacements(),
+	}
+	defer func() { srv = nil }()
+
+	model := `
+switches:
+- name: sw1
+  capacity_blocks: [cb1, cb2]
+capacity_blocks:
+- name: cb1
+  nvlink: nvl1
+  nodes: [n1, n2]
+- name: cb2
+  nvlink: nvl2
+  nodes: [n3]
+`
+
+	testCases := []struct {
+		name      string
+		prvParams map[string]any
+		engParams map[string]any
+		output    string
+		code      int
+	}{
+		{
+			name:      "Case 1: YAML topology",
+			prvParams: map[string]any{"topology": model},
+			output:    "SwitchName=sw1 Nodes=n[1-3]\n",
+		},
+		{
+			name:      "Case 2: YAML topology with block plugin",
+			prvParams: map[string]any{"topology": model},
+			engParams: map[string]any{"plugin": "topology/block"},
+			output:    "BlockName=cb1 Nodes=n[1-2]\nBlockName=cb2 Nodes=n3\nBlockSizes=1\n",
+		},
+		{
+			name:      "Case 3: undefined child switch",
+			prvParams: map[string]any{"topology": map[string]any{"switches": []any{map[string]any{"name": "sw1", "switches": []any{"sw2"}}}}},
+			code:      http.StatusBadRequest,
+		},
+		{
+			name:      "Case 4: invalid document",
+			prvParams: map[string]any{"topology": "switches: [\n"},
+			code:      http.StatusBadRequest,
+		},
+		{
+			name:      "Case 5: unsupported type",
+			prvParams: map[string]any{"topology": 5},
+			code:      http.StatusBadRequest,
+		},
+		{
+			name:      "Case 6: topology and model path",
+			prvParams: map[string]any{"topology": model, "model_path": "../../tests/models/medium.yaml"},
+			code:      http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tr := topology.NewRequest("test", nil, "slurm", tc.engParams)
+			tr.Provider.Params = tc.prvParams
+
+			res, err := processTopologyRequest(tr)
+			if tc.code != 0 {
+				require.NotNil(t, err)
+				require.Equal(t, tc.code, err.Code)
+				return
+			}
+			require.Nil(t, err)
+			require.Equal(t, tc.output, string(res.data))
+		})
+	}
+}
+
+func TestStaticTopology(t *testing.T) {
+	srv = &HttpServer{
+		cfg:        &config.Config{},
+		placements: newPlacements(),
+	}
+	defer func() { srv = nil }()
+
+	path := filepath.Join(t.TempDir(), "topology.yaml")
+	nodes := map[string]string{"n1": "n1", "n2": "n2", "n3": "n3"}
+
+	testCases := []struct {
+		name   string
+		model  string
+		output string
+	}{
+		{
+			name: "Case 1: initial topology",
+			model: `
+switches:
+- name: sw1
+  capacity_blocks: [cb1]
+capacity_blocks:
+- name: cb1
+  nodes: [n1, n2, n3, n4]
+`,
+			output: "SwitchName=sw1 Nodes=n[1-3]\n",
+		},
+		{
+			name: "Case 2: updated topology",
+			model: `
+switches:
+- name: sw1
+  switches: [sw2, sw3]
+- name: sw2
+  capacity_blocks: [cb1]
+- name: sw3
+  capacity_blocks: [cb2]
+capacity_blocks:
+- name: cb1
+  nodes: [n1, n2]
+- name: cb2
+  nodes: [n3, n4]
+`,
+			output: "SwitchName=sw1 Switches=sw[2-3]\nSwitchName=sw2 Nodes=n[1-2]\nSwitchName=sw3 Nodes=n3\n",
+		},
+	}
+
+	// changes to the file between requests are picked up
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.NoError(t, os.WriteFile(path, []byte(tc.model), 0o644))
+
+			tr := topology.NewRequest("static", nil, "slurm", nil)
+			tr.Provider.Params = map[string]any{"topology_path": path}
+			tr.Nodes = []topology.ComputeInstances{{Instances: nodes}}
+
+			res, err := processTopologyRequest(tr)
+			require.Nil(t, err)
+			require.Equal(t, tc.output, string(res.data))
+		})
+	}
 }
@@ -0,0 +1,165 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"k8s.io/klog/v2"
+
+	"github.com/NVIDIA/topograph/internal/files"
+)
+
+// mtlsConfig returns the TLS config requiring the client certificates signed by the CA certificate
+func mtlsConfig(caCert string) (*tls.Config, error) {
+	data, err := files.ReadFile(caCert)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA certificate: %v", err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no certificates found in %s", caCert)
+	}
+
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ClientCAs:  pool,
+		ClientAuth: tls.RequireAndVerifyClientCert,
+	}, nil
+}
+
+// allowClients rejects the requests whose verified client certificate matches none of the allowed
+// common names and SANs. The health endpoint is exempt, so that the probes only need a trusted certificate.
+func allowClients(next http.Handler, allowed []string) http.Handler {
+	allowedSet := newAllowedSet(allowed)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/healthz" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		cert := verifiedClient(r.TLS)
+		if cert == nil {
+			http.Error(w, "client certificate required", http.StatusUnauthorized)
+			return
+		}
+
+		if isAllowed(cert, allowedSet) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		klog.Warningf("Rejected client %q from %s", cert.Subject.CommonName, r.RemoteAddr)
+		http.Error(w, fmt.Sprintf("client %q is not allowed", cert.Subject.CommonName), http.StatusForbidden)
+	})
+}
+
+// allowGRPCClients returns the interceptors rejecting the gRPC calls whose verified client certificate
+// matches none of the allowed common names and SANs
+func allowGRPCClients(allowed []string) (grpc.UnaryServerInterceptor, grpc.StreamServerInterceptor) {
+	allowedSet := newAllowedSet(allowed)
+
+	check := func(ctx context.Context) error {
+		p, ok := peer.FromContext(ctx)
+		if !ok {
+			return status.Error(codes.Unauthenticated, "client certificate required")
+		}
+		var cert *x509.Certificate
+		if info, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+			cert = verifiedClient(&info.State)
+		}
+		if cert == nil {
+			return status.Error(codes.Unauthenticated, "client certificate required")
+		}
+
+		if isAllowed(cert, allowedSet) {
+			return nil
+		}
+
+		klog.Warningf("Rejected gRPC client %q from %s", cert.Subject.CommonName, p.Addr)
+		return status.Errorf(codes.PermissionDenied, "client %q is not allowed", cert.Subject.CommonName)
+	}
+
+	unary := func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if err := check(ctx); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+	stream := func(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := check(ss.Context()); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+
+	return unary, stream
+}
+
+func newAllowedSet(allowed []string) map[string]struct{} {
+	allowedSet := make(map[string]struct{}, len(allowed))
+	for _, name := range allowed {
+		allowedSet[name] = struct{}{}
+	}
+	return allowedSet
+}
+
+// verifiedClient returns the verified client certificate of the connection, or nil if there is none
+func verifiedClient(state *tls.ConnectionState) *x509.Certificate {
+	if state == nil || len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return nil
+	}
+	return state.VerifiedChains[0][0]
+}
+
+// isAllowed returns true if the common name or one of the SANs of the certificate is allowed
+func isAllowed(cert *x509.Certificate, allowedSet map[string]struct{}) bool {
+	for _, name := range certNames(cert) {
+		if _, ok := allowedSet[name]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// certNames returns the common name and the SANs of the certificate
+func certNames(cert *x509.Certificate) []string {
+	names := make([]string, 0, 1+len(cert.DNSNames)+len(cert.EmailAddresses)+len(cert.URIs)+len(cert.IPAddresses))
+	if len(cert.Subject.CommonName) != 0 {
+		names = append(names, cert.Subject.CommonName)
+	}
+	names = append(names, cert.DNSNames...)
+	names = append(names, cert.EmailAddresses...)
+	for _, uri := range cert.URIs {
+		names = append(names, uri.String())
+	}
+	for _, ip := range cert.IPAddresses {
+		names = append(names, ip.String())
+	}
+	return names
+}
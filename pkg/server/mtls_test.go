@@ -0,0 +1,204 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "topograph test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return &testCA{
+		cert: cert,
+		key:  key,
+		pem:  pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+	}
+}
+
+// clientCert issues a client certificate with the common name and DNS SANs
+func (ca *testCA) clientCert(t *testing.T, cn string, dnsNames ...string) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		DNSNames:     dnsNames,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+// serverCert issues a server certificate for localhost, and writes it with its key to the directory
+func (ca *testCA) serverCert(t *testing.T, dir string) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certPath := filepath.Join(dir, "server-cert.pem")
+	keyPath := filepath.Join(dir, "server-key.pem")
+	require.NoError(t, os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	require.NoError(t, os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600))
+
+	return certPath, keyPath
+}
+
+func TestMTLSConfig(t *testing.T) {
+	dir := t.TempDir()
+
+	_, err := mtlsConfig(filepath.Join(dir, "missing.pem"))
+	require.ErrorContains(t, err, "failed to read CA certificate")
+
+	bad := filepath.Join(dir, "bad.pem")
+	require.NoError(t, os.WriteFile(bad, []byte("not a certificate"), 0600))
+	_, err = mtlsConfig(bad)
+	require.EqualError(t, err, "no certificates found in "+bad)
+}
+
+func TestMTLS(t *testing.T) {
+	ca := newTestCA(t)
+	caPath := filepath.Join(t.TempDir(), "ca-cert.pem")
+	require.NoError(t, os.WriteFile(caPath, ca.pem, 0600))
+
+	tlsConfig, err := mtlsConfig(caPath)
+	require.NoError(t, err)
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	ts := httptest.NewUnstartedServer(allowClients(handler, []string{"slurmctld", "topograph-node-observer.svc"}))
+	ts.TLS = tlsConfig
+	ts.StartTLS()
+	defer ts.Close()
+
+	testCases := []struct {
+		name  string
+		path  string
+		certs []tls.Certificate
+		code  int
+		err   bool
+	}{
+		{
+			name:  "Case 1: allowed common name",
+			path:  "/v1/generate",
+			certs: []tls.Certificate{ca.clientCert(t, "slurmctld")},
+			code:  http.StatusOK,
+		},
+		{
+			name:  "Case 2: allowed SAN",
+			path:  "/v1/generate",
+			certs: []tls.Certificate{ca.clientCert(t, "node-observer", "topograph-node-observer.svc")},
+			code:  http.StatusOK,
+		},
+		{
+			name: "Case 3: no client certificate",
+			path: "/v1/generate",
+			err:  true,
+		},
+		{
+			name:  "Case 4: wrong common name",
+			path:  "/v1/generate",
+			certs: []tls.Certificate{ca.clientCert(t, "intruder")},
+			code:  http.StatusForbidden,
+		},
+		{
+			name:  "Case 5: client certificate of another CA",
+			path:  "/v1/generate",
+			certs: []tls.Certificate{newTestCA(t).clientCert(t, "slurmctld")},
+			err:   true,
+		},
+		{
+			name:  "Case 6: health endpoint with any trusted certificate",
+			path:  "/healthz",
+			certs: []tls.Certificate{ca.clientCert(t, "intruder")},
+			code:  http.StatusOK,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			transport := ts.Client().Transport.(*http.Transport).Clone()
+			transport.TLSClientConfig.Certificates = tc.certs
+			client := &http.Client{Transport: transport}
+
+			resp, err := client.Get(ts.URL + tc.path)
+			if tc.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			defer func() { _ = resp.Body.Close() }()
+			require.Equal(t, tc.code, resp.StatusCode)
+		})
+	}
+}
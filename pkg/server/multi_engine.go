@@ -0,0 +1,100 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"k8s.io/klog/v2"
+
+	"github.com/NVIDIA/topograph/pkg/engines"
+	"github.com/NVIDIA/topograph/pkg/metrics"
+	"github.com/NVIDIA/topograph/pkg/topology"
+)
+
+// EngineResult is the result of an engine of a multi-engine topology request
+type EngineResult struct {
+	Engine string `json:"engine"`
+	Status int    `json:"status"`
+	Output string `json:"output,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// processMultiEngineRequest generates the topology once and passes a copy of it to each engine of the request.
+// The result is the JSON list of the engine results. The request fails if all the engines fail.
+func processMultiEngineRequest(ctx context.Context, tr *topology.Request) (*topologyResult, *HTTPError) {
+	engs := make([]engines.Engine, 0, len(tr.Engines))
+	for _, e := range tr.Engines {
+		eng, httpErr := loadEngine(ctx, e)
+		if httpErr != nil {
+			return nil, httpErr
+		}
+		engs = append(engs, eng)
+	}
+
+	root, httpErr := generateTopology(ctx, tr, computeInstancesEngine(tr.Engines, engs), setStage)
+	if httpErr != nil {
+		return nil, httpErr
+	}
+	stats := root.Stats()
+
+	saveSnapshot(ctx, tr, root)
+
+	setStage(stageOutput)
+	results := make([]EngineResult, 0, len(engs))
+	var failed int
+	for i, eng := range engs {
+		name := tr.Engines[i].Name
+		metrics.SetTopologyStats(tr.Provider.Name, name, stats)
+		klog.InfoS("Generating engine output", "engine", name)
+		// engines may change the graph, e.g. remove the excluded nodes
+		data, err := eng.GenerateOutput(ctx, root.Clone(), tr.Engines[i].Params)
+		if err != nil {
+			httpErr := outputError(err)
+			failed++
+			results = append(results, EngineResult{Engine: name, Status: httpErr.Code, Error: httpErr.Message})
+			continue
+		}
+		results = append(results, EngineResult{Engine: name, Status: http.StatusOK, Output: string(data)})
+	}
+
+	data, err := json.Marshal(results)
+	if err != nil {
+		return nil, NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	if failed == len(results) {
+		return nil, NewHTTPError(results[0].Status, string(data))
+	}
+
+	srv.placements.set(tr, root)
+
+	return &topologyResult{data: data, root: root}, nil
+}
+
+// computeInstancesEngine returns the engine listing the compute instances of the request,
+// or nil if the engines are of different types and would list them differently
+func computeInstancesEngine(specs []topology.Engine, engs []engines.Engine) engines.Engine {
+	for _, spec := range specs[1:] {
+		if spec.Name != specs[0].Name {
+			return nil
+		}
+	}
+	return engs[0]
+}
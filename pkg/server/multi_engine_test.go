@@ -0,0 +1,202 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/NVIDIA/topograph/internal/component"
+	"github.com/NVIDIA/topograph/pkg/config"
+	enginefake "github.com/NVIDIA/topograph/pkg/engines/fake"
+	"github.com/NVIDIA/topograph/pkg/providers"
+	"github.com/NVIDIA/topograph/pkg/providers/fake"
+	"github.com/NVIDIA/topograph/pkg/registry"
+	"github.com/NVIDIA/topograph/pkg/topology"
+)
+
+// engineListedProvider hides the compute instance listing of the provider, so that the engine lists them
+type engineListedProvider struct {
+	providers.Provider
+}
+
+// registerFakeK8s replaces the k8s engine with the fake engine
+func registerFakeK8s(t *testing.T, eng *enginefake.Engine) {
+	loader := registry.Engines["k8s"]
+	registry.Engines.Register(eng.NamedLoader("k8s"))
+	t.Cleanup(func() { registry.Engines["k8s"] = loader })
+}
+
+func TestMultiEngineRequest(t *testing.T) {
+	srv = &HttpServer{
+		cfg:        &config.Config{},
+		placements: newPlacements(),
+	}
+	defer func() { srv = nil }()
+
+	model := `
+switches:
+- name: sw1
+  capacity_blocks: [cb1]
+capacity_blocks:
+- name: cb1
+  nodes: [n1, n2, n3]
+`
+
+	testCases := []struct {
+		name    string
+		engines []topology.Engine
+		err     error
+		results []EngineResult
+		code    int
+	}{
+		{
+			name: "Case 1: slurm and k8s engines",
+			engines: []topology.Engine{
+				{Name: "slurm", Params: map[string]any{"exclude_nodes": "n3"}},
+				{Name: "k8s"},
+			},
+			results: []EngineResult{
+				{Engine: "slurm", Status: http.StatusOK, Output: "SwitchName=sw1 Nodes=n[1-2]\n"},
+				{Engine: "k8s", Status: http.StatusOK, Output: "OK\n"},
+			},
+		},
+		{
+			name: "Case 2: failed k8s engine",
+			engines: []topology.Engine{
+				{Name: "slurm"},
+				{Name: "k8s"},
+			},
+			err: errors.New("failed to label nodes"),
+			results: []EngineResult{
+				{Engine: "slurm", Status: http.StatusOK, Output: "SwitchName=sw1 Nodes=n[1-3]\n"},
+				{Engine: "k8s", Status: http.StatusInternalServerError, Error: "failed to label nodes"},
+			},
+		},
+		{
+			name: "Case 3: all engines failed",
+			engines: []topology.Engine{
+				{Name: "k8s"},
+				{Name: "k8s"},
+			},
+			err: errors.New("failed to label nodes"),
+			results: []EngineResult{
+				{Engine: "k8s", Status: http.StatusInternalServerError, Error: "failed to label nodes"},
+				{Engine: "k8s", Status: http.StatusInternalServerError, Error: "failed to label nodes"},
+			},
+			code: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			eng := enginefake.New().WithOutput([]byte("OK\n"), tc.err)
+			registerFakeK8s(t, eng)
+
+			tr := topology.NewRequest("test", nil, "", nil)
+			tr.Provider.Params = map[string]any{"topology": model}
+			tr.Engines = tc.engines
+
+			res, httpErr := processTopologyRequest(tr)
+
+			var data []byte
+			if tc.code != 0 {
+				require.NotNil(t, httpErr)
+				require.Equal(t, tc.code, httpErr.Code)
+				data = []byte(httpErr.Message)
+			} else {
+				require.Nil(t, httpErr)
+				data = res.data
+				// the engines get copies of the topology
+				require.Equal(t, 3, res.root.Stats().Nodes)
+				for _, call := range eng.OutputCalls() {
+					require.Equal(t, 3, call.Root.Stats().Nodes)
+				}
+			}
+
+			var results []EngineResult
+			require.NoError(t, json.Unmarshal(data, &results))
+			require.Equal(t, tc.results, results)
+		})
+	}
+}
+
+func TestMultiEngineComputeInstances(t *testing.T) {
+	srv = &HttpServer{
+		cfg:        &config.Config{},
+		placements: newPlacements(),
+	}
+	defer func() { srv = nil }()
+
+	registry.Providers.Register(component.Named("engine-listed", func(context.Context, providers.Config) (providers.Provider, error) {
+		return engineListedProvider{fake.NewTree()}, nil
+	}))
+	t.Cleanup(func() { delete(registry.Providers, "engine-listed") })
+
+	nodes := []topology.ComputeInstances{{Region: "local", Instances: map[string]string{"n1": "n1"}}}
+
+	testCases := []struct {
+		name    string
+		engines []string
+		nodes   []topology.ComputeInstances
+		calls   int
+		code    int
+	}{
+		{
+			name:    "Case 1: engines listing the compute instances differently",
+			engines: []string{"slurm", "k8s"},
+			code:    http.StatusBadRequest,
+		},
+		{
+			name:    "Case 2: compute instances in the request",
+			engines: []string{"slurm", "k8s"},
+			nodes:   nodes,
+		},
+		{
+			name:    "Case 3: engines of the same type",
+			engines: []string{"k8s", "k8s"},
+			calls:   1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			eng := enginefake.New().WithComputeInstances(nodes...)
+			registerFakeK8s(t, eng)
+
+			tr := topology.NewRequest("engine-listed", nil, "", nil)
+			tr.Nodes = tc.nodes
+			for _, name := range tc.engines {
+				tr.Engines = append(tr.Engines, topology.Engine{Name: name})
+			}
+
+			_, httpErr := processTopologyRequest(tr)
+			if tc.code != 0 {
+				require.NotNil(t, httpErr)
+				require.Equal(t, tc.code, httpErr.Code)
+			} else {
+				require.Nil(t, httpErr)
+			}
+			require.Equal(t, tc.calls, eng.ComputeInstancesCalls())
+		})
+	}
+}
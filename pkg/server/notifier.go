@@ -0,0 +1,130 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package server
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"github.com/NVIDIA/topograph/internal/httpreq"
+	"github.com/NVIDIA/topograph/pkg/config"
+	"github.com/NVIDIA/topograph/pkg/metrics"
+	"github.com/NVIDIA/topograph/pkg/topology"
+)
+
+const (
+	defaultNotifyRetries    = 3
+	defaultNotifyRetryDelay = time.Second
+)
+
+// Notification is the payload sent to the webhook when a topology is generated
+type Notification struct {
+	UID      string `json:"uid"`
+	Provider string `json:"provider"`
+	Engine   string `json:"engine"`
+	Hash     string `json:"hash"`
+	Changed  bool   `json:"changed"`
+}
+
+type notifier struct {
+	cfg    *config.Notify
+	mutex  sync.Mutex
+	hashes map[string]string // map provider/engine : output hash of the previous run
+}
+
+func newNotifier(cfg *config.Notify) *notifier {
+	if cfg == nil {
+		return nil
+	}
+	return &notifier{
+		cfg:    cfg,
+		hashes: make(map[string]string),
+	}
+}
+
+// newNotification creates the notification and records the output hash
+func (n *notifier) newNotification(uid string, tr *topology.Request, data []byte) *Notification {
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+	key := tr.Provider.Name + "/" + tr.Engine.Name
+
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	changed := n.hashes[key] != hash
+	n.hashes[key] = hash
+
+	return &Notification{
+		UID:      uid,
+		Provider: tr.Provider.Name,
+		Engine:   tr.Engine.Name,
+		Hash:     hash,
+		Changed:  changed,
+	}
+}
+
+// send posts the notification to the webhook, retrying on failure.
+// Errors are logged and counted, but not returned.
+func (n *notifier) send(notification *Notification) {
+	payload, err := json.Marshal(notification)
+	if err != nil {
+		klog.Errorf("failed to marshal notification: %v", err)
+		metrics.AddNotificationError()
+		return
+	}
+
+	f := func() (*http.Request, error) {
+		req, err := http.NewRequest(http.MethodPost, n.cfg.URL, bytes.NewBuffer(payload))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		for key, val := range n.cfg.Headers {
+			req.Header.Set(key, val)
+		}
+		return req, nil
+	}
+
+	retries, delay := n.cfg.Retries, n.cfg.RetryDelay
+	if retries <= 0 {
+		retries = defaultNotifyRetries
+	}
+	if delay <= 0 {
+		delay = defaultNotifyRetryDelay
+	}
+
+	for r := 1; r <= retries; r++ {
+		if _, _, err = httpreq.DoRequest(nil, f); err == nil {
+			klog.V(4).Infof("Sent notification for request %s", notification.UID)
+			return
+		}
+		if r < retries {
+			klog.Infof("Notification error: %v. Retrying in %s", err, delay.String())
+			time.Sleep(delay)
+			delay *= 2
+		}
+	}
+
+	klog.Errorf("failed to send notification for request %s: %v", notification.UID, err)
+	metrics.AddNotificationError()
+}
@@ -0,0 +1,125 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package server
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/NVIDIA/topograph/pkg/config"
+	"github.com/NVIDIA/topograph/pkg/topology"
+)
+
+func TestNewNotification(t *testing.T) {
+	require.Nil(t, newNotifier(nil))
+
+	n := newNotifier(&config.Notify{URL: "http://localhost"})
+	tr := &topology.Request{
+		Provider: topology.Provider{Name: "test"},
+		Engine:   topology.Engine{Name: "slurm"},
+	}
+
+	n1 := n.newNotification("uid1", tr, []byte("config1"))
+	require.Equal(t, "uid1", n1.UID)
+	require.Equal(t, "test", n1.Provider)
+	require.Equal(t, "slurm", n1.Engine)
+	require.Len(t, n1.Hash, 64)
+	require.True(t, n1.Changed)
+
+	n2 := n.newNotification("uid2", tr, []byte("config1"))
+	require.Equal(t, n1.Hash, n2.Hash)
+	require.False(t, n2.Changed)
+
+	n3 := n.newNotification("uid3", tr, []byte("config2"))
+	require.NotEqual(t, n1.Hash, n3.Hash)
+	require.True(t, n3.Changed)
+
+	// the hash is kept per provider and engine
+	tr.Engine.Name = "k8s"
+	n4 := n.newNotification("uid4", tr, []byte("config2"))
+	require.True(t, n4.Changed)
+}
+
+func TestSendNotification(t *testing.T) {
+	testCases := []struct {
+		name     string
+		failures int
+		attempts int
+		received bool
+	}{
+		{
+			name:     "Case 1: success",
+			attempts: 1,
+			received: true,
+		},
+		{
+			name:     "Case 2: success after retry",
+			failures: 1,
+			attempts: 2,
+			received: true,
+		},
+		{
+			name:     "Case 3: failure",
+			failures: 3,
+			attempts: 2,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var attempts int
+			var received *Notification
+			var header http.Header
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				attempts++
+				if attempts <= tc.failures {
+					w.WriteHeader(http.StatusInternalServerError)
+					return
+				}
+				body, err := io.ReadAll(r.Body)
+				require.NoError(t, err)
+				received = &Notification{}
+				require.NoError(t, json.Unmarshal(body, received))
+				header = r.Header
+			}))
+			defer server.Close()
+
+			n := newNotifier(&config.Notify{
+				URL:        server.URL,
+				Headers:    map[string]string{"Authorization": "Bearer token"},
+				Retries:    2,
+				RetryDelay: time.Millisecond,
+			})
+			notification := &Notification{UID: "uid", Provider: "test", Engine: "slurm", Hash: "abc", Changed: true}
+			n.send(notification)
+
+			require.Equal(t, tc.attempts, attempts)
+			if tc.received {
+				require.Equal(t, notification, received)
+				require.Equal(t, "Bearer token", header.Get("Authorization"))
+				require.Equal(t, "application/json", header.Get("Content-Type"))
+			} else {
+				require.Nil(t, received)
+			}
+		})
+	}
+}
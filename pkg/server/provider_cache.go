@@ -0,0 +1,135 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/NVIDIA/topograph/pkg/metrics"
+	"github.com/NVIDIA/topograph/pkg/topology"
+)
+
+// providerCache keeps the topology graphs returned by the providers for the provider_cache_ttl,
+// keyed by the provider name and a hash of the provider parameters and the compute instances.
+// At most maxEntries graphs are kept, evicting the least recently used one.
+type providerCache struct {
+	ttl        time.Duration
+	maxEntries int
+	now        func() time.Time
+
+	mutex   sync.Mutex
+	entries map[string]*providerCacheEntry
+	// seq orders the uses of the entries
+	seq uint64
+}
+
+type providerCacheEntry struct {
+	provider string
+	root     *topology.Vertex
+	expiry   time.Time
+	used     uint64
+}
+
+// newProviderCache returns the provider cache, or nil if the cache is disabled
+func newProviderCache(ttl time.Duration, maxEntries int) *providerCache {
+	if ttl <= 0 || maxEntries <= 0 {
+		return nil
+	}
+
+	return &providerCache{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		now:        time.Now,
+		entries:    make(map[string]*providerCacheEntry),
+	}
+}
+
+// providerCacheKey returns the cache key of the provider topology of the compute instances,
+// or an empty string if the request cannot be hashed
+func providerCacheKey(prv topology.Provider, cis []topology.ComputeInstances) string {
+	// the map keys are marshaled in sorted order
+	data, err := json.Marshal(struct {
+		Params map[string]any              `json:"params"`
+		Nodes  []topology.ComputeInstances `json:"nodes"`
+	}{
+		Params: prv.Params,
+		Nodes:  cis,
+	})
+	if err != nil {
+		return ""
+	}
+
+	hash := sha256.Sum256(data)
+	return prv.Name + "/" + hex.EncodeToString(hash[:])
+}
+
+// get returns a copy of the cached topology graph, or nil if it is missing or expired
+func (c *providerCache) get(prv string, key string) *topology.Vertex {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok || !c.now().Before(entry.expiry) {
+		metrics.AddProviderCacheMiss(prv)
+		return nil
+	}
+
+	metrics.AddProviderCacheHit(prv)
+	c.seq++
+	entry.used = c.seq
+	// engines may change the graph, e.g. remove the excluded nodes
+	return entry.root.Clone()
+}
+
+// set caches a copy of the topology graph, dropping the expired entries,
+// and evicting the least recently used entries beyond the maximum number of entries
+func (c *providerCache) set(prv string, key string, root *topology.Vertex) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	now := c.now()
+	for k, entry := range c.entries {
+		if !now.Before(entry.expiry) {
+			delete(c.entries, k)
+		}
+	}
+
+	delete(c.entries, key)
+	for len(c.entries) >= c.maxEntries {
+		c.evict()
+	}
+
+	c.seq++
+	c.entries[key] = &providerCacheEntry{provider: prv, root: root.Clone(), expiry: now.Add(c.ttl), used: c.seq}
+}
+
+// evict drops the least recently used entry
+func (c *providerCache) evict() {
+	var lru string
+	for k, entry := range c.entries {
+		if len(lru) == 0 || entry.used < c.entries[lru].used {
+			lru = k
+		}
+	}
+
+	metrics.AddProviderCacheEviction(c.entries[lru].provider)
+	delete(c.entries, lru)
+}
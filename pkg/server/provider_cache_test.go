@@ -0,0 +1,194 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/NVIDIA/topograph/internal/fixtures"
+	"github.com/NVIDIA/topograph/pkg/config"
+	enginefake "github.com/NVIDIA/topograph/pkg/engines/fake"
+	"github.com/NVIDIA/topograph/pkg/providers/fake"
+	"github.com/NVIDIA/topograph/pkg/topology"
+)
+
+func TestProviderCacheKey(t *testing.T) {
+	nodes := []topology.ComputeInstances{{Region: "r1", Instances: map[string]string{"i1": "n1", "i2": "n2"}}}
+	key := providerCacheKey(topology.Provider{Name: "aws", Params: map[string]any{"page_size": 10}}, nodes)
+
+	testCases := []struct {
+		name  string
+		prv   topology.Provider
+		nodes []topology.ComputeInstances
+		equal bool
+	}{
+		{
+			name:  "Case 1: same request with other credentials",
+			prv:   topology.Provider{Name: "aws", Creds: map[string]string{"token": "t"}, Params: map[string]any{"page_size": 10}},
+			nodes: []topology.ComputeInstances{{Region: "r1", Instances: map[string]string{"i2": "n2", "i1": "n1"}}},
+			equal: true,
+		},
+		{
+			name:  "Case 2: other provider",
+			prv:   topology.Provider{Name: "oci", Params: map[string]any{"page_size": 10}},
+			nodes: nodes,
+		},
+		{
+			name:  "Case 3: other parameters",
+			prv:   topology.Provider{Name: "aws", Params: map[string]any{"page_size": 20}},
+			nodes: nodes,
+		},
+		{
+			name:  "Case 4: other instances",
+			prv:   topology.Provider{Name: "aws", Params: map[string]any{"page_size": 10}},
+			nodes: []topology.ComputeInstances{{Region: "r1", Instances: map[string]string{"i1": "n1"}}},
+		},
+		{
+			name:  "Case 5: other node names",
+			prv:   topology.Provider{Name: "aws", Params: map[string]any{"page_size": 10}},
+			nodes: []topology.ComputeInstances{{Region: "r1", Instances: map[string]string{"i1": "n1", "i2": "n3"}}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			other := providerCacheKey(tc.prv, tc.nodes)
+			require.NotEmpty(t, other)
+			require.Equal(t, tc.equal, key == other)
+		})
+	}
+}
+
+func TestProviderCacheCopies(t *testing.T) {
+	root, _ := fixtures.TreeTestSet()
+	expected, _ := fixtures.TreeTestSet()
+
+	cache := newProviderCache(time.Minute, 1)
+	cache.set("test", "key", root)
+
+	// changes of the cached or returned graphs do not affect the cache
+	delete(root.Vertices, topology.TopologyTree)
+	cached := cache.get("test", "key")
+	require.Equal(t, expected, cached)
+	delete(cached.Vertices, topology.TopologyTree)
+	require.Equal(t, expected, cache.get("test", "key"))
+
+	require.Nil(t, cache.get("test", "other"))
+	require.Nil(t, newProviderCache(0, 1))
+}
+
+func TestProviderCacheEviction(t *testing.T) {
+	root, _ := fixtures.TreeTestSet()
+	now := time.Now()
+	cache := newProviderCache(time.Minute, 2)
+	cache.now = func() time.Time { return now }
+
+	cache.set("test", "k1", root)
+	cache.set("test", "k2", root)
+	// k1 becomes the most recently used entry
+	require.NotNil(t, cache.get("test", "k1"))
+
+	// the least recently used entry is evicted
+	cache.set("test", "k3", root)
+	require.Len(t, cache.entries, 2)
+	require.NotNil(t, cache.get("test", "k1"))
+	require.Nil(t, cache.get("test", "k2"))
+	require.NotNil(t, cache.get("test", "k3"))
+
+	// replacing an entry does not evict another one
+	cache.set("test", "k3", root)
+	require.Len(t, cache.entries, 2)
+	require.NotNil(t, cache.get("test", "k1"))
+
+	// the expired entries are dropped before the eviction
+	now = now.Add(time.Minute)
+	cache.set("test", "k4", root)
+	require.Len(t, cache.entries, 1)
+}
+
+func TestProviderCache(t *testing.T) {
+	now := time.Now()
+	cache := newProviderCache(time.Minute, config.DefaultProviderCacheMaxEntries)
+	cache.now = func() time.Time { return now }
+
+	srv = &HttpServer{
+		cfg:           &config.Config{},
+		placements:    newPlacements(),
+		providerCache: cache,
+	}
+	defer func() { srv = nil }()
+
+	prv := fake.NewTree()
+	eng := enginefake.New()
+	registerFakes(t, prv, eng)
+
+	testCases := []struct {
+		name    string
+		elapsed time.Duration
+		refresh bool
+		nodes   []topology.ComputeInstances
+		calls   int
+	}{
+		{
+			name:  "Case 1: first request queries the provider",
+			calls: 1,
+		},
+		{
+			name:    "Case 2: identical request within the TTL uses the cache",
+			elapsed: 30 * time.Second,
+			calls:   1,
+		},
+		{
+			name:    "Case 3: refresh bypasses the cache",
+			refresh: true,
+			calls:   2,
+		},
+		{
+			name:  "Case 4: refreshed topology is cached",
+			calls: 2,
+		},
+		{
+			name:  "Case 5: other instances query the provider",
+			nodes: []topology.ComputeInstances{{Instances: map[string]string{"i1": "Node201"}}},
+			calls: 3,
+		},
+		{
+			name:    "Case 6: expired topology queries the provider",
+			elapsed: time.Minute,
+			calls:   4,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			now = now.Add(tc.elapsed)
+			tr := topology.NewRequest("fake", nil, "fake", nil)
+			tr.Refresh = tc.refresh
+			tr.Nodes = tc.nodes
+
+			res, err := processTopologyRequest(tr)
+			require.Nil(t, err)
+			require.Equal(t, "OK\n", string(res.data))
+
+			prv.RequireCalls(t, tc.calls)
+			eng.RequireOutputCalls(t, i+1)
+		})
+	}
+}
@@ -0,0 +1,147 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package server
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/NVIDIA/topograph/internal/config"
+	"github.com/NVIDIA/topograph/pkg/topology"
+)
+
+// placements keeps the topology graph of the last successful request per provider and engine.
+// It allows to re-apply the placement of known nodes without querying the provider.
+type placements struct {
+	mutex sync.Mutex
+	roots map[string]*topology.Vertex // map provider/engine : topology graph
+}
+
+func newPlacements() *placements {
+	return &placements{roots: make(map[string]*topology.Vertex)}
+}
+
+func placementKey(tr *topology.Request) string {
+	return tr.Provider.Name + "/" + tr.Engine.Name
+}
+
+func (p *placements) get(tr *topology.Request) *topology.Vertex {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return p.roots[placementKey(tr)]
+}
+
+func (p *placements) set(tr *topology.Request, root *topology.Vertex) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.roots[placementKey(tr)] = root
+}
+
+// getRepairNodes returns the nodes listed in the "repair_nodes" engine parameter
+func getRepairNodes(params map[string]any) []string {
+	var p struct {
+		RepairNodes []string `mapstructure:"repair_nodes"`
+	}
+	if err := config.Decode(params, &p); err != nil {
+		return nil
+	}
+	return p.RepairNodes
+}
+
+// withoutRepairNodes returns a copy of the engine parameters without "repair_nodes"
+func withoutRepairNodes(params map[string]any) map[string]any {
+	ret := make(map[string]any, len(params))
+	for key, val := range params {
+		if key != topology.KeyRepairNodes {
+			ret[key] = val
+		}
+	}
+	return ret
+}
+
+// coversNodes returns true if all the nodes are present in the topology graph
+func coversNodes(root *topology.Vertex, nodes []string) bool {
+	if root == nil {
+		return false
+	}
+
+	present := make(map[string]bool)
+	collectNodeNames(root, present)
+	for _, node := range nodes {
+		if !present[node] {
+			return false
+		}
+	}
+	return true
+}
+
+func collectNodeNames(v *topology.Vertex, present map[string]bool) {
+	if len(v.Vertices) == 0 {
+		if len(v.Name) != 0 {
+			present[v.Name] = true
+		}
+		return
+	}
+	for _, w := range v.Vertices {
+		collectNodeNames(w, present)
+	}
+}
+
+// mergeRequests combines two topology requests coalesced by the queue.
+// A full request absorbs node repairs; two repair requests repair the union of their nodes.
+func mergeRequests(prev, next interface{}) interface{} {
+	prevReq, ok := prev.(*topology.Request)
+	if !ok {
+		return next
+	}
+	nextReq, ok := next.(*topology.Request)
+	if !ok {
+		return next
+	}
+	// a coalesced refresh request still bypasses the provider cache
+	nextReq.Refresh = nextReq.Refresh || prevReq.Refresh
+	if placementKey(prevReq) != placementKey(nextReq) {
+		return next
+	}
+
+	prevNodes := getRepairNodes(prevReq.Engine.Params)
+	nextNodes := getRepairNodes(nextReq.Engine.Params)
+	if len(nextNodes) == 0 {
+		return next
+	}
+	if len(prevNodes) == 0 {
+		nextReq.Engine.Params = withoutRepairNodes(nextReq.Engine.Params)
+		return nextReq
+	}
+
+	union := make(map[string]bool)
+	for _, node := range append(prevNodes, nextNodes...) {
+		union[node] = true
+	}
+	nodes := make([]string, 0, len(union))
+	for node := range union {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+
+	params := withoutRepairNodes(nextReq.Engine.Params)
+	params[topology.KeyRepairNodes] = nodes
+	nextReq.Engine.Params = params
+
+	return nextReq
+}
@@ -0,0 +1,256 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/NVIDIA/topograph/internal/component"
+	"github.com/NVIDIA/topograph/internal/fixtures"
+	"github.com/NVIDIA/topograph/pkg/config"
+	"github.com/NVIDIA/topograph/pkg/engines"
+	enginefake "github.com/NVIDIA/topograph/pkg/engines/fake"
+	"github.com/NVIDIA/topograph/pkg/providers"
+	"github.com/NVIDIA/topograph/pkg/providers/fake"
+	"github.com/NVIDIA/topograph/pkg/registry"
+	"github.com/NVIDIA/topograph/pkg/topology"
+	"github.com/NVIDIA/topograph/pkg/translate"
+)
+
+func TestCoversNodes(t *testing.T) {
+	root, _ := fixtures.TreeTestSet()
+
+	require.True(t, coversNodes(root, []string{"Node201", "Node306"}))
+	require.False(t, coversNodes(root, []string{"Node201", "Node999"}))
+	require.False(t, coversNodes(nil, []string{"Node201"}))
+}
+
+func TestMergeRequests(t *testing.T) {
+	repair := func(nodes ...any) *topology.Request {
+		return topology.NewRequest("test", nil, "k8s", map[string]any{"dry_run": true, topology.KeyRepairNodes: nodes})
+	}
+	full := func() *topology.Request {
+		return topology.NewRequest("test", nil, "k8s", map[string]any{"dry_run": true})
+	}
+
+	testCases := []struct {
+		name     string
+		prev     *topology.Request
+		next     *topology.Request
+		expected map[string]any
+	}{
+		{
+			name:     "Case 1: two repairs",
+			prev:     repair("n2", "n1"),
+			next:     repair("n3", "n2"),
+			expected: map[string]any{"dry_run": true, topology.KeyRepairNodes: []string{"n1", "n2", "n3"}},
+		},
+		{
+			name:     "Case 2: repair after full request",
+			prev:     full(),
+			next:     repair("n1"),
+			expected: map[string]any{"dry_run": true},
+		},
+		{
+			name:     "Case 3: full request after repair",
+			prev:     repair("n1"),
+			next:     full(),
+			expected: map[string]any{"dry_run": true},
+		},
+		{
+			name:     "Case 4: different engine",
+			prev:     topology.NewRequest("test", nil, "slurm", nil),
+			next:     repair("n1"),
+			expected: map[string]any{"dry_run": true, topology.KeyRepairNodes: []any{"n1"}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			merged := mergeRequests(tc.prev, tc.next).(*topology.Request)
+			require.Equal(t, tc.expected, merged.Engine.Params)
+		})
+	}
+}
+
+func TestMergeRefreshRequests(t *testing.T) {
+	prev := topology.NewRequest("test", nil, "k8s", nil)
+	prev.Refresh = true
+
+	merged := mergeRequests(prev, topology.NewRequest("test", nil, "slurm", nil)).(*topology.Request)
+	require.True(t, merged.Refresh)
+
+	merged = mergeRequests(topology.NewRequest("test", nil, "k8s", nil), topology.NewRequest("test", nil, "k8s", nil)).(*topology.Request)
+	require.False(t, merged.Refresh)
+}
+
+func TestRepairNodes(t *testing.T) {
+	srv = &HttpServer{
+		cfg:        &config.Config{},
+		placements: newPlacements(),
+	}
+	defer func() { srv = nil }()
+
+	prv := fake.NewTree()
+	eng := enginefake.New()
+	registerFakes(t, prv, eng)
+
+	stored, _ := fixtures.TreeTestSet()
+	srv.placements.set(topology.NewRequest("fake", nil, "fake", nil), stored)
+
+	testCases := []struct {
+		name   string
+		nodes  []any
+		calls  int
+		stored bool
+	}{
+		{
+			name:   "Case 1: known nodes use the stored topology",
+			nodes:  []any{"Node201", "Node305"},
+			calls:  0,
+			stored: true,
+		},
+		{
+			name:   "Case 2: unknown node falls back to full run",
+			nodes:  []any{"Node201", "Node999"},
+			calls:  1,
+			stored: false,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tr := topology.NewRequest("fake", nil, "fake", map[string]any{topology.KeyRepairNodes: tc.nodes})
+			res, err := processTopologyRequest(tr)
+			require.Nil(t, err)
+			require.Equal(t, "OK\n", string(res.data))
+			require.Equal(t, tc.stored, res.root == stored)
+
+			prv.RequireCalls(t, tc.calls)
+			eng.RequireOutputCalls(t, i+1)
+			params := eng.OutputCalls()[i].Params
+			if tc.stored {
+				require.Contains(t, params, topology.KeyRepairNodes)
+			} else {
+				require.NotContains(t, params, topology.KeyRepairNodes)
+			}
+		})
+	}
+}
+
+func TestProcessTopologyRequestErrors(t *testing.T) {
+	srv = &HttpServer{
+		cfg:        &config.Config{},
+		placements: newPlacements(),
+	}
+	defer func() { srv = nil }()
+
+	testCases := []struct {
+		name   string
+		prvErr error
+		engErr error
+		code   int
+	}{
+		{
+			name:   "Case 1: provider error",
+			prvErr: errors.New("provider failure"),
+			code:   http.StatusInternalServerError,
+		},
+		{
+			name:   "Case 2: validation error",
+			engErr: fmt.Errorf("%w: 1 missing nodes", engines.ErrTopologyValidation),
+			code:   http.StatusBadGateway,
+		},
+		{
+			name:   "Case 3: provider API error",
+			prvErr: fmt.Errorf("%w: page request failed", providers.ErrProviderAPI),
+			code:   http.StatusBadGateway,
+		},
+		{
+			name:   "Case 4: provider timeout",
+			prvErr: fmt.Errorf("interrupted pagination: %w", context.DeadlineExceeded),
+			code:   http.StatusGatewayTimeout,
+		},
+		{
+			name:   "Case 5: duplicate nodes",
+			engErr: fmt.Errorf("%w: n2 in blocks B1,B2", translate.ErrDuplicateNodes),
+			code:   http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			root, i2n := fixtures.TreeTestSet()
+			prv := fake.New(fake.Result{Root: root, Err: tc.prvErr}).WithComputeInstances(topology.ComputeInstances{Instances: i2n})
+			eng := enginefake.New().WithOutput(nil, tc.engErr)
+			registerFakes(t, prv, eng)
+
+			_, err := processTopologyRequest(topology.NewRequest("fake", nil, "fake", nil))
+			require.NotNil(t, err)
+			require.Equal(t, tc.code, err.Code)
+		})
+	}
+}
+
+// blockingProvider lists the compute instances only once the context is done
+type blockingProvider struct{}
+
+func (blockingProvider) GetComputeInstances(ctx context.Context) ([]topology.ComputeInstances, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (blockingProvider) GenerateTopologyConfig(context.Context, *int, []topology.ComputeInstances) (*topology.Vertex, error) {
+	return nil, errors.New("unexpected topology request")
+}
+
+func TestComputeInstancesTimeout(t *testing.T) {
+	srv = &HttpServer{
+		cfg:        &config.Config{ProviderTimeout: 10 * time.Millisecond},
+		placements: newPlacements(),
+	}
+	defer func() { srv = nil }()
+
+	registry.Providers.Register(component.Named("blocking", func(context.Context, providers.Config) (providers.Provider, error) {
+		return blockingProvider{}, nil
+	}))
+	registry.Engines.Register(enginefake.New().NamedLoader("fake"))
+	t.Cleanup(func() {
+		delete(registry.Providers, "blocking")
+		delete(registry.Engines, "fake")
+	})
+
+	_, err := processTopologyRequest(topology.NewRequest("blocking", nil, "fake", nil))
+	require.NotNil(t, err)
+	require.Equal(t, http.StatusGatewayTimeout, err.Code)
+}
+
+// registerFakes registers the fake provider and engine as "fake" for the duration of the test
+func registerFakes(t *testing.T, prv *fake.Provider, eng *enginefake.Engine) {
+	registry.Providers.Register(prv.NamedLoader("fake"))
+	registry.Engines.Register(eng.NamedLoader("fake"))
+	t.Cleanup(func() {
+		delete(registry.Providers, "fake")
+		delete(registry.Engines, "fake")
+	})
+}
@@ -0,0 +1,90 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/NVIDIA/topograph/pkg/topology"
+)
+
+func getrequests(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	writeJSON(w, getRequests(srv.async.queue.Status()))
+}
+
+func getrequest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	uid := r.PathValue("uid")
+	for _, info := range getRequests(srv.async.queue.Status()) {
+		if info.UID == uid {
+			writeJSON(w, info)
+			return
+		}
+	}
+
+	http.Error(w, fmt.Sprintf("no data for request ID %s", uid), http.StatusNotFound)
+}
+
+func flush(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	writeJSON(w, srv.async.queue.Flush(r.URL.Query().Get(topology.KeyUID)))
+}
+
+// getRequests returns the pending, in-flight and completed requests, most recent first
+func getRequests(qs *QueueStatus) []*RequestInfo {
+	requests := make([]*RequestInfo, 0, len(qs.Completed)+2)
+
+	if qs.Pending != nil {
+		requests = append(requests, getRequestInfo(qs.Pending, statePending))
+	}
+
+	if qs.InFlight != nil {
+		requests = append(requests, getRequestInfo(qs.InFlight, stateRunning))
+	}
+
+	for _, rs := range qs.Completed {
+		requests = append(requests, getRequestInfo(rs, getCompletedState(rs)))
+	}
+
+	return requests
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(data)
+}
@@ -0,0 +1,153 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/NVIDIA/topograph/pkg/config"
+	"github.com/NVIDIA/topograph/pkg/topology"
+)
+
+func getTestRequests(t *testing.T, path string, code int, v any) {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	rec := httptest.NewRecorder()
+	srv.srv.Handler.ServeHTTP(rec, req)
+	require.Equal(t, code, rec.Code)
+	if v != nil {
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), v))
+	}
+}
+
+func TestRequests(t *testing.T) {
+	cfg := &config.Config{
+		RequestAggregationDelay: time.Second,
+	}
+	srv = initHttpServer(context.TODO(), cfg)
+	srv.async.queue.Shutdown()
+
+	// replace the queue with the one failing the requests for "bad" provider
+	handle := func(item interface{}) (interface{}, *HTTPError) {
+		if item.(*topology.Request).Provider.Name == "bad" {
+			return nil, NewHTTPError(http.StatusBadRequest, "bad provider")
+		}
+		return &topologyResult{data: []byte("OK\n")}, nil
+	}
+	srv.async.queue = NewTrailingDelayQueue(handle, 100*time.Millisecond)
+	defer srv.async.queue.Shutdown()
+
+	var requests []*RequestInfo
+	getTestRequests(t, "/v1/requests", http.StatusOK, &requests)
+	require.Empty(t, requests)
+
+	submit := func(provider string) string {
+		return srv.async.queue.Submit(&topology.Request{
+			Provider: topology.Provider{Name: provider},
+			Engine:   topology.Engine{Name: "test"},
+		})
+	}
+
+	uid1 := submit("test")
+	time.Sleep(time.Second)
+	uid2 := submit("bad")
+	time.Sleep(time.Second)
+	start := time.Now()
+	uid3 := submit("test")
+
+	getTestRequests(t, "/v1/requests", http.StatusOK, &requests)
+	require.Len(t, requests, 3)
+
+	require.Equal(t, uid3, requests[0].UID)
+	require.Equal(t, statePending, requests[0].State)
+	require.WithinDuration(t, start, requests[0].Submitted, time.Second)
+
+	require.Equal(t, uid2, requests[1].UID)
+	require.Equal(t, stateFailed, requests[1].State)
+	require.Equal(t, http.StatusBadRequest, requests[1].Status)
+	require.Equal(t, "bad provider", requests[1].Message)
+
+	require.Equal(t, uid1, requests[2].UID)
+	require.Equal(t, stateSucceeded, requests[2].State)
+	require.Equal(t, http.StatusOK, requests[2].Status)
+	require.Equal(t, "test", requests[2].Provider)
+	require.Equal(t, "test", requests[2].Engine)
+	require.Empty(t, requests[2].Message)
+
+	var info RequestInfo
+	getTestRequests(t, "/v1/requests/"+uid2, http.StatusOK, &info)
+	require.Equal(t, requests[1], &info)
+
+	getTestRequests(t, "/v1/requests/unknown", http.StatusNotFound, nil)
+
+	// completed requests expire after the TTL
+	srv.async.queue.SetHistoryTTL(time.Millisecond)
+	time.Sleep(10 * time.Millisecond)
+	getTestRequests(t, "/v1/requests", http.StatusOK, &requests)
+	require.Len(t, requests, 1)
+	require.Equal(t, uid3, requests[0].UID)
+}
+
+func TestFlush(t *testing.T) {
+	cfg := &config.Config{
+		RequestAggregationDelay: time.Hour,
+	}
+	srv = initHttpServer(context.TODO(), cfg)
+	srv.async.queue.Shutdown()
+
+	handle := func(item interface{}) (interface{}, *HTTPError) {
+		return &topologyResult{data: []byte("OK\n")}, nil
+	}
+	srv.async.queue = NewTrailingDelayQueue(handle, time.Hour)
+	defer srv.async.queue.Shutdown()
+
+	flush := func(method, path string, code int, v any) {
+		req := httptest.NewRequest(method, path, nil)
+		rec := httptest.NewRecorder()
+		srv.srv.Handler.ServeHTTP(rec, req)
+		require.Equal(t, code, rec.Code)
+		if v != nil {
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), v))
+		}
+	}
+
+	flush(http.MethodGet, "/v1/flush", http.StatusMethodNotAllowed, nil)
+
+	var uids []string
+	flush(http.MethodPost, "/v1/flush", http.StatusOK, &uids)
+	require.Empty(t, uids)
+
+	uid := srv.async.queue.Submit(&topology.Request{
+		Provider: topology.Provider{Name: "test"},
+		Engine:   topology.Engine{Name: "test"},
+	})
+
+	flush(http.MethodPost, "/v1/flush?uid=other", http.StatusOK, &uids)
+	require.Empty(t, uids)
+
+	flush(http.MethodPost, "/v1/flush?uid="+uid, http.StatusOK, &uids)
+	require.Equal(t, []string{uid}, uids)
+
+	require.Eventually(t, func() bool {
+		return srv.async.queue.Get(uid).Status == http.StatusOK
+	}, 5*time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,102 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"github.com/NVIDIA/topograph/pkg/engines"
+	"github.com/NVIDIA/topograph/pkg/topology"
+)
+
+// rollback restores a previous revision of the topology config applied by the engine.
+// The engine and the revision are given as query parameters, and the optional body is the JSON object of the engine parameters.
+func rollback(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+
+	engine := r.URL.Query().Get("engine")
+	if len(engine) == 0 {
+		engine = srv.cfg.Engine
+	}
+
+	if r.Method != http.MethodPost {
+		httpError(w, "", engine, "Invalid request method", http.StatusMethodNotAllowed, time.Since(start))
+		return
+	}
+
+	revision, err := strconv.Atoi(r.URL.Query().Get("revision"))
+	if err != nil || revision < 1 {
+		httpError(w, "", engine, "revision must be a positive integer", http.StatusBadRequest, time.Since(start))
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		httpError(w, "", engine, "Unable to read request body", http.StatusInternalServerError, time.Since(start))
+		return
+	}
+	defer func() { _ = r.Body.Close() }()
+
+	var params map[string]any
+	if len(body) != 0 {
+		if err := json.Unmarshal(body, &params); err != nil {
+			httpError(w, "", engine, fmt.Sprintf("failed to parse engine parameters: %v", err), http.StatusBadRequest, time.Since(start))
+			return
+		}
+	}
+
+	tr := &topology.Request{Engine: topology.Engine{Name: engine, Params: params}}
+	if httpErr := rollbackTopology(r, tr, revision); httpErr != nil {
+		httpError(w, "", engine, httpErr.Message, httpErr.Code, time.Since(start))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte("OK\n"))
+}
+
+func rollbackTopology(r *http.Request, tr *topology.Request, revision int) *HTTPError {
+	klog.InfoS("Rolling back topology config", "engine", tr.Engine.Name, "revision", revision)
+	ctx := r.Context()
+
+	eng, httpErr := loadEngine(ctx, tr.Engine)
+	if httpErr != nil {
+		return httpErr
+	}
+	rollbacker, ok := eng.(engines.ConfigRollbacker)
+	if !ok {
+		return NewHTTPError(http.StatusBadRequest, fmt.Sprintf("engine %s does not support topology rollback", tr.Engine.Name))
+	}
+
+	if err := rollbacker.RollbackTopologyConfig(ctx, tr.Engine.Params, revision); err != nil {
+		klog.Error(err.Error())
+		if errors.Is(err, engines.ErrRevisionNotFound) {
+			return NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		return NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return nil
+}
@@ -0,0 +1,106 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/NVIDIA/topograph/pkg/config"
+	enginefake "github.com/NVIDIA/topograph/pkg/engines/fake"
+	"github.com/NVIDIA/topograph/pkg/providers/fake"
+)
+
+func TestRollback(t *testing.T) {
+	srv = &HttpServer{
+		cfg:        &config.Config{Engine: "fake"},
+		placements: newPlacements(),
+	}
+	defer func() { srv = nil }()
+
+	eng := enginefake.New().
+		WithAppliedConfig([]byte("conf-3")).
+		WithRevisions([]byte("conf-2"), []byte("conf-1"))
+	registerFakes(t, fake.NewTree(), eng)
+
+	testCases := []struct {
+		name    string
+		method  string
+		query   string
+		body    string
+		code    int
+		applied string
+	}{
+		{
+			name:    "Case 1: invalid method",
+			method:  http.MethodGet,
+			query:   "engine=fake&revision=1",
+			code:    http.StatusMethodNotAllowed,
+			applied: "conf-3",
+		},
+		{
+			name:    "Case 2: invalid revision",
+			method:  http.MethodPost,
+			query:   "engine=fake&revision=0",
+			code:    http.StatusBadRequest,
+			applied: "conf-3",
+		},
+		{
+			name:    "Case 3: invalid engine parameters",
+			method:  http.MethodPost,
+			query:   "engine=fake&revision=1",
+			body:    "[",
+			code:    http.StatusBadRequest,
+			applied: "conf-3",
+		},
+		{
+			name:    "Case 4: missing revision",
+			method:  http.MethodPost,
+			query:   "engine=fake&revision=3",
+			code:    http.StatusNotFound,
+			applied: "conf-3",
+		},
+		{
+			name:    "Case 5: unsupported engine",
+			method:  http.MethodPost,
+			query:   "engine=unknown&revision=1",
+			code:    http.StatusBadRequest,
+			applied: "conf-3",
+		},
+		{
+			name:    "Case 6: rollback with the configured engine",
+			method:  http.MethodPost,
+			query:   "revision=2",
+			body:    `{"topology_configmap_name":"topology"}`,
+			code:    http.StatusOK,
+			applied: "conf-1",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, "/v1/rollback?"+tc.query, strings.NewReader(tc.body))
+			rec := httptest.NewRecorder()
+			rollback(rec, req)
+			require.Equal(t, tc.code, rec.Code)
+			require.Equal(t, tc.applied, string(eng.AppliedConfig()))
+		})
+	}
+}
@@ -0,0 +1,212 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"k8s.io/klog/v2"
+
+	"github.com/NVIDIA/topograph/pkg/config"
+	"github.com/NVIDIA/topograph/pkg/topology"
+)
+
+const (
+	defaultSnapshotMaxCount = 100
+	defaultSnapshotMaxAge   = 24 * time.Hour
+
+	snapshotTimeFormat = "20060102T150405Z"
+	snapshotExt        = ".json"
+)
+
+// Snapshot is the topology graph reported by the provider for a topology request,
+// before the engine has processed it
+type Snapshot struct {
+	UID      string    `json:"uid"`
+	Time     time.Time `json:"time"`
+	Provider string    `json:"provider"`
+	Engine   string    `json:"engine"`
+	// Engines holds the engines of a multi-engine request, with their parameters
+	Engines  []topology.Engine `json:"engines,omitempty"`
+	Topology *topology.Vertex  `json:"topology"`
+}
+
+// snapshotStore keeps the provider snapshots in timestamped files, one per request UID
+type snapshotStore struct {
+	dir      string
+	maxCount int
+	maxAge   time.Duration
+	now      func() time.Time
+}
+
+// newSnapshotStore returns the snapshot store, or nil if the snapshots are disabled
+func newSnapshotStore(cfg *config.Snapshot) *snapshotStore {
+	if cfg == nil {
+		return nil
+	}
+
+	s := &snapshotStore{
+		dir:      cfg.Dir,
+		maxCount: cfg.MaxCount,
+		maxAge:   cfg.MaxAge,
+		now:      time.Now,
+	}
+	if s.maxCount == 0 {
+		s.maxCount = defaultSnapshotMaxCount
+	}
+	if s.maxAge == 0 {
+		s.maxAge = defaultSnapshotMaxAge
+	}
+
+	return s
+}
+
+// save writes the snapshot of the request and prunes the outdated snapshots
+func (s *snapshotStore) save(uid string, tr *topology.Request, root *topology.Vertex) error {
+	if _, err := uuid.Parse(uid); err != nil {
+		return fmt.Errorf("invalid snapshot uid %q", uid)
+	}
+
+	now := s.now().UTC()
+	data, err := json.Marshal(&Snapshot{
+		UID:      uid,
+		Time:     now,
+		Provider: tr.Provider.Name,
+		Engine:   tr.Engine.Name,
+		Engines:  tr.Engines,
+		Topology: root,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode snapshot: %v", err)
+	}
+
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create snapshot directory: %v", err)
+	}
+
+	fname := filepath.Join(s.dir, now.Format(snapshotTimeFormat)+"_"+uid+snapshotExt)
+	if err := os.WriteFile(fname, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write snapshot: %v", err)
+	}
+
+	return s.prune()
+}
+
+// load returns the snapshot of the request, or nil if the snapshot does not exist
+func (s *snapshotStore) load(uid string) ([]byte, error) {
+	matches, err := filepath.Glob(filepath.Join(s.dir, "*_"+uid+snapshotExt))
+	if err != nil {
+		return nil, err
+	}
+	if len(matches) == 0 {
+		return nil, nil
+	}
+
+	// the latest snapshot wins
+	sort.Strings(matches)
+	return os.ReadFile(matches[len(matches)-1])
+}
+
+// prune removes the snapshots older than the maximum age,
+// and then the oldest snapshots in excess of the maximum count
+func (s *snapshotStore) prune() error {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return fmt.Errorf("failed to read snapshot directory: %v", err)
+	}
+
+	// the file names start with the timestamp, so the entries are sorted from oldest to newest
+	var names []string
+	cutoff := s.now().UTC().Add(-s.maxAge)
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, snapshotExt) {
+			continue
+		}
+		ts, _, ok := strings.Cut(name, "_")
+		if !ok {
+			continue
+		}
+		t, err := time.Parse(snapshotTimeFormat, ts)
+		if err != nil {
+			continue
+		}
+		if t.Before(cutoff) {
+			s.remove(name)
+			continue
+		}
+		names = append(names, name)
+	}
+
+	for len(names) > s.maxCount {
+		s.remove(names[0])
+		names = names[1:]
+	}
+
+	return nil
+}
+
+func (s *snapshotStore) remove(name string) {
+	if err := os.Remove(filepath.Join(s.dir, name)); err != nil && !os.IsNotExist(err) {
+		klog.Errorf("Failed to remove snapshot %s: %v", name, err)
+	}
+}
+
+// getsnapshot returns the provider topology snapshot of the request
+func getsnapshot(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	uid := r.URL.Query().Get(topology.KeyUID)
+	if len(uid) == 0 {
+		http.Error(w, "must specify request uid", http.StatusBadRequest)
+		return
+	}
+	if _, err := uuid.Parse(uid); err != nil {
+		http.Error(w, fmt.Sprintf("invalid request uid %q", uid), http.StatusBadRequest)
+		return
+	}
+
+	if srv.snapshots == nil {
+		http.Error(w, "snapshots are not enabled", http.StatusNotFound)
+		return
+	}
+
+	data, err := srv.snapshots.load(uid)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if data == nil {
+		http.Error(w, fmt.Sprintf("snapshot of request %s not found", uid), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(data)
+}
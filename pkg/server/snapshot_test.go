@@ -0,0 +1,197 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/NVIDIA/topograph/pkg/config"
+	"github.com/NVIDIA/topograph/pkg/topology"
+)
+
+func TestSnapshot(t *testing.T) {
+	srv = &HttpServer{
+		cfg:        &config.Config{},
+		placements: newPlacements(),
+		snapshots:  newSnapshotStore(&config.Snapshot{Dir: t.TempDir()}),
+	}
+	defer func() { srv = nil }()
+
+	tr := topology.NewRequest("aws-sim", nil, "slurm", nil)
+	tr.Provider.Params = map[string]any{"model_path": "../../tests/models/medium.yaml"}
+	ctx := context.TODO()
+	eng, httpErr := loadEngine(ctx, tr.Engine)
+	require.Nil(t, httpErr)
+	root, httpErr := generateTopology(ctx, tr, eng, func(string) {})
+	require.Nil(t, httpErr)
+
+	uid := uuid.New().String()
+	require.NoError(t, srv.snapshots.save(uid, tr, root))
+
+	testCases := []struct {
+		name string
+		uid  string
+		code int
+	}{
+		{
+			name: "Case 1: missing uid",
+			code: http.StatusBadRequest,
+		},
+		{
+			name: "Case 2: invalid uid",
+			uid:  "../config",
+			code: http.StatusBadRequest,
+		},
+		{
+			name: "Case 3: unknown uid",
+			uid:  uuid.New().String(),
+			code: http.StatusNotFound,
+		},
+		{
+			name: "Case 4: valid uid",
+			uid:  uid,
+			code: http.StatusOK,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/v1/snapshot?uid="+tc.uid, nil)
+			w := httptest.NewRecorder()
+			getsnapshot(w, req)
+			require.Equal(t, tc.code, w.Code)
+			if tc.code != http.StatusOK {
+				return
+			}
+			require.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+			var fields map[string]json.RawMessage
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &fields))
+			require.ElementsMatch(t, []string{"uid", "time", "provider", "engine", "topology"}, keys(fields))
+
+			var snapshot Snapshot
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &snapshot))
+			require.Equal(t, uid, snapshot.UID)
+			require.Equal(t, "aws-sim", snapshot.Provider)
+			require.Equal(t, "slurm", snapshot.Engine)
+			require.False(t, snapshot.Time.IsZero())
+			require.NotNil(t, snapshot.Topology)
+			require.Contains(t, snapshot.Topology.Vertices, topology.TopologyTree)
+
+			names := make(map[string]bool)
+			collectNames(snapshot.Topology, names)
+			for _, name := range []string{"n11-1", "n11-2", "n12-1", "n12-2", "n13-1", "n13-2", "n14-1", "n14-2"} {
+				require.True(t, names[name], "missing node %s", name)
+			}
+		})
+	}
+
+	// the snapshot of a multi-engine request keeps the engine parameters
+	multi := topology.NewRequest("aws-sim", nil, "slurm,k8s", nil)
+	multi.Engines = []topology.Engine{
+		{Name: "slurm", Params: map[string]any{"plugin": "topology/block"}},
+		{Name: "k8s"},
+	}
+	multiUID := uuid.New().String()
+	require.NoError(t, srv.snapshots.save(multiUID, multi, root))
+	data, err := srv.snapshots.load(multiUID)
+	require.NoError(t, err)
+	var snapshot Snapshot
+	require.NoError(t, json.Unmarshal(data, &snapshot))
+	require.Equal(t, multi.Engines, snapshot.Engines)
+}
+
+func TestSnapshotPrune(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC)
+	store := newSnapshotStore(&config.Snapshot{Dir: dir, MaxCount: 3, MaxAge: time.Hour})
+	store.now = func() time.Time { return now }
+
+	tr := topology.NewRequest("test", nil, "slurm", nil)
+	root := &topology.Vertex{Vertices: map[string]*topology.Vertex{}}
+
+	// an unrelated file is left intact
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README"), nil, 0o644))
+
+	var uids []string
+	for i := 0; i < 6; i++ {
+		uids = append(uids, uuid.New().String())
+	}
+
+	// expired snapshot
+	now = now.Add(-2 * time.Hour)
+	require.NoError(t, store.save(uids[0], tr, root))
+
+	// the most recent snapshots are kept up to the maximum count
+	now = now.Add(2 * time.Hour)
+	for _, uid := range uids[1:] {
+		now = now.Add(time.Second)
+		require.NoError(t, store.save(uid, tr, root))
+	}
+
+	for i, uid := range uids {
+		data, err := store.load(uid)
+		require.NoError(t, err)
+		require.Equal(t, i >= 3, data != nil, fmt.Sprintf("snapshot %d", i))
+	}
+
+	_, err := os.Stat(filepath.Join(dir, "README"))
+	require.NoError(t, err)
+
+	// all snapshots expire
+	now = now.Add(2 * time.Hour)
+	require.NoError(t, store.prune())
+	matches, err := filepath.Glob(filepath.Join(dir, "*"+snapshotExt))
+	require.NoError(t, err)
+	require.Empty(t, matches)
+}
+
+func TestNewSnapshotStore(t *testing.T) {
+	require.Nil(t, newSnapshotStore(nil))
+
+	store := newSnapshotStore(&config.Snapshot{Dir: "/tmp"})
+	require.Equal(t, defaultSnapshotMaxCount, store.maxCount)
+	require.Equal(t, defaultSnapshotMaxAge, store.maxAge)
+}
+
+func keys(m map[string]json.RawMessage) []string {
+	ret := make([]string, 0, len(m))
+	for key := range m {
+		ret = append(ret, key)
+	}
+	return ret
+}
+
+func collectNames(v *topology.Vertex, names map[string]bool) {
+	if len(v.Name) != 0 {
+		names[v.Name] = true
+	}
+	for _, w := range v.Vertices {
+		collectNames(w, names)
+	}
+}
@@ -0,0 +1,141 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/NVIDIA/topograph/pkg/topology"
+)
+
+// Status is the response of the /v1/status endpoint
+type Status struct {
+	QueueDepth    int            `json:"queue_depth"`
+	InFlightCount int            `json:"in_flight_count"`
+	Pending       *PendingStatus `json:"pending,omitempty"`
+	InFlight      *RequestInfo   `json:"in_flight,omitempty"`
+	Completed     []*RequestInfo `json:"completed"`
+}
+
+// PendingStatus describes the request waiting in the aggregation window
+type PendingStatus struct {
+	UID         string  `json:"uid"`
+	Provider    string  `json:"provider"`
+	Engine      string  `json:"engine"`
+	Submissions int     `json:"submissions"`
+	FlushIn     float64 `json:"flush_in_seconds"`
+}
+
+// request states
+const (
+	statePending   = "pending"
+	stateRunning   = "running"
+	stateSucceeded = "succeeded"
+	stateFailed    = "failed"
+)
+
+// RequestInfo describes a pending, in-flight or completed request
+type RequestInfo struct {
+	UID      string `json:"uid"`
+	Provider string `json:"provider"`
+	Engine   string `json:"engine"`
+	// Engines holds the engines of a multi-engine request, with their parameters
+	Engines   []topology.Engine `json:"engines,omitempty"`
+	State     string            `json:"state"`
+	Submitted time.Time         `json:"submitted"`
+	Stage     string            `json:"stage,omitempty"`
+	Status    int               `json:"status,omitempty"`
+	Message   string            `json:"message,omitempty"`
+	Duration  float64           `json:"duration_seconds"`
+}
+
+func getstatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	writeJSON(w, getStatus(srv.async.queue.Status()))
+}
+
+func getStatus(qs *QueueStatus) *Status {
+	status := &Status{
+		QueueDepth: qs.Depth,
+		Completed:  make([]*RequestInfo, 0, len(qs.Completed)),
+	}
+
+	if qs.Pending != nil {
+		provider, engine := getRequestNames(qs.Pending.Item)
+		status.Pending = &PendingStatus{
+			UID:         qs.Pending.UID,
+			Provider:    provider,
+			Engine:      engine,
+			Submissions: qs.Submissions,
+			FlushIn:     qs.FlushIn.Seconds(),
+		}
+	}
+
+	if qs.InFlight != nil {
+		status.InFlightCount = 1
+		status.InFlight = getRequestInfo(qs.InFlight, stateRunning)
+	}
+
+	for _, rs := range qs.Completed {
+		status.Completed = append(status.Completed, getRequestInfo(rs, getCompletedState(rs)))
+	}
+
+	return status
+}
+
+func getRequestInfo(rs *RequestStatus, state string) *RequestInfo {
+	provider, engine := getRequestNames(rs.Item)
+	return &RequestInfo{
+		UID:       rs.UID,
+		Provider:  provider,
+		Engine:    engine,
+		Engines:   getRequestEngines(rs.Item),
+		State:     state,
+		Submitted: rs.Submitted,
+		Stage:     rs.Stage,
+		Status:    rs.Status,
+		Message:   rs.Message,
+		Duration:  rs.Duration.Seconds(),
+	}
+}
+
+func getCompletedState(rs *RequestStatus) string {
+	if rs.Status == http.StatusOK {
+		return stateSucceeded
+	}
+	return stateFailed
+}
+
+func getRequestNames(item interface{}) (string, string) {
+	if tr, ok := item.(*topology.Request); ok {
+		return tr.Provider.Name, tr.Engine.Name
+	}
+	return "", ""
+}
+
+// getRequestEngines returns the engines of a multi-engine request, or nil
+func getRequestEngines(item interface{}) []topology.Engine {
+	if tr, ok := item.(*topology.Request); ok {
+		return tr.Engines
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/NVIDIA/topograph/pkg/config"
+	"github.com/NVIDIA/topograph/pkg/topology"
+)
+
+func getTestStatus(t *testing.T) *Status {
+	req := httptest.NewRequest(http.MethodGet, "/v1/status", nil)
+	rec := httptest.NewRecorder()
+	getstatus(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var status Status
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &status))
+	return &status
+}
+
+func TestStatus(t *testing.T) {
+	cfg := &config.Config{
+		RequestAggregationDelay: time.Second,
+	}
+	srv = initHttpServer(context.TODO(), cfg)
+	defer srv.async.queue.Shutdown()
+
+	status := getTestStatus(t)
+	require.Equal(t, &Status{Completed: []*RequestInfo{}}, status)
+
+	// submit several requests to be coalesced into one
+	var uid string
+	for i := 0; i < 3; i++ {
+		tr := &topology.Request{
+			Provider: topology.Provider{
+				Name:   "aws-sim",
+				Params: map[string]any{"model_path": "../../tests/models/medium.yaml"},
+			},
+			Engine: topology.Engine{Name: "slurm"},
+		}
+		uid = srv.async.queue.Submit(tr)
+	}
+
+	status = getTestStatus(t)
+	require.Equal(t, 1, status.QueueDepth)
+	require.Equal(t, 0, status.InFlightCount)
+	require.NotNil(t, status.Pending)
+	require.Equal(t, uid, status.Pending.UID)
+	require.Equal(t, "aws-sim", status.Pending.Provider)
+	require.Equal(t, "slurm", status.Pending.Engine)
+	require.Equal(t, 3, status.Pending.Submissions)
+	require.LessOrEqual(t, status.Pending.FlushIn, time.Second.Seconds())
+	require.Empty(t, status.Completed)
+
+	// wait for the request to be processed
+	require.Eventually(t, func() bool {
+		return len(srv.async.queue.Status().Completed) != 0
+	}, 10*time.Second, 50*time.Millisecond)
+
+	status = getTestStatus(t)
+	require.Equal(t, 0, status.QueueDepth)
+	require.Equal(t, 0, status.InFlightCount)
+	require.Nil(t, status.Pending)
+	require.Nil(t, status.InFlight)
+	require.Len(t, status.Completed, 1)
+	require.Equal(t, uid, status.Completed[0].UID)
+	require.Equal(t, "aws-sim", status.Completed[0].Provider)
+	require.Equal(t, "slurm", status.Completed[0].Engine)
+	require.Equal(t, http.StatusOK, status.Completed[0].Status)
+	require.Equal(t, stageOutput, status.Completed[0].Stage)
+}
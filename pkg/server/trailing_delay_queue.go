@@ -25,28 +25,63 @@ import (
 	"github.com/google/uuid"
 	lru "github.com/hashicorp/golang-lru"
 	"k8s.io/klog/v2"
+
+	"github.com/NVIDIA/topograph/pkg/metrics"
 )
 
 const RequestHistorySize = 100
 
 type HandleFunc func(interface{}) (interface{}, *HTTPError)
 
+// MergeFunc combines the pending item with a newly submitted one
+type MergeFunc func(prev, next interface{}) interface{}
+
 type Completion struct {
 	Ret     interface{}
 	Status  int
 	Message string
 }
 
+// RequestStatus describes an item submitted to the queue
+type RequestStatus struct {
+	UID       string
+	Item      interface{}
+	Submitted time.Time     // first submission time
+	Stage     string        // processing stage of the in-flight item
+	Start     time.Time     // processing start time
+	Duration  time.Duration // processing duration of the completed item
+	Status    int           // HTTP status of the completed item
+	Message   string        // error message of the failed item
+}
+
+// QueueStatus is a snapshot of the queue state
+type QueueStatus struct {
+	Depth       int              // number of items waiting to be processed
+	Submissions int              // number of submissions coalesced into the pending item
+	FlushIn     time.Duration    // time until the pending item is processed
+	Pending     *RequestStatus   // pending item, if any
+	InFlight    *RequestStatus   // item being processed, if any
+	Completed   []*RequestStatus // recently completed items, most recent first
+}
+
 type TrailingDelayQueue struct {
-	mutex    sync.Mutex
-	ticker   *time.Ticker
-	handle   HandleFunc
-	delay    time.Duration
-	shutdown chan struct{}
-	item     interface{} // current item to be processed, if not nil
-	lastTime time.Time   // last submit time
-	uid      string      // unique item processing ID
-	store    *lru.Cache  // map uid:process result
+	mutex       sync.Mutex
+	ticker      *time.Ticker
+	handle      HandleFunc
+	merge       MergeFunc // combines coalesced items, if not nil
+	delay       time.Duration
+	shutdown    chan struct{}
+	flush       chan struct{}    // wakes up the processing loop to dispatch the pending item
+	force       bool             // dispatch the pending item regardless of the delay
+	item        interface{}      // current item to be processed, if not nil
+	lastTime    time.Time        // last submit time
+	uid         string           // unique item processing ID
+	submissions int              // number of submissions of the current item
+	firstTime   time.Time        // first submission time of the current item
+	historyTTL  time.Duration    // retention time of the completed items, if not zero
+	inFlight    *RequestStatus   // item being processed, if not nil
+	completed   []*RequestStatus // recently completed items, most recent first
+	store       *lru.Cache       // map uid:process result
 }
 
 func NewTrailingDelayQueue(handle HandleFunc, delay time.Duration) *TrailingDelayQueue {
@@ -54,6 +89,7 @@ func NewTrailingDelayQueue(handle HandleFunc, delay time.Duration) *TrailingDela
 		delay:    delay,
 		handle:   handle,
 		shutdown: make(chan struct{}),
+		flush:    make(chan struct{}, 1),
 		ticker:   time.NewTicker(delay),
 	}
 	q.store, _ = lru.New(RequestHistorySize)
@@ -70,51 +106,181 @@ func (q *TrailingDelayQueue) run() {
 		case <-q.shutdown:
 			klog.V(4).Infof("queue shutdown")
 			return
+		case <-q.flush:
+			q.process()
 		case <-q.ticker.C:
-			var item interface{}
-			var uid string
-			q.mutex.Lock()
-			if time.Since(q.lastTime) > q.delay && q.item != nil {
-				item = q.item
-				uid = q.uid
-				q.item = nil
-				q.uid = ""
-			}
-			q.mutex.Unlock()
-
-			if item != nil {
-				res := &Completion{}
-				if data, err := q.handle(item); err != nil {
-					res.Status = err.Code
-					res.Message = err.Error()
-					klog.Error(res.Message)
-				} else {
-					res.Ret = data
-					res.Status = http.StatusOK
-				}
-
-				q.mutex.Lock()
-				q.store.Add(uid, res)
-				q.mutex.Unlock()
-			}
+			q.process()
 		}
 	}
 }
 
+// process dispatches the pending item, if its delay has passed or it was flushed
+func (q *TrailingDelayQueue) process() {
+	var item interface{}
+	var uid string
+	q.mutex.Lock()
+	if (q.force || time.Since(q.lastTime) > q.delay) && q.item != nil {
+		item = q.item
+		uid = q.uid
+		q.item = nil
+		q.uid = ""
+		q.submissions = 0
+		q.force = false
+		q.inFlight = &RequestStatus{UID: uid, Item: item, Submitted: q.firstTime, Start: time.Now()}
+		metrics.SetQueueDepth(0)
+		metrics.SetInFlightRequests(1)
+	}
+	q.mutex.Unlock()
+
+	if item != nil {
+		res := &Completion{}
+		if data, err := q.handle(item); err != nil {
+			res.Status = err.Code
+			res.Message = err.Error()
+			klog.Error(res.Message)
+		} else {
+			res.Ret = data
+			res.Status = http.StatusOK
+		}
+
+		q.mutex.Lock()
+		q.store.Add(uid, res)
+		q.inFlight.Duration = time.Since(q.inFlight.Start)
+		q.inFlight.Status = res.Status
+		q.inFlight.Message = res.Message
+		q.completed = append([]*RequestStatus{q.inFlight}, q.completed...)
+		if len(q.completed) > RequestHistorySize {
+			q.completed = q.completed[:RequestHistorySize]
+		}
+		q.pruneHistory()
+		q.inFlight = nil
+		metrics.SetInFlightRequests(0)
+		q.mutex.Unlock()
+	}
+}
+
 func (q *TrailingDelayQueue) Submit(item interface{}) string {
 	q.mutex.Lock()
 	defer q.mutex.Unlock()
 
 	klog.Infof("Submit request; delay processing by %s", q.delay.String())
+	if q.item != nil && q.merge != nil {
+		item = q.merge(q.item, item)
+	}
 	q.item = item
 	q.lastTime = time.Now()
+	q.submissions++
 	if len(q.uid) == 0 {
 		q.uid = uuid.New().String()
+		q.firstTime = q.lastTime
 	}
+	metrics.SetQueueDepth(1)
 
 	return q.uid
 }
 
+// Flush dispatches the pending item right away, if its UID matches the given one or no UID is given,
+// and returns the UIDs of the flushed items
+func (q *TrailingDelayQueue) Flush(uid string) []string {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if q.item == nil || (len(uid) != 0 && uid != q.uid) {
+		return []string{}
+	}
+
+	klog.Infof("Flush request %s", q.uid)
+	q.force = true
+	select {
+	case q.flush <- struct{}{}:
+	default:
+	}
+
+	return []string{q.uid}
+}
+
+// SetMergeFunc sets the function combining the pending item with a newly submitted one.
+// By default, the newly submitted item replaces the pending one.
+func (q *TrailingDelayQueue) SetMergeFunc(merge MergeFunc) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	q.merge = merge
+}
+
+// SetHistoryTTL sets the retention time of the completed items. Zero disables expiration.
+func (q *TrailingDelayQueue) SetHistoryTTL(ttl time.Duration) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	q.historyTTL = ttl
+}
+
+// pruneHistory removes the completed items older than the retention time. Must be called under the lock.
+func (q *TrailingDelayQueue) pruneHistory() {
+	if q.historyTTL <= 0 {
+		return
+	}
+	for i, rs := range q.completed {
+		if time.Since(rs.Start.Add(rs.Duration)) > q.historyTTL {
+			q.completed = q.completed[:i]
+			return
+		}
+	}
+}
+
+// SetStage sets the processing stage of the in-flight item
+func (q *TrailingDelayQueue) SetStage(stage string) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if q.inFlight != nil {
+		q.inFlight.Stage = stage
+	}
+}
+
+// InFlightUID returns the UID of the in-flight item, if any
+func (q *TrailingDelayQueue) InFlightUID() string {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if q.inFlight != nil {
+		return q.inFlight.UID
+	}
+	return ""
+}
+
+// Status returns a snapshot of the queue state
+func (q *TrailingDelayQueue) Status() *QueueStatus {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	q.pruneHistory()
+
+	status := &QueueStatus{
+		Completed: make([]*RequestStatus, 0, len(q.completed)),
+	}
+	if q.item != nil {
+		status.Depth = 1
+		status.Submissions = q.submissions
+		status.Pending = &RequestStatus{UID: q.uid, Item: q.item, Submitted: q.firstTime, Start: q.lastTime}
+		if flushIn := q.delay - time.Since(q.lastTime); flushIn > 0 {
+			status.FlushIn = flushIn
+		}
+	}
+	if q.inFlight != nil {
+		inFlight := *q.inFlight
+		inFlight.Duration = time.Since(inFlight.Start)
+		status.InFlight = &inFlight
+	}
+	for _, rs := range q.completed {
+		completed := *rs
+		status.Completed = append(status.Completed, &completed)
+	}
+
+	return status
+}
+
 func (q *TrailingDelayQueue) Get(uid string) *Completion {
 	q.mutex.Lock()
 	defer q.mutex.Unlock()
@@ -17,6 +17,7 @@
 package server_test
 
 import (
+	"net/http"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -54,6 +55,36 @@ func TestTrailingDelayQueue(t *testing.T) {
 	queue.Shutdown()
 }
 
+func TestTrailingDelayQueueFlush(t *testing.T) {
+	processItem := func(item interface{}) (interface{}, *server.HTTPError) {
+		return item, nil
+	}
+
+	queue := server.NewTrailingDelayQueue(processItem, time.Hour)
+	defer queue.Shutdown()
+
+	// nothing to flush
+	require.Empty(t, queue.Flush(""))
+
+	uid := queue.Submit("item")
+	require.Equal(t, http.StatusAccepted, queue.Get(uid).Status)
+
+	// UID filter not matching the pending item
+	require.Empty(t, queue.Flush("other"))
+	require.Equal(t, http.StatusAccepted, queue.Get(uid).Status)
+
+	start := time.Now()
+	require.Equal(t, []string{uid}, queue.Flush(uid))
+	require.Eventually(t, func() bool {
+		return queue.Get(uid).Status == http.StatusOK
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Less(t, time.Since(start), time.Minute)
+	require.Equal(t, "item", queue.Get(uid).Ret)
+
+	// the flushed item is no longer pending
+	require.Empty(t, queue.Flush(""))
+}
+
 func TestLRU(t *testing.T) {
 	cache, _ := lru.New(3)
 
@@ -0,0 +1,40 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package topograph_test
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/NVIDIA/topograph/pkg/topograph"
+	"github.com/NVIDIA/topograph/pkg/topology"
+)
+
+func ExampleGenerate() {
+	tr := topology.NewRequest("test", nil, "slurm", nil)
+
+	data, err := topograph.Generate(context.Background(), tr, nil)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Print(string(data))
+	// Output:
+	// SwitchName=S1 Switches=S[2-3]
+	// SwitchName=S2 Nodes=Node[201-202],Node205
+	// SwitchName=S3 Nodes=Node[304-306]
+}
@@ -0,0 +1,136 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package topograph generates cluster topologies in-process, without running the topograph server.
+package topograph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/NVIDIA/topograph/pkg/config"
+	"github.com/NVIDIA/topograph/pkg/engines"
+	"github.com/NVIDIA/topograph/pkg/providers"
+	"github.com/NVIDIA/topograph/pkg/registry"
+	"github.com/NVIDIA/topograph/pkg/topology"
+)
+
+// ErrNoEngine indicates that the compute instances are listed by the engine, but no engine is given
+var ErrNoEngine = errors.New("no engine to list the compute instances")
+
+// Generate synchronously generates the topology config for the request, using the registered
+// provider and engine. The provider and engine names, as well as the provider credentials,
+// default to those of the config. cfg may be nil.
+func Generate(ctx context.Context, tr *topology.Request, cfg *config.Config) ([]byte, error) {
+	if tr == nil {
+		return nil, fmt.Errorf("missing topology request")
+	}
+	if cfg == nil {
+		cfg = &config.Config{}
+	}
+
+	prvName, engName := tr.Provider.Name, tr.Engine.Name
+	if len(prvName) == 0 {
+		prvName = cfg.Provider
+	}
+	if len(engName) == 0 {
+		engName = cfg.Engine
+	}
+	if len(prvName) == 0 {
+		return nil, fmt.Errorf("no provider given for topology request")
+	}
+	if len(engName) == 0 {
+		return nil, fmt.Errorf("no engine given for topology request")
+	}
+
+	engLoader, err := registry.Engines.Get(engName)
+	if err != nil {
+		return nil, err
+	}
+	eng, err := engLoader(ctx, engines.Config{Params: tr.Engine.Params})
+	if err != nil {
+		return nil, err
+	}
+
+	creds := tr.Provider.Creds
+	if len(creds) == 0 {
+		creds = cfg.Credentials
+	}
+
+	prv, err := NewProvider(ctx, prvName, providers.Config{
+		Creds:  creds,
+		Params: tr.Provider.Params,
+		Models: cfg.Models,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	root, err := generateTopology(ctx, prv, eng, tr.Nodes, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return eng.GenerateOutput(ctx, root, tr.Engine.Params)
+}
+
+// NewProvider returns the registered provider of the given name
+func NewProvider(ctx context.Context, name string, cfg providers.Config) (providers.Provider, error) {
+	loader, err := registry.Providers.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	return loader(ctx, cfg)
+}
+
+// ComputeInstances returns the compute instances listed by the provider, if it supports it,
+// or by the engine otherwise. It returns ErrNoEngine if the engine is needed but not given.
+func ComputeInstances(ctx context.Context, prv providers.Provider, eng engines.Engine) ([]topology.ComputeInstances, error) {
+	// Optional provider interface if it directly supports getting compute instances.
+	// (e.g., Test provider)
+	type simpleGetComputeInstances interface {
+		GetComputeInstances(ctx context.Context) ([]topology.ComputeInstances, error)
+	}
+
+	if t, ok := prv.(simpleGetComputeInstances); ok {
+		return t.GetComputeInstances(ctx)
+	}
+	if eng == nil {
+		return nil, ErrNoEngine
+	}
+	return eng.GetComputeInstances(ctx, prv)
+}
+
+// generateTopology returns the topology graph of the compute instances, getting them
+// from the provider or the engine if not given
+func generateTopology(ctx context.Context, prv providers.Provider, eng engines.Engine, cis []topology.ComputeInstances, cfg *config.Config) (*topology.Vertex, error) {
+	// the deadline covers the compute instance listing and the topology request
+	if cfg.ProviderTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.ProviderTimeout)
+		defer cancel()
+	}
+
+	if len(cis) == 0 {
+		var err error
+		if cis, err = ComputeInstances(ctx, prv, eng); err != nil {
+			return nil, fmt.Errorf("failed to get compute instances: %w", err)
+		}
+	}
+
+	return prv.GenerateTopologyConfig(ctx, cfg.PageSize, cis)
+}
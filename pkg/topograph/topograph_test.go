@@ -0,0 +1,165 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package topograph_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/NVIDIA/topograph/pkg/config"
+	"github.com/NVIDIA/topograph/pkg/engines"
+	enginefake "github.com/NVIDIA/topograph/pkg/engines/fake"
+	"github.com/NVIDIA/topograph/pkg/providers"
+	"github.com/NVIDIA/topograph/pkg/providers/fake"
+	"github.com/NVIDIA/topograph/pkg/topograph"
+	"github.com/NVIDIA/topograph/pkg/topology"
+)
+
+// listlessProvider cannot list the compute instances
+type listlessProvider struct{}
+
+func (listlessProvider) GenerateTopologyConfig(context.Context, *int, []topology.ComputeInstances) (*topology.Vertex, error) {
+	return nil, nil
+}
+
+func TestGenerate(t *testing.T) {
+	testCases := []struct {
+		name string
+		tr   *topology.Request
+		cfg  *config.Config
+		out  string
+		err  string
+	}{
+		{
+			name: "Case 1: missing request",
+			err:  "missing topology request",
+		},
+		{
+			name: "Case 2: missing provider",
+			tr:   topology.NewRequest("", nil, "slurm", nil),
+			err:  "no provider given for topology request",
+		},
+		{
+			name: "Case 3: missing engine",
+			tr:   topology.NewRequest("test", nil, "", nil),
+			err:  "no engine given for topology request",
+		},
+		{
+			name: "Case 4: unsupported provider",
+			tr:   topology.NewRequest("unknown", nil, "slurm", nil),
+			err:  `unsupported provider "unknown", unsupported provider`,
+		},
+		{
+			name: "Case 5: tree topology",
+			tr:   topology.NewRequest("test", nil, "slurm", nil),
+			out: `SwitchName=S1 Switches=S[2-3]
+SwitchName=S2 Nodes=Node[201-202],Node205
+SwitchName=S3 Nodes=Node[304-306]
+`,
+		},
+		{
+			name: "Case 6: provider and engine from config",
+			tr:   &topology.Request{},
+			cfg:  &config.Config{Provider: "test", Engine: "slurm"},
+			out: `SwitchName=S1 Switches=S[2-3]
+SwitchName=S2 Nodes=Node[201-202],Node205
+SwitchName=S3 Nodes=Node[304-306]
+`,
+		},
+		{
+			name: "Case 7: block topology from model",
+			tr: &topology.Request{
+				Provider: topology.Provider{
+					Name:   "test",
+					Params: map[string]any{"model_path": "../../tests/models/medium.yaml"},
+				},
+				Engine: topology.Engine{
+					Name:   "slurm",
+					Params: map[string]any{"plugin": "topology/block", "block_sizes": "2,4"},
+				},
+			},
+			out: `BlockName=cb11 Nodes=n11-[1-2]
+BlockName=cb12 Nodes=n12-[1-2]
+BlockName=cb13 Nodes=n13-[1-2]
+BlockName=cb14 Nodes=n14-[1-2]
+BlockSizes=2,4
+`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			data, err := topograph.Generate(context.Background(), tc.tr, tc.cfg)
+			if len(tc.err) != 0 {
+				require.EqualError(t, err, tc.err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tc.out, string(data))
+			}
+		})
+	}
+}
+
+func TestComputeInstances(t *testing.T) {
+	prvInstances := []topology.ComputeInstances{{Region: "r1", Instances: map[string]string{"i1": "n1"}}}
+	engInstances := []topology.ComputeInstances{{Region: "r2", Instances: map[string]string{"i2": "n2"}}}
+
+	testCases := []struct {
+		name string
+		prv  providers.Provider
+		eng  engines.Engine
+		cis  []topology.ComputeInstances
+		err  error
+	}{
+		{
+			name: "Case 1: listed by the provider",
+			prv:  fake.NewTree().WithComputeInstances(prvInstances...),
+			eng:  enginefake.New().WithComputeInstances(engInstances...),
+			cis:  prvInstances,
+		},
+		{
+			name: "Case 2: listed by the provider without engine",
+			prv:  fake.NewTree().WithComputeInstances(prvInstances...),
+			cis:  prvInstances,
+		},
+		{
+			name: "Case 3: listed by the engine",
+			prv:  listlessProvider{},
+			eng:  enginefake.New().WithComputeInstances(engInstances...),
+			cis:  engInstances,
+		},
+		{
+			name: "Case 4: no engine",
+			prv:  listlessProvider{},
+			err:  topograph.ErrNoEngine,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cis, err := topograph.ComputeInstances(context.Background(), tc.prv, tc.eng)
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tc.cis, cis)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package topology
+
+import "maps"
+
+// Clone returns a deep copy of the graph. Vertices shared within the graph stay shared in the copy.
+func (v *Vertex) Clone() *Vertex {
+	return cloneVertex(v, make(map[*Vertex]*Vertex))
+}
+
+func cloneVertex(v *Vertex, clones map[*Vertex]*Vertex) *Vertex {
+	if v == nil {
+		return nil
+	}
+	if w, ok := clones[v]; ok {
+		return w
+	}
+
+	w := &Vertex{Name: v.Name, ID: v.ID}
+	clones[v] = w
+	if v.Vertices != nil {
+		w.Vertices = make(map[string]*Vertex, len(v.Vertices))
+		for id, u := range v.Vertices {
+			w.Vertices[id] = cloneVertex(u, clones)
+		}
+	}
+	w.Metadata = maps.Clone(v.Metadata)
+	return w
+}
@@ -0,0 +1,57 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package topology_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/NVIDIA/topograph/internal/fixtures"
+	"github.com/NVIDIA/topograph/pkg/topology"
+)
+
+func TestClone(t *testing.T) {
+	root, _ := fixtures.BlockWithMultiIBTestSet()
+	stats := root.Stats()
+
+	clone := root.Clone()
+	require.Equal(t, root, clone)
+
+	// changes to the copy leave the graph unchanged
+	clone.ExcludeNodes(map[string]bool{"Node201": true, "Node304": true})
+	clone.Vertices[topology.TopologyTree].Metadata = map[string]string{topology.KeyPlugin: topology.TopologyTree}
+	require.Equal(t, stats, root.Stats())
+	require.NotEqual(t, root, clone)
+
+	require.Nil(t, (*topology.Vertex)(nil).Clone())
+}
+
+func TestCloneSharedVertex(t *testing.T) {
+	node := &topology.Vertex{Name: "node1", ID: "node1"}
+	root := &topology.Vertex{
+		Vertices: map[string]*topology.Vertex{
+			"sw1": {ID: "sw1", Vertices: map[string]*topology.Vertex{"node1": node}},
+			"sw2": {ID: "sw2", Vertices: map[string]*topology.Vertex{"node1": node}},
+		},
+	}
+
+	clone := root.Clone()
+	require.Equal(t, root, clone)
+	require.Same(t, clone.Vertices["sw1"].Vertices["node1"], clone.Vertices["sw2"].Vertices["node1"])
+	require.NotSame(t, node, clone.Vertices["sw1"].Vertices["node1"])
+}
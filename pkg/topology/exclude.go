@@ -0,0 +1,44 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package topology
+
+// ExcludeNodes removes the given compute nodes from all topologies of the graph.
+// The switches and domains left without vertices are removed as well.
+func (v *Vertex) ExcludeNodes(nodes map[string]bool) {
+	if len(nodes) == 0 {
+		return
+	}
+	for _, root := range v.Vertices {
+		excludeNodes(root, nodes)
+	}
+}
+
+// excludeNodes removes the excluded nodes under the vertex, and the vertices emptied by the removal
+func excludeNodes(v *Vertex, nodes map[string]bool) {
+	for id, w := range v.Vertices {
+		if len(w.Vertices) == 0 {
+			if nodes[nodeName(w)] {
+				delete(v.Vertices, id)
+			}
+			continue
+		}
+		excludeNodes(w, nodes)
+		if len(w.Vertices) == 0 {
+			delete(v.Vertices, id)
+		}
+	}
+}
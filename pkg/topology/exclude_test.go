@@ -0,0 +1,57 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package topology_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/NVIDIA/topograph/internal/fixtures"
+	"github.com/NVIDIA/topograph/pkg/topology"
+)
+
+func TestExcludeNodes(t *testing.T) {
+	testCases := []struct {
+		name  string
+		nodes map[string]bool
+		stats *topology.Stats
+	}{
+		{
+			name:  "Case 1: no excluded nodes",
+			stats: &topology.Stats{Nodes: 12, Switches: []int{4, 2, 2}, Depth: 3, Blocks: 4},
+		},
+		{
+			name:  "Case 2: excluded nodes",
+			nodes: map[string]bool{"Node104": true, "Node201": true, "Unknown": true},
+			stats: &topology.Stats{Nodes: 10, Switches: []int{4, 2, 2}, Depth: 3, Blocks: 4},
+		},
+		{
+			name:  "Case 3: excluded switch and block",
+			nodes: map[string]bool{"Node104": true, "Node105": true, "Node106": true},
+			stats: &topology.Stats{Nodes: 9, Switches: []int{3, 2, 2}, Depth: 3, Blocks: 3},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			root, _ := fixtures.BlockWithMultiIBTestSet()
+			root.ExcludeNodes(tc.nodes)
+			require.Equal(t, tc.stats, root.Stats())
+		})
+	}
+}
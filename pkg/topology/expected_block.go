@@ -0,0 +1,35 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package topology
+
+import "strings"
+
+// nvl72BlockSize is the number of compute nodes in a fully populated NVL72 rack
+const nvl72BlockSize = 18
+
+// ExpectedBlockSize returns the number of compute nodes of a fully populated accelerator domain
+// of the instance type or model, e.g. "GB200", "p6e-gb200.36xlarge" or "BM.GPU.GB200.4",
+// or 0 if the instance type is not known to form such domains
+func ExpectedBlockSize(instanceType string) int {
+	t := strings.ToLower(instanceType)
+	switch {
+	case strings.Contains(t, "gb200"), strings.Contains(t, "gb300"):
+		return nvl72BlockSize
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,60 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package topology
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpectedBlockSize(t *testing.T) {
+	testCases := []struct {
+		name         string
+		instanceType string
+		expected     int
+	}{
+		{
+			name: "Case 1: no instance type",
+		},
+		{
+			name:         "Case 2: GB200 model",
+			instanceType: "GB200",
+			expected:     18,
+		},
+		{
+			name:         "Case 3: GB200 instance type",
+			instanceType: "p6e-gb200.36xlarge",
+			expected:     18,
+		},
+		{
+			name:         "Case 4: GB300 shape",
+			instanceType: "BM.GPU.GB300.4",
+			expected:     18,
+		},
+		{
+			name:         "Case 5: instance type without accelerator domains",
+			instanceType: "p5.48xlarge",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, ExpectedBlockSize(tc.instanceType))
+		})
+	}
+}
@@ -0,0 +1,293 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package topology
+
+import "sort"
+
+// MergeConflict is a node placed under different leaf switches by two topology sources,
+// where the leaf switch of the lower-priority source is known to the higher-priority one
+type MergeConflict struct {
+	Node string
+	// Source is the index of the lower-priority source, whose leaf switch is dropped
+	Source int
+	// Kept and Dropped are the IDs of the leaf switches of the higher- and lower-priority sources
+	Kept    string
+	Dropped string
+}
+
+// Merge combines the topology graphs of several sources, given in priority order, into one graph.
+// The nodes are matched on their names. The switches placing a node are taken from the
+// highest-priority source, and the lower-priority sources fill in the tiers above them.
+// The tiers are matched by their height above the nodes, so that the sources may use
+// different switch IDs. A switch keeps the first parent it is assigned, so that the merged
+// tree stays a tree. Each node is placed into the block of the highest-priority source
+// that has one, and the metadata of the higher-priority sources take precedence.
+// The nodes found only in the blocks are not added to the tree.
+func Merge(roots ...*Vertex) (*Vertex, []MergeConflict) {
+	m := &merger{
+		nodes:    make(map[string]*Vertex),
+		switches: make(map[string]*Vertex),
+		parents:  make(map[string]string),
+	}
+
+	placements := make([]map[string][]*Vertex, len(roots))
+	known := make(map[string]int) // switch IDs by the index of the first source having them
+	for i, root := range roots {
+		if root == nil {
+			continue
+		}
+		if treeRoot, ok := root.Vertices[TopologyTree]; ok {
+			placements[i] = m.collectPaths(treeRoot)
+			for _, path := range placements[i] {
+				for _, sw := range path {
+					if _, ok := known[sw.ID]; !ok {
+						known[sw.ID] = i
+					}
+				}
+			}
+		}
+		if blockRoot, ok := root.Vertices[TopologyBlock]; ok {
+			for _, block := range blockRoot.Vertices {
+				for _, node := range block.Vertices {
+					m.addNode(node)
+				}
+			}
+		}
+	}
+
+	merged := &Vertex{Vertices: make(map[string]*Vertex)}
+	treeRoot := &Vertex{Vertices: make(map[string]*Vertex)}
+	var unplaced []string
+	var conflicts []MergeConflict
+
+	for _, name := range treeNodes(placements) {
+		var path []*Vertex
+		for i, paths := range placements {
+			p := paths[name]
+			if len(p) == 0 {
+				continue
+			}
+			if len(path) == 0 {
+				path = p
+				continue
+			}
+			if p[0].ID != path[0].ID && known[p[0].ID] < i {
+				conflicts = append(conflicts, MergeConflict{Node: name, Source: i, Kept: path[0].ID, Dropped: p[0].ID})
+				continue
+			}
+			// fill in the tiers missing from the higher-priority sources
+			if len(p) > len(path) {
+				path = append(path[:len(path):len(path)], p[len(path):]...)
+			}
+		}
+
+		if len(path) == 0 {
+			unplaced = append(unplaced, name)
+			continue
+		}
+		m.place(treeRoot, m.nodes[name], path)
+	}
+
+	if len(unplaced) != 0 {
+		noTopology := &Vertex{ID: NoTopology, Vertices: make(map[string]*Vertex)}
+		for _, name := range unplaced {
+			node := m.nodes[name]
+			noTopology.Vertices[node.ID] = node
+		}
+		treeRoot.Vertices[NoTopology] = noTopology
+	}
+	if len(treeRoot.Vertices) != 0 {
+		merged.Vertices[TopologyTree] = treeRoot
+	}
+
+	if blockRoot := m.mergeBlocks(roots); blockRoot != nil {
+		merged.Vertices[TopologyBlock] = blockRoot
+	}
+
+	for _, root := range roots {
+		if root == nil {
+			continue
+		}
+		for key, val := range root.Metadata {
+			if merged.Metadata == nil {
+				merged.Metadata = make(map[string]string)
+			}
+			if _, ok := merged.Metadata[key]; !ok {
+				merged.Metadata[key] = val
+			}
+		}
+	}
+
+	return merged, conflicts
+}
+
+type merger struct {
+	nodes    map[string]*Vertex // compute nodes by name, from the highest-priority source
+	switches map[string]*Vertex // merged switches by ID
+	parents  map[string]string  // parent switch IDs of the merged switches
+}
+
+// collectPaths returns the switches placing each node of the tree, starting from the leaf switch.
+// The nodes without topology have an empty path.
+func (m *merger) collectPaths(treeRoot *Vertex) map[string][]*Vertex {
+	paths := make(map[string][]*Vertex)
+	for _, id := range sortedIDs(treeRoot) {
+		v := treeRoot.Vertices[id]
+		if IsNoTopology(id) {
+			for _, nodeID := range sortedIDs(v) {
+				name := m.addNode(v.Vertices[nodeID])
+				if _, ok := paths[name]; !ok {
+					paths[name] = nil
+				}
+			}
+			continue
+		}
+		m.walk(v, nil, paths)
+	}
+	return paths
+}
+
+func (m *merger) walk(v *Vertex, ancestors []*Vertex, paths map[string][]*Vertex) {
+	if len(v.Vertices) == 0 {
+		name := m.addNode(v)
+		if len(paths[name]) == 0 {
+			path := make([]*Vertex, 0, len(ancestors))
+			for i := len(ancestors) - 1; i >= 0; i-- {
+				path = append(path, ancestors[i])
+			}
+			paths[name] = path
+		}
+		return
+	}
+
+	ancestors = append(ancestors, v)
+	for _, id := range sortedIDs(v) {
+		m.walk(v.Vertices[id], ancestors, paths)
+	}
+}
+
+// addNode records the compute node, unless a higher-priority source has it, and returns its name
+func (m *merger) addNode(v *Vertex) string {
+	name := nodeName(v)
+	if _, ok := m.nodes[name]; !ok {
+		m.nodes[name] = &Vertex{Name: v.Name, ID: v.ID, Metadata: v.Metadata}
+	}
+	return name
+}
+
+// treeNodes returns the sorted names of the nodes in the trees of the sources
+func treeNodes(placements []map[string][]*Vertex) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, paths := range placements {
+		for name := range paths {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// place attaches the node to the leaf switch of the path, and links the switches
+// of the path that have no parent yet
+func (m *merger) place(treeRoot, node *Vertex, path []*Vertex) {
+	leaf := m.getSwitch(path[0])
+	leaf.Vertices[node.ID] = node
+
+	for i := 0; i < len(path); i++ {
+		id := path[i].ID
+		if _, ok := m.parents[id]; ok {
+			return
+		}
+		if i+1 == len(path) {
+			m.parents[id] = ""
+			treeRoot.Vertices[id] = m.switches[id]
+			return
+		}
+		parent := m.getSwitch(path[i+1])
+		m.parents[id] = parent.ID
+		parent.Vertices[id] = m.switches[id]
+	}
+}
+
+// getSwitch returns the merged switch with the ID of the source switch
+func (m *merger) getSwitch(v *Vertex) *Vertex {
+	sw, ok := m.switches[v.ID]
+	if !ok {
+		sw = &Vertex{Name: v.Name, ID: v.ID, Metadata: v.Metadata, Vertices: make(map[string]*Vertex)}
+		m.switches[v.ID] = sw
+	}
+	return sw
+}
+
+// mergeBlocks places each node into the block of the highest-priority source that has one
+func (m *merger) mergeBlocks(roots []*Vertex) *Vertex {
+	blockRoot := &Vertex{Vertices: make(map[string]*Vertex)}
+	placed := make(map[string]bool)
+
+	for _, root := range roots {
+		if root == nil {
+			continue
+		}
+		src, ok := root.Vertices[TopologyBlock]
+		if !ok {
+			continue
+		}
+		for _, blockID := range sortedIDs(src) {
+			block := src.Vertices[blockID]
+			for _, nodeID := range sortedIDs(block) {
+				name := m.addNode(block.Vertices[nodeID])
+				if placed[name] {
+					continue
+				}
+				placed[name] = true
+				merged, ok := blockRoot.Vertices[blockID]
+				if !ok {
+					merged = &Vertex{Name: block.Name, ID: block.ID, Metadata: block.Metadata, Vertices: make(map[string]*Vertex)}
+					blockRoot.Vertices[blockID] = merged
+				}
+				node := m.nodes[name]
+				merged.Vertices[node.ID] = node
+			}
+		}
+	}
+
+	if len(blockRoot.Vertices) == 0 {
+		return nil
+	}
+	return blockRoot
+}
+
+// nodeName returns the name of the compute node, or its ID if it has no name
+func nodeName(v *Vertex) string {
+	if len(v.Name) != 0 {
+		return v.Name
+	}
+	return v.ID
+}
+
+func sortedIDs(v *Vertex) []string {
+	ids := make([]string, 0, len(v.Vertices))
+	for id := range v.Vertices {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
@@ -0,0 +1,226 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package topology_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/NVIDIA/topograph/internal/fixtures"
+	"github.com/NVIDIA/topograph/pkg/topology"
+)
+
+// mergeSource builds a topology graph from the switch paths of the nodes, starting from the leaf switch,
+// and the block nodes. Node "nX" has instance ID "iX".
+func mergeSource(paths map[string][]string, blocks map[string][]string, metadata map[string]string) *topology.Vertex {
+	root := &topology.Vertex{Vertices: map[string]*topology.Vertex{}, Metadata: metadata}
+	switches := map[string]*topology.Vertex{}
+	getSwitch := func(id string) *topology.Vertex {
+		sw, ok := switches[id]
+		if !ok {
+			sw = &topology.Vertex{ID: id, Vertices: map[string]*topology.Vertex{}}
+			switches[id] = sw
+		}
+		return sw
+	}
+
+	if len(paths) != 0 {
+		treeRoot := &topology.Vertex{Vertices: map[string]*topology.Vertex{}}
+		for name, path := range paths {
+			node := &topology.Vertex{ID: "i" + name[1:], Name: name}
+			if len(path) == 0 {
+				path = []string{topology.NoTopology}
+			}
+			getSwitch(path[0]).Vertices[node.ID] = node
+			for i := 1; i < len(path); i++ {
+				getSwitch(path[i]).Vertices[path[i-1]] = getSwitch(path[i-1])
+			}
+			top := path[len(path)-1]
+			treeRoot.Vertices[top] = getSwitch(top)
+		}
+		root.Vertices[topology.TopologyTree] = treeRoot
+	}
+
+	if len(blocks) != 0 {
+		blockRoot := &topology.Vertex{Vertices: map[string]*topology.Vertex{}}
+		for id, names := range blocks {
+			block := &topology.Vertex{ID: id, Vertices: map[string]*topology.Vertex{}}
+			for _, name := range names {
+				block.Vertices["i"+name[1:]] = &topology.Vertex{ID: "i" + name[1:], Name: name}
+			}
+			blockRoot.Vertices[id] = block
+		}
+		root.Vertices[topology.TopologyBlock] = blockRoot
+	}
+
+	return root
+}
+
+// mergedPaths returns the switch paths of the nodes of the graph, starting from the leaf switch,
+// and the blocks of the nodes
+func mergedPaths(root *topology.Vertex) (map[string][]string, map[string]string) {
+	paths := map[string][]string{}
+	var walk func(v *topology.Vertex, ancestors []string)
+	walk = func(v *topology.Vertex, ancestors []string) {
+		if len(v.Vertices) == 0 {
+			path := []string{}
+			for i := len(ancestors) - 1; i >= 0; i-- {
+				path = append(path, ancestors[i])
+			}
+			paths[v.Name] = path
+			return
+		}
+		for _, w := range v.Vertices {
+			walk(w, append(ancestors[:len(ancestors):len(ancestors)], v.ID))
+		}
+	}
+	if treeRoot, ok := root.Vertices[topology.TopologyTree]; ok {
+		for _, v := range treeRoot.Vertices {
+			walk(v, nil)
+		}
+	}
+
+	blocks := map[string]string{}
+	if blockRoot, ok := root.Vertices[topology.TopologyBlock]; ok {
+		for _, block := range blockRoot.Vertices {
+			for _, node := range block.Vertices {
+				blocks[node.Name] = block.ID
+			}
+		}
+	}
+	return paths, blocks
+}
+
+func TestMerge(t *testing.T) {
+	testCases := []struct {
+		name      string
+		sources   []*topology.Vertex
+		paths     map[string][]string
+		blocks    map[string]string
+		metadata  map[string]string
+		conflicts []topology.MergeConflict
+	}{
+		{
+			name: "Case 1: single source",
+			sources: []*topology.Vertex{
+				mergeSource(map[string][]string{"n1": {"S2", "S1"}, "n2": {"S3", "S1"}}, map[string][]string{"B1": {"n1", "n2"}}, nil),
+			},
+			paths:  map[string][]string{"n1": {"S2", "S1"}, "n2": {"S3", "S1"}},
+			blocks: map[string]string{"n1": "B1", "n2": "B1"},
+		},
+		{
+			name: "Case 2: disjoint trees",
+			sources: []*topology.Vertex{
+				mergeSource(map[string][]string{"n1": {"S2", "S1"}}, nil, nil),
+				mergeSource(map[string][]string{"n2": {"T2", "T1"}}, nil, nil),
+			},
+			paths:  map[string][]string{"n1": {"S2", "S1"}, "n2": {"T2", "T1"}},
+			blocks: map[string]string{},
+		},
+		{
+			name: "Case 3: upper tiers filled in from a source with different switch IDs",
+			sources: []*topology.Vertex{
+				mergeSource(map[string][]string{"n1": {"ib1"}, "n2": {"ib1"}, "n3": {"ib2"}}, nil, nil),
+				mergeSource(map[string][]string{
+					"n1": {"leaf1", "spine1", "dc1"},
+					"n2": {"leaf1", "spine1", "dc1"},
+					"n3": {"leaf2", "spine2", "dc1"},
+				}, nil, nil),
+			},
+			paths: map[string][]string{
+				"n1": {"ib1", "spine1", "dc1"},
+				"n2": {"ib1", "spine1", "dc1"},
+				"n3": {"ib2", "spine2", "dc1"},
+			},
+			blocks: map[string]string{},
+		},
+		{
+			name: "Case 4: overlapping trees with a partial upper tier",
+			sources: []*topology.Vertex{
+				mergeSource(map[string][]string{"n1": {"S2", "S1"}, "n2": {"S3"}}, nil, nil),
+				mergeSource(map[string][]string{"n1": {"S2", "S1", "S0"}, "n2": {"S3", "S4", "S0"}, "n3": {"S5", "S4", "S0"}}, nil, nil),
+			},
+			paths: map[string][]string{
+				"n1": {"S2", "S1", "S0"},
+				"n2": {"S3", "S4", "S0"},
+				"n3": {"S5", "S4", "S0"},
+			},
+			blocks: map[string]string{},
+		},
+		{
+			name: "Case 5: conflicting leaf switches",
+			sources: []*topology.Vertex{
+				mergeSource(map[string][]string{"n1": {"S2", "S1"}, "n2": {"S3", "S1"}}, nil, nil),
+				mergeSource(map[string][]string{"n1": {"S2", "S1"}, "n2": {"S2", "S1"}}, nil, nil),
+			},
+			paths:     map[string][]string{"n1": {"S2", "S1"}, "n2": {"S3", "S1"}},
+			blocks:    map[string]string{},
+			conflicts: []topology.MergeConflict{{Node: "n2", Source: 1, Kept: "S3", Dropped: "S2"}},
+		},
+		{
+			name: "Case 6: nodes without topology placed by a lower-priority source",
+			sources: []*topology.Vertex{
+				mergeSource(map[string][]string{"n1": {"S2", "S1"}, "n2": nil, "n3": nil}, nil, nil),
+				mergeSource(map[string][]string{"n2": {"S3", "S1"}}, nil, nil),
+			},
+			paths: map[string][]string{
+				"n1": {"S2", "S1"},
+				"n2": {"S3", "S1"},
+				"n3": {topology.NoTopology},
+			},
+			blocks: map[string]string{},
+		},
+		{
+			name: "Case 7: blocks and metadata",
+			sources: []*topology.Vertex{
+				mergeSource(map[string][]string{"n1": {"S2"}}, map[string][]string{"B1": {"n1"}},
+					map[string]string{topology.KeyPlugin: topology.TopologyBlock}),
+				mergeSource(nil, map[string][]string{"nvl1": {"n1", "n2"}},
+					map[string]string{topology.KeyPlugin: topology.TopologyTree, topology.KeyBlockSizes: "2"}),
+			},
+			paths:    map[string][]string{"n1": {"S2"}},
+			blocks:   map[string]string{"n1": "B1", "n2": "nvl1"},
+			metadata: map[string]string{topology.KeyPlugin: topology.TopologyBlock, topology.KeyBlockSizes: "2"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			merged, conflicts := topology.Merge(tc.sources...)
+			paths, blocks := mergedPaths(merged)
+			require.Equal(t, tc.paths, paths)
+			require.Equal(t, tc.blocks, blocks)
+			require.Equal(t, tc.metadata, merged.Metadata)
+			require.Equal(t, tc.conflicts, conflicts)
+		})
+	}
+}
+
+func TestMergeSameTopology(t *testing.T) {
+	root, _ := fixtures.BlockWithMultiIBTestSet()
+	other, _ := fixtures.BlockWithMultiIBTestSet()
+
+	merged, conflicts := topology.Merge(root, other)
+	require.Empty(t, conflicts)
+
+	expectedPaths, expectedBlocks := mergedPaths(root)
+	paths, blocks := mergedPaths(merged)
+	require.Equal(t, expectedPaths, paths)
+	require.Equal(t, expectedBlocks, blocks)
+	require.Equal(t, root.Stats(), merged.Stats())
+}
@@ -0,0 +1,66 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package topology
+
+import "fmt"
+
+// Missing block policies define the placement of an instance whose lowest (block) switch
+// is unknown, while the upper tiers are present.
+const (
+	// MissingBlockParentToSpine attaches the instance directly to the spine switch
+	MissingBlockParentToSpine = "parent_to_spine"
+	// MissingBlockSyntheticBlock attaches the instance to a synthetic block switch under the spine
+	MissingBlockSyntheticBlock = "synthetic_block"
+	// MissingBlockNoTopology places the instance among the nodes without topology
+	MissingBlockNoTopology = "no_topology"
+
+	DefaultMissingBlockPolicy = MissingBlockNoTopology
+)
+
+// ValidateMissingBlockPolicy returns an error if the policy is not supported.
+// An empty policy is valid and stands for the default one.
+func ValidateMissingBlockPolicy(policy string) error {
+	switch policy {
+	case "", MissingBlockParentToSpine, MissingBlockSyntheticBlock, MissingBlockNoTopology:
+		return nil
+	default:
+		return fmt.Errorf("unsupported missing_block_policy %q", policy)
+	}
+}
+
+// ResolveMissingBlock returns the switch IDs, starting from the lowest tier, for an instance
+// with missing block switch, given the IDs of the upper tiers starting from the spine.
+// It returns nil if the instance should be placed among the nodes without topology.
+func ResolveMissingBlock(policy string, upperIDs []string) []string {
+	if len(upperIDs) == 0 {
+		return nil
+	}
+
+	switch policy {
+	case MissingBlockParentToSpine:
+		return upperIDs
+	case MissingBlockSyntheticBlock:
+		return append([]string{SyntheticBlockID(upperIDs[0])}, upperIDs...)
+	default:
+		return nil
+	}
+}
+
+// SyntheticBlockID returns the ID of the synthetic block switch under the spine
+func SyntheticBlockID(spineID string) string {
+	return spineID + "_nil"
+}
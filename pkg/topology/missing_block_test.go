@@ -0,0 +1,76 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package topology
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveMissingBlock(t *testing.T) {
+	testCases := []struct {
+		name     string
+		policy   string
+		upperIDs []string
+		expected []string
+		err      string
+	}{
+		{
+			name:     "Case 1: default policy",
+			upperIDs: []string{"spine", "core"},
+		},
+		{
+			name:     "Case 2: no topology",
+			policy:   MissingBlockNoTopology,
+			upperIDs: []string{"spine", "core"},
+		},
+		{
+			name:     "Case 3: parent to spine",
+			policy:   MissingBlockParentToSpine,
+			upperIDs: []string{"spine", "core"},
+			expected: []string{"spine", "core"},
+		},
+		{
+			name:     "Case 4: synthetic block",
+			policy:   MissingBlockSyntheticBlock,
+			upperIDs: []string{"spine", "core"},
+			expected: []string{"spine_nil", "spine", "core"},
+		},
+		{
+			name:   "Case 5: missing upper tiers",
+			policy: MissingBlockSyntheticBlock,
+		},
+		{
+			name:   "Case 6: invalid policy",
+			policy: "bad",
+			err:    `unsupported missing_block_policy "bad"`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidateMissingBlockPolicy(tc.policy)
+			if len(tc.err) != 0 {
+				require.EqualError(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, ResolveMissingBlock(tc.policy, tc.upperIDs))
+		})
+	}
+}
@@ -17,16 +17,25 @@
 package topology
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"sort"
 	"strings"
+
+	"github.com/NVIDIA/topograph/internal/cluset"
+	"github.com/NVIDIA/topograph/internal/files"
 )
 
 type Request struct {
-	Provider Provider           `json:"provider"`
-	Engine   Engine             `json:"engine"`
-	Nodes    []ComputeInstances `json:"nodes"`
+	Provider Provider `json:"provider"`
+	Engine   Engine   `json:"engine"`
+	// Engines holds the engines of the request whose engine field is a list. The topology is generated once
+	// and passed to each engine. Engine.Name is then the comma-separated list of the engine names.
+	Engines []Engine           `json:"-"`
+	Nodes   []ComputeInstances `json:"nodes"`
+	// Refresh bypasses the cached provider topology
+	Refresh bool `json:"refresh,omitempty"`
 }
 
 type Provider struct {
@@ -43,6 +52,9 @@ type Engine struct {
 type ComputeInstances struct {
 	Region    string            `json:"region"`
 	Instances map[string]string `json:"instances"` // <instance ID>:<node name> map
+	// NodePattern is the Slurm hostlist of the nodes whose instance IDs equal their names, e.g. dgx[0001-2048].
+	// It is expanded into the instance map by GetTopologyRequest.
+	NodePattern string `json:"node_pattern,omitempty"`
 }
 
 func NewRequest(prv string, creds map[string]string, eng string, params map[string]any) *Request {
@@ -58,14 +70,37 @@ func NewRequest(prv string, creds map[string]string, eng string, params map[stri
 	}
 }
 
+// MarshalJSON writes the engine field of a multi-engine request as the list of its engines,
+// as in the request payload, so that the per-engine parameters are kept
+func (p Request) MarshalJSON() ([]byte, error) {
+	type request Request
+	if len(p.Engines) == 0 {
+		return json.Marshal(request(p))
+	}
+
+	return json.Marshal(struct {
+		request
+		Engine []Engine `json:"engine"`
+	}{
+		request: request(p),
+		Engine:  p.Engines,
+	})
+}
+
 func (p *Request) String() string {
 	var sb strings.Builder
 	sb.WriteString("TopologyRequest:\n")
 	sb.WriteString(fmt.Sprintf("  Provider:%s\n", spacer(p.Provider.Name)))
 	sb.WriteString(map2string(p.Provider.Creds, "  Credentials", true, "\n"))
 	sb.WriteString(map2string(p.Provider.Params, "  Parameters", false, "\n"))
-	sb.WriteString(fmt.Sprintf("  Engine:%s\n", spacer(p.Engine.Name)))
-	sb.WriteString(map2string(p.Engine.Params, "  Parameters", false, "\n"))
+	engines := p.Engines
+	if len(engines) == 0 {
+		engines = []Engine{p.Engine}
+	}
+	for _, eng := range engines {
+		sb.WriteString(fmt.Sprintf("  Engine:%s\n", spacer(eng.Name)))
+		sb.WriteString(map2string(eng.Params, "  Parameters", false, "\n"))
+	}
 	sb.WriteString("  Nodes:")
 	for _, nodes := range p.Nodes {
 		sb.WriteByte(' ')
@@ -82,13 +117,93 @@ func GetTopologyRequest(body []byte) (*Request, error) {
 		return &payload, nil
 	}
 
+	body, err := files.Normalize(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse payload: %v", err)
+	}
+
+	engines, body, err := splitEngines(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse payload: %v", err)
+	}
+
 	if err := json.Unmarshal(body, &payload); err != nil {
 		return nil, fmt.Errorf("failed to parse payload: %v", err)
 	}
 
+	if len(engines) != 0 {
+		names := make([]string, 0, len(engines))
+		for _, eng := range engines {
+			names = append(names, eng.Name)
+		}
+		payload.Engine = Engine{Name: strings.Join(names, ",")}
+		payload.Engines = engines
+	}
+
+	for i := range payload.Nodes {
+		if err := payload.Nodes[i].expandNodePattern(); err != nil {
+			return nil, fmt.Errorf("failed to parse payload: %v", err)
+		}
+	}
+
 	return &payload, nil
 }
 
+// splitEngines returns the engines of the payload whose engine field is a list,
+// and the payload without the engine field. Other payloads are returned unchanged.
+func splitEngines(body []byte) ([]Engine, []byte, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		// reported by the payload decoding
+		return nil, body, nil
+	}
+
+	raw := bytes.TrimSpace(fields[KeyEngine])
+	if len(raw) == 0 || raw[0] != '[' {
+		return nil, body, nil
+	}
+
+	var engines []Engine
+	if err := json.Unmarshal(raw, &engines); err != nil {
+		return nil, nil, err
+	}
+	if len(engines) == 0 {
+		return nil, nil, fmt.Errorf("empty engine list")
+	}
+
+	delete(fields, KeyEngine)
+	body, err := json.Marshal(fields)
+	if err != nil {
+		return nil, nil, err
+	}
+	return engines, body, nil
+}
+
+// expandNodePattern replaces the node pattern with the identity map of the node names
+func (ci *ComputeInstances) expandNodePattern() error {
+	if len(ci.NodePattern) == 0 {
+		return nil
+	}
+	if len(ci.Instances) != 0 {
+		return fmt.Errorf("region %q: instances and node_pattern are mutually exclusive", ci.Region)
+	}
+
+	nodes, err := cluset.Expand(ci.NodePattern)
+	if err != nil {
+		return fmt.Errorf("region %q: invalid node_pattern %q: %v", ci.Region, ci.NodePattern, err)
+	}
+	if len(nodes) == 0 {
+		return fmt.Errorf("region %q: empty node_pattern", ci.Region)
+	}
+
+	ci.Instances = make(map[string]string, len(nodes))
+	for _, node := range nodes {
+		ci.Instances[node] = node
+	}
+	ci.NodePattern = ""
+	return nil
+}
+
 func spacer(value string) string {
 	if len(value) > 0 {
 		return " " + value
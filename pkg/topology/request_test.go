@@ -17,6 +17,7 @@
 package topology_test
 
 import (
+	"encoding/json"
 	"testing"
 
 	"github.com/NVIDIA/topograph/pkg/topology"
@@ -136,6 +137,160 @@ func TestPayload(t *testing.T) {
   Nodes: region1: [instance1:node1 instance2:node2 instance3:node3] region2: [instance4:node4 instance5:node5 instance6:node6]
 `,
 		},
+		{
+			name:    "Case 4: BOM and CRLF",
+			input:   "\xEF\xBB\xBF{\r\n  \"provider\": {\r\n    \"name\": \"aws\"\r\n  }\r\n}\r\n",
+			payload: &topology.Request{Provider: topology.Provider{Name: "aws"}},
+			print: `TopologyRequest:
+  Provider: aws
+  Credentials: []
+  Parameters: []
+  Engine:
+  Parameters: []
+  Nodes:
+`,
+		},
+		{
+			name:  "Case 5: UTF-16",
+			input: "\xFF\xFE{\x00}\x00",
+			err:   "failed to parse payload: UTF-16 encoding is not supported; convert the input to UTF-8",
+		},
+		{
+			name: "Case 6: node pattern",
+			input: `
+{
+  "provider": {
+    "name": "test"
+  },
+  "nodes": [
+    {
+      "region": "region1",
+      "node_pattern": "dgx[0001-0003]"
+    },
+    {
+      "region": "region2",
+      "instances": {
+        "instance4": "node4"
+      }
+    }
+  ]
+}
+`,
+			payload: &topology.Request{
+				Provider: topology.Provider{Name: "test"},
+				Nodes: []topology.ComputeInstances{
+					{
+						Region: "region1",
+						Instances: map[string]string{
+							"dgx0001": "dgx0001",
+							"dgx0002": "dgx0002",
+							"dgx0003": "dgx0003",
+						},
+					},
+					{
+						Region:    "region2",
+						Instances: map[string]string{"instance4": "node4"},
+					},
+				},
+			},
+			print: `TopologyRequest:
+  Provider: test
+  Credentials: []
+  Parameters: []
+  Engine:
+  Parameters: []
+  Nodes: region1: [dgx0001:dgx0001 dgx0002:dgx0002 dgx0003:dgx0003] region2: [instance4:node4]
+`,
+		},
+		{
+			name: "Case 7: instances and node pattern",
+			input: `
+{
+  "nodes": [
+    {
+      "region": "region1",
+      "instances": {
+        "instance1": "node1"
+      },
+      "node_pattern": "dgx[0001-0003]"
+    }
+  ]
+}
+`,
+			err: `failed to parse payload: region "region1": instances and node_pattern are mutually exclusive`,
+		},
+		{
+			name: "Case 8: invalid node pattern",
+			input: `
+{
+  "nodes": [
+    {
+      "region": "region1",
+      "node_pattern": "dgx[0003-0001]"
+    }
+  ]
+}
+`,
+			err: `failed to parse payload: region "region1": invalid node_pattern "dgx[0003-0001]": invalid range "0003-0001": end is less than start`,
+		},
+		{
+			name: "Case 9: empty node pattern",
+			input: `
+{
+  "nodes": [
+    {
+      "region": "region1",
+      "node_pattern": ","
+    }
+  ]
+}
+`,
+			err: `failed to parse payload: region "region1": empty node_pattern`,
+		},
+		{
+			name: "Case 10: engine list",
+			input: `
+{
+  "provider": {
+    "name": "test"
+  },
+  "engine": [
+    {
+      "name": "slurm",
+      "params": {
+        "plugin": "topology/block"
+      }
+    },
+    {
+      "name": "k8s"
+    }
+  ]
+}
+`,
+			payload: &topology.Request{
+				Provider: topology.Provider{Name: "test"},
+				Engine:   topology.Engine{Name: "slurm,k8s"},
+				Engines: []topology.Engine{
+					{Name: "slurm", Params: map[string]any{topology.KeyPlugin: topology.TopologyBlock}},
+					{Name: "k8s"},
+				},
+			},
+			print: `TopologyRequest:
+  Provider: test
+  Credentials: []
+  Parameters: []
+  Engine: slurm
+  Parameters: [plugin:topology/block]
+  Engine: k8s
+  Parameters: []
+  Nodes:
+`,
+		},
+		{
+			name:  "Case 11: empty engine list",
+			input: `{"engine": []}`,
+			err:   "failed to parse payload: empty engine list",
+		},
 	}
 
 	for _, tc := range testCases {
@@ -147,6 +302,13 @@ func TestPayload(t *testing.T) {
 				require.NoError(t, err)
 				require.Equal(t, tc.payload, payload)
 				require.Equal(t, tc.print, payload.String())
+
+				// the encoded request is decoded unchanged
+				data, err := json.Marshal(payload)
+				require.NoError(t, err)
+				decoded, err := topology.GetTopologyRequest(data)
+				require.NoError(t, err)
+				require.Equal(t, payload, decoded)
 			}
 		})
 	}
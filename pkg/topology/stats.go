@@ -0,0 +1,92 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package topology
+
+// Stats summarizes the shape of a topology graph
+type Stats struct {
+	// Nodes is the number of compute nodes
+	Nodes int
+	// Switches is the number of switches per tier, starting with the leaf switches
+	Switches []int
+	// Depth is the number of switch tiers
+	Depth int
+	// NoTopologyNodes is the number of nodes without topology information
+	NoTopologyNodes int
+	// Blocks is the number of accelerator domains
+	Blocks int
+}
+
+// Stats returns the statistics of the topology graph. A switch tier is the height
+// of the switch above the compute nodes: the leaf switches are in tier 1.
+func (v *Vertex) Stats() *Stats {
+	stats := &Stats{}
+	nodes := make(map[string]bool)
+
+	if treeRoot, ok := v.Vertices[TopologyTree]; ok {
+		tiers := make(map[string]int)
+		for id, w := range treeRoot.Vertices {
+			if IsNoTopology(id) {
+				stats.NoTopologyNodes += len(w.Vertices)
+				addLeaves(w, nodes)
+				continue
+			}
+			switchTier(w, tiers, nodes)
+		}
+		for _, tier := range tiers {
+			for len(stats.Switches) < tier {
+				stats.Switches = append(stats.Switches, 0)
+			}
+			stats.Switches[tier-1]++
+		}
+		stats.Depth = len(stats.Switches)
+	}
+
+	if blockRoot, ok := v.Vertices[TopologyBlock]; ok {
+		stats.Blocks = len(blockRoot.Vertices)
+		for _, block := range blockRoot.Vertices {
+			addLeaves(block, nodes)
+		}
+	}
+
+	stats.Nodes = len(nodes)
+	return stats
+}
+
+// switchTier returns the tier of the switch, recording the tiers of the switches
+// and the compute nodes under it. A compute node has tier 0.
+func switchTier(v *Vertex, tiers map[string]int, nodes map[string]bool) int {
+	if len(v.Vertices) == 0 {
+		nodes[v.ID] = true
+		return 0
+	}
+	if tier, ok := tiers[v.ID]; ok {
+		return tier
+	}
+
+	tier := 0
+	for _, w := range v.Vertices {
+		tier = max(tier, switchTier(w, tiers, nodes))
+	}
+	tiers[v.ID] = tier + 1
+	return tier + 1
+}
+
+func addLeaves(v *Vertex, nodes map[string]bool) {
+	for _, w := range v.Vertices {
+		nodes[w.ID] = true
+	}
+}
@@ -0,0 +1,94 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package topology_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/NVIDIA/topograph/internal/fixtures"
+	"github.com/NVIDIA/topograph/pkg/topology"
+)
+
+func TestStats(t *testing.T) {
+	testCases := []struct {
+		name  string
+		root  func() *topology.Vertex
+		stats *topology.Stats
+	}{
+		{
+			name: "Case 1: tree topology",
+			root: func() *topology.Vertex {
+				root, _ := fixtures.TreeTestSet()
+				return root
+			},
+			stats: &topology.Stats{Nodes: 6, Switches: []int{2, 1}, Depth: 2},
+		},
+		{
+			name: "Case 2: block topology with two fabrics",
+			root: func() *topology.Vertex {
+				root, _ := fixtures.BlockWithMultiIBTestSet()
+				return root
+			},
+			stats: &topology.Stats{Nodes: 12, Switches: []int{4, 2, 2}, Depth: 3, Blocks: 4},
+		},
+		{
+			name: "Case 3: nodes without topology",
+			root: func() *topology.Vertex {
+				root, _ := fixtures.TreeTestSet()
+				n1 := &topology.Vertex{ID: "I1", Name: "Node1"}
+				n2 := &topology.Vertex{ID: "I2", Name: "Node2"}
+				root.Vertices[topology.TopologyTree].Vertices[topology.NoTopology] = &topology.Vertex{
+					ID:       topology.NoTopology,
+					Vertices: map[string]*topology.Vertex{"I1": n1, "I2": n2},
+				}
+				return root
+			},
+			stats: &topology.Stats{Nodes: 8, Switches: []int{2, 1}, Depth: 2, NoTopologyNodes: 2},
+		},
+		{
+			name: "Case 4: grouped nodes without topology",
+			root: func() *topology.Vertex {
+				root, _ := fixtures.TreeTestSet()
+				n1 := &topology.Vertex{ID: "I1", Name: "cpu1"}
+				n2 := &topology.Vertex{ID: "I2", Name: "gpu1"}
+				root.Vertices[topology.TopologyTree].Vertices["no-topology-cpu"] = &topology.Vertex{
+					ID:       "no-topology-cpu",
+					Vertices: map[string]*topology.Vertex{"I1": n1},
+				}
+				root.Vertices[topology.TopologyTree].Vertices["no-topology-gpu"] = &topology.Vertex{
+					ID:       "no-topology-gpu",
+					Vertices: map[string]*topology.Vertex{"I2": n2},
+				}
+				return root
+			},
+			stats: &topology.Stats{Nodes: 8, Switches: []int{2, 1}, Depth: 2, NoTopologyNodes: 2},
+		},
+		{
+			name:  "Case 5: empty topology",
+			root:  func() *topology.Vertex { return &topology.Vertex{} },
+			stats: &topology.Stats{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.stats, tc.root().Stats())
+		})
+	}
+}
@@ -29,10 +29,32 @@ const (
 	KeyTopoConfigmapName      = "topology_configmap_name"
 	KeyTopoConfigmapNamespace = "topology_configmap_namespace"
 	KeyBlockSizes             = "block_sizes"
+	KeyBlockSizeHint          = "block_size_hint"
+	KeyBlockSizeStrategy      = "block_size_strategy"
+	KeyFlatMode               = "flat_mode"
+	KeyComments               = "comments"
+	KeyDisplayName            = "display_name"
+	KeyRepairNodes            = "repair_nodes"
+	KeyAnnotate               = "annotate"
+	KeyDuplicateNodes         = "duplicate_nodes"
+	KeyExpectedBlockSizes     = "expected_block_sizes"
+
+	// KeyExpectedBlockSize holds the number of nodes of a fully populated block, set by the providers on the block vertices
+	KeyExpectedBlockSize = "expected_block_size"
+
+	KeyUplinks          = "uplinks"
+	KeyDownlinks        = "downlinks"
+	KeyOversubscription = "oversubscription"
+	// KeyUplinkBandwidth and KeyDownlinkBandwidth hold the aggregate link bandwidth of a switch in Gb/s
+	KeyUplinkBandwidth   = "uplink_bandwidth"
+	KeyDownlinkBandwidth = "downlink_bandwidth"
+
+	KeySecondarySwitches = "secondary_switches"
 
 	KeyPlugin     = "plugin"
 	TopologyTree  = "topology/tree"
 	TopologyBlock = "topology/block"
+	TopologyFlat  = "topology/flat"
 	NoTopology    = "no-topology"
 )
 
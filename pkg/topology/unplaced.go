@@ -0,0 +1,89 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package topology
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Grouping modes of the nodes without topology
+const (
+	// GroupUnplacedNone places all nodes without topology under the no-topology switch (default)
+	GroupUnplacedNone = "none"
+	// GroupUnplacedInstanceType groups the nodes without topology by their instance type, if known
+	GroupUnplacedInstanceType = "instance_type"
+	// GroupUnplacedPrefix groups the nodes without topology by their node name prefix,
+	// i.e. the name without the trailing number and the separator before it
+	GroupUnplacedPrefix = "prefix"
+)
+
+// ValidateGroupUnplaced returns an error if the grouping mode is not supported.
+// An empty mode is valid and stands for the default one.
+func ValidateGroupUnplaced(mode string) error {
+	switch mode {
+	case "", GroupUnplacedNone, GroupUnplacedInstanceType, GroupUnplacedPrefix:
+		return nil
+	default:
+		return fmt.Errorf("unsupported group_unplaced_by %q", mode)
+	}
+}
+
+// IsNoTopology returns true if the switch ID stands for nodes without topology,
+// either the no-topology switch or one of its groups
+func IsNoTopology(id string) bool {
+	return id == NoTopology || strings.HasPrefix(id, NoTopology+"-")
+}
+
+// NoTopologySwitches returns the switches of the nodes without topology by switch ID, given the
+// instance ID to node name map and the optional instance ID to instance type map. The nodes are grouped
+// under the switches "no-topology-<key>" by the key of the grouping mode, and the nodes without a key
+// are placed under the no-topology switch.
+func NoTopologySwitches(unplaced, instanceTypes map[string]string, mode string) map[string]*Vertex {
+	switches := make(map[string]*Vertex)
+	for instanceID, nodeName := range unplaced {
+		id := NoTopology
+		if key := unplacedKey(nodeName, instanceTypes[instanceID], mode); len(key) != 0 {
+			id = NoTopology + "-" + key
+		}
+		sw, ok := switches[id]
+		if !ok {
+			sw = &Vertex{ID: id, Vertices: make(map[string]*Vertex)}
+			switches[id] = sw
+		}
+		sw.Vertices[instanceID] = &Vertex{Name: nodeName, ID: instanceID}
+	}
+	return switches
+}
+
+// unplacedKey returns the grouping key of the node without topology, or "" if the node is not grouped
+func unplacedKey(nodeName, instanceType, mode string) string {
+	var key string
+	switch mode {
+	case GroupUnplacedInstanceType:
+		key = instanceType
+	case GroupUnplacedPrefix:
+		key = strings.TrimRight(strings.TrimRight(nodeName, "0123456789"), "-_.")
+	}
+
+	return strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '-' || r == '_' || r == '.' {
+			return r
+		}
+		return '-'
+	}, strings.ToLower(key))
+}
@@ -0,0 +1,120 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package topology
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNoTopologySwitches(t *testing.T) {
+	unplaced := map[string]string{
+		"i-1": "cpu-001",
+		"i-2": "cpu-002",
+		"i-3": "gpu-a100-01",
+		"i-4": "0042",
+	}
+	instanceTypes := map[string]string{
+		"i-1": "m5.xlarge",
+		"i-2": "m5.xlarge",
+		"i-3": "p4d.24xlarge",
+	}
+
+	testCases := []struct {
+		name     string
+		mode     string
+		expected map[string]*Vertex
+	}{
+		{
+			name: "Case 1: default mode",
+			expected: map[string]*Vertex{
+				NoTopology: {
+					ID: NoTopology,
+					Vertices: map[string]*Vertex{
+						"i-1": {ID: "i-1", Name: "cpu-001"},
+						"i-2": {ID: "i-2", Name: "cpu-002"},
+						"i-3": {ID: "i-3", Name: "gpu-a100-01"},
+						"i-4": {ID: "i-4", Name: "0042"},
+					},
+				},
+			},
+		},
+		{
+			name: "Case 2: group by instance type",
+			mode: GroupUnplacedInstanceType,
+			expected: map[string]*Vertex{
+				"no-topology-m5.xlarge": {
+					ID: "no-topology-m5.xlarge",
+					Vertices: map[string]*Vertex{
+						"i-1": {ID: "i-1", Name: "cpu-001"},
+						"i-2": {ID: "i-2", Name: "cpu-002"},
+					},
+				},
+				"no-topology-p4d.24xlarge": {
+					ID:       "no-topology-p4d.24xlarge",
+					Vertices: map[string]*Vertex{"i-3": {ID: "i-3", Name: "gpu-a100-01"}},
+				},
+				NoTopology: {
+					ID:       NoTopology,
+					Vertices: map[string]*Vertex{"i-4": {ID: "i-4", Name: "0042"}},
+				},
+			},
+		},
+		{
+			name: "Case 3: group by node name prefix",
+			mode: GroupUnplacedPrefix,
+			expected: map[string]*Vertex{
+				"no-topology-cpu": {
+					ID: "no-topology-cpu",
+					Vertices: map[string]*Vertex{
+						"i-1": {ID: "i-1", Name: "cpu-001"},
+						"i-2": {ID: "i-2", Name: "cpu-002"},
+					},
+				},
+				"no-topology-gpu-a100": {
+					ID:       "no-topology-gpu-a100",
+					Vertices: map[string]*Vertex{"i-3": {ID: "i-3", Name: "gpu-a100-01"}},
+				},
+				NoTopology: {
+					ID:       NoTopology,
+					Vertices: map[string]*Vertex{"i-4": {ID: "i-4", Name: "0042"}},
+				},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, NoTopologySwitches(unplaced, instanceTypes, tc.mode))
+		})
+	}
+}
+
+func TestValidateGroupUnplaced(t *testing.T) {
+	for _, mode := range []string{"", GroupUnplacedNone, GroupUnplacedInstanceType, GroupUnplacedPrefix} {
+		require.NoError(t, ValidateGroupUnplaced(mode))
+	}
+	require.EqualError(t, ValidateGroupUnplaced("rack"), `unsupported group_unplaced_by "rack"`)
+}
+
+func TestIsNoTopology(t *testing.T) {
+	require.True(t, IsNoTopology(NoTopology))
+	require.True(t, IsNoTopology("no-topology-cpu"))
+	require.False(t, IsNoTopology("no-topologyx"))
+	require.False(t, IsNoTopology("sw1"))
+}
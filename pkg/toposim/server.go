@@ -19,9 +19,15 @@ package toposim
 import (
 	"context"
 	"fmt"
+	"math/rand"
 	"net"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"k8s.io/klog/v2"
 
 	"github.com/NVIDIA/topograph/pkg/models"
@@ -31,16 +37,108 @@ import (
 type Server struct {
 	pb.UnimplementedTopologyServiceServer
 
-	model  *models.Model
+	state  atomic.Pointer[state]
+	path   string
+	mutex  sync.Mutex // serializes model reloads
 	port   int
 	server *grpc.Server
+
+	// simulation overrides of the model directives
+	latency   *time.Duration
+	errorRate *float64
+
+	randMutex sync.Mutex
+	rand      *rand.Rand
+	errors    atomic.Int64
+}
+
+// state is the served model with its simulation directives
+type state struct {
+	model *models.Model
+	sim   *simulation
+}
+
+type Option func(*Server)
+
+// WithLatency overrides the sim_latency directives of the model
+func WithLatency(latency time.Duration) Option {
+	return func(s *Server) { s.latency = &latency }
+}
+
+// WithErrorRate overrides the sim_error_rate directives of the model
+func WithErrorRate(rate float64) Option {
+	return func(s *Server) { s.errorRate = &rate }
+}
+
+// WithRand sets the random source of the simulated errors
+func WithRand(r *rand.Rand) Option {
+	return func(s *Server) { s.rand = r }
+}
+
+func NewServer(path string, port int, opts ...Option) (*Server, error) {
+	st, err := loadState(path)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Server{
+		path: path,
+		port: port,
+		rand: rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.errorRate != nil && (*s.errorRate < 0 || *s.errorRate > 100) {
+		return nil, fmt.Errorf("error rate must be between 0 and 100")
+	}
+	s.state.Store(st)
+
+	return s, nil
+}
+
+func loadState(path string) (*state, error) {
+	model, err := models.NewModelFromFile(path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	sim, err := newSimulation(model)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse simulation directives of %s: %v", path, err)
+	}
+
+	return &state{model: model, sim: sim}, nil
 }
 
-func NewServer(model *models.Model, port int) *Server {
-	return &Server{
-		model: model,
-		port:  port,
+// Reload re-parses the model file and replaces the served model.
+// If the path is empty, the current model file is used.
+// If the new model is invalid, the current model keeps being served.
+func (s *Server) Reload(path string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if len(path) == 0 {
+		path = s.path
+	}
+
+	klog.Infof("Reloading model from %q", path)
+	st, err := loadState(path)
+	if err != nil {
+		klog.Errorf("Failed to reload model: %v", err)
+		return err
 	}
+
+	s.state.Store(st)
+	s.path = path
+	klog.Infof("Reloaded model from %q", path)
+
+	return nil
+}
+
+// SimulatedErrors returns the number of calls failed by the sim_error_rate directive
+func (s *Server) SimulatedErrors() int64 {
+	return s.errors.Load()
 }
 
 func (s *Server) Start() error {
@@ -65,12 +163,20 @@ func (s *Server) Stop(err error) {
 func (s *Server) DescribeTopology(ctx context.Context, in *pb.TopologyRequest) (*pb.TopologyResponse, error) {
 	klog.Infof("Received: %s", in.String())
 
+	// use the same model for the entire request
+	st := s.state.Load()
+	model := st.model
+
+	if err := s.simulate(ctx, st.sim.get(model, in.InstanceIds)); err != nil {
+		return nil, err
+	}
+
 	res := &pb.TopologyResponse{
 		Instances: make([]*pb.Instance, 0, len(in.InstanceIds)),
 	}
 
 	for _, instance := range in.InstanceIds {
-		node, ok := s.model.Nodes[instance]
+		node, ok := model.Nodes[instance]
 		if !ok {
 			return nil, fmt.Errorf("missing instance %s", instance)
 		}
@@ -86,3 +192,35 @@ func (s *Server) DescribeTopology(ctx context.Context, in *pb.TopologyRequest) (
 
 	return res, nil
 }
+
+// simulate delays the response and fails the call according to the directives and their overrides
+func (s *Server) simulate(ctx context.Context, d directives) error {
+	if s.latency != nil {
+		d.latency = *s.latency
+	}
+	if s.errorRate != nil {
+		d.errorRate = *s.errorRate
+	}
+
+	if d.latency > 0 {
+		klog.V(4).Infof("Simulating latency of %s", d.latency.String())
+		select {
+		case <-time.After(d.latency):
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		}
+	}
+
+	if d.errorRate > 0 {
+		s.randMutex.Lock()
+		fail := s.rand.Float64()*100 < d.errorRate
+		s.randMutex.Unlock()
+		if fail {
+			s.errors.Add(1)
+			klog.Infof("Simulating error with rate %v%%", d.errorRate)
+			return status.Error(codes.Unavailable, "simulated error")
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,195 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package toposim
+
+import (
+	"context"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/NVIDIA/topograph/pkg/protos"
+)
+
+func TestReload(t *testing.T) {
+	smallTree, err := os.ReadFile("../../tests/models/small-tree.yaml")
+	require.NoError(t, err)
+	medium, err := os.ReadFile("../../tests/models/medium.yaml")
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "model.yaml")
+	require.NoError(t, os.WriteFile(path, smallTree, 0644))
+
+	s, err := NewServer(path, 0)
+	require.NoError(t, err)
+
+	small := &pb.TopologyRequest{InstanceIds: []string{"I21"}}
+	large := &pb.TopologyRequest{InstanceIds: []string{"n11-1"}}
+
+	_, err = s.DescribeTopology(context.TODO(), small)
+	require.NoError(t, err)
+	_, err = s.DescribeTopology(context.TODO(), large)
+	require.EqualError(t, err, "missing instance n11-1")
+
+	// model changes only after reload
+	require.NoError(t, os.WriteFile(path, medium, 0644))
+	_, err = s.DescribeTopology(context.TODO(), small)
+	require.NoError(t, err)
+
+	require.NoError(t, s.Reload(""))
+	_, err = s.DescribeTopology(context.TODO(), small)
+	require.EqualError(t, err, "missing instance I21")
+	res, err := s.DescribeTopology(context.TODO(), large)
+	require.NoError(t, err)
+	require.Equal(t, []string{"sw11", "sw21", "sw3"}, res.Instances[0].NetworkLayers)
+
+	// failed reload keeps the current model
+	require.NoError(t, os.WriteFile(path, []byte("switches: invalid"), 0644))
+	require.Error(t, s.Reload(""))
+	_, err = s.DescribeTopology(context.TODO(), large)
+	require.NoError(t, err)
+
+	// reload from a new path
+	newPath := filepath.Join(t.TempDir(), "new-model.yaml")
+	require.NoError(t, os.WriteFile(newPath, smallTree, 0644))
+	require.NoError(t, s.Reload(newPath))
+	_, err = s.DescribeTopology(context.TODO(), small)
+	require.NoError(t, err)
+	_, err = s.DescribeTopology(context.TODO(), large)
+	require.EqualError(t, err, "missing instance n11-1")
+}
+
+func TestSimulation(t *testing.T) {
+	model := func(rootMetadata, switchMetadata string) string {
+		return `metadata: {` + rootMetadata + `}
+switches:
+- name: S1
+  switches: [S2,S3]
+- name: S2
+  capacity_blocks: [CB2]
+- name: S3
+  metadata: {` + switchMetadata + `}
+  capacity_blocks: [CB3]
+capacity_blocks:
+- name: CB2
+  type: H100
+  nodes: [I21]
+- name: CB3
+  type: H100
+  nodes: [I34]
+`
+	}
+
+	testCases := []struct {
+		name      string
+		model     string
+		opts      []Option
+		instances []string
+		latency   time.Duration
+		code      codes.Code
+		err       string
+	}{
+		{
+			name:      "Case 1: no directives",
+			model:     model("", ""),
+			instances: []string{"I21", "I34"},
+		},
+		{
+			name:      "Case 2: model latency",
+			model:     model("sim_latency: 20ms", ""),
+			instances: []string{"I21"},
+			latency:   20 * time.Millisecond,
+		},
+		{
+			name:      "Case 3: model error rate",
+			model:     model("sim_error_rate: 100", ""),
+			instances: []string{"I21"},
+			code:      codes.Unavailable,
+		},
+		{
+			name:      "Case 4: switch error rate, instance under the switch",
+			model:     model("", "sim_error_rate: 100%"),
+			instances: []string{"I21", "I34"},
+			code:      codes.Unavailable,
+		},
+		{
+			name:      "Case 5: switch error rate, instance under another switch",
+			model:     model("", "sim_error_rate: 100%"),
+			instances: []string{"I21"},
+		},
+		{
+			name:      "Case 6: overrides",
+			model:     model("sim_error_rate: 100", ""),
+			opts:      []Option{WithErrorRate(0), WithLatency(20 * time.Millisecond)},
+			instances: []string{"I21"},
+			latency:   20 * time.Millisecond,
+		},
+		{
+			name:  "Case 7: invalid latency",
+			model: model("sim_latency: soon", ""),
+			err:   `model metadata: invalid sim_latency "soon"`,
+		},
+		{
+			name:  "Case 8: invalid error rate",
+			model: model("", "sim_error_rate: 150"),
+			err:   `switch "S3" metadata: invalid sim_error_rate "150": must be a percentage between 0 and 100`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "model.yaml")
+			require.NoError(t, os.WriteFile(path, []byte(tc.model), 0644))
+
+			s, err := NewServer(path, 0, tc.opts...)
+			if len(tc.err) != 0 {
+				require.ErrorContains(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+
+			start := time.Now()
+			_, err = s.DescribeTopology(context.TODO(), &pb.TopologyRequest{InstanceIds: tc.instances})
+			require.GreaterOrEqual(t, time.Since(start), tc.latency)
+			require.Equal(t, tc.code, status.Code(err))
+			if tc.code != codes.OK {
+				require.Equal(t, int64(1), s.SimulatedErrors())
+			}
+		})
+	}
+}
+
+func TestSimulatedErrorRate(t *testing.T) {
+	s, err := NewServer("../../tests/models/small-tree.yaml", 0, WithErrorRate(50), WithRand(rand.New(rand.NewSource(1))))
+	require.NoError(t, err)
+
+	var failed int64
+	for range 1000 {
+		if _, err := s.DescribeTopology(context.TODO(), &pb.TopologyRequest{InstanceIds: []string{"I21"}}); err != nil {
+			require.Equal(t, codes.Unavailable, status.Code(err))
+			failed++
+		}
+	}
+	require.Equal(t, failed, s.SimulatedErrors())
+	require.InDelta(t, 500, failed, 50)
+}
@@ -0,0 +1,113 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package toposim
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/NVIDIA/topograph/pkg/models"
+)
+
+// Simulation directives, read from the model or the switch metadata
+const (
+	// KeySimLatency delays the responses by the duration, e.g., "500ms"
+	KeySimLatency = "sim_latency"
+	// KeySimErrorRate fails the percentage of calls (0 to 100) with a gRPC error
+	KeySimErrorRate = "sim_error_rate"
+)
+
+type directives struct {
+	latency   time.Duration
+	errorRate float64
+}
+
+// merge returns the strictest of the directives
+func (d directives) merge(other directives) directives {
+	return directives{
+		latency:   max(d.latency, other.latency),
+		errorRate: max(d.errorRate, other.errorRate),
+	}
+}
+
+// simulation holds the directives of the model root and of the individual switches
+type simulation struct {
+	root     directives
+	switches map[string]directives
+}
+
+func newSimulation(model *models.Model) (*simulation, error) {
+	root, err := parseDirectives(model.Metadata)
+	if err != nil {
+		return nil, fmt.Errorf("model metadata: %v", err)
+	}
+
+	sim := &simulation{root: root, switches: make(map[string]directives)}
+	for _, sw := range model.Switches {
+		d, err := parseDirectives(sw.Metadata)
+		if err != nil {
+			return nil, fmt.Errorf("switch %q metadata: %v", sw.Name, err)
+		}
+		if d != (directives{}) {
+			sim.switches[sw.Name] = d
+		}
+	}
+
+	return sim, nil
+}
+
+// get returns the directives applying to the instances,
+// combining the model directives with those of the switches above the instances
+func (sim *simulation) get(model *models.Model, instances []string) directives {
+	d := sim.root
+	if len(sim.switches) == 0 {
+		return d
+	}
+	for _, instance := range instances {
+		node, ok := model.Nodes[instance]
+		if !ok {
+			continue
+		}
+		for _, sw := range node.NetLayers {
+			d = d.merge(sim.switches[sw])
+		}
+	}
+	return d
+}
+
+func parseDirectives(metadata map[string]string) (directives, error) {
+	var d directives
+
+	if val, ok := metadata[KeySimLatency]; ok {
+		latency, err := time.ParseDuration(val)
+		if err != nil || latency < 0 {
+			return d, fmt.Errorf("invalid %s %q", KeySimLatency, val)
+		}
+		d.latency = latency
+	}
+
+	if val, ok := metadata[KeySimErrorRate]; ok {
+		rate, err := strconv.ParseFloat(strings.TrimSuffix(val, "%"), 64)
+		if err != nil || rate < 0 || rate > 100 {
+			return d, fmt.Errorf("invalid %s %q: must be a percentage between 0 and 100", KeySimErrorRate, val)
+		}
+		d.errorRate = rate
+	}
+
+	return d, nil
+}
@@ -19,12 +19,21 @@ package translate
 import (
 	"fmt"
 	"sort"
+	"strconv"
 
 	"github.com/NVIDIA/topograph/pkg/topology"
 )
 
-// DomainMap maps domain name to a map of hostnames
-type DomainMap map[string]map[string]struct{}
+// Domain is a set of hostnames sharing an accelerator domain,
+// with an optional human-friendly display name and the optional node count of the fully populated domain
+type Domain struct {
+	DisplayName  string
+	ExpectedSize int
+	Hosts        map[string]struct{}
+}
+
+// DomainMap maps domain name to the domain
+type DomainMap map[string]*Domain
 
 func NewDomainMap() DomainMap {
 	return make(DomainMap)
@@ -42,8 +51,9 @@ func (m DomainMap) ToBlocks() *topology.Vertex {
 	sort.Strings(domainNames)
 
 	for i, domainName := range domainNames {
-		nodes := make([]string, 0, len(m[domainName]))
-		for node := range m[domainName] {
+		domain := m[domainName]
+		nodes := make([]string, 0, len(domain.Hosts))
+		for node := range domain.Hosts {
 			nodes = append(nodes, node)
 		}
 		sort.Strings(nodes)
@@ -53,6 +63,15 @@ func (m DomainMap) ToBlocks() *topology.Vertex {
 			Name:     domainName,
 			Vertices: make(map[string]*topology.Vertex),
 		}
+		if len(domain.DisplayName) != 0 {
+			vertex.Metadata = map[string]string{topology.KeyDisplayName: domain.DisplayName}
+		}
+		if domain.ExpectedSize > 0 {
+			if vertex.Metadata == nil {
+				vertex.Metadata = make(map[string]string)
+			}
+			vertex.Metadata[topology.KeyExpectedBlockSize] = strconv.Itoa(domain.ExpectedSize)
+		}
 
 		for _, node := range nodes {
 			vertex.Vertices[node] = &topology.Vertex{
@@ -70,8 +89,22 @@ func (m DomainMap) ToBlocks() *topology.Vertex {
 func (m DomainMap) AddHost(domain, host string) {
 	d, ok := m[domain]
 	if !ok {
-		m[domain] = make(map[string]struct{})
-		d = m[domain]
+		d = &Domain{Hosts: make(map[string]struct{})}
+		m[domain] = d
+	}
+	d.Hosts[host] = struct{}{}
+}
+
+// SetDisplayName sets the display name of an existing domain
+func (m DomainMap) SetDisplayName(domain, name string) {
+	if d, ok := m[domain]; ok {
+		d.DisplayName = name
+	}
+}
+
+// SetExpectedSize sets the node count of the fully populated domain of an existing domain
+func (m DomainMap) SetExpectedSize(domain string, size int) {
+	if d, ok := m[domain]; ok {
+		d.ExpectedSize = size
 	}
-	d[host] = struct{}{}
 }
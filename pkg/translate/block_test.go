@@ -37,7 +37,7 @@ func TestToBlocks(t *testing.T) {
 		},
 		{
 			name:      "Case 2: one block",
-			domainMap: DomainMap{"domain1": {"host1": struct{}{}, "host2": struct{}{}}},
+			domainMap: DomainMap{"domain1": {Hosts: map[string]struct{}{"host1": {}, "host2": {}}}},
 			blocks: &topology.Vertex{
 				Vertices: map[string]*topology.Vertex{
 					"domain1": {
@@ -54,8 +54,8 @@ func TestToBlocks(t *testing.T) {
 		{
 			name: "Case 3: two blocks",
 			domainMap: DomainMap{
-				"domain1": {"host1": struct{}{}, "host2": struct{}{}},
-				"domain2": {"host3": struct{}{}},
+				"domain1": {Hosts: map[string]struct{}{"host1": {}, "host2": {}}},
+				"domain2": {Hosts: map[string]struct{}{"host3": {}}},
 			},
 			blocks: &topology.Vertex{
 				Vertices: map[string]*topology.Vertex{
@@ -77,15 +77,66 @@ func TestToBlocks(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "Case 4: two blocks with one display name",
+			domainMap: DomainMap{
+				"domain1": {DisplayName: "rack-a", Hosts: map[string]struct{}{"host1": {}, "host2": {}}},
+				"domain2": {Hosts: map[string]struct{}{"host3": {}}},
+			},
+			blocks: &topology.Vertex{
+				Vertices: map[string]*topology.Vertex{
+					"domain1": {
+						Name:     "domain1",
+						ID:       "block001",
+						Metadata: map[string]string{topology.KeyDisplayName: "rack-a"},
+						Vertices: map[string]*topology.Vertex{
+							"host1": {ID: "host1", Name: "host1"},
+							"host2": {ID: "host2", Name: "host2"},
+						},
+					},
+					"domain2": {
+						Name: "domain2",
+						ID:   "block002",
+						Vertices: map[string]*topology.Vertex{
+							"host3": {ID: "host3", Name: "host3"},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "Case 5: block with expected size",
+			domainMap: DomainMap{
+				"domain1": {DisplayName: "rack-a", ExpectedSize: 18, Hosts: map[string]struct{}{"host1": {}, "host2": {}}},
+			},
+			blocks: &topology.Vertex{
+				Vertices: map[string]*topology.Vertex{
+					"domain1": {
+						Name: "domain1",
+						ID:   "block001",
+						Metadata: map[string]string{
+							topology.KeyDisplayName:       "rack-a",
+							topology.KeyExpectedBlockSize: "18",
+						},
+						Vertices: map[string]*topology.Vertex{
+							"host1": {ID: "host1", Name: "host1"},
+							"host2": {ID: "host2", Name: "host2"},
+						},
+					},
+				},
+			},
+		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			domainMap := NewDomainMap()
 			for domainName, domain := range tc.domainMap {
-				for hostname := range domain {
+				for hostname := range domain.Hosts {
 					domainMap.AddHost(domainName, hostname)
 				}
+				domainMap.SetDisplayName(domainName, domain.DisplayName)
+				domainMap.SetExpectedSize(domainName, domain.ExpectedSize)
 			}
 			require.Equal(t, tc.blocks, domainMap.ToBlocks())
 		})
@@ -0,0 +1,154 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package translate
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/NVIDIA/topograph/pkg/topology"
+)
+
+// flatConfComment is the line written for the topology/flat plugin without a flat switch
+var flatConfComment = fmt.Sprintf("# %s: network topology is not used", topology.TopologyFlat)
+
+// ParseConf reads a topology config in the Slurm topology.conf format.
+// Comments, including the node index and the accelerator domains, are ignored.
+func ParseConf(data []byte) (*TopologyUnit, error) {
+	unit := &TopologyUnit{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for num := 1; scanner.Scan(); num++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == flatConfComment {
+			unit.Flat = &FlatTopo{}
+			continue
+		}
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := make(map[string]string)
+		var key string
+		for i, field := range strings.Fields(line) {
+			k, v, ok := strings.Cut(field, "=")
+			if !ok {
+				return nil, fmt.Errorf("line %d: invalid field %q", num, field)
+			}
+			k = strings.ToLower(k)
+			if i == 0 {
+				key = k
+			}
+			fields[k] = v
+		}
+
+		switch key {
+		case "switchname":
+			if unit.Tree == nil {
+				unit.Tree = &TreeTopo{}
+			}
+			unit.Tree.Switches = append(unit.Tree.Switches, &Switch{
+				Switch:   fields["switchname"],
+				Children: fields["switches"],
+				Nodes:    fields["nodes"],
+			})
+		case "blockname":
+			if unit.Block == nil {
+				unit.Block = &BlockTopo{}
+			}
+			unit.Block.Blocks = append(unit.Block.Blocks, &Block{
+				Block: fields["blockname"],
+				Nodes: fields["nodes"],
+			})
+		case "blocksizes":
+			if unit.Block == nil {
+				unit.Block = &BlockTopo{}
+			}
+			for _, size := range strings.Split(fields["blocksizes"], ",") {
+				n, err := strconv.Atoi(size)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: invalid block size %q", num, size)
+				}
+				unit.Block.BlockSizes = append(unit.Block.BlockSizes, n)
+			}
+		default:
+			return nil, fmt.Errorf("line %d: unsupported entry %q", num, key)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	var plugins int
+	for _, ok := range []bool{unit.Tree != nil, unit.Block != nil, unit.Flat != nil} {
+		if ok {
+			plugins++
+		}
+	}
+	if plugins > 1 {
+		return nil, fmt.Errorf("topology config mixes switches and blocks")
+	}
+
+	return unit, nil
+}
+
+// ParseTopology reads a topology config in any of the conf, json or yaml formats.
+// For yaml, it returns the cluster default topology, or the first one.
+func ParseTopology(data []byte) (*TopologyUnit, error) {
+	trimmed := bytes.TrimSpace(data)
+	switch {
+	case len(trimmed) == 0:
+		return &TopologyUnit{}, nil
+	case trimmed[0] == '{':
+		unit := &TopologyUnit{}
+		if err := json.Unmarshal(trimmed, unit); err != nil {
+			return nil, fmt.Errorf("failed to parse topology JSON: %v", err)
+		}
+		return unit, nil
+	case isYAMLList(trimmed):
+		topos, err := ParseYAML(trimmed)
+		if err != nil {
+			return nil, err
+		}
+		if len(topos) == 0 {
+			return &TopologyUnit{}, nil
+		}
+		for _, topo := range topos {
+			if topo.Default {
+				return topo.Unit, nil
+			}
+		}
+		return topos[0].Unit, nil
+	default:
+		return ParseConf(data)
+	}
+}
+
+// isYAMLList returns true if the first non-comment line starts a YAML list
+func isYAMLList(data []byte) bool {
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 || line[0] == '#' {
+			continue
+		}
+		return line[0] == '-'
+	}
+	return false
+}
@@ -0,0 +1,124 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package translate
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/NVIDIA/topograph/internal/fixtures"
+)
+
+func TestParseConf(t *testing.T) {
+	testCases := []struct {
+		name string
+		data string
+		unit *TopologyUnit
+		err  string
+	}{
+		{
+			name: "Case 1: tree topology with comments",
+			data: "# S2=switch-2\n" + testTreeConfig + "# Node index (informational)\n# Node201: topology=topology/tree switch=S2\n",
+			unit: &TopologyUnit{Tree: &TreeTopo{Switches: []*Switch{
+				{Switch: "S1", Children: "S[2-3]"},
+				{Switch: "S2", Nodes: "Node[201-202],Node205"},
+				{Switch: "S3", Nodes: "Node[304-306]"},
+			}}},
+		},
+		{
+			name: "Case 2: block topology",
+			data: testBlockConfig,
+			unit: &TopologyUnit{Block: &BlockTopo{
+				Blocks: []*Block{
+					{Block: "B1", Nodes: "Node[104-106]"},
+					{Block: "B2", Nodes: "Node[201-202],Node205"},
+				},
+				BlockSizes: []int{3},
+			}},
+		},
+		{
+			name: "Case 3: flat topology without switch",
+			data: "# topology/flat: network topology is not used\n",
+			unit: &TopologyUnit{Flat: &FlatTopo{}},
+		},
+		{
+			name: "Case 4: keys are case-insensitive and link speed is ignored",
+			data: "switchname=s1 nodes=n[1-2] LinkSpeed=100\n",
+			unit: &TopologyUnit{Tree: &TreeTopo{Switches: []*Switch{{Switch: "s1", Nodes: "n[1-2]"}}}},
+		},
+		{
+			name: "Case 5: empty config",
+			unit: &TopologyUnit{},
+		},
+		{
+			name: "Case 6: invalid field",
+			data: "SwitchName=S1 Nodes\n",
+			err:  `line 1: invalid field "Nodes"`,
+		},
+		{
+			name: "Case 7: unsupported entry",
+			data: "\nNodeName=n1\n",
+			err:  `line 2: unsupported entry "nodename"`,
+		},
+		{
+			name: "Case 8: invalid block size",
+			data: "BlockName=B1 Nodes=n1\nBlockSizes=1,x\n",
+			err:  `line 2: invalid block size "x"`,
+		},
+		{
+			name: "Case 9: switches and blocks",
+			data: "SwitchName=S1 Nodes=n1\nBlockName=B1 Nodes=n1\n",
+			err:  "topology config mixes switches and blocks",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			unit, err := ParseConf([]byte(tc.data))
+			if len(tc.err) != 0 {
+				require.EqualError(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.unit, unit)
+		})
+	}
+}
+
+func TestParseTopology(t *testing.T) {
+	treeRoot, _ := fixtures.TreeTestSet()
+	unit, err := ToTopologyUnit(context.TODO(), treeRoot)
+	require.NoError(t, err)
+	expected := unit.ReverseIndex()
+
+	for _, format := range []string{FormatConf, FormatJSON, FormatYAML} {
+		t.Run(format, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			if format == FormatYAML {
+				require.NoError(t, unit.WriteYAML(context.TODO(), buf, DefaultYAMLSchema))
+			} else {
+				require.NoError(t, unit.Write(context.TODO(), buf, format))
+			}
+
+			parsed, err := ParseTopology(buf.Bytes())
+			require.NoError(t, err)
+			require.Equal(t, expected, parsed.ReverseIndex())
+		})
+	}
+}
@@ -0,0 +1,117 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package translate
+
+import "sort"
+
+// TopologyDiff is the difference between the applied and a newly generated topology config
+type TopologyDiff struct {
+	NodesAdded      []string    `json:"nodes_added,omitempty"`
+	NodesRemoved    []string    `json:"nodes_removed,omitempty"`
+	NodesMoved      []*NodeMove `json:"nodes_moved,omitempty"`
+	SwitchesAdded   []string    `json:"switches_added,omitempty"`
+	SwitchesRemoved []string    `json:"switches_removed,omitempty"`
+	BlocksAdded     []string    `json:"blocks_added,omitempty"`
+	BlocksRemoved   []string    `json:"blocks_removed,omitempty"`
+}
+
+// NodeMove is a node placed differently in the applied and the generated topology config
+type NodeMove struct {
+	Node string        `json:"node"`
+	From *NodeLocation `json:"from"`
+	To   *NodeLocation `json:"to"`
+}
+
+// Empty returns true if the topology configs match
+func (d *TopologyDiff) Empty() bool {
+	return len(d.NodesAdded) == 0 && len(d.NodesRemoved) == 0 && len(d.NodesMoved) == 0 &&
+		len(d.SwitchesAdded) == 0 && len(d.SwitchesRemoved) == 0 &&
+		len(d.BlocksAdded) == 0 && len(d.BlocksRemoved) == 0
+}
+
+// Diff compares the applied topology config with the generated one.
+// A nil applied config is treated as empty.
+func Diff(applied, generated *TopologyUnit) *TopologyDiff {
+	if applied == nil {
+		applied = &TopologyUnit{}
+	}
+	if generated == nil {
+		generated = &TopologyUnit{}
+	}
+	diff := &TopologyDiff{}
+
+	oldIndex, newIndex := applied.ReverseIndex(), generated.ReverseIndex()
+	for node, to := range newIndex {
+		from, ok := oldIndex[node]
+		if !ok {
+			diff.NodesAdded = append(diff.NodesAdded, node)
+		} else if *from != *to {
+			diff.NodesMoved = append(diff.NodesMoved, &NodeMove{Node: node, From: from, To: to})
+		}
+	}
+	for node := range oldIndex {
+		if _, ok := newIndex[node]; !ok {
+			diff.NodesRemoved = append(diff.NodesRemoved, node)
+		}
+	}
+	sort.Strings(diff.NodesAdded)
+	sort.Strings(diff.NodesRemoved)
+	sort.Slice(diff.NodesMoved, func(i, j int) bool { return diff.NodesMoved[i].Node < diff.NodesMoved[j].Node })
+
+	diff.SwitchesAdded, diff.SwitchesRemoved = diffNames(applied.switchNames(), generated.switchNames())
+	diff.BlocksAdded, diff.BlocksRemoved = diffNames(applied.blockNames(), generated.blockNames())
+
+	return diff
+}
+
+func (unit *TopologyUnit) switchNames() map[string]bool {
+	names := make(map[string]bool)
+	if unit.Tree != nil {
+		for _, sw := range unit.Tree.Switches {
+			names[sw.Switch] = true
+		}
+	}
+	return names
+}
+
+func (unit *TopologyUnit) blockNames() map[string]bool {
+	names := make(map[string]bool)
+	if unit.Block != nil {
+		for _, block := range unit.Block.Blocks {
+			names[block.Block] = true
+		}
+	}
+	return names
+}
+
+// diffNames returns the sorted names only in the new set, and only in the old set
+func diffNames(old, new map[string]bool) ([]string, []string) {
+	var added, removed []string
+	for name := range new {
+		if !old[name] {
+			added = append(added, name)
+		}
+	}
+	for name := range old {
+		if !new[name] {
+			removed = append(removed, name)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	return added, removed
+}
@@ -0,0 +1,141 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package translate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiff(t *testing.T) {
+	testCases := []struct {
+		name      string
+		applied   string
+		generated string
+		diff      *TopologyDiff
+	}{
+		{
+			name:      "Case 1: unchanged tree topology",
+			applied:   testTreeConfig,
+			generated: testTreeConfig,
+			diff:      &TopologyDiff{},
+		},
+		{
+			name:      "Case 2: nothing applied",
+			generated: testBlockConfig,
+			diff: &TopologyDiff{
+				NodesAdded:  []string{"Node104", "Node105", "Node106", "Node201", "Node202", "Node205"},
+				BlocksAdded: []string{"B1", "B2"},
+			},
+		},
+		{
+			name: "Case 3: tree topology changes",
+			applied: `SwitchName=S1 Switches=S[2-3]
+SwitchName=S2 Nodes=Node[201-202],Node205
+SwitchName=S3 Nodes=Node[304-306]
+`,
+			generated: `SwitchName=S1 Switches=S[2,4]
+SwitchName=S2 Nodes=Node[201-202],Node304
+SwitchName=S4 Nodes=Node[305-307]
+`,
+			diff: &TopologyDiff{
+				NodesAdded:   []string{"Node307"},
+				NodesRemoved: []string{"Node205"},
+				NodesMoved: []*NodeMove{
+					{
+						Node: "Node304",
+						From: &NodeLocation{Topology: "topology/tree", Switch: "S3"},
+						To:   &NodeLocation{Topology: "topology/tree", Switch: "S2"},
+					},
+					{
+						Node: "Node305",
+						From: &NodeLocation{Topology: "topology/tree", Switch: "S3"},
+						To:   &NodeLocation{Topology: "topology/tree", Switch: "S4"},
+					},
+					{
+						Node: "Node306",
+						From: &NodeLocation{Topology: "topology/tree", Switch: "S3"},
+						To:   &NodeLocation{Topology: "topology/tree", Switch: "S4"},
+					},
+				},
+				SwitchesAdded:   []string{"S4"},
+				SwitchesRemoved: []string{"S3"},
+			},
+		},
+		{
+			name:      "Case 4: block topology changes",
+			applied:   testBlockConfig,
+			generated: "BlockName=B1 Nodes=Node[104-105]\nBlockName=B3 Nodes=Node106,Node[201-202]\nBlockSizes=2\n",
+			diff: &TopologyDiff{
+				NodesRemoved: []string{"Node205"},
+				NodesMoved: []*NodeMove{
+					{
+						Node: "Node106",
+						From: &NodeLocation{Topology: "topology/block", Block: "B1"},
+						To:   &NodeLocation{Topology: "topology/block", Block: "B3"},
+					},
+					{
+						Node: "Node201",
+						From: &NodeLocation{Topology: "topology/block", Block: "B2"},
+						To:   &NodeLocation{Topology: "topology/block", Block: "B3"},
+					},
+					{
+						Node: "Node202",
+						From: &NodeLocation{Topology: "topology/block", Block: "B2"},
+						To:   &NodeLocation{Topology: "topology/block", Block: "B3"},
+					},
+				},
+				BlocksAdded:   []string{"B3"},
+				BlocksRemoved: []string{"B2"},
+			},
+		},
+		{
+			name:      "Case 5: plugin change",
+			applied:   "SwitchName=S1 Nodes=Node[104-105]\n",
+			generated: "BlockName=B1 Nodes=Node[104-105]\nBlockSizes=2\n",
+			diff: &TopologyDiff{
+				NodesMoved: []*NodeMove{
+					{
+						Node: "Node104",
+						From: &NodeLocation{Topology: "topology/tree", Switch: "S1"},
+						To:   &NodeLocation{Topology: "topology/block", Block: "B1"},
+					},
+					{
+						Node: "Node105",
+						From: &NodeLocation{Topology: "topology/tree", Switch: "S1"},
+						To:   &NodeLocation{Topology: "topology/block", Block: "B1"},
+					},
+				},
+				SwitchesRemoved: []string{"S1"},
+				BlocksAdded:     []string{"B1"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			applied, err := ParseConf([]byte(tc.applied))
+			require.NoError(t, err)
+			generated, err := ParseConf([]byte(tc.generated))
+			require.NoError(t, err)
+
+			diff := Diff(applied, generated)
+			require.Equal(t, tc.diff, diff)
+			require.Equal(t, tc.applied == tc.generated, diff.Empty())
+		})
+	}
+}
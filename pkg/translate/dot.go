@@ -0,0 +1,114 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package translate
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/NVIDIA/topograph/pkg/topology"
+)
+
+// ToDOT writes the tree and block topology as a Graphviz DOT graph, where switches are rendered as boxes,
+// compute nodes as ellipses, and blocks as clusters. Nodes and edges are sorted to produce stable output.
+func ToDOT(wr io.Writer, root *topology.Vertex) error {
+	switches := make(map[string]bool)
+	nodes := make(map[string]bool)
+	edges := make(map[string]bool)
+
+	if treeRoot, ok := root.Vertices[topology.TopologyTree]; ok {
+		visited := make(map[*topology.Vertex]bool)
+		var walk func(v *topology.Vertex)
+		walk = func(v *topology.Vertex) {
+			if visited[v] {
+				return
+			}
+			visited[v] = true
+			for _, w := range v.Vertices {
+				if len(w.Vertices) == 0 {
+					nodes[vertexName(w)] = true
+				} else {
+					switches[vertexName(w)] = true
+					walk(w)
+				}
+				if len(v.ID) != 0 {
+					edges[fmt.Sprintf("%s -> %s", dotID(vertexName(v)), dotID(vertexName(w)))] = true
+				}
+			}
+		}
+		walk(treeRoot)
+	}
+
+	var clusters []string
+	if blockRoot, ok := root.Vertices[topology.TopologyBlock]; ok {
+		for _, key := range sortVertices(blockRoot) {
+			block := blockRoot.Vertices[key]
+			members := make([]string, 0, len(block.Vertices))
+			for _, node := range block.Vertices {
+				name := vertexName(node)
+				nodes[name] = true
+				members = append(members, "\t\t"+dotID(name)+";\n")
+			}
+			sort.Strings(members)
+			clusters = append(clusters, fmt.Sprintf("\tsubgraph %s {\n\t\tlabel=%s;\n%s\t}\n",
+				dotID("cluster_"+block.ID), dotID(vertexName(block)), strings.Join(members, "")))
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString("digraph topology {\n")
+	for _, name := range sortedKeys(switches) {
+		sb.WriteString(fmt.Sprintf("\t%s [shape=box];\n", dotID(name)))
+	}
+	for _, name := range sortedKeys(nodes) {
+		if !switches[name] {
+			sb.WriteString(fmt.Sprintf("\t%s [shape=ellipse];\n", dotID(name)))
+		}
+	}
+	for _, edge := range sortedKeys(edges) {
+		sb.WriteString(fmt.Sprintf("\t%s;\n", edge))
+	}
+	for _, cluster := range clusters {
+		sb.WriteString(cluster)
+	}
+	sb.WriteString("}\n")
+
+	_, err := wr.Write([]byte(sb.String()))
+	return err
+}
+
+// vertexName returns the vertex name with fallback to the vertex ID
+func vertexName(v *topology.Vertex) string {
+	if len(v.Name) != 0 {
+		return v.Name
+	}
+	return v.ID
+}
+
+func dotID(s string) string {
+	return "\"" + strings.ReplaceAll(s, "\"", "\\\"") + "\""
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
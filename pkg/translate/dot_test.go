@@ -0,0 +1,125 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package translate
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/NVIDIA/topograph/internal/fixtures"
+	"github.com/NVIDIA/topograph/pkg/topology"
+)
+
+const (
+	testTreeDOT = `digraph topology {
+	"S1" [shape=box];
+	"S2" [shape=box];
+	"S3" [shape=box];
+	"Node201" [shape=ellipse];
+	"Node202" [shape=ellipse];
+	"Node205" [shape=ellipse];
+	"Node304" [shape=ellipse];
+	"Node305" [shape=ellipse];
+	"Node306" [shape=ellipse];
+	"S1" -> "S2";
+	"S1" -> "S3";
+	"S2" -> "Node201";
+	"S2" -> "Node202";
+	"S2" -> "Node205";
+	"S3" -> "Node304";
+	"S3" -> "Node305";
+	"S3" -> "Node306";
+}
+`
+
+	testBlockDOT = `digraph topology {
+	"Node104" [shape=ellipse];
+	"Node105" [shape=ellipse];
+	"Node106" [shape=ellipse];
+	"Node201" [shape=ellipse];
+	"Node202" [shape=ellipse];
+	"Node205" [shape=ellipse];
+	subgraph "cluster_B1" {
+		label="B1";
+		"Node104";
+		"Node105";
+		"Node106";
+	}
+	subgraph "cluster_B2" {
+		label="B2";
+		"Node201";
+		"Node202";
+		"Node205";
+	}
+}
+`
+
+	testNamedBlockDOT = `digraph topology {
+	"node1" [shape=ellipse];
+	"node2" [shape=ellipse];
+	subgraph "cluster_block001" {
+		label="cb1";
+		"node1";
+		"node2";
+	}
+}
+`
+)
+
+func TestToDOT(t *testing.T) {
+	tree, _ := fixtures.TreeTestSet()
+	block, _ := getBlockTestSet()
+
+	domainMap := NewDomainMap()
+	domainMap.AddHost("cb1", "node1")
+	domainMap.AddHost("cb1", "node2")
+	named := &topology.Vertex{
+		Vertices: map[string]*topology.Vertex{topology.TopologyBlock: domainMap.ToBlocks()},
+	}
+
+	testCases := []struct {
+		name     string
+		root     *topology.Vertex
+		expected string
+	}{
+		{
+			name:     "Case 1: tree topology",
+			root:     tree,
+			expected: testTreeDOT,
+		},
+		{
+			name:     "Case 2: block topology",
+			root:     block,
+			expected: testBlockDOT,
+		},
+		{
+			name:     "Case 3: block topology with block names",
+			root:     named,
+			expected: testNamedBlockDOT,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			err := ToDOT(buf, tc.root)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, buf.String())
+		})
+	}
+}
@@ -0,0 +1,166 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package translate
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/klog/v2"
+
+	"github.com/NVIDIA/topograph/pkg/metrics"
+	"github.com/NVIDIA/topograph/pkg/topology"
+)
+
+// policies for the compute nodes placed in more than one block or under more than one leaf switch
+const (
+	// DuplicateNodesError fails the topology config generation
+	DuplicateNodesError = "error"
+	// DuplicateNodesFirstWins keeps the node in the block with the smallest ID, or under the first
+	// leaf switch in the config order, and removes it from the others (default)
+	DuplicateNodesFirstWins = "first-wins"
+)
+
+// ErrDuplicateNodes is returned when the duplicate nodes policy is "error" and the topology has duplicate nodes
+var ErrDuplicateNodes = errors.New("duplicate nodes")
+
+// ValidateDuplicateNodes returns an error if the duplicate nodes policy is not supported
+func ValidateDuplicateNodes(policy string) error {
+	switch policy {
+	case "", DuplicateNodesError, DuplicateNodesFirstWins:
+		return nil
+	default:
+		return fmt.Errorf("unsupported duplicate nodes policy %q", policy)
+	}
+}
+
+// duplicates maps the duplicate nodes to the IDs of their blocks or leaf switches
+type duplicates map[string][]string
+
+// resolveDuplicateNodes detects the nodes listed in more than one block for the block plugin,
+// or under more than one leaf switch otherwise, and either fails or removes the later occurrences, as set by the policy
+func resolveDuplicateNodes(root *topology.Vertex, plugin, policy string) error {
+	firstWins := policy != DuplicateNodesError
+
+	var dups duplicates
+	var where string
+	if plugin == topology.TopologyBlock {
+		if blockRoot := root.Vertices[topology.TopologyBlock]; blockRoot != nil {
+			dups, where = blockDuplicates(blockRoot, firstWins), "in blocks"
+		}
+	} else if treeRoot := root.Vertices[topology.TopologyTree]; treeRoot != nil {
+		dups, where = treeDuplicates(treeRoot, firstWins), "under switches"
+	}
+	if len(dups) == 0 {
+		return nil
+	}
+
+	msg := dups.String(where)
+	if !firstWins {
+		return fmt.Errorf("%w: %s", ErrDuplicateNodes, msg)
+	}
+	klog.Warningf("Duplicate nodes, keeping the first placement: %s", msg)
+	metrics.AddValidationError("duplicate nodes")
+	return nil
+}
+
+// treeDuplicates walks the tree in the config order, and returns the nodes under more than one leaf switch.
+// If remove is set, the later occurrences are removed from the tree.
+func treeDuplicates(treeRoot *topology.Vertex, remove bool) duplicates {
+	dups := make(duplicates)
+	first := make(map[string]string)
+	visited := make(map[string]bool)
+	queue := []*topology.Vertex{treeRoot}
+
+	for len(queue) > 0 {
+		v := queue[0]
+		queue = queue[1:]
+		for _, key := range sortVertices(v) {
+			w := v.Vertices[key]
+			if len(w.Vertices) != 0 {
+				if !visited[w.ID] {
+					visited[w.ID] = true
+					queue = append(queue, w)
+				}
+				continue
+			}
+			if len(w.Name) == 0 {
+				continue
+			}
+			if dups.add(first, w.Name, v.ID) && remove {
+				delete(v.Vertices, key)
+			}
+		}
+	}
+
+	return dups
+}
+
+// blockDuplicates returns the nodes in more than one block, the first block being the one with the smallest ID.
+// If remove is set, the later occurrences are removed from the blocks, and the blocks left empty are removed.
+func blockDuplicates(blockRoot *topology.Vertex, remove bool) duplicates {
+	dups := make(duplicates)
+	first := make(map[string]string)
+
+	for _, id := range sortVertices(blockRoot) {
+		block := blockRoot.Vertices[id]
+		for _, key := range sortVertices(block) {
+			name := block.Vertices[key].Name
+			if len(name) == 0 {
+				continue
+			}
+			if dups.add(first, name, block.ID) && remove {
+				delete(block.Vertices, key)
+			}
+		}
+		if remove && len(block.Vertices) == 0 {
+			delete(blockRoot.Vertices, id)
+		}
+	}
+
+	return dups
+}
+
+// add records the placement of the node, and returns true if the node was already placed
+func (d duplicates) add(first map[string]string, node, id string) bool {
+	firstID, ok := first[node]
+	if !ok {
+		first[node] = id
+		return false
+	}
+	if _, ok := d[node]; !ok {
+		d[node] = []string{firstID}
+	}
+	d[node] = append(d[node], id)
+	return true
+}
+
+// String lists the duplicate nodes in sorted order, e.g. "node1 in blocks b1,b2"
+func (d duplicates) String(where string) string {
+	nodes := make([]string, 0, len(d))
+	for node := range d {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+
+	for i, node := range nodes {
+		nodes[i] = fmt.Sprintf("%s %s %s", node, where, strings.Join(d[node], ","))
+	}
+	return strings.Join(nodes, ", ")
+}
@@ -0,0 +1,122 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package translate
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/NVIDIA/topograph/pkg/topology"
+)
+
+// duplicateNodesRoot returns a topology where node n2 is under leaf switches S1 and S2,
+// and nodes n2 and n3 are in blocks B1, B2 and B2, B3, respectively
+func duplicateNodesRoot(plugin, policy string) *topology.Vertex {
+	n1 := &topology.Vertex{ID: "n1", Name: "n1"}
+	n2 := &topology.Vertex{ID: "n2", Name: "n2"}
+	n3 := &topology.Vertex{ID: "n3", Name: "n3"}
+
+	s1 := &topology.Vertex{ID: "S1", Vertices: map[string]*topology.Vertex{"n1": n1, "n2": n2}}
+	s2 := &topology.Vertex{ID: "S2", Vertices: map[string]*topology.Vertex{"n2": n2, "n3": n3}}
+	s3 := &topology.Vertex{ID: "S3", Vertices: map[string]*topology.Vertex{"n2": n2}}
+
+	return &topology.Vertex{
+		Vertices: map[string]*topology.Vertex{
+			topology.TopologyTree: {
+				Vertices: map[string]*topology.Vertex{
+					"S0": {ID: "S0", Vertices: map[string]*topology.Vertex{"S1": s1, "S2": s2, "S3": s3}},
+				},
+			},
+			topology.TopologyBlock: {
+				Vertices: map[string]*topology.Vertex{
+					"B1": {ID: "B1", Vertices: map[string]*topology.Vertex{"n1": n1, "n2": n2}},
+					"B2": {ID: "B2", Vertices: map[string]*topology.Vertex{"n2": n2, "n3": n3}},
+					"B3": {ID: "B3", Vertices: map[string]*topology.Vertex{"n3": n3}},
+				},
+			},
+		},
+		Metadata: map[string]string{
+			topology.KeyPlugin:         plugin,
+			topology.KeyDuplicateNodes: policy,
+			topology.KeyComments:       CommentsNone,
+		},
+	}
+}
+
+func TestDuplicateNodes(t *testing.T) {
+	testCases := []struct {
+		name   string
+		plugin string
+		policy string
+		output string
+		err    string
+	}{
+		{
+			name:   "Case 1: tree with default policy",
+			plugin: topology.TopologyTree,
+			output: "SwitchName=S0 Switches=S[1-2]\nSwitchName=S1 Nodes=n[1-2]\nSwitchName=S2 Nodes=n3\n",
+		},
+		{
+			name:   "Case 2: tree with first-wins policy",
+			plugin: topology.TopologyTree,
+			policy: DuplicateNodesFirstWins,
+			output: "SwitchName=S0 Switches=S[1-2]\nSwitchName=S1 Nodes=n[1-2]\nSwitchName=S2 Nodes=n3\n",
+		},
+		{
+			name:   "Case 3: tree with error policy",
+			plugin: topology.TopologyTree,
+			policy: DuplicateNodesError,
+			err:    "duplicate nodes: n2 under switches S1,S2,S3",
+		},
+		{
+			name:   "Case 4: block with first-wins policy",
+			plugin: topology.TopologyBlock,
+			policy: DuplicateNodesFirstWins,
+			output: "BlockName=B1 Nodes=n[1-2]\nBlockName=B2 Nodes=n3\nBlockSizes=1\n",
+		},
+		{
+			name:   "Case 5: block with error policy",
+			plugin: topology.TopologyBlock,
+			policy: DuplicateNodesError,
+			err:    "duplicate nodes: n2 in blocks B1,B2, n3 in blocks B2,B3",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			err := Write(context.TODO(), buf, duplicateNodesRoot(tc.plugin, tc.policy))
+			if len(tc.err) != 0 {
+				require.ErrorIs(t, err, ErrDuplicateNodes)
+				require.EqualError(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.output, buf.String())
+		})
+	}
+}
+
+func TestValidateDuplicateNodes(t *testing.T) {
+	for _, policy := range []string{"", DuplicateNodesError, DuplicateNodesFirstWins} {
+		require.NoError(t, ValidateDuplicateNodes(policy))
+	}
+	require.EqualError(t, ValidateDuplicateNodes("last-wins"), `unsupported duplicate nodes policy "last-wins"`)
+}
@@ -0,0 +1,76 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package translate
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/NVIDIA/topograph/pkg/topology"
+)
+
+// UnassignedGroup is the hostlist group of the nodes outside of any block
+const UnassignedGroup = "unassigned"
+
+// WriteHostlist writes the block topology as launcher hostfile groupings:
+// a "<block>: <nodes>" line per block, followed by the "unassigned:" line
+// with the nodes of the topology graph that are not in any block.
+// The graph may be nil, in which case the unassigned group is empty.
+func (unit *TopologyUnit) WriteHostlist(ctx context.Context, wr io.Writer, root *topology.Vertex) error {
+	if unit.Block == nil {
+		return fmt.Errorf("%s format requires the %s plugin", FormatHostlist, topology.TopologyBlock)
+	}
+
+	for _, block := range unit.Block.Blocks {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(wr, "%s: %s\n", block.Block, block.Nodes); err != nil {
+			return err
+		}
+	}
+
+	line := UnassignedGroup + ":"
+	if unassigned := unit.unassignedNodes(root); len(unassigned) != 0 {
+		line += " " + strings.Join(compress(unassigned), ",")
+	}
+	_, err := fmt.Fprintln(wr, line)
+	return err
+}
+
+// unassignedNodes returns the compute nodes of the graph that are not in the topology config
+func (unit *TopologyUnit) unassignedNodes(root *topology.Vertex) []string {
+	if root == nil {
+		return nil
+	}
+
+	nodes := make(map[string]bool)
+	for _, v := range root.Vertices {
+		collectNodes(v, nodes)
+	}
+	for _, node := range unit.Nodes() {
+		delete(nodes, node)
+	}
+
+	names := make([]string, 0, len(nodes))
+	for name := range nodes {
+		names = append(names, name)
+	}
+	return names
+}
@@ -0,0 +1,85 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package translate
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/NVIDIA/topograph/internal/fixtures"
+	"github.com/NVIDIA/topograph/pkg/topology"
+)
+
+func TestWriteHostlist(t *testing.T) {
+	testCases := []struct {
+		name   string
+		root   func() *topology.Vertex
+		output string
+		err    string
+	}{
+		{
+			name: "Case 1: all nodes in blocks",
+			root: func() *topology.Vertex {
+				root, _ := fixtures.BlockWithMultiIBTestSet()
+				return root
+			},
+			output: `B3: Node[301-303]
+B4: Node[401-403]
+B1: Node[104-106]
+B2: Node[201-202],Node205
+unassigned:
+`,
+		},
+		{
+			name: "Case 2: nodes without block",
+			root: func() *topology.Vertex {
+				root, _ := fixtures.BlockWithMultiIBTestSet()
+				delete(root.Vertices[topology.TopologyBlock].Vertices, "B2")
+				delete(root.Vertices[topology.TopologyBlock].Vertices, "B4")
+				return root
+			},
+			output: `B3: Node[301-303]
+B1: Node[104-106]
+unassigned: Node[201-202],Node205,Node[401-403]
+`,
+		},
+		{
+			name: "Case 3: tree plugin",
+			root: func() *topology.Vertex {
+				root, _ := fixtures.TreeTestSet()
+				return root
+			},
+			err: "hostlist format requires the topology/block plugin",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			err := WriteFormat(context.TODO(), buf, tc.root(), FormatHostlist)
+			if len(tc.err) != 0 {
+				require.EqualError(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.output, buf.String())
+		})
+	}
+}
@@ -0,0 +1,182 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package translate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"regexp"
+
+	"github.com/NVIDIA/topograph/pkg/topology"
+)
+
+// standard node labels and annotations of the network topology
+const (
+	LabelAccelerator = "network.topology.kubernetes.io/accelerator"
+	LabelBlock       = "network.topology.kubernetes.io/block"
+	LabelSpine       = "network.topology.kubernetes.io/spine"
+	LabelDatacenter  = "network.topology.kubernetes.io/datacenter"
+
+	// AnnotationBandwidth holds the aggregate uplink bandwidth in Gb/s of the leaf switch of the node
+	AnnotationBandwidth = "network.qos.nvidia.com/bandwidth"
+)
+
+const (
+	// FormatLabels is the JSON document of the standard labels and annotations of each node
+	FormatLabels = "labels"
+	// NodeLabelsSchemaVersion is the schema version of the node labels document
+	NodeLabelsSchemaVersion = "v1"
+
+	maxLabelValueLength = 63
+)
+
+// LabelTierKeys holds the label keys of the switch tiers, from the leaf tier up
+var LabelTierKeys = []string{LabelBlock, LabelSpine, LabelDatacenter}
+
+var labelValueRegexp = regexp.MustCompile(`^(([A-Za-z0-9][-A-Za-z0-9_.]*)?[A-Za-z0-9])?$`)
+
+// NodeLabels holds the standard labels and annotations of a node
+type NodeLabels struct {
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// NodeLabelsDocument maps the node names to their standard labels and annotations,
+// as applied by the k8s engine, for the consumers outside of Kubernetes
+type NodeLabelsDocument struct {
+	SchemaVersion string                 `json:"schema_version"`
+	Nodes         map[string]*NodeLabels `json:"nodes"`
+}
+
+// LabelValue returns the value as a Kubernetes label value.
+// A value longer than 63 characters or with invalid characters is replaced with its hash.
+func LabelValue(val string) string {
+	if len(val) <= maxLabelValueLength && labelValueRegexp.MatchString(val) {
+		return val
+	}
+
+	h := fnv.New64a()
+	h.Write([]byte(val))
+	return fmt.Sprintf("x%x", h.Sum64())
+}
+
+// WriteNodeLabels writes the JSON document of the standard labels and annotations of the nodes of the topology graph
+func WriteNodeLabels(ctx context.Context, wr io.Writer, root *topology.Vertex) error {
+	doc, err := ToNodeLabels(ctx, root)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = wr.Write(append(data, '\n'))
+	return err
+}
+
+// ToNodeLabels returns the standard labels of the nodes of the tree and block topologies of the graph:
+// the accelerator domain, and the switch tiers from the leaf switch up to the datacenter.
+// The nodes are annotated with the uplink bandwidth of their leaf switch, if known.
+func ToNodeLabels(ctx context.Context, root *topology.Vertex) (*NodeLabelsDocument, error) {
+	doc := &NodeLabelsDocument{
+		SchemaVersion: NodeLabelsSchemaVersion,
+		Nodes:         make(map[string]*NodeLabels),
+	}
+	if root == nil {
+		return doc, nil
+	}
+
+	if blockRoot, ok := root.Vertices[topology.TopologyBlock]; ok {
+		for _, id := range sortVertices(blockRoot) {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			block := blockRoot.Vertices[id]
+			for _, node := range block.Vertices {
+				labels := doc.node(node.Name).Labels
+				if val, ok := labels[LabelAccelerator]; ok {
+					return nil, fmt.Errorf("multiple accelerator labels %s, %s for node %s", val, block.ID, node.Name)
+				}
+				labels[LabelAccelerator] = LabelValue(block.ID)
+			}
+		}
+	}
+
+	if treeRoot, ok := root.Vertices[topology.TopologyTree]; ok {
+		var path []string
+		if len(treeRoot.ID) != 0 {
+			path = append(path, treeRoot.ID)
+		}
+		placed := make(map[string]bool)
+		if err := doc.addTreeLabels(ctx, treeRoot, path, placed); err != nil {
+			return nil, err
+		}
+	}
+
+	return doc, nil
+}
+
+// node returns the labels of the node, adding them if missing
+func (doc *NodeLabelsDocument) node(name string) *NodeLabels {
+	node, ok := doc.Nodes[name]
+	if !ok {
+		node = &NodeLabels{Labels: make(map[string]string)}
+		doc.Nodes[name] = node
+	}
+	return node
+}
+
+// addTreeLabels labels the nodes under the vertex with the switches of the path from the top of the tree.
+// A node reachable by several paths keeps the first one in the ID order of the switches.
+func (doc *NodeLabelsDocument) addTreeLabels(ctx context.Context, v *topology.Vertex, path []string, placed map[string]bool) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	for _, id := range sortVertices(v) {
+		w := v.Vertices[id]
+		if len(w.Vertices) != 0 {
+			if err := doc.addTreeLabels(ctx, w, append(path[:len(path):len(path)], w.ID), placed); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if placed[w.Name] {
+			continue
+		}
+		placed[w.Name] = true
+
+		node := doc.node(w.Name)
+		// the tiers closest to the node are labeled, the tiers beyond the label keys are skipped
+		for i, key := range LabelTierKeys {
+			if i == len(path) || len(path[len(path)-1-i]) == 0 {
+				break
+			}
+			node.Labels[key] = LabelValue(path[len(path)-1-i])
+		}
+		if bandwidth := v.Metadata[topology.KeyUplinkBandwidth]; len(bandwidth) != 0 {
+			node.Annotations = map[string]string{AnnotationBandwidth: bandwidth}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,219 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package translate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/NVIDIA/topograph/internal/fixtures"
+	"github.com/NVIDIA/topograph/pkg/topology"
+)
+
+// nodeLabels returns the labels of the nodes of the document
+func nodeLabels(doc *NodeLabelsDocument) map[string]map[string]string {
+	labels := make(map[string]map[string]string, len(doc.Nodes))
+	for name, node := range doc.Nodes {
+		labels[name] = node.Labels
+	}
+	return labels
+}
+
+func TestToNodeLabels(t *testing.T) {
+	tree, _ := fixtures.TreeTestSet(fixtures.WithLongSwitchNames())
+	block, _ := fixtures.BlockWithMultiIBTestSet()
+
+	labels := func(accelerator, leaf, spine, datacenter string) map[string]string {
+		return map[string]string{LabelAccelerator: accelerator, LabelBlock: leaf, LabelSpine: spine, LabelDatacenter: datacenter}
+	}
+
+	testCases := []struct {
+		name     string
+		root     *topology.Vertex
+		expected map[string]map[string]string
+	}{
+		{
+			name: "Case 1: tree topology with long switch names",
+			root: tree,
+			expected: map[string]map[string]string{
+				"Node201": {LabelBlock: "S2", LabelSpine: "S1"},
+				"Node202": {LabelBlock: "S2", LabelSpine: "S1"},
+				"Node205": {LabelBlock: "S2", LabelSpine: "S1"},
+				"Node304": {LabelBlock: "xf946c4acef2d5939", LabelSpine: "S1"},
+				"Node305": {LabelBlock: "xf946c4acef2d5939", LabelSpine: "S1"},
+				"Node306": {LabelBlock: "xf946c4acef2d5939", LabelSpine: "S1"},
+			},
+		},
+		{
+			name: "Case 2: block topology with two IB fabrics",
+			root: block,
+			expected: map[string]map[string]string{
+				"Node104": labels("B1", "S2", "S1", "ibRoot2"),
+				"Node105": labels("B1", "S2", "S1", "ibRoot2"),
+				"Node106": labels("B1", "S2", "S1", "ibRoot2"),
+				"Node201": labels("B2", "S3", "S1", "ibRoot2"),
+				"Node202": labels("B2", "S3", "S1", "ibRoot2"),
+				"Node205": labels("B2", "S3", "S1", "ibRoot2"),
+				"Node301": labels("B3", "S5", "S4", "ibRoot1"),
+				"Node302": labels("B3", "S5", "S4", "ibRoot1"),
+				"Node303": labels("B3", "S5", "S4", "ibRoot1"),
+				"Node401": labels("B4", "S6", "S4", "ibRoot1"),
+				"Node402": labels("B4", "S6", "S4", "ibRoot1"),
+				"Node403": labels("B4", "S6", "S4", "ibRoot1"),
+			},
+		},
+		{
+			name:     "Case 3: empty topology",
+			root:     &topology.Vertex{},
+			expected: map[string]map[string]string{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			doc, err := ToNodeLabels(context.TODO(), tc.root)
+			require.NoError(t, err)
+			require.Equal(t, NodeLabelsSchemaVersion, doc.SchemaVersion)
+			require.Equal(t, tc.expected, nodeLabels(doc))
+		})
+	}
+}
+
+func TestToNodeLabelsWithBandwidth(t *testing.T) {
+	root, _ := fixtures.TreeTestSet()
+	spine := root.Vertices[topology.TopologyTree].Vertices["S1"]
+	spine.Vertices["S2"].Metadata = map[string]string{topology.KeyUplinkBandwidth: "400"}
+
+	doc, err := ToNodeLabels(context.TODO(), root)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{AnnotationBandwidth: "400"}, doc.Nodes["Node202"].Annotations)
+	require.Nil(t, doc.Nodes["Node305"].Annotations)
+}
+
+func TestToNodeLabelsWithDeepTree(t *testing.T) {
+	// the node is under four switch tiers; the top tier has no label key
+	root := &topology.Vertex{Vertices: map[string]*topology.Vertex{
+		topology.TopologyTree: {Vertices: map[string]*topology.Vertex{
+			"T4": {ID: "T4", Vertices: map[string]*topology.Vertex{
+				"T3": {ID: "T3", Vertices: map[string]*topology.Vertex{
+					"T2": {ID: "T2", Vertices: map[string]*topology.Vertex{
+						"T1": {ID: "T1", Vertices: map[string]*topology.Vertex{
+							"I1": {ID: "I1", Name: "n1"},
+						}},
+					}},
+				}},
+			}},
+		}},
+	}}
+
+	doc, err := ToNodeLabels(context.TODO(), root)
+	require.NoError(t, err)
+	require.Equal(t, map[string]map[string]string{
+		"n1": {LabelBlock: "T1", LabelSpine: "T2", LabelDatacenter: "T3"},
+	}, nodeLabels(doc))
+}
+
+func TestToNodeLabelsWithMultipleAccelerators(t *testing.T) {
+	root := &topology.Vertex{Vertices: map[string]*topology.Vertex{
+		topology.TopologyBlock: {Vertices: map[string]*topology.Vertex{
+			"B1": {ID: "B1", Vertices: map[string]*topology.Vertex{"I1": {ID: "I1", Name: "n1"}}},
+			"B2": {ID: "B2", Vertices: map[string]*topology.Vertex{"I1": {ID: "I1", Name: "n1"}}},
+		}},
+	}}
+
+	_, err := ToNodeLabels(context.TODO(), root)
+	require.EqualError(t, err, "multiple accelerator labels B1, B2 for node n1")
+}
+
+func TestWriteNodeLabels(t *testing.T) {
+	root, _ := fixtures.TreeTestSet()
+	spine := root.Vertices[topology.TopologyTree].Vertices["S1"]
+	spine.Vertices["S2"].Metadata = map[string]string{topology.KeyUplinkBandwidth: "400"}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteFormat(context.TODO(), &buf, root, FormatLabels))
+
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &doc))
+	require.Equal(t, "v1", doc["schema_version"])
+	require.Equal(t, map[string]any{
+		"labels": map[string]any{
+			"network.topology.kubernetes.io/block": "S2",
+			"network.topology.kubernetes.io/spine": "S1",
+		},
+		"annotations": map[string]any{"network.qos.nvidia.com/bandwidth": "400"},
+	}, doc["nodes"].(map[string]any)["Node202"])
+	require.Equal(t, map[string]any{
+		"labels": map[string]any{
+			"network.topology.kubernetes.io/block": "S3",
+			"network.topology.kubernetes.io/spine": "S1",
+		},
+	}, doc["nodes"].(map[string]any)["Node305"])
+}
+
+func TestLabelValue(t *testing.T) {
+	testCases := []struct {
+		name   string
+		val    string
+		hashed bool
+	}{
+		{
+			name: "Case 1: valid value",
+			val:  "ib-leaf_01.rack2",
+		},
+		{
+			name: "Case 2: 63 characters",
+			val:  strings.Repeat("s", 63),
+		},
+		{
+			name:   "Case 3: 64 characters",
+			val:    strings.Repeat("s", 64),
+			hashed: true,
+		},
+		{
+			name:   "Case 4: invalid characters",
+			val:    "ocid1.switch/rack:1",
+			hashed: true,
+		},
+		{
+			name:   "Case 5: invalid first character",
+			val:    "-leaf",
+			hashed: true,
+		},
+		{
+			name: "Case 6: empty value",
+			val:  "",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			val := LabelValue(tc.val)
+			if tc.hashed {
+				require.Regexp(t, "^x[0-9a-f]{1,16}$", val)
+				require.Equal(t, val, LabelValue(tc.val))
+			} else {
+				require.Equal(t, tc.val, val)
+			}
+		})
+	}
+}
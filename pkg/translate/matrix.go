@@ -0,0 +1,145 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package translate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/NVIDIA/topograph/pkg/topology"
+)
+
+const (
+	// FormatMatrix is the pairwise node distance matrix, e.g. for the NCCL topology tuning
+	FormatMatrix = "matrix"
+	// DefaultMaxMatrixNodes caps the node count of the distance matrix, whose size is quadratic in it
+	DefaultMaxMatrixNodes = 1024
+	// DistanceUnreachable is the distance between the nodes without a common switch
+	DistanceUnreachable = -1
+)
+
+// DistanceMatrix holds the node names and the matrix of the distances between them, in the order of the names.
+// The distance of two nodes is the number of switch tiers up to their lowest common switch:
+// 1 for the nodes of the same leaf switch, 2 for the nodes of the same spine switch, and so on.
+// The nodes of the same NVLink domain are at distance 0.
+type DistanceMatrix struct {
+	Nodes     []string `json:"nodes"`
+	Distances [][]int  `json:"distances"`
+}
+
+// WriteMatrix writes the node distance matrix of the topology graph as a single line of JSON.
+// It fails if the graph has more than maxNodes nodes; if maxNodes is not positive, DefaultMaxMatrixNodes is used.
+func WriteMatrix(ctx context.Context, wr io.Writer, root *topology.Vertex, maxNodes int) error {
+	matrix, err := ToDistanceMatrix(ctx, root, maxNodes)
+	if err != nil {
+		return err
+	}
+	return json.NewEncoder(wr).Encode(matrix)
+}
+
+// ToDistanceMatrix computes the distances between every pair of nodes of the tree and block topologies of the graph.
+// It fails if the graph has more than maxNodes nodes; if maxNodes is not positive, DefaultMaxMatrixNodes is used.
+func ToDistanceMatrix(ctx context.Context, root *topology.Vertex, maxNodes int) (*DistanceMatrix, error) {
+	if maxNodes <= 0 {
+		maxNodes = DefaultMaxMatrixNodes
+	}
+
+	// the switches from the top of the tree down to the leaf switch of each node
+	paths := make(map[string][]string)
+	if treeRoot, ok := root.Vertices[topology.TopologyTree]; ok {
+		for _, id := range sortVertices(treeRoot) {
+			collectPaths(treeRoot.Vertices[id], nil, paths)
+		}
+	}
+
+	// the NVLink domain of each node
+	domains := make(map[string]string)
+	if blockRoot, ok := root.Vertices[topology.TopologyBlock]; ok {
+		for _, id := range sortVertices(blockRoot) {
+			for _, node := range blockRoot.Vertices[id].Vertices {
+				if _, ok := domains[node.Name]; !ok && len(node.Name) != 0 {
+					domains[node.Name] = id
+				}
+			}
+		}
+	}
+
+	nodes := make([]string, 0, len(paths))
+	for node := range paths {
+		nodes = append(nodes, node)
+	}
+	for node := range domains {
+		if _, ok := paths[node]; !ok {
+			nodes = append(nodes, node)
+		}
+	}
+	if len(nodes) > maxNodes {
+		return nil, fmt.Errorf("distance matrix of %d nodes exceeds max_matrix_nodes %d", len(nodes), maxNodes)
+	}
+	sort.Strings(nodes)
+
+	distances := make([][]int, len(nodes))
+	for i, a := range nodes {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		distances[i] = make([]int, len(nodes))
+		for j := 0; j < i; j++ {
+			distances[i][j] = distances[j][i]
+		}
+		for j := i + 1; j < len(nodes); j++ {
+			b := nodes[j]
+			if domain, ok := domains[a]; ok && domain == domains[b] {
+				continue
+			}
+			distances[i][j] = distance(paths[a], paths[b])
+		}
+	}
+
+	return &DistanceMatrix{Nodes: nodes, Distances: distances}, nil
+}
+
+// collectPaths records the path of the switches from the top of the tree down to each node under the vertex.
+// A node reachable by several paths keeps the first one in the ID order of the switches.
+func collectPaths(v *topology.Vertex, path []string, paths map[string][]string) {
+	if len(v.Vertices) == 0 {
+		if _, ok := paths[v.Name]; !ok && len(v.Name) != 0 && len(path) != 0 {
+			paths[v.Name] = path
+		}
+		return
+	}
+
+	path = append(path[:len(path):len(path)], v.ID)
+	for _, id := range sortVertices(v) {
+		collectPaths(v.Vertices[id], path, paths)
+	}
+}
+
+// distance returns the number of switch tiers up to the lowest common switch of the paths
+func distance(a, b []string) int {
+	common := 0
+	for common < len(a) && common < len(b) && a[common] == b[common] {
+		common++
+	}
+	if common == 0 {
+		return DistanceUnreachable
+	}
+	return max(len(a), len(b)) - common + 1
+}
@@ -0,0 +1,133 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package translate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/NVIDIA/topograph/pkg/models"
+	"github.com/NVIDIA/topograph/pkg/topology"
+)
+
+func TestDistanceMatrix(t *testing.T) {
+	model, err := models.NewModelFromFile("../../tests/models/medium.yaml", nil)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name      string
+		block     bool
+		distances map[[2]string]int
+	}{
+		{
+			name: "Case 1: tree topology",
+			distances: map[[2]string]int{
+				{"n11-1", "n11-1"}: 0,
+				{"n11-1", "n11-2"}: 1,
+				{"n11-1", "n12-1"}: 2,
+				{"n12-2", "n11-1"}: 2,
+				{"n11-1", "n13-1"}: 3,
+				{"n14-2", "n11-2"}: 3,
+				{"n13-1", "n14-2"}: 2,
+			},
+		},
+		{
+			name:  "Case 2: NVLink domains",
+			block: true,
+			distances: map[[2]string]int{
+				{"n11-1", "n11-1"}: 0,
+				{"n11-1", "n11-2"}: 0,
+				{"n14-2", "n14-1"}: 0,
+				{"n11-1", "n12-1"}: 2,
+				{"n11-1", "n13-1"}: 3,
+				{"n13-1", "n14-2"}: 2,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			root, _ := model.ToGraph()
+			if !tc.block {
+				delete(root.Vertices, topology.TopologyBlock)
+			}
+
+			matrix, err := ToDistanceMatrix(context.TODO(), root, 0)
+			require.NoError(t, err)
+			require.Equal(t, []string{"n11-1", "n11-2", "n12-1", "n12-2", "n13-1", "n13-2", "n14-1", "n14-2"}, matrix.Nodes)
+			require.Len(t, matrix.Distances, len(matrix.Nodes))
+
+			index := make(map[string]int)
+			for i, node := range matrix.Nodes {
+				index[node] = i
+			}
+			for pair, expected := range tc.distances {
+				i, j := index[pair[0]], index[pair[1]]
+				require.Equal(t, expected, matrix.Distances[i][j], "%s-%s", pair[0], pair[1])
+				require.Equal(t, expected, matrix.Distances[j][i], "%s-%s", pair[1], pair[0])
+			}
+		})
+	}
+}
+
+func TestDistanceMatrixUnreachable(t *testing.T) {
+	// two trees without a common switch, and a node of an NVLink domain outside of the trees
+	root := &topology.Vertex{
+		Vertices: map[string]*topology.Vertex{
+			topology.TopologyTree: {
+				Vertices: map[string]*topology.Vertex{
+					"sw1": {ID: "sw1", Vertices: map[string]*topology.Vertex{"n1": {ID: "n1", Name: "n1"}}},
+					"sw2": {ID: "sw2", Vertices: map[string]*topology.Vertex{"n2": {ID: "n2", Name: "n2"}}},
+				},
+			},
+			topology.TopologyBlock: {
+				Vertices: map[string]*topology.Vertex{
+					"nvl1": {ID: "nvl1", Vertices: map[string]*topology.Vertex{
+						"n2": {ID: "n2", Name: "n2"},
+						"n3": {ID: "n3", Name: "n3"},
+					}},
+				},
+			},
+		},
+	}
+
+	buf := &bytes.Buffer{}
+	err := WriteFormat(context.TODO(), buf, root, FormatMatrix)
+	require.NoError(t, err)
+	require.Equal(t, `{"nodes":["n1","n2","n3"],"distances":[[0,-1,-1],[-1,0,0],[-1,0,0]]}`+"\n", buf.String())
+
+	var matrix DistanceMatrix
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &matrix))
+	require.Equal(t, []string{"n1", "n2", "n3"}, matrix.Nodes)
+}
+
+func TestDistanceMatrixMaxNodes(t *testing.T) {
+	model, err := models.NewModelFromFile("../../tests/models/medium.yaml", nil)
+	require.NoError(t, err)
+	root, _ := model.ToGraph()
+
+	_, err = ToDistanceMatrix(context.TODO(), root, 7)
+	require.EqualError(t, err, "distance matrix of 8 nodes exceeds max_matrix_nodes 7")
+
+	matrix, err := ToDistanceMatrix(context.TODO(), root, 8)
+	require.NoError(t, err)
+	require.Len(t, matrix.Nodes, 8)
+}
@@ -0,0 +1,76 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package translate
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+const (
+	// maxNameLength is the maximum length of the Slurm switch and block names
+	maxNameLength = 64
+	// nameHashLength is the number of hex digits of the short hash of the original names
+	nameHashLength = 8
+)
+
+// shortHash returns the first hex digits of the SHA-256 hash of the string
+func shortHash(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])[:nameHashLength]
+}
+
+// slurmName returns the Slurm-safe switch or block name and whether it differs from the given one.
+// The characters other than letters, digits, '-', '_' and '.' are replaced with '_', and the name
+// is truncated to the Slurm limit. A modified name ends with the short hash of the original name,
+// so that the names colliding after the replacement or the truncation stay unique.
+func slurmName(name string) (string, bool) {
+	valid := len(name) <= maxNameLength
+	sanitized := strings.Map(func(r rune) rune {
+		if isNameChar(r) {
+			return r
+		}
+		valid = false
+		return '_'
+	}, name)
+	if valid {
+		return name, false
+	}
+
+	if maxLen := maxNameLength - nameHashLength - 1; len(sanitized) > maxLen {
+		sanitized = sanitized[:maxLen]
+	}
+	return sanitized + "-" + shortHash(name), true
+}
+
+func isNameChar(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') ||
+		r == '-' || r == '_' || r == '.'
+}
+
+// switchName returns the Slurm name of the switch, and its original ID if it differs from the name
+func switchName(id, name string) (string, string) {
+	if len(name) != 0 {
+		name, _ = slurmName(name)
+		return name, id
+	}
+	if name, ok := slurmName(id); ok {
+		return name, id
+	}
+	return id, ""
+}
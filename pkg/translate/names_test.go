@@ -0,0 +1,167 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package translate
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/NVIDIA/topograph/pkg/topology"
+)
+
+func TestSlurmName(t *testing.T) {
+	long := strings.Repeat("S3very-long-", 8)
+
+	testCases := []struct {
+		name     string
+		input    string
+		expected string
+		modified bool
+	}{
+		{
+			name:     "Case 1: valid name",
+			input:    "switch.1.1",
+			expected: "switch.1.1",
+		},
+		{
+			name:     "Case 2: name of maximum length",
+			input:    long[:maxNameLength],
+			expected: long[:maxNameLength],
+		},
+		{
+			name:     "Case 3: spaces and colons",
+			input:    "MF0;ib-sw01:MQM8700 HDR/U1",
+			expected: "MF0_ib-sw01_MQM8700_HDR_U1-" + shortHash("MF0;ib-sw01:MQM8700 HDR/U1"),
+			modified: true,
+		},
+		{
+			name:     "Case 4: long name",
+			input:    long + "a",
+			expected: long[:55] + "-" + shortHash(long+"a"),
+			modified: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			name, modified := slurmName(tc.input)
+			require.Equal(t, tc.expected, name)
+			require.Equal(t, tc.modified, modified)
+			require.LessOrEqual(t, len(name), maxNameLength)
+		})
+	}
+}
+
+func TestSlurmNameCollisions(t *testing.T) {
+	long := strings.Repeat("x", 2*maxNameLength)
+
+	// names colliding after the replacement or the truncation stay unique
+	inputs := []string{"ib sw:1", "ib:sw 1", "ib_sw_1", long + "a", long + "b"}
+	names := make(map[string]string)
+	for _, input := range inputs {
+		name, _ := slurmName(input)
+		require.NotContains(t, names, name, "%q collides with %q", input, names[name])
+		names[name] = input
+	}
+}
+
+func TestToSlurmSanitizedNames(t *testing.T) {
+	long := strings.Repeat("S3very-very-long-", 5)
+	leaf1, leaf2 := long+"leaf-1", long+"leaf-2"
+	spine := "MF0;spine:01"
+
+	tree := &topology.Vertex{
+		Vertices: map[string]*topology.Vertex{
+			spine: {
+				ID: spine,
+				Vertices: map[string]*topology.Vertex{
+					leaf1: {
+						ID:       leaf1,
+						Vertices: map[string]*topology.Vertex{"n1": {ID: "n1", Name: "node1"}},
+					},
+					leaf2: {
+						ID:       leaf2,
+						Vertices: map[string]*topology.Vertex{"n2": {ID: "n2", Name: "node2"}},
+					},
+				},
+			},
+		},
+	}
+	blocks := &topology.Vertex{
+		Vertices: map[string]*topology.Vertex{
+			"nvl 1": {
+				ID:       "nvl 1",
+				Vertices: map[string]*topology.Vertex{"n1": {ID: "n1", Name: "node1"}},
+			},
+			"nvl:2": {
+				ID:       "nvl:2",
+				Name:     "rack-2",
+				Vertices: map[string]*topology.Vertex{"n2": {ID: "n2", Name: "node2"}},
+			},
+		},
+	}
+
+	spineName := "MF0_spine_01-" + shortHash(spine)
+	leaf1Name := long[:55] + "-" + shortHash(leaf1)
+	leaf2Name := long[:55] + "-" + shortHash(leaf2)
+	block1Name := "nvl_1-" + shortHash("nvl 1")
+	block2Name := "nvl_2-" + shortHash("nvl:2")
+
+	testCases := []struct {
+		name   string
+		root   *topology.Vertex
+		output string
+	}{
+		{
+			name: "Case 1: tree topology",
+			root: &topology.Vertex{
+				Vertices: map[string]*topology.Vertex{topology.TopologyTree: tree},
+			},
+			output: "# " + spineName + "=" + spine + "\n" +
+				"SwitchName=" + spineName + " Switches=" + leaf1Name + "," + leaf2Name + "\n" +
+				"# " + leaf1Name + "=" + leaf1 + "\n" +
+				"SwitchName=" + leaf1Name + " Nodes=node1\n" +
+				"# " + leaf2Name + "=" + leaf2 + "\n" +
+				"SwitchName=" + leaf2Name + " Nodes=node2\n",
+		},
+		{
+			name: "Case 2: block topology",
+			root: &topology.Vertex{
+				Vertices: map[string]*topology.Vertex{topology.TopologyBlock: blocks},
+				Metadata: map[string]string{topology.KeyPlugin: topology.TopologyBlock},
+			},
+			output: "# " + block1Name + "=nvl 1\n" +
+				"BlockName=" + block1Name + " Nodes=node1\n" +
+				"# " + block2Name + "=rack-2\n" +
+				"BlockName=" + block2Name + " Nodes=node2\n" +
+				"BlockSizes=1\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			err := Write(context.TODO(), buf, tc.root)
+			require.NoError(t, err)
+			require.Equal(t, tc.output, buf.String())
+		})
+	}
+}
@@ -17,6 +17,8 @@
 package translate
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"math"
@@ -26,52 +28,533 @@ import (
 
 	"k8s.io/klog/v2"
 
+	"github.com/NVIDIA/topograph/internal/cluset"
 	"github.com/NVIDIA/topograph/pkg/metrics"
 	"github.com/NVIDIA/topograph/pkg/topology"
 )
 
-func Write(wr io.Writer, root *topology.Vertex) error {
+const (
+	FormatConf = "conf"
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+	// FormatHostlist lists the nodes of each block for the job launchers
+	FormatHostlist = "hostlist"
+)
+
+// TopologyUnit is a format independent representation of the Slurm topology config
+type TopologyUnit struct {
+	Tree  *TreeTopo  `json:"tree,omitempty"`
+	Block *BlockTopo `json:"block,omitempty"`
+	Flat  *FlatTopo  `json:"flat,omitempty"`
+	// NodeIndex is an informational node lookup table, ignored by Slurm
+	NodeIndex map[string]*NodeLocation `json:"node_index,omitempty"`
+	// Accelerators is an informational map of the accelerator (NVLink) domains to their nodes
+	// for the topology/tree plugin, ignored by Slurm
+	Accelerators map[string]string `json:"accelerators,omitempty"`
+}
+
+// NodeLocation is the place of a node in the topology config
+type NodeLocation struct {
+	Topology string `json:"topology"`
+	Block    string `json:"block,omitempty"`
+	Switch   string `json:"switch,omitempty"`
+}
+
+// TreeTopo is the topology config for the topology/tree plugin
+type TreeTopo struct {
+	Switches []*Switch `json:"switches"`
+}
+
+// Switch is a network switch with either child switches or nodes
+type Switch struct {
+	Switch string `json:"switch"`
+	// ID is the CSP defined switch ID, if it differs from the switch name
+	ID       string `json:"id,omitempty"`
+	Children string `json:"children,omitempty"`
+	Nodes    string `json:"nodes,omitempty"`
+}
+
+// BlockTopo is the topology config for the topology/block plugin
+type BlockTopo struct {
+	Blocks     []*Block `json:"blocks"`
+	BlockSizes []int    `json:"block_sizes,omitempty"`
+	// BlockSizesSource tells whether the block size hint was used ("hint") or not ("fallback"),
+	// if the hint was given, or whether the expected block sizes were used ("expected")
+	BlockSizesSource string `json:"block_sizes_source,omitempty"`
+}
+
+const (
+	BlockSizesSourceHint     = "hint"
+	BlockSizesSourceFallback = "fallback"
+	BlockSizesSourceExpected = "expected"
+)
+
+// strategies selecting the domain size the block sizes are planned for
+const (
+	// BlockSizeStrategyMin plans for the smallest domain (default)
+	BlockSizeStrategyMin = "min"
+	// BlockSizeStrategyMedian plans for the median domain size
+	BlockSizeStrategyMedian = "median"
+	// BlockSizeStrategyHistogram plans for the most common domain size
+	BlockSizeStrategyHistogram = "histogram"
+)
+
+// Block is an accelerator domain
+type Block struct {
+	Block       string `json:"block"`
+	Name        string `json:"name,omitempty"`
+	DisplayName string `json:"display_name,omitempty"`
+	Nodes       string `json:"nodes"`
+}
+
+// Nodes returns the sorted names of the nodes in the topology config
+func (unit *TopologyUnit) Nodes() []string {
+	var lists []string
+	if unit.Tree != nil {
+		for _, sw := range unit.Tree.Switches {
+			lists = append(lists, sw.Nodes)
+		}
+	}
+	if unit.Block != nil {
+		for _, block := range unit.Block.Blocks {
+			lists = append(lists, block.Nodes)
+		}
+	}
+	if unit.Flat != nil {
+		lists = append(lists, unit.Flat.Nodes)
+	}
+
+	set := make(map[string]bool)
+	for _, list := range lists {
+		for _, node := range nodeNames(list) {
+			set[node] = true
+		}
+	}
+
+	nodes := make([]string, 0, len(set))
+	for node := range set {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+
+	return nodes
+}
+
+// ReverseIndex returns the location of each node in the topology config
+func (unit *TopologyUnit) ReverseIndex() map[string]*NodeLocation {
+	index := make(map[string]*NodeLocation)
+	if unit.Tree != nil {
+		for _, sw := range unit.Tree.Switches {
+			for _, node := range nodeNames(sw.Nodes) {
+				index[node] = &NodeLocation{Topology: topology.TopologyTree, Switch: sw.Switch}
+			}
+		}
+	}
+	if unit.Block != nil {
+		for _, block := range unit.Block.Blocks {
+			for _, node := range nodeNames(block.Nodes) {
+				index[node] = &NodeLocation{Topology: topology.TopologyBlock, Block: block.Block}
+			}
+		}
+	}
+	if unit.Flat != nil {
+		for _, node := range nodeNames(unit.Flat.Nodes) {
+			index[node] = &NodeLocation{Topology: topology.TopologyFlat, Switch: FlatSwitchName}
+		}
+	}
+	return index
+}
+
+// AddAccelerators sets the accelerator domains from the block topology of the graph,
+// if the topology config uses the topology/tree plugin
+func (unit *TopologyUnit) AddAccelerators(root *topology.Vertex) {
+	blockRoot, ok := root.Vertices[topology.TopologyBlock]
+	if unit.Tree == nil || !ok {
+		return
+	}
+
+	unit.Accelerators = make(map[string]string)
+	for _, block := range blockRoot.Vertices {
+		nodes := make([]string, 0, len(block.Vertices))
+		for _, node := range block.Vertices {
+			nodes = append(nodes, node.Name)
+		}
+		sort.Strings(nodes)
+		unit.Accelerators[block.ID] = strings.Join(compress(nodes), ",")
+	}
+}
+
+// acceleratorComments returns the comment lines mapping the nodes to their accelerator domains
+func acceleratorComments(nodes []string, nodeDomain map[string]string) string {
+	domainNodes := make(map[string][]string)
+	for _, node := range nodes {
+		if domain, ok := nodeDomain[node]; ok {
+			domainNodes[domain] = append(domainNodes[domain], node)
+		}
+	}
+
+	domains := make([]string, 0, len(domainNodes))
+	for domain := range domainNodes {
+		domains = append(domains, domain)
+	}
+	sort.Strings(domains)
+
+	var sb strings.Builder
+	for _, domain := range domains {
+		sb.WriteString(fmt.Sprintf("# nvlink-domain %s: %s\n", domain, strings.Join(compress(domainNodes[domain]), ",")))
+	}
+	return sb.String()
+}
+
+// FlatTopo is the topology config for the topology/flat plugin.
+// Nodes is set if all nodes are placed under a single switch. The nodes are
+// the tree leaves or the block members, whichever the topology has.
+type FlatTopo struct {
+	Nodes string `json:"nodes,omitempty"`
+}
+
+// rendering modes of the topology/flat plugin
+const (
+	FlatModeEmpty  = "empty"
+	FlatModeSwitch = "switch"
+
+	FlatSwitchName = "flat"
+)
+
+// modes of the comment lines mapping the switch and block names to the original IDs
+const (
+	// CommentsFull shows the original IDs (default)
+	CommentsFull = "full"
+	// CommentsNone omits the comment lines
+	CommentsNone = "none"
+	// CommentsHash shows a short hash of the original IDs
+	CommentsHash = "hash"
+)
+
+// ValidateComments returns an error if the comment mode is not supported
+func ValidateComments(mode string) error {
+	switch mode {
+	case "", CommentsFull, CommentsNone, CommentsHash:
+		return nil
+	default:
+		return fmt.Errorf("unsupported comments mode %q", mode)
+	}
+}
+
+// commentID returns the original ID as shown by the comment lines of the given mode
+func commentID(id, mode string) string {
+	if len(id) == 0 {
+		return ""
+	}
+	switch mode {
+	case CommentsNone:
+		return ""
+	case CommentsHash:
+		return shortHash(id)
+	default:
+		return id
+	}
+}
+
+// Write writes the topology config. It stops and returns the context error if the context is cancelled.
+func Write(ctx context.Context, wr io.Writer, root *topology.Vertex) error {
+	return WriteFormat(ctx, wr, root, FormatConf)
+}
+
+// WriteFormat writes the topology config in the given format: "conf" (default), "json", "yaml", "hostlist", "matrix"
+// or "labels". The matrix output is capped at DefaultMaxMatrixNodes nodes.
+func WriteFormat(ctx context.Context, wr io.Writer, root *topology.Vertex, format string) error {
+	switch format {
+	case "", FormatConf, FormatJSON, FormatYAML, FormatHostlist:
+	case FormatMatrix:
+		return WriteMatrix(ctx, wr, root, DefaultMaxMatrixNodes)
+	case FormatLabels:
+		return WriteNodeLabels(ctx, wr, root)
+	default:
+		return fmt.Errorf("unsupported topology format %q", format)
+	}
+
+	unit, err := ToTopologyUnit(ctx, root)
+	if err != nil {
+		return err
+	}
+
+	if format == FormatHostlist {
+		return unit.WriteHostlist(ctx, wr, root)
+	}
+	return unit.Write(ctx, wr, format)
+}
+
+// Write writes the topology config in the given format: "conf" (default), "json", "yaml" or "hostlist".
+// The YAML output uses the default schema dialect. The hostlist output has no unassigned nodes.
+func (unit *TopologyUnit) Write(ctx context.Context, wr io.Writer, format string) error {
+	switch format {
+	case "", FormatConf:
+		return unit.toConfTopology(ctx, wr)
+	case FormatJSON:
+		return unit.toJSONTopology(ctx, wr)
+	case FormatYAML:
+		return unit.WriteYAML(ctx, wr, DefaultYAMLSchema)
+	case FormatHostlist:
+		return unit.WriteHostlist(ctx, wr, nil)
+	default:
+		return fmt.Errorf("unsupported topology format %q", format)
+	}
+}
+
+// ToTopologyUnit converts the topology graph into the topology config structures
+func ToTopologyUnit(ctx context.Context, root *topology.Vertex) (*TopologyUnit, error) {
 	var plugin string
 
 	if len(root.Metadata) != 0 {
 		plugin = root.Metadata[topology.KeyPlugin]
 	}
 
+	if plugin == topology.TopologyFlat {
+		return toFlatTopology(ctx, root)
+	}
+
+	if err := resolveDuplicateNodes(root, plugin, root.Metadata[topology.KeyDuplicateNodes]); err != nil {
+		return nil, err
+	}
+
 	if plugin == topology.TopologyBlock {
-		return toBlockTopology(wr, root)
+		block, err := toBlockTopology(ctx, root)
+		if err != nil {
+			return nil, err
+		}
+		return &TopologyUnit{Block: block}, nil
 	}
 
-	return toTreeTopology(wr, root.Vertices[topology.TopologyTree])
+	tree, err := toTreeTopology(ctx, root.Vertices[topology.TopologyTree], root.Metadata[topology.KeyComments])
+	if err != nil {
+		return nil, err
+	}
+	return &TopologyUnit{Tree: tree}, nil
 }
 
-func printBlock(wr io.Writer, block *topology.Vertex, domainVisited map[string]int) error {
-	if _, exists := domainVisited[block.ID]; !exists {
-		nodes := make([]string, 0, len(block.Vertices))
-		for _, node := range block.Vertices { //nodes within each domain
-			nodes = append(nodes, node.Name)
+func (unit *TopologyUnit) toJSONTopology(ctx context.Context, wr io.Writer) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	enc := json.NewEncoder(wr)
+	enc.SetIndent("", "  ")
+	return enc.Encode(unit)
+}
+
+func (unit *TopologyUnit) toConfTopology(ctx context.Context, wr io.Writer) error {
+	if err := unit.toConfConfig(ctx, wr); err != nil {
+		return err
+	}
+	if unit.NodeIndex == nil {
+		return nil
+	}
+	return unit.toConfNodeIndex(ctx, wr)
+}
+
+// toConfNodeIndex writes the node index as comments, so that Slurm ignores it
+func (unit *TopologyUnit) toConfNodeIndex(ctx context.Context, wr io.Writer) error {
+	nodes := make([]string, 0, len(unit.NodeIndex))
+	for node := range unit.NodeIndex {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+
+	if _, err := wr.Write([]byte("# Node index (informational)\n")); err != nil {
+		return err
+	}
+	for _, node := range nodes {
+		if err := ctx.Err(); err != nil {
+			return err
 		}
-		var comment string
-		if len(block.Name) != 0 {
-			comment = fmt.Sprintf("# %s=%s\n", block.ID, block.Name)
+		loc := unit.NodeIndex[node]
+		line := fmt.Sprintf("# %s: topology=%s", node, loc.Topology)
+		if len(loc.Block) != 0 {
+			line += " block=" + loc.Block
 		}
-		_, err := wr.Write([]byte(fmt.Sprintf("%sBlockName=%s Nodes=%s\n", comment, block.ID, strings.Join(compress(nodes), ","))))
-		if err != nil {
+		if len(loc.Switch) != 0 {
+			line += " switch=" + loc.Switch
+		}
+		if _, err := wr.Write([]byte(line + "\n")); err != nil {
 			return err
 		}
-		domainVisited[block.ID] = len(nodes)
 	}
 	return nil
 }
 
-func findBlock(wr io.Writer, nodename string, root *topology.Vertex, domainVisited map[string]int) error { // blockRoot
-	for _, block := range root.Vertices {
-		if _, exists := block.Vertices[nodename]; exists {
-			return printBlock(wr, block, domainVisited)
+func (unit *TopologyUnit) toConfConfig(ctx context.Context, wr io.Writer) error {
+	if unit.Flat != nil {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		var line string
+		if len(unit.Flat.Nodes) != 0 {
+			line = fmt.Sprintf("SwitchName=%s Nodes=%s\n", FlatSwitchName, unit.Flat.Nodes)
+		} else {
+			line = fmt.Sprintf("# %s: network topology is not used\n", topology.TopologyFlat)
+		}
+		_, err := wr.Write([]byte(line))
+		return err
+	}
+
+	if unit.Block != nil {
+		for _, block := range unit.Block.Blocks {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			var comment string
+			if len(block.Name) != 0 {
+				if len(block.DisplayName) != 0 {
+					comment = fmt.Sprintf("# %s=%s (%s)\n", block.Block, block.Name, block.DisplayName)
+				} else {
+					comment = fmt.Sprintf("# %s=%s\n", block.Block, block.Name)
+				}
+			}
+			if _, err := wr.Write([]byte(fmt.Sprintf("%sBlockName=%s Nodes=%s\n", comment, block.Block, block.Nodes))); err != nil {
+				return err
+			}
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		sizes := make([]string, 0, len(unit.Block.BlockSizes))
+		for _, size := range unit.Block.BlockSizes {
+			sizes = append(sizes, strconv.Itoa(size))
+		}
+		var comment string
+		switch unit.Block.BlockSizesSource {
+		case BlockSizesSourceHint:
+			comment = "# BlockSizes derived from block_size_hint\n"
+		case BlockSizesSourceFallback:
+			comment = "# BlockSizes derived from domain size; block_size_hint does not fit\n"
+		case BlockSizesSourceExpected:
+			comment = "# BlockSizes derived from expected_block_size\n"
+		}
+		_, err := wr.Write([]byte(fmt.Sprintf("%sBlockSizes=%s\n", comment, strings.Join(sizes, ","))))
+		return err
+	}
+
+	if unit.Tree != nil {
+		nodeDomain := make(map[string]string)
+		for domain, nodes := range unit.Accelerators {
+			for _, node := range nodeNames(nodes) {
+				nodeDomain[node] = domain
+			}
+		}
+		for _, sw := range unit.Tree.Switches {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			var comment string
+			if len(sw.ID) != 0 {
+				comment = fmt.Sprintf("# %s=%s\n", sw.Switch, sw.ID)
+			}
+			var line string
+			if len(sw.Nodes) != 0 {
+				line = fmt.Sprintf("%sSwitchName=%s Nodes=%s\n", comment, sw.Switch, sw.Nodes)
+				if len(nodeDomain) != 0 {
+					line += acceleratorComments(nodeNames(sw.Nodes), nodeDomain)
+				}
+			} else {
+				line = fmt.Sprintf("%sSwitchName=%s Switches=%s\n", comment, sw.Switch, sw.Children)
+			}
+			if _, err := wr.Write([]byte(line)); err != nil {
+				return fmt.Errorf("failed to write switch %s: %w", sw.Switch, err)
+			}
 		}
 	}
+
 	return nil
 }
 
+func toFlatTopology(ctx context.Context, root *topology.Vertex) (*TopologyUnit, error) {
+	flat := &FlatTopo{}
+
+	switch mode := root.Metadata[topology.KeyFlatMode]; mode {
+	case "", FlatModeEmpty:
+	case FlatModeSwitch:
+		nodes := make(map[string]bool)
+		for _, v := range root.Vertices {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			collectNodes(v, nodes)
+		}
+		if len(nodes) == 0 {
+			return nil, fmt.Errorf("no nodes for the %s switch of the %s mode", FlatSwitchName, FlatModeSwitch)
+		}
+		names := make([]string, 0, len(nodes))
+		for name := range nodes {
+			names = append(names, name)
+		}
+		flat.Nodes = strings.Join(compress(names), ",")
+	default:
+		return nil, fmt.Errorf("unsupported flat mode %q", mode)
+	}
+
+	return &TopologyUnit{Flat: flat}, nil
+}
+
+// collectNodes adds the names of the compute nodes (leaf vertices) under the vertex
+func collectNodes(v *topology.Vertex, nodes map[string]bool) {
+	if len(v.Vertices) == 0 {
+		if len(v.Name) != 0 {
+			nodes[v.Name] = true
+		}
+		return
+	}
+	for _, w := range v.Vertices {
+		collectNodes(w, nodes)
+	}
+}
+
+func newBlock(block *topology.Vertex) *Block {
+	nodes := make([]string, 0, len(block.Vertices))
+	for _, node := range block.Vertices { //nodes within each domain
+		nodes = append(nodes, node.Name)
+	}
+	// compress keeps the input order of the names without numerical suffix
+	sort.Strings(nodes)
+	name, original := block.ID, block.Name
+	if sanitized, ok := slurmName(block.ID); ok {
+		name = sanitized
+		if len(original) == 0 {
+			original = block.ID
+		}
+	}
+	return &Block{
+		Block:       name,
+		Name:        original,
+		DisplayName: block.Metadata[topology.KeyDisplayName],
+		Nodes:       strings.Join(compress(nodes), ","),
+	}
+}
+
+func addBlock(topo *BlockTopo, block *topology.Vertex, domainVisited map[string]int) {
+	if _, exists := domainVisited[block.ID]; !exists {
+		topo.Blocks = append(topo.Blocks, newBlock(block))
+		domainVisited[block.ID] = len(block.Vertices)
+	}
+}
+
+// blockIndex maps the nodes to their blocks. A node listed in several blocks is mapped
+// to the block with the smallest ID, so that the result does not depend on the map iteration.
+func blockIndex(blockRoot *topology.Vertex) map[string]*topology.Vertex {
+	index := make(map[string]*topology.Vertex)
+	if blockRoot == nil {
+		return index
+	}
+	for _, key := range sortVertices(blockRoot) {
+		block := blockRoot.Vertices[key]
+		for nodename := range block.Vertices {
+			if _, ok := index[nodename]; !ok {
+				index[nodename] = block
+			}
+		}
+	}
+	return index
+}
+
 func sortVertices(root *topology.Vertex) []string {
 	// sort the IDs
 	keys := make([]string, 0, len(root.Vertices))
@@ -82,86 +565,223 @@ func sortVertices(root *topology.Vertex) []string {
 	return keys
 }
 
-func printDisconnectedBlocks(wr io.Writer, root *topology.Vertex, domainVisited map[string]int) error {
+func addDisconnectedBlocks(ctx context.Context, topo *BlockTopo, root *topology.Vertex, domainVisited map[string]int) error {
 	if root != nil {
 		keys := sortVertices(root)
 		for _, key := range keys {
-			block := root.Vertices[key]
-			err := printBlock(wr, block, domainVisited)
-			if err != nil {
+			if err := ctx.Err(); err != nil {
 				return err
 			}
+			addBlock(topo, root.Vertices[key], domainVisited)
 		}
 	}
 	return nil
 }
 
-func getBlockSize(domainVisited map[string]int, adminBlockSize string) string {
-	minDomainSize := -1
+func parseBlockSizes(blockSizes string) ([]int, error) {
+	arr := strings.Split(blockSizes, ",")
+	sizes := make([]int, 0, len(arr))
+	for _, str := range arr {
+		size, err := strconv.Atoi(strings.TrimSpace(str))
+		if err != nil {
+			return nil, err
+		}
+		sizes = append(sizes, size)
+	}
+	return sizes, nil
+}
+
+// planningDomainSize returns the domain size the block sizes are planned for, according to the strategy.
+// With the median and histogram strategies, the domains smaller than the returned size
+// cannot hold a base block and are left to the planning overflow.
+func planningDomainSize(domainVisited map[string]int, strategy string) int {
+	if len(domainVisited) == 0 {
+		return -1
+	}
+
+	sizes := make([]int, 0, len(domainVisited))
 	for _, dSize := range domainVisited {
-		if minDomainSize == -1 || minDomainSize > dSize {
-			minDomainSize = dSize
+		sizes = append(sizes, dSize)
+	}
+	sort.Ints(sizes)
+
+	var size int
+	switch strategy {
+	case "", BlockSizeStrategyMin:
+		return sizes[0]
+	case BlockSizeStrategyMedian:
+		// the lower median, so that at least half of the domains fit
+		size = sizes[(len(sizes)-1)/2]
+	case BlockSizeStrategyHistogram:
+		// the most common size; the larger size wins a tie
+		counts := make(map[int]int)
+		for _, dSize := range sizes {
+			counts[dSize]++
+			if counts[dSize] >= counts[size] {
+				size = dSize
+			}
 		}
+	default:
+		metrics.AddValidationError("bad block size strategy")
+		klog.Warningf("Unsupported block size strategy %q. Using %q.", strategy, BlockSizeStrategyMin)
+		return sizes[0]
 	}
+
+	if undersized := sort.SearchInts(sizes, size); undersized != 0 {
+		klog.Infof("Block size strategy %q: planning for domain size %d; %d smaller domain(s) left to the planning overflow",
+			strategy, size, undersized)
+	}
+	return size
+}
+
+// getBlockSize returns the block sizes and, if the block size hint is given, the source of the block sizes.
+// The admin block sizes take precedence. Otherwise, the base block size is the largest hint value
+// not exceeding the planning domain size, doubled while it fits the domain. If no hint value fits,
+// the base block size is the largest power of 2 not exceeding the planning domain size.
+// The planning domain size is selected by the strategy; it is the minimum domain size by default.
+func getBlockSize(domainVisited map[string]int, adminBlockSize, blockSizeHint, strategy string) ([]int, string) {
+	minDomainSize := planningDomainSize(domainVisited, strategy)
 	if adminBlockSize != "" {
-		blockSizes := strings.Split(adminBlockSize, ",")
-		planningBS, err := strconv.Atoi(blockSizes[0])
+		blockSizes, err := parseBlockSizes(adminBlockSize)
 		if err != nil {
 			metrics.AddValidationError("block size parsing error")
-			klog.Warningf("Failed to parse blockSize %v: %v. Ignoring.", blockSizes[0], err)
+			klog.Warningf("Failed to parse blockSize %v: %v. Ignoring.", adminBlockSize, err)
 		} else {
+			planningBS := blockSizes[0]
 			if planningBS > 0 && planningBS <= minDomainSize {
-				return adminBlockSize
+				return blockSizes, ""
 			}
 			metrics.AddValidationError("bad block domain size")
 			klog.Warningf("Overriden planning blockSize of %v does not meet criteria, minimum domain size %v. Ignoring.", planningBS, minDomainSize)
 		}
 	}
+
+	var source string
+	if blockSizeHint != "" {
+		source = BlockSizesSourceFallback
+		hints, err := parseBlockSizes(blockSizeHint)
+		if err != nil {
+			metrics.AddValidationError("block size hint parsing error")
+			klog.Warningf("Failed to parse block size hint %v: %v. Ignoring.", blockSizeHint, err)
+		} else {
+			base := 0
+			for _, hint := range hints {
+				if hint > base && hint <= minDomainSize {
+					base = hint
+				}
+			}
+			if base > 0 {
+				blockSizes := []int{}
+				for bs := base; bs <= minDomainSize; bs *= 2 {
+					blockSizes = append(blockSizes, bs)
+				}
+				klog.Infof("Using block size hint %d for minimum domain size %d", base, minDomainSize)
+				return blockSizes, BlockSizesSourceHint
+			}
+			klog.Infof("Block size hint %v does not fit minimum domain size %d. Ignoring.", blockSizeHint, minDomainSize)
+		}
+	}
+
 	logDsize := math.Log2(float64(minDomainSize))
 	bs := math.Pow(2, float64(int(logDsize)))
-	return strconv.Itoa(int(bs))
+	return []int{int(bs)}, source
 }
 
-func toBlockTopology(wr io.Writer, root *topology.Vertex) error {
-	// traverse tree topology in DFS manner and when a node is reached, check within blockRoot for domain and print that domain.
-	// keep a map of which domain has been printed
+// expectedBlockSizes returns the block sizes planned for the expected sizes of the blocks, set by the providers
+// for partially populated accelerator domains, e.g. 18 for a GB200 NVL72 rack. The base block size is the
+// expected size selected by the strategy, doubled while it fits the expected size of all blocks.
+// It returns nil if a block has no expected size, so that the block sizes are derived from the observed domain sizes.
+func expectedBlockSizes(blockRoot *topology.Vertex, domainVisited map[string]int, strategy string) []int {
+	if blockRoot == nil || len(domainVisited) == 0 {
+		return nil
+	}
+
+	expected := make(map[string]int, len(domainVisited))
+	for _, block := range blockRoot.Vertices {
+		observed, ok := domainVisited[block.ID]
+		if !ok {
+			continue
+		}
+		size, err := strconv.Atoi(block.Metadata[topology.KeyExpectedBlockSize])
+		if err != nil || size <= 0 {
+			klog.Infof("Block %s has no expected block size; using the observed domain sizes", block.ID)
+			return nil
+		}
+		if observed > size {
+			metrics.AddValidationError("bad expected block size")
+			klog.Warningf("Block %s has %d nodes, more than the expected block size %d", block.ID, observed, size)
+			size = observed
+		}
+		expected[block.ID] = size
+	}
+
+	base := planningDomainSize(expected, strategy)
+	blockSizes := []int{}
+	for bs := base; bs <= base*len(expected); bs *= 2 {
+		blockSizes = append(blockSizes, bs)
+	}
+	klog.Infof("Using expected block size %d for %d blocks", base, len(expected))
+	return blockSizes
+}
+
+func toBlockTopology(ctx context.Context, root *topology.Vertex) (*BlockTopo, error) {
+	// traverse tree topology in DFS manner and when a node is reached, check within blockRoot for domain and add that domain.
+	// keep a map of which domain has been added
 	treeRoot := root.Vertices[topology.TopologyTree]
 	blockRoot := root.Vertices[topology.TopologyBlock]
 	visited := make(map[string]bool)
 	domainVisited := make(map[string]int)
+	topo := &BlockTopo{}
 
 	if treeRoot != nil {
-		err := dfsTraversal(wr, treeRoot, blockRoot, visited, domainVisited)
+		err := dfsTraversal(ctx, topo, treeRoot, blockIndex(blockRoot), visited, domainVisited)
 		if err != nil {
-			return err
+			return nil, err
 		}
 	}
-	err := printDisconnectedBlocks(wr, blockRoot, domainVisited)
+	err := addDisconnectedBlocks(ctx, topo, blockRoot, domainVisited)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	blockSize := ""
 	if _, exists := root.Metadata[topology.KeyBlockSizes]; exists {
 		blockSize = root.Metadata[topology.KeyBlockSizes]
 	}
-	blockSize = getBlockSize(domainVisited, blockSize)
-	_, err = wr.Write([]byte(fmt.Sprintf("BlockSizes=%s\n", blockSize)))
-	return err
+	strategy := root.Metadata[topology.KeyBlockSizeStrategy]
+	if root.Metadata[topology.KeyExpectedBlockSizes] == "true" && len(blockSize) == 0 {
+		if sizes := expectedBlockSizes(blockRoot, domainVisited, strategy); sizes != nil {
+			topo.BlockSizes, topo.BlockSizesSource = sizes, BlockSizesSourceExpected
+		}
+	}
+	if topo.BlockSizes == nil {
+		topo.BlockSizes, topo.BlockSizesSource = getBlockSize(domainVisited, blockSize, root.Metadata[topology.KeyBlockSizeHint], strategy)
+	}
+
+	comments := root.Metadata[topology.KeyComments]
+	for _, block := range topo.Blocks {
+		block.Name = commentID(block.Name, comments)
+		if comments == CommentsNone {
+			block.DisplayName = ""
+		}
+	}
+	return topo, nil
 }
 
-func dfsTraversal(wr io.Writer, curVertex *topology.Vertex, blockRoot *topology.Vertex, visited map[string]bool, domainVisited map[string]int) error {
+func dfsTraversal(ctx context.Context, topo *BlockTopo, curVertex *topology.Vertex, nodeBlocks map[string]*topology.Vertex, visited map[string]bool, domainVisited map[string]int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 	visited[curVertex.ID] = true
 	keys := sortVertices(curVertex)
 	for _, key := range keys {
 		w := curVertex.Vertices[key]
 		if len(w.Vertices) == 0 { // it's a leaf; don't add to queue
-			err := findBlock(wr, w.ID, blockRoot, domainVisited)
-			if err != nil {
-				return err
+			if block, ok := nodeBlocks[w.ID]; ok {
+				addBlock(topo, block, domainVisited)
 			}
 		} else {
 			if !visited[w.ID] {
-				err := dfsTraversal(wr, w, blockRoot, visited, domainVisited)
+				err := dfsTraversal(ctx, topo, w, nodeBlocks, visited, domainVisited)
 				if err != nil {
 					return err
 				}
@@ -171,14 +791,24 @@ func dfsTraversal(wr io.Writer, curVertex *topology.Vertex, blockRoot *topology.
 	return nil
 }
 
-func toTreeTopology(wr io.Writer, root *topology.Vertex) error {
+// toTreeTopology returns the tree topology config, where the original switch IDs are shown as set by the comments mode
+func toTreeTopology(ctx context.Context, root *topology.Vertex, comments string) (*TreeTopo, error) {
 	visited := make(map[string]bool)
 	leaves := make(map[string][]string)
 	parents := []*topology.Vertex{}
 	queue := []*topology.Vertex{root}
 	idToName := make(map[string]string)
+	topo := &TreeTopo{}
+
+	// switches without compute nodes beneath, e.g. left by a scoped node list, are pruned,
+	// so that every switch lists either its nodes or its relevant children
+	withNodes := make(map[*topology.Vertex]bool)
+	hasNodes(root, withNodes)
 
 	for len(queue) > 0 {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
 		v := queue[0]
 		queue = queue[1:]
 		if len(v.ID) != 0 {
@@ -186,16 +816,24 @@ func toTreeTopology(wr io.Writer, root *topology.Vertex) error {
 		}
 		idToName[v.ID] = v.Name
 
+		// the nodes of a switch that also has child switches, e.g. attached to the spine
+		// by the parent_to_spine policy, are moved to a leaf switch beneath it,
+		// since a Slurm switch lists either its nodes or its child switches
+		leafID := v.ID
+		if len(v.ID) != 0 && isMixedSwitch(v, withNodes) {
+			leafID = leafSwitchID(v.ID)
+			idToName[leafID] = leafSwitchName(v.Name)
+		}
+
 		// sort the IDs
 		keys := sortVertices(v)
 		for _, key := range keys {
 			w := v.Vertices[key]
+			if !withNodes[w] {
+				continue
+			}
 			if len(w.Vertices) == 0 { // it's a leaf; don't add to queue
-				_, ok := leaves[v.ID]
-				if !ok {
-					leaves[v.ID] = []string{}
-				}
-				leaves[v.ID] = append(leaves[v.ID], w.Name)
+				leaves[leafID] = append(leaves[leafID], w.Name)
 			} else if !visited[w.ID] {
 				queue = append(queue, w)
 				visited[w.ID] = true
@@ -204,11 +842,11 @@ func toTreeTopology(wr io.Writer, root *topology.Vertex) error {
 	}
 
 	for _, sw := range parents {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
 		if _, ok := leaves[sw.ID]; !ok {
-			err := writeSwitch(wr, sw)
-			if err != nil {
-				return fmt.Errorf("failed to write switch %s: %w", sw.ID, err)
-			}
+			topo.Switches = append(topo.Switches, newSwitch(sw, withNodes))
 		}
 	}
 
@@ -218,298 +856,106 @@ func toTreeTopology(wr io.Writer, root *topology.Vertex) error {
 		ids = append(ids, id)
 	}
 	sort.Strings(ids)
-	var comment, switchName string
-	for _, sw := range ids {
-		nodes := leaves[sw]
-		if idToName[sw] != "" {
-			comment = fmt.Sprintf("# %s=%s\n", idToName[sw], sw)
-			switchName = idToName[sw]
-		} else {
-			comment = ""
-			switchName = sw
-		}
-		_, err := wr.Write([]byte(fmt.Sprintf("%sSwitchName=%s Nodes=%s\n", comment, switchName, strings.Join(compress(nodes), ","))))
-		if err != nil {
-			return err
+	for _, id := range ids {
+		if err := ctx.Err(); err != nil {
+			return nil, err
 		}
+		sw := &Switch{Nodes: strings.Join(compress(leaves[id]), ",")}
+		sw.Switch, sw.ID = switchName(id, idToName[id])
+		topo.Switches = append(topo.Switches, sw)
 	}
 
-	return nil
-}
-
-func writeSwitch(wr io.Writer, v *topology.Vertex) error {
-	if len(v.ID) == 0 {
-		return nil
+	for _, sw := range topo.Switches {
+		sw.ID = commentID(sw.ID, comments)
 	}
 
-	arr := make([]string, 0, len(v.Vertices))
-	for _, node := range v.Vertices {
-		if node.Name == "" {
-			arr = append(arr, node.ID)
-		} else {
-			arr = append(arr, node.Name)
-		}
-	}
-	var comment string
-	if v.Name == "" {
-		comment = ""
-		v.Name = v.ID
-	} else {
-		comment = fmt.Sprintf("# %s=%s\n", v.Name, v.ID)
-	}
-	_, err := wr.Write([]byte(fmt.Sprintf("%sSwitchName=%s Switches=%s\n", comment, v.Name, strings.Join(compress(arr), ","))))
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return topo, nil
 }
 
-// compress finds contiguos numerical suffixes in names and presents then as ranges.
-// example: ["eos0507", "eos0509", "eos0508"] -> ["eos0[507-509"]
-func compress(input []string) []string {
-	ret := []string{}
-	keys := []string{}
-	m := make(map[string][]int) // map of prefix : array of numerical suffix
-	for _, name := range input {
-		if prefix, suffix := split(name); len(suffix) == 0 {
-			ret = append(ret, name)
-		} else {
-			num, _ := strconv.Atoi(suffix)
-			if arr, ok := m[prefix]; !ok {
-				m[prefix] = []int{num}
-			} else {
-				m[prefix] = append(arr, num)
-			}
-		}
+// hasNodes returns true if there are compute nodes under the vertex, recording the result for each vertex.
+// A vertex without children is a compute node if it has a name, and an empty switch otherwise.
+func hasNodes(v *topology.Vertex, withNodes map[*topology.Vertex]bool) bool {
+	if ok, visited := withNodes[v]; visited {
+		return ok
 	}
 
-	// we sort the prefix to get consistent output for tests
-	for prefix := range m {
-		keys = append(keys, prefix)
+	ok := len(v.Vertices) == 0 && len(v.Name) != 0
+	for _, w := range v.Vertices {
+		if hasNodes(w, withNodes) {
+			ok = true
+		}
 	}
-	sort.Strings(keys)
+	withNodes[v] = ok
+	return ok
+}
 
-	for _, prefix := range keys {
-		arr := m[prefix]
-		sort.Ints(arr)
-		var start, end int
-		for i, num := range arr {
-			if i == 0 {
-				start = num
-				end = num
-			} else if num == end+1 {
-				end = num
-			} else if start == end {
-				ret = append(ret, fmt.Sprintf("%s%d", prefix, start))
-				start = num
-				end = num
-			} else {
-				ret = append(ret, fmt.Sprintf("%s[%d-%d]", prefix, start, end))
-				start = num
-				end = num
-			}
+// isMixedSwitch returns true if the switch has both compute nodes and child switches with nodes beneath
+func isMixedSwitch(v *topology.Vertex, withNodes map[*topology.Vertex]bool) bool {
+	var nodes, switches bool
+	for _, w := range v.Vertices {
+		if !withNodes[w] {
+			continue
 		}
-		if start == end {
-			ret = append(ret, fmt.Sprintf("%s%d", prefix, end))
+		if len(w.Vertices) == 0 {
+			nodes = true
 		} else {
-			ret = append(ret, fmt.Sprintf("%s[%d-%d]", prefix, start, end))
+			switches = true
 		}
 	}
+	return nodes && switches
+}
 
-	return ret
+// leafSwitchID returns the ID of the leaf switch holding the compute nodes of a mixed switch
+func leafSwitchID(id string) string {
+	return id + "_nodes"
 }
 
-// split divides a string into a prefix and a numerical suffix
-func split(input string) (string, string) {
-	n := len(input)
-	if n == 0 {
-		return input, ""
+// leafSwitchName returns the name of the leaf switch holding the compute nodes of a mixed switch
+func leafSwitchName(name string) string {
+	if len(name) == 0 {
+		return ""
 	}
+	return name + "_nodes"
+}
 
-	// find numerical suffix
-	i := n - 1
-	for i >= 0 {
-		if input[i] >= '0' && input[i] <= '9' {
-			i--
-		} else {
-			break
+// newSwitch returns the switch listing the children with compute nodes beneath.
+// The compute nodes of a mixed switch are represented by its leaf switch.
+func newSwitch(v *topology.Vertex, withNodes map[*topology.Vertex]bool) *Switch {
+	arr := make([]string, 0, len(v.Vertices))
+	var leaf bool
+	for _, node := range v.Vertices {
+		if !withNodes[node] {
+			continue
 		}
-	}
-	i++
-
-	// ignore leading zeros
-	for i < n {
-		if input[i] == '0' {
-			i++
-		} else {
-			break
+		if len(node.Vertices) == 0 {
+			leaf = true
+			continue
 		}
+		name, _ := switchName(node.ID, node.Name)
+		arr = append(arr, name)
 	}
-
-	if i == n { // no suffix
-		return input, ""
+	if leaf {
+		name, _ := switchName(leafSwitchID(v.ID), leafSwitchName(v.Name))
+		arr = append(arr, name)
 	}
 
-	return input[:i], input[i:]
+	sw := &Switch{Children: strings.Join(compress(arr), ",")}
+	sw.Switch, sw.ID = switchName(v.ID, v.Name)
+	return sw
 }
 
-func GetTreeTestSet(testForLongLabelName bool) (*topology.Vertex, map[string]string) {
-	//
-	//        S1
-	//      /    \
-	//    S2      S3
-	//    |       |
-	//   ---     ---
-	//   I14     I21
-	//   I15     I22
-	//   I16     I25
-	//   ---     ---
-	//
-	var s3name string
-	if testForLongLabelName {
-		s3name = "S3very-very-long-id-to-check-label-value-limits-of-63-characters"
-	} else {
-		s3name = "S3"
-	}
-
-	instance2node := map[string]string{
-		"I21": "Node201", "I22": "Node202", "I25": "Node205",
-		"I34": "Node304", "I35": "Node305", "I36": "Node306",
-	}
-
-	n21 := &topology.Vertex{ID: "I21", Name: "Node201"}
-	n22 := &topology.Vertex{ID: "I22", Name: "Node202"}
-	n25 := &topology.Vertex{ID: "I25", Name: "Node205"}
-
-	n34 := &topology.Vertex{ID: "I34", Name: "Node304"}
-	n35 := &topology.Vertex{ID: "I35", Name: "Node305"}
-	n36 := &topology.Vertex{ID: "I36", Name: "Node306"}
-
-	sw2 := &topology.Vertex{
-		ID:       "S2",
-		Vertices: map[string]*topology.Vertex{"I21": n21, "I22": n22, "I25": n25},
-	}
-	sw3 := &topology.Vertex{
-		ID:       s3name,
-		Vertices: map[string]*topology.Vertex{"I34": n34, "I35": n35, "I36": n36},
-	}
-	sw1 := &topology.Vertex{
-		ID:       "S1",
-		Vertices: map[string]*topology.Vertex{"S2": sw2, s3name: sw3},
-	}
-	treeRoot := &topology.Vertex{
-		Vertices: map[string]*topology.Vertex{"S1": sw1},
-	}
-
-	root := &topology.Vertex{
-		Vertices: map[string]*topology.Vertex{topology.TopologyTree: treeRoot},
-	}
-	return root, instance2node
+// compress presents contiguous numerical fields of the names as ranges, preserving their zero padding.
+// example: ["eos0507", "eos0509", "eos0508"] -> ["eos[0507-0509]"]
+func compress(input []string) []string {
+	return cluset.Compact(input)
 }
 
-func GetBlockWithMultiIBTestSet() (*topology.Vertex, map[string]string) {
-	//
-	//     ibRoot2        ibRoot1
-	//        |               |
-	//        S1              S4
-	//      /    \          /    \
-	//    S2      S3      S5      S6
-	//    |       |       |       |
-	//   ---     ---     ---     ---
-	//   I14\    I21\    I31\    I41\
-	//   I15-B1  I22-B2  I32-B3  I42-B4
-	//   I16/    I25/     I33/   I43/
-	//   ---     ---      ---    ---
-	//
-	instance2node := map[string]string{
-		"I14": "Node104", "I15": "Node105", "I16": "Node106",
-		"I21": "Node201", "I22": "Node202", "I25": "Node205",
-		"I31": "Node301", "I32": "Node302", "I33": "Node303",
-		"I41": "Node401", "I42": "Node402", "I43": "Node403",
-	}
-
-	n14 := &topology.Vertex{ID: "I14", Name: "Node104"}
-	n15 := &topology.Vertex{ID: "I15", Name: "Node105"}
-	n16 := &topology.Vertex{ID: "I16", Name: "Node106"}
-
-	n21 := &topology.Vertex{ID: "I21", Name: "Node201"}
-	n22 := &topology.Vertex{ID: "I22", Name: "Node202"}
-	n25 := &topology.Vertex{ID: "I25", Name: "Node205"}
-
-	n31 := &topology.Vertex{ID: "I31", Name: "Node301"}
-	n32 := &topology.Vertex{ID: "I32", Name: "Node302"}
-	n33 := &topology.Vertex{ID: "I33", Name: "Node303"}
-
-	n41 := &topology.Vertex{ID: "I41", Name: "Node401"}
-	n42 := &topology.Vertex{ID: "I42", Name: "Node402"}
-	n43 := &topology.Vertex{ID: "I43", Name: "Node403"}
-
-	sw5 := &topology.Vertex{
-		ID:       "S5",
-		Vertices: map[string]*topology.Vertex{"I31": n31, "I32": n32, "I33": n33},
-	}
-	sw6 := &topology.Vertex{
-		ID:       "S6",
-		Vertices: map[string]*topology.Vertex{"I41": n41, "I42": n42, "I43": n43},
-	}
-	sw4 := &topology.Vertex{
-		ID:       "S4",
-		Vertices: map[string]*topology.Vertex{"S5": sw5, "S6": sw6},
-	}
-	ibRoot1 := &topology.Vertex{
-		ID:       "ibRoot1",
-		Vertices: map[string]*topology.Vertex{"S4": sw4},
-	}
-
-	sw2 := &topology.Vertex{
-		ID:       "S2",
-		Vertices: map[string]*topology.Vertex{"I14": n14, "I15": n15, "I16": n16},
-	}
-	sw3 := &topology.Vertex{
-		ID:       "S3",
-		Vertices: map[string]*topology.Vertex{"I21": n21, "I22": n22, "I25": n25},
-	}
-	sw1 := &topology.Vertex{
-		ID:       "S1",
-		Vertices: map[string]*topology.Vertex{"S2": sw2, "S3": sw3},
-	}
-	ibRoot2 := &topology.Vertex{
-		ID:       "ibRoot2",
-		Vertices: map[string]*topology.Vertex{"S1": sw1},
-	}
-
-	treeRoot := &topology.Vertex{
-		Vertices: map[string]*topology.Vertex{"IB1": ibRoot1, "IB2": ibRoot2},
-	}
-
-	block1 := &topology.Vertex{
-		ID:       "B1",
-		Vertices: map[string]*topology.Vertex{"I14": n14, "I15": n15, "I16": n16},
-	}
-	block2 := &topology.Vertex{
-		ID:       "B2",
-		Vertices: map[string]*topology.Vertex{"I21": n21, "I22": n22, "I25": n25},
-	}
-	block3 := &topology.Vertex{
-		ID:       "B3",
-		Vertices: map[string]*topology.Vertex{"I31": n31, "I32": n32, "I33": n33},
-	}
-	block4 := &topology.Vertex{
-		ID:       "B4",
-		Vertices: map[string]*topology.Vertex{"I41": n41, "I42": n42, "I43": n43},
-	}
-
-	blockRoot := &topology.Vertex{
-		Vertices: map[string]*topology.Vertex{"B1": block1, "B2": block2, "B3": block3, "B4": block4},
-	}
-
-	root := &topology.Vertex{
-		Vertices: map[string]*topology.Vertex{topology.TopologyBlock: blockRoot, topology.TopologyTree: treeRoot},
-		Metadata: map[string]string{
-			topology.KeyPlugin:     topology.TopologyBlock,
-			topology.KeyBlockSizes: "3",
-		},
+// nodeNames returns the node names of the hostlist, the inverse of compress.
+// A hostlist that cannot be expanded is returned as a single name.
+func nodeNames(hostlist string) []string {
+	names, err := cluset.Expand(hostlist)
+	if err != nil {
+		return []string{hostlist}
 	}
-	return root, instance2node
+	return names
 }
@@ -18,10 +18,16 @@ package translate
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/NVIDIA/topograph/internal/fixtures"
 	"github.com/NVIDIA/topograph/pkg/topology"
 )
 
@@ -29,6 +35,14 @@ const (
 	testTreeConfig = `SwitchName=S1 Switches=S[2-3]
 SwitchName=S2 Nodes=Node[201-202],Node205
 SwitchName=S3 Nodes=Node[304-306]
+`
+
+	testTreeMixedConfig = `SwitchName=S1 Switches=S[2-3],S1_nodes
+SwitchName=S2 Switches=S4,S2_nodes
+SwitchName=S1_nodes Nodes=Node[101-102]
+SwitchName=S2_nodes Nodes=Node201
+SwitchName=S3 Nodes=Node[301-302]
+SwitchName=S4 Nodes=Node401
 `
 
 	testBlockConfig = `BlockName=B1 Nodes=Node[104-106]
@@ -52,6 +66,52 @@ BlockSizes=3
 BlockName=B2 Nodes=Node[104-105]
 BlockName=B3 Nodes=Node205
 BlockSizes=1
+`
+
+	testBlockConfigDisplayName = `# block001=cb1 (rack-a)
+BlockName=block001 Nodes=node[1-2]
+# block002=cb2
+BlockName=block002 Nodes=node[3-4]
+BlockSizes=2
+`
+
+	testTreeConfigJSON = `{
+  "tree": {
+    "switches": [
+      {
+        "switch": "S1",
+        "children": "S[2-3]"
+      },
+      {
+        "switch": "S2",
+        "nodes": "Node[201-202],Node205"
+      },
+      {
+        "switch": "S3",
+        "nodes": "Node[304-306]"
+      }
+    ]
+  }
+}
+`
+
+	testBlockConfigJSON = `{
+  "block": {
+    "blocks": [
+      {
+        "block": "B1",
+        "nodes": "Node[104-106]"
+      },
+      {
+        "block": "B2",
+        "nodes": "Node[201-202],Node205"
+      }
+    ],
+    "block_sizes": [
+      3
+    ]
+  }
+}
 `
 
 	shortNameExpectedResult = `# switch.3.1=hpcislandid-1
@@ -64,74 +124,717 @@ SwitchName=switch.2.2 Switches=switch.1.2
 SwitchName=switch.1.1 Nodes=node-1
 # switch.1.2=local-block-2
 SwitchName=switch.1.2 Nodes=node-2
+`
+
+	shortNameNoComments = `SwitchName=switch.3.1 Switches=switch.2.[1-2]
+SwitchName=switch.2.1 Switches=switch.1.1
+SwitchName=switch.2.2 Switches=switch.1.2
+SwitchName=switch.1.1 Nodes=node-1
+SwitchName=switch.1.2 Nodes=node-2
+`
+
+	shortNameHashComments = `# switch.3.1=b887a160
+SwitchName=switch.3.1 Switches=switch.2.[1-2]
+# switch.2.1=0f3115b9
+SwitchName=switch.2.1 Switches=switch.1.1
+# switch.2.2=ea230df0
+SwitchName=switch.2.2 Switches=switch.1.2
+# switch.1.1=18d9222e
+SwitchName=switch.1.1 Nodes=node-1
+# switch.1.2=23aa0a5e
+SwitchName=switch.1.2 Nodes=node-2
 `
 )
 
 func TestToTreeTopology(t *testing.T) {
-	v, _ := GetTreeTestSet(false)
+	v, _ := fixtures.TreeTestSet()
 	buf := &bytes.Buffer{}
-	err := Write(buf, v)
+	err := Write(context.TODO(), buf, v)
 	require.NoError(t, err)
 	require.Equal(t, testTreeConfig, buf.String())
 }
 
+func TestToTreeMixedTopology(t *testing.T) {
+	//
+	//            S1
+	//      /    |   |    \
+	//    S2     S3  I101 I102
+	//   /  \    |
+	//  S4  I201 I301,I302
+	//  |
+	// I401
+	//
+	node := func(id, name string) *topology.Vertex { return &topology.Vertex{ID: id, Name: name} }
+	sw4 := &topology.Vertex{ID: "S4", Vertices: map[string]*topology.Vertex{"I401": node("I401", "Node401")}}
+	sw2 := &topology.Vertex{ID: "S2", Vertices: map[string]*topology.Vertex{"S4": sw4, "I201": node("I201", "Node201")}}
+	sw3 := &topology.Vertex{ID: "S3", Vertices: map[string]*topology.Vertex{
+		"I301": node("I301", "Node301"),
+		"I302": node("I302", "Node302"),
+	}}
+	sw1 := &topology.Vertex{ID: "S1", Vertices: map[string]*topology.Vertex{
+		"S2":   sw2,
+		"S3":   sw3,
+		"I101": node("I101", "Node101"),
+		"I102": node("I102", "Node102"),
+	}}
+	root := &topology.Vertex{
+		Vertices: map[string]*topology.Vertex{
+			topology.TopologyTree: {Vertices: map[string]*topology.Vertex{"S1": sw1}},
+		},
+	}
+
+	buf := &bytes.Buffer{}
+	err := Write(context.TODO(), buf, root)
+	require.NoError(t, err)
+	require.Equal(t, testTreeMixedConfig, buf.String())
+}
+
 func TestToBlockTopology(t *testing.T) {
 	v, _ := getBlockTestSet()
 	buf := &bytes.Buffer{}
-	err := Write(buf, v)
+	err := Write(context.TODO(), buf, v)
 	require.NoError(t, err)
 	require.Equal(t, testBlockConfig, buf.String())
 }
 
 func TestToBlockMultiIBTopology(t *testing.T) {
-	v, _ := GetBlockWithMultiIBTestSet()
+	v, _ := fixtures.BlockWithMultiIBTestSet()
 	buf := &bytes.Buffer{}
-	err := Write(buf, v)
+	err := Write(context.TODO(), buf, v)
 	require.NoError(t, err)
-	switch buf.String() {
-	case testBlockConfig2:
-		// nop
-	default:
-		t.Errorf("unexpected result %s", buf.String())
-	}
+	require.Equal(t, testBlockConfig2, buf.String())
 }
 
 func TestToBlockIBTopology(t *testing.T) {
 	v, _ := getBlockWithIBTestSet()
 	buf := &bytes.Buffer{}
-	err := Write(buf, v)
+	err := Write(context.TODO(), buf, v)
 	require.NoError(t, err)
-	switch buf.String() {
-	case testBlockConfig:
-		// nop
-	default:
-		t.Errorf("unexpected result %s", buf.String())
-	}
+	require.Equal(t, testBlockConfig, buf.String())
 }
 
 func TestToBlockDiffNumNode(t *testing.T) {
 	v, _ := getBlockWithDiffNumNodeTestSet()
 	buf := &bytes.Buffer{}
-	err := Write(buf, v)
+	err := Write(context.TODO(), buf, v)
 	require.NoError(t, err)
-	switch buf.String() {
-	case testBlockConfigDiffNumNodes:
-		// nop
-	default:
-		t.Errorf("unexpected result %s", buf.String())
-	}
+	require.Equal(t, testBlockConfigDiffNumNodes, buf.String())
 }
 
 func TestToBlockDFSIBTopology(t *testing.T) {
 	v, _ := getBlockWithDFSIBTestSet()
 	buf := &bytes.Buffer{}
-	err := Write(buf, v)
+	err := Write(context.TODO(), buf, v)
 	require.NoError(t, err)
-	switch buf.String() {
-	case testBlockConfigDFS:
-		// nop
-	default:
-		t.Errorf("unexpected result %s", buf.String())
+	require.Equal(t, testBlockConfigDFS, buf.String())
+}
+
+func TestToBlockDeterministicOrder(t *testing.T) {
+	// the nodes without numerical suffix are listed in sorted order
+	newRoot := func() *topology.Vertex {
+		login := &topology.Vertex{ID: "login", Name: "login"}
+		viz := &topology.Vertex{ID: "viz", Name: "viz"}
+		gpu := &topology.Vertex{ID: "gpu", Name: "gpu"}
+		mgmt := &topology.Vertex{ID: "mgmt", Name: "mgmt"}
+		data := &topology.Vertex{ID: "data", Name: "data"}
+		cpu := &topology.Vertex{ID: "cpu", Name: "cpu"}
+		return &topology.Vertex{
+			Vertices: map[string]*topology.Vertex{
+				topology.TopologyBlock: {
+					Vertices: map[string]*topology.Vertex{
+						"B1": {ID: "B1", Vertices: map[string]*topology.Vertex{"viz": viz, "login": login, "gpu": gpu}},
+						"B2": {ID: "B2", Vertices: map[string]*topology.Vertex{"mgmt": mgmt, "data": data, "cpu": cpu}},
+					},
+				},
+				topology.TopologyTree: {
+					Vertices: map[string]*topology.Vertex{
+						"S1": {ID: "S1", Vertices: map[string]*topology.Vertex{"viz": viz, "login": login, "gpu": gpu}},
+					},
+				},
+			},
+			Metadata: map[string]string{topology.KeyPlugin: topology.TopologyBlock},
+		}
+	}
+
+	for i := 0; i < 10; i++ {
+		buf := &bytes.Buffer{}
+		require.NoError(t, Write(context.TODO(), buf, newRoot()))
+		require.Equal(t, "BlockName=B1 Nodes=gpu,login,viz\nBlockName=B2 Nodes=cpu,data,mgmt\nBlockSizes=2\n", buf.String())
+	}
+}
+
+func TestToBlockDisplayName(t *testing.T) {
+	domainMap := NewDomainMap()
+	domainMap.AddHost("cb1", "node1")
+	domainMap.AddHost("cb1", "node2")
+	domainMap.AddHost("cb2", "node3")
+	domainMap.AddHost("cb2", "node4")
+	domainMap.SetDisplayName("cb1", "rack-a")
+
+	testCases := []struct {
+		name     string
+		comments string
+		output   string
+	}{
+		{
+			name:   "Case 1: default comments",
+			output: testBlockConfigDisplayName,
+		},
+		{
+			name:     "Case 2: no comments",
+			comments: CommentsNone,
+			output:   "BlockName=block001 Nodes=node[1-2]\nBlockName=block002 Nodes=node[3-4]\nBlockSizes=2\n",
+		},
+		{
+			name:     "Case 3: hashed comments",
+			comments: CommentsHash,
+			output:   "# block001=17f97017 (rack-a)\nBlockName=block001 Nodes=node[1-2]\n# block002=2ebc8629\nBlockName=block002 Nodes=node[3-4]\nBlockSizes=2\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			v := &topology.Vertex{
+				Vertices: map[string]*topology.Vertex{topology.TopologyBlock: domainMap.ToBlocks()},
+				Metadata: map[string]string{topology.KeyPlugin: topology.TopologyBlock, topology.KeyComments: tc.comments},
+			}
+			buf := &bytes.Buffer{}
+			err := Write(context.TODO(), buf, v)
+			require.NoError(t, err)
+			require.Equal(t, tc.output, buf.String())
+		})
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	tree, _ := fixtures.TreeTestSet()
+	block, _ := getBlockTestSet()
+
+	testCases := []struct {
+		name     string
+		root     *topology.Vertex
+		expected string
+	}{
+		{
+			name:     "Case 1: tree topology",
+			root:     tree,
+			expected: testTreeConfigJSON,
+		},
+		{
+			name:     "Case 2: block topology",
+			root:     block,
+			expected: testBlockConfigJSON,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// the output must be deterministic
+			for i := 0; i < 3; i++ {
+				buf := &bytes.Buffer{}
+				err := WriteFormat(context.TODO(), buf, tc.root, FormatJSON)
+				require.NoError(t, err)
+				require.Equal(t, tc.expected, buf.String())
+
+				// the output must round-trip to the same topology config
+				var unit TopologyUnit
+				require.NoError(t, json.Unmarshal(buf.Bytes(), &unit))
+				expected, err := ToTopologyUnit(context.TODO(), tc.root)
+				require.NoError(t, err)
+				require.Equal(t, expected, &unit)
+			}
+		})
+	}
+}
+
+func TestWriteFormat(t *testing.T) {
+	v, _ := fixtures.TreeTestSet()
+
+	buf := &bytes.Buffer{}
+	err := WriteFormat(context.TODO(), buf, v, FormatConf)
+	require.NoError(t, err)
+	require.Equal(t, testTreeConfig, buf.String())
+
+	err = WriteFormat(context.TODO(), buf, v, "xml")
+	require.EqualError(t, err, `unsupported topology format "xml"`)
+}
+
+// cancelWriter cancels the context on the first write
+type cancelWriter struct {
+	cancel context.CancelFunc
+	writes int
+}
+
+func (w *cancelWriter) Write(p []byte) (int, error) {
+	w.writes++
+	w.cancel()
+	return len(p), nil
+}
+
+func getLargeTestSet(switches, nodes int) *topology.Vertex {
+	spine := &topology.Vertex{ID: "spine", Vertices: make(map[string]*topology.Vertex)}
+	blockRoot := &topology.Vertex{Vertices: make(map[string]*topology.Vertex)}
+	for i := 0; i < switches; i++ {
+		leaf := &topology.Vertex{ID: fmt.Sprintf("leaf%d", i), Vertices: make(map[string]*topology.Vertex)}
+		block := &topology.Vertex{ID: fmt.Sprintf("block%03d", i+1), Vertices: make(map[string]*topology.Vertex)}
+		for j := 0; j < nodes; j++ {
+			name := fmt.Sprintf("node-%d-%d", i, j)
+			node := &topology.Vertex{ID: name, Name: name}
+			leaf.Vertices[name] = node
+			block.Vertices[name] = node
+		}
+		spine.Vertices[leaf.ID] = leaf
+		blockRoot.Vertices[block.ID] = block
+	}
+
+	return &topology.Vertex{
+		Vertices: map[string]*topology.Vertex{
+			topology.TopologyTree:  {Vertices: map[string]*topology.Vertex{spine.ID: spine}},
+			topology.TopologyBlock: blockRoot,
+		},
+	}
+}
+
+func TestWriteCancel(t *testing.T) {
+	root := getLargeTestSet(1000, 40)
+
+	for _, plugin := range []string{topology.TopologyTree, topology.TopologyBlock} {
+		t.Run(plugin, func(t *testing.T) {
+			root.Metadata = map[string]string{topology.KeyPlugin: plugin}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			wr := &cancelWriter{cancel: cancel}
+
+			start := time.Now()
+			err := Write(ctx, wr, root)
+			require.ErrorIs(t, err, context.Canceled)
+			require.Equal(t, 1, wr.writes)
+			require.Less(t, time.Since(start), 5*time.Second)
+		})
+	}
+}
+
+func TestGetBlockSize(t *testing.T) {
+	testCases := []struct {
+		name       string
+		domains    map[string]int
+		blockSizes string
+		hint       string
+		strategy   string
+		expected   []int
+		source     string
+	}{
+		{
+			name:     "Case 1: no hint",
+			domains:  map[string]int{"b1": 18, "b2": 20},
+			expected: []int{16},
+		},
+		{
+			name:     "Case 2: hint fits",
+			domains:  map[string]int{"b1": 18, "b2": 20},
+			hint:     "4",
+			expected: []int{4, 8, 16},
+			source:   BlockSizesSourceHint,
+		},
+		{
+			name:     "Case 3: largest fitting hint",
+			domains:  map[string]int{"b1": 18, "b2": 20},
+			hint:     "4,6,32",
+			expected: []int{6, 12},
+			source:   BlockSizesSourceHint,
+		},
+		{
+			name:     "Case 4: hint does not fit",
+			domains:  map[string]int{"b1": 18, "b2": 20},
+			hint:     "24,32",
+			expected: []int{16},
+			source:   BlockSizesSourceFallback,
+		},
+		{
+			name:     "Case 5: invalid hint",
+			domains:  map[string]int{"b1": 18, "b2": 20},
+			hint:     "4,x",
+			expected: []int{16},
+			source:   BlockSizesSourceFallback,
+		},
+		{
+			name:       "Case 6: admin block sizes take precedence",
+			domains:    map[string]int{"b1": 18, "b2": 20},
+			blockSizes: "9,18",
+			hint:       "4",
+			expected:   []int{9, 18},
+		},
+		{
+			name:       "Case 7: hint used when admin block sizes do not fit",
+			domains:    map[string]int{"b1": 18, "b2": 20},
+			blockSizes: "20",
+			hint:       "4",
+			expected:   []int{4, 8, 16},
+			source:     BlockSizesSourceHint,
+		},
+		{
+			name:     "Case 8: min strategy with undersized blocks",
+			domains:  map[string]int{"b1": 18, "b2": 18, "b3": 18, "b4": 9, "b5": 9},
+			strategy: BlockSizeStrategyMin,
+			expected: []int{8},
+		},
+		{
+			name:     "Case 9: median strategy with undersized blocks",
+			domains:  map[string]int{"b1": 18, "b2": 18, "b3": 18, "b4": 9, "b5": 9},
+			strategy: BlockSizeStrategyMedian,
+			expected: []int{16},
+		},
+		{
+			name:     "Case 10: histogram strategy with undersized blocks",
+			domains:  map[string]int{"b1": 18, "b2": 18, "b3": 18, "b4": 9, "b5": 9},
+			strategy: BlockSizeStrategyHistogram,
+			expected: []int{16},
+		},
+		{
+			name:     "Case 11: median and histogram differ",
+			domains:  map[string]int{"b1": 9, "b2": 9, "b3": 18, "b4": 20, "b5": 24},
+			strategy: BlockSizeStrategyMedian,
+			expected: []int{16},
+		},
+		{
+			name:     "Case 12: histogram picks the most common size",
+			domains:  map[string]int{"b1": 9, "b2": 9, "b3": 18, "b4": 20, "b5": 24},
+			strategy: BlockSizeStrategyHistogram,
+			expected: []int{8},
+		},
+		{
+			name:     "Case 13: median of an even number of domains",
+			domains:  map[string]int{"b1": 8, "b2": 16, "b3": 32, "b4": 32},
+			strategy: BlockSizeStrategyMedian,
+			expected: []int{16},
+		},
+		{
+			name:     "Case 14: histogram tie picks the larger size",
+			domains:  map[string]int{"b1": 8, "b2": 8, "b3": 32, "b4": 32},
+			strategy: BlockSizeStrategyHistogram,
+			expected: []int{32},
+		},
+		{
+			name:     "Case 15: hint with median strategy",
+			domains:  map[string]int{"b1": 18, "b2": 18, "b3": 18, "b4": 9, "b5": 9},
+			hint:     "6",
+			strategy: BlockSizeStrategyMedian,
+			expected: []int{6, 12},
+			source:   BlockSizesSourceHint,
+		},
+		{
+			name:       "Case 16: admin block sizes validated against the planning size",
+			domains:    map[string]int{"b1": 18, "b2": 18, "b3": 18, "b4": 9, "b5": 9},
+			blockSizes: "18",
+			strategy:   BlockSizeStrategyHistogram,
+			expected:   []int{18},
+		},
+		{
+			name:     "Case 17: unsupported strategy",
+			domains:  map[string]int{"b1": 18, "b2": 18, "b3": 18, "b4": 9, "b5": 9},
+			strategy: "mean",
+			expected: []int{8},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			blockSizes, source := getBlockSize(tc.domains, tc.blockSizes, tc.hint, tc.strategy)
+			require.Equal(t, tc.expected, blockSizes)
+			require.Equal(t, tc.source, source)
+		})
+	}
+}
+
+func TestToBlockTopologyWithHint(t *testing.T) {
+	v, _ := getBlockTestSet()
+	v.Metadata = map[string]string{
+		topology.KeyPlugin:        topology.TopologyBlock,
+		topology.KeyBlockSizeHint: "1",
+	}
+	buf := &bytes.Buffer{}
+	err := Write(context.TODO(), buf, v)
+	require.NoError(t, err)
+	require.Equal(t, `BlockName=B1 Nodes=Node[104-106]
+BlockName=B2 Nodes=Node[201-202],Node205
+# BlockSizes derived from block_size_hint
+BlockSizes=1,2
+`, buf.String())
+}
+
+func TestToBlockTopologyExpected(t *testing.T) {
+	testCases := []struct {
+		name     string
+		expected map[string]string
+		metadata map[string]string
+		output   string
+	}{
+		{
+			name:     "Case 1: expected block sizes of partially populated blocks",
+			expected: map[string]string{"B1": "18", "B2": "18"},
+			metadata: map[string]string{topology.KeyExpectedBlockSizes: "true"},
+			output: `BlockName=B1 Nodes=node[101-112]
+BlockName=B2 Nodes=node[201-212]
+# BlockSizes derived from expected_block_size
+BlockSizes=18,36
+`,
+		},
+		{
+			name:     "Case 2: expected block sizes not enabled",
+			expected: map[string]string{"B1": "18", "B2": "18"},
+			output: `BlockName=B1 Nodes=node[101-112]
+BlockName=B2 Nodes=node[201-212]
+BlockSizes=8
+`,
+		},
+		{
+			name:     "Case 3: missing expected block size",
+			expected: map[string]string{"B1": "18"},
+			metadata: map[string]string{topology.KeyExpectedBlockSizes: "true"},
+			output: `BlockName=B1 Nodes=node[101-112]
+BlockName=B2 Nodes=node[201-212]
+BlockSizes=8
+`,
+		},
+		{
+			name:     "Case 4: expected block size smaller than the observed block size",
+			expected: map[string]string{"B1": "18", "B2": "10"},
+			metadata: map[string]string{topology.KeyExpectedBlockSizes: "true"},
+			output: `BlockName=B1 Nodes=node[101-112]
+BlockName=B2 Nodes=node[201-212]
+# BlockSizes derived from expected_block_size
+BlockSizes=12,24
+`,
+		},
+		{
+			name:     "Case 5: admin block sizes take precedence",
+			expected: map[string]string{"B1": "18", "B2": "18"},
+			metadata: map[string]string{topology.KeyExpectedBlockSizes: "true", topology.KeyBlockSizes: "4,8"},
+			output: `BlockName=B1 Nodes=node[101-112]
+BlockName=B2 Nodes=node[201-212]
+BlockSizes=4,8
+`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			blockRoot := &topology.Vertex{Vertices: make(map[string]*topology.Vertex)}
+			for i, id := range []string{"B1", "B2"} {
+				block := &topology.Vertex{ID: id, Vertices: make(map[string]*topology.Vertex)}
+				if size, ok := tc.expected[id]; ok {
+					block.Metadata = map[string]string{topology.KeyExpectedBlockSize: size}
+				}
+				for j := 1; j <= 12; j++ {
+					name := fmt.Sprintf("node%d%02d", i+1, j)
+					block.Vertices[name] = &topology.Vertex{ID: name, Name: name}
+				}
+				blockRoot.Vertices[id] = block
+			}
+			root := &topology.Vertex{
+				Vertices: map[string]*topology.Vertex{topology.TopologyBlock: blockRoot},
+				Metadata: map[string]string{topology.KeyPlugin: topology.TopologyBlock},
+			}
+			for key, val := range tc.metadata {
+				root.Metadata[key] = val
+			}
+
+			buf := &bytes.Buffer{}
+			err := Write(context.TODO(), buf, root)
+			require.NoError(t, err)
+			require.Equal(t, tc.output, buf.String())
+		})
+	}
+}
+
+func TestToFlatTopology(t *testing.T) {
+	testCases := []struct {
+		name   string
+		root   func() *topology.Vertex
+		mode   string
+		output string
+		err    string
+	}{
+		{
+			name:   "Case 1: default mode",
+			output: "# topology/flat: network topology is not used\n",
+		},
+		{
+			name:   "Case 2: empty mode",
+			mode:   FlatModeEmpty,
+			output: "# topology/flat: network topology is not used\n",
+		},
+		{
+			name:   "Case 3: switch mode",
+			mode:   FlatModeSwitch,
+			output: "SwitchName=flat Nodes=Node[201-202],Node205,Node[304-306]\n",
+		},
+		{
+			name: "Case 4: unsupported mode",
+			mode: "bad",
+			err:  `unsupported flat mode "bad"`,
+		},
+		{
+			name: "Case 5: switch mode with block-only topology",
+			root: func() *topology.Vertex {
+				v, _ := getBlockTestSet()
+				return v
+			},
+			mode:   FlatModeSwitch,
+			output: "SwitchName=flat Nodes=Node[104-106],Node[201-202],Node205\n",
+		},
+		{
+			name: "Case 6: switch mode with empty topology",
+			root: func() *topology.Vertex { return &topology.Vertex{Vertices: map[string]*topology.Vertex{}} },
+			mode: FlatModeSwitch,
+			err:  "no nodes for the flat switch of the switch mode",
+		},
+		{
+			name:   "Case 7: empty mode with empty topology",
+			root:   func() *topology.Vertex { return &topology.Vertex{Vertices: map[string]*topology.Vertex{}} },
+			output: "# topology/flat: network topology is not used\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var v *topology.Vertex
+			if tc.root != nil {
+				v = tc.root()
+			} else {
+				v, _ = fixtures.TreeTestSet()
+			}
+			v.Metadata = map[string]string{
+				topology.KeyPlugin:   topology.TopologyFlat,
+				topology.KeyFlatMode: tc.mode,
+			}
+			buf := &bytes.Buffer{}
+			err := Write(context.TODO(), buf, v)
+			if len(tc.err) != 0 {
+				require.EqualError(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.output, buf.String())
+		})
+	}
+}
+
+func TestReverseIndex(t *testing.T) {
+	treeRoot, _ := fixtures.TreeTestSet()
+	blockRoot, _ := getBlockTestSet()
+
+	testCases := []struct {
+		name   string
+		root   *topology.Vertex
+		output string
+	}{
+		{
+			name: "Case 1: tree topology",
+			root: treeRoot,
+			output: testTreeConfig + `# Node index (informational)
+# Node201: topology=topology/tree switch=S2
+# Node202: topology=topology/tree switch=S2
+# Node205: topology=topology/tree switch=S2
+# Node304: topology=topology/tree switch=S3
+# Node305: topology=topology/tree switch=S3
+# Node306: topology=topology/tree switch=S3
+`,
+		},
+		{
+			name: "Case 2: block topology",
+			root: blockRoot,
+			output: testBlockConfig + `# Node index (informational)
+# Node104: topology=topology/block block=B1
+# Node105: topology=topology/block block=B1
+# Node106: topology=topology/block block=B1
+# Node201: topology=topology/block block=B2
+# Node202: topology=topology/block block=B2
+# Node205: topology=topology/block block=B2
+`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			unit, err := ToTopologyUnit(context.TODO(), tc.root)
+			require.NoError(t, err)
+			unit.NodeIndex = unit.ReverseIndex()
+
+			buf := &bytes.Buffer{}
+			require.NoError(t, unit.Write(context.TODO(), buf, FormatConf))
+			require.Equal(t, tc.output, buf.String())
+
+			// the index covers exactly the nodes of the topology config
+			nodes := make([]string, 0, len(unit.NodeIndex))
+			for node := range unit.NodeIndex {
+				nodes = append(nodes, node)
+			}
+			sort.Strings(nodes)
+			require.Equal(t, unit.Nodes(), nodes)
+		})
+	}
+}
+
+func TestAccelerators(t *testing.T) {
+	multiIBRoot, _ := fixtures.BlockWithMultiIBTestSet()
+	multiIBRoot.Metadata = nil
+	treeRoot, _ := fixtures.TreeTestSet()
+	blockRoot, _ := getBlockTestSet()
+
+	testCases := []struct {
+		name         string
+		root         *topology.Vertex
+		accelerators map[string]string
+		output       string
+	}{
+		{
+			name: "Case 1: tree plugin with accelerator domains",
+			root: multiIBRoot,
+			accelerators: map[string]string{
+				"B1": "Node[104-106]",
+				"B2": "Node[201-202],Node205",
+				"B3": "Node[301-303]",
+				"B4": "Node[401-403]",
+			},
+			output: `SwitchName=ibRoot1 Switches=S4
+SwitchName=ibRoot2 Switches=S1
+SwitchName=S4 Switches=S[5-6]
+SwitchName=S1 Switches=S[2-3]
+SwitchName=S2 Nodes=Node[104-106]
+# nvlink-domain B1: Node[104-106]
+SwitchName=S3 Nodes=Node[201-202],Node205
+# nvlink-domain B2: Node[201-202],Node205
+SwitchName=S5 Nodes=Node[301-303]
+# nvlink-domain B3: Node[301-303]
+SwitchName=S6 Nodes=Node[401-403]
+# nvlink-domain B4: Node[401-403]
+`,
+		},
+		{
+			name:   "Case 2: tree plugin without accelerator domains",
+			root:   treeRoot,
+			output: testTreeConfig,
+		},
+		{
+			name:   "Case 3: block plugin",
+			root:   blockRoot,
+			output: testBlockConfig,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			unit, err := ToTopologyUnit(context.TODO(), tc.root)
+			require.NoError(t, err)
+			unit.AddAccelerators(tc.root)
+			require.Equal(t, tc.accelerators, unit.Accelerators)
+
+			buf := &bytes.Buffer{}
+			require.NoError(t, unit.Write(context.TODO(), buf, FormatConf))
+			require.Equal(t, tc.output, buf.String())
+		})
 	}
 }
 
@@ -179,14 +882,45 @@ func TestToSlurmNameShortener(t *testing.T) {
 		},
 	}
 
-	root := &topology.Vertex{
-		Vertices: map[string]*topology.Vertex{topology.TopologyTree: v},
+	testCases := []struct {
+		name     string
+		comments string
+		output   string
+	}{
+		{
+			name:   "Case 1: default comments",
+			output: shortNameExpectedResult,
+		},
+		{
+			name:     "Case 2: full comments",
+			comments: CommentsFull,
+			output:   shortNameExpectedResult,
+		},
+		{
+			name:     "Case 3: no comments",
+			comments: CommentsNone,
+			output:   shortNameNoComments,
+		},
+		{
+			name:     "Case 4: hashed comments",
+			comments: CommentsHash,
+			output:   shortNameHashComments,
+		},
 	}
 
-	buf := &bytes.Buffer{}
-	err := Write(buf, root)
-	require.NoError(t, err)
-	require.Equal(t, shortNameExpectedResult, buf.String())
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			root := &topology.Vertex{
+				Vertices: map[string]*topology.Vertex{topology.TopologyTree: v},
+				Metadata: map[string]string{topology.KeyComments: tc.comments},
+			}
+
+			buf := &bytes.Buffer{}
+			err := Write(context.TODO(), buf, root)
+			require.NoError(t, err)
+			require.Equal(t, tc.output, buf.String())
+		})
+	}
 }
 
 func TestCompress(t *testing.T) {
@@ -201,7 +935,7 @@ func TestCompress(t *testing.T) {
 		{
 			name:   "Case 2: ranges",
 			input:  []string{"eos0507", "eos0509", "eos0482", "eos0483", "eos0508", "eos0484"},
-			output: []string{"eos0[482-484]", "eos0[507-509]"},
+			output: []string{"eos[0482-0484]", "eos[0507-0509]"},
 		},
 		{
 			name:   "Case 3: singles",
@@ -211,12 +945,22 @@ func TestCompress(t *testing.T) {
 		{
 			name:   "Case 4: mix1",
 			input:  []string{"eos0507", "eos0509", "abc", "eos0482", "eos0508"},
-			output: []string{"abc", "eos0482", "eos0[507-509]"},
+			output: []string{"abc", "eos0482", "eos[0507-0509]"},
 		},
 		{
 			name:   "Case 5: mix2",
 			input:  []string{"eos0507", "eos0509", "abc", "eos0508", "eos0482"},
-			output: []string{"abc", "eos0482", "eos0[507-509]"},
+			output: []string{"abc", "eos0482", "eos[0507-0509]"},
+		},
+		{
+			name:   "Case 6: zero padding across powers of ten",
+			input:  []string{"fake098", "fake099", "fake100", "fake101"},
+			output: []string{"fake[098-101]"},
+		},
+		{
+			name:   "Case 7: mixed padding",
+			input:  []string{"dgx-01-c03", "dgx-01-c3", "dgx-01-c04", "dgx-01-c4"},
+			output: []string{"dgx-01-c[3-4]", "dgx-01-c[03-04]"},
 		},
 	}
 
@@ -227,49 +971,41 @@ func TestCompress(t *testing.T) {
 	}
 }
 
-func TestSplit(t *testing.T) {
+func TestNodeNames(t *testing.T) {
 	testCases := []struct {
-		name                  string
-		input, prefix, suffix string
+		name   string
+		input  string
+		output []string
 	}{
 		{
-			name: "Case 1: empty string",
-		},
-		{
-			name:   "Case 2: no digits",
-			input:  "abc",
-			prefix: "abc",
+			name:   "Case 1: empty list",
+			output: []string{},
 		},
 		{
-			name:   "Case 3: digits only",
-			input:  "12345",
-			suffix: "12345",
+			name:   "Case 2: ranges and singles",
+			input:  "abc,eos0482,eos0[507-509]",
+			output: []string{"abc", "eos0482", "eos0507", "eos0508", "eos0509"},
 		},
 		{
-			name:   "Case 4: digits only, leading zeros",
-			input:  "0012345",
-			prefix: "00",
-			suffix: "12345",
+			name:   "Case 3: invalid range",
+			input:  "eos[a-b]",
+			output: []string{"eos[a-b]"},
 		},
 		{
-			name:   "Case 5: mix",
-			input:  "abc1203045",
-			prefix: "abc",
-			suffix: "1203045",
+			name:   "Case 4: several ranges in brackets",
+			input:  "n[1-2,5],m[7]",
+			output: []string{"n1", "n2", "n5", "m7"},
 		},
 		{
-			name:   "Case 6: mix, leading zeros",
-			input:  "abc01203045",
-			prefix: "abc0",
-			suffix: "1203045",
+			name:   "Case 5: zero-padded range",
+			input:  "gpu[08-10]",
+			output: []string{"gpu08", "gpu09", "gpu10"},
 		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			prefix, suffix := split(tc.input)
-			require.Equal(t, tc.prefix, prefix)
-			require.Equal(t, tc.suffix, suffix)
+			require.Equal(t, tc.output, nodeNames(tc.input))
 		})
 	}
 }
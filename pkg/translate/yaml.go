@@ -0,0 +1,238 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package translate
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/NVIDIA/topograph/pkg/topology"
+)
+
+// YAML schema dialects of the Slurm topology.yaml file
+const (
+	// YAMLSchema2411 is the 24.11 preview dialect: "default" key and comma-separated block sizes
+	YAMLSchema2411 = "24.11"
+	// YAMLSchema2505 is the 25.05 dialect: "cluster_default" key and a list of block sizes
+	YAMLSchema2505 = "25.05"
+
+	DefaultYAMLSchema = YAMLSchema2505
+)
+
+// YAMLTopology is a named topology of the topology.yaml file
+type YAMLTopology struct {
+	Name    string
+	Default bool
+	Unit    *TopologyUnit
+}
+
+// yamlTopology is the marshal structure of a topology.yaml entry.
+// Default and ClusterDefault hold the default flag of the 24.11 and 25.05 dialects.
+type yamlTopology struct {
+	Topology       string     `yaml:"topology"`
+	ClusterDefault *bool      `yaml:"cluster_default,omitempty"`
+	Default        *bool      `yaml:"default,omitempty"`
+	Tree           *yamlTree  `yaml:"tree,omitempty"`
+	Block          *yamlBlock `yaml:"block,omitempty"`
+	Flat           bool       `yaml:"flat,omitempty"`
+}
+
+type yamlTree struct {
+	Switches []*yamlSwitch `yaml:"switches"`
+}
+
+type yamlSwitch struct {
+	Switch   string `yaml:"switch"`
+	Children string `yaml:"children,omitempty"`
+	Nodes    string `yaml:"nodes,omitempty"`
+}
+
+type yamlBlock struct {
+	Blocks     []*yamlBlockEntry `yaml:"blocks"`
+	BlockSizes *yamlBlockSizes   `yaml:"block_sizes,omitempty"`
+}
+
+// yamlBlockEntry is a block of the topology.yaml file.
+// Name is the original block ID, e.g. the NVLink domain, shown according to the comments mode.
+type yamlBlockEntry struct {
+	Block string `yaml:"block"`
+	Name  string `yaml:"name,omitempty"`
+	Nodes string `yaml:"nodes"`
+}
+
+// yamlBlockSizes is written either as a list (25.05) or as a comma-separated string (24.11),
+// and read from both
+type yamlBlockSizes struct {
+	sizes    []int
+	asString bool
+}
+
+func (bs *yamlBlockSizes) MarshalYAML() (interface{}, error) {
+	if !bs.asString {
+		return bs.sizes, nil
+	}
+	sizes := make([]string, 0, len(bs.sizes))
+	for _, size := range bs.sizes {
+		sizes = append(sizes, strconv.Itoa(size))
+	}
+	return strings.Join(sizes, ","), nil
+}
+
+func (bs *yamlBlockSizes) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.SequenceNode {
+		return value.Decode(&bs.sizes)
+	}
+	var str string
+	if err := value.Decode(&str); err != nil {
+		return err
+	}
+	sizes, err := parseBlockSizes(str)
+	if err != nil {
+		return fmt.Errorf("invalid block_sizes %q: %v", str, err)
+	}
+	bs.sizes, bs.asString = sizes, true
+	return nil
+}
+
+// ValidateYAMLSchema returns an error if the YAML schema dialect is not supported
+func ValidateYAMLSchema(schema string) error {
+	switch schema {
+	case "", YAMLSchema2411, YAMLSchema2505:
+		return nil
+	default:
+		return fmt.Errorf("unsupported YAML schema version %q", schema)
+	}
+}
+
+// WriteYAML writes the topology config as a topology.yaml file in the given schema dialect.
+// The topology is named after its plugin, e.g. "tree", and is the cluster default.
+func (unit *TopologyUnit) WriteYAML(ctx context.Context, wr io.Writer, schema string) error {
+	if err := ValidateYAMLSchema(schema); err != nil {
+		return err
+	}
+	if len(schema) == 0 {
+		schema = DefaultYAMLSchema
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	isDefault := true
+	entry := &yamlTopology{}
+	if schema == YAMLSchema2411 {
+		entry.Default = &isDefault
+	} else {
+		entry.ClusterDefault = &isDefault
+	}
+
+	switch {
+	case unit.Flat != nil && len(unit.Flat.Nodes) != 0:
+		// all nodes under a single switch
+		entry.Topology = yamlTopologyName(topology.TopologyTree)
+		entry.Tree = &yamlTree{Switches: []*yamlSwitch{{Switch: FlatSwitchName, Nodes: unit.Flat.Nodes}}}
+	case unit.Flat != nil:
+		entry.Topology = yamlTopologyName(topology.TopologyFlat)
+		entry.Flat = true
+	case unit.Block != nil:
+		entry.Topology = yamlTopologyName(topology.TopologyBlock)
+		entry.Block = &yamlBlock{Blocks: make([]*yamlBlockEntry, 0, len(unit.Block.Blocks))}
+		for _, block := range unit.Block.Blocks {
+			entry.Block.Blocks = append(entry.Block.Blocks, &yamlBlockEntry{Block: block.Block, Name: block.Name, Nodes: block.Nodes})
+		}
+		if len(unit.Block.BlockSizes) != 0 {
+			entry.Block.BlockSizes = &yamlBlockSizes{sizes: unit.Block.BlockSizes, asString: schema == YAMLSchema2411}
+		}
+	case unit.Tree != nil:
+		entry.Topology = yamlTopologyName(topology.TopologyTree)
+		entry.Tree = &yamlTree{Switches: make([]*yamlSwitch, 0, len(unit.Tree.Switches))}
+		for _, sw := range unit.Tree.Switches {
+			// Slurm rejects a switch without children and nodes
+			if len(sw.Children) == 0 && len(sw.Nodes) == 0 {
+				return fmt.Errorf("switch %s has neither children nor nodes", sw.Switch)
+			}
+			entry.Tree.Switches = append(entry.Tree.Switches, &yamlSwitch{Switch: sw.Switch, Children: sw.Children, Nodes: sw.Nodes})
+		}
+	default:
+		return fmt.Errorf("empty topology config")
+	}
+
+	enc := yaml.NewEncoder(wr)
+	enc.SetIndent(2)
+	if err := enc.Encode([]*yamlTopology{entry}); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// yamlTopologyName returns the topology name for the plugin, e.g. "tree" for topology/tree
+func yamlTopologyName(plugin string) string {
+	return strings.TrimPrefix(plugin, "topology/")
+}
+
+// ParseYAML reads the topologies of a topology.yaml file in any supported schema dialect
+func ParseYAML(data []byte) ([]*YAMLTopology, error) {
+	var entries []*yamlTopology
+	if err := yaml.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse topology YAML: %v", err)
+	}
+
+	topos := make([]*YAMLTopology, 0, len(entries))
+	for _, entry := range entries {
+		if len(entry.Topology) == 0 {
+			return nil, fmt.Errorf("missing topology name")
+		}
+		topo := &YAMLTopology{
+			Name:    entry.Topology,
+			Default: (entry.ClusterDefault != nil && *entry.ClusterDefault) || (entry.Default != nil && *entry.Default),
+			Unit:    &TopologyUnit{},
+		}
+
+		var plugins int
+		if entry.Tree != nil {
+			plugins++
+			topo.Unit.Tree = &TreeTopo{Switches: make([]*Switch, 0, len(entry.Tree.Switches))}
+			for _, sw := range entry.Tree.Switches {
+				topo.Unit.Tree.Switches = append(topo.Unit.Tree.Switches, &Switch{Switch: sw.Switch, Children: sw.Children, Nodes: sw.Nodes})
+			}
+		}
+		if entry.Block != nil {
+			plugins++
+			topo.Unit.Block = &BlockTopo{Blocks: make([]*Block, 0, len(entry.Block.Blocks))}
+			for _, block := range entry.Block.Blocks {
+				topo.Unit.Block.Blocks = append(topo.Unit.Block.Blocks, &Block{Block: block.Block, Name: block.Name, Nodes: block.Nodes})
+			}
+			if entry.Block.BlockSizes != nil {
+				topo.Unit.Block.BlockSizes = entry.Block.BlockSizes.sizes
+			}
+		}
+		if entry.Flat {
+			plugins++
+			topo.Unit.Flat = &FlatTopo{}
+		}
+		if plugins != 1 {
+			return nil, fmt.Errorf("topology %q must have exactly one of tree, block or flat", entry.Topology)
+		}
+
+		topos = append(topos, topo)
+	}
+
+	return topos, nil
+}
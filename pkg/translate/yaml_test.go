@@ -0,0 +1,373 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package translate
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/NVIDIA/topograph/internal/fixtures"
+	"github.com/NVIDIA/topograph/pkg/topology"
+)
+
+// yamlTestSet returns the topology graphs written in every YAML schema dialect
+func yamlTestSet() map[string]*topology.Vertex {
+	tree, _ := fixtures.TreeTestSet()
+	block, _ := getBlockTestSet()
+	block.Metadata[topology.KeyBlockSizes] = "1,2"
+	flat := &topology.Vertex{
+		Vertices: map[string]*topology.Vertex{},
+		Metadata: map[string]string{topology.KeyPlugin: topology.TopologyFlat},
+	}
+	return map[string]*topology.Vertex{"tree": tree, "block": block, "flat": flat}
+}
+
+const yamlTree2505 = `- topology: tree
+  cluster_default: true
+  tree:
+    switches:
+      - switch: S1
+        children: S[2-3]
+      - switch: S2
+        nodes: Node[201-202],Node205
+      - switch: S3
+        nodes: Node[304-306]
+`
+
+const yamlBlock2505 = `- topology: block
+  cluster_default: true
+  block:
+    blocks:
+      - block: B1
+        nodes: Node[104-106]
+      - block: B2
+        nodes: Node[201-202],Node205
+    block_sizes:
+      - 1
+      - 2
+`
+
+const yamlFlat2505 = `- topology: flat
+  cluster_default: true
+  flat: true
+`
+
+const yamlTree2411 = `- topology: tree
+  default: true
+  tree:
+    switches:
+      - switch: S1
+        children: S[2-3]
+      - switch: S2
+        nodes: Node[201-202],Node205
+      - switch: S3
+        nodes: Node[304-306]
+`
+
+const yamlBlock2411 = `- topology: block
+  default: true
+  block:
+    blocks:
+      - block: B1
+        nodes: Node[104-106]
+      - block: B2
+        nodes: Node[201-202],Node205
+    block_sizes: 1,2
+`
+
+const yamlFlat2411 = `- topology: flat
+  default: true
+  flat: true
+`
+
+func TestWriteYAML(t *testing.T) {
+	testCases := []struct {
+		name     string
+		schema   string
+		expected map[string]string
+		err      string
+	}{
+		{
+			name:     "Case 1: default schema",
+			expected: map[string]string{"tree": yamlTree2505, "block": yamlBlock2505, "flat": yamlFlat2505},
+		},
+		{
+			name:     "Case 2: 25.05 schema",
+			schema:   YAMLSchema2505,
+			expected: map[string]string{"tree": yamlTree2505, "block": yamlBlock2505, "flat": yamlFlat2505},
+		},
+		{
+			name:     "Case 3: 24.11 schema",
+			schema:   YAMLSchema2411,
+			expected: map[string]string{"tree": yamlTree2411, "block": yamlBlock2411, "flat": yamlFlat2411},
+		},
+		{
+			name:   "Case 4: unsupported schema",
+			schema: "23.02",
+			err:    `unsupported YAML schema version "23.02"`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			for name, root := range yamlTestSet() {
+				unit, err := ToTopologyUnit(context.TODO(), root)
+				require.NoError(t, err)
+				buf := &bytes.Buffer{}
+				err = unit.WriteYAML(context.TODO(), buf, tc.schema)
+				if len(tc.err) != 0 {
+					require.EqualError(t, err, tc.err)
+					continue
+				}
+				require.NoError(t, err)
+				require.Equal(t, tc.expected[name], buf.String(), name)
+			}
+		})
+	}
+}
+
+func TestWriteFormatYAML(t *testing.T) {
+	root, _ := fixtures.TreeTestSet()
+	buf := &bytes.Buffer{}
+	require.NoError(t, WriteFormat(context.TODO(), buf, root, FormatYAML))
+	require.Equal(t, yamlTree2505, buf.String())
+}
+
+// sparseTreeTestSet returns a deep tree where the nodes live under a few switches only,
+// as left by a scoped node list: S3, S4 and S7 have no nodes beneath
+func sparseTreeTestSet() *topology.Vertex {
+	//
+	//            S1
+	//          /    \
+	//        S2      S6
+	//      /  |  \    |
+	//    S3  S4  S5  Node601
+	//         |   |
+	//        S7  Node[501-502]
+	//
+	s5 := &topology.Vertex{
+		ID: "S5",
+		Vertices: map[string]*topology.Vertex{
+			"I501": {ID: "I501", Name: "Node501"},
+			"I502": {ID: "I502", Name: "Node502"},
+		},
+	}
+	s4 := &topology.Vertex{
+		ID:       "S4",
+		Vertices: map[string]*topology.Vertex{"S7": {ID: "S7"}},
+	}
+	s2 := &topology.Vertex{
+		ID:       "S2",
+		Vertices: map[string]*topology.Vertex{"S3": {ID: "S3"}, "S4": s4, "S5": s5},
+	}
+	s6 := &topology.Vertex{
+		ID:       "S6",
+		Vertices: map[string]*topology.Vertex{"I601": {ID: "I601", Name: "Node601"}},
+	}
+	s1 := &topology.Vertex{
+		ID:       "S1",
+		Vertices: map[string]*topology.Vertex{"S2": s2, "S6": s6},
+	}
+
+	return &topology.Vertex{
+		Vertices: map[string]*topology.Vertex{
+			topology.TopologyTree: {Vertices: map[string]*topology.Vertex{"S1": s1}},
+		},
+	}
+}
+
+func TestWriteYAMLSparseTree(t *testing.T) {
+	expected := `- topology: tree
+  cluster_default: true
+  tree:
+    switches:
+      - switch: S1
+        children: S2,S6
+      - switch: S2
+        children: S5
+      - switch: S5
+        nodes: Node[501-502]
+      - switch: S6
+        nodes: Node601
+`
+	buf := &bytes.Buffer{}
+	require.NoError(t, WriteFormat(context.TODO(), buf, sparseTreeTestSet(), FormatYAML))
+	require.Equal(t, expected, buf.String())
+
+	// switches without children and nodes are rejected
+	unit := &TopologyUnit{Tree: &TreeTopo{Switches: []*Switch{{Switch: "S1", Children: "S2"}, {Switch: "S2"}}}}
+	err := unit.WriteYAML(context.TODO(), &bytes.Buffer{}, "")
+	require.EqualError(t, err, "switch S2 has neither children nor nodes")
+}
+
+func TestWriteYAMLBlockNames(t *testing.T) {
+	domainMap := DomainMap{}
+	domainMap.AddHost("nvl1", "node1")
+	domainMap.AddHost("nvl1", "node2")
+	domainMap.AddHost("nvl2", "node3")
+	domainMap.AddHost("nvl2", "node4")
+
+	testCases := []struct {
+		name     string
+		comments string
+		names    []string
+	}{
+		{
+			name:  "Case 1: default comments",
+			names: []string{"\n        name: nvl1", "\n        name: nvl2"},
+		},
+		{
+			name:     "Case 2: no comments",
+			comments: CommentsNone,
+			names:    []string{"", ""},
+		},
+		{
+			name:     "Case 3: hashed comments",
+			comments: CommentsHash,
+			names:    []string{"\n        name: " + shortHash("nvl1"), "\n        name: " + shortHash("nvl2")},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			root := &topology.Vertex{
+				Vertices: map[string]*topology.Vertex{topology.TopologyBlock: domainMap.ToBlocks()},
+				Metadata: map[string]string{topology.KeyPlugin: topology.TopologyBlock, topology.KeyComments: tc.comments},
+			}
+			expected := `- topology: block
+  cluster_default: true
+  block:
+    blocks:
+      - block: block001` + tc.names[0] + `
+        nodes: node[1-2]
+      - block: block002` + tc.names[1] + `
+        nodes: node[3-4]
+    block_sizes:
+      - 2
+`
+			buf := &bytes.Buffer{}
+			require.NoError(t, WriteFormat(context.TODO(), buf, root, FormatYAML))
+			require.Equal(t, expected, buf.String())
+		})
+	}
+}
+
+func TestParseYAML(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected []*YAMLTopology
+		err      string
+	}{
+		{
+			name:  "Case 1: 25.05 block",
+			input: yamlBlock2505,
+			expected: []*YAMLTopology{
+				{
+					Name:    "block",
+					Default: true,
+					Unit: &TopologyUnit{Block: &BlockTopo{
+						Blocks: []*Block{
+							{Block: "B1", Nodes: "Node[104-106]"},
+							{Block: "B2", Nodes: "Node[201-202],Node205"},
+						},
+						BlockSizes: []int{1, 2},
+					}},
+				},
+			},
+		},
+		{
+			name:  "Case 2: 24.11 block",
+			input: yamlBlock2411,
+			expected: []*YAMLTopology{
+				{
+					Name:    "block",
+					Default: true,
+					Unit: &TopologyUnit{Block: &BlockTopo{
+						Blocks: []*Block{
+							{Block: "B1", Nodes: "Node[104-106]"},
+							{Block: "B2", Nodes: "Node[201-202],Node205"},
+						},
+						BlockSizes: []int{1, 2},
+					}},
+				},
+			},
+		},
+		{
+			name:  "Case 3: mixed dialects",
+			input: yamlTree2411 + "- topology: flat\n  flat: true\n",
+			expected: []*YAMLTopology{
+				{
+					Name:    "tree",
+					Default: true,
+					Unit: &TopologyUnit{Tree: &TreeTopo{
+						Switches: []*Switch{
+							{Switch: "S1", Children: "S[2-3]"},
+							{Switch: "S2", Nodes: "Node[201-202],Node205"},
+							{Switch: "S3", Nodes: "Node[304-306]"},
+						},
+					}},
+				},
+				{
+					Name: "flat",
+					Unit: &TopologyUnit{Flat: &FlatTopo{}},
+				},
+			},
+		},
+		{
+			name:  "Case 4: block names",
+			input: "- topology: block\n  block:\n    blocks:\n      - block: block001\n        name: nvl1\n        nodes: node[1-2]\n",
+			expected: []*YAMLTopology{
+				{
+					Name: "block",
+					Unit: &TopologyUnit{Block: &BlockTopo{
+						Blocks: []*Block{{Block: "block001", Name: "nvl1", Nodes: "node[1-2]"}},
+					}},
+				},
+			},
+		},
+		{
+			name:  "Case 5: invalid block sizes",
+			input: "- topology: block\n  block:\n    blocks: []\n    block_sizes: 1,x\n",
+			err:   "failed to parse topology YAML: invalid block_sizes \"1,x\": strconv.Atoi: parsing \"x\": invalid syntax",
+		},
+		{
+			name:  "Case 6: multiple plugins",
+			input: "- topology: both\n  flat: true\n  block:\n    blocks: []\n",
+			err:   `topology "both" must have exactly one of tree, block or flat`,
+		},
+		{
+			name:  "Case 7: missing name",
+			input: "- flat: true\n",
+			err:   "missing topology name",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			topos, err := ParseYAML([]byte(tc.input))
+			if len(tc.err) != 0 {
+				require.EqualError(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, topos)
+		})
+	}
+}